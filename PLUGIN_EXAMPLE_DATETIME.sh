@@ -14,9 +14,10 @@
 if [ "$1" = "--schema" ]; then
     cat <<'EOF'
 {
+  "protocolVersion": 1,
   "name": "datetime",
   "description": "Get the current date and time in various formats",
-  "input_schema": {
+  "inputSchema": {
     "type": "object",
     "properties": {
       "format": {
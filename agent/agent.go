@@ -4,52 +4,157 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/aelse/artoo/conversation"
+	"github.com/aelse/artoo/instructions"
 	"github.com/aelse/artoo/tool"
 	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
 )
 
-// toolResult holds a tool execution result with its original index
-// to preserve ordering after concurrent execution.
-type toolResult struct {
-	index  int
-	result anthropic.ContentBlockParamUnion
-}
-
 // Agent manages the conversation with Claude and tool execution.
 type Agent struct {
 	client          anthropic.Client
 	conversation    *conversation.Conversation
-	tools           []tool.Tool
-	toolMap         map[string]tool.Tool
+	registry        *tool.Registry // tools the model may call
 	toolUnionParams []anthropic.ToolUnionParam
+	deferred        map[string]tool.Tool // tools whose schemas are withheld until enabled
+	mu              sync.Mutex           // guards toolUnionParams and deferred
+	cache           *tool.ResultCache    // shared cache for idempotent tool results (nil disables)
+	instructions    *instructions.Set    // AGENTS.md/CLAUDE.md discovery (nil disables)
+	summary         string               // rolling one-line task summary, guarded by mu
+	recorder        Recorder             // usage statistics sink (nil disables)
+	dryRun          bool                 // skip tools that may write (see Plan), guarded by mu
+	autonomy        Autonomy             // what may run without approval, guarded by mu
+	approveMu       sync.Mutex           // serializes approval prompts
+	store           conversation.Store   // conversation persistence (nil disables)
+	usage           Usage                // token breakdown of the last turn, guarded by mu
+	turns           int                  // number of turns started, guarded by mu
+	systemPrompt    string               // config.SystemPrompt rendered for this session
+	allowed         map[string]bool      // tools that may be offered (nil allows all), guarded by mu
+	fallback        *Fallback            // model to switch to when the API cannot be reached, guarded by mu
+	offline         bool                 // switched to the fallback, guarded by mu
+	events          *Bus                 // what the agent does, for Callbacks and other subscribers
+	toolEnv         *tool.Environment    // settings the agent's tools share
 	config          Config
 }
 
 // New creates a new Agent with the given client and config.
-// Additional tools can be provided via the extraTools parameter.
-func New(client anthropic.Client, config Config, extraTools ...tool.Tool) *Agent {
-	allTools := make([]tool.Tool, 0, len(tool.AllTools)+len(extraTools))
-	allTools = append(allTools, tool.AllTools...)
-	allTools = append(allTools, extraTools...)
-
-	return &Agent{
-		client:          client,
-		conversation:    conversation.New(),
-		tools:           allTools,
-		toolMap:         makeToolMap(allTools),
-		toolUnionParams: makeToolUnionParams(allTools),
-		config:          config,
+// Additional tools can be provided via the extraTools parameter; one named
+// like a built-in tool or another extra tool is reported with
+// tool.ErrToolExists.
+// When config.DeferTools is set, the extra tools are only summarized to the
+// model until it loads them with the enable_tools meta-tool.
+// The tools share the settings of config.ToolEnvironment, or of an
+// Environment of the agent's own if it is nil.
+func New(client anthropic.Client, config Config, extraTools ...tool.Tool) (*Agent, error) {
+	env := config.ToolEnvironment
+	if env == nil {
+		env = tool.NewEnvironment()
+	}
+
+	registry := tool.Builtins()
+	tool.BindEnvironment(env, registry.Tools()...)
+	tool.BindEnvironment(env, extraTools...)
+
+	deferred := make(map[string]tool.Tool)
+	if config.DeferTools {
+		for _, t := range extraTools {
+			name := t.Param().Name
+			if _, taken := registry.Lookup(name); taken || deferred[name] != nil {
+				return nil, fmt.Errorf("%w: %s", tool.ErrToolExists, name)
+			}
+
+			deferred[name] = t
+		}
+	} else if err := registry.Register(extraTools...); err != nil {
+		return nil, err
+	}
+
+	a := &Agent{
+		client:       client,
+		conversation: conversation.New(),
+		registry:     registry,
+		deferred:     deferred,
+		autonomy:     config.Autonomy,
+		events:       NewBus(),
+		toolEnv:      env,
+		config:       config,
 	}
+
+	if a.autonomy == "" {
+		a.autonomy = AutonomyFullAuto
+	}
+
+	a.refreshSystemPrompt()
+	a.allowed = allowedSet(config.Tools)
+
+	if config.ToolCacheTTL > 0 {
+		a.cache = tool.NewResultCache(config.ToolCacheTTL)
+	}
+
+	if len(deferred) > 0 {
+		if err := a.registry.Register(&enableToolsTool{agent: a}); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.registry.Register(&notesTool{agent: a}); err != nil {
+		return nil, err
+	}
+
+	a.rebuildToolParams()
+
+	return a, nil
+}
+
+// ToolEnvironment returns the settings the agent's tools share, such as the
+// default search root and whether secret files may be read.
+func (a *Agent) ToolEnvironment() *tool.Environment {
+	return a.toolEnv
 }
 
 // SetConversationConfig updates the conversation's configuration.
 // This allows the agent to use custom context management settings.
 func (a *Agent) SetConversationConfig(cfg conversation.Config) {
 	a.conversation = conversation.NewWithConfig(cfg)
+	a.resetInstructions()
+}
+
+// SetInstructions enables per-directory instruction files. Workspace-wide
+// files are sent with the system prompt; nested files are added to context
+// the first time a tool touches a path in their directory.
+func (a *Agent) SetInstructions(set *instructions.Set) {
+	a.instructions = set
+}
+
+// ResultCache returns the agent's tool result cache, or nil if caching is disabled.
+func (a *Agent) ResultCache() *tool.ResultCache {
+	return a.cache
+}
+
+// SetResultCache replaces the tool result cache, allowing several agents in
+// one session to share cached read-only results. A nil cache disables caching.
+func (a *Agent) SetResultCache(cache *tool.ResultCache) {
+	a.cache = cache
+}
+
+// SetRecorder sets the recorder notified of token usage and tool calls.
+func (a *Agent) SetRecorder(r Recorder) {
+	a.recorder = r
+}
+
+// recordUsage reports the token usage of an API response to the recorder, if any.
+func (a *Agent) recordUsage(message *anthropic.Message) {
+	if a.recorder != nil {
+		a.recorder.RecordUsage(message.Usage.InputTokens, message.Usage.OutputTokens)
+	}
 }
 
 // SendMessage sends a user message and handles the agentic loop (API calls + tool use).
@@ -58,57 +163,160 @@ func (a *Agent) SetConversationConfig(cfg conversation.Config) {
 //
 // The loop continues until the assistant stops requesting tools.
 func (a *Agent) SendMessage(ctx context.Context, text string, cb Callbacks) (*Response, error) {
+	return a.SendBlocks(ctx, cb, anthropic.NewTextBlock(text))
+}
+
+// SendBlocks is like SendMessage but sends a user message made of arbitrary
+// content blocks, such as pasted images or attached file contents.
+func (a *Agent) SendBlocks(
+	ctx context.Context,
+	cb Callbacks,
+	blocks ...anthropic.ContentBlockParamUnion,
+) (*Response, error) {
+	cb, detach := a.attach(cb)
+	defer detach()
+
 	// Append user message to conversation
-	a.conversation.Append(anthropic.NewUserMessage(
-		anthropic.NewTextBlock(text),
-	))
+	prompt := anthropic.NewUserMessage(blocks...)
+	a.conversation.Append(prompt)
+	defer a.save(ctx, prompt, cb)
 
 	var finalText string
 	var finalStopReason string
+	var finalStopSequence string
+	var usage Usage
+	var outputTokens int64
+
+	// The completion gate: set once a tool may have changed something
+	var modified bool
+	var verifyFailed string
+	verifyAttempts := 0
+
+	// Why the turn ended without a complete answer, and how often it was paused
+	var unfinished string
+	pauses := 0
+
+	// The prefill and tool choice only apply to the first response of the turn
+	prefill := strings.TrimRight(a.config.Prefill, " \t\r\n")
+	first := true
+
+	// Tools that act on their input as it streams, closed once the calls ran
+	streams := inputStreams{}
+	defer streams.close()
 
 	// Tool-use loop: call API, execute any tools, repeat until no more tools
 	for {
 		// Trim conversation if approaching context window limit before making API call
-		a.conversation.Trim()
-
-		cb.OnThinking()
-		var message *anthropic.Message
-		var err error
-		if a.config.Streaming {
-			cb.OnThinkingDone() // Stop spinner before streaming starts
-			message, err = a.callStreaming(ctx, cb)
-		} else {
-			message, err = a.client.Messages.New(ctx, anthropic.MessageNewParams{
-				Model:     anthropic.Model(a.config.Model),
-				MaxTokens: a.config.MaxTokens,
-				Messages:  a.conversation.Messages(),
-				Tools:     a.toolUnionParams,
-			})
-			cb.OnThinkingDone()
+		messages := a.conversation.MessageCount()
+		a.conversation.TrimWith(a.resultSummarizer(ctx))
+
+		// Nested instruction files trimmed away are added again when next touched
+		if a.conversation.MessageCount() < messages {
+			a.resetInstructions()
+		}
+
+		params := a.messageParams()
+
+		if first {
+			choice, err := toolChoiceParam(a.config.ToolChoice, params.Tools)
+			if err != nil {
+				return nil, err
+			}
+
+			params.ToolChoice = choice
+		}
+
+		turnID := a.nextTurnID()
+		cb.OnTurnStart(turnID)
+
+		if prefill != "" {
+			params.Messages = append(slices.Clone(params.Messages),
+				anthropic.NewAssistantMessage(anthropic.NewTextBlock(prefill)))
+		}
+
+		cites := &citations{}
+
+		call := func() (*anthropic.Message, error) {
+			cb.OnThinking()
+			if a.config.Streaming {
+				cb.OnThinkingDone() // Stop spinner before streaming starts
+				if prefill != "" {
+					cb.OnTextDelta(prefill)
+				}
+				return a.callStreaming(ctx, params, cites, streams, cb)
+			}
+			defer cb.OnThinkingDone()
+			return a.client.Messages.New(ctx, params, a.requestOptions()...)
+		}
+
+		message, err := call()
+		if err != nil && a.switchToFallback(err) {
+			// The API cannot be reached: retry with the fallback model
+			params.Model = anthropic.Model(a.config.Model)
+			params.Tools = a.offlineTools(params.Tools)
+			message, err = call()
 		}
 		if err != nil {
+			cb.OnTurnEnd(turnID, Usage{}, "")
 			return nil, err
 		}
 
+		a.recordUsage(message)
+		usage = measureUsage(params, message.Usage)
+		outputTokens += message.Usage.OutputTokens
+
+		turnUsage := usage
+		turnUsage.OutputTokens = message.Usage.OutputTokens
+
 		// Update token count from API response
 		if message.Usage.InputTokens > 0 {
 			a.conversation.UpdateTokenCount(int(message.Usage.InputTokens))
 		}
 
-		// Append the assistant's response to conversation
-		a.conversation.Append(message.ToParam())
+		// Append the assistant's response to conversation, restoring the prefilled text
+		a.conversation.Append(withPrefill(message.ToParam(), prefill))
 		finalStopReason = string(message.StopReason)
+		finalStopSequence = message.StopSequence
 
 		var toolUseBlocks []anthropic.ToolUseBlock
 		var toolResults []anthropic.ContentBlockParamUnion
 		hasToolUse := false
 
+		// Consecutive text blocks, as a cited answer arrives in, are reported
+		// as one text with citation markers
+		var text strings.Builder
+		flushText := func() {
+			if text.Len() > 0 {
+				finalText = text.String()
+				cb.OnText(finalText)
+				text.Reset()
+			}
+		}
+
 		// Collect text blocks and tool use blocks separately
-		for _, block := range message.Content {
+		for i, block := range message.Content {
+			if _, ok := block.AsAny().(anthropic.TextBlock); !ok {
+				flushText()
+			}
+
 			switch b := block.AsAny().(type) {
 			case anthropic.TextBlock:
-				finalText = b.Text
-				cb.OnText(b.Text)
+				if i == 0 {
+					text.WriteString(prefill)
+				}
+				text.WriteString(b.Text + cites.markers(b.Citations))
+
+			case anthropic.ServerToolUseBlock:
+				// Executed by the API; shown like a local tool call
+				inputJSON, err := json.Marshal(b.Input)
+				if err != nil {
+					inputJSON = []byte("{}")
+				}
+				cb.OnToolCall(b.ID, string(b.Name), string(inputJSON))
+
+			case anthropic.WebSearchToolResultBlock:
+				summary, isError := webSearchSummary(b)
+				cb.OnToolResult(b.ToolUseID, "web_search", summary, isError)
 
 			case anthropic.ToolUseBlock:
 				hasToolUse = true
@@ -119,60 +327,247 @@ func (a *Agent) SendMessage(ctx context.Context, text string, cb Callbacks) (*Re
 				if err != nil {
 					inputJSON = []byte("{}")
 				}
-				cb.OnToolCall(b.Name, string(inputJSON))
+				cb.OnToolCall(b.ID, b.Name, string(inputJSON))
+			}
+		}
+
+		// The cited sources follow the response's last text
+		if footer := cites.footer(); footer != "" {
+			if a.config.Streaming {
+				cb.OnTextDelta(footer)
 			}
+			text.WriteString(footer)
 		}
+		flushText()
 
 		// Execute tool blocks concurrently if any exist
 		if len(toolUseBlocks) > 0 {
 			toolResults = a.executeToolsConcurrently(ctx, toolUseBlocks, cb)
+			modified = modified || a.modifies(toolUseBlocks)
 		}
 
+		streams.close()
+
 		// If there were tool calls, add results to conversation and loop again
 		if len(toolResults) > 0 {
-			// Append tool results, with truncation applied if needed
-			a.conversation.Append(anthropic.NewUserMessage(toolResults...))
+			// Instruction files in directories the tools just touched follow the results
+			if text := a.touchedInstructions(toolUseBlocks); text != "" {
+				toolResults = append(toolResults, anthropic.NewTextBlock(text))
+			}
+
+			// Append tool results, truncated to budgets from the remaining context
+			a.conversation.AppendToolResults(toolResults...)
+
+			// Save progress so long turns can be followed from another process
+			a.save(ctx, prompt, cb)
+		}
+
+		prefill = ""
+		first = false
+
+		cb.OnTurnEnd(turnID, turnUsage, finalStopReason)
+
+		if hasToolUse {
+			continue
+		}
+
+		// A long server tool turn the API paused resumes when the conversation
+		// is sent back, up to a limit
+		if message.StopReason == anthropic.StopReasonPauseTurn {
+			if pauses < maxPauseResumes {
+				pauses++
+
+				continue
+			}
+
+			unfinished = pausedNotice
+
+			break
 		}
 
-		// If no tool use, we're done
-		if !hasToolUse {
+		// A refusal is no answer to verify
+		if message.StopReason == anthropic.StopReasonRefusal {
+			unfinished = refusalNotice
+
 			break
 		}
+
+		// Work that changed something must pass verification; failures go
+		// back to the model until the retries run out
+		if !modified || a.config.VerifyCommand == "" || ctx.Err() != nil {
+			break
+		}
+
+		failure := a.verify(ctx, turnID, cb)
+		if failure == "" {
+			verifyFailed = ""
+
+			break
+		}
+
+		verifyFailed = failure
+		if verifyAttempts >= a.config.VerifyRetries {
+			break
+		}
+
+		verifyAttempts++
+		a.conversation.Append(conversation.NewFeedbackMessage(failure))
 	}
 
+	usage.OutputTokens = outputTokens
+
+	a.mu.Lock()
+	a.usage = usage
+	a.mu.Unlock()
+
 	return &Response{
-		Text:       finalText,
-		StopReason: finalStopReason,
+		Text:         finalText,
+		StopReason:   finalStopReason,
+		StopSequence: finalStopSequence,
+		Usage:        usage,
+		VerifyFailed: verifyFailed,
+		Unfinished:   unfinished,
 	}, nil
 }
 
-// callStreaming calls the Claude API with streaming enabled and emits text deltas via callback.
-func (a *Agent) callStreaming(ctx context.Context, cb Callbacks) (*anthropic.Message, error) {
-	stream := a.client.Messages.NewStreaming(ctx, anthropic.MessageNewParams{
-		Model:     anthropic.Model(a.config.Model),
-		MaxTokens: a.config.MaxTokens,
-		Messages:  a.conversation.Messages(),
-		Tools:     a.toolUnionParams,
-	})
+// nextTurnID returns a new turn ID, unique within the conversation's lifetime
+// in this process, e.g. "20260228-143052-a1b2/3".
+func (a *Agent) nextTurnID() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.turns++
+
+	return fmt.Sprintf("%s/%d", a.conversation.ID(), a.turns)
+}
+
+// withPrefill restores prefilled text at the start of an assistant message,
+// since the API response only contains the continuation.
+func withPrefill(message anthropic.MessageParam, prefill string) anthropic.MessageParam {
+	if prefill == "" {
+		return message
+	}
+
+	if len(message.Content) > 0 && message.Content[0].OfText != nil {
+		message.Content[0].OfText.Text = prefill + message.Content[0].OfText.Text
+
+		return message
+	}
+
+	message.Content = append([]anthropic.ContentBlockParamUnion{anthropic.NewTextBlock(prefill)}, message.Content...)
+
+	return message
+}
+
+// messageParams builds the request for the next API call from the agent's
+// config, system prompt, conversation and currently enabled tools. Message
+// shapes the API would reject, such as empty text or tool calls whose
+// results were lost, are fixed first.
+func (a *Agent) messageParams() anthropic.MessageNewParams {
+	a.conversation.Sanitize()
+	a.conversation.Repair()
+
+	return a.requestParams(a.conversation.Snapshot())
+}
+
+// requestParams returns the parameters of a request for messages, with the
+// agent's model, token limit, system prompt, tools and stop sequences.
+func (a *Agent) requestParams(messages []anthropic.MessageParam) anthropic.MessageNewParams {
+	return anthropic.MessageNewParams{
+		Model:         anthropic.Model(a.config.Model),
+		MaxTokens:     a.maxTokens(),
+		System:        a.systemBlocks(),
+		Messages:      messages,
+		Tools:         a.offlineTools(a.toolParams()),
+		StopSequences: a.config.StopSequences,
+	}
+}
+
+// systemBlocks returns the system prompt followed by workspace-wide
+// instruction files, the scratch directory and the suggest-mode notice, or
+// nil when none apply.
+func (a *Agent) systemBlocks() []anthropic.TextBlockParam {
+	var blocks []anthropic.TextBlockParam
+
+	if a.systemPrompt != "" {
+		blocks = append(blocks, anthropic.TextBlockParam{Text: a.systemPrompt})
+	}
+
+	if a.instructions != nil {
+		if text := instructions.Render(a.instructions.Base()); text != "" {
+			blocks = append(blocks, anthropic.TextBlockParam{Text: text})
+		}
+	}
+
+	if dir := a.toolEnv.ScratchDir(); dir != "" {
+		blocks = append(blocks, anthropic.TextBlockParam{Text: fmt.Sprintf(scratchNotice, dir, tool.ScratchDirEnv)})
+	}
+
+	if a.Autonomy() == AutonomySuggest {
+		blocks = append(blocks, anthropic.TextBlockParam{Text: suggestNotice})
+	}
+
+	return blocks
+}
+
+// callStreaming calls the Claude API with streaming enabled and emits text deltas via callback,
+// each cited block followed by its citation markers from cites.
+// The full message, including tool use blocks, is accumulated from the stream events.
+func (a *Agent) callStreaming(
+	ctx context.Context,
+	params anthropic.MessageNewParams,
+	cites *citations,
+	streams inputStreams,
+	cb Callbacks,
+) (*anthropic.Message, error) {
+	opts := a.requestOptions()
+	if a.config.FineGrained {
+		opts = append(opts, option.WithHeaderAdd("anthropic-beta", fineGrainedStreamingBeta))
+	}
+
+	stream := a.client.Messages.NewStreaming(ctx, params, opts...)
 
 	var message anthropic.Message
 
+	// Citation markers are held until the cited text block ends
+	var markers strings.Builder
+
+	// Tool input so far, previewed as it streams if cb watches for it and
+	// written to the streams of tools that act on it
+	watcher, _ := cb.(ToolInputWatcher)
+	var toolID, toolName string
+	var toolInput strings.Builder
+
 	for stream.Next() {
 		event := stream.Current()
+		if err := message.Accumulate(event); err != nil {
+			return nil, err
+		}
 
 		switch e := event.AsAny().(type) {
-		case anthropic.MessageStartEvent:
-			message = e.Message
+		case anthropic.ContentBlockStartEvent:
+			toolID, toolName = e.ContentBlock.ID, e.ContentBlock.Name
+			toolInput.Reset()
+			if e.ContentBlock.Type == "tool_use" {
+				a.openInputStream(streams, toolID, toolName)
+			}
 		case anthropic.ContentBlockDeltaEvent:
-			if d, ok := e.Delta.AsAny().(anthropic.TextDelta); ok {
+			switch d := e.Delta.AsAny().(type) {
+			case anthropic.TextDelta:
 				cb.OnTextDelta(d.Text)
+			case anthropic.InputJSONDelta:
+				toolInput.WriteString(d.PartialJSON)
+				streams.write(toolID, d.PartialJSON)
+				if watcher != nil && d.PartialJSON != "" {
+					watcher.OnToolInputDelta(toolName, toolInputPreview(toolInput.String()))
+				}
+			case anthropic.CitationsDelta:
+				markers.WriteString(cites.marker(d.Citation.URL, d.Citation.Title))
 			}
-		case anthropic.MessageDeltaEvent:
-			if e.Delta.StopReason != "" {
-				message.StopReason = e.Delta.StopReason
-			}
-			if e.Usage.OutputTokens > 0 {
-				message.Usage.OutputTokens = e.Usage.OutputTokens
+		case anthropic.ContentBlockStopEvent:
+			if markers.Len() > 0 {
+				cb.OnTextDelta(markers.String())
+				markers.Reset()
 			}
 		}
 	}
@@ -184,8 +579,10 @@ func (a *Agent) callStreaming(ctx context.Context, cb Callbacks) (*anthropic.Mes
 	return &message, nil
 }
 
-// executeToolsConcurrently executes tool blocks concurrently,
-// returning results in the original order.
+// executeToolsConcurrently executes tool blocks concurrently, up to
+// MaxConcurrentTools at a time, returning results in the original order.
+// Each call first waits for the earlier calls it conflicts with; see
+// toolDependencies.
 func (a *Agent) executeToolsConcurrently(
 	_ context.Context,
 	blocks []anthropic.ToolUseBlock,
@@ -197,54 +594,119 @@ func (a *Agent) executeToolsConcurrently(
 		maxConcurrent = 1
 	}
 
+	deps := a.toolDependencies(blocks)
+
 	// Channel to limit concurrent goroutines
 	semaphore := make(chan struct{}, maxConcurrent)
-	resultsChan := make(chan toolResult, len(blocks))
+	done := make([]chan struct{}, len(blocks))
+	results := make([]*anthropic.ContentBlockParamUnion, len(blocks))
+
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+
+	// The first call to fail hard, after which queued calls are skipped
+	var failed atomic.Pointer[anthropic.ToolUseBlock]
+
 	var wg sync.WaitGroup
 
 	// Launch goroutines for each tool block
 	for i, block := range blocks {
 		wg.Go(func() {
-			// Acquire semaphore slot
+			defer close(done[i])
+
+			// Dependencies are earlier calls, so waiting cannot deadlock;
+			// the slot is taken only once the call can run
+			for _, j := range deps[i] {
+				<-done[j]
+			}
+
 			semaphore <- struct{}{}
 			defer func() { <-semaphore }()
 
-			result := a.executeToolUse(block, cb)
-			if result != nil {
-				resultsChan <- toolResult{
-					index:  i,
-					result: *result,
-				}
+			if first := failed.Load(); first != nil {
+				results[i] = a.skipToolUse(block, *first, cb)
+
+				return
+			}
+
+			results[i] = a.executeToolUse(block, cb)
+
+			if a.failsHard(block, results[i]) {
+				failed.CompareAndSwap(nil, &block)
 			}
 		})
 	}
 
-	// Wait for all goroutines to complete
-	go func() {
-		wg.Wait()
-		close(resultsChan)
-	}()
+	wg.Wait()
 
-	// Collect results
-	resultMap := make(map[int]anthropic.ContentBlockParamUnion)
-	for tr := range resultsChan {
-		resultMap[tr.index] = tr.result
+	// Build ordered result slice
+	ordered := make([]anthropic.ContentBlockParamUnion, 0, len(results))
+	for _, result := range results {
+		if result != nil {
+			ordered = append(ordered, *result)
+		}
 	}
 
-	// Sort results by original index
-	indices := make([]int, 0, len(resultMap))
-	for idx := range resultMap {
-		indices = append(indices, idx)
+	return ordered
+}
+
+// toolDependencies returns, for each call, the earlier calls it must wait
+// for: an exclusive call waits for every earlier call and every later call
+// waits for it, and per-path calls wait for earlier per-path calls touching
+// a common path or one in a directory the other touches. Other calls run as
+// soon as a slot is free.
+func (a *Agent) toolDependencies(blocks []anthropic.ToolUseBlock) [][]int {
+	classes := make([]tool.ConcurrencyClass, len(blocks))
+	paths := make([][]string, len(blocks))
+
+	for i, block := range blocks {
+		if t, ok := a.registry.Lookup(block.Name); ok {
+			classes[i], paths[i] = tool.ConcurrencyOf(t, block.Input)
+		}
 	}
-	slices.Sort(indices)
 
-	// Build ordered result slice
-	results := make([]anthropic.ContentBlockParamUnion, 0, len(resultMap))
-	for _, idx := range indices {
-		results = append(results, resultMap[idx])
+	deps := make([][]int, len(blocks))
+
+	for i := range blocks {
+		for j := range i {
+			exclusive := classes[i] == tool.ConcurrencyExclusive || classes[j] == tool.ConcurrencyExclusive
+			samePath := classes[i] == tool.ConcurrencyPerPath && classes[j] == tool.ConcurrencyPerPath &&
+				sharePath(paths[i], paths[j])
+
+			if exclusive || samePath {
+				deps[i] = append(deps[i], j)
+			}
+		}
 	}
 
-	return results
+	return deps
+}
+
+// sharePath reports whether a path in a is one in b or lies in or contains
+// one, as a directory does its files.
+func sharePath(a, b []string) bool {
+	within := func(path, dir string) bool {
+		dir = strings.TrimSuffix(dir, string(filepath.Separator))
+
+		return path == dir || strings.HasPrefix(path, dir+string(filepath.Separator))
+	}
+
+	for _, p := range a {
+		if slices.ContainsFunc(b, func(q string) bool { return within(p, q) || within(q, p) }) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// toolParams returns the tools array for the next API request.
+func (a *Agent) toolParams() []anthropic.ToolUnionParam {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.toolUnionParams
 }
 
 func makeToolUnionParams(tools []tool.Tool) []anthropic.ToolUnionParam {
@@ -257,38 +719,99 @@ func makeToolUnionParams(tools []tool.Tool) []anthropic.ToolUnionParam {
 	return tup
 }
 
-func makeToolMap(tools []tool.Tool) map[string]tool.Tool {
-	toolMap := make(map[string]tool.Tool)
-	for i := range tools {
-		t := tools[i]
-		toolMap[t.Param().Name] = tools[i]
-	}
-
-	return toolMap
-}
-
 // executeToolUse calls a tool and notifies the callback of the result.
 func (a *Agent) executeToolUse(block anthropic.ToolUseBlock, cb Callbacks) *anthropic.ContentBlockParamUnion {
 	var result *anthropic.ContentBlockParamUnion
 
-	t, exists := a.toolMap[block.Name]
-	if !exists {
+	t, exists := a.registry.Lookup(block.Name)
+
+	a.mu.Lock()
+	_, deferred := a.deferred[block.Name]
+	allowed := a.toolAllowed(block.Name)
+	a.mu.Unlock()
+
+	// Input that does not match the schema is reported field by field
+	var invalid []tool.InputError
+	if exists && allowed {
+		invalid = tool.ValidateInput(t.Param().InputSchema, block.Input)
+	}
+
+	switch {
+	case !allowed:
+		errMsg := fmt.Sprintf("Tool %s is not available in the current profile", block.Name)
+		result = new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+	case !exists && deferred:
+		// Deferred tool called before its schema was loaded
+		errMsg := fmt.Sprintf("Tool %s is not enabled; call %s first", block.Name, enableToolsName)
+		result = new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+	case !exists:
 		// Tool not found — return error result
 		result = new(anthropic.NewToolResultBlock(block.ID, "Tool not found", true))
-	} else {
-		result = t.Call(block)
+	case len(invalid) > 0:
+		result = new(anthropic.NewToolResultBlock(block.ID, tool.FormatInputErrors(block.Name, invalid), true))
+	case a.skipsExecution(t):
+		result = new(anthropic.NewToolResultBlock(block.ID, dryRunResult, false))
+	case a.needsApproval(t) && !a.approve(block, cb):
+		result = new(anthropic.NewToolResultBlock(block.ID, declinedResult, true))
+	default:
+		result = a.callTool(t, block)
 	}
 
+	// Every call gets one result, which the API matches to it by ID
+	if result == nil || result.OfToolResult == nil {
+		result = new(anthropic.NewToolResultBlock(block.ID, "Tool returned no result", true))
+	}
+
+	result.OfToolResult.ToolUseID = block.ID
+
 	// Extract output and error status from the result for callback
-	if result != nil && result.OfToolResult != nil {
-		isError := result.OfToolResult.IsError.Value
-		output := ""
-		if len(result.OfToolResult.Content) > 0 {
-			if result.OfToolResult.Content[0].OfText != nil {
-				output = result.OfToolResult.Content[0].OfText.Text
-			}
+	isError := result.OfToolResult.IsError.Value
+	output := ""
+	if len(result.OfToolResult.Content) > 0 {
+		if result.OfToolResult.Content[0].OfText != nil {
+			output = result.OfToolResult.Content[0].OfText.Text
 		}
-		cb.OnToolResult(block.Name, output, isError)
+	}
+	if !isError {
+		warnInjections(block.Name, output, cb)
+	}
+
+	cb.OnToolResult(block.ID, block.Name, output, isError)
+
+	if a.recorder != nil {
+		a.recorder.RecordTool(block.Name, isError)
+	}
+
+	return result
+}
+
+// callTool invokes t, serving idempotent tools from the result cache and
+// invalidating the cache around calls to tools that may write.
+func (a *Agent) callTool(t tool.Tool, block anthropic.ToolUseBlock) *anthropic.ContentBlockParamUnion {
+	if a.cache == nil {
+		return t.Call(block)
+	}
+
+	if !tool.IsReadOnly(t) {
+		a.cache.Invalidate()
+		defer a.cache.Invalidate()
+
+		return t.Call(block)
+	}
+
+	if !tool.IsIdempotent(t) {
+		return t.Call(block)
+	}
+
+	output, generation, ok := a.cache.Get(a.toolEnv, block.Name, block.Input)
+	if ok {
+		return new(anthropic.NewToolResultBlock(block.ID, output, false))
+	}
+
+	result := t.Call(block)
+	if result != nil && result.OfToolResult != nil && !result.OfToolResult.IsError.Value &&
+		len(result.OfToolResult.Content) > 0 && result.OfToolResult.Content[0].OfText != nil {
+		a.cache.Put(a.toolEnv, block.Name, block.Input, result.OfToolResult.Content[0].OfText.Text, generation)
 	}
 
 	return result
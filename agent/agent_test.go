@@ -2,11 +2,16 @@ package agent
 
 import (
 	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/aelse/artoo/conversation"
 	"github.com/aelse/artoo/tool"
 	"github.com/anthropics/anthropic-sdk-go"
 )
@@ -50,9 +55,20 @@ func (m *mockTool) Call(block anthropic.ToolUseBlock) *anthropic.ContentBlockPar
 	))
 }
 
+// registryOf returns a registry holding tools, whose names must be distinct.
+func registryOf(tools ...tool.Tool) *tool.Registry {
+	r := tool.NewRegistry()
+	if err := r.Register(tools...); err != nil {
+		panic(err)
+	}
+
+	return r
+}
+
 // mockCallbacks implements Callbacks for testing.
 type mockCallbacks struct {
 	toolResultsCalls []struct {
+		id      string
 		name    string
 		output  string
 		isError bool
@@ -64,15 +80,18 @@ func (m *mockCallbacks) OnThinking() {}
 func (m *mockCallbacks) OnThinkingDone() {}
 func (m *mockCallbacks) OnText(_ string) {}
 func (m *mockCallbacks) OnTextDelta(_ string) {}
-func (m *mockCallbacks) OnToolCall(_ string, _ string) {}
-func (m *mockCallbacks) OnToolResult(name string, output string, isError bool) {
+func (m *mockCallbacks) OnToolCall(_ string, _ string, _ string) {}
+func (m *mockCallbacks) OnTurnStart(_ string) {}
+func (m *mockCallbacks) OnTurnEnd(_ string, _ Usage, _ string) {}
+func (m *mockCallbacks) OnToolResult(id string, name string, output string, isError bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.toolResultsCalls = append(m.toolResultsCalls, struct {
+		id      string
 		name    string
 		output  string
 		isError bool
-	}{name, output, isError})
+	}{id, name, output, isError})
 }
 
 func TestExecuteToolsConcurrently_Single(t *testing.T) {
@@ -80,9 +99,7 @@ func TestExecuteToolsConcurrently_Single(t *testing.T) {
 
 	ag := &Agent{
 		config: Config{MaxConcurrentTools: 4},
-		toolMap: map[string]tool.Tool{
-			"tool1": &mockTool{name: "tool1"},
-		},
+		registry: registryOf(&mockTool{name: "tool1"}),
 	}
 
 	block := anthropic.ToolUseBlock{
@@ -104,11 +121,7 @@ func TestExecuteToolsConcurrently_Multiple(t *testing.T) {
 
 	ag := &Agent{
 		config: Config{MaxConcurrentTools: 4},
-		toolMap: map[string]tool.Tool{
-			"tool1": &mockTool{name: "tool1"},
-			"tool2": &mockTool{name: "tool2"},
-			"tool3": &mockTool{name: "tool3"},
-		},
+		registry: registryOf(&mockTool{name: "tool1"}, &mockTool{name: "tool2"}, &mockTool{name: "tool3"}),
 	}
 
 	blocks := []anthropic.ToolUseBlock{
@@ -135,11 +148,11 @@ func TestExecuteToolsConcurrently_OrderPreserved(t *testing.T) {
 	// Use tools with different sleep durations to verify result ordering
 	ag := &Agent{
 		config: Config{MaxConcurrentTools: 4},
-		toolMap: map[string]tool.Tool{
-			"fast":   &mockTool{name: "fast", sleep: 10 * time.Millisecond},
-			"medium": &mockTool{name: "medium", sleep: 50 * time.Millisecond},
-			"slow":   &mockTool{name: "slow", sleep: 100 * time.Millisecond},
-		},
+		registry: registryOf(
+			&mockTool{name: "fast", sleep: 10 * time.Millisecond},
+			&mockTool{name: "medium", sleep: 50 * time.Millisecond},
+			&mockTool{name: "slow", sleep: 100 * time.Millisecond},
+		),
 	}
 
 	// Reverse order: slow, medium, fast
@@ -171,16 +184,85 @@ func TestExecuteToolsConcurrently_OrderPreserved(t *testing.T) {
 	}
 }
 
+// echoTool returns its input's "input" after sleeping for its "ms". Input
+// "wrong id" is answered under another ID and "none" with no result.
+type echoTool struct{}
+
+func (echoTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{Name: "echo", InputSchema: anthropic.ToolInputSchemaParam{}}
+}
+
+func (echoTool) Call(block anthropic.ToolUseBlock) *anthropic.ContentBlockParamUnion {
+	var input struct {
+		Input string `json:"input"`
+		MS    int    `json:"ms"`
+	}
+
+	_ = json.Unmarshal(block.Input, &input)
+	time.Sleep(time.Duration(input.MS) * time.Millisecond)
+
+	switch input.Input {
+	case "wrong id":
+		return new(anthropic.NewToolResultBlock("other", input.Input, false))
+	case "none":
+		return nil
+	}
+
+	return new(anthropic.NewToolResultBlock(block.ID, input.Input, false))
+}
+
+func TestExecuteToolsConcurrently_ResultsMatchByID(t *testing.T) {
+	t.Parallel()
+
+	ag := &Agent{
+		config:   Config{MaxConcurrentTools: 5},
+		registry: registryOf(echoTool{}),
+	}
+
+	// Five calls of one tool, finishing in reverse order
+	var blocks []anthropic.ToolUseBlock
+	for i, input := range []string{"a", "b", "wrong id", "none", "e"} {
+		blocks = append(blocks, anthropic.ToolUseBlock{
+			ID:    fmt.Sprintf("id%d", i),
+			Name:  "echo",
+			Input: json.RawMessage(fmt.Sprintf(`{"input": %q, "ms": %d}`, input, 50-10*i)),
+		})
+	}
+
+	cb := &mockCallbacks{}
+	results := ag.executeToolsConcurrently(t.Context(), blocks, cb)
+
+	if len(results) != len(blocks) {
+		t.Fatalf("got %d results, want one per call", len(results))
+	}
+
+	for i, result := range results {
+		if id := result.OfToolResult.ToolUseID; id != blocks[i].ID {
+			t.Errorf("result %d has ID %q, want %q", i, id, blocks[i].ID)
+		}
+	}
+
+	outputs := map[string]string{}
+	for _, call := range cb.toolResultsCalls {
+		outputs[call.id] = call.output
+	}
+
+	want := map[string]string{"id0": "a", "id1": "b", "id2": "wrong id", "id3": "Tool returned no result", "id4": "e"}
+	if !maps.Equal(outputs, want) {
+		t.Errorf("callback results by ID = %v, want %v", outputs, want)
+	}
+}
+
 func TestExecuteToolsConcurrently_ErrorDoesNotAffectOthers(t *testing.T) {
 	t.Parallel()
 
 	ag := &Agent{
 		config: Config{MaxConcurrentTools: 4},
-		toolMap: map[string]tool.Tool{
-			"tool1": &mockTool{name: "tool1"},
-			// tool2 not in map, will result in error
-			"tool3": &mockTool{name: "tool3"},
-		},
+		registry: registryOf(
+			&mockTool{name: "tool1"},
+			// tool2 not registered, will result in error
+			&mockTool{name: "tool3"},
+		),
 	}
 
 	blocks := []anthropic.ToolUseBlock{
@@ -220,14 +302,20 @@ func TestExecuteToolsConcurrently_ErrorDoesNotAffectOthers(t *testing.T) {
 
 // concurrentTrackingTool tracks concurrent execution.
 type concurrentTrackingTool struct {
+	name              string // "tracking_tool" if empty
 	currentConcurrent int32
 	maxConcurrent     int32
 	sleep             time.Duration
 }
 
 func (c *concurrentTrackingTool) Param() anthropic.ToolParam {
+	name := c.name
+	if name == "" {
+		name = "tracking_tool"
+	}
+
 	return anthropic.ToolParam{
-		Name:        "tracking_tool",
+		Name:        name,
 		Description: anthropic.String("Tool for tracking concurrent execution"),
 		InputSchema: anthropic.ToolInputSchemaParam{
 			Properties: map[string]any{},
@@ -266,14 +354,13 @@ func TestExecuteToolsConcurrently_SemaphoreLimit(t *testing.T) {
 	t.Parallel()
 
 	tracker := &concurrentTrackingTool{
+		name:  "tracker",
 		sleep: 50 * time.Millisecond,
 	}
 
 	ag := &Agent{
 		config: Config{MaxConcurrentTools: 1},
-		toolMap: map[string]tool.Tool{
-			"tracker": tracker,
-		},
+		registry: registryOf(tracker),
 	}
 
 	blocks := make([]anthropic.ToolUseBlock, 5)
@@ -296,3 +383,247 @@ func TestExecuteToolsConcurrently_SemaphoreLimit(t *testing.T) {
 		t.Errorf("Expected max concurrent to be 1, got %d", atomic.LoadInt32(&tracker.maxConcurrent))
 	}
 }
+
+// readOnlyTool is a mockTool that declares itself read-only and idempotent.
+type readOnlyTool struct {
+	mockTool
+}
+
+func (r *readOnlyTool) ReadOnly() bool   { return true }
+func (r *readOnlyTool) Idempotent() bool { return true }
+
+func TestExecuteToolsConcurrently_ResultCache(t *testing.T) {
+	t.Parallel()
+
+	reader := &readOnlyTool{mockTool: mockTool{name: "reader"}}
+	writer := &mockTool{name: "writer"}
+
+	ag := &Agent{
+		config: Config{MaxConcurrentTools: 1},
+		cache:  tool.NewResultCache(time.Minute),
+		registry: registryOf(reader, writer),
+	}
+
+	read := anthropic.ToolUseBlock{ID: "id1", Name: "reader", Input: json.RawMessage(`{"input": "a"}`)}
+	cb := &mockCallbacks{}
+
+	ag.executeToolsConcurrently(t.Context(), []anthropic.ToolUseBlock{read}, cb)
+	results := ag.executeToolsConcurrently(t.Context(), []anthropic.ToolUseBlock{read}, cb)
+
+	if reader.callCount != 1 {
+		t.Errorf("expected repeated read to be served from cache, tool called %d times", reader.callCount)
+	}
+
+	if len(results) != 1 || results[0].OfToolResult.ToolUseID != "id1" {
+		t.Errorf("cached result should carry the request's tool_use ID, got %+v", results)
+	}
+
+	// A write invalidates the cache so the next read hits the tool again
+	write := anthropic.ToolUseBlock{ID: "id2", Name: "writer", Input: json.RawMessage(`{}`)}
+	ag.executeToolsConcurrently(t.Context(), []anthropic.ToolUseBlock{write}, cb)
+	ag.executeToolsConcurrently(t.Context(), []anthropic.ToolUseBlock{read}, cb)
+
+	if reader.callCount != 2 {
+		t.Errorf("expected read after write to call the tool, tool called %d times", reader.callCount)
+	}
+}
+
+func TestWithPrefill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		message anthropic.MessageParam
+		prefill string
+		want    []string
+	}{
+		{
+			name:    "no prefill",
+			message: anthropic.NewAssistantMessage(anthropic.NewTextBlock("hello")),
+			want:    []string{"hello"},
+		},
+		{
+			name:    "prepended to first text block",
+			message: anthropic.NewAssistantMessage(anthropic.NewTextBlock(`"a": 1}`)),
+			prefill: "{",
+			want:    []string{`{"a": 1}`},
+		},
+		{
+			name:    "inserted before non-text block",
+			message: anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("id", map[string]any{}, "ls")),
+			prefill: "Let me look.",
+			want:    []string{"Let me look.", ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got := withPrefill(tt.message, tt.prefill)
+			if len(got.Content) != len(tt.want) {
+				t.Fatalf("expected %d blocks, got %d", len(tt.want), len(got.Content))
+			}
+
+			for i, want := range tt.want {
+				var text string
+				if got.Content[i].OfText != nil {
+					text = got.Content[i].OfText.Text
+				}
+
+				if text != want {
+					t.Errorf("block %d: expected %q, got %q", i, want, text)
+				}
+			}
+		})
+	}
+}
+
+func TestMessageParams_StopSequences(t *testing.T) {
+	t.Parallel()
+
+	a := &Agent{
+		config:       Config{Model: "test", MaxTokens: 10, StopSequences: []string{"</answer>"}},
+		conversation: conversation.New(),
+	}
+
+	params := a.messageParams()
+	if len(params.StopSequences) != 1 || params.StopSequences[0] != "</answer>" {
+		t.Errorf("expected stop sequences to be passed through, got %v", params.StopSequences)
+	}
+}
+
+func TestAutoTitle(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"short", "Fix the grep tool", "Fix the grep tool"},
+		{"whitespace collapsed", "Fix\n  the   grep", "Fix the grep"},
+		{
+			"truncated at word boundary",
+			"Please investigate why the authentication middleware rejects valid refresh tokens",
+			"Please investigate why the authentication middleware…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := autoTitle(tt.text); got != tt.want {
+				t.Errorf("autoTitle(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExecuteToolUse_InvalidInput(t *testing.T) {
+	t.Parallel()
+
+	mock := &mockTool{name: "tool1"}
+	ag := &Agent{registry: registryOf(mock)}
+
+	block := anthropic.ToolUseBlock{ID: "id1", Name: "tool1", Input: json.RawMessage(`{"input": 42}`)}
+
+	result := ag.executeToolUse(block, &mockCallbacks{})
+	if !result.OfToolResult.IsError.Value {
+		t.Fatal("input not matching the schema should be an error")
+	}
+
+	if got, want := result.OfToolResult.Content[0].OfText.Text, "input: expected string, got number 42"; !strings.Contains(got, want) {
+		t.Errorf("error should name the field and expected type, got %q", got)
+	}
+
+	if mock.callCount != 0 {
+		t.Error("tool should not be called with invalid input")
+	}
+}
+
+// classTool is a tracking tool of a fixed concurrency class whose calls
+// touch the "path" in their input.
+type classTool struct {
+	*concurrentTrackingTool
+
+	class tool.ConcurrencyClass
+}
+
+func (c *classTool) Concurrency(input json.RawMessage) (tool.ConcurrencyClass, []string) {
+	var params struct {
+		Path string `json:"path"`
+	}
+
+	_ = json.Unmarshal(input, &params)
+
+	return c.class, []string{params.Path}
+}
+
+func TestToolDependencies(t *testing.T) {
+	t.Parallel()
+
+	tracker := &concurrentTrackingTool{name: "write"}
+	ag := &Agent{registry: registryOf(
+		&readOnlyTool{mockTool: mockTool{name: "read"}},
+		&classTool{tracker, tool.ConcurrencyPerPath},
+		&mockTool{name: "shell"}, // not read-only: exclusive by default
+	)}
+
+	call := func(name, path string) anthropic.ToolUseBlock {
+		return anthropic.ToolUseBlock{Name: name, Input: json.RawMessage(`{"path":"` + path + `"}`)}
+	}
+
+	deps := ag.toolDependencies([]anthropic.ToolUseBlock{
+		call("read", "a"),
+		call("write", "a"),
+		call("write", "b"),
+		call("write", "a"),
+		call("shell", ""),
+		call("read", "a"),
+		call("missing", ""),
+	})
+
+	want := [][]int{nil, nil, nil, {1}, {0, 1, 2, 3}, {4}, {4}}
+	for i := range want {
+		if !slices.Equal(deps[i], want[i]) {
+			t.Errorf("deps[%d] = %v, want %v", i, deps[i], want[i])
+		}
+	}
+}
+
+func TestExecuteToolsConcurrently_ConcurrencyClasses(t *testing.T) {
+	t.Parallel()
+
+	samePath := &concurrentTrackingTool{name: "same", sleep: 20 * time.Millisecond}
+	otherPaths := &concurrentTrackingTool{name: "other", sleep: 20 * time.Millisecond}
+
+	ag := &Agent{
+		config: Config{MaxConcurrentTools: 4},
+		registry: registryOf(
+			&classTool{samePath, tool.ConcurrencyPerPath},
+			&classTool{otherPaths, tool.ConcurrencyPerPath},
+		),
+	}
+
+	var blocks []anthropic.ToolUseBlock
+	for i := range 3 {
+		blocks = append(blocks,
+			anthropic.ToolUseBlock{ID: fmt.Sprintf("s%d", i), Name: "same", Input: json.RawMessage(`{"path":"x"}`)},
+			anthropic.ToolUseBlock{ID: fmt.Sprintf("o%d", i), Name: "other", Input: json.RawMessage(fmt.Sprintf(`{"path":"p%d"}`, i))},
+		)
+	}
+
+	if results := ag.executeToolsConcurrently(t.Context(), blocks, &mockCallbacks{}); len(results) != len(blocks) {
+		t.Fatalf("expected %d results, got %d", len(blocks), len(results))
+	}
+
+	if got := atomic.LoadInt32(&samePath.maxConcurrent); got != 1 {
+		t.Errorf("calls on one path should run one at a time, max concurrent %d", got)
+	}
+
+	if got := atomic.LoadInt32(&otherPaths.maxConcurrent); got < 2 {
+		t.Errorf("calls on different paths should overlap, max concurrent %d", got)
+	}
+}
@@ -0,0 +1,67 @@
+package agent
+
+import "github.com/aelse/artoo/tool"
+
+// SetAllowedTools restricts the tools offered to the model to names. Empty
+// names offers every tool again. The agent's own notes and enable_tools
+// tools are always offered.
+func (a *Agent) SetAllowedTools(names []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.allowed = allowedSet(names)
+	a.rebuildToolParams()
+}
+
+// allowedSet returns names as a set, or nil (allowing all) if empty.
+func allowedSet(names []string) map[string]bool {
+	if len(names) == 0 {
+		return nil
+	}
+
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+
+	return set
+}
+
+// toolAllowed reports whether the named tool may be offered and called.
+// The caller must hold a.mu.
+func (a *Agent) toolAllowed(name string) bool {
+	return a.allowed == nil || a.allowed[name] || name == notesName || name == enableToolsName
+}
+
+// allowedTools filters tools by the allowed set. The caller must hold a.mu.
+func (a *Agent) allowedTools(tools []tool.Tool) []tool.Tool {
+	if a.allowed == nil {
+		return tools
+	}
+
+	var filtered []tool.Tool
+
+	for _, t := range tools {
+		if a.toolAllowed(t.Param().Name) {
+			filtered = append(filtered, t)
+		}
+	}
+
+	return filtered
+}
+
+// SetModel switches the model used for subsequent requests.
+func (a *Agent) SetModel(model string) {
+	a.config.Model = model
+}
+
+// Model returns the model used for requests.
+func (a *Agent) Model() string {
+	return a.config.Model
+}
+
+// SetSystemPrompt replaces the system prompt template and renders it.
+func (a *Agent) SetSystemPrompt(text string) {
+	a.config.SystemPrompt = text
+	a.refreshSystemPrompt()
+}
@@ -0,0 +1,36 @@
+package agent
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestAllowedTools(t *testing.T) {
+	t.Parallel()
+
+	deploy := &mockTool{name: "deploy"}
+	ag := mustNew(t, anthropic.NewClient(), Config{Tools: []string{"grep"}}, deploy)
+
+	names := toolNames(ag.toolParams())
+	if !slices.Contains(names, "grep") || !slices.Contains(names, notesName) {
+		t.Errorf("grep and %s should be offered, got %v", notesName, names)
+	}
+
+	if slices.Contains(names, "deploy") || slices.Contains(names, "write_files") {
+		t.Errorf("tools outside the profile should not be offered, got %v", names)
+	}
+
+	result := ag.executeToolUse(anthropic.ToolUseBlock{ID: "id", Name: "deploy", Input: json.RawMessage(`{}`)}, &approvingCallbacks{})
+	if !result.OfToolResult.IsError.Value || deploy.callCount != 0 {
+		t.Errorf("calling a tool outside the profile should fail without running it")
+	}
+
+	ag.SetAllowedTools(nil)
+
+	if !slices.Contains(toolNames(ag.toolParams()), "deploy") {
+		t.Error("clearing the allowed tools should offer every tool again")
+	}
+}
@@ -0,0 +1,101 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Autonomy controls how much the agent may do without the user's approval.
+type Autonomy string
+
+const (
+	// AutonomySuggest runs read-only tools only; other calls are reported
+	// as a plan and not executed.
+	AutonomySuggest Autonomy = "suggest"
+
+	// AutonomyAutoEdit runs read-only and file-editing tools unattended and
+	// asks the user before any other tool runs.
+	AutonomyAutoEdit Autonomy = "auto-edit"
+
+	// AutonomyFullAuto runs every tool without asking, except tools that
+	// require approval for every call.
+	AutonomyFullAuto Autonomy = "full-auto"
+)
+
+// Autonomies lists the autonomy levels from least to most autonomous.
+var Autonomies = []Autonomy{AutonomySuggest, AutonomyAutoEdit, AutonomyFullAuto}
+
+// declinedResult is returned to the model when the user refuses a tool call.
+const declinedResult = "The user declined this tool call. Do not retry it; ask the user how to proceed instead."
+
+// suggestNotice tells the model that only read-only tools run.
+const suggestNotice = "You are in suggest mode: read-only tools run normally, but tools that modify files " +
+	"or other state are not executed. Call them as you would for real so the user can review the proposed " +
+	"changes, and summarize what they would do."
+
+var errUnknownAutonomy = errors.New("unknown autonomy level")
+
+// Approver is optionally implemented by Callbacks to ask the user whether a
+// tool call may run. Without it, calls that need approval are declined.
+type Approver interface {
+	// Approve reports whether the call of tool name with the JSON input may run.
+	Approve(name string, input string) bool
+}
+
+// ParseAutonomy parses an autonomy level name. Empty means full-auto.
+func ParseAutonomy(s string) (Autonomy, error) {
+	if s == "" {
+		return AutonomyFullAuto, nil
+	}
+
+	for _, level := range Autonomies {
+		if Autonomy(s) == level {
+			return level, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: %q (want suggest, auto-edit or full-auto)", errUnknownAutonomy, s)
+}
+
+// Autonomy returns the current autonomy level.
+func (a *Agent) Autonomy() Autonomy {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.autonomy
+}
+
+// SetAutonomy changes the autonomy level for subsequent tool calls.
+func (a *Agent) SetAutonomy(level Autonomy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.autonomy = level
+}
+
+// needsApproval reports whether calling t requires the user's approval at
+// the current autonomy level.
+func (a *Agent) needsApproval(t tool.Tool) bool {
+	if tool.RequiresApproval(t) {
+		return true
+	}
+
+	return a.Autonomy() == AutonomyAutoEdit && !tool.IsReadOnly(t) && !tool.IsFileEditor(t)
+}
+
+// approve asks cb whether block may run. Prompts are serialized so that
+// concurrent tool calls don't ask at the same time.
+func (a *Agent) approve(block anthropic.ToolUseBlock, cb Callbacks) bool {
+	approver, ok := cb.(Approver)
+	if !ok {
+		return false
+	}
+
+	a.approveMu.Lock()
+	defer a.approveMu.Unlock()
+
+	return approver.Approve(block.Name, string(block.Input))
+}
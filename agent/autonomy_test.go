@@ -0,0 +1,150 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// editorTool is a mock tool that declares it only edits files.
+type editorTool struct {
+	mockTool
+}
+
+func (e *editorTool) EditsFiles() bool { return true }
+
+// guardedTool is a mock tool that requires approval for every call.
+type guardedTool struct {
+	mockTool
+}
+
+func (g *guardedTool) RequiresApproval() bool { return true }
+
+// approvingCallbacks answers approval prompts with a fixed answer.
+type approvingCallbacks struct {
+	mockCallbacks
+
+	answer bool
+	asked  []string
+}
+
+func (c *approvingCallbacks) Approve(name string, _ string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.asked = append(c.asked, name)
+
+	return c.answer
+}
+
+func TestExecuteToolUse_Autonomy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		autonomy  Autonomy
+		approve   bool
+		wantRuns  map[string]int
+		wantAsked int
+	}{
+		{"suggest", AutonomySuggest, true, map[string]int{"reader": 1, "editor": 0, "writer": 0}, 0},
+		{"auto-edit approved", AutonomyAutoEdit, true, map[string]int{"reader": 1, "editor": 1, "writer": 1}, 1},
+		{"auto-edit declined", AutonomyAutoEdit, false, map[string]int{"reader": 1, "editor": 1, "writer": 0}, 1},
+		{"full-auto", AutonomyFullAuto, false, map[string]int{"reader": 1, "editor": 1, "writer": 1}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tools := map[string]*mockTool{}
+			reader := &readOnlyTool{mockTool: mockTool{name: "reader"}}
+			editor := &editorTool{mockTool: mockTool{name: "editor"}}
+			writer := &mockTool{name: "writer"}
+			tools["reader"], tools["editor"], tools["writer"] = &reader.mockTool, &editor.mockTool, writer
+
+			ag := &Agent{
+				autonomy: tt.autonomy,
+				registry: registryOf(reader, editor, writer),
+			}
+			cb := &approvingCallbacks{answer: tt.approve}
+
+			for _, name := range []string{"reader", "editor", "writer"} {
+				ag.executeToolUse(anthropic.ToolUseBlock{ID: name, Name: name, Input: json.RawMessage(`{}`)}, cb)
+			}
+
+			for name, want := range tt.wantRuns {
+				if got := tools[name].callCount; got != want {
+					t.Errorf("%s: expected %d calls, got %d", name, want, got)
+				}
+			}
+
+			if len(cb.asked) != tt.wantAsked {
+				t.Errorf("expected %d approval prompts, got %v", tt.wantAsked, cb.asked)
+			}
+		})
+	}
+}
+
+func TestExecuteToolUse_AutoEditWithoutApprover(t *testing.T) {
+	t.Parallel()
+
+	writer := &mockTool{name: "writer"}
+	ag := &Agent{autonomy: AutonomyAutoEdit, registry: registryOf(writer)}
+
+	result := ag.executeToolUse(anthropic.ToolUseBlock{ID: "1", Name: "writer", Input: json.RawMessage(`{}`)},
+		&mockCallbacks{})
+
+	if writer.callCount != 0 {
+		t.Errorf("expected tool to be declined, got %d calls", writer.callCount)
+	}
+
+	if !result.OfToolResult.IsError.Value || result.OfToolResult.Content[0].OfText.Text != declinedResult {
+		t.Errorf("expected declined result, got %+v", result.OfToolResult)
+	}
+}
+
+func TestExecuteToolUse_RequiresApproval(t *testing.T) {
+	t.Parallel()
+
+	guarded := &guardedTool{mockTool: mockTool{name: "guarded"}}
+	ag := &Agent{autonomy: AutonomyFullAuto, registry: registryOf(guarded)}
+	block := anthropic.ToolUseBlock{ID: "1", Name: "guarded", Input: json.RawMessage(`{}`)}
+
+	declining := &approvingCallbacks{answer: false}
+	ag.executeToolUse(block, declining)
+
+	if guarded.callCount != 0 || len(declining.asked) != 1 {
+		t.Errorf("full-auto should still ask: %d calls, asked %v", guarded.callCount, declining.asked)
+	}
+
+	ag.executeToolUse(block, &approvingCallbacks{answer: true})
+
+	if guarded.callCount != 1 {
+		t.Errorf("approved call should run, got %d calls", guarded.callCount)
+	}
+}
+
+func TestParseAutonomy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in      string
+		want    Autonomy
+		wantErr bool
+	}{
+		{"", AutonomyFullAuto, false},
+		{"suggest", AutonomySuggest, false},
+		{"auto-edit", AutonomyAutoEdit, false},
+		{"full-auto", AutonomyFullAuto, false},
+		{"yolo", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseAutonomy(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseAutonomy(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
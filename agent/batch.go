@@ -0,0 +1,163 @@
+package agent
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// batchIDPrefix prefixes the custom ID of each request in a submitted batch.
+const batchIDPrefix = "prompt-"
+
+var (
+	errEmptyBatch     = errors.New("batch has no prompts")
+	errBatchNotEnded  = errors.New("batch has not finished processing")
+	errBatchRequest   = errors.New("batch request failed")
+	errNoBatchMessage = errors.New("batch result has no message")
+)
+
+// BatchResult is the outcome of one prompt in a Message Batch.
+type BatchResult struct {
+	Index   int                // position of the prompt in the submitted slice
+	Message *anthropic.Message // nil unless the request succeeded
+	Err     error              // set when the request errored, was canceled or expired
+}
+
+// SubmitBatch submits each prompt as an independent single-turn request
+// using the Message Batches API, which is processed asynchronously at a
+// lower cost. Requests are built like interactive ones, with the agent's
+// model, token limit, system prompt, tools and stop sequences.
+func (a *Agent) SubmitBatch(ctx context.Context, prompts []string) (*anthropic.MessageBatch, error) {
+	if len(prompts) == 0 {
+		return nil, errEmptyBatch
+	}
+
+	return a.client.Messages.Batches.New(ctx, anthropic.MessageBatchNewParams{Requests: a.batchRequests(prompts)})
+}
+
+// batchRequests returns the batch request of each prompt.
+func (a *Agent) batchRequests(prompts []string) []anthropic.MessageBatchNewParamsRequest {
+	requests := make([]anthropic.MessageBatchNewParamsRequest, len(prompts))
+	for i, prompt := range prompts {
+		params := a.requestParams([]anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
+		})
+
+		requests[i] = anthropic.MessageBatchNewParamsRequest{
+			CustomID: batchIDPrefix + strconv.Itoa(i),
+			Params: anthropic.MessageBatchNewParamsRequestParams{
+				Model:         params.Model,
+				MaxTokens:     params.MaxTokens,
+				System:        params.System,
+				Messages:      params.Messages,
+				Tools:         params.Tools,
+				StopSequences: params.StopSequences,
+			},
+		}
+	}
+
+	return requests
+}
+
+// BatchStatus returns the current state of a submitted batch.
+func (a *Agent) BatchStatus(ctx context.Context, id string) (*anthropic.MessageBatch, error) {
+	return a.client.Messages.Batches.Get(ctx, id)
+}
+
+// BatchResults downloads the results of a finished batch, ordered by prompt index.
+func (a *Agent) BatchResults(ctx context.Context, id string) ([]BatchResult, error) {
+	batch, err := a.BatchStatus(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if batch.ProcessingStatus != anthropic.MessageBatchProcessingStatusEnded {
+		return nil, fmt.Errorf("%w: %s is %s", errBatchNotEnded, id, batch.ProcessingStatus)
+	}
+
+	stream := a.client.Messages.Batches.ResultsStreaming(ctx, id)
+	defer stream.Close()
+
+	var results []BatchResult
+
+	for stream.Next() {
+		resp := stream.Current()
+
+		index, err := strconv.Atoi(strings.TrimPrefix(resp.CustomID, batchIDPrefix))
+		if err != nil {
+			continue // Not a request submitted by SubmitBatch
+		}
+
+		result := BatchResult{Index: index}
+
+		switch r := resp.Result.AsAny().(type) {
+		case anthropic.MessageBatchSucceededResult:
+			result.Message = &r.Message
+		case anthropic.MessageBatchErroredResult:
+			result.Err = fmt.Errorf("%w: %s", errBatchRequest, r.Error.Error.Message)
+		default:
+			result.Err = fmt.Errorf("%w: %s", errBatchRequest, resp.Result.Type)
+		}
+
+		results = append(results, result)
+	}
+
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+
+	// The API returns results in arbitrary order
+	slices.SortFunc(results, func(a, b BatchResult) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+
+	return results, nil
+}
+
+// ApplyBatchResult executes the tool calls requested in a succeeded batch
+// result with the agent's tools, notifying cb as for an interactive turn.
+// Batch requests are single-turn, so the results are returned to the caller
+// rather than sent back to the model.
+func (a *Agent) ApplyBatchResult(
+	ctx context.Context,
+	result BatchResult,
+	cb Callbacks,
+) ([]anthropic.ContentBlockParamUnion, error) {
+	if result.Message == nil {
+		return nil, errNoBatchMessage
+	}
+
+	cb, detach := a.attach(cb)
+	defer detach()
+
+	var blocks []anthropic.ToolUseBlock
+
+	for _, block := range result.Message.Content {
+		switch b := block.AsAny().(type) {
+		case anthropic.TextBlock:
+			cb.OnText(b.Text)
+		case anthropic.ToolUseBlock:
+			blocks = append(blocks, b)
+
+			inputJSON, err := json.Marshal(b.Input)
+			if err != nil {
+				inputJSON = []byte("{}")
+			}
+
+			cb.OnToolCall(b.ID, b.Name, string(inputJSON))
+		}
+	}
+
+	if len(blocks) == 0 {
+		return nil, nil
+	}
+
+	return a.executeToolsConcurrently(ctx, blocks, cb), nil
+}
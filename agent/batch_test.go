@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestSubmitBatch_Empty(t *testing.T) {
+	t.Parallel()
+
+	ag := &Agent{}
+	if _, err := ag.SubmitBatch(t.Context(), nil); !errors.Is(err, errEmptyBatch) {
+		t.Errorf("expected errEmptyBatch, got %v", err)
+	}
+}
+
+func TestBatchRequests_MatchMessageParams(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{
+		Model:         "test",
+		SystemPrompt:  "You review Go code.",
+		StopSequences: []string{"</answer>"},
+	})
+
+	requests := ag.batchRequests([]string{"first", "second"})
+	if len(requests) != 2 || requests[1].CustomID != batchIDPrefix+"1" {
+		t.Fatalf("expected a request per prompt, got %+v", requests)
+	}
+
+	params := requests[0].Params
+	if len(params.System) == 0 || params.System[0].Text != "You review Go code." {
+		t.Errorf("batch requests should carry the system prompt, got %+v", params.System)
+	}
+
+	if !slices.Equal(params.StopSequences, []string{"</answer>"}) {
+		t.Errorf("batch requests should carry the stop sequences, got %v", params.StopSequences)
+	}
+
+	if len(params.Tools) != len(ag.messageParams().Tools) {
+		t.Errorf("batch requests should offer the same tools, got %d", len(params.Tools))
+	}
+}
+
+func TestApplyBatchResult(t *testing.T) {
+	t.Parallel()
+
+	var message anthropic.Message
+
+	raw := `{
+		"id": "msg_1", "type": "message", "role": "assistant", "model": "m",
+		"stop_reason": "tool_use",
+		"content": [
+			{"type": "text", "text": "Adding doc comments"},
+			{"type": "tool_use", "id": "tu_1", "name": "tool1", "input": {}},
+			{"type": "tool_use", "id": "tu_2", "name": "tool2", "input": {}}
+		]
+	}`
+	if err := json.Unmarshal([]byte(raw), &message); err != nil {
+		t.Fatalf("failed to unmarshal message: %v", err)
+	}
+
+	ag := &Agent{
+		config:   Config{MaxConcurrentTools: 2},
+		registry: registryOf(&mockTool{name: "tool1"}, &mockTool{name: "tool2"}),
+	}
+
+	cb := &mockCallbacks{}
+
+	results, err := ag.ApplyBatchResult(t.Context(), BatchResult{Message: &message}, cb)
+	if err != nil {
+		t.Fatalf("ApplyBatchResult failed: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("expected 2 tool results, got %d", len(results))
+	}
+
+	if results[0].OfToolResult.ToolUseID != "tu_1" || results[1].OfToolResult.ToolUseID != "tu_2" {
+		t.Errorf("tool results should follow tool_use order, got %+v", results)
+	}
+
+	if _, err := ag.ApplyBatchResult(t.Context(), BatchResult{Err: errBatchRequest}, cb); err == nil {
+		t.Error("expected error applying a failed batch result")
+	}
+}
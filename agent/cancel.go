@@ -0,0 +1,33 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// failsHard reports whether a call of block that returned an error should,
+// with CancelOnFailure, cancel the calls still queued in its turn: it ran,
+// or was meant to run, a tool that may change something, so the calls
+// planned around it likely build on a change that was not made.
+func (a *Agent) failsHard(block anthropic.ToolUseBlock, result *anthropic.ContentBlockParamUnion) bool {
+	if !a.config.CancelOnFailure || !result.OfToolResult.IsError.Value {
+		return false
+	}
+
+	t, ok := a.registry.Lookup(block.Name)
+
+	return ok && !tool.IsReadOnly(t)
+}
+
+// skipToolUse answers a queued call without running it, because failed, an
+// earlier call in the turn, failed hard.
+func (a *Agent) skipToolUse(block, failed anthropic.ToolUseBlock, cb Callbacks) *anthropic.ContentBlockParamUnion {
+	msg := fmt.Sprintf("Skipped: %s (%s) failed earlier in this turn, so this call was cancelled before it ran. "+
+		"Fix that failure, then call it again if it is still needed.", failed.Name, failed.ID)
+
+	cb.OnToolResult(block.ID, block.Name, msg, true)
+
+	return new(anthropic.NewToolResultBlock(block.ID, msg, true))
+}
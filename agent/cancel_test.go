@@ -0,0 +1,84 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// failingTool is a mockTool whose calls fail.
+type failingTool struct {
+	mockTool
+}
+
+func (f *failingTool) Call(block anthropic.ToolUseBlock) *anthropic.ContentBlockParamUnion {
+	f.mockTool.Call(block)
+
+	return new(anthropic.NewToolResultBlock(block.ID, "permission denied", true))
+}
+
+// failingReader is a read-only tool whose calls fail.
+type failingReader struct {
+	failingTool
+}
+
+func (*failingReader) ReadOnly() bool { return true }
+
+func TestExecuteToolsConcurrently_CancelOnFailure(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cancel  bool
+		first   string
+		skipped bool
+	}{
+		{"failed edit cancels", true, "edit", true},
+		{"failed read does not cancel", true, "search", false},
+		{"unknown tool does not cancel", true, "missing", false},
+		{"policy off", false, "edit", false},
+	}
+
+	for _, tt := range tests {
+		writer := &mockTool{name: "writer"}
+		reader := &readOnlyTool{mockTool: mockTool{name: "reader"}}
+		ag := &Agent{
+			config: Config{MaxConcurrentTools: 4, CancelOnFailure: tt.cancel},
+			registry: registryOf(&failingTool{mockTool{name: "edit"}}, &failingReader{failingTool{mockTool{name: "search"}}},
+				writer, reader),
+		}
+
+		// Calls of tools that may write run one at a time in order, so the
+		// others are still queued when the first fails
+		blocks := []anthropic.ToolUseBlock{
+			{ID: "id1", Name: tt.first, Input: json.RawMessage(`{}`)},
+			{ID: "id2", Name: "writer", Input: json.RawMessage(`{}`)},
+			{ID: "id3", Name: "reader", Input: json.RawMessage(`{}`)},
+		}
+
+		cb := &mockCallbacks{}
+		results := ag.executeToolsConcurrently(t.Context(), blocks, cb)
+
+		if len(results) != 3 || len(cb.toolResultsCalls) != 3 {
+			t.Fatalf("%s: got %d results and %d callbacks, want one of each per call", tt.name, len(results), len(cb.toolResultsCalls))
+		}
+
+		ran := writer.callCount + reader.callCount
+		if tt.skipped && ran != 0 || !tt.skipped && ran != 2 {
+			t.Errorf("%s: %d queued calls ran", tt.name, ran)
+		}
+
+		for _, result := range results[1:] {
+			text := result.OfToolResult.Content[0].OfText.Text
+			if skipped := strings.HasPrefix(text, "Skipped: "+tt.first+" (id1) failed"); skipped != tt.skipped {
+				t.Errorf("%s: result %q, want skipped %v", tt.name, text, tt.skipped)
+			}
+
+			if tt.skipped && !result.OfToolResult.IsError.Value {
+				t.Errorf("%s: a skipped call should be an error", tt.name)
+			}
+		}
+	}
+}
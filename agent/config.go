@@ -4,6 +4,7 @@ package agent
 import (
 	"time"
 
+	"github.com/aelse/artoo/tool"
 	"github.com/anthropics/anthropic-sdk-go"
 )
 
@@ -15,11 +16,27 @@ const (
 // Config holds agent configuration.
 type Config struct {
 	Model               string        // e.g. "claude-sonnet-4-20250514"
+	SystemPrompt        string        // Optional system prompt sent with every request; a template over PromptData
 	MaxTokens           int64         // per-response token limit
 	MaxConcurrentTools  int           // maximum concurrent tool executions
 	PluginDir           string        // Directory containing plugin executables
 	PluginTimeout       time.Duration // Execution timeout per plugin call
 	Streaming           bool          // Whether to use streaming API (default: true)
+	DeferTools          bool          // Summarize plugin tools and load their schemas on demand
+	ToolCacheTTL        time.Duration // Lifetime of cached read-only tool results (0 disables caching)
+	ToolEnvironment     *tool.Environment // Settings the agent's tools share (nil gets an Environment of its own)
+	SummaryModel        string        // Cheap model for the task summary, titles and compressed tool results (empty works locally)
+	StopSequences       []string      // Custom sequences that end a response when generated
+	Prefill             string        // Text the first response of each turn is forced to start with
+	ToolChoice          ToolChoice    // How the first response of each turn may use tools (empty is auto)
+	Autonomy            Autonomy      // What may run without the user's approval (empty means full-auto)
+	ServerTools         []string      // Anthropic server tools to enable, e.g. "web_search"
+	Tools               []string      // Names of the tools offered to the model (empty offers all)
+	VerifyCommand       string        // Shell command that must pass before a turn that changed something ends
+	VerifyRetries       int           // Times a failed verification is fed back before the turn ends anyway
+	CancelOnFailure     bool          // Skip a turn's queued tool calls once a call that may change something fails
+	LongContext         bool          // Opt into the long-context beta on models that support it
+	FineGrained         bool          // Fine-grained tool streaming (beta): tool input arrives unbuffered
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -35,9 +52,12 @@ func DefaultConfig() Config {
 // Callbacks is implemented by the UI layer to observe agent events
 // without the agent knowing about terminals or styling.
 //
-// When concurrent tool execution is active (MaxConcurrentTools > 1),
-// callback methods may be called from multiple goroutines simultaneously.
-// Implementations must be thread-safe.
+// The agent publishes its events on a Bus; the Callbacks passed to a call
+// such as SendMessage subscribe to it for that call and are called on a
+// single goroutine, in the order the events were published, until the call
+// returns. Approve, if implemented, is called from the tool's goroutine once
+// the events before it have been delivered. Other observers can follow the
+// same events with Agent.Subscribe.
 type Callbacks interface {
 	// OnThinking is called when the agent starts thinking (API call starting).
 	OnThinking()
@@ -51,17 +71,57 @@ type Callbacks interface {
 	// OnTextDelta is called when a text delta is received (streaming only).
 	OnTextDelta(delta string)
 
-	// OnToolCall is called when the assistant calls a tool.
+	// OnToolCall is called when the assistant calls a tool. id is the call's
+	// tool_use ID, which tells apart calls of the same tool in one response.
 	// input is the JSON-marshaled parameters.
-	OnToolCall(name string, input string)
+	OnToolCall(id string, name string, input string)
 
-	// OnToolResult is called after a tool completes.
-	// May be called from multiple goroutines concurrently.
-	OnToolResult(name string, output string, isError bool)
+	// OnToolResult is called after a tool completes, with the id of its
+	// OnToolCall. Concurrent calls finish in any order, so results must be
+	// matched to calls by id, not by name or position; each call gets exactly
+	// one result. id is empty for notices that belong to no call, such as a
+	// failed save.
+	OnToolResult(id string, name string, output string, isError bool)
+
+	// OnTurnStart is called before each request to the model. A turn is one
+	// model response and the tool calls it requested; callbacks up to the
+	// matching OnTurnEnd belong to the turn.
+	OnTurnStart(turnID string)
+
+	// OnTurnEnd is called once the turn's tool calls have completed, with
+	// the turn's token usage and the response's stop reason. stopReason is
+	// empty if the request failed.
+	OnTurnEnd(turnID string, usage Usage, stopReason string)
 }
 
+// NoCallbacks ignores every event. Embed it to implement only some of
+// Callbacks; a nil Callbacks passed to a call is treated the same way.
+type NoCallbacks struct{}
+
+var _ Callbacks = NoCallbacks{}
+
+func (NoCallbacks) OnThinking()                               {}
+func (NoCallbacks) OnThinkingDone()                           {}
+func (NoCallbacks) OnText(string)                             {}
+func (NoCallbacks) OnTextDelta(string)                        {}
+func (NoCallbacks) OnToolCall(string, string, string)         {}
+func (NoCallbacks) OnToolResult(string, string, string, bool) {}
+func (NoCallbacks) OnTurnStart(string)                        {}
+func (NoCallbacks) OnTurnEnd(string, Usage, string)           {}
+
 // Response is the final output from a SendMessage call.
 type Response struct {
-	Text       string // The assistant's text response
-	StopReason string // Why the assistant stopped (e.g., "end_turn", "tool_use")
+	Text         string // The assistant's text response (including any prefill)
+	StopReason   string // Why the assistant stopped (e.g., "end_turn", "tool_use")
+	StopSequence string // The custom stop sequence that ended the response, if any
+	Usage        Usage  // Token breakdown of the turn
+	VerifyFailed string // Why verification still failed when the turn ended, if it did
+	Unfinished   string // Why the turn ended without a complete answer (a refusal or a turn left paused), if it did
+}
+
+// Recorder receives usage events, e.g. for local usage statistics.
+// RecordTool may be called from multiple goroutines concurrently.
+type Recorder interface {
+	RecordUsage(inputTokens, outputTokens int64)
+	RecordTool(name string, isError bool)
 }
@@ -0,0 +1,122 @@
+package agent
+
+import (
+	"encoding/json"
+
+	"github.com/aelse/artoo/conversation"
+)
+
+// charsPerToken approximates how many characters make a token, for a context
+// map drawn before the API has reported any usage.
+const charsPerToken = 4
+
+// ContextTurn is one turn's share of the context window: a user prompt and
+// everything after it up to the next prompt.
+type ContextTurn struct {
+	Messages int64 // prompt, replies and tool calls
+	Results  int64 // tool outputs
+	Freed    int64 // tokens compressing its tool results would free
+}
+
+// ContextMap describes what occupies the context window, in tokens.
+type ContextMap struct {
+	System      int64         // system prompt and instruction files
+	ToolSchemas int64         // tool definitions
+	Turns       []ContextTurn // oldest first; messages before the first prompt count as a turn
+	Tokens      int64         // total, as last reported by the API or estimated
+	Measured    bool          // Tokens was reported by the API rather than estimated
+	Budget      int64         // context budget (0 if unlimited)
+	TrimAt      int64         // tokens at which trimming starts (0 if unlimited)
+}
+
+// Freed returns the tokens compressing old tool results would free.
+func (m ContextMap) Freed() int64 {
+	var freed int64
+	for _, t := range m.Turns {
+		freed += t.Freed
+	}
+
+	return freed
+}
+
+// ContextMap returns what occupies the context window of the next request.
+// The API reports only a total, so it is apportioned by the size of each
+// part, as for Usage.
+func (a *Agent) ContextMap() ContextMap {
+	messages := a.conversation.Snapshot()
+	freed := a.conversation.Compressible()
+
+	var sizes ContextMap
+
+	for _, block := range a.systemBlocks() {
+		sizes.System += int64(len(block.Text))
+	}
+
+	if tools := a.offlineTools(a.toolParams()); len(tools) > 0 {
+		if data, err := json.Marshal(tools); err == nil {
+			sizes.ToolSchemas = int64(len(data))
+		}
+	}
+
+	for i, message := range messages {
+		if len(sizes.Turns) == 0 || conversation.IsPrompt(message) {
+			sizes.Turns = append(sizes.Turns, ContextTurn{})
+		}
+
+		turn := &sizes.Turns[len(sizes.Turns)-1]
+
+		for _, block := range message.Content {
+			if block.OfToolResult != nil {
+				turn.Results += blockSize(block)
+			} else {
+				turn.Messages += blockSize(block)
+			}
+		}
+
+		if i < len(freed) {
+			turn.Freed += int64(freed[i])
+		}
+	}
+
+	total := sizes.System + sizes.ToolSchemas
+	for _, t := range sizes.Turns {
+		total += t.Messages + t.Results
+	}
+
+	tokens := int64(a.conversation.EstimatedTokens())
+	measured := tokens > 0
+
+	if !measured {
+		tokens = total / charsPerToken
+	}
+
+	scale := func(size int64) int64 {
+		if total == 0 {
+			return 0
+		}
+
+		return size * tokens / total
+	}
+
+	m := ContextMap{
+		System:      scale(sizes.System),
+		ToolSchemas: scale(sizes.ToolSchemas),
+		Tokens:      tokens,
+		Measured:    measured,
+	}
+
+	for _, t := range sizes.Turns {
+		m.Turns = append(m.Turns, ContextTurn{
+			Messages: scale(t.Messages),
+			Results:  scale(t.Results),
+			Freed:    min(scale(t.Freed), scale(t.Results)),
+		})
+	}
+
+	if budget := a.conversation.Config().MaxContextTokens; budget > 0 {
+		m.Budget = int64(budget)
+		m.TrimAt = int64(budget) * conversation.TrimPercent / 100
+	}
+
+	return m
+}
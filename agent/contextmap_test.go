@@ -0,0 +1,65 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/conversation"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestContextMap(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{MaxTokens: 100, MaxConcurrentTools: 1}, &mockTool{name: "lookup"})
+	ag.SetConversationConfig(conversation.Config{MaxContextTokens: 10_000, ToolResultMaxChars: 100_000})
+
+	if m := ag.ContextMap(); len(m.Turns) != 0 || m.Measured {
+		t.Fatalf("empty conversation: Turns = %d, Measured = %v", len(m.Turns), m.Measured)
+	}
+
+	output := strings.Repeat("func lookup() {}\n", 200)
+
+	c := ag.conversation
+	for i, id := range []string{"t1", "t2", "t3"} {
+		c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("look it up")))
+		c.Append(anthropic.NewAssistantMessage(anthropic.NewToolUseBlock(id, map[string]any{"q": i}, "lookup")))
+		c.AppendToolResults(anthropic.NewToolResultBlock(id, output[i:], false))
+		c.Append(anthropic.NewAssistantMessage(anthropic.NewTextBlock("found")))
+	}
+
+	m := ag.ContextMap()
+
+	if m.Measured || m.Budget != 10_000 || m.TrimAt != 7_500 {
+		t.Errorf("Measured = %v, Budget = %d, TrimAt = %d; want an estimate against 10000, trimming at 7500",
+			m.Measured, m.Budget, m.TrimAt)
+	}
+
+	if len(m.Turns) != 3 {
+		t.Fatalf("Turns = %d, want 3", len(m.Turns))
+	}
+
+	if m.Turns[0].Freed == 0 || m.Turns[1].Freed != 0 || m.Turns[2].Freed != 0 {
+		t.Errorf("Freed = %d, %d, %d; want only the oldest turn's results compressible",
+			m.Turns[0].Freed, m.Turns[1].Freed, m.Turns[2].Freed)
+	}
+
+	if m.Turns[0].Results <= m.Turns[0].Messages || m.ToolSchemas == 0 {
+		t.Errorf("turn 1 = %+v, tool schemas = %d; want results to dominate and schemas counted",
+			m.Turns[0], m.ToolSchemas)
+	}
+
+	// Once the API reports usage, the parts share that total
+	c.UpdateTokenCount(5_000)
+
+	m = ag.ContextMap()
+
+	total := m.System + m.ToolSchemas
+	for _, turn := range m.Turns {
+		total += turn.Messages + turn.Results
+	}
+
+	if !m.Measured || m.Tokens != 5_000 || total > 5_000 || total < 4_990 {
+		t.Errorf("Measured = %v, Tokens = %d, parts = %d; want the reported 5000 apportioned", m.Measured, m.Tokens, total)
+	}
+}
@@ -0,0 +1,177 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// enableToolsName is the name of the meta-tool the model calls to load deferred tool schemas.
+const enableToolsName = "enable_tools"
+
+// maxSummaryLen caps the one-line description shown for each deferred tool.
+const maxSummaryLen = 100
+
+// enableToolsParams defines the parameters for the enable_tools meta-tool.
+type enableToolsParams struct {
+	Names []string `json:"names"`
+}
+
+// enableToolsTool lets the model load the schemas of deferred tools on demand.
+// Its description is regenerated whenever the deferred set changes so it only
+// lists tools that are still deferred.
+type enableToolsTool struct {
+	agent *Agent
+}
+
+// ReadOnly reports true: enabling tools changes only what the model sees, so
+// it neither invalidates cached results nor is skipped in a dry run.
+func (t *enableToolsTool) ReadOnly() bool {
+	return true
+}
+
+// Call enables the requested tools so their full schemas are sent from the next request on.
+func (t *enableToolsTool) Call(block anthropic.ToolUseBlock) *anthropic.ContentBlockParamUnion {
+	var params enableToolsParams
+	if err := json.Unmarshal(block.Input, &params); err != nil {
+		errMsg := fmt.Sprintf("Error unmarshalling parameters: %v", err)
+
+		return new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+	}
+
+	enabled, unknown := t.agent.enableTools(params.Names)
+	if len(enabled) == 0 {
+		errMsg := "No tools enabled"
+		if len(unknown) > 0 {
+			errMsg = "Unknown or already enabled tools: " + strings.Join(unknown, ", ")
+		}
+
+		return new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+	}
+
+	output := "Enabled: " + strings.Join(enabled, ", ")
+	if len(unknown) > 0 {
+		output += "\nUnknown or already enabled: " + strings.Join(unknown, ", ")
+	}
+
+	return new(anthropic.NewToolResultBlock(block.ID, output, false))
+}
+
+// Param describes the meta-tool, summarizing every tool that is still deferred.
+func (t *enableToolsTool) Param() anthropic.ToolParam {
+	return enableToolsParam(t.agent.deferredTools())
+}
+
+// enableToolsParam builds the enable_tools definition listing the given deferred tools.
+func enableToolsParam(deferred []tool.Tool) anthropic.ToolParam {
+	var desc strings.Builder
+	desc.WriteString("Load additional tools. The following tools are available but their full " +
+		"definitions are not loaded yet; call this tool with their names before using them:\n")
+
+	for _, d := range deferred {
+		fmt.Fprintf(&desc, "- %s: %s\n", d.Param().Name, summarize(d.Param()))
+	}
+
+	return anthropic.ToolParam{
+		Name:        enableToolsName,
+		Description: anthropic.String(desc.String()),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"names": map[string]any{
+					"type":        "array",
+					"description": "Names of the tools to enable",
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
+			},
+			Required: []string{"names"},
+		},
+	}
+}
+
+// summarize returns the first line of a tool's description, capped at maxSummaryLen.
+func summarize(param anthropic.ToolParam) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(param.Description.Value), "\n")
+	line = strings.TrimPrefix(strings.TrimSpace(line), "- ")
+
+	if len(line) > maxSummaryLen {
+		line = line[:maxSummaryLen] + "..."
+	}
+
+	return line
+}
+
+// enableTools moves the named tools from the deferred set into the active set.
+// It returns the names that were enabled and those that were not deferred.
+func (a *Agent) enableTools(names []string) ([]string, []string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var enabled, unknown []string
+
+	for _, name := range names {
+		t, ok := a.deferred[name]
+		if !ok {
+			unknown = append(unknown, name)
+
+			continue
+		}
+
+		delete(a.deferred, name)
+		_ = a.registry.Register(t) // deferred tools' names are not registered
+		enabled = append(enabled, name)
+	}
+
+	if len(enabled) > 0 {
+		a.rebuildToolParams()
+	}
+
+	return enabled, unknown
+}
+
+// deferredTools returns the still-deferred tools sorted by name.
+func (a *Agent) deferredTools() []tool.Tool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.sortedDeferred()
+}
+
+// sortedDeferred returns the deferred tools sorted by name. Callers must hold a.mu.
+func (a *Agent) sortedDeferred() []tool.Tool {
+	names := make([]string, 0, len(a.deferred))
+	for name := range a.deferred {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	tools := make([]tool.Tool, len(names))
+	for i, name := range names {
+		tools[i] = a.deferred[name]
+	}
+
+	return tools
+}
+
+// rebuildToolParams regenerates the tools array sent to the API.
+// The enable_tools meta-tool is appended while any tool remains deferred.
+// Callers must hold a.mu.
+func (a *Agent) rebuildToolParams() {
+	// enable_tools describes the deferred tools itself, and needs a.mu to
+	tools := slices.DeleteFunc(a.registry.Tools(), func(t tool.Tool) bool {
+		_, meta := t.(*enableToolsTool)
+
+		return meta
+	})
+	a.toolUnionParams = append(makeToolUnionParams(a.allowedTools(tools)), serverToolParams(a.config.ServerTools)...)
+	if deferred := a.allowedTools(a.sortedDeferred()); len(deferred) > 0 {
+		enable := enableToolsParam(deferred)
+		a.toolUnionParams = append(a.toolUnionParams, anthropic.ToolUnionParam{OfTool: &enable})
+	}
+}
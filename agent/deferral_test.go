@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// toolNames returns the names of the tools in the API tools array.
+func toolNames(params []anthropic.ToolUnionParam) []string {
+	names := make([]string, 0, len(params))
+	for _, p := range params {
+		if p.OfTool != nil {
+			names = append(names, p.OfTool.Name)
+		}
+	}
+
+	return names
+}
+
+func TestNew_DeferTools(t *testing.T) {
+	t.Parallel()
+
+	plugin := &mockTool{name: "deploy"}
+	ag := mustNew(t, anthropic.NewClient(), Config{DeferTools: true}, plugin)
+
+	names := toolNames(ag.toolParams())
+	if strings.Contains(strings.Join(names, ","), "deploy") {
+		t.Errorf("deferred tool should not be sent, got %v", names)
+	}
+
+	last := ag.toolParams()[len(ag.toolParams())-1].OfTool
+	if last.Name != enableToolsName {
+		t.Fatalf("expected %s as last tool, got %s", enableToolsName, last.Name)
+	}
+
+	if !strings.Contains(last.Description.Value, "- deploy: Mock tool for testing") {
+		t.Errorf("enable_tools description should summarize deferred tools, got %q", last.Description.Value)
+	}
+}
+
+func TestNew_WithoutDeferTools(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{}, &mockTool{name: "deploy"})
+
+	names := strings.Join(toolNames(ag.toolParams()), ",")
+	if !strings.Contains(names, "deploy") {
+		t.Errorf("plugin tool should be sent when deferral is off, got %v", names)
+	}
+
+	if strings.Contains(names, enableToolsName) {
+		t.Errorf("%s should not be sent when nothing is deferred", enableToolsName)
+	}
+}
+
+func TestEnableTools(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{DeferTools: true, MaxConcurrentTools: 1},
+		&mockTool{name: "deploy"}, &mockTool{name: "rollback"})
+	cb := &mockCallbacks{}
+
+	// Calling a deferred tool before enabling it returns a helpful error
+	results := ag.executeToolsConcurrently(t.Context(), []anthropic.ToolUseBlock{
+		{ID: "id1", Name: "deploy", Input: json.RawMessage(`{}`)},
+	}, cb)
+	if len(results) != 1 || !results[0].OfToolResult.IsError.Value {
+		t.Fatalf("expected error result for deferred tool, got %+v", results)
+	}
+
+	block := anthropic.ToolUseBlock{ID: "id2", Name: enableToolsName, Input: json.RawMessage(`{"names": ["deploy", "missing"]}`)}
+
+	results = ag.executeToolsConcurrently(t.Context(), []anthropic.ToolUseBlock{block}, cb)
+	if len(results) != 1 || results[0].OfToolResult.IsError.Value {
+		t.Fatalf("expected successful enable_tools result, got %+v", results)
+	}
+
+	names := strings.Join(toolNames(ag.toolParams()), ",")
+	if !strings.Contains(names, "deploy") {
+		t.Errorf("enabled tool should be sent, got %v", names)
+	}
+
+	if !strings.Contains(names, enableToolsName) {
+		t.Errorf("%s should remain while rollback is deferred, got %v", enableToolsName, names)
+	}
+
+	ag.enableTools([]string{"rollback"})
+
+	if strings.Contains(strings.Join(toolNames(ag.toolParams()), ","), enableToolsName) {
+		t.Error("enable_tools should be dropped once nothing is deferred")
+	}
+}
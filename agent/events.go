@@ -0,0 +1,360 @@
+package agent
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aelse/artoo/tool"
+)
+
+// callbackBuffer is how many events the Callbacks of a call may fall behind
+// before the agent waits for them.
+const callbackBuffer = 256
+
+// EventKind identifies what an Event reports.
+type EventKind int
+
+const (
+	EventThinking       EventKind = iota + 1 // a request to the model started
+	EventThinkingDone                        // the model's response arrived
+	EventText                                // the assistant produced Text
+	EventTextDelta                           // a delta of streamed Text arrived
+	EventToolInputDelta                      // the Input of tool call Name is being formed
+	EventToolCall                            // the assistant called tool Name with Input
+	EventToolResult                          // tool call ToolID finished with Output
+	EventInjection                           // tool Name returned suspected prompt Injections
+	EventTurnStart                           // turn TurnID started
+	EventTurnEnd                             // turn TurnID ended with Usage and StopReason
+)
+
+var eventKindNames = map[EventKind]string{
+	EventThinking:       "thinking",
+	EventThinkingDone:   "thinking_done",
+	EventText:           "text",
+	EventTextDelta:      "text_delta",
+	EventToolInputDelta: "tool_input_delta",
+	EventToolCall:       "tool_call",
+	EventToolResult:     "tool_result",
+	EventInjection:      "injection",
+	EventTurnStart:      "turn_start",
+	EventTurnEnd:        "turn_end",
+}
+
+// String returns the kind's name, e.g. "tool_call".
+func (k EventKind) String() string {
+	if name, ok := eventKindNames[k]; ok {
+		return name
+	}
+
+	return "unknown"
+}
+
+// Event is something the agent did, reported to every subscriber of its Bus.
+// Fields that do not apply to the Kind are empty.
+type Event struct {
+	Kind       EventKind
+	Time       time.Time
+	TurnID     string
+	ToolID     string // tool_use ID of the call
+	Name       string // tool name
+	Text       string
+	Input      string // JSON input of a call, or a preview while it forms
+	Output     string
+	IsError    bool
+	Usage      Usage
+	StopReason string
+	Injections []tool.Injection
+
+	flushed chan struct{} // closed instead of delivering, for Subscription.flush
+}
+
+// Overflow decides what happens to an event when a subscriber's buffer is
+// full.
+type Overflow int
+
+const (
+	// OverflowBlock makes the agent wait for the subscriber, so no event is
+	// lost. The subscriber must keep reading until it closes the
+	// subscription, since a full buffer holds up the agent and every other
+	// subscriber.
+	OverflowBlock Overflow = iota
+
+	// OverflowDrop discards the event for that subscriber and counts it,
+	// so a slow subscriber, such as a metrics exporter, never holds up
+	// the agent.
+	OverflowDrop
+)
+
+// Bus delivers the agent's events to any number of subscribers. It is safe
+// for concurrent use; a nil Bus has no subscribers.
+type Bus struct {
+	mu   sync.RWMutex
+	subs []*Subscription
+}
+
+// NewBus returns a bus without subscribers.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscription is a subscriber's feed of events.
+type Subscription struct {
+	bus      *Bus
+	events   chan Event
+	done     chan struct{} // closed by Close to release waiting publishers
+	overflow Overflow
+	dropped  atomic.Int64
+	once     sync.Once
+}
+
+// Subscribe returns a feed of the events published from now on, buffering
+// up to buffer of them. Events from one goroutine arrive in the order they
+// were published.
+func (b *Bus) Subscribe(buffer int, overflow Overflow) *Subscription {
+	s := &Subscription{
+		bus:      b,
+		events:   make(chan Event, max(buffer, 0)),
+		done:     make(chan struct{}),
+		overflow: overflow,
+	}
+
+	if b == nil {
+		close(s.events)
+
+		return s
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subs = append(b.subs, s)
+
+	return s
+}
+
+// Publish delivers ev to every subscriber, waiting for those that block
+// when their buffer is full.
+func (b *Bus) Publish(ev Event) {
+	if b == nil {
+		return
+	}
+
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for _, s := range b.subs {
+		s.send(ev, s.overflow)
+	}
+}
+
+// send delivers ev, reporting whether it was. Called with the bus's read
+// lock held, so Close cannot close the channel meanwhile.
+func (s *Subscription) send(ev Event, overflow Overflow) bool {
+	if overflow == OverflowDrop {
+		select {
+		case s.events <- ev:
+			return true
+		default:
+			s.dropped.Add(1)
+
+			return false
+		}
+	}
+
+	select {
+	case s.events <- ev:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// Events returns the feed, closed once the subscription is and its
+// buffered events are read.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// Dropped returns how many events were discarded because the buffer was
+// full.
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Load()
+}
+
+// Close ends the subscription. Events already buffered can still be read.
+func (s *Subscription) Close() {
+	if s.bus == nil {
+		return
+	}
+
+	s.once.Do(func() {
+		close(s.done)
+
+		s.bus.mu.Lock()
+		defer s.bus.mu.Unlock()
+
+		s.bus.subs = slices.DeleteFunc(s.bus.subs, func(o *Subscription) bool { return o == s })
+		close(s.events)
+	})
+}
+
+// flush waits until the subscriber has read every event published to it so
+// far, by sending a marker its reader must close.
+func (s *Subscription) flush() {
+	if s.bus == nil {
+		return
+	}
+
+	flushed := make(chan struct{})
+
+	s.bus.mu.RLock()
+	sent := s.send(Event{flushed: flushed}, OverflowBlock)
+	s.bus.mu.RUnlock()
+
+	if sent {
+		select {
+		case <-flushed:
+		case <-s.done:
+		}
+	}
+}
+
+// follow passes each event of s to handle on a new goroutine, returning a
+// function that closes s and waits for the events delivered before.
+func follow(s *Subscription, handle func(Event)) (stop func()) {
+	stopped := make(chan struct{})
+
+	go func() {
+		defer close(stopped)
+
+		for ev := range s.Events() {
+			if ev.flushed != nil {
+				close(ev.flushed)
+
+				continue
+			}
+
+			handle(ev)
+		}
+	}()
+
+	return func() {
+		s.Close()
+		<-stopped
+	}
+}
+
+// Subscribe returns a feed of the agent's events from now on, for observers
+// such as an audit log, metrics or a server stream. Close it when done.
+func (a *Agent) Subscribe(buffer int, overflow Overflow) *Subscription {
+	return a.events.Subscribe(buffer, overflow)
+}
+
+// attach subscribes cb to the agent's events until the returned detach is
+// called, returning the Callbacks the agent reports to meanwhile. cb gets
+// the events in order on one goroutine; detach waits until it has them all.
+// Callbacks already attached are returned as they are.
+func (a *Agent) attach(cb Callbacks) (Callbacks, func()) {
+	if cb == nil {
+		cb = NoCallbacks{}
+	}
+
+	if _, attached := cb.(*publisher); attached || a.events == nil {
+		return cb, func() {}
+	}
+
+	sub := a.events.Subscribe(callbackBuffer, OverflowBlock)
+	approver, _ := cb.(Approver)
+
+	return &publisher{bus: a.events, sub: sub, approver: approver}, follow(sub, func(ev Event) { deliver(ev, cb) })
+}
+
+// deliver reports ev to cb.
+func deliver(ev Event, cb Callbacks) {
+	switch ev.Kind {
+	case EventThinking:
+		cb.OnThinking()
+	case EventThinkingDone:
+		cb.OnThinkingDone()
+	case EventText:
+		cb.OnText(ev.Text)
+	case EventTextDelta:
+		cb.OnTextDelta(ev.Text)
+	case EventToolInputDelta:
+		if w, ok := cb.(ToolInputWatcher); ok {
+			w.OnToolInputDelta(ev.Name, ev.Input)
+		}
+	case EventToolCall:
+		cb.OnToolCall(ev.ToolID, ev.Name, ev.Input)
+	case EventToolResult:
+		cb.OnToolResult(ev.ToolID, ev.Name, ev.Output, ev.IsError)
+	case EventInjection:
+		if w, ok := cb.(InjectionWarner); ok {
+			w.OnSuspectedInjection(ev.Name, ev.Injections)
+		}
+	case EventTurnStart:
+		cb.OnTurnStart(ev.TurnID)
+	case EventTurnEnd:
+		cb.OnTurnEnd(ev.TurnID, ev.Usage, ev.StopReason)
+	}
+}
+
+// publisher is what the agent reports to while Callbacks are attached: it
+// publishes each callback as an event on the bus.
+type publisher struct {
+	bus      *Bus
+	sub      *Subscription // the attached Callbacks' feed
+	approver Approver      // the attached Callbacks, if they can approve calls
+}
+
+func (p *publisher) OnThinking()     { p.bus.Publish(Event{Kind: EventThinking}) }
+func (p *publisher) OnThinkingDone() { p.bus.Publish(Event{Kind: EventThinkingDone}) }
+func (p *publisher) OnText(text string) {
+	p.bus.Publish(Event{Kind: EventText, Text: text})
+}
+
+func (p *publisher) OnTextDelta(delta string) {
+	p.bus.Publish(Event{Kind: EventTextDelta, Text: delta})
+}
+
+func (p *publisher) OnToolInputDelta(name string, preview string) {
+	p.bus.Publish(Event{Kind: EventToolInputDelta, Name: name, Input: preview})
+}
+
+func (p *publisher) OnToolCall(id string, name string, input string) {
+	p.bus.Publish(Event{Kind: EventToolCall, ToolID: id, Name: name, Input: input})
+}
+
+func (p *publisher) OnToolResult(id string, name string, output string, isError bool) {
+	p.bus.Publish(Event{Kind: EventToolResult, ToolID: id, Name: name, Output: output, IsError: isError})
+}
+
+func (p *publisher) OnSuspectedInjection(name string, found []tool.Injection) {
+	p.bus.Publish(Event{Kind: EventInjection, Name: name, Injections: found})
+}
+
+func (p *publisher) OnTurnStart(turnID string) {
+	p.bus.Publish(Event{Kind: EventTurnStart, TurnID: turnID})
+}
+
+func (p *publisher) OnTurnEnd(turnID string, usage Usage, stopReason string) {
+	p.bus.Publish(Event{Kind: EventTurnEnd, TurnID: turnID, Usage: usage, StopReason: stopReason})
+}
+
+// Approve asks the attached Callbacks once they have shown everything
+// reported before, so the question follows the call it is about.
+func (p *publisher) Approve(name string, input string) bool {
+	if p.approver == nil {
+		return false
+	}
+
+	p.sub.flush()
+
+	return p.approver.Approve(name, input)
+}
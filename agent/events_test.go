@@ -0,0 +1,197 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBus_FansOut(t *testing.T) {
+	t.Parallel()
+
+	bus := NewBus()
+	a := bus.Subscribe(10, OverflowBlock)
+	b := bus.Subscribe(10, OverflowDrop)
+
+	bus.Publish(Event{Kind: EventText, Text: "one"})
+	bus.Publish(Event{Kind: EventText, Text: "two"})
+	a.Close()
+	b.Close()
+	bus.Publish(Event{Kind: EventText, Text: "after"})
+
+	for _, sub := range []*Subscription{a, b} {
+		var got []string
+		for ev := range sub.Events() {
+			if ev.Time.IsZero() {
+				t.Error("published events should be timestamped")
+			}
+
+			got = append(got, ev.Text)
+		}
+
+		if !slices.Equal(got, []string{"one", "two"}) {
+			t.Errorf("expected both events before closing, got %q", got)
+		}
+	}
+}
+
+func TestBus_DropCountsOverflow(t *testing.T) {
+	t.Parallel()
+
+	bus := NewBus()
+	sub := bus.Subscribe(2, OverflowDrop)
+
+	for range 5 {
+		bus.Publish(Event{Kind: EventTextDelta})
+	}
+
+	if sub.Dropped() != 3 || len(sub.Events()) != 2 {
+		t.Errorf("expected 2 buffered and 3 dropped, got %d and %d", len(sub.Events()), sub.Dropped())
+	}
+}
+
+func TestBus_BlockWaitsForSubscriber(t *testing.T) {
+	t.Parallel()
+
+	bus := NewBus()
+	sub := bus.Subscribe(1, OverflowBlock)
+	bus.Publish(Event{Kind: EventText, Text: "one"})
+
+	published := make(chan struct{})
+
+	go func() {
+		bus.Publish(Event{Kind: EventText, Text: "two"})
+		close(published)
+	}()
+
+	select {
+	case <-published:
+		t.Fatal("publishing to a full subscriber should wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	if ev := <-sub.Events(); ev.Text != "one" {
+		t.Errorf("expected the first event, got %q", ev.Text)
+	}
+
+	<-published
+
+	if ev := <-sub.Events(); ev.Text != "two" {
+		t.Errorf("expected the second event, got %q", ev.Text)
+	}
+}
+
+func TestBus_CloseReleasesPublisher(t *testing.T) {
+	t.Parallel()
+
+	bus := NewBus()
+	sub := bus.Subscribe(0, OverflowBlock)
+
+	published := make(chan struct{})
+
+	go func() {
+		bus.Publish(Event{Kind: EventText})
+		close(published)
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	sub.Close()
+
+	select {
+	case <-published:
+	case <-time.After(5 * time.Second):
+		t.Fatal("closing a subscription should release a publisher waiting for it")
+	}
+}
+
+func TestBus_Nil(t *testing.T) {
+	t.Parallel()
+
+	var bus *Bus
+
+	bus.Publish(Event{Kind: EventText})
+
+	sub := bus.Subscribe(1, OverflowBlock)
+	defer sub.Close()
+
+	if _, open := <-sub.Events(); open {
+		t.Error("a nil bus should have no events")
+	}
+}
+
+// orderedCallbacks records tool calls and approves every call.
+type orderedCallbacks struct {
+	mockCallbacks
+
+	calls          []string
+	seenAtApproval int
+}
+
+func (c *orderedCallbacks) OnToolCall(id string, _ string, _ string) {
+	c.calls = append(c.calls, id)
+}
+
+func (c *orderedCallbacks) Approve(string, string) bool {
+	c.seenAtApproval = len(c.calls)
+
+	return true
+}
+
+func TestAttach_DeliversInOrder(t *testing.T) {
+	t.Parallel()
+
+	ag := &Agent{events: NewBus()}
+	watcher := ag.Subscribe(100, OverflowBlock)
+	cb := &orderedCallbacks{}
+
+	attached, detach := ag.attach(cb)
+	if again, _ := ag.attach(attached); again != attached {
+		t.Error("attaching already attached callbacks should keep them")
+	}
+
+	for i := range 50 {
+		attached.OnToolCall(fmt.Sprint(i), "echo", "{}")
+	}
+
+	if approver, ok := attached.(Approver); !ok || !approver.Approve("echo", "{}") {
+		t.Fatal("the attached callbacks' approval should be asked")
+	}
+
+	if cb.seenAtApproval != 50 {
+		t.Errorf("approval should follow the 50 calls before it, followed %d", cb.seenAtApproval)
+	}
+
+	attached.OnToolResult("49", "echo", "done", false)
+	detach()
+	watcher.Close()
+
+	if len(cb.toolResultsCalls) != 1 || cb.calls[49] != "49" {
+		t.Errorf("detach should wait for every event, got %+v", cb.toolResultsCalls)
+	}
+
+	count := 0
+	for range watcher.Events() {
+		count++
+	}
+
+	if count != 51 {
+		t.Errorf("other subscribers should see the same 51 events, saw %d", count)
+	}
+}
+
+func TestAttach_NilCallbacks(t *testing.T) {
+	t.Parallel()
+
+	ag := &Agent{events: NewBus()}
+	watcher := ag.Subscribe(10, OverflowBlock)
+
+	attached, detach := ag.attach(nil)
+	attached.OnText("hello")
+	detach()
+	watcher.Close()
+
+	if ev := <-watcher.Events(); ev.Kind != EventText || ev.Text != "hello" {
+		t.Errorf("subscribers should still see the events, got %+v", ev)
+	}
+}
@@ -0,0 +1,62 @@
+package agent_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// toolLogger reports tool calls and ignores the other events.
+type toolLogger struct {
+	agent.NoCallbacks
+}
+
+func (toolLogger) OnToolCall(_ string, name string, input string) {
+	fmt.Fprintf(os.Stderr, "tool %s %s\n", name, input)
+}
+
+// Example embeds the agent in another program, answering one prompt with the
+// built-in tools.
+func Example() {
+	client := anthropic.NewClient() // reads ANTHROPIC_API_KEY
+
+	a, err := agent.New(client, agent.DefaultConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	resp, err := a.SendMessage(context.Background(), "Which Go packages are in this directory?", toolLogger{})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(resp.Text)
+}
+
+// ExampleAgent_Subscribe follows the agent's events without passing
+// callbacks to each call.
+func ExampleAgent_Subscribe() {
+	a, err := agent.New(anthropic.NewClient(), agent.DefaultConfig())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	events := a.Subscribe(64, agent.OverflowDrop)
+	defer events.Close()
+
+	go func() {
+		for ev := range events.Events() {
+			if ev.Kind == agent.EventToolResult && ev.IsError {
+				log.Printf("%s failed: %s", ev.Name, ev.Output)
+			}
+		}
+	}()
+
+	if _, err := a.SendMessage(context.Background(), "Summarize README.md", nil); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aelse/artoo/conversation"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// maxTitleLen caps the length of automatically generated conversation titles.
+	maxTitleLen = 60
+
+	// titleContextChars caps how much of the first prompt is sent to the title model.
+	titleContextChars = 2000
+)
+
+const titlePrompt = "Below is the first message of a coding session. Reply with a short title " +
+	"for the session of at most 6 words, without quotes or trailing punctuation."
+
+// SetStore enables saving the conversation to store after every exchange
+// and every round of tool calls.
+func (a *Agent) SetStore(store conversation.Store) {
+	a.store = store
+}
+
+// ConversationID returns the ID of the current conversation.
+func (a *Agent) ConversationID() string {
+	return a.conversation.ID()
+}
+
+// ConversationRecord returns a snapshot of the current conversation.
+func (a *Agent) ConversationRecord() conversation.Record {
+	return a.conversation.Record()
+}
+
+// LoadConversation replaces the current conversation with a stored one,
+// keeping the current context management settings.
+func (a *Agent) LoadConversation(record conversation.Record) {
+	a.conversation = conversation.FromRecord(record, a.conversation.Config())
+	a.resetInstructions()
+	a.refreshSystemPrompt()
+}
+
+// NewConversation abandons the current conversation and starts a fresh one,
+// re-rendering the system prompt template.
+func (a *Agent) NewConversation() {
+	a.conversation = conversation.NewWithConfig(a.conversation.Config())
+	a.resetInstructions()
+	a.refreshSystemPrompt()
+
+	a.mu.Lock()
+	a.summary = ""
+	a.mu.Unlock()
+}
+
+// save persists the conversation if a store is set, titling it from the
+// first prompt if it has no title yet. Failures are reported through cb
+// but don't fail the exchange.
+func (a *Agent) save(ctx context.Context, prompt anthropic.MessageParam, cb Callbacks) {
+	if a.store == nil {
+		return
+	}
+
+	if a.conversation.Title() == "" {
+		a.conversation.SetTitle(a.title(ctx, conversation.MessageText(prompt)))
+	}
+
+	if err := a.store.Save(a.conversation.Record()); err != nil {
+		cb.OnToolResult("", "_system", "Failed to save conversation: "+err.Error(), true)
+	}
+}
+
+// title names a session from its first prompt. When SummaryModel is set a
+// cheap model call writes the title; otherwise, or if that call fails, the
+// start of the prompt is used.
+func (a *Agent) title(ctx context.Context, text string) string {
+	if a.config.SummaryModel != "" {
+		ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+		defer cancel()
+
+		sample := text
+		if r := []rune(sample); len(r) > titleContextChars {
+			sample = string(r[:titleContextChars])
+		}
+
+		if t, err := a.complete(ctx, titlePrompt+"\n\n"+sample); err == nil {
+			if t = strings.Trim(oneLine(t, maxTitleLen), `"'. `); t != "" {
+				return t
+			}
+		}
+	}
+
+	return autoTitle(text)
+}
+
+// autoTitle returns the first maxTitleLen characters of text on one line,
+// truncated at a word boundary.
+func autoTitle(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+
+	runes := []rune(text)
+	if len(runes) <= maxTitleLen {
+		return text
+	}
+
+	title := string(runes[:maxTitleLen])
+	if i := strings.LastIndex(title, " "); i > 0 {
+		title = title[:i]
+	}
+
+	return title + "…"
+}
@@ -0,0 +1,25 @@
+package agent
+
+import "github.com/aelse/artoo/tool"
+
+// InjectionWarner is optionally implemented by Callbacks to warn the user
+// when a tool returns untrusted content, such as a fetched page, that looks
+// like a prompt injection. It is called before the content is added to the
+// conversation.
+type InjectionWarner interface {
+	// OnSuspectedInjection is called with the suspect content a call of tool
+	// name returned.
+	OnSuspectedInjection(name string, found []tool.Injection)
+}
+
+// warnInjections tells cb about suspected prompt injections in output.
+func warnInjections(name, output string, cb Callbacks) {
+	warner, ok := cb.(InjectionWarner)
+	if !ok {
+		return
+	}
+
+	if found := tool.SuspectedInjections(output); len(found) > 0 {
+		warner.OnSuspectedInjection(name, found)
+	}
+}
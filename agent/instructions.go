@@ -0,0 +1,71 @@
+package agent
+
+import (
+	"encoding/json"
+
+	"github.com/aelse/artoo/instructions"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// pathKeys are tool input fields treated as filesystem paths when looking
+// for nested instruction files. A list of objects, such as write_files'
+// files, contributes the path of each.
+var pathKeys = []string{"path", "file_path", "paths", "files", "source", "destination"}
+
+// touchedInstructions returns rendered instruction files for directories
+// touched by the given tool calls that haven't been added to context yet.
+func (a *Agent) touchedInstructions(blocks []anthropic.ToolUseBlock) string {
+	if a.instructions == nil {
+		return ""
+	}
+
+	var files []instructions.File
+
+	for _, block := range blocks {
+		for _, path := range inputPaths(block.Input) {
+			files = append(files, a.instructions.Touch(path)...)
+		}
+	}
+
+	return instructions.Render(files)
+}
+
+// resetInstructions makes nested instruction files be added again the next
+// time their directories are touched, for a conversation that no longer has
+// them.
+func (a *Agent) resetInstructions() {
+	if a.instructions != nil {
+		a.instructions.Reset()
+	}
+}
+
+// inputPaths extracts path-like string values from a tool's JSON input.
+func inputPaths(input json.RawMessage) []string {
+	var fields map[string]any
+	if err := json.Unmarshal(input, &fields); err != nil {
+		return nil
+	}
+
+	var paths []string
+
+	for _, key := range pathKeys {
+		switch v := fields[key].(type) {
+		case string:
+			if v != "" {
+				paths = append(paths, v)
+			}
+		case []any:
+			for _, item := range v {
+				if obj, ok := item.(map[string]any); ok {
+					item = obj["path"]
+				}
+
+				if s, ok := item.(string); ok && s != "" {
+					paths = append(paths, s)
+				}
+			}
+		}
+	}
+
+	return paths
+}
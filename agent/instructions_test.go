@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/instructions"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestInputPaths(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{`{"pattern": "foo", "path": "services/api"}`, []string{"services/api"}},
+		{`{"paths": ["a.go", "b/c.go", 3]}`, []string{"a.go", "b/c.go"}},
+		{`{"file_path": "/abs/x.go", "path": ""}`, []string{"/abs/x.go"}},
+		{`{"files": [{"path": "api/a.go", "content": "x"}, {"content": "y"}]}`, []string{"api/a.go"}},
+		{`{"source": "old/a.go", "destination": "api/a.go"}`, []string{"old/a.go", "api/a.go"}},
+		{`{"min": 1, "max": 2}`, nil},
+		{`not json`, nil},
+	}
+
+	for _, tt := range tests {
+		if got := inputPaths(json.RawMessage(tt.input)); !slices.Equal(got, tt.want) {
+			t.Errorf("inputPaths(%s) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestNewConversation_ResetsInstructions(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "api"), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(root, "api", "AGENTS.md"), []byte("api rules"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	set, err := instructions.Discover(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ag := mustNew(t, anthropic.NewClient(), Config{})
+	ag.SetInstructions(set)
+
+	blocks := []anthropic.ToolUseBlock{{Name: "read_many", Input: json.RawMessage(`{"paths": ["api/handler.go"]}`)}}
+	if text := ag.touchedInstructions(blocks); !strings.Contains(text, "api rules") {
+		t.Fatalf("expected the nested instructions, got %q", text)
+	}
+
+	if text := ag.touchedInstructions(blocks); text != "" {
+		t.Fatalf("instructions already in context should not be added again, got %q", text)
+	}
+
+	ag.NewConversation()
+
+	if text := ag.touchedInstructions(blocks); !strings.Contains(text, "api rules") {
+		t.Errorf("a new conversation should get the nested instructions again, got %q", text)
+	}
+}
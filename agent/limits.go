@@ -0,0 +1,89 @@
+package agent
+
+import (
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// longContextBeta is the beta that enables the long context window.
+const longContextBeta = "context-1m-2025-08-07"
+
+// ModelLimits are the token limits of a model.
+type ModelLimits struct {
+	ContextWindow     int   // input and output tokens of one request
+	MaxOutputTokens   int64 // largest max_tokens the model accepts
+	LongContextWindow int   // window with the long-context beta (0 if the model has none)
+}
+
+// LongContext returns the limits with the long-context beta enabled, and
+// whether the model supports it.
+func (l ModelLimits) LongContext() (ModelLimits, bool) {
+	if l.LongContextWindow == 0 {
+		return l, false
+	}
+
+	l.ContextWindow = l.LongContextWindow
+
+	return l, true
+}
+
+// ContextBudget is how many tokens of the window the conversation may use,
+// leaving a tenth as headroom for the response and estimation error.
+func (l ModelLimits) ContextBudget() int {
+	return l.ContextWindow * 9 / 10
+}
+
+// modelLimits are matched against the model name by prefix; the first match
+// applies, so longer prefixes come first.
+var modelLimits = []struct {
+	prefix string
+	limits ModelLimits
+}{
+	{"claude-opus-4-5", ModelLimits{200_000, 64_000, 0}},
+	{"claude-opus-4", ModelLimits{200_000, 32_000, 0}},
+	{"claude-sonnet-4", ModelLimits{200_000, 64_000, 1_000_000}},
+	{"claude-haiku-4-5", ModelLimits{200_000, 64_000, 0}},
+	{"claude-3-7-sonnet", ModelLimits{200_000, 64_000, 0}},
+	{"claude-3-5-sonnet", ModelLimits{200_000, 8192, 0}},
+	{"claude-3-5-haiku", ModelLimits{200_000, 8192, 0}},
+	{"claude-3-opus", ModelLimits{200_000, 4096, 0}},
+	{"claude-3-haiku", ModelLimits{200_000, 4096, 0}},
+}
+
+// LimitsOf returns the token limits of model and whether they are known.
+func LimitsOf(model string) (ModelLimits, bool) {
+	for _, m := range modelLimits {
+		if strings.HasPrefix(model, m.prefix) {
+			return m.limits, true
+		}
+	}
+
+	return ModelLimits{}, false
+}
+
+// maxTokens returns the configured max_tokens, capped at what the model
+// accepts so a switch to a smaller model does not fail every request.
+func (a *Agent) maxTokens() int64 {
+	if limits, ok := LimitsOf(a.config.Model); ok {
+		return min(a.config.MaxTokens, limits.MaxOutputTokens)
+	}
+
+	return a.config.MaxTokens
+}
+
+// requestOptions returns the per-request options for the model: the
+// long-context beta header when Config.LongContext is set and the model
+// supports it.
+func (a *Agent) requestOptions() []option.RequestOption {
+	if !a.config.LongContext {
+		return nil
+	}
+
+	limits, _ := LimitsOf(a.config.Model)
+	if _, ok := limits.LongContext(); !ok {
+		return nil
+	}
+
+	return []option.RequestOption{option.WithHeaderAdd("anthropic-beta", longContextBeta)}
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+func TestLimitsOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model  string
+		output int64
+		ok     bool
+	}{
+		{"claude-opus-4-5-20251101", 64_000, true},
+		{"claude-opus-4-1-20250805", 32_000, true},
+		{"claude-sonnet-4-20250514", 64_000, true},
+		{"claude-3-haiku-20240307", 4096, true},
+		{"qwen3-coder", 0, false},
+	}
+
+	for _, tt := range tests {
+		limits, ok := LimitsOf(tt.model)
+		if ok != tt.ok || limits.MaxOutputTokens != tt.output {
+			t.Errorf("LimitsOf(%q) = %+v, %v; want max output %d, %v", tt.model, limits, ok, tt.output, tt.ok)
+		}
+	}
+
+	if got := (ModelLimits{ContextWindow: 200_000}).ContextBudget(); got != 180_000 {
+		t.Errorf("ContextBudget() = %d, want 180000", got)
+	}
+}
+
+func TestAgent_MaxTokensCappedByModel(t *testing.T) {
+	t.Parallel()
+
+	a := &Agent{config: Config{Model: "claude-3-haiku-20240307", MaxTokens: 8192}}
+	if got := a.maxTokens(); got != 4096 {
+		t.Errorf("maxTokens() = %d, want the model's 4096", got)
+	}
+
+	a.config.Model = "local-model"
+	if got := a.maxTokens(); got != 8192 {
+		t.Errorf("maxTokens() for an unknown model = %d, want the configured 8192", got)
+	}
+}
+
+func TestSendMessage_LongContextBeta(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		long  bool
+		want  bool
+	}{
+		{"claude-sonnet-4-20250514", true, true},
+		{"claude-sonnet-4-20250514", false, false},
+		{"claude-opus-4-1-20250805", true, false},
+	}
+
+	for _, tt := range tests {
+		var betas []string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			betas = r.Header.Values("anthropic-beta")
+
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id":"m1","type":"message","role":"assistant","model":"m","stop_reason":"end_turn",
+				"content":[{"type":"text","text":"ok"}],"usage":{"input_tokens":10,"output_tokens":3}}`))
+		}))
+
+		client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"))
+		ag := mustNew(t, client, Config{Model: tt.model, MaxTokens: 100, MaxConcurrentTools: 1, LongContext: tt.long})
+
+		if _, err := ag.SendMessage(t.Context(), "hi", &mockCallbacks{}); err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+
+		server.Close()
+
+		if got := slices.Contains(betas, longContextBeta); got != tt.want {
+			t.Errorf("%s with LongContext %v: beta header sent = %v, want %v", tt.model, tt.long, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// notesName is the name of the scratchpad tool.
+const notesName = "notes"
+
+// notesParams defines the parameters for the notes tool.
+type notesParams struct {
+	Action string `json:"action"`
+	Text   string `json:"text,omitempty"`
+	Number int    `json:"number,omitempty"`
+}
+
+// notesTool is a per-session scratchpad for intermediate findings. Notes
+// are stored with the conversation rather than in its messages, so they
+// survive context trimming and are restored when a session is resumed.
+type notesTool struct {
+	agent *Agent
+}
+
+// ReadOnly reports true: notes change only the session's scratchpad, so
+// they neither invalidate cached results nor are skipped in a dry run.
+func (t *notesTool) ReadOnly() bool {
+	return true
+}
+
+// Call adds, lists or deletes notes of the current conversation.
+func (t *notesTool) Call(block anthropic.ToolUseBlock) *anthropic.ContentBlockParamUnion {
+	var params notesParams
+	if err := json.Unmarshal(block.Input, &params); err != nil {
+		errMsg := fmt.Sprintf("Error unmarshalling parameters: %v", err)
+
+		return new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+	}
+
+	conv := t.agent.conversation
+
+	switch params.Action {
+	case "add":
+		if strings.TrimSpace(params.Text) == "" {
+			return new(anthropic.NewToolResultBlock(block.ID, "Error: text is required to add a note", true))
+		}
+
+		n := conv.AddNote(strings.TrimSpace(params.Text))
+
+		return new(anthropic.NewToolResultBlock(block.ID, fmt.Sprintf("Saved note %d", n), false))
+
+	case "list":
+		return new(anthropic.NewToolResultBlock(block.ID, formatNotes(conv.Notes()), false))
+
+	case "delete":
+		if !conv.DeleteNote(params.Number) {
+			errMsg := fmt.Sprintf("Error: no note %d", params.Number)
+
+			return new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+		}
+
+		return new(anthropic.NewToolResultBlock(block.ID, fmt.Sprintf("Deleted note %d", params.Number), false))
+	}
+
+	errMsg := fmt.Sprintf("Error: unknown action %q (want add, list or delete)", params.Action)
+
+	return new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+}
+
+// formatNotes numbers notes from 1.
+func formatNotes(notes []string) string {
+	if len(notes) == 0 {
+		return "No notes yet."
+	}
+
+	var b strings.Builder
+	for i, note := range notes {
+		fmt.Fprintf(&b, "%d. %s\n", i+1, note)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// Param describes the notes tool.
+func (t *notesTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: notesName,
+		Description: anthropic.String("Session scratchpad for intermediate findings such as call graphs, " +
+			"hypotheses and where things live. Notes are kept even when older messages are dropped from " +
+			"context, so list them before rediscovering something in a long session."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"add", "list", "delete"},
+					"description": "add a note, list all notes, or delete a note by number",
+				},
+				"text": map[string]any{
+					"type":        "string",
+					"description": "Note to add (for add)",
+				},
+				"number": map[string]any{
+					"type":        "integer",
+					"description": "Number of the note to delete, as shown by list (for delete)",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestNotesTool(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{})
+
+	if !slices.Contains(toolNames(ag.toolParams()), notesName) {
+		t.Fatalf("%s should be offered by default", notesName)
+	}
+
+	call := func(input string) (string, bool) {
+		t.Helper()
+
+		notes, _ := ag.registry.Lookup(notesName)
+		result := notes.Call(anthropic.ToolUseBlock{ID: "id", Name: notesName, Input: json.RawMessage(input)})
+
+		return result.OfToolResult.Content[0].OfText.Text, result.OfToolResult.IsError.Value
+	}
+
+	if text, isErr := call(`{"action": "add", "text": "parser lives in cmd/parse"}`); isErr || text != "Saved note 1" {
+		t.Errorf("add = %q, %v", text, isErr)
+	}
+
+	call(`{"action": "add", "text": "hypothesis: cache key ignores mtime"}`)
+
+	if text, _ := call(`{"action": "list"}`); text != "1. parser lives in cmd/parse\n2. hypothesis: cache key ignores mtime" {
+		t.Errorf("list = %q", text)
+	}
+
+	if _, isErr := call(`{"action": "delete", "number": 1}`); isErr {
+		t.Error("deleting note 1 should succeed")
+	}
+
+	if _, isErr := call(`{"action": "delete", "number": 5}`); !isErr {
+		t.Error("deleting a missing note should fail")
+	}
+
+	if _, isErr := call(`{"action": "add", "text": "  "}`); !isErr {
+		t.Error("adding an empty note should fail")
+	}
+
+	if text, _ := call(`{"action": "list"}`); text != "1. hypothesis: cache key ignores mtime" {
+		t.Errorf("list after delete = %q", text)
+	}
+
+	// Notes are saved with the conversation
+	if notes := ag.conversation.Record().Notes; len(notes) != 1 {
+		t.Errorf("record notes = %v, want 1 note", notes)
+	}
+}
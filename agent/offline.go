@@ -0,0 +1,99 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/url"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Fallback is a model the agent switches to for the rest of the session when
+// the API cannot be reached, such as a local model served by Ollama through
+// its Anthropic-compatible API.
+type Fallback struct {
+	Client   anthropic.Client
+	Model    string
+	OnSwitch func(err error) // called once, with the error that caused the switch (nil is fine)
+}
+
+// SetFallback sets the model to switch to when the API cannot be reached.
+// nil disables switching.
+func (a *Agent) SetFallback(f *Fallback) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.fallback = f
+}
+
+// Offline reports whether the agent has switched to its fallback model.
+func (a *Agent) Offline() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.offline
+}
+
+// IsUnreachable reports whether err is a failure to reach the API at all, such
+// as a refused connection or a failed DNS lookup, rather than an error the
+// API returned or a cancellation.
+func IsUnreachable(err error) bool {
+	var apiErr *anthropic.Error
+	if err == nil || errors.As(err, &apiErr) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var netErr net.Error
+	var urlErr *url.Error
+
+	return errors.As(err, &netErr) || errors.As(err, &urlErr)
+}
+
+// switchToFallback switches to the fallback model if err means the API cannot
+// be reached and there is a fallback not yet in use. It reports whether the
+// request should be retried.
+func (a *Agent) switchToFallback(err error) bool {
+	if !IsUnreachable(err) {
+		return false
+	}
+
+	a.mu.Lock()
+	f := a.fallback
+
+	if f == nil || a.offline {
+		a.mu.Unlock()
+
+		return false
+	}
+
+	a.client = f.Client
+	a.config.Model = f.Model
+	a.offline = true
+	a.mu.Unlock()
+
+	if f.OnSwitch != nil {
+		f.OnSwitch(err)
+	}
+
+	return true
+}
+
+// offlineTools leaves out the server tools, such as web search, of tools when
+// the agent has switched to its fallback model, which cannot run them.
+func (a *Agent) offlineTools(tools []anthropic.ToolUnionParam) []anthropic.ToolUnionParam {
+	if !a.Offline() {
+		return tools
+	}
+
+	local := make([]anthropic.ToolUnionParam, 0, len(tools))
+
+	for _, t := range tools {
+		if t.OfTool != nil {
+			local = append(local, t)
+		}
+	}
+
+	return local
+}
@@ -0,0 +1,139 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// closedURL returns the URL of a server that is no longer listening.
+func closedURL(t *testing.T) string {
+	t.Helper()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	return server.URL
+}
+
+func TestIsUnreachable(t *testing.T) {
+	t.Parallel()
+
+	resp, connErr := http.Get(closedURL(t)) //nolint:noctx // a refused connection
+	if connErr == nil {
+		_ = resp.Body.Close()
+		t.Fatal("request to a closed server succeeded")
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"refused", connErr, true},
+		{"wrapped", fmt.Errorf("sending: %w", connErr), true},
+		{"api error", &anthropic.Error{StatusCode: http.StatusTooManyRequests}, false},
+		{"canceled", errors.Join(connErr, context.Canceled), false},
+		{"other", errors.New("boom"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsUnreachable(tt.err); got != tt.want {
+			t.Errorf("%s: IsUnreachable(%v) = %v, want %v", tt.name, tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestSendMessage_SwitchesToFallback(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		Model string           `json:"model"`
+		Tools []map[string]any `json:"tools"`
+	}
+
+	var requests []request
+
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req request
+
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+		requests = append(requests, req)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"m1","type":"message","role":"assistant","model":"local","stop_reason":"end_turn",
+			"content":[{"type":"text","text":"offline answer"}],"usage":{"input_tokens":10,"output_tokens":3}}`))
+	}))
+	t.Cleanup(local.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(closedURL(t)), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	ag := mustNew(t, client,
+		Config{Model: "claude", MaxTokens: 100, MaxConcurrentTools: 1, ServerTools: []string{"web_search"}})
+
+	switches := 0
+	ag.SetFallback(&Fallback{
+		Client:   anthropic.NewClient(option.WithBaseURL(local.URL), option.WithAPIKey("offline")),
+		Model:    "local",
+		OnSwitch: func(error) { switches++ },
+	})
+
+	for range 2 {
+		resp, err := ag.SendMessage(t.Context(), "hi", &mockCallbacks{})
+		if err != nil {
+			t.Fatalf("SendMessage: %v", err)
+		}
+
+		if resp.Text != "offline answer" {
+			t.Errorf("Text = %q, want the local model's answer", resp.Text)
+		}
+	}
+
+	if switches != 1 || !ag.Offline() {
+		t.Errorf("switched %d times, Offline = %v; want one switch", switches, ag.Offline())
+	}
+
+	for i, req := range requests {
+		if req.Model != "local" {
+			t.Errorf("request %d model = %q, want local", i, req.Model)
+		}
+
+		for _, tl := range req.Tools {
+			if tl["name"] == "web_search" {
+				t.Errorf("request %d offers the web_search server tool", i)
+			}
+		}
+	}
+}
+
+func TestSendMessage_NoFallbackForAPIErrors(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"), option.WithMaxRetries(0))
+	ag := mustNew(t, client, Config{Model: "claude", MaxTokens: 100, MaxConcurrentTools: 1})
+	ag.SetFallback(&Fallback{Client: client, Model: "local"})
+
+	if _, err := ag.SendMessage(t.Context(), "hi", &mockCallbacks{}); err == nil {
+		t.Fatal("SendMessage succeeded, want the API error")
+	}
+
+	if ag.Offline() {
+		t.Error("switched to the fallback on an API error")
+	}
+}
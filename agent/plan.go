@@ -0,0 +1,104 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// dryRunNotice tells the model that mutating tools won't run.
+	dryRunNotice = "This is a dry run. Read-only tools run normally, but tools that modify files " +
+		"or other state are not executed. Call them exactly as you would for real and assume they succeed, " +
+		"then finish with a short summary of the plan."
+
+	// dryRunResult is returned to the model in place of a mutating tool's output.
+	dryRunResult = "Dry run: not executed. Assume this call succeeded."
+)
+
+// Plan is the outcome of a dry-run turn: the mutating tool calls the agent
+// would have made, in order, and its closing summary.
+type Plan struct {
+	Steps   []PlanStep `json:"steps"`
+	Summary string     `json:"summary"`
+}
+
+// PlanStep is one planned (not executed) tool call.
+type PlanStep struct {
+	Tool   string          `json:"tool"`
+	Input  json.RawMessage `json:"input"`
+	Reason string          `json:"reason,omitempty"` // assistant text preceding the call
+}
+
+// Plan runs a turn for text in dry-run mode: read-only tools execute so the
+// model can investigate, but calls to any other tool are recorded in the
+// returned plan instead of being executed.
+func (a *Agent) Plan(ctx context.Context, text string, cb Callbacks) (*Plan, error) {
+	a.mu.Lock()
+	a.dryRun = true
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		a.dryRun = false
+		a.mu.Unlock()
+	}()
+
+	cb, detach := a.attach(cb)
+	defer detach()
+
+	rec := &planRecorder{agent: a, plan: &Plan{Steps: []PlanStep{}}}
+	stop := follow(a.Subscribe(callbackBuffer, OverflowBlock), rec.record)
+
+	resp, err := a.SendBlocks(ctx, cb, anthropic.NewTextBlock(text), anthropic.NewTextBlock(dryRunNotice))
+
+	stop()
+
+	if err != nil {
+		return nil, err
+	}
+
+	rec.plan.Summary = resp.Text
+
+	return rec.plan, nil
+}
+
+// skipsExecution reports whether a call to t must be skipped because a dry
+// run is in progress or the autonomy level is suggest, and t may modify state.
+func (a *Agent) skipsExecution(t tool.Tool) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return (a.dryRun || a.autonomy == AutonomySuggest) && !tool.IsReadOnly(t)
+}
+
+// planRecorder records mutating tool calls into a plan. It follows the
+// agent's events on a single goroutine, in response order, so no locking is
+// needed.
+type planRecorder struct {
+	agent    *Agent
+	plan     *Plan
+	lastText string
+}
+
+func (p *planRecorder) record(ev Event) {
+	switch ev.Kind {
+	case EventText:
+		p.lastText = ev.Text
+	case EventToolCall:
+		t, exists := p.agent.registry.Lookup(ev.Name)
+		if !exists || tool.IsReadOnly(t) {
+			return
+		}
+
+		p.plan.Steps = append(p.plan.Steps, PlanStep{
+			Tool:   ev.Name,
+			Input:  json.RawMessage(ev.Input),
+			Reason: p.lastText,
+		})
+		p.lastText = ""
+	default:
+	}
+}
@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestExecuteToolsConcurrently_DryRun(t *testing.T) {
+	t.Parallel()
+
+	reader := &readOnlyTool{mockTool: mockTool{name: "reader"}}
+	writer := &mockTool{name: "writer"}
+
+	ag := &Agent{
+		config:   Config{MaxConcurrentTools: 2},
+		dryRun:   true,
+		registry: registryOf(reader, writer),
+	}
+
+	blocks := []anthropic.ToolUseBlock{
+		{ID: "id1", Name: "reader", Input: json.RawMessage(`{}`)},
+		{ID: "id2", Name: "writer", Input: json.RawMessage(`{}`)},
+	}
+
+	results := ag.executeToolsConcurrently(t.Context(), blocks, &mockCallbacks{})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+
+	if reader.callCount != 1 {
+		t.Errorf("expected read-only tool to run, got %d calls", reader.callCount)
+	}
+
+	if writer.callCount != 0 {
+		t.Errorf("expected writing tool to be skipped, got %d calls", writer.callCount)
+	}
+
+	if got := results[1].OfToolResult.Content[0].OfText.Text; got != dryRunResult {
+		t.Errorf("expected dry-run result, got %q", got)
+	}
+}
+
+func TestPlanRecorder_RecordsMutatingCalls(t *testing.T) {
+	t.Parallel()
+
+	ag := &Agent{registry: registryOf(
+		&readOnlyTool{mockTool: mockTool{name: "reader"}},
+		&mockTool{name: "writer"},
+	)}
+
+	pc := &planRecorder{agent: ag, plan: &Plan{}}
+
+	pc.record(Event{Kind: EventText, Text: "Let me look first."})
+	pc.record(Event{Kind: EventToolCall, ToolID: "1", Name: "reader", Input: `{"input":"a"}`})
+	pc.record(Event{Kind: EventText, Text: "Now write the file."})
+	pc.record(Event{Kind: EventToolCall, ToolID: "2", Name: "writer", Input: `{"input":"b"}`})
+	pc.record(Event{Kind: EventToolCall, ToolID: "3", Name: "unknown", Input: `{}`})
+
+	if len(pc.plan.Steps) != 1 {
+		t.Fatalf("expected 1 planned step, got %+v", pc.plan.Steps)
+	}
+
+	step := pc.plan.Steps[0]
+	if step.Tool != "writer" || string(step.Input) != `{"input":"b"}` || step.Reason != "Now write the file." {
+		t.Errorf("unexpected step: %+v", step)
+	}
+}
@@ -0,0 +1,95 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// gitBranchTimeout bounds the git call made when rendering the system prompt.
+const gitBranchTimeout = 2 * time.Second
+
+// scratchNotice tells the model where the session's scratch directory is.
+const scratchNotice = "Write temporary files, such as patches, test fixtures and throwaway scripts, to the " +
+	"scratch directory %s (also $%s) rather than the workspace. It is deleted when the session ends."
+
+// PromptData holds the values available to system prompt templates.
+type PromptData struct {
+	CWD       string // working directory
+	GitBranch string // current git branch ("" outside a repository)
+	Date      string // today's date, YYYY-MM-DD
+	OS        string // operating system, e.g. "linux" or "darwin"
+}
+
+// currentPromptData collects the prompt template values for this process.
+func currentPromptData(now time.Time) PromptData {
+	cwd, _ := os.Getwd()
+
+	return PromptData{
+		CWD:       cwd,
+		GitBranch: gitBranch(),
+		Date:      now.Format(time.DateOnly),
+		OS:        runtime.GOOS,
+	}
+}
+
+// gitBranch returns the checked-out branch, or "" if there is none.
+func gitBranch() string {
+	ctx, cancel := context.WithTimeout(context.Background(), gitBranchTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// parseSystemPrompt parses text as a Go template over PromptData.
+func parseSystemPrompt(text string) (*template.Template, error) {
+	return template.New("system prompt").Option("missingkey=error").Parse(text)
+}
+
+// ValidateSystemPrompt reports template errors in a system prompt, such as
+// unknown placeholders.
+func ValidateSystemPrompt(text string) error {
+	tmpl, err := parseSystemPrompt(text)
+	if err != nil {
+		return err
+	}
+
+	return tmpl.Execute(&strings.Builder{}, PromptData{})
+}
+
+// renderSystemPrompt fills in the placeholders of a system prompt template.
+// A prompt that fails to render is returned unchanged.
+func renderSystemPrompt(text string, data PromptData) string {
+	tmpl, err := parseSystemPrompt(text)
+	if err != nil {
+		return text
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return text
+	}
+
+	return b.String()
+}
+
+// refreshSystemPrompt renders the configured system prompt for a new session.
+// Prompts without placeholders are used as is, without running git.
+func (a *Agent) refreshSystemPrompt() {
+	if !strings.Contains(a.config.SystemPrompt, "{{") {
+		a.systemPrompt = a.config.SystemPrompt
+
+		return
+	}
+
+	a.systemPrompt = renderSystemPrompt(a.config.SystemPrompt, currentPromptData(time.Now()))
+}
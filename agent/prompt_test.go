@@ -0,0 +1,58 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestRenderSystemPrompt(t *testing.T) {
+	t.Parallel()
+
+	data := PromptData{CWD: "/src/app", GitBranch: "main", Date: "2026-03-14", OS: "linux"}
+
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"Plain prompt", "Plain prompt"},
+		{"In {{.CWD}} on {{.GitBranch}} ({{.OS}}), today is {{.Date}}.", "In /src/app on main (linux), today is 2026-03-14."},
+		{"{{if .GitBranch}}Branch {{.GitBranch}}{{end}}", "Branch main"},
+		// Broken templates are sent as written
+		{"Unknown {{.Nope}}", "Unknown {{.Nope}}"},
+		{"Unclosed {{.CWD", "Unclosed {{.CWD"},
+	}
+
+	for _, tt := range tests {
+		if got := renderSystemPrompt(tt.text, data); got != tt.want {
+			t.Errorf("renderSystemPrompt(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestValidateSystemPrompt(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidateSystemPrompt("Date: {{.Date}}"); err != nil {
+		t.Errorf("valid template: %v", err)
+	}
+
+	for _, text := range []string{"{{.Branch}}", "{{.CWD"} {
+		if err := ValidateSystemPrompt(text); err == nil {
+			t.Errorf("%q: expected an error", text)
+		}
+	}
+}
+
+func TestNewConversation_RendersSystemPrompt(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{SystemPrompt: "OS is {{.OS}}"})
+	ag.systemPrompt = "stale"
+
+	ag.NewConversation()
+
+	if blocks := ag.systemBlocks(); len(blocks) == 0 || blocks[0].Text == "stale" || blocks[0].Text == "OS is {{.OS}}" {
+		t.Errorf("system prompt not re-rendered: %+v", blocks)
+	}
+}
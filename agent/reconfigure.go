@@ -0,0 +1,39 @@
+package agent
+
+import "github.com/aelse/artoo/conversation"
+
+// Reconfigure applies the settings of config that can change mid-session,
+// and conv to the current conversation, keeping its messages. The settings
+// that decide which tools the agent was built with (PluginDir,
+// PluginTimeout, DeferTools, ToolCacheTTL) and Streaming keep their values
+// until a new Agent is created. Call it between turns.
+func (a *Agent) Reconfigure(config Config, conv conversation.Config) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.config.Model = config.Model
+	a.config.MaxTokens = config.MaxTokens
+	a.config.MaxConcurrentTools = config.MaxConcurrentTools
+	a.config.SummaryModel = config.SummaryModel
+	a.config.StopSequences = config.StopSequences
+	a.config.Prefill = config.Prefill
+	a.config.ServerTools = config.ServerTools
+	a.config.Tools = config.Tools
+	a.config.SystemPrompt = config.SystemPrompt
+	a.config.Autonomy = config.Autonomy
+	a.config.LongContext = config.LongContext
+	a.config.FineGrained = config.FineGrained
+	a.config.CancelOnFailure = config.CancelOnFailure
+	a.config.VerifyCommand = config.VerifyCommand
+	a.config.VerifyRetries = config.VerifyRetries
+
+	a.autonomy = config.Autonomy
+	if a.autonomy == "" {
+		a.autonomy = AutonomyFullAuto
+	}
+
+	a.allowed = allowedSet(config.Tools)
+	a.rebuildToolParams()
+	a.refreshSystemPrompt()
+	a.conversation.SetConfig(conv)
+}
@@ -0,0 +1,68 @@
+package agent
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/conversation"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestReconfigure(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{Model: "old-model", PluginDir: "/plugins"})
+	conv := ag.conversation
+
+	ag.Reconfigure(Config{
+		Model:     "new-model",
+		MaxTokens: 2048,
+		Autonomy:  AutonomySuggest,
+		Tools:     []string{"grep"},
+		PluginDir: "/elsewhere",
+	}, conversation.Config{MaxContextTokens: 50_000, ToolResultMaxChars: 2_000})
+
+	if ag.config.Model != "new-model" || ag.config.MaxTokens != 2048 || ag.Autonomy() != AutonomySuggest {
+		t.Errorf("live settings not applied: %+v, autonomy %s", ag.config, ag.Autonomy())
+	}
+
+	if ag.config.PluginDir != "/plugins" {
+		t.Errorf("PluginDir needs a new agent, got %q", ag.config.PluginDir)
+	}
+
+	if names := toolNames(ag.toolParams()); slices.Contains(names, "write_files") {
+		t.Errorf("tools outside the new allowed set should not be offered, got %v", names)
+	}
+
+	if ag.conversation != conv || conv.Config().MaxContextTokens != 50_000 {
+		t.Error("the conversation should be kept, with the new limits")
+	}
+
+	ag.Reconfigure(Config{}, conversation.Config{})
+
+	if ag.Autonomy() != AutonomyFullAuto {
+		t.Errorf("empty autonomy should mean full-auto, got %s", ag.Autonomy())
+	}
+}
+
+func TestReconfigure_VerifyCommand(t *testing.T) {
+	t.Parallel()
+
+	ag := newVerifyAgent(t, "echo old gate; exit 1", 2, verifyToolUse, verifyDone)
+	ag.Reconfigure(Config{
+		MaxTokens:          100,
+		MaxConcurrentTools: 1,
+		VerifyCommand:      "echo new gate; exit 1",
+		VerifyRetries:      0,
+	}, ag.conversation.Config())
+
+	resp, err := ag.SendMessage(t.Context(), "fix it", &mockCallbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.VerifyFailed, "new gate") {
+		t.Errorf("the reloaded verification command should run, got %q", resp.VerifyFailed)
+	}
+}
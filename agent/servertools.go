@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// webSearchMaxUses caps the searches the model may run per request.
+const webSearchMaxUses = 5
+
+// serverTools builds the tool params of the Anthropic server tools that can
+// be enabled with Config.ServerTools. Server tools are executed by the API;
+// their calls and results arrive as content blocks of the response.
+var serverTools = map[string]func() anthropic.ToolUnionParam{
+	"web_search": func() anthropic.ToolUnionParam {
+		return anthropic.ToolUnionParam{OfWebSearchTool20250305: &anthropic.WebSearchTool20250305Param{
+			MaxUses: anthropic.Int(webSearchMaxUses),
+		}}
+	},
+}
+
+var errUnknownServerTool = errors.New("unknown server tool")
+
+// ValidateServerTools returns an error naming the first entry of names that
+// is not a supported server tool.
+func ValidateServerTools(names []string) error {
+	supported := make([]string, 0, len(serverTools))
+	for name := range serverTools {
+		supported = append(supported, name)
+	}
+
+	slices.Sort(supported)
+
+	for _, name := range names {
+		if _, ok := serverTools[name]; !ok {
+			return fmt.Errorf("%w: %q (supported: %s)", errUnknownServerTool, name, strings.Join(supported, ", "))
+		}
+	}
+
+	return nil
+}
+
+// serverToolParams returns the params of the named server tools, skipping
+// unknown names.
+func serverToolParams(names []string) []anthropic.ToolUnionParam {
+	var params []anthropic.ToolUnionParam
+
+	for _, name := range names {
+		if build, ok := serverTools[name]; ok {
+			params = append(params, build())
+		}
+	}
+
+	return params
+}
+
+// webSearchSummary describes a web search result block for the UI and
+// reports whether the search failed.
+func webSearchSummary(block anthropic.WebSearchToolResultBlock) (string, bool) {
+	if block.Content.ErrorCode != "" {
+		return "Web search failed: " + string(block.Content.ErrorCode), true
+	}
+
+	var b strings.Builder
+
+	for _, result := range block.Content.OfWebSearchResultBlockArray {
+		fmt.Fprintf(&b, "%s <%s>\n", result.Title, result.URL)
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), false
+}
+
+// citations numbers the web sources cited in one response, so that cited
+// text can be followed by markers like "[1]" and the response by a list of
+// sources. Each URL gets one number.
+type citations struct {
+	urls   []string
+	titles []string
+}
+
+// marker returns the marker for a citation of url, numbering it if new.
+// Citations without a URL (e.g. of documents in the prompt) get no marker.
+func (c *citations) marker(url, title string) string {
+	if url == "" {
+		return ""
+	}
+
+	n := slices.Index(c.urls, url)
+	if n < 0 {
+		c.urls = append(c.urls, url)
+		c.titles = append(c.titles, title)
+		n = len(c.urls) - 1
+	}
+
+	return fmt.Sprintf("[%d]", n+1)
+}
+
+// markers returns the markers for the citations of a text block.
+func (c *citations) markers(cites []anthropic.TextCitationUnion) string {
+	var b strings.Builder
+
+	for _, cite := range cites {
+		b.WriteString(c.marker(cite.URL, cite.Title))
+	}
+
+	return b.String()
+}
+
+// footer lists the numbered sources, or returns "" if nothing was cited.
+func (c *citations) footer() string {
+	if len(c.urls) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("\n\nSources:")
+
+	for i, url := range c.urls {
+		title := c.titles[i]
+		if title == "" {
+			title = url
+		}
+
+		fmt.Fprintf(&b, "\n[%d] %s <%s>", i+1, title, url)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,97 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+)
+
+// textCallbacks records the texts and tool results reported.
+type textCallbacks struct {
+	mockCallbacks
+
+	texts []string
+}
+
+func (c *textCallbacks) OnText(text string) { c.texts = append(c.texts, text) }
+
+func TestSendMessage_WebSearchCitations(t *testing.T) {
+	t.Parallel()
+
+	response := `{"id":"m1","type":"message","role":"assistant","model":"m","stop_reason":"end_turn",
+		"content":[
+			{"type":"text","text":"Let me search."},
+			{"type":"server_tool_use","id":"s1","name":"web_search","input":{"query":"go release"}},
+			{"type":"web_search_tool_result","tool_use_id":"s1","content":[
+				{"type":"web_search_result","title":"Go 1.26","url":"https://go.dev/doc/go1.26","encrypted_content":"x","page_age":""}]},
+			{"type":"text","text":"Go 1.26 is out."},
+			{"type":"text","text":" It was released in February","citations":[
+				{"type":"web_search_result_location","url":"https://go.dev/doc/go1.26","title":"Go 1.26","cited_text":"x","encrypted_index":"y"}]},
+			{"type":"text","text":"."}],
+		"usage":{"input_tokens":10,"output_tokens":3}}`
+
+	ag := mustNew(t, newTestClient(t, response), Config{MaxTokens: 100, ServerTools: []string{"web_search"}})
+	cb := &textCallbacks{}
+
+	resp, err := ag.SendMessage(t.Context(), "when was go 1.26 released?", cb)
+	if err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	want := "Go 1.26 is out. It was released in February[1].\n\nSources:\n[1] Go 1.26 <https://go.dev/doc/go1.26>"
+	if resp.Text != want {
+		t.Errorf("text = %q, want %q", resp.Text, want)
+	}
+
+	if len(cb.texts) != 2 || cb.texts[0] != "Let me search." {
+		t.Errorf("expected text before the search and merged answer, got %q", cb.texts)
+	}
+
+	if len(cb.toolResultsCalls) != 1 || cb.toolResultsCalls[0].name != "web_search" ||
+		!strings.Contains(cb.toolResultsCalls[0].output, "https://go.dev/doc/go1.26") {
+		t.Errorf("expected web search result callback, got %+v", cb.toolResultsCalls)
+	}
+}
+
+func TestNew_ServerTools(t *testing.T) {
+	t.Parallel()
+
+	ag := &Agent{config: Config{ServerTools: []string{"web_search", "unknown"}}}
+	ag.rebuildToolParams()
+
+	params := ag.toolParams()
+	if len(params) != 1 || params[0].OfWebSearchTool20250305 == nil {
+		t.Errorf("expected only the web search tool, got %+v", params)
+	}
+}
+
+func TestValidateServerTools(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidateServerTools([]string{"web_search"}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	if err := ValidateServerTools([]string{"code_execution"}); err == nil {
+		t.Error("expected error for unsupported server tool")
+	}
+}
+
+func TestCitations(t *testing.T) {
+	t.Parallel()
+
+	var c citations
+
+	if got := c.footer(); got != "" {
+		t.Errorf("expected no footer without citations, got %q", got)
+	}
+
+	markers := []string{c.marker("https://a", "A"), c.marker("https://b", ""), c.marker("https://a", "A"), c.marker("", "doc")}
+	if got := strings.Join(markers, ","); got != "[1],[2],[1]," {
+		t.Errorf("markers = %q", got)
+	}
+
+	want := "\n\nSources:\n[1] A <https://a>\n[2] https://b <https://b>"
+	if got := c.footer(); got != want {
+		t.Errorf("footer = %q, want %q", got, want)
+	}
+}
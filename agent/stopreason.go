@@ -0,0 +1,15 @@
+package agent
+
+import "fmt"
+
+// maxPauseResumes bounds how often a turn the API paused during long server
+// tool use (stop reason pause_turn) is resumed automatically.
+const maxPauseResumes = 5
+
+// refusalNotice is Response.Unfinished for a response the model declined to give.
+const refusalNotice = "the model declined to respond (stop reason refusal); rephrase the request or start a new conversation"
+
+// pausedNotice is Response.Unfinished for a turn still paused after
+// maxPauseResumes resumes.
+var pausedNotice = fmt.Sprintf("the API paused the turn during server tool use %d times; send a message to let it continue",
+	maxPauseResumes+1)
@@ -0,0 +1,57 @@
+package agent
+
+import (
+	"slices"
+	"testing"
+)
+
+const (
+	pausedResponse = `{"id":"p","type":"message","role":"assistant","model":"m","stop_reason":"pause_turn",
+		"content":[{"type":"text","text":"searching"}],"usage":{"input_tokens":10,"output_tokens":3}}`
+	doneResponse = `{"id":"d","type":"message","role":"assistant","model":"m","stop_reason":"end_turn",
+		"content":[{"type":"text","text":"done"}],"usage":{"input_tokens":10,"output_tokens":3}}`
+	refusalResponse = `{"id":"r","type":"message","role":"assistant","model":"m","stop_reason":"refusal",
+		"content":[],"usage":{"input_tokens":10,"output_tokens":0}}`
+)
+
+func TestSendMessage_StopReasons(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		responses  []string
+		stop       string
+		unfinished string
+	}{
+		{"paused turn resumes", []string{pausedResponse, pausedResponse, doneResponse}, "end_turn", ""},
+		{
+			"paused turn gives up",
+			slices.Repeat([]string{pausedResponse}, maxPauseResumes+1),
+			"pause_turn", pausedNotice,
+		},
+		{"refusal", []string{refusalResponse}, "refusal", refusalNotice},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cb := &turnCallbacks{}
+			ag := mustNew(t, newTestClient(t, tt.responses...), Config{MaxTokens: 100, MaxConcurrentTools: 1})
+
+			resp, err := ag.SendMessage(t.Context(), "hi", cb)
+			if err != nil {
+				t.Fatalf("SendMessage: %v", err)
+			}
+
+			if resp.StopReason != tt.stop || resp.Unfinished != tt.unfinished {
+				t.Errorf("StopReason, Unfinished = %q, %q; want %q, %q", resp.StopReason, resp.Unfinished, tt.stop, tt.unfinished)
+			}
+
+			// Every response was requested, and no more
+			if got := len(cb.events) / 2; got != len(tt.responses) {
+				t.Errorf("%d requests, want %d", got, len(tt.responses))
+			}
+		})
+	}
+}
@@ -0,0 +1,228 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+)
+
+const (
+	// finalAnswerName is the tool the model is forced to call with its answer.
+	finalAnswerName = "final_answer"
+
+	// maxRepairAttempts bounds how often a non-conforming answer is sent back
+	// to the model with the validation errors.
+	maxRepairAttempts = 3
+
+	finalAnswerPrompt = "Now provide your final answer by calling the " + finalAnswerName +
+		" tool. Its input must conform exactly to the tool's schema."
+)
+
+var errInvalidSchema = errors.New("invalid JSON schema")
+
+var (
+	// ErrNoFinalAnswer is returned by SendStructured when the turn ends
+	// without the model submitting an answer.
+	ErrNoFinalAnswer = errors.New("model did not call " + finalAnswerName)
+
+	// ErrSchemaViolation is returned by SendStructured when the model's
+	// answer still fails the schema after the repair attempts.
+	ErrSchemaViolation = errors.New("answer does not conform to schema")
+)
+
+// answerSchema is a compiled JSON schema for a structured final answer.
+// Tool inputs must be objects, so other schemas are wrapped in an object
+// with a single "answer" property and unwrapped after validation.
+type answerSchema struct {
+	raw     map[string]any
+	wrapped bool
+	schema  *jsonschema.Schema
+}
+
+// compileAnswerSchema parses and compiles a JSON schema document.
+func compileAnswerSchema(doc []byte) (*answerSchema, error) {
+	parsed, err := jsonschema.UnmarshalJSON(bytes.NewReader(doc))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidSchema, err)
+	}
+
+	c := jsonschema.NewCompiler()
+	if err := c.AddResource("answer.json", parsed); err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidSchema, err)
+	}
+
+	compiled, err := c.Compile("answer.json")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errInvalidSchema, err)
+	}
+
+	s := &answerSchema{schema: compiled}
+
+	raw, ok := parsed.(map[string]any)
+	if ok && raw["type"] == "object" {
+		s.raw = raw
+	} else {
+		s.wrapped = true
+		s.raw = map[string]any{
+			"type":       "object",
+			"properties": map[string]any{"answer": parsed},
+			"required":   []string{"answer"},
+		}
+	}
+
+	return s, nil
+}
+
+// toolParam returns the final_answer tool whose input schema is the answer schema.
+func (s *answerSchema) toolParam() anthropic.ToolUnionParam {
+	extra := maps.Clone(s.raw)
+	properties := extra["properties"]
+	delete(extra, "properties")
+	delete(extra, "type")
+
+	var required []string
+	if r, ok := extra["required"].([]any); ok {
+		for _, name := range r {
+			if n, ok := name.(string); ok {
+				required = append(required, n)
+			}
+		}
+	} else if r, ok := extra["required"].([]string); ok {
+		required = r
+	}
+	delete(extra, "required")
+
+	return anthropic.ToolUnionParam{OfTool: &anthropic.ToolParam{
+		Name:        finalAnswerName,
+		Description: anthropic.String("Submit the final answer. Call this exactly once, with the complete answer."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties:  properties,
+			Required:    required,
+			ExtraFields: extra,
+		},
+	}}
+}
+
+// validate checks a final_answer tool input against the schema and returns
+// the answer document, unwrapped if the schema was wrapped.
+func (s *answerSchema) validate(input json.RawMessage) (json.RawMessage, error) {
+	if s.wrapped {
+		var w struct {
+			Answer json.RawMessage `json:"answer"`
+		}
+		if err := json.Unmarshal(input, &w); err != nil || w.Answer == nil {
+			return nil, fmt.Errorf("%w: missing \"answer\" property", ErrSchemaViolation)
+		}
+
+		input = w.Answer
+	}
+
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(input))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchemaViolation, err)
+	}
+
+	if err := s.schema.Validate(doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSchemaViolation, err)
+	}
+
+	return input, nil
+}
+
+// SendStructured runs a normal turn for text (tools included), then forces
+// the model to submit its final answer through the final_answer tool with
+// schema as the input schema. Answers that fail validation are returned to
+// the model with the errors, up to maxRepairAttempts times.
+func (a *Agent) SendStructured(
+	ctx context.Context,
+	text string,
+	schema []byte,
+	cb Callbacks,
+) (json.RawMessage, error) {
+	answer, err := compileAnswerSchema(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	cb, detach := a.attach(cb)
+	defer detach()
+
+	resp, err := a.SendMessage(ctx, text, cb)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.Unfinished != "" {
+		return nil, fmt.Errorf("%w: %s", ErrNoFinalAnswer, resp.Unfinished)
+	}
+
+	a.conversation.Append(anthropic.NewUserMessage(anthropic.NewTextBlock(finalAnswerPrompt)))
+
+	var lastErr error
+
+	for range maxRepairAttempts + 1 {
+		params := a.messageParams()
+		params.Tools = append(params.Tools, answer.toolParam())
+		params.ToolChoice = anthropic.ToolChoiceParamOfTool(finalAnswerName)
+
+		turnID := a.nextTurnID()
+		cb.OnTurnStart(turnID)
+
+		cb.OnThinking()
+		message, err := a.client.Messages.New(ctx, params, a.requestOptions()...)
+		cb.OnThinkingDone()
+
+		if err != nil {
+			cb.OnTurnEnd(turnID, Usage{}, "")
+
+			return nil, err
+		}
+
+		a.recordUsage(message)
+		a.conversation.Append(message.ToParam())
+
+		turnUsage := measureUsage(params, message.Usage)
+		turnUsage.OutputTokens = message.Usage.OutputTokens
+		cb.OnTurnEnd(turnID, turnUsage, string(message.StopReason))
+
+		block, ok := finalAnswerBlock(message)
+		if !ok && message.StopReason == anthropic.StopReasonRefusal {
+			return nil, fmt.Errorf("%w: %s", ErrNoFinalAnswer, refusalNotice)
+		}
+
+		if !ok {
+			return nil, ErrNoFinalAnswer
+		}
+
+		result, err := answer.validate(block.Input)
+		if err == nil {
+			a.conversation.Append(anthropic.NewUserMessage(
+				anthropic.NewToolResultBlock(block.ID, "Answer accepted.", false)))
+
+			return result, nil
+		}
+
+		lastErr = err
+		a.conversation.Append(anthropic.NewUserMessage(
+			anthropic.NewToolResultBlock(block.ID, err.Error()+"\nCall "+finalAnswerName+" again with a corrected answer.", true)))
+	}
+
+	return nil, lastErr
+}
+
+// finalAnswerBlock returns the final_answer tool call in message, if any.
+func finalAnswerBlock(message *anthropic.Message) (anthropic.ToolUseBlock, bool) {
+	for _, block := range message.Content {
+		if b, ok := block.AsAny().(anthropic.ToolUseBlock); ok && b.Name == finalAnswerName {
+			return b, true
+		}
+	}
+
+	return anthropic.ToolUseBlock{}, false
+}
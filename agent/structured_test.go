@@ -0,0 +1,98 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestAnswerSchema_Validate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		schema  string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "object schema passes through",
+			schema: `{"type":"object","properties":{"count":{"type":"integer"}},"required":["count"]}`,
+			input:  `{"count":3}`,
+			want:   `{"count":3}`,
+		},
+		{
+			name:    "object schema violation",
+			schema:  `{"type":"object","properties":{"count":{"type":"integer"}},"required":["count"]}`,
+			input:   `{"count":"three"}`,
+			wantErr: true,
+		},
+		{
+			name:   "array schema is unwrapped",
+			schema: `{"type":"array","items":{"type":"string"}}`,
+			input:  `{"answer":["a","b"]}`,
+			want:   `["a","b"]`,
+		},
+		{
+			name:    "wrapped schema missing answer",
+			schema:  `{"type":"array","items":{"type":"string"}}`,
+			input:   `{}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := compileAnswerSchema([]byte(tt.schema))
+			if err != nil {
+				t.Fatalf("compile: %v", err)
+			}
+
+			got, err := s.validate(json.RawMessage(tt.input))
+			if tt.wantErr {
+				if !errors.Is(err, ErrSchemaViolation) {
+					t.Errorf("expected schema violation, got %v", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompileAnswerSchema_Invalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := compileAnswerSchema([]byte(`{"type":`)); !errors.Is(err, errInvalidSchema) {
+		t.Errorf("expected invalid schema error, got %v", err)
+	}
+}
+
+func TestAnswerSchema_ToolParam(t *testing.T) {
+	t.Parallel()
+
+	s, err := compileAnswerSchema([]byte(`{"type":"string","enum":["yes","no"]}`))
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+
+	param := s.toolParam().OfTool
+	if param.Name != finalAnswerName {
+		t.Errorf("expected tool %s, got %s", finalAnswerName, param.Name)
+	}
+
+	if len(param.InputSchema.Required) != 1 || param.InputSchema.Required[0] != "answer" {
+		t.Errorf("expected wrapped schema to require answer, got %v", param.InputSchema.Required)
+	}
+}
@@ -0,0 +1,156 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/conversation"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	summaryMaxTokens     = 60
+	summaryTimeout       = 10 * time.Second
+	summaryMaxLen        = 80   // characters in the displayed one-line summary
+	summaryContextChars  = 4000 // characters of recent transcript sent to the summary model
+	summaryRecentMessage = 6    // number of recent messages considered
+	resultSummaryMaxLen  = 200  // characters in a compressed tool result's summary
+)
+
+const resultSummaryPrompt = "Below is the output of a tool call from earlier in a coding session. In one " +
+	"line of at most 25 words, summarize what it showed, naming the file or command and the key names " +
+	"or findings, e.g. \"read main.go: 180 lines, defines Agent, Run loop\". Reply with the line only."
+
+const summaryPrompt = "Below is the recent transcript of a coding session. In one line of at most " +
+	"12 words, state the task the assistant is currently working on. Reply with the line only."
+
+// Summary returns a one-line description of the current task. It is the
+// last summary produced by UpdateSummary, falling back to the latest user
+// prompt when no summary model is configured.
+func (a *Agent) Summary() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.summary
+}
+
+// UpdateSummary refreshes the rolling task summary. When SummaryModel is set
+// a cheap model call condenses the recent transcript; otherwise, or if that
+// call fails, the most recent user prompt is used.
+func (a *Agent) UpdateSummary(ctx context.Context) string {
+	messages := a.conversation.Snapshot()
+	summary := lastUserPrompt(messages)
+
+	if a.config.SummaryModel != "" {
+		if s, err := a.summarize(ctx, messages); err == nil && s != "" {
+			summary = s
+		}
+	}
+
+	summary = oneLine(summary, summaryMaxLen)
+
+	a.mu.Lock()
+	a.summary = summary
+	a.mu.Unlock()
+
+	return summary
+}
+
+// summarize asks the summary model to condense the tail of the transcript.
+func (a *Agent) summarize(ctx context.Context, messages []anthropic.MessageParam) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+	defer cancel()
+
+	start := max(0, len(messages)-summaryRecentMessage)
+
+	var transcript strings.Builder
+
+	for _, m := range messages[start:] {
+		if text := conversation.MessageText(m); text != "" {
+			transcript.WriteString(string(m.Role) + ": " + text + "\n")
+		}
+	}
+
+	tail := transcript.String()
+	if len(tail) > summaryContextChars {
+		tail = tail[len(tail)-summaryContextChars:]
+	}
+
+	return a.complete(ctx, summaryPrompt+"\n\n"+tail)
+}
+
+// resultSummarizer returns the summarizer for compressing old tool results:
+// the summary model when SummaryModel is set, or nil for local summaries.
+// Failed calls fall back to the local summary.
+func (a *Agent) resultSummarizer(ctx context.Context) conversation.Summarizer {
+	if a.config.SummaryModel == "" {
+		return nil
+	}
+
+	return func(tool, input, output string) string {
+		ctx, cancel := context.WithTimeout(ctx, summaryTimeout)
+		defer cancel()
+
+		if len(output) > summaryContextChars {
+			output = output[:summaryContextChars]
+		}
+
+		s, err := a.complete(ctx, resultSummaryPrompt+"\n\nTool: "+tool+"\nInput: "+input+"\nOutput:\n"+output)
+		if err != nil {
+			return ""
+		}
+
+		return oneLine(s, resultSummaryMaxLen)
+	}
+}
+
+// complete sends a single prompt to the summary model and returns its reply.
+func (a *Agent) complete(ctx context.Context, prompt string) (string, error) {
+	message, err := a.client.Messages.New(ctx, anthropic.MessageNewParams{
+		Model:     anthropic.Model(a.config.SummaryModel),
+		MaxTokens: summaryMaxTokens,
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(prompt)),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	for _, block := range message.Content {
+		if text, ok := block.AsAny().(anthropic.TextBlock); ok {
+			return strings.TrimSpace(text.Text), nil
+		}
+	}
+
+	return "", nil
+}
+
+// lastUserPrompt returns the text of the most recent user prompt, skipping
+// tool results, the instructions that follow them and verification feedback.
+func lastUserPrompt(messages []anthropic.MessageParam) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if !conversation.IsPrompt(messages[i]) {
+			continue
+		}
+
+		if text := conversation.MessageText(messages[i]); text != "" {
+			return text
+		}
+	}
+
+	return ""
+}
+
+// oneLine returns the first line of s, truncated to maxLen runes.
+func oneLine(s string, maxLen int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(s), "\n")
+
+	runes := []rune(strings.TrimSpace(line))
+	if len(runes) > maxLen {
+		return string(runes[:maxLen-1]) + "…"
+	}
+
+	return string(runes)
+}
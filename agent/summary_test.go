@@ -0,0 +1,85 @@
+package agent
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/aelse/artoo/conversation"
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+func TestUpdateSummary_FallsBackToLastPrompt(t *testing.T) {
+	t.Parallel()
+
+	ag := &Agent{conversation: conversation.New()}
+	ag.conversation.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("Fix the flaky plugin timeout test\nand more")))
+	ag.conversation.Append(anthropic.NewAssistantMessage(
+		anthropic.NewToolUseBlock("tu_1", map[string]any{}, "grep"),
+	))
+	ag.conversation.Append(anthropic.NewUserMessage(
+		anthropic.NewToolResultBlock("tu_1", "matches", false),
+		anthropic.NewTextBlock("Instructions from services/api/AGENTS.md"),
+	))
+	ag.conversation.Append(anthropic.NewAssistantMessage(anthropic.NewTextBlock("Fixed")))
+	ag.conversation.Append(conversation.NewFeedbackMessage("The verification command failed"))
+
+	if got := ag.UpdateSummary(t.Context()); got != "Fix the flaky plugin timeout test" {
+		t.Errorf("expected summary from last prompt, got %q", got)
+	}
+
+	if ag.Summary() != "Fix the flaky plugin timeout test" {
+		t.Errorf("Summary should return the last computed summary, got %q", ag.Summary())
+	}
+}
+
+func TestOneLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"  first line  \nsecond", 20, "first line"},
+		{"abcdefghij", 5, "abcd…"},
+		{"日本語のテキスト", 4, "日本語…"},
+	}
+
+	for _, tt := range tests {
+		if got := oneLine(tt.in, tt.max); got != tt.want {
+			t.Errorf("oneLine(%q, %d) = %q, want %q", tt.in, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestResultSummarizer(t *testing.T) {
+	t.Parallel()
+
+	if (&Agent{}).resultSummarizer(t.Context()) != nil {
+		t.Error("resultSummarizer without SummaryModel should be nil, for local summaries")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"m1","type":"message","role":"assistant","model":"haiku","stop_reason":"end_turn",
+			"content":[{"type":"text","text":"read main.go: defines Agent, Run loop\n"}],"usage":{"input_tokens":10,"output_tokens":9}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"))
+	ag := mustNew(t, client, Config{MaxTokens: 100, MaxConcurrentTools: 1, SummaryModel: "haiku"})
+
+	summarize := ag.resultSummarizer(t.Context())
+	if got := summarize("read_file", `{"path":"main.go"}`, "package main"); got != "read main.go: defines Agent, Run loop" {
+		t.Errorf("summary = %q, want the summary model's line", got)
+	}
+
+	server.Close()
+
+	if got := summarize("read_file", `{"path":"main.go"}`, "package main"); got != "" {
+		t.Errorf("summary after a failed call = %q, want empty for the local fallback", got)
+	}
+}
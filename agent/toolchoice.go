@@ -0,0 +1,49 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ToolChoice is how the first response of each turn may use tools; later
+// responses in the turn choose freely, so a forced tool call cannot repeat
+// forever. Besides the constants, a ToolChoice can name the tool the model
+// must call.
+type ToolChoice string
+
+const (
+	ToolChoiceAuto ToolChoice = "auto" // the model decides (the default)
+	ToolChoiceAny  ToolChoice = "any"  // the model must call a tool, any tool
+	ToolChoiceNone ToolChoice = "none" // the model must answer in text
+)
+
+var errUnknownToolChoice = errors.New("tool choice names a tool that is not offered")
+
+// SetToolChoice changes how the first response of subsequent turns may use
+// tools.
+func (a *Agent) SetToolChoice(choice ToolChoice) {
+	a.config.ToolChoice = choice
+}
+
+// toolChoiceParam returns the request's tool_choice for choice, given the
+// tools offered. The zero value leaves the API default, auto.
+func toolChoiceParam(choice ToolChoice, tools []anthropic.ToolUnionParam) (anthropic.ToolChoiceUnionParam, error) {
+	switch choice {
+	case "", ToolChoiceAuto:
+		return anthropic.ToolChoiceUnionParam{}, nil
+	case ToolChoiceAny:
+		return anthropic.ToolChoiceUnionParam{OfAny: &anthropic.ToolChoiceAnyParam{}}, nil
+	case ToolChoiceNone:
+		return anthropic.ToolChoiceUnionParam{OfNone: &anthropic.ToolChoiceNoneParam{}}, nil
+	}
+
+	for _, t := range tools {
+		if name := t.GetName(); name != nil && *name == string(choice) {
+			return anthropic.ToolChoiceParamOfTool(*name), nil
+		}
+	}
+
+	return anthropic.ToolChoiceUnionParam{}, fmt.Errorf("%w: %q", errUnknownToolChoice, string(choice))
+}
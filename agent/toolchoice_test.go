@@ -0,0 +1,114 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+func TestToolChoiceParam(t *testing.T) {
+	t.Parallel()
+
+	tools := []anthropic.ToolUnionParam{{OfTool: &anthropic.ToolParam{Name: "lookup"}}}
+
+	tests := []struct {
+		choice ToolChoice
+		want   string
+	}{
+		{"", ""},
+		{ToolChoiceAuto, ""},
+		{ToolChoiceAny, "any"},
+		{ToolChoiceNone, "none"},
+		{"lookup", "tool"},
+	}
+
+	for _, tt := range tests {
+		got, err := toolChoiceParam(tt.choice, tools)
+		if err != nil {
+			t.Errorf("toolChoiceParam(%q): %v", tt.choice, err)
+
+			continue
+		}
+
+		var typ struct {
+			Type string `json:"type"`
+		}
+
+		if tt.want != "" {
+			data, _ := json.Marshal(got)
+			_ = json.Unmarshal(data, &typ)
+		}
+
+		if typ.Type != tt.want {
+			t.Errorf("toolChoiceParam(%q) type = %q, want %q", tt.choice, typ.Type, tt.want)
+		}
+	}
+
+	if _, err := toolChoiceParam("missing", tools); !errors.Is(err, errUnknownToolChoice) {
+		t.Errorf("toolChoiceParam(missing) = %v, want errUnknownToolChoice", err)
+	}
+}
+
+func TestSendMessage_ToolChoiceFirstResponseOnly(t *testing.T) {
+	t.Parallel()
+
+	responses := []string{
+		`{"id":"m1","type":"message","role":"assistant","model":"m","stop_reason":"tool_use",
+			"content":[{"type":"tool_use","id":"t1","name":"lookup","input":{}}],
+			"usage":{"input_tokens":10,"output_tokens":3}}`,
+		`{"id":"m2","type":"message","role":"assistant","model":"m","stop_reason":"end_turn",
+			"content":[{"type":"text","text":"done"}],
+			"usage":{"input_tokens":20,"output_tokens":5}}`,
+	}
+
+	var (
+		mu      sync.Mutex
+		choices []json.RawMessage
+	)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ToolChoice json.RawMessage `json:"tool_choice"`
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &req)
+
+		mu.Lock()
+		choices = append(choices, req.ToolChoice)
+		reply := responses[0]
+		responses = responses[1:]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"))
+	ag := mustNew(t, client, Config{MaxTokens: 100, MaxConcurrentTools: 1, ToolChoice: "lookup"},
+		&mockTool{name: "lookup"})
+
+	if _, err := ag.SendMessage(t.Context(), "hi", &mockCallbacks{}); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	if len(choices) != 2 {
+		t.Fatalf("%d requests, want 2", len(choices))
+	}
+
+	if got := string(choices[0]); got != `{"name":"lookup","type":"tool"}` {
+		t.Errorf("first request tool_choice = %s, want the lookup tool", got)
+	}
+
+	if choices[1] != nil {
+		t.Errorf("second request tool_choice = %s, want none sent", choices[1])
+	}
+}
@@ -0,0 +1,226 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/aelse/artoo/tool"
+)
+
+// fineGrainedStreamingBeta is the beta that streams tool input as it is
+// generated instead of in validated chunks.
+const fineGrainedStreamingBeta = "fine-grained-tool-streaming-2025-05-14"
+
+// previewScanBytes is how much of a tool's input is parsed for a preview, so
+// a large input is not parsed again for every delta.
+const previewScanBytes = 4096
+
+// previewFields are the input fields a tool input preview shows, most
+// telling first: the command being formed is worth more than its path.
+var previewFields = []string{"command", "code", "query", "pattern", "sql", "url", "path", "description"}
+
+// ToolInputWatcher is optionally implemented by Callbacks to show a tool
+// call while its input streams in, before the call runs (streaming only).
+// Large inputs, such as a file being written, take a while to arrive.
+type ToolInputWatcher interface {
+	// OnToolInputDelta is called as input for a call of tool name arrives,
+	// with a one-line preview of the input so far.
+	OnToolInputDelta(name string, preview string)
+}
+
+// toolInputPreview describes partial, the JSON input of a tool call as
+// received so far: the first of previewFields it has, even if the value is
+// still arriving, and how much input there is.
+func toolInputPreview(partial string) string {
+	fields := partialStrings(partial[:min(len(partial), previewScanBytes)])
+	size := formatBytes(len(partial))
+
+	for _, name := range previewFields {
+		if value, ok := fields[name]; ok {
+			return fmt.Sprintf("%s: %s (%s)", name, value, size)
+		}
+	}
+
+	return "receiving input (" + size + ")"
+}
+
+// formatBytes formats n bytes for a preview.
+func formatBytes(n int) string {
+	if n < 1024 {
+		return strconv.Itoa(n) + " B"
+	}
+
+	return fmt.Sprintf("%.1f KB", float64(n)/1024)
+}
+
+// partialStrings returns the string fields at the top level of a JSON
+// object that may be cut off anywhere, including within the last value.
+// Fields of other types are skipped, and scanning stops at anything that is
+// not valid JSON.
+func partialStrings(partial string) map[string]string {
+	fields := map[string]string{}
+
+	s := strings.TrimSpace(partial)
+	if !strings.HasPrefix(s, "{") {
+		return fields
+	}
+
+	s = s[1:]
+
+	for {
+		s = strings.TrimLeft(s, " \t\r\n,")
+		if !strings.HasPrefix(s, `"`) {
+			return fields
+		}
+
+		key, rest, complete := scanString(s)
+		if !complete {
+			return fields
+		}
+
+		rest = strings.TrimLeft(rest, " \t\r\n")
+		if !strings.HasPrefix(rest, ":") {
+			return fields
+		}
+
+		rest = strings.TrimLeft(rest[1:], " \t\r\n")
+
+		if strings.HasPrefix(rest, `"`) {
+			value, after, complete := scanString(rest)
+			fields[key] = value
+
+			if !complete {
+				return fields
+			}
+
+			s = after
+
+			continue
+		}
+
+		var ok bool
+		if s, ok = skipValue(rest); !ok {
+			return fields
+		}
+	}
+}
+
+// scanString decodes the JSON string at the start of s, which begins with a
+// quote, returning it, the text after it and whether it was complete. A cut
+// off string is returned as far as it goes.
+func scanString(s string) (value string, rest string, complete bool) {
+	var b strings.Builder
+
+	for i := 1; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '"':
+			return b.String(), s[i+1:], true
+		case '\\':
+			if i+1 >= len(s) {
+				return b.String(), "", false
+			}
+
+			i++
+
+			switch e := s[i]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case 'r':
+				b.WriteByte('\r')
+			case 'b', 'f':
+			case 'u':
+				if i+4 >= len(s) {
+					return b.String(), "", false
+				}
+
+				r, err := strconv.ParseUint(s[i+1:i+5], 16, 32)
+				if err != nil {
+					return b.String(), "", false
+				}
+
+				b.WriteRune(rune(r))
+				i += 4
+			default:
+				b.WriteByte(e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return strings.ToValidUTF8(b.String(), string(utf8.RuneError)), "", false
+}
+
+// skipValue skips the non-string JSON value at the start of s, returning
+// the text after it and whether the value was complete.
+func skipValue(s string) (string, bool) {
+	depth := 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			_, rest, complete := scanString(s[i:])
+			if !complete {
+				return "", false
+			}
+
+			i = len(s) - len(rest) - 1
+		case '{', '[':
+			depth++
+		case '}', ']':
+			if depth == 0 {
+				return s[i:], true
+			}
+
+			depth--
+		case ',':
+			if depth == 0 {
+				return s[i:], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// inputStreams are the input streams of tool calls whose tools act on their
+// input as it arrives (see tool.InputStreamer), by call ID.
+type inputStreams map[string]io.WriteCloser
+
+// openInputStream opens the input stream of call id of tool name, if the tool
+// acts on streamed input and is not skipped by a dry run.
+func (a *Agent) openInputStream(streams inputStreams, id, name string) {
+	t, ok := a.registry.Lookup(name)
+
+	a.mu.Lock()
+	dryRun := a.dryRun
+	a.mu.Unlock()
+
+	if !ok || dryRun {
+		return
+	}
+
+	if w := tool.StreamInput(t); w != nil {
+		streams[id] = w
+	}
+}
+
+// write passes a piece of call id's input to its stream, if it has one.
+func (s inputStreams) write(id, partial string) {
+	if w, ok := s[id]; ok {
+		_, _ = io.WriteString(w, partial)
+	}
+}
+
+// close ends every stream, once their calls have run or will not run.
+func (s inputStreams) close() {
+	for id, w := range s {
+		_ = w.Close()
+		delete(s, id)
+	}
+}
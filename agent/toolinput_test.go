@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+func TestToolInputPreview(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		partial string
+		want    string
+	}{
+		{``, "receiving input (0 B)"},
+		{`{"comm`, "receiving input (6 B)"},
+		{`{"command": "go te`, "command: go te (18 B)"},
+		{`{"timeout": 30, "command": "echo \"hi\"\nls"}`, "command: echo \"hi\"\nls (45 B)"},
+		{`{"path": "a.go", "command": "cat`, "command: cat (32 B)"},
+		{`{"files": [{"path": "a.go", "content": "x"}], "path": "b.go"}`, "path: b.go (61 B)"},
+		{`{"files": [{"path": "a.go", "content": "` + strings.Repeat("x", 2000), "receiving input (2.0 KB)"},
+		{`{"query": "café`, "query: café (16 B)"},
+	}
+
+	for _, tt := range tests {
+		if got := toolInputPreview(tt.partial); got != tt.want {
+			t.Errorf("toolInputPreview(%.40q) = %q, want %q", tt.partial, got, tt.want)
+		}
+	}
+}
+
+// inputWatcher records tool input previews.
+type inputWatcher struct {
+	mockCallbacks
+
+	mu       sync.Mutex
+	previews []string
+}
+
+func (w *inputWatcher) OnToolInputDelta(name string, preview string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.previews = append(w.previews, name+" "+preview)
+}
+
+func TestSendMessage_StreamsToolInput(t *testing.T) {
+	t.Parallel()
+
+	event := func(data string) string {
+		typ, _, _ := strings.Cut(strings.TrimPrefix(data, `{"type":"`), `"`)
+
+		return fmt.Sprintf("event: %s\ndata: %s\n\n", typ, data)
+	}
+
+	start := event(`{"type":"message_start","message":{"id":"m","type":"message","role":"assistant","model":"m",` +
+		`"content":[],"stop_reason":null,"usage":{"input_tokens":10,"output_tokens":1}}}`)
+	end := func(reason string) string {
+		return event(`{"type":"message_delta","delta":{"stop_reason":"`+reason+`"},"usage":{"output_tokens":5}}`) +
+			event(`{"type":"message_stop"}`)
+	}
+
+	responses := []string{
+		start +
+			event(`{"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"t1","name":"lookup","input":{}}}`) +
+			event(`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\": \"wea"}}`) +
+			event(`{"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"ther\"}"}}`) +
+			event(`{"type":"content_block_stop","index":0}`) + end("tool_use"),
+		start +
+			event(`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`) +
+			event(`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"sunny"}}`) +
+			event(`{"type":"content_block_stop","index":0}`) + end("end_turn"),
+	}
+
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		reply := responses[0]
+		responses = responses[1:]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte(reply))
+	}))
+	t.Cleanup(server.Close)
+
+	client := anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"))
+	ag := mustNew(t, client, Config{MaxTokens: 100, MaxConcurrentTools: 1, Streaming: true}, &mockTool{name: "lookup"})
+
+	cb := &inputWatcher{}
+	if _, err := ag.SendMessage(t.Context(), "weather?", cb); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	want := []string{"lookup query: wea (14 B)", "lookup query: weather (20 B)"}
+	if strings.Join(cb.previews, "|") != strings.Join(want, "|") {
+		t.Errorf("previews = %q, want %q", cb.previews, want)
+	}
+}
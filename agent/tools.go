@@ -0,0 +1,52 @@
+package agent
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/aelse/artoo/tool"
+)
+
+// ToolStatus describes one of the agent's tools.
+type ToolStatus struct {
+	tool.Info
+
+	Deferred bool // only summarized to the model until enable_tools loads it
+	Allowed  bool // offered to the model under the current tool restrictions
+}
+
+// Tools describes the agent's tools, deferred ones included, sorted by name.
+func (a *Agent) Tools() []ToolStatus {
+	infos := a.registry.List()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, t := range a.deferred {
+		infos = append(infos, tool.InfoOf(t))
+	}
+
+	statuses := make([]ToolStatus, len(infos))
+	for i, info := range infos {
+		_, deferred := a.deferred[info.Name]
+		statuses[i] = ToolStatus{Info: info, Deferred: deferred, Allowed: a.toolAllowed(info.Name)}
+	}
+
+	slices.SortFunc(statuses, func(x, y ToolStatus) int { return cmp.Compare(x.Name, y.Name) })
+
+	return statuses
+}
+
+// Tool returns the agent's tool called name, deferred ones included.
+func (a *Agent) Tool(name string) (tool.Tool, bool) {
+	if t, ok := a.registry.Lookup(name); ok {
+		return t, true
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	t, ok := a.deferred[name]
+
+	return t, ok
+}
@@ -0,0 +1,181 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// mustNew is New, failing the test if the agent cannot be created.
+func mustNew(t *testing.T, client anthropic.Client, config Config, extraTools ...tool.Tool) *Agent {
+	t.Helper()
+
+	ag, err := New(client, config, extraTools...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return ag
+}
+
+func TestNew_NameCollision(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		config Config
+		tools  []tool.Tool
+	}{
+		{"built-in", Config{}, []tool.Tool{&mockTool{name: "grep"}}},
+		{"notes", Config{}, []tool.Tool{&mockTool{name: "notes"}}},
+		{"extra tools", Config{}, []tool.Tool{&mockTool{name: "deploy"}, &mockTool{name: "deploy"}}},
+		{"deferred built-in", Config{DeferTools: true}, []tool.Tool{&mockTool{name: "grep"}}},
+		{"deferred extra tools", Config{DeferTools: true}, []tool.Tool{&mockTool{name: "deploy"}, &mockTool{name: "deploy"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := New(anthropic.NewClient(), tt.config, tt.tools...); !errors.Is(err, tool.ErrToolExists) {
+				t.Errorf("expected ErrToolExists, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTools(t *testing.T) {
+	t.Parallel()
+
+	ag := mustNew(t, anthropic.NewClient(), Config{DeferTools: true, Tools: []string{"grep", "deploy"}},
+		&mockTool{name: "deploy"})
+
+	tools := ag.Tools()
+	if !slices.IsSortedFunc(tools, func(a, b ToolStatus) int { return strings.Compare(a.Name, b.Name) }) {
+		t.Errorf("tools should be sorted by name: %v", tools)
+	}
+
+	byName := map[string]ToolStatus{}
+	for _, s := range tools {
+		if _, dup := byName[s.Name]; dup {
+			t.Errorf("%s listed twice", s.Name)
+		}
+
+		byName[s.Name] = s
+	}
+
+	if s := byName["deploy"]; !s.Deferred || !s.Allowed || s.Source != tool.SourceBuiltin {
+		t.Errorf("deploy = %+v, want a deferred, allowed tool", s)
+	}
+
+	if s := byName["grep"]; s.Deferred || !s.Allowed || s.Description == "" {
+		t.Errorf("grep = %+v, want the built-in, allowed grep", s)
+	}
+
+	if s, ok := byName["write_files"]; !ok || s.Allowed {
+		t.Errorf("write_files = %+v, want it listed but not allowed", s)
+	}
+
+	if _, ok := byName[notesName]; !ok {
+		t.Errorf("%s should be listed", notesName)
+	}
+
+	if _, ok := ag.Tool("deploy"); !ok {
+		t.Error("a deferred tool should be found")
+	}
+
+	if _, ok := ag.Tool("missing"); ok {
+		t.Error("an unknown tool should not be found")
+	}
+
+	ag.enableTools([]string{"deploy"})
+
+	if s := ag.Tools(); !slices.ContainsFunc(s, func(s ToolStatus) bool { return s.Name == "deploy" && !s.Deferred }) {
+		t.Errorf("enabled deploy should no longer be deferred: %v", s)
+	}
+}
+
+func TestNew_ToolEnvironment(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("TOKEN=hunter2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	shared := tool.NewEnvironment()
+	shared.AllowSecrets(true)
+
+	allowed := mustNew(t, anthropic.NewClient(), Config{ToolEnvironment: shared})
+	other := mustNew(t, anthropic.NewClient(), Config{})
+
+	if allowed.ToolEnvironment() != shared || other.ToolEnvironment() == nil || other.ToolEnvironment() == shared {
+		t.Fatal("an agent should use the configured environment, or one of its own")
+	}
+
+	var block anthropic.ToolUseBlock
+
+	input := fmt.Sprintf(`{"id": "1", "name": "read_many", "type": "tool_use", "input": {"paths": [%q]}}`, path)
+	if err := json.Unmarshal([]byte(input), &block); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(ag *Agent) string {
+		readMany, _ := ag.registry.Lookup("read_many")
+
+		return readMany.Call(block).OfToolResult.Content[0].OfText.Text
+	}
+
+	if out := read(allowed); !strings.Contains(out, "hunter2") {
+		t.Errorf("the agent allowing secrets withheld them:\n%s", out)
+	}
+
+	if out := read(other); strings.Contains(out, "hunter2") {
+		t.Errorf("secrets allowed for another agent were shown:\n%s", out)
+	}
+}
+
+func TestExecuteToolsConcurrently_ReadAfterWrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.go")
+	if err := os.WriteFile(path, []byte("old"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ag := mustNew(t, anthropic.NewClient(), Config{MaxConcurrentTools: 4})
+
+	var blocks []anthropic.ToolUseBlock
+	for _, input := range []string{
+		fmt.Sprintf(`{"id": "w", "name": "write_files", "type": "tool_use",
+			"input": {"files": [{"path": %q, "content": "new"}]}}`, path),
+		fmt.Sprintf(`{"id": "r", "name": "read_many", "type": "tool_use", "input": {"paths": [%q]}}`, path),
+		fmt.Sprintf(`{"id": "g", "name": "grep", "type": "tool_use", "input": {"pattern": "new", "path": %q}}`, dir),
+	} {
+		var block anthropic.ToolUseBlock
+		if err := json.Unmarshal([]byte(input), &block); err != nil {
+			t.Fatal(err)
+		}
+
+		blocks = append(blocks, block)
+	}
+
+	deps := ag.toolDependencies(blocks)
+	if !slices.Equal(deps[1], []int{0}) || !slices.Contains(deps[2], 0) {
+		t.Fatalf("reads should wait for the write to their path, deps = %v", deps)
+	}
+
+	results := ag.executeToolsConcurrently(t.Context(), blocks, &mockCallbacks{})
+	if out := results[1].OfToolResult.Content[0].OfText.Text; !strings.Contains(out, "new") {
+		t.Errorf("a read after a write to the same path should see the write:\n%s", out)
+	}
+}
@@ -0,0 +1,93 @@
+package agent
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// turnCallbacks records callback events in order.
+type turnCallbacks struct {
+	mockCallbacks
+
+	mu     sync.Mutex
+	events []string
+}
+
+func (c *turnCallbacks) record(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.events = append(c.events, event)
+}
+
+func (c *turnCallbacks) OnTurnStart(turnID string) { c.record("start " + turnID) }
+
+func (c *turnCallbacks) OnTurnEnd(turnID string, usage Usage, stopReason string) {
+	c.record(fmt.Sprintf("end %s %s out=%d", turnID, stopReason, usage.OutputTokens))
+}
+
+func (c *turnCallbacks) OnToolResult(_ string, name string, _ string, _ bool) {
+	c.record("result " + name)
+}
+
+// newTestClient returns a client whose API replies with the given message
+// JSON bodies in order, one per request.
+func newTestClient(t *testing.T, responses ...string) anthropic.Client {
+	t.Helper()
+
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		body := responses[0]
+		responses = responses[1:]
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return anthropic.NewClient(option.WithBaseURL(server.URL), option.WithAPIKey("test"))
+}
+
+func TestSendMessage_TurnEvents(t *testing.T) {
+	t.Parallel()
+
+	responses := []string{
+		`{"id":"m1","type":"message","role":"assistant","model":"m","stop_reason":"tool_use",
+			"content":[{"type":"tool_use","id":"t1","name":"lookup","input":{}}],
+			"usage":{"input_tokens":10,"output_tokens":3}}`,
+		`{"id":"m2","type":"message","role":"assistant","model":"m","stop_reason":"end_turn",
+			"content":[{"type":"text","text":"done"}],
+			"usage":{"input_tokens":20,"output_tokens":5}}`,
+	}
+
+	ag := mustNew(t, newTestClient(t, responses...), Config{MaxTokens: 100, MaxConcurrentTools: 1})
+	_ = ag.registry.Register(&mockTool{name: "lookup"})
+
+	cb := &turnCallbacks{}
+	if _, err := ag.SendMessage(t.Context(), "hi", cb); err != nil {
+		t.Fatalf("SendMessage: %v", err)
+	}
+
+	id := ag.ConversationID()
+	want := []string{
+		"start " + id + "/1",
+		"result lookup",
+		"end " + id + "/1 tool_use out=3",
+		"start " + id + "/2",
+		"end " + id + "/2 end_turn out=5",
+	}
+
+	if strings.Join(cb.events, "\n") != strings.Join(want, "\n") {
+		t.Errorf("events:\n%s\nwant:\n%s", strings.Join(cb.events, "\n"), strings.Join(want, "\n"))
+	}
+}
@@ -0,0 +1,132 @@
+package agent
+
+import (
+	"encoding/json"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// imageSize approximates an image's share of a request: images cost about
+// 1,600 tokens regardless of their base64 size, roughly 6,400 characters.
+const imageSize = 6400
+
+// Usage breaks down the tokens of a turn by category. The API reports only
+// totals, so input tokens are apportioned across categories by their share
+// of the request's serialized size.
+type Usage struct {
+	System        int64 // system prompt and instruction files
+	ToolSchemas   int64 // tool definitions
+	UserText      int64 // user prompts and attachments
+	AssistantText int64 // earlier assistant replies
+	ToolCalls     int64 // tool call inputs
+	ToolResults   int64 // tool outputs
+
+	InputTokens  int64 // context size of the turn's last request, as reported by the API
+	OutputTokens int64 // tokens generated across the whole turn
+}
+
+// UsageCategory is one named share of a turn's input tokens.
+type UsageCategory struct {
+	Name   string
+	Tokens int64
+}
+
+// Categories returns the input token breakdown in display order.
+func (u Usage) Categories() []UsageCategory {
+	return []UsageCategory{
+		{"system prompt", u.System},
+		{"tool schemas", u.ToolSchemas},
+		{"user text", u.UserText},
+		{"assistant text", u.AssistantText},
+		{"tool calls", u.ToolCalls},
+		{"tool results", u.ToolResults},
+	}
+}
+
+// LastUsage returns the token breakdown of the most recent turn.
+func (a *Agent) LastUsage() Usage {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.usage
+}
+
+// measureUsage apportions the input tokens reported for a request made with
+// params across the categories of its content.
+func measureUsage(params anthropic.MessageNewParams, usage anthropic.Usage) Usage {
+	var sizes Usage
+
+	for _, block := range params.System {
+		sizes.System += int64(len(block.Text))
+	}
+
+	if data, err := json.Marshal(params.Tools); err == nil && len(params.Tools) > 0 {
+		sizes.ToolSchemas = int64(len(data))
+	}
+
+	for _, message := range params.Messages {
+		for _, block := range message.Content {
+			size := blockSize(block)
+
+			switch {
+			case block.OfToolResult != nil:
+				sizes.ToolResults += size
+			case block.OfToolUse != nil:
+				sizes.ToolCalls += size
+			case message.Role == anthropic.MessageParamRoleAssistant:
+				sizes.AssistantText += size
+			default:
+				sizes.UserText += size
+			}
+		}
+	}
+
+	total := sizes.System + sizes.ToolSchemas + sizes.UserText + sizes.AssistantText +
+		sizes.ToolCalls + sizes.ToolResults
+	input := usage.InputTokens + usage.CacheReadInputTokens + usage.CacheCreationInputTokens
+
+	scale := func(size int64) int64 {
+		if total == 0 {
+			return 0
+		}
+
+		return size * input / total
+	}
+
+	return Usage{
+		System:        scale(sizes.System),
+		ToolSchemas:   scale(sizes.ToolSchemas),
+		UserText:      scale(sizes.UserText),
+		AssistantText: scale(sizes.AssistantText),
+		ToolCalls:     scale(sizes.ToolCalls),
+		ToolResults:   scale(sizes.ToolResults),
+		InputTokens:   input,
+	}
+}
+
+// blockSize returns the number of content bytes in a block: the text for
+// text blocks and tool results, the serialized form for anything else.
+func blockSize(block anthropic.ContentBlockParamUnion) int64 {
+	switch {
+	case block.OfText != nil:
+		return int64(len(block.OfText.Text))
+	case block.OfImage != nil:
+		return imageSize
+	case block.OfToolResult != nil:
+		var n int64
+		for _, c := range block.OfToolResult.Content {
+			if c.OfText != nil {
+				n += int64(len(c.OfText.Text))
+			}
+		}
+
+		return n
+	}
+
+	data, err := json.Marshal(block)
+	if err != nil {
+		return 0
+	}
+
+	return int64(len(data))
+}
@@ -0,0 +1,43 @@
+package agent
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestMeasureUsage(t *testing.T) {
+	t.Parallel()
+
+	params := anthropic.MessageNewParams{
+		System: []anthropic.TextBlockParam{{Text: strings.Repeat("s", 100)}},
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock(strings.Repeat("u", 100))),
+			anthropic.NewAssistantMessage(anthropic.NewTextBlock(strings.Repeat("a", 100))),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("t1", strings.Repeat("r", 700), false)),
+		},
+	}
+
+	u := measureUsage(params, anthropic.Usage{InputTokens: 800, CacheReadInputTokens: 200})
+
+	if u.InputTokens != 1000 {
+		t.Errorf("expected input to include cache reads, got %d", u.InputTokens)
+	}
+
+	if u.ToolResults != 700 || u.System != 100 || u.UserText != 100 || u.AssistantText != 100 {
+		t.Errorf("unexpected breakdown: %+v", u)
+	}
+
+	if u.ToolSchemas != 0 || u.ToolCalls != 0 {
+		t.Errorf("expected no tool schema or call tokens, got %+v", u)
+	}
+}
+
+func TestMeasureUsage_Empty(t *testing.T) {
+	t.Parallel()
+
+	if u := measureUsage(anthropic.MessageNewParams{}, anthropic.Usage{}); u != (Usage{}) {
+		t.Errorf("expected zero usage, got %+v", u)
+	}
+}
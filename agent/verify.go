@@ -0,0 +1,69 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// verifyName is how the completion gate appears to callbacks.
+	verifyName = "verify"
+
+	// verifyTimeout bounds one run of the verification command.
+	verifyTimeout = 10 * time.Minute
+
+	// verifyMaxOutput caps the failure output fed back to the model.
+	verifyMaxOutput = 4_000
+)
+
+// modifies reports whether any of blocks ran a tool that may have changed
+// something, making the turn's work worth verifying.
+func (a *Agent) modifies(blocks []anthropic.ToolUseBlock) bool {
+	for _, block := range blocks {
+		if t, ok := a.registry.Lookup(block.Name); ok && !tool.IsReadOnly(t) && !a.skipsExecution(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// verify runs the configured verification command once the model says it
+// is done, reporting it to callbacks as a call in turnID. It returns "" if the command passed, or a message asking the
+// model to fix the failure.
+func (a *Agent) verify(ctx context.Context, turnID string, cb Callbacks) string {
+	command := a.config.VerifyCommand
+	id := turnID + "/" + verifyName
+
+	cb.OnToolCall(id, verifyName, command)
+
+	ctx, cancel := context.WithTimeout(ctx, verifyTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // configured by the user
+	cmd.Env = a.toolEnv.Environ()
+	cmd.Dir = a.toolEnv.DefaultRoot() // the focused project, if any
+
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		cb.OnToolResult(id, verifyName, "passed", false)
+
+		return ""
+	}
+
+	output := strings.TrimSpace(string(out))
+	if len(output) > verifyMaxOutput {
+		output = "…" + output[len(output)-verifyMaxOutput:]
+	}
+
+	cb.OnToolResult(id, verifyName, output, true)
+
+	return fmt.Sprintf("The verification command `%s` failed (%v), so the task is not complete yet. "+
+		"Fix the cause and finish the task.\n\n```\n%s\n```", command, err, output)
+}
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const (
+	verifyToolUse = `{"id":"m1","type":"message","role":"assistant","model":"m","stop_reason":"tool_use",
+		"content":[{"type":"tool_use","id":"t1","name":"edit","input":{}}],
+		"usage":{"input_tokens":10,"output_tokens":3}}`
+	verifyDone = `{"id":"m2","type":"message","role":"assistant","model":"m","stop_reason":"end_turn",
+		"content":[{"type":"text","text":"done"}],
+		"usage":{"input_tokens":20,"output_tokens":5}}`
+)
+
+func newVerifyAgent(t *testing.T, command string, retries int, responses ...string) *Agent {
+	t.Helper()
+
+	ag := mustNew(t, newTestClient(t, responses...), Config{
+		MaxTokens:          100,
+		MaxConcurrentTools: 1,
+		VerifyCommand:      command,
+		VerifyRetries:      retries,
+	})
+	_ = ag.registry.Register(&mockTool{name: "edit"})
+
+	return ag
+}
+
+func TestVerify_FeedsFailureBack(t *testing.T) {
+	t.Parallel()
+
+	// Fails the first time, passes the second
+	marker := filepath.Join(t.TempDir(), "passed")
+	command := "test -f " + marker + " || { touch " + marker + "; echo 'FAIL: TestParse'; exit 1; }"
+
+	ag := newVerifyAgent(t, command, 2, verifyToolUse, verifyDone, verifyDone)
+
+	resp, err := ag.SendMessage(t.Context(), "fix it", &mockCallbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.VerifyFailed != "" {
+		t.Errorf("verification should have passed, got %q", resp.VerifyFailed)
+	}
+
+	messages := ag.conversation.Messages()
+	if len(messages) != 6 {
+		t.Fatalf("expected prompt, tool use, result, done, failure and done; got %d messages", len(messages))
+	}
+
+	if text := messages[4].Content[0].OfText.Text; !strings.Contains(text, "FAIL: TestParse") {
+		t.Errorf("failure output should be fed back, got %q", text)
+	}
+}
+
+func TestVerify_RetriesExhausted(t *testing.T) {
+	t.Parallel()
+
+	ag := newVerifyAgent(t, "echo broken; exit 1", 1, verifyToolUse, verifyDone, verifyDone)
+
+	resp, err := ag.SendMessage(t.Context(), "fix it", &mockCallbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(resp.VerifyFailed, "broken") {
+		t.Errorf("VerifyFailed = %q, want the failure", resp.VerifyFailed)
+	}
+}
+
+func TestVerify_SkippedWithoutChanges(t *testing.T) {
+	t.Parallel()
+
+	ag := newVerifyAgent(t, "exit 1", 2, verifyDone)
+
+	resp, err := ag.SendMessage(t.Context(), "what does this do?", &mockCallbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.VerifyFailed != "" {
+		t.Errorf("a turn that changed nothing should not be verified, got %q", resp.VerifyFailed)
+	}
+}
+
+func TestVerify_RunsInDefaultRoot(t *testing.T) {
+	t.Parallel()
+
+	project := t.TempDir()
+	if err := os.WriteFile(filepath.Join(project, "go.mod"), []byte("module api\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ag := newVerifyAgent(t, "test -f go.mod", 0, verifyToolUse, verifyDone)
+	ag.ToolEnvironment().SetDefaultRoot(project)
+
+	resp, err := ag.SendMessage(t.Context(), "fix it", &mockCallbacks{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.VerifyFailed != "" {
+		t.Errorf("verification should run in the focused project, got %q", resp.VerifyFailed)
+	}
+}
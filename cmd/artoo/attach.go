@@ -0,0 +1,176 @@
+// Package main provides attachment of dragged-and-dropped files.
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// maxAttachmentBytes caps the size of a text file attached to a prompt.
+const maxAttachmentBytes = 256 * 1024
+
+var (
+	errAttachmentTooLarge      = errors.New("file is too large to attach")
+	errImageAttachmentTooLarge = errors.New("image file is too large for the API")
+	errBinaryAttachment        = errors.New("file appears to be binary")
+)
+
+// imageMediaTypes maps image extensions to the media types the API accepts.
+var imageMediaTypes = map[string]string{
+	".png":  "image/png",
+	".jpg":  "image/jpeg",
+	".jpeg": "image/jpeg",
+	".gif":  "image/gif",
+	".webp": "image/webp",
+}
+
+// detectPaths reports the files named by input when the whole input looks
+// like one or more dropped file paths, as terminals insert when files are
+// dragged onto them. Paths may be quoted, backslash-escaped or file:// URIs.
+// It returns nil unless every token is an existing regular file.
+func detectPaths(input string) []string {
+	tokens := splitShellWords(input)
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	paths := make([]string, 0, len(tokens))
+
+	for _, token := range tokens {
+		if strings.HasPrefix(token, "file://") {
+			u, err := url.Parse(token)
+			if err != nil {
+				return nil
+			}
+
+			token = u.Path
+		}
+
+		if strings.HasPrefix(token, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				token = filepath.Join(home, token[2:])
+			}
+		}
+
+		info, err := os.Stat(token)
+		if err != nil || !info.Mode().IsRegular() {
+			return nil
+		}
+
+		paths = append(paths, token)
+	}
+
+	return paths
+}
+
+// splitShellWords splits input on unquoted whitespace, honouring single
+// quotes, double quotes and backslash escapes.
+func splitShellWords(input string) []string {
+	var words []string
+	var current strings.Builder
+
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, r := range input {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case unicode.IsSpace(r):
+			if inWord {
+				words = append(words, current.String())
+				current.Reset()
+				inWord = false
+			}
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	if inWord {
+		words = append(words, current.String())
+	}
+
+	return words
+}
+
+// attachmentBlock reads path into a content block: images as image blocks,
+// everything else as a text block labelled with the file path.
+func attachmentBlock(path string) (anthropic.ContentBlockParamUnion, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // user explicitly chose to attach this file
+	if err != nil {
+		return anthropic.ContentBlockParamUnion{}, err
+	}
+
+	if mediaType, ok := imageMediaTypes[strings.ToLower(filepath.Ext(path))]; ok {
+		if len(data) > maxImageBytes {
+			return anthropic.ContentBlockParamUnion{}, fmt.Errorf("%w: %s is %d bytes, the limit is %d",
+				errImageAttachmentTooLarge, path, len(data), maxImageBytes)
+		}
+
+		return anthropic.NewImageBlockBase64(mediaType, base64.StdEncoding.EncodeToString(data)), nil
+	}
+
+	if len(data) > maxAttachmentBytes {
+		return anthropic.ContentBlockParamUnion{}, fmt.Errorf("%w: %s is %d bytes, the limit is %d",
+			errAttachmentTooLarge, path, len(data), maxAttachmentBytes)
+	}
+
+	if bytes.IndexByte(data, 0) >= 0 {
+		return anthropic.ContentBlockParamUnion{}, fmt.Errorf("%w: %s", errBinaryAttachment, path)
+	}
+
+	return anthropic.NewTextBlock(fmt.Sprintf("Contents of %s:\n%s", path, data)), nil
+}
+
+// offerAttachments asks whether to attach the dropped files instead of
+// sending their paths as a prompt. It reports whether input was consumed.
+func (a *app) offerAttachments(input string) bool {
+	paths := detectPaths(input)
+	if len(paths) == 0 {
+		return false
+	}
+
+	question := fmt.Sprintf("Attach %d file(s) to your next message instead of sending the path as a prompt?", len(paths))
+	if !a.term.Confirm(question) {
+		return false
+	}
+
+	for _, path := range paths {
+		block, err := attachmentBlock(path)
+		if err != nil {
+			a.term.PrintError(err)
+
+			continue
+		}
+
+		a.pending = append(a.pending, block)
+		a.term.PrintInfo("Attached " + path)
+	}
+
+	return true
+}
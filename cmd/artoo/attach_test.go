@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSplitShellWords(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"/tmp/a.png", []string{"/tmp/a.png"}},
+		{`/tmp/my\ file.txt /tmp/b.go`, []string{"/tmp/my file.txt", "/tmp/b.go"}},
+		{`'/tmp/it''s.txt' "/tmp/quoted file.md"`, []string{"/tmp/its.txt", "/tmp/quoted file.md"}},
+		{"  spaced   out  ", []string{"spaced", "out"}},
+		{"", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitShellWords(tt.input); !slices.Equal(got, tt.want) {
+			t.Errorf("splitShellWords(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestDetectPaths(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	spaced := filepath.Join(dir, "with space.txt")
+	plain := filepath.Join(dir, "plain.go")
+
+	for _, p := range []string{spaced, plain} {
+		if err := os.WriteFile(p, []byte("content"), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", p, err)
+		}
+	}
+
+	escaped := strings.ReplaceAll(spaced, " ", `\ `)
+
+	if got := detectPaths(escaped + " " + plain); !slices.Equal(got, []string{spaced, plain}) {
+		t.Errorf("expected both dropped files, got %v", got)
+	}
+
+	if got := detectPaths("file://" + plain); !slices.Equal(got, []string{plain}) {
+		t.Errorf("expected file:// URI to resolve, got %v", got)
+	}
+
+	// Ordinary prompts, directories and missing files are not attachments
+	for _, input := range []string{"explain " + plain, dir, "/project api", filepath.Join(dir, "missing")} {
+		if got := detectPaths(input); got != nil {
+			t.Errorf("detectPaths(%q) = %v, want nil", input, got)
+		}
+	}
+}
+
+func TestAttachmentBlock(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	text := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(text, []byte("hello"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	block, err := attachmentBlock(text)
+	if err != nil {
+		t.Fatalf("attachmentBlock failed: %v", err)
+	}
+
+	if block.OfText == nil || !strings.Contains(block.OfText.Text, "hello") {
+		t.Errorf("expected text block with file contents, got %+v", block)
+	}
+
+	img := filepath.Join(dir, "shot.PNG")
+	if err := os.WriteFile(img, []byte("\x89PNG"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	block, err = attachmentBlock(img)
+	if err != nil {
+		t.Fatalf("attachmentBlock failed: %v", err)
+	}
+
+	if block.OfImage == nil {
+		t.Errorf("expected image block for .PNG file, got %+v", block)
+	}
+
+	binary := filepath.Join(dir, "blob.bin")
+	if err := os.WriteFile(binary, []byte{0, 1, 2}, 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := attachmentBlock(binary); err == nil {
+		t.Error("expected error attaching a binary file")
+	}
+
+	large := filepath.Join(dir, "large.png")
+	if err := os.WriteFile(large, make([]byte, maxImageBytes+1), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	_, err = attachmentBlock(large)
+	if !errors.Is(err, errImageAttachmentTooLarge) || !strings.Contains(err.Error(), large) ||
+		!strings.Contains(err.Error(), strconv.Itoa(maxImageBytes)) {
+		t.Errorf("expected an error naming the image and the limit, got %v", err)
+	}
+}
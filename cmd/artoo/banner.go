@@ -0,0 +1,105 @@
+// Package main provides the banner shown when a session starts.
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/tool"
+	"github.com/aelse/artoo/ui"
+	"github.com/aelse/artoo/workspace"
+)
+
+// gitStatusTimeout bounds the git call made for the banner, so a slow
+// repository does not delay the prompt.
+const gitStatusTimeout = 2 * time.Second
+
+// sessionBanner describes the context the session starts in.
+func sessionBanner(ctx context.Context, cfg AppConfig, a *agent.Agent, ws *workspace.Workspace) ui.Banner {
+	root, _ := os.Getwd()
+	if ws != nil {
+		root = ws.Root()
+	}
+
+	tools := a.Tools()
+	plugins := 0
+
+	for _, t := range tools {
+		if t.Source == tool.SourcePlugin {
+			plugins++
+		}
+	}
+
+	branch, dirty := gitState(ctx, root)
+
+	return ui.Banner{
+		Model:     a.Model(),
+		Workspace: homeRelative(root),
+		Branch:    branch,
+		Dirty:     dirty,
+		Tools:     len(tools),
+		Plugins:   plugins,
+		Autonomy:  string(a.Autonomy()),
+		Profile:   cfg.Profile,
+	}
+}
+
+// gitState returns the branch checked out in dir and whether its working
+// tree has uncommitted changes. The branch is "" outside a repository or if
+// git is missing.
+func gitState(ctx context.Context, dir string) (string, bool) {
+	ctx, cancel := context.WithTimeout(ctx, gitStatusTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "status", "--porcelain", "--branch")
+	cmd.Dir = dir
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+
+	return parseGitStatus(string(out))
+}
+
+// parseGitStatus reads the branch and whether anything changed from the
+// output of git status --porcelain --branch.
+func parseGitStatus(out string) (string, bool) {
+	header, changes, _ := strings.Cut(out, "\n")
+
+	header, ok := strings.CutPrefix(header, "## ")
+	if !ok {
+		return "", false
+	}
+
+	// "main...origin/main [ahead 1]", "No commits yet on main" or
+	// "HEAD (no branch)" when detached
+	header = strings.TrimPrefix(header, "No commits yet on ")
+	header, _, _ = strings.Cut(header, "...")
+	branch, _, _ := strings.Cut(header, " ")
+
+	return branch, strings.TrimSpace(changes) != ""
+}
+
+// homeRelative abbreviates a path under the home directory with "~".
+func homeRelative(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+
+	if rel, err := filepath.Rel(home, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		if rel == "." {
+			return "~"
+		}
+
+		return filepath.Join("~", rel)
+	}
+
+	return path
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseGitStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		out    string
+		branch string
+		dirty  bool
+	}{
+		{"## main...origin/main [ahead 1]\n", "main", false},
+		{"## feature/x\n M main.go\n?? new.go\n", "feature/x", true},
+		{"## No commits yet on main\n", "main", false},
+		{"## HEAD (no branch)\n", "HEAD", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		if branch, dirty := parseGitStatus(tt.out); branch != tt.branch || dirty != tt.dirty {
+			t.Errorf("parseGitStatus(%q) = %q, %v, want %q, %v", tt.out, branch, dirty, tt.branch, tt.dirty)
+		}
+	}
+}
+
+func TestHomeRelative(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	for path, want := range map[string]string{
+		home:                              "~",
+		filepath.Join(home, "src", "app"): filepath.Join("~", "src", "app"),
+		filepath.Dir(home) + "/elsewhere": filepath.Dir(home) + "/elsewhere",
+	} {
+		if got := homeRelative(path); got != want {
+			t.Errorf("homeRelative(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// Package main provides the batch subcommand for asynchronous jobs.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/ui"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const batchUsage = `usage:
+  artoo batch submit <prompts-file>   submit one prompt per non-empty line
+  artoo batch status <batch-id>       show processing status
+  artoo batch results <batch-id> [--apply]
+                                      print results; --apply runs requested tool calls`
+
+var errBatchUsage = errors.New(batchUsage)
+
+// runBatch implements the `artoo batch` subcommand, which submits prompts to
+// the Message Batches API and later retrieves (and optionally applies) results.
+func runBatch(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	if len(args) < 2 {
+		return errBatchUsage
+	}
+
+	a, err := agent.New(client, cfg.agentConfig(), loadTools(cfg)...)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "submit":
+		prompts, err := readPrompts(args[1])
+		if err != nil {
+			return err
+		}
+
+		batch, err := a.SubmitBatch(ctx, prompts)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Submitted batch %s with %d prompts\n", batch.ID, len(prompts))
+
+		return nil
+
+	case "status":
+		batch, err := a.BatchStatus(ctx, args[1])
+		if err != nil {
+			return err
+		}
+
+		c := batch.RequestCounts
+		fmt.Printf("%s: %s (processing %d, succeeded %d, errored %d, canceled %d, expired %d)\n",
+			batch.ID, batch.ProcessingStatus, c.Processing, c.Succeeded, c.Errored, c.Canceled, c.Expired)
+
+		return nil
+
+	case "results":
+		apply := len(args) > 2 && args[2] == "--apply"
+
+		return printBatchResults(ctx, a, newTerminal(cfg, false), args[1], apply)
+	}
+
+	return errBatchUsage
+}
+
+// printBatchResults prints each result's text to term and, if apply is set,
+// executes the tool calls it requested.
+func printBatchResults(ctx context.Context, a *agent.Agent, term *ui.Terminal, id string, apply bool) error {
+	results, err := a.BatchResults(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range results {
+		fmt.Printf("--- prompt %d\n", r.Index)
+
+		if r.Err != nil {
+			term.PrintError(r.Err)
+
+			continue
+		}
+
+		if !apply {
+			for _, block := range r.Message.Content {
+				if text, ok := block.AsAny().(anthropic.TextBlock); ok {
+					term.PrintAssistant(text.Text)
+				}
+			}
+
+			continue
+		}
+
+		if _, err := a.ApplyBatchResult(ctx, r, term); err != nil {
+			term.PrintError(err)
+		}
+	}
+
+	return nil
+}
+
+// readPrompts reads one prompt per non-empty line of path.
+func readPrompts(path string) ([]string, error) {
+	f, err := os.Open(path) //nolint:gosec // user-supplied prompts file
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var prompts []string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			prompts = append(prompts, line)
+		}
+	}
+
+	return prompts, scanner.Err()
+}
@@ -0,0 +1,219 @@
+// Package main provides the review of the files a turn changed.
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// reviewDiffLines caps the diff shown for one file.
+const reviewDiffLines = 200
+
+var errReviewAnswer = errors.New("answer with Enter to keep, r to revert, e to edit or a to keep the rest")
+
+// reviewAction is what the user chose to do with a changed file.
+type reviewAction int
+
+const (
+	reviewUnknown reviewAction = iota
+	reviewKeep
+	reviewRevert
+	reviewEdit
+	reviewKeepRest
+)
+
+// parseReviewAnswer reads an answer to the review prompt; empty keeps the file.
+func parseReviewAnswer(answer string) reviewAction {
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "", "k", "keep", "y", "yes":
+		return reviewKeep
+	case "r", "revert", "n", "no":
+		return reviewRevert
+	case "e", "edit":
+		return reviewEdit
+	case "a", "all":
+		return reviewKeepRest
+	default:
+		return reviewUnknown
+	}
+}
+
+// reviewTurn offers the files the last turn changed for review, when
+// ARTOO_REVIEW_CHANGES is on: each is shown as a diff and can be kept,
+// reverted or opened in the editor. The next prompt tells the model which
+// files were reverted.
+func (a *app) reviewTurn() {
+	changes := a.changes.Take()
+	if !a.config.ReviewChanges || len(changes) == 0 {
+		return
+	}
+
+	var reverted []string
+
+	for i, c := range changes {
+		action := a.reviewChange(c, i+1, len(changes))
+		if action == reviewKeepRest {
+			break
+		}
+
+		if action != reviewRevert {
+			continue
+		}
+
+		if err := c.Revert(); err != nil {
+			a.term.PrintError(err)
+
+			continue
+		}
+
+		reverted = append(reverted, tool.DisplayPath(c.Path))
+	}
+
+	if len(reverted) > 0 {
+		a.term.PrintInfo(fmt.Sprintf("Reverted %d files; their current content is in the trash.", len(reverted)))
+		a.pending = append(a.pending, anthropic.NewTextBlock(revertedNote(reverted)))
+	}
+}
+
+// reviewChange shows change n of total and asks what to do with it until
+// the answer is to keep or revert it.
+func (a *app) reviewChange(c tool.Change, n, total int) reviewAction {
+	for {
+		a.term.PrintInfo(fmt.Sprintf("Changed file %d of %d: %s (%s)", n, total, tool.DisplayPath(c.Path), changeStatus(c)))
+		fmt.Print(diffChange(c))
+		a.term.PrintInfo("Keep this change? Enter keeps it, r reverts it, e opens it in your editor, a keeps the rest.")
+
+		answer, err := a.term.ReadInput()
+		if err != nil {
+			return reviewKeepRest
+		}
+
+		action := parseReviewAnswer(answer)
+
+		switch action {
+		case reviewUnknown:
+			a.term.PrintError(errReviewAnswer)
+		case reviewEdit:
+			if err := openEditor(c.Path); err != nil {
+				a.term.PrintError(err)
+			}
+		default:
+			return action
+		}
+	}
+}
+
+// changeStatus describes what happened to the file.
+func changeStatus(c tool.Change) string {
+	if !c.Existed {
+		return "added"
+	}
+
+	if _, err := os.Lstat(c.Path); errors.Is(err, os.ErrNotExist) {
+		return "deleted"
+	}
+
+	return "modified"
+}
+
+// diffChange returns a unified diff of the file from before the change to
+// now, of at most reviewDiffLines lines.
+func diffChange(c tool.Change) string {
+	if !c.Revertible {
+		return "(previous content was not kept, so there is no diff and it cannot be reverted)\n"
+	}
+
+	current, err := os.ReadFile(c.Path)
+	deleted := errors.Is(err, os.ErrNotExist)
+
+	if err != nil && !deleted {
+		return fmt.Sprintf("(cannot read the file: %v)\n", err)
+	}
+
+	dir, err := os.MkdirTemp("", "artoo-review-*")
+	if err != nil {
+		return fmt.Sprintf("(cannot diff: %v)\n", err)
+	}
+	defer os.RemoveAll(dir)
+
+	before, after := filepath.Join(dir, "before"), filepath.Join(dir, "after")
+	if err := os.WriteFile(before, c.Content, 0o600); err != nil {
+		return fmt.Sprintf("(cannot diff: %v)\n", err)
+	}
+
+	if err := os.WriteFile(after, current, 0o600); err != nil {
+		return fmt.Sprintf("(cannot diff: %v)\n", err)
+	}
+
+	name := filepath.ToSlash(tool.DisplayPath(c.Path))
+	oldLabel, newLabel := "a/"+name, "b/"+name
+
+	if !c.Existed {
+		oldLabel = "/dev/null"
+	}
+
+	if deleted {
+		newLabel = "/dev/null"
+	}
+
+	// diff exits 1 when the files differ
+	out, err := exec.Command("diff", "-u", "--label", oldLabel, "--label", newLabel, before, after).Output()
+
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || exitErr.ExitCode() != 1) {
+		return fmt.Sprintf("(%d bytes before, %d bytes now; no diff: %v)\n", len(c.Content), len(current), err)
+	}
+
+	if len(out) == 0 {
+		return "(no difference: the file is as it was)\n"
+	}
+
+	return capLines(string(out), reviewDiffLines)
+}
+
+// capLines returns the first n lines of text, noting how many were left out.
+func capLines(text string, n int) string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) <= n {
+		return text
+	}
+
+	return strings.Join(lines[:n], "") + fmt.Sprintf("... %d more lines; open the file in your editor to see them all\n", len(lines)-n)
+}
+
+// openEditor opens path in $VISUAL or $EDITOR, or vi if neither is set.
+func openEditor(path string) error {
+	editor := cmp.Or(os.Getenv("VISUAL"), os.Getenv("EDITOR"), "vi")
+
+	args := strings.Fields(editor)
+	if len(args) == 0 {
+		args = []string{"vi"}
+	}
+
+	cmd := exec.Command(args[0], append(args[1:], path)...) //nolint:gosec // the user's own editor
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running %s: %w", args[0], err)
+	}
+
+	return nil
+}
+
+// revertedNote tells the model which of its changes the user reverted.
+func revertedNote(paths []string) string {
+	return "I reviewed the files you changed and reverted these to how they were before your last turn: " +
+		strings.Join(paths, ", ") + ". Do not assume your changes to them are in place."
+}
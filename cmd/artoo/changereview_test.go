@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/tool"
+)
+
+func TestParseReviewAnswer(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]reviewAction{
+		"":       reviewKeep,
+		" Y ":    reviewKeep,
+		"r":      reviewRevert,
+		"revert": reviewRevert,
+		"e":      reviewEdit,
+		"A":      reviewKeepRest,
+		"maybe":  reviewUnknown,
+	}
+
+	for answer, want := range tests {
+		if got := parseReviewAnswer(answer); got != want {
+			t.Errorf("parseReviewAnswer(%q) = %v, want %v", answer, got, want)
+		}
+	}
+}
+
+func TestDiffChange(t *testing.T) {
+	t.Parallel()
+
+	if _, err := exec.LookPath("diff"); err != nil {
+		t.Skip("diff not installed")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go")
+
+	if err := os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	modified := tool.Change{Path: path, Existed: true, Content: []byte("package main\n"), Revertible: true}
+	if got := diffChange(modified); !strings.Contains(got, "+func main() {}\n") || !strings.Contains(got, "main.go\n") {
+		t.Errorf("diffChange(modified) = %q, want the added line", got)
+	}
+
+	if got := changeStatus(modified); got != "modified" {
+		t.Errorf("changeStatus(modified) = %q", got)
+	}
+
+	if got := diffChange(tool.Change{Path: path, Revertible: true}); !strings.Contains(got, "--- /dev/null\n") {
+		t.Errorf("diffChange(added) = %q, want a diff from /dev/null", got)
+	}
+
+	deleted := tool.Change{Path: filepath.Join(dir, "gone.go"), Existed: true, Content: []byte("x\n"), Revertible: true}
+	if got := diffChange(deleted); !strings.Contains(got, "+++ /dev/null\n") || !strings.Contains(got, "-x\n") {
+		t.Errorf("diffChange(deleted) = %q, want a diff to /dev/null", got)
+	}
+
+	if got := changeStatus(deleted); got != "deleted" {
+		t.Errorf("changeStatus(deleted) = %q", got)
+	}
+
+	same := tool.Change{Path: path, Existed: true, Content: []byte("package main\n\nfunc main() {}\n"), Revertible: true}
+	if got := diffChange(same); !strings.Contains(got, "no difference") {
+		t.Errorf("diffChange(unchanged) = %q", got)
+	}
+
+	if got := diffChange(tool.Change{Path: path, Existed: true}); !strings.Contains(got, "not kept") {
+		t.Errorf("diffChange(not kept) = %q", got)
+	}
+}
+
+func TestCapLines(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Repeat("line\n", 5)
+
+	if got := capLines(text, 5); got != text {
+		t.Errorf("capLines() at the limit = %q, want unchanged", got)
+	}
+
+	if got, want := capLines(text, 2), "line\nline\n... 3 more lines"; !strings.HasPrefix(got, want) {
+		t.Errorf("capLines() = %q, want prefix %q", got, want)
+	}
+}
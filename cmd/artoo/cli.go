@@ -0,0 +1,128 @@
+// Package main provides the command line: subcommands, their usage and help.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// subcommand runs a mode of artoo with the arguments after its name.
+type subcommand func(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error
+
+// subcommands maps the first command-line argument to its subcommand. With
+// no arguments, artoo runs chat.
+var subcommands = map[string]subcommand{
+	"attach":     runAttach,
+	"batch":      runBatch,
+	"chat":       runChat,
+	"completion": runCompletion,
+	"doctor":     runDoctor,
+	"explain":    runExplain,
+	"history":    runHistory,
+	"login":      runLogin,
+	"maintain":   runMaintain,
+	"models":     runModels,
+	"review":     runReview,
+	"run":        runOnce,
+	"sessions":   runHistory,
+	"stats":      runStats,
+	"version":    runVersion,
+}
+
+const chatUsage = `usage:
+  artoo [chat]                        talk to the agent in the terminal (the default)`
+
+var (
+	errChatUsage = errors.New(chatUsage)
+	errHelpUsage = errors.New("usage: artoo help [command]")
+)
+
+// subcommandUsages are printed by artoo help <command>. Subcommands without
+// arguments are described by their summary.
+var subcommandUsages = map[string]string{
+	"attach":     attachUsage,
+	"batch":      batchUsage,
+	"chat":       chatUsage,
+	"completion": completionUsage,
+	"explain":    explainUsage,
+	"history":    historyUsage,
+	"login":      loginUsage,
+	"maintain":   maintainUsage,
+	"models":     modelsUsage,
+	"review":     reviewUsage,
+	"run":        runUsage,
+	"sessions":   historyUsage,
+	"version":    versionUsage,
+}
+
+// parseCommand splits the arguments after the global flags into the
+// subcommand and its arguments. Help, asked for as artoo help [command],
+// artoo --help or artoo <command> --help, is the help command with the
+// command as its argument.
+func parseCommand(args []string) (string, []string) {
+	switch {
+	case len(args) == 0:
+		return "chat", nil
+	case isHelpFlag(args[0]):
+		return "help", nil
+	case len(args) > 1 && isHelpFlag(args[1]) && args[0] != "help":
+		return "help", args[:1]
+	default:
+		return args[0], args[1:]
+	}
+}
+
+func isHelpFlag(arg string) bool {
+	return arg == "--help" || arg == "-h"
+}
+
+// printHelp writes the usage of the named command to w, or the list of
+// commands without one.
+func printHelp(w io.Writer, args []string) error {
+	if len(args) > 1 {
+		return errHelpUsage
+	}
+
+	if len(args) == 0 {
+		fmt.Fprintln(w, "usage: artoo [--profile <name>] [command] [arguments]")
+		fmt.Fprintln(w, "\ncommands:")
+
+		for _, name := range commandNames() {
+			fmt.Fprintf(w, "  %-12s %s\n", name, cliCommands[name].summary)
+		}
+
+		fmt.Fprintln(w, "\nWithout a command, artoo runs chat. Run artoo help <command> for its arguments.")
+
+		return nil
+	}
+
+	name := args[0]
+
+	cmd, ok := cliCommands[name]
+	if !ok {
+		return fmt.Errorf("%w: %q (run artoo help)", errUnknownCommand, name)
+	}
+
+	if usage, ok := subcommandUsages[name]; ok {
+		fmt.Fprintln(w, usage)
+	} else {
+		fmt.Fprintf(w, "usage:\n  artoo %-29s %s\n", name, cmd.summary)
+	}
+
+	return nil
+}
+
+// runChat implements the `artoo chat` subcommand, the interactive session.
+func runChat(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	if len(args) > 0 {
+		return errChatUsage
+	}
+
+	runREPL(ctx, cfg, client)
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		args     []string
+		wantName string
+		wantArgs []string
+	}{
+		{nil, "chat", nil},
+		{[]string{"run", "hello"}, "run", []string{"hello"}},
+		{[]string{"--help"}, "help", nil},
+		{[]string{"-h"}, "help", nil},
+		{[]string{"review", "--help"}, "help", []string{"review"}},
+		{[]string{"help", "-h"}, "help", []string{"-h"}},
+		{[]string{"run", "explain", "-h"}, "run", []string{"explain", "-h"}},
+	} {
+		name, args := parseCommand(tt.args)
+		if name != tt.wantName || !slices.Equal(args, tt.wantArgs) {
+			t.Errorf("parseCommand(%q) = %q, %q, want %q, %q", tt.args, name, args, tt.wantName, tt.wantArgs)
+		}
+	}
+}
+
+func TestPrintHelp(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := printHelp(&out, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range commandNames() {
+		if !strings.Contains(out.String(), "  "+name+" ") {
+			t.Errorf("expected %s in:\n%s", name, out.String())
+		}
+	}
+
+	out.Reset()
+
+	if err := printHelp(&out, []string{"run"}); err != nil || out.String() != runUsage+"\n" {
+		t.Errorf("help run = %q, %v", out.String(), err)
+	}
+
+	out.Reset()
+
+	if err := printHelp(&out, []string{"doctor"}); err != nil || !strings.Contains(out.String(), "artoo doctor") {
+		t.Errorf("help doctor = %q, %v", out.String(), err)
+	}
+
+	if err := printHelp(&out, []string{"bogus"}); !errors.Is(err, errUnknownCommand) {
+		t.Errorf("help bogus: got %v, want %v", err, errUnknownCommand)
+	}
+
+	if err := printHelp(&out, []string{"run", "review"}); !errors.Is(err, errHelpUsage) {
+		t.Errorf("help with two commands: got %v, want %v", err, errHelpUsage)
+	}
+}
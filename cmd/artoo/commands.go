@@ -0,0 +1,427 @@
+// Package main provides slash command handling for the REPL.
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/conversation"
+	"github.com/aelse/artoo/tool"
+	"github.com/aelse/artoo/ui"
+	"github.com/aelse/artoo/workspace"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+var (
+	errUnknownCommand = errors.New("unknown command")
+	errNoWorkspace    = errors.New("no workspace detected")
+	errImageTooLarge  = errors.New("clipboard image exceeds the 5 MB API limit")
+)
+
+// maxImageBytes is the largest image the API accepts in a single content block.
+const maxImageBytes = 5 * 1024 * 1024
+
+// app bundles the state slash commands operate on.
+type app struct {
+	agent     *agent.Agent
+	term      *ui.Terminal
+	workspace *workspace.Workspace
+	store     conversation.Store                 // saved conversations (nil if history is disabled)
+	pending   []anthropic.ContentBlockParamUnion // attachments sent with the next prompt
+	matches   []conversation.Match               // results of the last /search, numbered from 1
+	changes   *tool.Changes                      // files tools changed, reviewed after each turn
+	config    AppConfig                          // configuration profiles are applied to, as last (re)loaded
+	started   AppConfig                          // configuration at startup, still in effect for restart-only settings
+	profile   string                             // active profile ("" for none)
+}
+
+// command is a slash command handler. args is the text after the command name.
+type command func(a *app, args string)
+
+// commands maps slash command names (without the leading "/") to handlers.
+var commands = map[string]command{
+	"project":  (*app).projectCommand,
+	"paste":    (*app).pasteCommand,
+	"history":  (*app).historyCommand,
+	"resume":   (*app).resumeCommand,
+	"new":      (*app).newCommand,
+	"clear":    (*app).newCommand,
+	"search":   (*app).searchCommand,
+	"branch":   (*app).branchCommand,
+	"export":   (*app).exportCommand,
+	"usage":    (*app).usageCommand,
+	"context":  (*app).contextCommand,
+	"tools":    (*app).toolsCommand,
+	"autonomy": (*app).autonomyCommand,
+	"profile":  (*app).profileCommand,
+	"reload":   (*app).reloadCommand,
+	"restore":  (*app).restoreCommand,
+	"secrets":  (*app).secretsCommand,
+	"grep":     (*app).grepCommand,
+	"ls":       (*app).lsCommand,
+	"help":     (*app).helpCommand,
+}
+
+// send sends input to the agent together with any pending attachments.
+func (a *app) send(ctx context.Context, input string) error {
+	blocks := append(a.pending, anthropic.NewTextBlock(input))
+	a.pending = nil
+
+	resp, err := a.agent.SendBlocks(ctx, a.term, blocks...)
+	if err != nil {
+		return offlineError(a.config, err)
+	}
+
+	return turnError(resp)
+}
+
+// runCommand executes input if it is a slash command, reporting whether it was one.
+func (a *app) runCommand(input string) bool {
+	if !strings.HasPrefix(input, "/") {
+		return false
+	}
+
+	name, args, _ := strings.Cut(strings.TrimPrefix(input, "/"), " ")
+
+	cmd, ok := commands[name]
+	if !ok {
+		a.term.PrintError(fmt.Errorf("%w: /%s", errUnknownCommand, name))
+
+		return true
+	}
+
+	cmd(a, strings.TrimSpace(args))
+
+	return true
+}
+
+// projectCommand lists workspace sub-projects, or focuses one so that
+// search and listing tools default to its directory. "/project ." clears focus.
+func (a *app) projectCommand(args string) {
+	if a.workspace == nil {
+		a.term.PrintError(errNoWorkspace)
+
+		return
+	}
+
+	if args == "" {
+		a.term.PrintInfo(formatProjects(a.workspace))
+
+		return
+	}
+
+	if err := a.workspace.SetActive(args); err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.agent.ToolEnvironment().SetDefaultRoot(a.workspace.SearchRoot())
+	a.term.PrintInfo("Search root: " + a.workspace.SearchRoot())
+}
+
+// formatProjects renders the sub-project list, marking the active one.
+func formatProjects(ws *workspace.Workspace) string {
+	projects := ws.Projects()
+	if len(projects) == 0 {
+		return "No sub-projects detected in " + ws.Root()
+	}
+
+	active := ws.Active()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Sub-projects in %s:", ws.Root())
+
+	for _, p := range projects {
+		marker := " "
+		if active != nil && active.Path == p.Path {
+			marker = "*"
+		}
+
+		fmt.Fprintf(&b, "\n %s %s (%s)", marker, p.Name, p.Kind)
+	}
+
+	return b.String()
+}
+
+// autonomyDescriptions explains each autonomy level for /autonomy.
+var autonomyDescriptions = map[agent.Autonomy]string{
+	agent.AutonomySuggest:  "plan only: tools that modify state are not run",
+	agent.AutonomyAutoEdit: "file edits run unattended; other tools that modify state ask first",
+	agent.AutonomyFullAuto: "every tool runs without asking",
+}
+
+// autonomyCommand shows the autonomy levels, or switches to the named one.
+func (a *app) autonomyCommand(args string) {
+	if args == "" {
+		a.term.PrintInfo(formatAutonomy(a.agent.Autonomy()))
+
+		return
+	}
+
+	level, err := agent.ParseAutonomy(args)
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.agent.SetAutonomy(level)
+	a.term.PrintInfo(fmt.Sprintf("Autonomy: %s (%s)", level, autonomyDescriptions[level]))
+}
+
+// formatAutonomy renders the autonomy levels, marking the current one.
+func formatAutonomy(current agent.Autonomy) string {
+	var b strings.Builder
+	b.WriteString("Autonomy levels:")
+
+	for _, level := range agent.Autonomies {
+		marker := " "
+		if level == current {
+			marker = "*"
+		}
+
+		fmt.Fprintf(&b, "\n %s %-9s  %s", marker, level, autonomyDescriptions[level])
+	}
+
+	return b.String()
+}
+
+// usageCommand shows how the last turn's context splits across categories.
+func (a *app) usageCommand(_ string) {
+	a.term.PrintInfo(formatUsage(a.agent.LastUsage()))
+}
+
+// toolResultHintShare is the share of context above which tool results are
+// called out as the main consumer.
+const toolResultHintShare = 0.5
+
+// formatUsage renders a token breakdown as a table of shares of the input.
+func formatUsage(u agent.Usage) string {
+	if u.InputTokens == 0 {
+		return "No usage recorded yet."
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Last turn: %d input tokens (context), %d output tokens", u.InputTokens, u.OutputTokens)
+
+	for _, c := range u.Categories() {
+		share := float64(c.Tokens) / float64(u.InputTokens)
+		fmt.Fprintf(&b, "\n  %-15s %8d  %5.1f%%", c.Name, c.Tokens, 100*share)
+	}
+
+	if share := float64(u.ToolResults) / float64(u.InputTokens); share > toolResultHintShare {
+		fmt.Fprintf(&b, "\nTool results are %.0f%% of the context; lowering ARTOO_TOOL_RESULT_MAX_CHARS "+
+			"truncates them sooner.", 100*share)
+	}
+
+	return b.String()
+}
+
+// contextCommand charts what occupies the context window and what
+// compressing old tool results would free.
+func (a *app) contextCommand(_ string) {
+	a.term.PrintInfo(formatContext(a.agent.ContextMap()))
+}
+
+const (
+	// contextBarWidth is the width of the longest bar in /context.
+	contextBarWidth = 40
+
+	// maxContextTurns is how many turns /context charts on their own; older
+	// turns share a row.
+	maxContextTurns = 20
+)
+
+// contextRow is one bar of the /context chart.
+type contextRow struct {
+	name     string
+	messages int64
+	results  int64 // tool results that stay
+	freed    int64 // tool results compression would free
+}
+
+// formatContext renders a context map as a bar chart, one row for the system
+// prompt, the tool schemas and each turn.
+func formatContext(m agent.ContextMap) string {
+	if len(m.Turns) == 0 {
+		return "The context holds no conversation yet."
+	}
+
+	rows := []contextRow{
+		{name: "system prompt", messages: m.System},
+		{name: "tool schemas", messages: m.ToolSchemas},
+	}
+
+	first := max(0, len(m.Turns)-maxContextTurns)
+	if first > 0 {
+		older := contextRow{name: fmt.Sprintf("turns 1-%d", first)}
+		for _, t := range m.Turns[:first] {
+			older.messages += t.Messages
+			older.results += t.Results - t.Freed
+			older.freed += t.Freed
+		}
+
+		rows = append(rows, older)
+	}
+
+	for i, t := range m.Turns[first:] {
+		rows = append(rows, contextRow{
+			name:     fmt.Sprintf("turn %d", first+i+1),
+			messages: t.Messages,
+			results:  t.Results - t.Freed,
+			freed:    t.Freed,
+		})
+	}
+
+	var longest int64
+	for _, r := range rows {
+		longest = max(longest, r.messages+r.results+r.freed)
+	}
+
+	var b strings.Builder
+
+	if m.Measured {
+		fmt.Fprintf(&b, "Context: %d tokens", m.Tokens)
+	} else {
+		fmt.Fprintf(&b, "Context: about %d tokens (estimated; no request sent yet)", m.Tokens)
+	}
+
+	if m.Budget > 0 {
+		fmt.Fprintf(&b, " of %d (%.0f%%); trimming starts at %d", m.Budget,
+			100*float64(m.Tokens)/float64(m.Budget), m.TrimAt)
+	}
+
+	for _, r := range rows {
+		fmt.Fprintf(&b, "\n  %-13s %s %8d", r.name, contextBar(r, longest), r.messages+r.results+r.freed)
+
+		if r.freed > 0 {
+			fmt.Fprintf(&b, "  %d freeable", r.freed)
+		}
+	}
+
+	b.WriteString("\n  █ prompts, replies and tool calls  ▒ tool results  ░ freed by compression")
+
+	if freed := m.Freed(); freed > 0 {
+		fmt.Fprintf(&b, "\nCompressing old tool results would free about %d tokens; it happens "+
+			"before any turn is dropped.", freed)
+	} else {
+		b.WriteString("\nNo old tool results to compress.")
+	}
+
+	return b.String()
+}
+
+// contextBar draws r's bar, scaled so a row of longest tokens fills
+// contextBarWidth, and padded to that width.
+func contextBar(r contextRow, longest int64) string {
+	if longest == 0 {
+		return strings.Repeat(" ", contextBarWidth)
+	}
+
+	cells := func(tokens int64) int {
+		return int(tokens * contextBarWidth / longest)
+	}
+
+	messages := cells(r.messages)
+	results := cells(r.messages+r.results) - messages
+	freed := cells(r.messages+r.results+r.freed) - messages - results
+
+	return strings.Repeat("█", messages) + strings.Repeat("▒", results) + strings.Repeat("░", freed) +
+		strings.Repeat(" ", contextBarWidth-messages-results-freed)
+}
+
+// toolsCommand lists the tools the agent has and where each comes from.
+func (a *app) toolsCommand(_ string) {
+	a.term.PrintInfo(formatTools(a.agent.Tools()))
+}
+
+// maxToolDescription is how much of a tool's description /tools shows.
+const maxToolDescription = 70
+
+// toolSources orders the sources in the /tools summary.
+var toolSources = []tool.Source{tool.SourceBuiltin, tool.SourcePlugin}
+
+// formatTools renders one line per tool: its name, source and description,
+// with where a tool comes from and whether it is deferred or withheld.
+func formatTools(tools []agent.ToolStatus) string {
+	counts := map[tool.Source]int{}
+	width := 0
+
+	for _, t := range tools {
+		counts[t.Source]++
+		width = max(width, len(t.Name))
+	}
+
+	var summary []string
+
+	for _, source := range toolSources {
+		if counts[source] > 0 {
+			summary = append(summary, fmt.Sprintf("%d %s", counts[source], source))
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Tools (%s):", strings.Join(summary, ", "))
+
+	for _, t := range tools {
+		description := t.Description
+		if r := []rune(description); len(r) > maxToolDescription {
+			description = string(r[:maxToolDescription-1]) + "…"
+		}
+
+		var notes []string
+		if t.Origin != "" {
+			notes = append(notes, t.Origin)
+		}
+
+		if t.Deferred {
+			notes = append(notes, "deferred until the model enables it")
+		}
+
+		if !t.Allowed {
+			notes = append(notes, "not offered to the model")
+		}
+
+		fmt.Fprintf(&b, "\n  %-*s  %-7s  %s", width, t.Name, t.Source, description)
+
+		if len(notes) > 0 {
+			fmt.Fprintf(&b, " (%s)", strings.Join(notes, "; "))
+		}
+	}
+
+	return b.String()
+}
+
+// pasteCommand attaches the clipboard (an image or text) to the next prompt.
+func (a *app) pasteCommand(_ string) {
+	content, err := ui.ReadClipboard()
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	if content.Image != nil {
+		if len(content.Image) > maxImageBytes {
+			a.term.PrintError(errImageTooLarge)
+
+			return
+		}
+
+		encoded := base64.StdEncoding.EncodeToString(content.Image)
+		a.pending = append(a.pending, anthropic.NewImageBlockBase64(content.MediaType, encoded))
+		a.term.PrintInfo(fmt.Sprintf("Attached clipboard image (%d KB); it will be sent with your next message.",
+			len(content.Image)/1024))
+
+		return
+	}
+
+	a.pending = append(a.pending, anthropic.NewTextBlock("Pasted from clipboard:\n"+content.Text))
+	a.term.PrintInfo(fmt.Sprintf("Attached %d characters of clipboard text; it will be sent with your next message.",
+		len(content.Text)))
+}
@@ -0,0 +1,313 @@
+// Package main provides the completion subcommand generating shell completion scripts.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const completionUsage = `usage:
+  artoo completion bash|zsh|fish      print a completion script for the shell, e.g.
+                                      source <(artoo completion bash) in ~/.bashrc,
+                                      source <(artoo completion zsh) in ~/.zshrc, or
+                                      artoo completion fish > ~/.config/fish/completions/artoo.fish`
+
+var errCompletionUsage = errors.New(completionUsage)
+
+// Kinds of flag values, for completing them.
+const (
+	valueNone    = ""        // the flag takes no value
+	valueText    = "text"    // anything, so nothing is offered
+	valueFile    = "file"    // a path
+	valueSession = "session" // a saved conversation ID
+	valueProfile = "profile" // a profile name
+)
+
+// cliFlag is a command-line flag and the kind of value it takes, or its
+// choices separated by spaces.
+type cliFlag struct {
+	name   string
+	values string
+}
+
+// cliCommand describes a subcommand for completion.
+type cliCommand struct {
+	summary  string
+	words    []string // sub-subcommands, such as batch's submit
+	flags    []cliFlag
+	sessions []string // words followed by a conversation ID ("" for the subcommand itself)
+}
+
+// globalFlags come before the subcommand.
+var globalFlags = []cliFlag{{"--profile", valueProfile}}
+
+// sessionWords are the subcommands of sessions and history.
+var sessionWords = []string{"list", "search", "branch", "export"}
+
+// cliCommands describes each subcommand for completion.
+var cliCommands = map[string]cliCommand{
+	"attach": {summary: "follow a detached run, then continue it", sessions: []string{""}},
+	"batch": {summary: "submit prompts through the Message Batches API", words: []string{"submit", "status", "results"},
+		flags: []cliFlag{{"--apply", valueNone}}},
+	"chat":       {summary: "talk to the agent in the terminal (the default)"},
+	"completion": {summary: "print a shell completion script", words: []string{"bash", "zsh", "fish"}},
+	"doctor":     {summary: "check the configuration and environment"},
+	"explain":    {summary: "explain a directory, file or symbol", flags: []cliFlag{{"--save", valueNone}}},
+	"help":       {summary: "show the commands, or the usage of one"},
+	"history":    {summary: "another name for sessions", words: sessionWords, sessions: []string{"branch", "export"}},
+	"login":      {summary: "check and save an API key"},
+	"maintain": {summary: "run a workflow unattended on a new branch", flags: []cliFlag{
+		{"--branch", valueText}, {"--token-budget", valueText}, {"--tool-budget", valueText},
+		{"--timeout", valueText}, {"--pr", valueNone},
+	}},
+	"models": {summary: "list the models the API key can use"},
+	"review": {summary: "review a diff without editing anything", flags: []cliFlag{
+		{"--staged", valueNone}, {"--json", valueNone}, {"--post", valueNone},
+	}},
+	"run": {summary: "answer one prompt, or run a workflow file", flags: []cliFlag{
+		{"--schema", valueFile}, {"--plan", valueNone}, {"--detach", valueNone}, {"--session", valueSession},
+		{"--tool-choice", "auto any none"}, {"--quiet", valueNone},
+	}},
+	"sessions": {summary: "list, search, branch or export saved conversations", words: sessionWords,
+		sessions: []string{"branch", "export"}},
+	"stats":   {summary: "show local usage statistics"},
+	"version": {summary: "print the version, and look for a newer one", flags: []cliFlag{{"--check", valueNone}}},
+}
+
+// completionScripts generate the script for each shell.
+var completionScripts = map[string]func() string{
+	"bash": bashCompletion,
+	"zsh":  zshCompletion,
+	"fish": fishCompletion,
+}
+
+// runCompletion implements the `artoo completion` subcommand. The scripts
+// call `artoo completion sessions` for the saved conversation IDs.
+func runCompletion(_ context.Context, cfg AppConfig, _ anthropic.Client, args []string) error {
+	if len(args) != 1 {
+		return errCompletionUsage
+	}
+
+	if args[0] == "sessions" {
+		return printSessionIDs(cfg)
+	}
+
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return errCompletionUsage
+	}
+
+	fmt.Print(script())
+
+	return nil
+}
+
+// printSessionIDs prints the IDs of the saved conversations, one per line.
+func printSessionIDs(cfg AppConfig) error {
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	summaries, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	for _, s := range summaries {
+		fmt.Println(s.ID)
+	}
+
+	return nil
+}
+
+// commandNames returns the subcommands in order.
+func commandNames() []string {
+	return slices.Sorted(maps.Keys(cliCommands))
+}
+
+// flagNames returns the names of flags.
+func flagNames(flags []cliFlag) []string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = f.name
+	}
+
+	return names
+}
+
+// bashValues returns the bash command completing a value of the given kind.
+func bashValues(values string) string {
+	switch values {
+	case valueText:
+		return "COMPREPLY=()"
+	case valueFile:
+		return `COMPREPLY=($(compgen -f -- "$cur"))`
+	case valueSession:
+		return `COMPREPLY=($(compgen -W "$(artoo completion sessions 2>/dev/null)" -- "$cur"))`
+	case valueProfile:
+		return `COMPREPLY=($(compgen -W "` + strings.Join(profileNames(), " ") + `" -- "$cur"))`
+	default:
+		return `COMPREPLY=($(compgen -W "` + values + `" -- "$cur"))`
+	}
+}
+
+// bashCompletion returns the bash completion script.
+func bashCompletion() string {
+	var b strings.Builder
+
+	b.WriteString(`# bash completion for artoo. Load it with: source <(artoo completion bash)
+_artoo() {
+    local cur="${COMP_WORDS[COMP_CWORD]}" prev="${COMP_WORDS[COMP_CWORD-1]}"
+
+    # The subcommand follows the global flags
+    local i=1
+    while [[ "${COMP_WORDS[i]}" == --* && $i -lt $COMP_CWORD ]]; do
+        [[ "${COMP_WORDS[i]}" == *=* ]] || ((i++))
+        ((i++))
+    done
+
+    # Flag values
+    case "$prev" in
+`)
+
+	valued := map[string]string{}
+	for _, f := range globalFlags {
+		valued[f.name] = f.values
+	}
+
+	for _, name := range commandNames() {
+		for _, f := range cliCommands[name].flags {
+			if f.values != valueNone {
+				valued[f.name] = f.values
+			}
+		}
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(valued)) {
+		fmt.Fprintf(&b, "        %s) %s; return ;;\n", name, bashValues(valued[name]))
+	}
+
+	fmt.Fprintf(&b, `    esac
+
+    if ((COMP_CWORD <= i)); then
+        COMPREPLY=($(compgen -W "%s %s" -- "$cur"))
+        return
+    fi
+
+    case "${COMP_WORDS[i]}" in
+`, strings.Join(flagNames(globalFlags), " "), strings.Join(commandNames(), " "))
+
+	for _, name := range commandNames() {
+		cmd := cliCommands[name]
+		fmt.Fprintf(&b, "        %s)\n", name)
+
+		for _, after := range cmd.sessions {
+			if after == "" {
+				fmt.Fprintf(&b, "            ((COMP_CWORD == i + 1)) && { %s; return; }\n", bashValues(valueSession))
+			} else {
+				fmt.Fprintf(&b, "            ((COMP_CWORD == i + 2)) && [[ \"${COMP_WORDS[i+1]}\" == %s ]] && { %s; return; }\n",
+					after, bashValues(valueSession))
+			}
+		}
+
+		if len(cmd.words) > 0 {
+			fmt.Fprintf(&b, "            ((COMP_CWORD == i + 1)) && { %s; return; }\n", bashValues(strings.Join(cmd.words, " ")))
+		}
+
+		if len(cmd.flags) > 0 {
+			fmt.Fprintf(&b, "            [[ \"$cur\" == -* ]] && { %s; return; }\n",
+				bashValues(strings.Join(flagNames(cmd.flags), " ")))
+		}
+
+		fmt.Fprintf(&b, "            %s ;;\n", bashValues(valueFile))
+	}
+
+	b.WriteString(`    esac
+}
+
+complete -o filenames -F _artoo artoo
+`)
+
+	return b.String()
+}
+
+// zshCompletion returns the zsh completion script, which runs the bash one
+// through zsh's bash completion support.
+func zshCompletion() string {
+	return "# zsh completion for artoo. Load it with: source <(artoo completion zsh)\n" +
+		"autoload -U +X compinit && compinit\n" +
+		"autoload -U +X bashcompinit && bashcompinit\n\n" +
+		strings.TrimPrefix(bashCompletion(), "# bash completion for artoo. Load it with: source <(artoo completion bash)\n")
+}
+
+// fishValues returns the fish arguments completing a value of the given kind.
+func fishValues(values string) string {
+	switch values {
+	case valueNone:
+		return ""
+	case valueText:
+		return " -x"
+	case valueFile:
+		return " -r -F"
+	case valueSession:
+		return " -xa '(artoo completion sessions 2>/dev/null)'"
+	case valueProfile:
+		return " -xa '" + strings.Join(profileNames(), " ") + "'"
+	default:
+		return " -xa '" + values + "'"
+	}
+}
+
+// fishCompletion returns the fish completion script.
+func fishCompletion() string {
+	var b strings.Builder
+
+	b.WriteString("# fish completion for artoo. Install it with:\n" +
+		"# artoo completion fish > ~/.config/fish/completions/artoo.fish\n" +
+		"complete -c artoo -f\n")
+
+	for _, f := range globalFlags {
+		fmt.Fprintf(&b, "complete -c artoo -n __fish_use_subcommand -l %s%s\n",
+			strings.TrimPrefix(f.name, "--"), fishValues(f.values))
+	}
+
+	for _, name := range commandNames() {
+		fmt.Fprintf(&b, "complete -c artoo -n __fish_use_subcommand -a %s -d '%s'\n", name, cliCommands[name].summary)
+	}
+
+	for _, name := range commandNames() {
+		cmd := cliCommands[name]
+		seen := "__fish_seen_subcommand_from " + name
+
+		if len(cmd.words) > 0 {
+			fmt.Fprintf(&b, "complete -c artoo -n '%s; and not __fish_seen_subcommand_from %s'%s\n",
+				seen, strings.Join(cmd.words, " "), fishValues(strings.Join(cmd.words, " ")))
+		}
+
+		for _, after := range cmd.sessions {
+			condition := seen
+			if after != "" {
+				condition += "; and __fish_seen_subcommand_from " + after
+			}
+
+			fmt.Fprintf(&b, "complete -c artoo -n '%s'%s\n", condition, fishValues(valueSession))
+		}
+
+		for _, f := range cmd.flags {
+			fmt.Fprintf(&b, "complete -c artoo -n '%s' -l %s%s\n", seen, strings.TrimPrefix(f.name, "--"), fishValues(f.values))
+		}
+
+		if len(cmd.words) == 0 && len(cmd.sessions) == 0 {
+			fmt.Fprintf(&b, "complete -c artoo -n '%s' -F\n", seen)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestCLICommands_CoverSubcommands(t *testing.T) {
+	t.Parallel()
+
+	want := slices.Sorted(slices.Values(append(slices.Collect(maps.Keys(subcommands)), "help")))
+
+	if got := commandNames(); !slices.Equal(got, want) {
+		t.Errorf("completed subcommands %v, want %v", got, want)
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	t.Parallel()
+
+	for shell, script := range completionScripts {
+		out := script()
+
+		// Every subcommand, a flag, the profiles and the saved sessions
+		for _, want := range append(commandNames(), "schema", "tool-choice", "explore", "artoo completion sessions") {
+			if !strings.Contains(out, want) {
+				t.Errorf("%s: expected %q in:\n%s", shell, want, out)
+			}
+		}
+	}
+}
+
+func TestRunCompletion_Usage(t *testing.T) {
+	t.Parallel()
+
+	for _, args := range [][]string{nil, {"powershell"}, {"bash", "zsh"}} {
+		err := runCompletion(context.Background(), AppConfig{}, anthropic.Client{}, args)
+		if !errors.Is(err, errCompletionUsage) {
+			t.Errorf("runCompletion(%q) = %v, want usage error", args, err)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Package main provides configuration loading from environment variables.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/conversation"
+	"github.com/aelse/artoo/tool"
+)
+
+const (
+	defaultModel                = "claude-sonnet-4-20250514"
+	defaultMaxTokens            = 8192
+	defaultMaxConcurrentTools   = 4
+	defaultMaxContextTokens     = 180_000
+	defaultToolResultMaxChars   = 10_000
+	defaultPluginTimeout        = 30
+	defaultVerifyRetries        = 2
+	defaultDebug                = false
+)
+
+// AppConfig holds all configuration for the artoo application,
+// loaded from environment variables with sensible defaults.
+type AppConfig struct {
+	Agent        agent.Config
+	Conversation conversation.Config
+	ContextFromModel bool // ARTOO_MAX_CONTEXT_TOKENS is unset, so the context budget follows the model
+	Stats          bool   // Record local usage statistics (opt-in)
+	StatsFile      string // Path of the local statistics file
+	StorageDir     string // Directory for saved conversations
+	HistoryBackend string // Conversation store: "json" (one file per conversation) or "sqlite"
+	Resume         string // ID of a saved conversation to resume at startup
+	Accessible     bool   // Screen-reader friendly output without color, spinners or cursor control
+	MessagesFile   string // JSON file replacing the terminal's built-in messages (see ui.DefaultMessages)
+	ReviewChanges  bool   // Review the files each turn changed, keeping or reverting each
+	DatabaseDSN    string // Database for the db tool, as driver:source (db tool disabled if empty)
+	DatabaseWrite  bool   // Allow the db tool to run statements that modify data
+	HTTPAllow      []string // Domains the http_request tool may contact (local hosts if empty)
+	EnvAllow       []string // Environment variables the env tool may show (toolchain defaults if empty)
+	SecretFiles    []string // Patterns of files read_many and grep withhold (tool.DefaultSecretFiles if empty)
+	InjectionScan  bool     // Scan fetched pages and outside files for prompt injections, warning the user
+	MaxMemoryMB    int      // Virtual memory limit of plugins and the python interpreter, in MiB (none if 0)
+	MaxCPUSeconds  int      // CPU time limit of plugins and the python interpreter (none if 0)
+	MaxOutputBytes int      // Output kept from each stream of a plugin call before the plugin is stopped (none if 0)
+	SystemPromptFile string // File whose content replaces Agent.SystemPrompt
+	Knowledge      string // Shared knowledge base: directory or http(s) URL (.artoo/knowledge if it exists when empty)
+	KnowledgeToken string // Bearer token for a knowledge service
+	Profile        string // Named preset applied over Agent (none if empty)
+	APIKey         string // Anthropic API key
+	BaseURL        string   // API endpoint, e.g. a gateway or proxy (the Anthropic API if empty)
+	APIHeaders     []string // Extra headers sent with every API request, as "Name: value"
+	OfflineModel   string   // Local model used when the API cannot be reached (no fallback if empty)
+	OfflineURL     string   // Anthropic-compatible endpoint serving OfflineModel, e.g. Ollama
+	Instructions   string // Project instructions from settings files, appended to the system prompt
+	UpdateCheck    bool   // Look for a newer release in the background when the REPL starts
+	Debug          bool
+}
+
+// LoadConfig loads configuration from environment variables.
+// Unset variables use sensible defaults.
+func LoadConfig() AppConfig {
+	homeDir, _ := os.UserHomeDir()
+	defaultPluginDir := filepath.Join(homeDir, ".artoo", "plugins")
+
+	return AppConfig{
+		Agent: agent.Config{
+			Model:              getEnv("ARTOO_MODEL", defaultModel),
+			SystemPrompt:       getEnv("ARTOO_SYSTEM_PROMPT", ""),
+			MaxTokens:          getEnvInt64("ARTOO_MAX_TOKENS", defaultMaxTokens),
+			MaxConcurrentTools: getEnvInt("ARTOO_MAX_CONCURRENT_TOOLS", defaultMaxConcurrentTools),
+			PluginDir:          getEnv("ARTOO_PLUGIN_DIR", defaultPluginDir),
+			PluginTimeout:      time.Duration(getEnvInt("ARTOO_PLUGIN_TIMEOUT", defaultPluginTimeout)) * time.Second,
+			Streaming:          getEnvBool("ARTOO_STREAMING", true),
+			DeferTools:         getEnvBool("ARTOO_DEFER_TOOLS", false),
+			ToolCacheTTL:       time.Duration(getEnvInt("ARTOO_TOOL_CACHE_TTL", 0)) * time.Second,
+			SummaryModel:       getEnv("ARTOO_SUMMARY_MODEL", ""),
+			StopSequences:      getEnvList("ARTOO_STOP_SEQUENCES"),
+			Prefill:            getEnv("ARTOO_PREFILL", ""),
+			Autonomy:           getEnvAutonomy("ARTOO_AUTONOMY"),
+			ServerTools:        getEnvList("ARTOO_SERVER_TOOLS"),
+			Tools:              getEnvList("ARTOO_TOOLS"),
+			VerifyCommand:      getEnv("ARTOO_VERIFY_COMMAND", ""),
+			VerifyRetries:      getEnvInt("ARTOO_VERIFY_RETRIES", defaultVerifyRetries),
+			CancelOnFailure:    getEnvBool("ARTOO_CANCEL_ON_FAILURE", false),
+			LongContext:        getEnvBool("ARTOO_LONG_CONTEXT", false),
+			FineGrained:        getEnvBool("ARTOO_FINE_GRAINED_STREAMING", false),
+		},
+		Conversation: conversation.Config{
+			MaxContextTokens:   getEnvInt("ARTOO_MAX_CONTEXT_TOKENS", defaultMaxContextTokens),
+			ToolResultMaxChars: getEnvInt("ARTOO_TOOL_RESULT_MAX_CHARS", defaultToolResultMaxChars),
+		},
+		ContextFromModel: !envSet("ARTOO_MAX_CONTEXT_TOKENS"),
+		Stats:          getEnvBool("ARTOO_STATS", false),
+		StatsFile:      getEnv("ARTOO_STATS_FILE", filepath.Join(homeDir, ".artoo", "stats.json")),
+		StorageDir:     getEnv("ARTOO_STORAGE_DIR", filepath.Join(homeDir, ".artoo", "conversations")),
+		HistoryBackend: getEnv("ARTOO_HISTORY_BACKEND", "json"),
+		Resume:         getEnv("ARTOO_RESUME", ""),
+		Accessible:     getEnvBool("ARTOO_ACCESSIBLE", os.Getenv("TERM") == "dumb"),
+		MessagesFile:   getEnv("ARTOO_MESSAGES_FILE", ""),
+		ReviewChanges:  getEnvBool("ARTOO_REVIEW_CHANGES", false),
+		DatabaseDSN:    getEnv("ARTOO_DB_DSN", ""),
+		DatabaseWrite:  getEnvBool("ARTOO_DB_WRITE", false),
+		HTTPAllow:      getEnvList("ARTOO_HTTP_ALLOW"),
+		EnvAllow:       getEnvList("ARTOO_ENV_ALLOW"),
+		SecretFiles:    getEnvList("ARTOO_SECRET_FILES"),
+		InjectionScan:  getEnvBool("ARTOO_INJECTION_SCAN", true),
+		MaxMemoryMB:    getEnvInt("ARTOO_SUBPROCESS_MAX_MEMORY_MB", 0),
+		MaxCPUSeconds:  getEnvInt("ARTOO_SUBPROCESS_MAX_CPU_SECONDS", 0),
+		MaxOutputBytes: getEnvInt("ARTOO_SUBPROCESS_MAX_OUTPUT_BYTES", tool.DefaultMaxOutputBytes),
+		SystemPromptFile: getEnv("ARTOO_SYSTEM_PROMPT_FILE", ""),
+		Knowledge:      getEnv("ARTOO_KNOWLEDGE", ""),
+		KnowledgeToken: getEnv("ARTOO_KNOWLEDGE_TOKEN", ""),
+		Profile:        getEnv("ARTOO_PROFILE", ""),
+		APIKey:         os.Getenv("ANTHROPIC_API_KEY"),
+		BaseURL:        getEnv("ARTOO_BASE_URL", os.Getenv("ANTHROPIC_BASE_URL")),
+		APIHeaders:     getEnvList("ARTOO_API_HEADERS"),
+		OfflineModel:   getEnv("ARTOO_OFFLINE_MODEL", ""),
+		OfflineURL:     getEnv("ARTOO_OFFLINE_URL", defaultOfflineURL),
+		UpdateCheck:    getEnvBool("ARTOO_UPDATE_CHECK", false),
+		Debug:          getEnvBool("ARTOO_DEBUG", defaultDebug),
+	}
+}
+
+// subprocessLimits returns the limits of the subprocesses tools start.
+func (c AppConfig) subprocessLimits() tool.Limits {
+	return tool.Limits{MemoryMB: c.MaxMemoryMB, CPUSeconds: c.MaxCPUSeconds, OutputBytes: c.MaxOutputBytes}
+}
+
+// agentConfig returns the agent settings with the profile, if any, applied.
+// The profile name is validated at startup.
+func (c AppConfig) agentConfig() agent.Config {
+	if c.Profile == "" {
+		return c.Agent
+	}
+
+	cfg, err := applyProfile(c.Agent, c.Profile)
+	if err != nil {
+		return c.Agent
+	}
+
+	return cfg
+}
+
+// conversationConfig returns the conversation settings for model. Unless
+// ARTOO_MAX_CONTEXT_TOKENS is set, the context budget is derived from the
+// model's window, when it is known: the long-context window with
+// ARTOO_LONG_CONTEXT, if the model has one.
+func (c AppConfig) conversationConfig(model string) conversation.Config {
+	conv := c.Conversation
+
+	limits, ok := agent.LimitsOf(model)
+	if !ok || !c.ContextFromModel {
+		return conv
+	}
+
+	if c.Agent.LongContext {
+		limits, _ = limits.LongContext()
+	}
+
+	conv.MaxContextTokens = limits.ContextBudget()
+
+	return conv
+}
+
+// getEnv returns the value of the environment variable key, or defaultValue if not set.
+func getEnv(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList returns the comma-separated values of the environment variable key,
+// with surrounding whitespace trimmed and empty entries dropped. Unset returns nil.
+func getEnvList(key string) []string {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return nil
+	}
+
+	var list []string
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvAutonomy returns the autonomy level named by the environment variable
+// key, or full-auto if not set or invalid.
+func getEnvAutonomy(key string) agent.Autonomy {
+	level, err := agent.ParseAutonomy(os.Getenv(key))
+	if err != nil {
+		return agent.AutonomyFullAuto
+	}
+	return level
+}
+
+// getEnvInt returns the integer value of the environment variable key,
+// or defaultValue if not set or invalid. Invalid values are logged and default is used.
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if intVal, err := strconv.Atoi(value); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvInt64 returns the int64 value of the environment variable key,
+// or defaultValue if not set or invalid.
+func getEnvInt64(key string, defaultValue int64) int64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return intVal
+		}
+	}
+	return defaultValue
+}
+
+// getEnvBool returns the boolean value of the environment variable key,
+// or defaultValue if not set or invalid.
+// Valid true values: "1", "true", "yes", "on" (case-insensitive).
+// Valid false values: "0", "false", "no", "off" (case-insensitive).
+func getEnvBool(key string, defaultValue bool) bool {
+	if value, exists := os.LookupEnv(key); exists {
+		if b, ok := parseBool(value); ok {
+			return b
+		}
+	}
+	return defaultValue
+}
+
+// parseBool parses a boolean value as getEnvBool accepts it. ok is false if
+// value is not a boolean.
+func parseBool(value string) (b, ok bool) {
+	switch value {
+	case "1", "true", "True", "TRUE", "yes", "Yes", "YES", "on", "On", "ON":
+		return true, true
+	case "0", "false", "False", "FALSE", "no", "No", "NO", "off", "Off", "OFF":
+		return false, true
+	}
+	return false, false
+}
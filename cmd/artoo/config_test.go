@@ -187,3 +187,33 @@ func TestPartialEnvConfig(t *testing.T) {
 		t.Error("should use default debug")
 	}
 }
+
+func TestConversationConfig_FollowsModel(t *testing.T) {
+	t.Parallel()
+
+	cfg := LoadConfig()
+	cfg.ContextFromModel = true
+	cfg.Conversation.MaxContextTokens = 50_000
+
+	if got := cfg.conversationConfig("claude-opus-4-5-20251101").MaxContextTokens; got != 180_000 {
+		t.Errorf("context budget for a known model = %d, want 180000", got)
+	}
+
+	if got := cfg.conversationConfig("qwen3-coder").MaxContextTokens; got != 50_000 {
+		t.Errorf("context budget for an unknown model = %d, want the configured 50000", got)
+	}
+
+	cfg.Agent.LongContext = true
+	if got := cfg.conversationConfig("claude-sonnet-4-20250514").MaxContextTokens; got != 900_000 {
+		t.Errorf("context budget with the long-context beta = %d, want 900000", got)
+	}
+
+	if got := cfg.conversationConfig("claude-opus-4-5-20251101").MaxContextTokens; got != 180_000 {
+		t.Errorf("context budget with the beta on a model without it = %d, want 180000", got)
+	}
+
+	cfg.ContextFromModel = false
+	if got := cfg.conversationConfig("claude-opus-4-5-20251101").MaxContextTokens; got != 50_000 {
+		t.Errorf("context budget with ARTOO_MAX_CONTEXT_TOKENS set = %d, want the configured 50000", got)
+	}
+}
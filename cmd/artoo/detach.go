@@ -0,0 +1,231 @@
+// Package main provides detached background runs and reattaching to them.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/conversation"
+	"github.com/aelse/artoo/ui"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// attachPollInterval is how often a followed session is reloaded from the store.
+const attachPollInterval = time.Second
+
+const attachUsage = `usage:
+  artoo attach <id>                   follow a detached run, then continue it interactively`
+
+var errAttachUsage = errors.New(attachUsage)
+
+// detachedDir holds the prompt, pid and log files of detached runs.
+func detachedDir(cfg AppConfig) string {
+	return filepath.Join(cfg.StorageDir, "detached")
+}
+
+// startDetached starts `artoo run --session <id>` as a background process
+// that outlives the terminal, creating the session first if id is empty.
+// The prompt is passed on stdin and output goes to a log file.
+func startDetached(cfg AppConfig, id, prompt string) error {
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	if id == "" {
+		record := conversation.New().Record()
+		if err := store.Save(record); err != nil {
+			return err
+		}
+
+		id = record.ID
+	} else if _, err := store.Load(id); err != nil {
+		return err
+	}
+
+	dir := detachedDir(cfg)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return fmt.Errorf("creating detached run directory: %w", err)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	logFile, err := os.OpenFile(filepath.Join(dir, id+".log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("creating log file: %w", err)
+	}
+	defer logFile.Close()
+
+	// The prompt is read from a file because this process exits before a
+	// pipe could be drained
+	promptPath := filepath.Join(dir, id+".prompt")
+	if err := os.WriteFile(promptPath, []byte(prompt), 0o600); err != nil {
+		return fmt.Errorf("saving prompt: %w", err)
+	}
+
+	promptFile, err := os.Open(promptPath) //nolint:gosec // path built from the storage directory
+	if err != nil {
+		return err
+	}
+	defer promptFile.Close()
+
+	cmd := exec.Command(exe, detachedArgs(cfg, id)...) //nolint:gosec // re-executes this binary
+	cmd.Stdin = promptFile
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	if cmd.SysProcAttr, err = detachAttr(); err != nil {
+		return err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting detached run: %w", err)
+	}
+
+	pid := strconv.Itoa(cmd.Process.Pid)
+	if err := os.WriteFile(filepath.Join(dir, id+".pid"), []byte(pid), 0o600); err != nil {
+		return fmt.Errorf("recording detached run: %w", err)
+	}
+
+	_ = cmd.Process.Release()
+
+	fmt.Printf("Started detached session %s (pid %s)\n", id, pid)
+	fmt.Printf("Follow it with: artoo attach %s\n", id)
+
+	return nil
+}
+
+// detachedArgs returns the arguments the detached run of session id is
+// started with, keeping the profile this run was started with.
+func detachedArgs(cfg AppConfig, id string) []string {
+	args := []string{"run", "--session", id}
+	if cfg.Profile != "" {
+		args = append([]string{"--profile", cfg.Profile}, args...)
+	}
+
+	return args
+}
+
+// resumeSession loads the saved conversation id into a and saves progress
+// back to it, as detached runs do.
+func resumeSession(cfg AppConfig, a *agent.Agent, id string) error {
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	record, err := store.Load(id)
+	if err != nil {
+		return err
+	}
+
+	a.LoadConversation(record)
+	a.SetStore(store)
+
+	return nil
+}
+
+// runAttach implements the `artoo attach` subcommand. It replays the
+// session, follows it while its detached run is alive, then continues the
+// conversation in the REPL.
+func runAttach(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	if len(args) != 1 {
+		return errAttachUsage
+	}
+
+	id := args[0]
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	term := newTerminal(cfg, false)
+
+	if err := follow(ctx, cfg, store, id, term); err != nil {
+		return err
+	}
+
+	cfg.Resume = id
+	runREPL(ctx, cfg, client)
+
+	return nil
+}
+
+// follow prints the messages of session id as the store is updated, until
+// its detached run (if any) has exited.
+func follow(ctx context.Context, cfg AppConfig, store conversation.Store, id string, term *ui.Terminal) error {
+	pidFile := filepath.Join(detachedDir(cfg), id+".pid")
+	shown := 0
+
+	for {
+		record, err := store.Load(id)
+		if err != nil {
+			return err
+		}
+
+		replay(term, record.Messages[min(shown, len(record.Messages)):])
+		shown = len(record.Messages)
+
+		if !detachedRunning(pidFile) {
+			_ = os.Remove(pidFile)
+			term.PrintInfo("Session " + id + " is not running; continuing interactively.")
+
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(attachPollInterval):
+		}
+	}
+}
+
+// detachedRunning reports whether the process recorded in pidFile is alive.
+func detachedRunning(pidFile string) bool {
+	data, err := os.ReadFile(pidFile) //nolint:gosec // path built from the storage directory
+	if err != nil {
+		return false
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return false
+	}
+
+	return processAlive(pid)
+}
+
+// replay prints stored messages the way the REPL showed them live.
+func replay(term *ui.Terminal, messages []anthropic.MessageParam) {
+	for _, message := range messages {
+		for _, block := range message.Content {
+			switch {
+			case block.OfText != nil && message.Role == anthropic.MessageParamRoleAssistant:
+				term.PrintAssistant(block.OfText.Text)
+			case block.OfText != nil:
+				term.PrintInfo("> " + block.OfText.Text)
+			case block.OfToolUse != nil:
+				input, err := json.Marshal(block.OfToolUse.Input)
+				if err != nil {
+					input = []byte("{}")
+				}
+
+				term.PrintToolCall(block.OfToolUse.Name, string(input))
+			}
+		}
+	}
+}
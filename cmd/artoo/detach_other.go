@@ -0,0 +1,20 @@
+//go:build !unix
+
+package main
+
+import (
+	"errors"
+	"syscall"
+)
+
+var errDetachUnsupported = errors.New("detached runs are not supported on this platform")
+
+// detachAttr reports that detaching is unsupported.
+func detachAttr() (*syscall.SysProcAttr, error) {
+	return nil, errDetachUnsupported
+}
+
+// processAlive reports false, since no detached runs can be started.
+func processAlive(int) bool {
+	return false
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+)
+
+func TestDetachedRunning(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+
+		return path
+	}
+
+	tests := []struct {
+		name    string
+		pidFile string
+		want    bool
+	}{
+		{"running", write("self.pid", strconv.Itoa(os.Getpid())), true},
+		{"missing", filepath.Join(dir, "missing.pid"), false},
+		{"garbage", write("bad.pid", "not a pid"), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := detachedRunning(tt.pidFile); got != tt.want {
+				t.Errorf("detachedRunning = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetachedArgs(t *testing.T) {
+	t.Parallel()
+
+	if got := detachedArgs(AppConfig{}, "s1"); !slices.Equal(got, []string{"run", "--session", "s1"}) {
+		t.Errorf("detachedArgs = %v", got)
+	}
+
+	want := []string{"--profile", "review", "run", "--session", "s1"}
+	if got := detachedArgs(AppConfig{Profile: "review"}, "s1"); !slices.Equal(got, want) {
+		t.Errorf("the profile should be forwarded: got %v, want %v", got, want)
+	}
+}
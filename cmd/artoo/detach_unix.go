@@ -0,0 +1,16 @@
+//go:build unix
+
+package main
+
+import "syscall"
+
+// detachAttr starts the process in its own session so it survives the
+// terminal that launched it closing.
+func detachAttr() (*syscall.SysProcAttr, error) {
+	return &syscall.SysProcAttr{Setsid: true}, nil
+}
+
+// processAlive reports whether a process with the given pid exists.
+func processAlive(pid int) bool {
+	return syscall.Kill(pid, 0) == nil
+}
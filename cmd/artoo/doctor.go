@@ -0,0 +1,380 @@
+// Package main provides the doctor subcommand for diagnosing setup problems.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/tool"
+	"github.com/aelse/artoo/ui"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// doctorAPITimeout bounds the API connectivity check.
+const doctorAPITimeout = 15 * time.Second
+
+var errDoctorProblems = errors.New("problems found")
+
+// checkStatus is the outcome of one doctor check.
+type checkStatus int
+
+const (
+	checkOK checkStatus = iota
+	checkWarn
+	checkFail
+)
+
+// finding is the result of one doctor check, with a suggested fix for
+// anything that is not OK.
+type finding struct {
+	status checkStatus
+	name   string
+	detail string
+	fix    string
+}
+
+// intEnvVars and boolEnvVars are the variables that fall back to their
+// default, without a message, when set to something unparsable.
+var (
+	intEnvVars = []string{
+		"ARTOO_MAX_TOKENS", "ARTOO_MAX_CONCURRENT_TOOLS", "ARTOO_PLUGIN_TIMEOUT", "ARTOO_TOOL_CACHE_TTL",
+		"ARTOO_MAX_CONTEXT_TOKENS", "ARTOO_TOOL_RESULT_MAX_CHARS", "ARTOO_VERIFY_RETRIES",
+		"ARTOO_SUBPROCESS_MAX_MEMORY_MB", "ARTOO_SUBPROCESS_MAX_CPU_SECONDS", "ARTOO_SUBPROCESS_MAX_OUTPUT_BYTES",
+	}
+	boolEnvVars = []string{
+		"ARTOO_STREAMING", "ARTOO_DEFER_TOOLS", "ARTOO_STATS", "ARTOO_ACCESSIBLE", "ARTOO_REVIEW_CHANGES",
+		"ARTOO_LONG_CONTEXT", "ARTOO_FINE_GRAINED_STREAMING", "ARTOO_DB_WRITE", "ARTOO_DEBUG", "ARTOO_CANCEL_ON_FAILURE",
+		"ARTOO_UPDATE_CHECK",
+	}
+)
+
+// runDoctor implements the `artoo doctor` subcommand: it checks the
+// configuration, the external programs tools rely on, the plugins and API
+// access, and prints what is wrong and how to fix it.
+func runDoctor(ctx context.Context, cfg AppConfig, client anthropic.Client, _ []string) error {
+	findings := checkConfig(cfg, os.LookupEnv)
+	findings = append(findings, checkPrograms(exec.LookPath)...)
+	findings = append(findings, checkPlugins(cfg)...)
+	findings = append(findings, checkStorage(cfg)...)
+	findings = append(findings, checkAPI(ctx, cfg, client))
+
+	if failed := printFindings(os.Stdout, findings); failed > 0 {
+		return fmt.Errorf("%d %w", failed, errDoctorProblems)
+	}
+
+	return nil
+}
+
+// checkConfig validates configuration values, including environment
+// variables whose invalid values are otherwise silently replaced by defaults.
+func checkConfig(cfg AppConfig, lookupEnv func(string) (string, bool)) []finding {
+	var findings []finding
+
+	fail := func(name, detail, fix string) {
+		findings = append(findings, finding{checkFail, name, detail, fix})
+	}
+
+	for _, key := range intEnvVars {
+		if value, ok := lookupEnv(key); ok {
+			if _, err := strconv.Atoi(value); err != nil {
+				fail(key, fmt.Sprintf("%q is not an integer; the default is used", value), "set it to a whole number or unset it")
+			}
+		}
+	}
+
+	for _, key := range boolEnvVars {
+		if value, ok := lookupEnv(key); ok {
+			if _, valid := parseBool(value); valid {
+				continue
+			}
+
+			fail(key, fmt.Sprintf("%q is not a boolean; the default is used", value), "use true/false, yes/no, on/off or 1/0")
+		}
+	}
+
+	if value, ok := lookupEnv("ARTOO_AUTONOMY"); ok {
+		if _, err := agent.ParseAutonomy(value); err != nil {
+			fail("ARTOO_AUTONOMY", err.Error()+"; full-auto is used", "set it to suggest, auto-edit or full-auto")
+		}
+	}
+
+	if _, err := loadSettings(".artoo"); err != nil {
+		fail("settings", err.Error(), "fix or remove the file; see Project Settings in CONFIG.md")
+	}
+
+	if cfg.Agent.Model == "" {
+		fail("model", "no model is configured", "set ARTOO_MODEL")
+	}
+
+	for _, limit := range []struct {
+		name  string
+		value int64
+	}{
+		{"ARTOO_MAX_TOKENS", cfg.Agent.MaxTokens},
+		{"ARTOO_MAX_CONCURRENT_TOOLS", int64(cfg.Agent.MaxConcurrentTools)},
+		{"ARTOO_MAX_CONTEXT_TOKENS", int64(cfg.Conversation.MaxContextTokens)},
+		{"ARTOO_TOOL_RESULT_MAX_CHARS", int64(cfg.Conversation.ToolResultMaxChars)},
+	} {
+		if limit.value <= 0 {
+			fail(limit.name, fmt.Sprintf("%d is not positive", limit.value), "set it to a positive number or unset it")
+		}
+	}
+
+	for _, limit := range []struct {
+		name  string
+		value int
+	}{
+		{"ARTOO_SUBPROCESS_MAX_MEMORY_MB", cfg.MaxMemoryMB},
+		{"ARTOO_SUBPROCESS_MAX_CPU_SECONDS", cfg.MaxCPUSeconds},
+		{"ARTOO_SUBPROCESS_MAX_OUTPUT_BYTES", cfg.MaxOutputBytes},
+	} {
+		if limit.value < 0 {
+			fail(limit.name, fmt.Sprintf("%d is negative", limit.value), "set it to 0 for no limit, or a positive number")
+		}
+	}
+
+	model := cfg.agentConfig().Model
+	budget := cfg.conversationConfig(model).MaxContextTokens
+
+	if int64(budget) <= cfg.Agent.MaxTokens {
+		fail("ARTOO_MAX_CONTEXT_TOKENS", fmt.Sprintf("%d leaves no room for the conversation with ARTOO_MAX_TOKENS %d",
+			budget, cfg.Agent.MaxTokens), "raise ARTOO_MAX_CONTEXT_TOKENS or lower ARTOO_MAX_TOKENS")
+	}
+
+	if limits, ok := agent.LimitsOf(model); ok {
+		if cfg.Agent.LongContext {
+			if long, supported := limits.LongContext(); supported {
+				limits = long
+			} else {
+				findings = append(findings, finding{checkWarn, "ARTOO_LONG_CONTEXT",
+					model + " has no long-context window, so the standard one is used", "switch to a model that supports it, such as Claude Sonnet 4"})
+			}
+		}
+
+		if cfg.Agent.MaxTokens > limits.MaxOutputTokens {
+			findings = append(findings, finding{checkWarn, "ARTOO_MAX_TOKENS",
+				fmt.Sprintf("%d is above the %d %s accepts; requests use %d", cfg.Agent.MaxTokens, limits.MaxOutputTokens, model, limits.MaxOutputTokens),
+				"lower it or unset it"})
+		}
+
+		if budget > limits.ContextWindow {
+			findings = append(findings, finding{checkWarn, "ARTOO_MAX_CONTEXT_TOKENS",
+				fmt.Sprintf("%d is above the %d-token window of %s, so long conversations fail unless a beta enables a larger one", budget, limits.ContextWindow, model),
+				"unset it to follow the model's window"})
+		}
+	}
+
+	if err := validateBaseURL(cfg.BaseURL); err != nil {
+		fail("ARTOO_BASE_URL", err.Error(), "set it to the gateway's URL, e.g. https://gateway.example.com, or unset it")
+	}
+
+	if err := validateBaseURL(cfg.OfflineURL); cfg.OfflineModel != "" && err != nil {
+		fail("ARTOO_OFFLINE_URL", err.Error(), "set it to the local model server's URL, e.g. "+defaultOfflineURL)
+	}
+
+	if _, err := parseHeaders(cfg.APIHeaders); err != nil {
+		fail("ARTOO_API_HEADERS", err.Error(), "list headers as Name: value, separated by commas")
+	}
+
+	if err := tool.ValidateSecretFiles(cfg.SecretFiles); err != nil {
+		fail("ARTOO_SECRET_FILES", err.Error(), "list gitignore-style patterns, e.g. .env,*.pem, separated by commas")
+	}
+
+	if cfg.HistoryBackend != "json" && cfg.HistoryBackend != "sqlite" {
+		fail("ARTOO_HISTORY_BACKEND", fmt.Sprintf("%q is not a history backend", cfg.HistoryBackend), "set it to json or sqlite")
+	}
+
+	if err := agent.ValidateServerTools(cfg.Agent.ServerTools); err != nil {
+		fail("ARTOO_SERVER_TOOLS", err.Error()+"; it is left out of requests", "remove it from the list")
+	}
+
+	if err := agent.ValidateSystemPrompt(cfg.Agent.SystemPrompt); err != nil {
+		fail("system prompt", err.Error()+"; the prompt is sent as written", "fix the template syntax")
+	}
+
+	if cfg.SystemPromptFile != "" {
+		if _, err := os.Stat(cfg.SystemPromptFile); err != nil {
+			fail("ARTOO_SYSTEM_PROMPT_FILE", err.Error(), "point it at a readable file or unset it")
+		}
+	}
+
+	if cfg.MessagesFile != "" {
+		if _, err := ui.ReadMessages(cfg.MessagesFile); err != nil {
+			fail("ARTOO_MESSAGES_FILE", err.Error()+"; the built-in messages are used", "fix the file or unset it")
+		}
+	}
+
+	if cfg.Profile != "" {
+		if _, err := applyProfile(cfg.Agent, cfg.Profile); err != nil {
+			fail("profile", err.Error(), "choose one of "+strings.Join(profileNames(), ", "))
+		}
+	}
+
+	if cfg.DatabaseDSN != "" {
+		if _, err := tool.NewDBTool(cfg.DatabaseDSN, cfg.DatabaseWrite); err != nil {
+			fail("ARTOO_DB_DSN", err.Error()+"; the db tool is disabled", "use driver:source, e.g. sqlite:app.db")
+		}
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, finding{status: checkOK, name: "configuration", detail: "valid"})
+	}
+
+	return findings
+}
+
+// checkPrograms reports the external programs tools run.
+func checkPrograms(lookPath func(string) (string, error)) []finding {
+	programs := []struct {
+		name    string
+		status  checkStatus // when missing
+		missing string
+		fix     string
+	}{
+		{"rg", checkFail, "the grep and list tools fail", "install ripgrep (https://github.com/BurntSushi/ripgrep#installation)"},
+		{"git", checkWarn, "{{.GitBranch}} in the system prompt is empty", "install git"},
+		{"python3", checkWarn, "the python tool is disabled", "install Python 3 to enable it"},
+		{"docker", checkWarn, "the docker tools are disabled", "install Docker to enable them"},
+		{"gopls", checkWarn, "find_references is disabled and find_definition needs ctags",
+			"go install golang.org/x/tools/gopls@latest"},
+		{"ctags", checkWarn, "find_definition covers Go modules only, and only with gopls",
+			"install Universal Ctags (https://ctags.io)"},
+	}
+
+	findings := make([]finding, 0, len(programs))
+
+	for _, p := range programs {
+		path, err := lookPath(p.name)
+		if err != nil {
+			findings = append(findings, finding{p.status, p.name, "not found in PATH; " + p.missing, p.fix})
+
+			continue
+		}
+
+		findings = append(findings, finding{status: checkOK, name: p.name, detail: path})
+	}
+
+	return findings
+}
+
+// checkPlugins loads the plugins and verifies their schemas and names.
+func checkPlugins(cfg AppConfig) []finding {
+	dir := cfg.Agent.PluginDir
+	if _, err := os.Stat(dir); errors.Is(err, os.ErrNotExist) {
+		return []finding{{status: checkOK, name: "plugins", detail: "none (" + dir + " does not exist)"}}
+	}
+
+	plugins, errs := tool.LoadPlugins(dir, cfg.Agent.PluginTimeout)
+
+	findings := make([]finding, 0, len(errs)+1)
+	for _, err := range errs {
+		findings = append(findings, finding{checkFail, "plugin", err.Error(), "fix the plugin's schema or remove it from " + dir})
+	}
+
+	if err := tool.Builtins().Register(plugins...); err != nil {
+		findings = append(findings, finding{checkFail, "plugins", err.Error(), "rename or remove the conflicting plugin"})
+	}
+
+	var unversioned []string
+
+	for _, p := range plugins {
+		if pt, ok := p.(*tool.PluginTool); ok && pt.ProtocolVersion() == 0 {
+			unversioned = append(unversioned, pt.Param().Name)
+		}
+	}
+
+	if len(unversioned) > 0 {
+		findings = append(findings, finding{checkWarn, "plugins",
+			"no protocolVersion in the schema of " + strings.Join(unversioned, ", ") + "; assuming the oldest protocol",
+			fmt.Sprintf(`add "protocolVersion": %d to the schema once the plugin follows PLUGIN_EXAMPLE.md`, tool.PluginProtocolVersion)})
+	}
+
+	if len(findings) == 0 {
+		findings = append(findings, finding{status: checkOK, name: "plugins", detail: fmt.Sprintf("%d loaded from %s", len(plugins), dir)})
+	}
+
+	return findings
+}
+
+// checkStorage verifies that saved conversations can be written.
+func checkStorage(cfg AppConfig) []finding {
+	if err := os.MkdirAll(cfg.StorageDir, 0o750); err != nil {
+		return []finding{{checkFail, "storage", err.Error(), "set ARTOO_STORAGE_DIR to a writable directory"}}
+	}
+
+	probe, err := os.CreateTemp(cfg.StorageDir, ".doctor-*")
+	if err != nil {
+		return []finding{{checkFail, "storage", err.Error(), "set ARTOO_STORAGE_DIR to a writable directory"}}
+	}
+
+	_ = probe.Close()
+	_ = os.Remove(probe.Name())
+
+	return []finding{{status: checkOK, name: "storage", detail: filepath.Clean(cfg.StorageDir) + " is writable"}}
+}
+
+// checkAPI verifies the API key and that the configured model is available.
+func checkAPI(ctx context.Context, cfg AppConfig, client anthropic.Client) finding {
+	if cfg.APIKey == "" {
+		return finding{checkFail, "API", "no API key", "set ANTHROPIC_API_KEY, or api_key in .artoo/settings.local.json"}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, doctorAPITimeout)
+	defer cancel()
+
+	model, err := client.Models.Get(ctx, cfg.Agent.Model, anthropic.ModelGetParams{})
+	if err != nil {
+		var apiErr *anthropic.Error
+		if errors.As(err, &apiErr) {
+			switch apiErr.StatusCode {
+			case 401, 403:
+				return finding{checkFail, "API", "the API key was rejected", "check ANTHROPIC_API_KEY"}
+			case 404:
+				return finding{checkFail, "API", fmt.Sprintf("model %q not found", cfg.Agent.Model), "set ARTOO_MODEL to an available model"}
+			}
+		}
+
+		fix := "check your network connection and proxy settings"
+		if cfg.BaseURL != "" {
+			fix = "check that the gateway at " + cfg.BaseURL + " (ARTOO_BASE_URL) is reachable"
+		}
+
+		return finding{checkFail, "API", err.Error(), fix}
+	}
+
+	return finding{status: checkOK, name: "API", detail: "connected; model " + model.ID + " is available"}
+}
+
+// printFindings writes the findings and returns how many failed.
+func printFindings(w io.Writer, findings []finding) int {
+	failed := 0
+
+	for _, f := range findings {
+		mark := "ok  "
+
+		switch f.status {
+		case checkWarn:
+			mark = "warn"
+		case checkFail:
+			mark = "FAIL"
+			failed++
+		}
+
+		fmt.Fprintf(w, "[%s] %s: %s\n", mark, f.name, f.detail)
+
+		if f.fix != "" && f.status != checkOK {
+			fmt.Fprintf(w, "       fix: %s\n", f.fix)
+		}
+	}
+
+	return failed
+}
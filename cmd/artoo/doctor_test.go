@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// findingNames returns the names of the findings with the given status.
+func findingNames(findings []finding, status checkStatus) []string {
+	var names []string
+
+	for _, f := range findings {
+		if f.status == status {
+			names = append(names, f.name)
+		}
+	}
+
+	return names
+}
+
+func TestCheckConfig(t *testing.T) {
+	t.Parallel()
+
+	env := map[string]string{
+		"ARTOO_MAX_TOKENS": "lots",
+		"ARTOO_STREAMING":  "maybe",
+		"ARTOO_DEBUG":      "TRUE",
+		"ARTOO_AUTONOMY":   "sometimes",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		value, ok := env[key]
+
+		return value, ok
+	}
+
+	cfg := LoadConfig()
+	cfg.HistoryBackend = "postgres"
+	cfg.Profile = "fast"
+	cfg.Agent.ServerTools = []string{"web_search"}
+
+	got := strings.Join(findingNames(checkConfig(cfg, lookupEnv), checkFail), ",")
+	if want := "ARTOO_MAX_TOKENS,ARTOO_STREAMING,ARTOO_AUTONOMY,ARTOO_HISTORY_BACKEND,profile"; got != want {
+		t.Errorf("failed checks = %s, want %s", got, want)
+	}
+
+	findings := checkConfig(LoadConfig(), func(string) (string, bool) { return "", false })
+	if len(findings) != 1 || findings[0].status != checkOK {
+		t.Errorf("default configuration should be valid, got %+v", findings)
+	}
+}
+
+func TestCheckPrograms(t *testing.T) {
+	t.Parallel()
+
+	lookPath := func(name string) (string, error) {
+		if name == "git" {
+			return "/usr/bin/git", nil
+		}
+
+		return "", exec.ErrNotFound
+	}
+
+	findings := checkPrograms(lookPath)
+
+	if got := findingNames(findings, checkFail); len(got) != 1 || got[0] != "rg" {
+		t.Errorf("a missing rg should fail, got %v", got)
+	}
+
+	if got := findingNames(findings, checkWarn); len(got) != 4 {
+		t.Errorf("missing optional programs should warn, got %v", got)
+	}
+}
+
+func TestPrintFindings(t *testing.T) {
+	t.Parallel()
+
+	var b strings.Builder
+
+	failed := printFindings(&b, []finding{
+		{status: checkOK, name: "git", detail: "/usr/bin/git"},
+		{checkFail, "rg", "not found in PATH", "install ripgrep"},
+	})
+
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+
+	want := "[ok  ] git: /usr/bin/git\n[FAIL] rg: not found in PATH\n       fix: install ripgrep\n"
+	if b.String() != want {
+		t.Errorf("output = %q, want %q", b.String(), want)
+	}
+}
+
+func TestCheckConfig_ModelLimits(t *testing.T) {
+	t.Parallel()
+
+	cfg := LoadConfig()
+	cfg.Agent.Model = "claude-opus-4-1-20250805"
+	cfg.Agent.MaxTokens = 64_000
+	cfg.ContextFromModel = false
+	cfg.Conversation.MaxContextTokens = 500_000
+	cfg.Agent.LongContext = true
+
+	got := strings.Join(findingNames(checkConfig(cfg, func(string) (string, bool) { return "", false }), checkWarn), ",")
+	if want := "ARTOO_LONG_CONTEXT,ARTOO_MAX_TOKENS,ARTOO_MAX_CONTEXT_TOKENS"; got != want {
+		t.Errorf("warnings = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+// Package main provides the API endpoint configuration: base URL and headers.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+var (
+	errInvalidBaseURL = errors.New("invalid API base URL")
+	errInvalidHeader  = errors.New("invalid API header")
+)
+
+// validateBaseURL checks that base, if set, is an http or https URL with a
+// host, as a gateway or proxy in front of the API would be.
+func validateBaseURL(base string) error {
+	if base == "" {
+		return nil
+	}
+
+	u, err := url.Parse(base)
+	if err != nil {
+		return fmt.Errorf("%w: %w", errInvalidBaseURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("%w: %q (want http:// or https:// and a host)", errInvalidBaseURL, base)
+	}
+
+	if u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("%w: %q has a query or fragment", errInvalidBaseURL, base)
+	}
+
+	return nil
+}
+
+// parseHeaders parses "Name: value" entries into headers.
+func parseHeaders(entries []string) (http.Header, error) {
+	headers := make(http.Header, len(entries))
+
+	for _, entry := range entries {
+		name, value, ok := strings.Cut(entry, ":")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		if !ok || !validHeaderName(name) || strings.ContainsFunc(value, isControl) {
+			return nil, fmt.Errorf("%w: %q (want Name: value)", errInvalidHeader, entry)
+		}
+
+		headers.Add(name, value)
+	}
+
+	return headers, nil
+}
+
+// validHeaderName reports whether name is an HTTP token, as header names must be.
+func validHeaderName(name string) bool {
+	return name != "" && !strings.ContainsFunc(name, func(r rune) bool {
+		return r > '~' || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	})
+}
+
+// isControl reports control characters, which header values must not hold
+// except for tabs.
+func isControl(r rune) bool {
+	return r < ' ' && r != '\t' || r == 0x7f
+}
+
+// clientOptions returns the options creating the API client: the API key,
+// and the base URL and headers when configured.
+func clientOptions(cfg AppConfig) ([]option.RequestOption, error) {
+	opts := []option.RequestOption{option.WithAPIKey(cfg.APIKey)}
+
+	if err := validateBaseURL(cfg.BaseURL); err != nil {
+		return opts, err
+	}
+
+	if cfg.BaseURL != "" {
+		opts = append(opts, option.WithBaseURL(cfg.BaseURL))
+	}
+
+	headers, err := parseHeaders(cfg.APIHeaders)
+	if err != nil {
+		return opts, err
+	}
+
+	for name, values := range headers {
+		opts = append(opts, option.WithHeaderDel(name))
+
+		for _, value := range values {
+			opts = append(opts, option.WithHeaderAdd(name, value))
+		}
+	}
+
+	return opts, nil
+}
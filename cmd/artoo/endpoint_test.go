@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestValidateBaseURL(t *testing.T) {
+	t.Parallel()
+
+	for _, base := range []string{"", "https://gateway.example.com", "http://localhost:4000", "https://proxy.example.com/anthropic/"} {
+		if err := validateBaseURL(base); err != nil {
+			t.Errorf("validateBaseURL(%q) = %v, want nil", base, err)
+		}
+	}
+
+	for _, base := range []string{"gateway.example.com", "ftp://example.com", "https://", "https://example.com/?key=x", "://x"} {
+		if err := validateBaseURL(base); !errors.Is(err, errInvalidBaseURL) {
+			t.Errorf("validateBaseURL(%q) = %v, want errInvalidBaseURL", base, err)
+		}
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	t.Parallel()
+
+	headers, err := parseHeaders([]string{"X-Team: core", "Authorization:Bearer abc:def", "X-Team: tools"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := headers.Values("X-Team"); len(got) != 2 || got[0] != "core" || got[1] != "tools" {
+		t.Errorf("X-Team = %q, want both values", got)
+	}
+
+	if got := headers.Get("Authorization"); got != "Bearer abc:def" {
+		t.Errorf("Authorization = %q", got)
+	}
+
+	for _, entry := range []string{"X-Team", ": value", "Bad Name: x", "X-Line: a\nb"} {
+		if _, err := parseHeaders([]string{entry}); !errors.Is(err, errInvalidHeader) {
+			t.Errorf("parseHeaders(%q) = %v, want errInvalidHeader", entry, err)
+		}
+	}
+}
+
+func TestClientOptions_Gateway(t *testing.T) {
+	t.Parallel()
+
+	var got *http.Request
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id":"claude-test","type":"model","display_name":"Test","created_at":"2025-01-01T00:00:00Z"}`))
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := AppConfig{APIKey: "sk-test", BaseURL: server.URL + "/anthropic", APIHeaders: []string{"X-Gateway-Team: core"}}
+
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := anthropic.NewClient(opts...)
+	if _, err := client.Models.Get(t.Context(), "claude-test", anthropic.ModelGetParams{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.URL.Path != "/anthropic/v1/models/claude-test" {
+		t.Errorf("request path = %q, want it under the base URL", got.URL.Path)
+	}
+
+	if h := got.Header.Get("X-Gateway-Team"); h != "core" {
+		t.Errorf("X-Gateway-Team = %q, want core", h)
+	}
+
+	if _, err := clientOptions(AppConfig{APIHeaders: []string{"nonsense"}}); !errors.Is(err, errInvalidHeader) {
+		t.Errorf("clientOptions with a bad header = %v, want errInvalidHeader", err)
+	}
+}
@@ -0,0 +1,126 @@
+// Package main provides the explain subcommand for onboarding to unfamiliar code.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/knowledge"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const explainUsage = `usage:
+  artoo explain [--save] <path|symbol>
+                                      explain a directory, file or symbol for someone new to
+                                      the code: entry points, data flow and key types, as
+                                      Markdown; --save also adds it to the knowledge base`
+
+var errExplainUsage = errors.New(explainUsage)
+
+// explainPrompt is appended to the system prompt while explaining.
+const explainPrompt = "You are explaining code to an engineer new to it. Read and search, but do not modify " +
+	"anything. Answer in Markdown only, with no preamble."
+
+// runExplain implements the `artoo explain` subcommand. The explanation is
+// written to stdout and tool activity to stderr.
+func runExplain(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	save := false
+	if len(args) > 0 && args[0] == "--save" {
+		save, args = true, args[1:]
+	}
+
+	if len(args) != 1 || strings.HasPrefix(args[0], "-") {
+		return errExplainUsage
+	}
+
+	target := args[0]
+
+	a, err := agent.New(client, explainConfig(cfg), loadTools(cfg)...)
+	if err != nil {
+		return err
+	}
+
+	a.SetConversationConfig(cfg.conversationConfig(a.Model()))
+
+	resp, err := a.SendMessage(ctx, explainRequest(target, isPath(target)), &headlessCallbacks{out: os.Stderr})
+	if err != nil {
+		return err
+	}
+
+	explanation := strings.TrimSpace(resp.Text)
+	fmt.Println(explanation)
+
+	if !save {
+		return nil
+	}
+
+	store := openKnowledge(cfg)
+	if store == nil {
+		store = knowledge.NewDirStore(knowledge.DefaultDir)
+	}
+
+	e, err := store.Add(explainEntry(target, explanation, knowledgeAuthor()))
+	if err != nil {
+		return fmt.Errorf("saving explanation: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Saved to the knowledge base (%s, id %s)\n", store.Location(), e.ID)
+
+	return nil
+}
+
+// isPath reports whether target names an existing file or directory rather
+// than a symbol.
+func isPath(target string) bool {
+	_, err := os.Stat(target)
+
+	return err == nil
+}
+
+// explainConfig returns the agent settings of explain: those of the
+// session's profile, limited to reading.
+func explainConfig(cfg AppConfig) agent.Config {
+	agentCfg := cfg.agentConfig()
+	agentCfg.Tools = readOnlyTools
+	agentCfg.Autonomy = agent.AutonomySuggest
+	agentCfg.SystemPrompt = strings.TrimSpace(agentCfg.SystemPrompt + "\n\n" + explainPrompt)
+	agentCfg.Streaming = false
+
+	return agentCfg
+}
+
+// explainRequest asks for a guided explanation of target.
+func explainRequest(target string, path bool) string {
+	subject := fmt.Sprintf("the code at `%s`. Start from its structure: list and read the files that matter", target)
+	if !path {
+		subject = fmt.Sprintf("the symbol `%s`. Start by searching for its definition, then for its callers", target)
+	}
+
+	return "Explain " + subject + ". Then write a guide with these sections:\n\n" +
+		"1. **Purpose** — what it is for, in a few sentences\n" +
+		"2. **Entry points** — where control enters: exported functions, commands, handlers, with file:line\n" +
+		"3. **Data flow** — how a typical request or value moves through it, step by step\n" +
+		"4. **Key types** — the types and interfaces worth knowing first, and how they relate\n" +
+		"5. **Where to start** — the files to read first and the gotchas to watch for\n\n" +
+		"Cite the files you relied on. Say when something is inferred rather than read."
+}
+
+// explainEntry makes a knowledge base entry of an explanation.
+func explainEntry(target, explanation, author string) knowledge.Entry {
+	tags := []string{"explain"}
+	if name := filepath.Base(target); name != "." && name != string(filepath.Separator) {
+		tags = append(tags, strings.TrimSuffix(name, filepath.Ext(name)))
+	}
+
+	return knowledge.Entry{
+		Title:  "How " + target + " works",
+		Body:   explanation,
+		Tags:   tags,
+		Author: author,
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/agent"
+)
+
+func TestExplainRequest(t *testing.T) {
+	t.Parallel()
+
+	if got := explainRequest("agent", true); !strings.Contains(got, "the code at `agent`") {
+		t.Errorf("path request should name the code, got %q", got)
+	}
+
+	got := explainRequest("Agent.SendMessage", false)
+	if !strings.Contains(got, "the symbol `Agent.SendMessage`") || !strings.Contains(got, "definition") {
+		t.Errorf("symbol request should search for the definition, got %q", got)
+	}
+
+	for _, section := range []string{"Entry points", "Data flow", "Key types"} {
+		if !strings.Contains(got, section) {
+			t.Errorf("request should ask for %q", section)
+		}
+	}
+}
+
+func TestExplainEntry(t *testing.T) {
+	t.Parallel()
+
+	e := explainEntry("tool/grep.go", "# grep\n\nSearches files.", "sam")
+
+	if e.Title != "How tool/grep.go works" || e.Author != "sam" || !strings.HasPrefix(e.Body, "# grep") {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	if !slices.Equal(e.Tags, []string{"explain", "grep"}) {
+		t.Errorf("Tags = %v", e.Tags)
+	}
+
+	if e := explainEntry(".", "text", ""); !slices.Equal(e.Tags, []string{"explain"}) {
+		t.Errorf("Tags for . = %v", e.Tags)
+	}
+}
+
+func TestIsPath(t *testing.T) {
+	t.Parallel()
+
+	if !isPath("explain.go") || isPath("runExplain") {
+		t.Error("isPath should tell files from symbols")
+	}
+}
+
+func TestExplainConfig_Profile(t *testing.T) {
+	t.Parallel()
+
+	cfg := AppConfig{Agent: agent.Config{Model: "default-model", Tools: []string{"write_files"}}, Profile: "explore"}
+
+	got := explainConfig(cfg)
+	if got.Model != profiles["explore"].model {
+		t.Errorf("Model = %q, want the profile's %q", got.Model, profiles["explore"].model)
+	}
+
+	if !slices.Equal(got.Tools, readOnlyTools) || got.Autonomy != agent.AutonomySuggest {
+		t.Errorf("explain should stay read-only, got tools %v autonomy %s", got.Tools, got.Autonomy)
+	}
+
+	if !strings.HasSuffix(got.SystemPrompt, explainPrompt) {
+		t.Errorf("SystemPrompt should end with the explain prompt, got %q", got.SystemPrompt)
+	}
+}
@@ -0,0 +1,108 @@
+// Package main provides /help, documenting slash commands and tools.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/tool"
+)
+
+var errNoHelp = errors.New("no command or tool by that name")
+
+// commandHelp describes each slash command for /help: its arguments and
+// what it does.
+var commandHelp = map[string]struct{ args, summary string }{
+	"help":     {"[command or tool]", "List commands and tools, or document one"},
+	"project":  {"[name or .]", "List workspace sub-projects, or focus searches on one (. clears focus)"},
+	"paste":    {"", "Attach the clipboard (an image or text) to the next prompt"},
+	"history":  {"", "List recent conversations"},
+	"resume":   {"<id or match>", "Continue a saved conversation"},
+	"new":      {"", "Start a fresh conversation"},
+	"clear":    {"", "Start a fresh conversation, like /new"},
+	"search":   {"<text>", "Search saved conversations, numbering the matches for /resume and /branch"},
+	"branch":   {"<match>", "Continue a search match's conversation from the matched turn as a new one"},
+	"export":   {"[path]", "Write the conversation as a Markdown transcript"},
+	"usage":    {"", "Show how the last turn's context splits across categories"},
+	"context":  {"", "Chart what fills the context window and what compressing old results would free"},
+	"tools":    {"", "List the tools and where each comes from"},
+	"autonomy": {"[level]", "Show the autonomy levels, or switch to one"},
+	"profile":  {"[name]", "List the profiles, or switch to one"},
+	"reload":   {"", "Reload the configuration without losing the conversation"},
+	"restore":  {"[number or id]", "List the versions in the trash, or restore one"},
+	"secrets":  {"[allow or deny]", "Show whether secret files are withheld, or allow or withhold them"},
+	"grep":     {"<pattern>", "Search file contents without the model"},
+	"ls":       {"[directory]", "List files without the model"},
+}
+
+// helpCommand lists the slash commands and tools, or documents the command
+// or tool named in args.
+func (a *app) helpCommand(args string) {
+	if args == "" {
+		a.term.PrintInfo(formatHelp(a.agent.Tools()))
+
+		return
+	}
+
+	name := strings.TrimPrefix(args, "/")
+
+	// grep and ls are commands and tools: "/help /grep" means the command
+	var sections []string
+
+	if h, ok := commandHelp[name]; ok {
+		sections = append(sections, commandUsage(name)+"\n\n"+h.summary)
+	}
+
+	if t, ok := a.agent.Tool(name); ok && name == args {
+		sections = append(sections, tool.Help(t))
+	}
+
+	if len(sections) == 0 {
+		a.term.PrintError(fmt.Errorf("%w: %s", errNoHelp, args))
+
+		return
+	}
+
+	a.term.PrintInfo(strings.Join(sections, "\n\n"))
+}
+
+// commandUsage returns how to call the slash command name.
+func commandUsage(name string) string {
+	return strings.TrimSpace("/" + name + " " + commandHelp[name].args)
+}
+
+// formatHelp lists the slash commands with their arguments and the names of
+// the tools.
+func formatHelp(tools []agent.ToolStatus) string {
+	names := slices.Sorted(maps.Keys(commandHelp))
+
+	usages := make([]string, len(names))
+	width := 0
+
+	for i, name := range names {
+		usages[i] = commandUsage(name)
+		width = max(width, len(usages[i]))
+	}
+
+	var b strings.Builder
+
+	b.WriteString("Commands:")
+
+	for i, name := range names {
+		fmt.Fprintf(&b, "\n  %-*s  %s", width, usages[i], commandHelp[name].summary)
+	}
+
+	toolNames := make([]string, len(tools))
+	for i, t := range tools {
+		toolNames[i] = t.Name
+	}
+
+	fmt.Fprintf(&b, "\n\nTools: %s", strings.Join(toolNames, ", "))
+	b.WriteString("\n\n/help <tool> shows a tool's parameters and an example; /tools lists where each comes from.")
+
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/tool"
+)
+
+func TestCommandHelp_CoversCommands(t *testing.T) {
+	t.Parallel()
+
+	if got, want := slices.Sorted(maps.Keys(commandHelp)), slices.Sorted(maps.Keys(commands)); !slices.Equal(got, want) {
+		t.Errorf("commands with help %v, want %v", got, want)
+	}
+}
+
+func TestFormatHelp(t *testing.T) {
+	t.Parallel()
+
+	tools := []agent.ToolStatus{
+		{Info: tool.Info{Name: "grep"}, Allowed: true},
+		{Info: tool.Info{Name: "ls"}, Allowed: true},
+	}
+
+	out := formatHelp(tools)
+
+	for _, want := range []string{"/resume <id or match>", "/help [command or tool]", "Tools: grep, ls"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,498 @@
+// Package main provides conversation history commands for the REPL.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/conversation"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// contextLineLen caps the neighbouring-message lines shown with search matches.
+const contextLineLen = 100
+
+// maxSlugLen caps the title part of exported transcript filenames.
+const maxSlugLen = 50
+
+const historyUsage = `usage:
+  artoo sessions list                 list saved conversations
+  artoo sessions search <query>       search saved conversations
+  artoo sessions branch <id> <index>  copy a conversation up to a message to continue separately
+  artoo sessions export <id> [path]   write a conversation as a Markdown transcript
+                                      (artoo history is another name for sessions)`
+
+var (
+	errNoStore        = errors.New("conversation history is disabled")
+	errUnknownBackend = errors.New("unknown history backend")
+	errResumeUsage    = errors.New("usage: /resume <id | search result number>")
+	errBranchUsage    = errors.New("usage: /branch <search result number>")
+	errSearchUsage    = errors.New("usage: /search <query>")
+	errHistoryUsage   = errors.New(historyUsage)
+	errNoSuchMatch    = errors.New("no such search result")
+)
+
+// openStore opens the conversation store selected by cfg.HistoryBackend.
+func openStore(cfg AppConfig) (conversation.Store, error) {
+	switch cfg.HistoryBackend {
+	case "", "json":
+		return conversation.NewFileStore(cfg.StorageDir)
+	case "sqlite":
+		if err := os.MkdirAll(cfg.StorageDir, 0o750); err != nil {
+			return nil, fmt.Errorf("creating storage directory: %w", err)
+		}
+
+		return conversation.NewSQLiteStore(filepath.Join(cfg.StorageDir, "history.db"))
+	}
+
+	return nil, fmt.Errorf("%w: %q (want json or sqlite)", errUnknownBackend, cfg.HistoryBackend)
+}
+
+// setupHistory opens the store, attaches it to the agent and resumes the
+// conversation named by ARTOO_RESUME. Failures disable history with a warning.
+func setupHistory(cfg AppConfig, a *agent.Agent) conversation.Store {
+	store, err := openStore(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+
+		return nil
+	}
+
+	a.SetStore(store)
+
+	if cfg.Resume != "" {
+		record, err := store.Load(cfg.Resume)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else {
+			a.LoadConversation(record)
+			fmt.Fprintf(os.Stderr, "Resumed %q (%s, %d messages)\n", record.Title, record.ID, len(record.Messages))
+		}
+	}
+
+	return store
+}
+
+// historyCommand lists recent conversations.
+func (a *app) historyCommand(_ string) {
+	if a.store == nil {
+		a.term.PrintError(errNoStore)
+
+		return
+	}
+
+	summaries, err := a.store.List()
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.term.PrintInfo(formatHistory(summaries, a.agent.ConversationID()))
+}
+
+// formatHistory renders conversation summaries, marking the current one.
+func formatHistory(summaries []conversation.Summary, current string) string {
+	if len(summaries) == 0 {
+		return "No saved conversations."
+	}
+
+	var b strings.Builder
+	b.WriteString("Saved conversations:")
+
+	for _, s := range summaries {
+		marker := " "
+		if s.ID == current {
+			marker = "*"
+		}
+
+		fmt.Fprintf(&b, "\n %s %s  %s  %s", marker, s.ID, s.UpdatedAt.Local().Format("2006-01-02 15:04"), s.Title)
+	}
+
+	return b.String()
+}
+
+// resumeCommand loads a saved conversation and continues from where it left off.
+func (a *app) resumeCommand(args string) {
+	if a.store == nil {
+		a.term.PrintError(errNoStore)
+
+		return
+	}
+
+	if args == "" {
+		a.term.PrintError(errResumeUsage)
+
+		return
+	}
+
+	id := args
+	if match, ok := a.match(args); ok {
+		id = match.ID
+	}
+
+	record, err := a.store.Load(id)
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.agent.LoadConversation(record)
+	a.term.PrintInfo(fmt.Sprintf("Resumed %q (%s, %d messages)", record.Title, record.ID, len(record.Messages)))
+}
+
+// newCommand abandons the current conversation and starts a fresh one.
+func (a *app) newCommand(_ string) {
+	a.agent.NewConversation()
+	a.pending = nil
+	a.term.PrintInfo("Started new conversation " + a.agent.ConversationID())
+}
+
+// searchCommand searches saved conversations and numbers the matches so
+// they can be passed to /resume or /branch.
+func (a *app) searchCommand(args string) {
+	if a.store == nil {
+		a.term.PrintError(errNoStore)
+
+		return
+	}
+
+	if args == "" {
+		a.term.PrintError(errSearchUsage)
+
+		return
+	}
+
+	matches, err := a.store.Search(args)
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.matches = matches
+	a.term.PrintInfo(formatMatches(a.store, matches))
+
+	if len(matches) > 0 {
+		a.term.PrintInfo("Use /resume <n> to continue a session or /branch <n> to continue from that turn.")
+	}
+}
+
+// branchCommand copies the session of a search result up to the matched
+// turn and continues it as a new conversation.
+func (a *app) branchCommand(args string) {
+	if a.store == nil {
+		a.term.PrintError(errNoStore)
+
+		return
+	}
+
+	match, ok := a.match(args)
+	if !ok {
+		a.term.PrintError(errBranchUsage)
+
+		return
+	}
+
+	record, err := branch(a.store, match.ID, match.Index)
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.agent.LoadConversation(record)
+	a.term.PrintInfo(fmt.Sprintf("Branched %s into %s (%d messages)", match.ID, record.ID, len(record.Messages)))
+}
+
+// match returns the search result numbered by arg (1-based) from the last /search.
+func (a *app) match(arg string) (conversation.Match, bool) {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 1 || n > len(a.matches) {
+		return conversation.Match{}, false
+	}
+
+	return a.matches[n-1], true
+}
+
+// branch saves a branch of the stored conversation id from message index.
+func branch(store conversation.Store, id string, index int) (conversation.Record, error) {
+	record, err := store.Load(id)
+	if err != nil {
+		return conversation.Record{}, err
+	}
+
+	if index < 0 || index >= len(record.Messages) {
+		return conversation.Record{}, fmt.Errorf("%w: message %d of %s", errNoSuchMatch, index, id)
+	}
+
+	b := conversation.Branch(record, index)
+	if err := store.Save(b); err != nil {
+		return conversation.Record{}, err
+	}
+
+	return b, nil
+}
+
+// formatMatches renders numbered search results grouped by session, each
+// with the neighbouring messages for context.
+func formatMatches(store conversation.Store, matches []conversation.Match) string {
+	if len(matches) == 0 {
+		return "No matches."
+	}
+
+	var b strings.Builder
+
+	records := make(map[string]conversation.Record)
+	lastID := ""
+
+	for i, m := range matches {
+		if m.ID != lastID {
+			fmt.Fprintf(&b, "\n%s  %s  %s\n", m.ID, m.UpdatedAt.Local().Format("2006-01-02 15:04"), m.Title)
+			lastID = m.ID
+		}
+
+		record, ok := records[m.ID]
+		if !ok {
+			record, _ = store.Load(m.ID)
+			records[m.ID] = record
+		}
+
+		before, after := matchContext(record.Messages, m.Index)
+		if before != "" {
+			fmt.Fprintf(&b, "       %s\n", before)
+		}
+
+		fmt.Fprintf(&b, "  [%d] %s: %s\n", i+1, m.Role, m.Snippet)
+
+		if after != "" {
+			fmt.Fprintf(&b, "       %s\n", after)
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// matchContext returns one-line previews of the nearest text messages
+// before and after index.
+func matchContext(messages []anthropic.MessageParam, index int) (string, string) {
+	var before, after string
+
+	for i := index - 1; i >= 0 && before == ""; i-- {
+		before = contextLine(messages[i])
+	}
+
+	for i := index + 1; i < len(messages) && after == ""; i++ {
+		after = contextLine(messages[i])
+	}
+
+	return before, after
+}
+
+// contextLine previews a message as "role: text" on one line.
+func contextLine(message anthropic.MessageParam) string {
+	text := strings.Join(strings.Fields(conversation.MessageText(message)), " ")
+	if text == "" {
+		return ""
+	}
+
+	if r := []rune(text); len(r) > contextLineLen {
+		text = string(r[:contextLineLen]) + "…"
+	}
+
+	return string(message.Role) + ": " + text
+}
+
+// exportCommand writes the current conversation as a Markdown transcript,
+// named after the session title unless a path is given.
+func (a *app) exportCommand(args string) {
+	path, err := exportTranscript(a.agent.ConversationRecord(), args)
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.term.PrintInfo("Wrote " + path)
+}
+
+// exportTranscript writes record to path, or to transcriptFilename(record)
+// in the current directory when path is empty, and returns the path written.
+func exportTranscript(record conversation.Record, path string) (string, error) {
+	if path == "" {
+		path = transcriptFilename(record)
+	}
+
+	if err := os.WriteFile(path, []byte(transcriptMarkdown(record)), 0o600); err != nil {
+		return "", fmt.Errorf("exporting transcript: %w", err)
+	}
+
+	return path, nil
+}
+
+// transcriptFilename names a transcript by date and title, e.g.
+// "2026-02-28-debugging-grep-tool.md", falling back to the ID when untitled.
+func transcriptFilename(record conversation.Record) string {
+	var slug strings.Builder
+
+	for _, r := range strings.ToLower(record.Title) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			slug.WriteRune(r)
+		case slug.Len() > 0 && !strings.HasSuffix(slug.String(), "-"):
+			slug.WriteByte('-')
+		}
+	}
+
+	name := strings.Trim(slug.String(), "-")
+	if r := []rune(name); len(r) > maxSlugLen {
+		name = strings.Trim(string(r[:maxSlugLen]), "-")
+	}
+
+	if name == "" {
+		return record.ID + ".md"
+	}
+
+	return record.CreatedAt.Local().Format(time.DateOnly) + "-" + name + ".md"
+}
+
+// transcriptMarkdown renders the text of a conversation as Markdown. Tool
+// calls are listed by name; tool results are omitted.
+func transcriptMarkdown(record conversation.Record) string {
+	var b strings.Builder
+
+	title := record.Title
+	if title == "" {
+		title = record.ID
+	}
+
+	fmt.Fprintf(&b, "# %s\n\n_%s · %s_\n", title, record.ID, record.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	for _, message := range record.Messages {
+		text := conversation.MessageText(message)
+
+		var tools []string
+		for _, block := range message.Content {
+			if block.OfToolUse != nil {
+				tools = append(tools, "`"+block.OfToolUse.Name+"`")
+			}
+		}
+
+		if text == "" && len(tools) == 0 {
+			continue
+		}
+
+		role := "User"
+		if message.Role == anthropic.MessageParamRoleAssistant {
+			role = "Assistant"
+		}
+
+		fmt.Fprintf(&b, "\n## %s\n\n", role)
+
+		if text != "" {
+			b.WriteString(strings.TrimSpace(text) + "\n")
+		}
+
+		if len(tools) > 0 {
+			if text != "" {
+				b.WriteString("\n")
+			}
+
+			b.WriteString("_Tools: " + strings.Join(tools, ", ") + "_\n")
+		}
+	}
+
+	return b.String()
+}
+
+// runHistory implements the `artoo sessions` subcommand, also named history.
+func runHistory(_ context.Context, cfg AppConfig, _ anthropic.Client, args []string) error {
+	if len(args) == 0 {
+		return errHistoryUsage
+	}
+
+	store, err := openStore(cfg)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		summaries, err := store.List()
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(formatHistory(summaries, ""))
+
+		return nil
+
+	case "search":
+		query := strings.Join(args[1:], " ")
+		if query == "" {
+			return errHistoryUsage
+		}
+
+		matches, err := store.Search(query)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(formatMatches(store, matches))
+
+		if len(matches) > 0 {
+			fmt.Fprintln(os.Stderr, "\nResume with ARTOO_RESUME=<id> artoo, or branch with artoo sessions branch <id> <index>.")
+		}
+
+		return nil
+
+	case "export":
+		if len(args) < 2 || len(args) > 3 {
+			return errHistoryUsage
+		}
+
+		record, err := store.Load(args[1])
+		if err != nil {
+			return err
+		}
+
+		path, err := exportTranscript(record, strings.Join(args[2:], ""))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println("Wrote " + path)
+
+		return nil
+
+	case "branch":
+		if len(args) != 3 {
+			return errHistoryUsage
+		}
+
+		index, err := strconv.Atoi(args[2])
+		if err != nil {
+			return errHistoryUsage
+		}
+
+		record, err := branch(store, args[1], index)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created %s; resume with ARTOO_RESUME=%s artoo\n", record.ID, record.ID)
+
+		return nil
+	}
+
+	return errHistoryUsage
+}
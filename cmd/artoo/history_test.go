@@ -0,0 +1,135 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aelse/artoo/conversation"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestFormatMatches(t *testing.T) {
+	t.Parallel()
+
+	store, err := conversation.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	record := conversation.Record{
+		ID:        "s1",
+		Title:     "Auth fix",
+		UpdatedAt: time.Now(),
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock("login is broken")),
+			anthropic.NewAssistantMessage(anthropic.NewTextBlock("The auth bug is in the token refresh.")),
+			anthropic.NewUserMessage(anthropic.NewTextBlock("great, fix it")),
+		},
+	}
+	if err := store.Save(record); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	matches, err := store.Search("auth bug")
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+
+	out := formatMatches(store, matches)
+
+	for _, want := range []string{"s1", "Auth fix", "[1] assistant:", "user: login is broken", "user: great, fix it"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in output:\n%s", want, out)
+		}
+	}
+}
+
+func TestBranch_SavesCopy(t *testing.T) {
+	t.Parallel()
+
+	store, err := conversation.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	record := conversation.Record{
+		ID: "s1",
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock("one")),
+			anthropic.NewAssistantMessage(anthropic.NewTextBlock("two")),
+			anthropic.NewUserMessage(anthropic.NewTextBlock("three")),
+		},
+	}
+	if err := store.Save(record); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	b, err := branch(store, "s1", 1)
+	if err != nil {
+		t.Fatalf("branch: %v", err)
+	}
+
+	loaded, err := store.Load(b.ID)
+	if err != nil {
+		t.Fatalf("load branch: %v", err)
+	}
+
+	if len(loaded.Messages) != 2 {
+		t.Errorf("expected branch to stop before the next prompt, got %d messages", len(loaded.Messages))
+	}
+
+	if _, err := branch(store, "s1", 5); err == nil {
+		t.Error("expected error for out-of-range index")
+	}
+}
+
+func TestTranscriptFilename(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, 2, 28, 12, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		title string
+		want  string
+	}{
+		{"Debugging grep tool", "2026-02-28-debugging-grep-tool.md"},
+		{"Fix: auth/refresh (v2)!", "2026-02-28-fix-auth-refresh-v2.md"},
+		{"", "s1.md"},
+		{"!!!", "s1.md"},
+	}
+
+	for _, tt := range tests {
+		record := conversation.Record{ID: "s1", Title: tt.title, CreatedAt: created}
+		if got := transcriptFilename(record); got != tt.want {
+			t.Errorf("transcriptFilename(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+}
+
+func TestTranscriptMarkdown(t *testing.T) {
+	t.Parallel()
+
+	record := conversation.Record{
+		ID:    "s1",
+		Title: "Grep fix",
+		Messages: []anthropic.MessageParam{
+			anthropic.NewUserMessage(anthropic.NewTextBlock("fix grep")),
+			anthropic.NewAssistantMessage(anthropic.NewToolUseBlock("t1", map[string]any{}, "grep")),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock("t1", "results", false)),
+			anthropic.NewAssistantMessage(anthropic.NewTextBlock("Fixed.")),
+		},
+	}
+
+	out := transcriptMarkdown(record)
+
+	for _, want := range []string{"# Grep fix", "## User\n\nfix grep", "_Tools: `grep`_", "## Assistant\n\nFixed."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in transcript:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "results") {
+		t.Errorf("expected tool results to be omitted:\n%s", out)
+	}
+}
@@ -0,0 +1,42 @@
+// Package main provides the shared knowledge base setup.
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/knowledge"
+)
+
+// openKnowledge returns the configured knowledge base, or the project's
+// knowledge directory if there is one. It returns nil if there is neither.
+func openKnowledge(cfg AppConfig) knowledge.Store {
+	location := cfg.Knowledge
+	if location == "" {
+		if info, err := os.Stat(knowledge.DefaultDir); err != nil || !info.IsDir() {
+			return nil
+		}
+
+		location = knowledge.DefaultDir
+	}
+
+	return knowledge.Open(location, cfg.KnowledgeToken)
+}
+
+// knowledgeAuthor names the user on knowledge base entries: git's user.name,
+// or the login name.
+func knowledgeAuthor() string {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	if out, err := exec.CommandContext(ctx, "git", "config", "user.name").Output(); err == nil {
+		if name := strings.TrimSpace(string(out)); name != "" {
+			return name
+		}
+	}
+
+	return os.Getenv("USER")
+}
@@ -0,0 +1,142 @@
+// Package main provides the login subcommand saving an API key.
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/charmbracelet/x/term"
+)
+
+const loginUsage = `usage:
+  artoo login                         read an API key (from stdin, hidden on a terminal),
+                                      check that the API accepts it and save it as api_key
+                                      in .artoo/settings.local.json`
+
+var (
+	errLoginUsage = errors.New(loginUsage)
+	errNoKey      = errors.New("no API key given")
+	errLoginCheck = errors.New("the API key could not be checked")
+)
+
+// runLogin implements the `artoo login` subcommand.
+func runLogin(ctx context.Context, cfg AppConfig, _ anthropic.Client, args []string) error {
+	if len(args) > 0 {
+		return errLoginUsage
+	}
+
+	key, err := readAPIKey(os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	cfg.APIKey = key
+
+	opts, err := clientOptions(cfg)
+	if err != nil {
+		return err
+	}
+
+	if f := checkAPI(ctx, cfg, anthropic.NewClient(opts...)); f.status == checkFail {
+		return fmt.Errorf("%w: %s (%s)", errLoginCheck, f.detail, f.fix)
+	}
+
+	path := filepath.Join(".artoo", localSettingsFile)
+	if err := saveLocalSetting(path, "api_key", key); err != nil {
+		return err
+	}
+
+	fmt.Printf("Saved the API key to %s.\n", path)
+
+	if envSet("ANTHROPIC_API_KEY") {
+		fmt.Fprintln(os.Stderr, "Warning: ANTHROPIC_API_KEY is set and takes precedence over the saved key.")
+	}
+
+	// The file holds a secret, so it must not be committed
+	if exec.Command("git", "check-ignore", "-q", path).Run() != nil && insideGitRepo() { //nolint:gosec // fixed arguments
+		fmt.Fprintf(os.Stderr, "Warning: %s is not ignored by git; add it to .gitignore.\n", path)
+	}
+
+	return nil
+}
+
+// readAPIKey reads the key from stdin, without echoing it on a terminal.
+func readAPIKey(stdin *os.File) (string, error) {
+	var key string
+
+	if term.IsTerminal(stdin.Fd()) {
+		fmt.Fprint(os.Stderr, "API key: ")
+
+		data, err := term.ReadPassword(stdin.Fd())
+		fmt.Fprintln(os.Stderr)
+
+		if err != nil {
+			return "", err
+		}
+
+		key = string(data)
+	} else {
+		line, err := bufio.NewReader(stdin).ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", err
+		}
+
+		key = line
+	}
+
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return "", errNoKey
+	}
+
+	return key, nil
+}
+
+// insideGitRepo reports whether the working directory is in a git repository.
+func insideGitRepo() bool {
+	return exec.Command("git", "rev-parse", "--git-dir").Run() == nil
+}
+
+// saveLocalSetting sets one field of the settings file at path, keeping the
+// others. The file is created readable only by the user.
+func saveLocalSetting(path, field string, value any) error {
+	fields := map[string]json.RawMessage{}
+
+	data, err := os.ReadFile(path) //nolint:gosec // project settings file
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return fmt.Errorf("%w: %s: %w", errInvalidSettings, path, err)
+		}
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	fields[field] = encoded
+
+	data, err = json.MarshalIndent(fields, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/tool"
+)
+
+func TestSaveLocalSetting(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), ".artoo", localSettingsFile)
+
+	if err := saveLocalSetting(path, "api_key", "sk-one"); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("permissions %o, want 600", perm)
+	}
+
+	// Other settings are kept when the key is replaced
+	if err := os.WriteFile(path, []byte(`{"api_key": "sk-one", "db_write": true}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveLocalSetting(path, "api_key", "sk-two"); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := readSettings(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if s.APIKey == nil || *s.APIKey != "sk-two" || s.DBWrite == nil || !*s.DBWrite {
+		t.Errorf("settings after login: %+v", s)
+	}
+}
+
+func TestSaveLocalSetting_InvalidFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), localSettingsFile)
+	if err := os.WriteFile(path, []byte("not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := saveLocalSetting(path, "api_key", "sk"); err == nil {
+		t.Error("expected an error for an invalid settings file")
+	}
+}
+
+// Not parallel: it relies on the tool package's default secret files.
+func TestSaveLocalSetting_WithheldFromTools(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".artoo", localSettingsFile)
+
+	if err := saveLocalSetting(path, "api_key", "sk-hunter2"); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := (&tool.ReadManyTool{}).Call(tool.ReadManyParams{Paths: []string{path}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out, "sk-hunter2") {
+		t.Errorf("read_many should withhold the saved API key:\n%s", out)
+	}
+}
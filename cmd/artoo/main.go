@@ -0,0 +1,364 @@
+// Package main is the entry point for the Artoo agent application.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/instructions"
+	"github.com/aelse/artoo/tool"
+	"github.com/aelse/artoo/ui"
+	"github.com/aelse/artoo/workspace"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func main() {
+	ctx := context.Background()
+
+	// A leading --profile selects a preset for the REPL and subcommands alike
+	name, args, err := profileFlag(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	command, args := parseCommand(args)
+
+	// Help needs no configuration, so it works before anything is set up
+	if command == "help" {
+		if err := printHelp(os.Stdout, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(exitStatus(err))
+		}
+
+		return
+	}
+
+	sub, ok := subcommands[command]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Error: %v: %q (run artoo help)\n", errUnknownCommand, command)
+		os.Exit(exitUsage)
+	}
+
+	// doctor reports invalid settings and profiles along with its other checks
+	doctor := command == "doctor"
+
+	cfg, err := loadAppConfig()
+	if err != nil && !doctor {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A prompt that fails to render is sent as written
+	if err := agent.ValidateSystemPrompt(cfg.Agent.SystemPrompt); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: system prompt template: %v\n", err)
+	}
+
+	// Unknown server tools are left out of requests
+	if err := agent.ValidateServerTools(cfg.Agent.ServerTools); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	if name != "" {
+		cfg.Profile = name
+	}
+
+	if cfg.Profile != "" && !doctor {
+		if _, err := applyProfile(cfg.Agent, cfg.Profile); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Create API client, behind a gateway if one is configured
+	opts, err := clientOptions(cfg)
+	if err != nil && !doctor {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	client := anthropic.NewClient(opts...)
+
+	// The session's agent tools share these settings
+	env := tool.NewEnvironment()
+	cfg.Agent.ToolEnvironment = env
+
+	// Files tools overwrite can be restored, in the REPL or a later session
+	env.SetTrash(tool.NewTrash(trashDir(cfg)))
+
+	// Keys and credentials are withheld from the model unless allowed
+	if err := env.SetSecretFiles(cfg.SecretFiles); err != nil && !doctor {
+		fmt.Fprintf(os.Stderr, "Error: ARTOO_SECRET_FILES: %v\n", err)
+		os.Exit(1)
+	}
+
+	env.SetInjectionScan(cfg.InjectionScan)
+	env.SetLimits(cfg.subprocessLimits())
+
+	// Tools and plugins keep throwaway files out of the workspace in a
+	// scratch directory removed when the session ends
+	if _, err := env.NewScratchDir(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: scratch directory: %v\n", err)
+	}
+
+	err = sub(ctx, cfg, client, args)
+
+	env.StopProcesses()
+	env.RemoveScratchDir()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(exitStatus(err))
+	}
+}
+
+// loadAppConfig loads the configuration from environment variables and the
+// project settings files, which fill in what the environment leaves unset,
+// and reads the system prompt file.
+func loadAppConfig() (AppConfig, error) {
+	cfg := LoadConfig()
+
+	projectSettings, err := loadSettings(".artoo")
+	if err != nil {
+		return cfg, err
+	}
+
+	applySettings(&cfg, projectSettings)
+
+	if cfg.SystemPromptFile != "" {
+		data, err := os.ReadFile(cfg.SystemPromptFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: system prompt file: %v\n", err)
+		} else {
+			cfg.Agent.SystemPrompt = string(data)
+		}
+	}
+
+	if cfg.Instructions != "" {
+		cfg.Agent.SystemPrompt = strings.TrimSpace(cfg.Agent.SystemPrompt + "\n\n" + cfg.Instructions)
+	}
+
+	return cfg, nil
+}
+
+// runREPL runs the interactive session until the user quits.
+func runREPL(ctx context.Context, cfg AppConfig, client anthropic.Client) {
+	// Create terminal UI
+	term := newTerminal(cfg, cfg.Agent.Streaming)
+
+	// Load plugins and create agent
+	extraTools := loadTools(cfg)
+	agentCfg := cfg.agentConfig()
+	a, err := agent.New(client, agentCfg, extraTools...)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A local model takes over for the rest of the session if the API cannot be reached
+	a.SetFallback(offlineFallback(cfg, func(err error) { term.PrintWarning(offlineBanner(cfg, err)) }))
+
+	// Update conversation with config (for context management)
+	a.SetConversationConfig(cfg.conversationConfig(a.Model()))
+
+	// Detect monorepo sub-projects so searches can be scoped with /project
+	ws, err := workspace.New(".")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	// What the session runs with: model, workspace, branch, tools and autonomy
+	term.PrintTitle(sessionBanner(ctx, cfg, a, ws))
+
+	// A newer release, if ARTOO_UPDATE_CHECK finds one, is mentioned once
+	updates := checkForUpdate(ctx, cfg)
+
+	// Instruction files (AGENTS.md, CLAUDE.md) from the workspace and its parents
+	if set, err := instructions.Discover("."); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	} else {
+		a.SetInstructions(set)
+	}
+
+	// Conversations are saved after every exchange and can be resumed
+	store := setupHistory(cfg, a)
+
+	// Files changed by tools, for the review after each turn and the summary
+	changes := tool.NewChanges()
+	a.ToolEnvironment().SetChanges(changes)
+
+	session := &app{agent: a, term: term, workspace: ws, store: store, changes: changes, config: cfg, started: cfg, profile: cfg.Profile}
+
+	// Opt-in local usage statistics, saved after every turn
+	usage := openStats(cfg, a)
+	defer saveStats(usage)
+
+	// What the session cost, printed when it ends
+	summary := startSummary(a, usage, changes)
+	defer summary.print()
+
+	// Debug logging if enabled
+	if cfg.Debug {
+		fmt.Fprintf(os.Stderr, "Debug: Model=%s MaxTokens=%d MaxContext=%d\n",
+			agentCfg.Model, agentCfg.MaxTokens, cfg.conversationConfig(agentCfg.Model).MaxContextTokens)
+	}
+
+	// SIGHUP reloads the configuration, like /reload, before the next input
+	// is handled: never while a turn is running
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	// REPL loop: read input, send message, repeat
+	for {
+		select {
+		case notice := <-updates:
+			if notice != "" {
+				term.PrintInfo(notice)
+			}
+
+			updates = nil
+		default:
+		}
+
+		input, err := term.ReadInput()
+		if err != nil {
+			term.PrintError(err)
+
+			break
+		}
+
+		select {
+		case <-hup:
+			session.reloadCommand("")
+		default:
+		}
+
+		// Empty input or quit commands end the loop
+		if input == "" || input == "quit" || input == "exit" {
+			break
+		}
+
+		// Dropped file paths are offered as attachments; checked before slash
+		// commands because absolute paths also start with "/"
+		if session.offerAttachments(input) {
+			continue
+		}
+
+		// Slash commands are handled locally and never sent to the model
+		if session.runCommand(input) {
+			continue
+		}
+
+		// Send message (and any pending attachments) to agent
+		began := time.Now()
+		err = session.send(ctx, input)
+		summary.turn(time.Since(began))
+		if err != nil {
+			term.PrintError(err)
+		}
+
+		session.reviewTurn()
+
+		// Print spacing between iterations, then the rolling task summary
+		fmt.Println()
+
+		if a.Offline() {
+			term.PrintWarning("OFFLINE MODE: answers come from the local model " + cfg.OfflineModel)
+		}
+
+		term.PrintStatus(a.UpdateSummary(ctx))
+		saveStats(usage)
+	}
+}
+
+// newTerminal creates a terminal UI honouring the accessibility setting.
+func newTerminal(cfg AppConfig, streaming bool) *ui.Terminal {
+	term := ui.NewTerminal(streaming)
+	term.SetAccessible(cfg.Accessible)
+
+	if cfg.MessagesFile != "" {
+		messages, err := ui.ReadMessages(cfg.MessagesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: messages file: %v\n", err)
+		} else {
+			_ = term.SetMessages(messages) // validated when read
+		}
+	}
+
+	return term
+}
+
+// loadTools returns the tools added to the built-in ones: plugins, the
+// http_request and env tools with their configured allowlists, the python,
+// docker and symbol tools if those programs are installed, the knowledge tool
+// if there is a knowledge base and, if a database is configured, the db tool.
+func loadTools(cfg AppConfig) []tool.Tool {
+	httpAllow := cfg.HTTPAllow
+	if len(httpAllow) == 0 {
+		httpAllow = tool.DefaultHTTPAllow
+	}
+
+	envAllow := cfg.EnvAllow
+	if len(envAllow) == 0 {
+		envAllow = tool.DefaultEnvAllow
+	}
+
+	tools := append(loadAndValidatePlugins(cfg),
+		tool.WrapTypedTool(tool.NewHTTPRequestTool(httpAllow)),
+		tool.WrapTypedTool(tool.NewEnvTool(envAllow)))
+	tools = append(tools, tool.PythonTools()...)
+	tools = append(tools, tool.DockerTools()...)
+	tools = append(tools, tool.SymbolTools()...)
+
+	if store := openKnowledge(cfg); store != nil {
+		tools = append(tools, tool.WrapTypedTool(tool.NewKnowledgeTool(store, knowledgeAuthor())))
+	}
+
+	if cfg.DatabaseDSN == "" {
+		return tools
+	}
+
+	db, err := tool.NewDBTool(cfg.DatabaseDSN, cfg.DatabaseWrite)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: db tool disabled: %v\n", err)
+
+		return tools
+	}
+
+	return append(tools, tool.WrapTypedTool(db))
+}
+
+func loadAndValidatePlugins(cfg AppConfig) []tool.Tool {
+	plugins, errs := tool.LoadPlugins(cfg.Agent.PluginDir, cfg.Agent.PluginTimeout)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	if len(plugins) == 0 {
+		return nil
+	}
+
+	// A plugin may not replace a built-in tool
+	if err := tool.Builtins().Register(plugins...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: plugin: %v\n", err)
+		os.Exit(1)
+	}
+
+	if cfg.Debug {
+		fmt.Fprintf(os.Stderr, "Debug: Loaded %d plugins\n", len(plugins))
+		for _, p := range plugins {
+			fmt.Fprintf(os.Stderr, "Debug:   - %s\n", p.Param().Name)
+		}
+	}
+
+	return plugins
+}
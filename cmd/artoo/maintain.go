@@ -0,0 +1,439 @@
+// Package main provides the maintain subcommand for scheduled chores.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const maintainUsage = `usage:
+  artoo maintain [--branch <name>] [--token-budget <n>] [--tool-budget <n>] [--timeout <duration>]
+                 [--pr] <workflow.yaml> [name=value...]
+                                      run a workflow unattended, as from cron or CI: on a new
+                                      branch (artoo/<workflow>-<time> by default), within token,
+                                      tool call and time budgets, committing the result and,
+                                      with --pr, opening a pull request (needs gh); prints a
+                                      JSON report`
+
+// maintainDefaultTimeout bounds a maintenance run without --timeout.
+const maintainDefaultTimeout = time.Hour
+
+var (
+	errMaintainUsage  = errors.New(maintainUsage)
+	errDirtyWorktree  = errors.New("the working tree has uncommitted changes; maintenance runs start from a clean tree")
+	errBudgetExceeded = errors.New("budget exceeded")
+	errDetachedPR     = errors.New("--pr needs a branch checked out to open the pull request against")
+)
+
+// maintainOptions are the maintain subcommand's arguments.
+type maintainOptions struct {
+	branch      string
+	tokenBudget int64
+	toolBudget  int64
+	timeout     time.Duration
+	pr          bool
+	workflow    string
+	vars        []string
+}
+
+// parseMaintainArgs parses the maintain subcommand's arguments.
+func parseMaintainArgs(args []string) (maintainOptions, error) {
+	opts := maintainOptions{timeout: maintainDefaultTimeout}
+
+	for len(args) > 0 && strings.HasPrefix(args[0], "--") {
+		flag := args[0]
+
+		if flag == "--pr" {
+			opts.pr, args = true, args[1:]
+
+			continue
+		}
+
+		if len(args) < 2 {
+			return opts, errMaintainUsage
+		}
+
+		value := args[1]
+		args = args[2:]
+
+		var err error
+
+		switch flag {
+		case "--branch":
+			opts.branch = value
+		case "--token-budget":
+			opts.tokenBudget, err = strconv.ParseInt(value, 10, 64)
+		case "--tool-budget":
+			opts.toolBudget, err = strconv.ParseInt(value, 10, 64)
+		case "--timeout":
+			opts.timeout, err = time.ParseDuration(value)
+		default:
+			return opts, errMaintainUsage
+		}
+
+		if err != nil || opts.tokenBudget < 0 || opts.toolBudget < 0 || opts.timeout <= 0 {
+			return opts, fmt.Errorf("%w\n\ninvalid %s %q", errMaintainUsage, flag, value)
+		}
+	}
+
+	if len(args) == 0 || !isWorkflowFile(args[0]) {
+		return opts, errMaintainUsage
+	}
+
+	opts.workflow, opts.vars = args[0], args[1:]
+
+	return opts, nil
+}
+
+// budget is a Recorder that cancels the run once it has used more tokens or
+// tool calls than allowed, passing usage on to the next recorder.
+type budget struct {
+	next   agent.Recorder // nil if statistics are off
+	cancel context.CancelCauseFunc
+
+	maxTokens, maxTools int64 // 0 is unlimited
+
+	mu     sync.Mutex
+	tokens int64
+	tools  int64
+}
+
+var _ agent.Recorder = (*budget)(nil)
+
+// RecordUsage implements agent.Recorder.
+func (b *budget) RecordUsage(inputTokens, outputTokens int64) {
+	if b.next != nil {
+		b.next.RecordUsage(inputTokens, outputTokens)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens += inputTokens + outputTokens
+	if b.maxTokens > 0 && b.tokens > b.maxTokens {
+		b.cancel(fmt.Errorf("%w: used %d of %d tokens", errBudgetExceeded, b.tokens, b.maxTokens))
+	}
+}
+
+// RecordTool implements agent.Recorder.
+func (b *budget) RecordTool(name string, isError bool) {
+	if b.next != nil {
+		b.next.RecordTool(name, isError)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tools++
+	if b.maxTools > 0 && b.tools > b.maxTools {
+		b.cancel(fmt.Errorf("%w: made %d of %d tool calls", errBudgetExceeded, b.tools, b.maxTools))
+	}
+}
+
+// used returns the tokens and tool calls used so far.
+func (b *budget) used() (tokens, tools int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.tokens, b.tools
+}
+
+// maintenanceReport is the machine-readable outcome of a maintenance run.
+type maintenanceReport struct {
+	Workflow    string       `json:"workflow"`
+	Status      string       `json:"status"` // ok, no_changes, failed or budget_exceeded
+	Error       string       `json:"error,omitempty"`
+	Base        string       `json:"base"`
+	Branch      string       `json:"branch,omitempty"` // omitted when nothing was committed
+	Commits     int          `json:"commits"`
+	PullRequest string       `json:"pull_request,omitempty"`
+	Steps       []stepReport `json:"steps"`
+	Tokens      int64        `json:"tokens"`
+	ToolCalls   int64        `json:"tool_calls"`
+	Seconds     float64      `json:"seconds"`
+}
+
+type stepReport struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Attempts int    `json:"attempts"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// newStepReports converts workflow results for the report.
+func newStepReports(results []stepResult) []stepReport {
+	steps := make([]stepReport, 0, len(results))
+	for _, r := range results {
+		steps = append(steps, stepReport{Name: r.name, Status: string(r.status), Attempts: r.attempts, Detail: r.detail})
+	}
+
+	return steps
+}
+
+// reportStatus classifies a run's outcome.
+func reportStatus(err error, commits int) string {
+	switch {
+	case errors.Is(err, errBudgetExceeded):
+		return "budget_exceeded"
+	case err != nil:
+		return "failed"
+	case commits == 0:
+		return "no_changes"
+	}
+
+	return "ok"
+}
+
+// maintenanceBranch names the branch for a run started at now.
+func maintenanceBranch(wf workflow, path string, now time.Time) string {
+	name := wf.Name
+	if name == "" {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	return "artoo/" + slugifyBranch(name) + "-" + now.Format("20060102-150405")
+}
+
+// slugifyBranch reduces a name to characters safe in a branch name.
+func slugifyBranch(name string) string {
+	var b strings.Builder
+
+	dash := false
+
+	for _, r := range strings.ToLower(name) {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			b.WriteRune(r)
+
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+
+			dash = true
+		}
+	}
+
+	if slug := strings.TrimSuffix(b.String(), "-"); slug != "" {
+		return slug
+	}
+
+	return "maintenance"
+}
+
+// runMaintain implements the `artoo maintain` subcommand. The report is
+// written to stdout and progress to stderr; the exit status is non-zero
+// unless the workflow succeeded.
+func runMaintain(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	opts, err := parseMaintainArgs(args)
+	if err != nil {
+		return err
+	}
+
+	vars, err := parseWorkflowVars(opts.vars)
+	if err != nil {
+		return err
+	}
+
+	wf, err := loadWorkflow(opts.workflow, vars)
+	if err != nil {
+		return err
+	}
+
+	started := time.Now()
+
+	if opts.branch == "" {
+		opts.branch = maintenanceBranch(wf, opts.workflow, started)
+	}
+
+	base, err := startMaintenanceBranch(ctx, opts.branch, opts.pr)
+	if err != nil {
+		return err
+	}
+
+	// Budgets end the agent's work; the branch is still committed and the
+	// base branch restored afterwards
+	runCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	runCtx, cancelTimeout := context.WithTimeoutCause(runCtx, opts.timeout,
+		fmt.Errorf("%w: ran for the %s timeout", errBudgetExceeded, opts.timeout))
+	defer cancelTimeout()
+
+	cfg.Agent.Streaming = false
+	agentCfg := cfg.agentConfig()
+
+	a, err := agent.New(client, agentCfg, loadTools(cfg)...)
+	if err != nil {
+		return err
+	}
+
+	a.SetConversationConfig(cfg.conversationConfig(a.Model()))
+
+	store := openStats(cfg, a)
+	defer saveStats(store)
+
+	limits := &budget{cancel: cancel, maxTokens: opts.tokenBudget, maxTools: opts.toolBudget}
+	if store != nil {
+		limits.next = store
+	}
+
+	a.SetRecorder(limits)
+
+	fmt.Fprintf(os.Stderr, "maintenance: %s on branch %s from %s\n", opts.workflow, opts.branch, base)
+
+	results, runErr := newWorkflowRunner(a, agentCfg.Tools, os.Stderr).run(runCtx, wf)
+	if cause := context.Cause(runCtx); runErr != nil && cause != nil {
+		runErr = cause
+	}
+
+	report := maintenanceReport{Workflow: opts.workflow, Base: base, Steps: newStepReports(results)}
+	report.Tokens, report.ToolCalls = limits.used()
+
+	// Finishing up must not be cut short by the budget that ended the run
+	report.Commits, report.PullRequest, err = finishMaintenanceBranch(ctx, base, opts, wf, results)
+	if err != nil && runErr == nil {
+		runErr = err
+	}
+
+	if report.Commits > 0 {
+		report.Branch = opts.branch
+	}
+
+	report.Status = reportStatus(runErr, report.Commits)
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+
+	report.Seconds = time.Since(started).Round(time.Millisecond).Seconds()
+
+	out, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(out))
+
+	return runErr
+}
+
+// startMaintenanceBranch checks the working tree is clean and switches to a
+// new branch, returning the branch, or commit, it started from.
+func startMaintenanceBranch(ctx context.Context, branch string, pr bool) (string, error) {
+	status, err := output(ctx, "git", "status", "--porcelain")
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(status) != "" {
+		return "", errDirtyWorktree
+	}
+
+	base, err := output(ctx, "git", "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	base = strings.TrimSpace(base)
+
+	// CI often checks out a commit rather than a branch
+	if base == "HEAD" {
+		if pr {
+			return "", errDetachedPR
+		}
+
+		if base, err = output(ctx, "git", "rev-parse", "HEAD"); err != nil {
+			return "", err
+		}
+
+		base = strings.TrimSpace(base)
+	}
+
+	if _, err := output(ctx, "git", "switch", "-q", "-c", branch); err != nil {
+		return "", err
+	}
+
+	return base, nil
+}
+
+// finishMaintenanceBranch commits what the workflow left uncommitted,
+// optionally pushes the branch and opens a pull request, and switches back
+// to base. A branch without commits is deleted.
+func finishMaintenanceBranch(
+	ctx context.Context, base string, opts maintainOptions, wf workflow, results []stepResult,
+) (int, string, error) {
+	title := maintenanceTitle(wf, opts.workflow)
+	body := maintenanceBody(opts.workflow, results)
+
+	status, err := output(ctx, "git", "status", "--porcelain")
+	if err == nil && strings.TrimSpace(status) != "" {
+		if _, err = output(ctx, "git", "add", "-A"); err == nil {
+			_, err = output(ctx, "git", "commit", "-q", "-m", title, "-m", body)
+		}
+	}
+
+	if err != nil {
+		// Leave the branch checked out so nothing is lost
+		return 0, "", fmt.Errorf("committing on %s: %w", opts.branch, err)
+	}
+
+	count, err := output(ctx, "git", "rev-list", "--count", base+".."+opts.branch)
+	if err != nil {
+		return 0, "", err
+	}
+
+	commits, _ := strconv.Atoi(strings.TrimSpace(count))
+
+	if _, err := output(ctx, "git", "checkout", "-q", base); err != nil {
+		return commits, "", err
+	}
+
+	if commits == 0 {
+		_, err := output(ctx, "git", "branch", "-q", "-D", opts.branch)
+
+		return 0, "", err
+	}
+
+	if !opts.pr {
+		return commits, "", nil
+	}
+
+	if _, err := output(ctx, "git", "push", "-q", "-u", "origin", opts.branch); err != nil {
+		return commits, "", err
+	}
+
+	url, err := output(ctx, "gh", "pr", "create", "--base", base, "--head", opts.branch, "--title", title, "--body", body)
+
+	return commits, strings.TrimSpace(url), err
+}
+
+// maintenanceTitle is the commit and pull request title of a run.
+func maintenanceTitle(wf workflow, path string) string {
+	if wf.Name != "" {
+		return "Automated maintenance: " + wf.Name
+	}
+
+	return "Automated maintenance: " + filepath.Base(path)
+}
+
+// maintenanceBody describes a run's steps for the commit and pull request.
+func maintenanceBody(path string, results []stepResult) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Made by artoo maintain running %s.\n", filepath.Base(path))
+
+	for _, r := range results {
+		fmt.Fprintf(&b, "\n- %s: %s", r.name, r.describe())
+	}
+
+	return b.String()
+}
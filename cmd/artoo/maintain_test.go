@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseMaintainArgs(t *testing.T) {
+	t.Parallel()
+
+	opts, err := parseMaintainArgs([]string{"--token-budget", "50000", "--tool-budget", "40", "--timeout", "20m", "--pr",
+		"bump.yaml", "dep=x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.tokenBudget != 50_000 || opts.toolBudget != 40 || opts.timeout != 20*time.Minute || !opts.pr {
+		t.Errorf("unexpected options %+v", opts)
+	}
+
+	if opts.workflow != "bump.yaml" || len(opts.vars) != 1 || opts.branch != "" {
+		t.Errorf("unexpected workflow %q vars %q", opts.workflow, opts.vars)
+	}
+
+	if opts, _ := parseMaintainArgs([]string{"bump.yml"}); opts.timeout != maintainDefaultTimeout {
+		t.Errorf("default timeout = %s", opts.timeout)
+	}
+
+	for _, args := range [][]string{
+		nil,
+		{"do the chores"},
+		{"--timeout", "soon", "bump.yaml"},
+		{"--token-budget", "-1", "bump.yaml"},
+		{"--branch"},
+		{"--force", "x", "bump.yaml"},
+	} {
+		if _, err := parseMaintainArgs(args); !errors.Is(err, errMaintainUsage) {
+			t.Errorf("parseMaintainArgs(%q) error = %v, want errMaintainUsage", args, err)
+		}
+	}
+}
+
+func TestBudget(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	b := &budget{cancel: cancel, maxTokens: 1000, maxTools: 3}
+
+	b.RecordUsage(400, 100)
+	b.RecordTool("grep", false)
+
+	if ctx.Err() != nil {
+		t.Fatal("budget should not be exceeded yet")
+	}
+
+	b.RecordUsage(400, 200)
+
+	if !errors.Is(context.Cause(ctx), errBudgetExceeded) {
+		t.Errorf("expected errBudgetExceeded, got %v", context.Cause(ctx))
+	}
+
+	if tokens, tools := b.used(); tokens != 1100 || tools != 1 {
+		t.Errorf("used() = %d, %d", tokens, tools)
+	}
+}
+
+func TestReportStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err     error
+		commits int
+		want    string
+	}{
+		{nil, 2, "ok"},
+		{nil, 0, "no_changes"},
+		{errWorkflowFailed, 1, "failed"},
+		{errBudgetExceeded, 1, "budget_exceeded"},
+	}
+
+	for _, tt := range tests {
+		if got := reportStatus(tt.err, tt.commits); got != tt.want {
+			t.Errorf("reportStatus(%v, %d) = %q, want %q", tt.err, tt.commits, got, tt.want)
+		}
+	}
+}
+
+func TestMaintenanceBranch(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 1, 4, 5, 6, 0, time.UTC)
+
+	if got := maintenanceBranch(workflow{Name: "Bump deps: Go!"}, "x.yaml", now); got != "artoo/bump-deps-go-20260301-040506" {
+		t.Errorf("maintenanceBranch() = %q", got)
+	}
+
+	if got := maintenanceBranch(workflow{}, "ci/todo_triage.yaml", now); got != "artoo/todo-triage-20260301-040506" {
+		t.Errorf("maintenanceBranch() without a name = %q", got)
+	}
+}
+
+func gitCommand(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+func TestMaintenanceBranchLifecycle(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	gitCommand(t, "init", "-q", "-b", "main")
+	gitCommand(t, "commit", "-q", "--allow-empty", "-m", "initial")
+
+	ctx := context.Background()
+	opts := maintainOptions{branch: "artoo/chores", workflow: "chores.yaml"}
+	results := []stepResult{{name: "tidy", status: stepOK, attempts: 1}}
+
+	if err := os.WriteFile("dirty.txt", []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := startMaintenanceBranch(ctx, opts.branch, false); !errors.Is(err, errDirtyWorktree) {
+		t.Fatalf("expected errDirtyWorktree, got %v", err)
+	}
+
+	gitCommand(t, "add", "-A")
+	gitCommand(t, "commit", "-q", "-m", "add dirty.txt")
+
+	base, err := startMaintenanceBranch(ctx, opts.branch, false)
+	if err != nil || base != "main" {
+		t.Fatalf("startMaintenanceBranch() = %q, %v", base, err)
+	}
+
+	if err := os.WriteFile("chore.txt", []byte("done"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, _, err := finishMaintenanceBranch(ctx, base, opts, workflow{Name: "chores"}, results)
+	if err != nil || commits != 1 {
+		t.Fatalf("finishMaintenanceBranch() = %d, %v", commits, err)
+	}
+
+	if head := gitCommand(t, "rev-parse", "--abbrev-ref", "HEAD"); head != "main" {
+		t.Errorf("should be back on main, on %q", head)
+	}
+
+	if subject := gitCommand(t, "log", "-1", "--format=%s", opts.branch); subject != "Automated maintenance: chores" {
+		t.Errorf("commit subject = %q", subject)
+	}
+
+	if _, err := os.Stat("chore.txt"); !os.IsNotExist(err) {
+		t.Error("main should not have the maintenance change")
+	}
+
+	// A run that changes nothing leaves no branch behind
+	opts.branch = "artoo/nothing"
+
+	if _, err := startMaintenanceBranch(ctx, opts.branch, false); err != nil {
+		t.Fatal(err)
+	}
+
+	if commits, _, err := finishMaintenanceBranch(ctx, base, opts, workflow{}, results); err != nil || commits != 0 {
+		t.Fatalf("finishMaintenanceBranch() = %d, %v", commits, err)
+	}
+
+	if branches := gitCommand(t, "branch", "--list", opts.branch); branches != "" {
+		t.Errorf("empty branch should be deleted, got %q", branches)
+	}
+}
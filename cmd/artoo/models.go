@@ -0,0 +1,71 @@
+// Package main provides the models subcommand listing the available models.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const modelsUsage = `usage:
+  artoo models                        list the models the API key can use; * marks the
+                                      configured one (ARTOO_MODEL)`
+
+var errModelsUsage = errors.New(modelsUsage)
+
+// runModels implements the `artoo models` subcommand.
+func runModels(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	if len(args) > 0 {
+		return errModelsUsage
+	}
+
+	var models []anthropic.ModelInfo
+
+	pager := client.Models.ListAutoPaging(ctx, anthropic.ModelListParams{})
+	for pager.Next() {
+		models = append(models, pager.Current())
+	}
+
+	if err := pager.Err(); err != nil {
+		return err
+	}
+
+	fmt.Print(formatModels(models, cfg.Agent.Model))
+
+	return nil
+}
+
+// formatModels lists models, newest first as the API returns them, marking
+// the configured one.
+func formatModels(models []anthropic.ModelInfo, configured string) string {
+	if len(models) == 0 {
+		return "No models available.\n"
+	}
+
+	width := 0
+	for _, m := range models {
+		width = max(width, len(m.ID))
+	}
+
+	var b strings.Builder
+
+	for _, m := range models {
+		marker := " "
+		if m.ID == configured {
+			marker = "*"
+		}
+
+		fmt.Fprintf(&b, "%s %-*s  %s", marker, width, m.ID, m.DisplayName)
+
+		if !m.CreatedAt.IsZero() && m.CreatedAt.Unix() > 0 {
+			b.WriteString("  " + m.CreatedAt.Format("2006-01-02"))
+		}
+
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
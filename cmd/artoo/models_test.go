@@ -0,0 +1,33 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestFormatModels(t *testing.T) {
+	t.Parallel()
+
+	models := []anthropic.ModelInfo{
+		{ID: "claude-sonnet-4-5", DisplayName: "Claude Sonnet 4.5", CreatedAt: time.Date(2025, 9, 29, 0, 0, 0, 0, time.UTC)},
+		{ID: "claude-haiku", DisplayName: "Claude Haiku"},
+	}
+
+	out := formatModels(models, "claude-haiku")
+
+	for _, want := range []string{
+		"  claude-sonnet-4-5  Claude Sonnet 4.5  2025-09-29\n",
+		"* claude-haiku       Claude Haiku\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	if got := formatModels(nil, ""); got != "No models available.\n" {
+		t.Errorf("formatModels(nil) = %q", got)
+	}
+}
@@ -0,0 +1,97 @@
+// Package main provides offline mode: a local model to fall back to when the
+// API cannot be reached, and searches that need no model at all.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+	"github.com/anthropics/anthropic-sdk-go/option"
+)
+
+// defaultOfflineURL is where Ollama serves its Anthropic-compatible API.
+const defaultOfflineURL = "http://localhost:11434"
+
+var errUnreachable = errors.New("the API cannot be reached")
+
+// offlineFallback returns the local model to switch to when the API cannot
+// be reached, or nil if ARTOO_OFFLINE_MODEL is unset. notify is called once,
+// on switching.
+func offlineFallback(cfg AppConfig, notify func(err error)) *agent.Fallback {
+	if cfg.OfflineModel == "" {
+		return nil
+	}
+
+	// Ollama ignores the key, but the client will not send a request without one
+	client := anthropic.NewClient(option.WithBaseURL(cfg.OfflineURL), option.WithAPIKey("offline"))
+
+	return &agent.Fallback{Client: client, Model: cfg.OfflineModel, OnSwitch: notify}
+}
+
+// offlineBanner announces the switch to the local model after err.
+func offlineBanner(cfg AppConfig, err error) string {
+	return fmt.Sprintf("OFFLINE MODE: %v (%v). Continuing with the local model %s at %s for the rest of the session; "+
+		"answers may be weaker and server tools such as web search are not available.",
+		errUnreachable, err, cfg.OfflineModel, cfg.OfflineURL)
+}
+
+// offlineError explains a turn that failed because the API cannot be
+// reached, pointing to what still works.
+func offlineError(cfg AppConfig, err error) error {
+	if !agent.IsUnreachable(err) {
+		return err
+	}
+
+	hint := "set ARTOO_OFFLINE_MODEL to continue with a local model"
+	if cfg.OfflineModel != "" {
+		hint = "the local model " + cfg.OfflineModel + " at " + cfg.OfflineURL + " cannot be reached either"
+	}
+
+	return fmt.Errorf("%w: %w; /grep and /ls still search the workspace, and %s", errUnreachable, err, hint)
+}
+
+// grepCommand searches file contents for the pattern in args with the grep
+// tool, without the model.
+func (a *app) grepCommand(args string) {
+	if args == "" {
+		a.term.PrintInfo("Usage: /grep <pattern>")
+
+		return
+	}
+
+	grep := &tool.GrepTool{}
+	grep.SetEnvironment(a.agent.ToolEnvironment()) // searches the /project focus
+
+	out, err := grep.Call(tool.GrepParams{Pattern: args})
+	a.printToolOutput(out, err)
+}
+
+// lsCommand lists the files under the directory in args, or the search
+// root, with the ls tool, without the model.
+func (a *app) lsCommand(args string) {
+	var params tool.LsParams
+	if args != "" {
+		params.Path = &args
+	}
+
+	ls := &tool.LsTool{}
+	ls.SetEnvironment(a.agent.ToolEnvironment())
+
+	out, err := ls.Call(params)
+	a.printToolOutput(out, err)
+}
+
+// printToolOutput prints what a tool run from a command returned.
+func (a *app) printToolOutput(out string, err error) {
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	fmt.Println(strings.TrimRight(out, "\n"))
+}
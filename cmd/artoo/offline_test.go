@@ -0,0 +1,50 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOfflineError(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	resp, connErr := http.Get(server.URL) //nolint:noctx // a refused connection
+	if connErr == nil {
+		_ = resp.Body.Close()
+		t.Fatal("request to a closed server succeeded")
+	}
+
+	other := errors.New("rate limited")
+	if got := offlineError(AppConfig{}, other); got != other {
+		t.Errorf("offlineError(other) = %v, want it unchanged", got)
+	}
+
+	err := offlineError(AppConfig{}, connErr)
+	if !errors.Is(err, errUnreachable) || !strings.Contains(err.Error(), "ARTOO_OFFLINE_MODEL") {
+		t.Errorf("offlineError without a local model = %v, want errUnreachable suggesting ARTOO_OFFLINE_MODEL", err)
+	}
+
+	cfg := AppConfig{OfflineModel: "qwen3-coder", OfflineURL: defaultOfflineURL}
+	if err := offlineError(cfg, connErr); !strings.Contains(err.Error(), "qwen3-coder") {
+		t.Errorf("offlineError with a local model = %v, want it named", err)
+	}
+}
+
+func TestOfflineFallback(t *testing.T) {
+	t.Parallel()
+
+	if f := offlineFallback(AppConfig{}, nil); f != nil {
+		t.Errorf("offlineFallback without ARTOO_OFFLINE_MODEL = %+v, want nil", f)
+	}
+
+	cfg := AppConfig{OfflineModel: "qwen3-coder", OfflineURL: defaultOfflineURL}
+	if f := offlineFallback(cfg, nil); f == nil || f.Model != "qwen3-coder" {
+		t.Errorf("offlineFallback = %+v, want the local model", f)
+	}
+}
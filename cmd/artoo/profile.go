@@ -0,0 +1,154 @@
+// Package main provides named profiles bundling model, tools, autonomy and prompt.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// profile is a named preset of agent settings. Empty fields keep the
+// configured value.
+type profile struct {
+	description string
+	model       string
+	tools       []string // tools offered to the model (nil offers all)
+	autonomy    agent.Autonomy
+	prompt      string // appended to the configured system prompt
+}
+
+// readOnlyTools are the built-in tools that never modify anything.
+var readOnlyTools = []string{
+	"grep", "list", "read_many", "calculate", "tree_snapshot", "env", "db", "docker",
+	"find_definition", "find_references",
+}
+
+// profiles are the built-in profiles selectable with --profile or /profile.
+var profiles = map[string]profile{
+	"review": {
+		description: "review code without changing it",
+		tools:       readOnlyTools,
+		autonomy:    agent.AutonomySuggest,
+		prompt: "You are reviewing code. Read and analyze, but do not modify anything. Report findings " +
+			"ordered by severity, each with a file:line reference and a concrete suggested fix.",
+	},
+	"explore": {
+		description: "answer questions about the codebase quickly and cheaply",
+		model:       string(anthropic.ModelClaude3_5HaikuLatest),
+		tools:       readOnlyTools,
+		autonomy:    agent.AutonomySuggest,
+		prompt: "You are exploring an unfamiliar codebase to answer questions about it. Search before " +
+			"reading, keep answers short and cite the files you relied on.",
+	},
+	"yolo": {
+		description: "every tool, no approval prompts",
+		autonomy:    agent.AutonomyFullAuto,
+	},
+}
+
+var errUnknownProfile = errors.New("unknown profile")
+
+// profileNames returns the profile names in order.
+func profileNames() []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	return names
+}
+
+// applyProfile returns base with the named profile's settings applied.
+func applyProfile(base agent.Config, name string) (agent.Config, error) {
+	p, ok := profiles[name]
+	if !ok {
+		return base, fmt.Errorf("%w: %q (available: %s)", errUnknownProfile, name, strings.Join(profileNames(), ", "))
+	}
+
+	cfg := base
+
+	if p.model != "" {
+		cfg.Model = p.model
+	}
+
+	if p.tools != nil {
+		cfg.Tools = p.tools
+	}
+
+	if p.autonomy != "" {
+		cfg.Autonomy = p.autonomy
+	}
+
+	if p.prompt != "" {
+		cfg.SystemPrompt = strings.TrimSpace(base.SystemPrompt + "\n\n" + p.prompt)
+	}
+
+	return cfg, nil
+}
+
+// profileFlag removes a leading "--profile <name>" or "--profile=<name>"
+// from args, returning the name ("" if absent) and the remaining arguments.
+func profileFlag(args []string) (string, []string, error) {
+	if len(args) == 0 {
+		return "", args, nil
+	}
+
+	if name, ok := strings.CutPrefix(args[0], "--profile="); ok {
+		return name, args[1:], nil
+	}
+
+	if args[0] != "--profile" {
+		return "", args, nil
+	}
+
+	if len(args) < 2 {
+		return "", nil, fmt.Errorf("--profile needs a name (available: %s)", strings.Join(profileNames(), ", "))
+	}
+
+	return args[1], args[2:], nil
+}
+
+// profileCommand lists the profiles, or switches the session to one.
+func (a *app) profileCommand(args string) {
+	if args == "" {
+		var b strings.Builder
+		b.WriteString("Profiles:")
+
+		for _, name := range profileNames() {
+			marker := " "
+			if name == a.profile {
+				marker = "*"
+			}
+
+			fmt.Fprintf(&b, "\n %s %-8s  %s", marker, name, profiles[name].description)
+		}
+
+		a.term.PrintInfo(b.String())
+
+		return
+	}
+
+	cfg, err := applyProfile(a.config.Agent, args)
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	autonomy := cfg.Autonomy
+	if autonomy == "" {
+		autonomy = agent.AutonomyFullAuto
+	}
+
+	// The context budget follows the profile's model
+	a.agent.Reconfigure(cfg, a.config.conversationConfig(cfg.Model))
+	a.profile = args
+
+	a.term.PrintInfo(fmt.Sprintf("Profile: %s (model %s, autonomy %s)", args, cfg.Model, autonomy))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/aelse/artoo/agent"
+)
+
+func TestApplyProfile(t *testing.T) {
+	t.Parallel()
+
+	base := agent.Config{Model: "base-model", SystemPrompt: "Be brief.", Autonomy: agent.AutonomyFullAuto}
+
+	cfg, err := applyProfile(base, "review")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Model != "base-model" {
+		t.Errorf("review should keep the configured model, got %q", cfg.Model)
+	}
+
+	if cfg.Autonomy != agent.AutonomySuggest || !slices.Equal(cfg.Tools, readOnlyTools) {
+		t.Errorf("review should be read-only, got autonomy %q tools %v", cfg.Autonomy, cfg.Tools)
+	}
+
+	if want := "Be brief.\n\n" + profiles["review"].prompt; cfg.SystemPrompt != want {
+		t.Errorf("SystemPrompt = %q, want %q", cfg.SystemPrompt, want)
+	}
+
+	if cfg, _ := applyProfile(base, "explore"); cfg.Model != profiles["explore"].model {
+		t.Errorf("explore should switch model, got %q", cfg.Model)
+	}
+
+	if cfg, _ := applyProfile(base, "yolo"); cfg.Tools != nil || cfg.SystemPrompt != "Be brief." {
+		t.Errorf("yolo should keep tools and prompt, got %v %q", cfg.Tools, cfg.SystemPrompt)
+	}
+
+	if _, err := applyProfile(base, "nope"); !errors.Is(err, errUnknownProfile) {
+		t.Errorf("expected errUnknownProfile, got %v", err)
+	}
+}
+
+func TestProfileFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args     []string
+		wantName string
+		wantRest []string
+		wantErr  bool
+	}{
+		{nil, "", nil, false},
+		{[]string{"run", "task"}, "", []string{"run", "task"}, false},
+		{[]string{"--profile", "review", "run"}, "review", []string{"run"}, false},
+		{[]string{"--profile=explore"}, "explore", []string{}, false},
+		{[]string{"--profile"}, "", nil, true},
+	}
+
+	for _, tt := range tests {
+		name, rest, err := profileFlag(tt.args)
+		if (err != nil) != tt.wantErr || name != tt.wantName || !slices.Equal(rest, tt.wantRest) {
+			t.Errorf("profileFlag(%q) = %q, %q, %v", tt.args, name, rest, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// Package main provides reloading the configuration during a session.
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// reloadSetting is a configuration setting compared on reload.
+type reloadSetting struct {
+	name    string // environment variable, as documented in CONFIG.md
+	restart bool   // takes effect only after a restart
+	value   func(AppConfig) any
+}
+
+// reloadSettings are the settings /reload reports on. Those that shape the
+// tools, the terminal or the stores are read once, at startup.
+var reloadSettings = []reloadSetting{
+	{"ARTOO_MODEL", false, func(c AppConfig) any { return c.Agent.Model }},
+	{"ARTOO_MAX_TOKENS", false, func(c AppConfig) any { return c.Agent.MaxTokens }},
+	{"ARTOO_MAX_CONCURRENT_TOOLS", false, func(c AppConfig) any { return c.Agent.MaxConcurrentTools }},
+	{"ARTOO_MAX_CONTEXT_TOKENS", false, func(c AppConfig) any { return c.Conversation.MaxContextTokens }},
+	{"ARTOO_TOOL_RESULT_MAX_CHARS", false, func(c AppConfig) any { return c.Conversation.ToolResultMaxChars }},
+	{"ARTOO_SYSTEM_PROMPT", false, func(c AppConfig) any { return c.Agent.SystemPrompt }},
+	{"ARTOO_SUMMARY_MODEL", false, func(c AppConfig) any { return c.Agent.SummaryModel }},
+	{"ARTOO_STOP_SEQUENCES", false, func(c AppConfig) any { return c.Agent.StopSequences }},
+	{"ARTOO_PREFILL", false, func(c AppConfig) any { return c.Agent.Prefill }},
+	{"ARTOO_LONG_CONTEXT", false, func(c AppConfig) any { return c.Agent.LongContext }},
+	{"ARTOO_FINE_GRAINED_STREAMING", false, func(c AppConfig) any { return c.Agent.FineGrained }},
+	{"ARTOO_AUTONOMY", false, func(c AppConfig) any { return c.Agent.Autonomy }},
+	{"ARTOO_TOOLS", false, func(c AppConfig) any { return c.Agent.Tools }},
+	{"ARTOO_SERVER_TOOLS", false, func(c AppConfig) any { return c.Agent.ServerTools }},
+	{"ARTOO_VERIFY_COMMAND", false, func(c AppConfig) any { return c.Agent.VerifyCommand }},
+	{"ARTOO_VERIFY_RETRIES", false, func(c AppConfig) any { return c.Agent.VerifyRetries }},
+	{"ARTOO_CANCEL_ON_FAILURE", false, func(c AppConfig) any { return c.Agent.CancelOnFailure }},
+	{"ARTOO_ACCESSIBLE", false, func(c AppConfig) any { return c.Accessible }},
+	{"ARTOO_REVIEW_CHANGES", false, func(c AppConfig) any { return c.ReviewChanges }},
+	{"ARTOO_SECRET_FILES", false, func(c AppConfig) any { return c.SecretFiles }},
+	{"ARTOO_INJECTION_SCAN", false, func(c AppConfig) any { return c.InjectionScan }},
+	{"ARTOO_SUBPROCESS_MAX_MEMORY_MB", false, func(c AppConfig) any { return c.MaxMemoryMB }},
+	{"ARTOO_SUBPROCESS_MAX_CPU_SECONDS", false, func(c AppConfig) any { return c.MaxCPUSeconds }},
+	{"ARTOO_SUBPROCESS_MAX_OUTPUT_BYTES", false, func(c AppConfig) any { return c.MaxOutputBytes }},
+	{"ARTOO_MESSAGES_FILE", true, func(c AppConfig) any { return c.MessagesFile }},
+	{"ARTOO_STREAMING", true, func(c AppConfig) any { return c.Agent.Streaming }},
+	{"ARTOO_PLUGIN_DIR", true, func(c AppConfig) any { return c.Agent.PluginDir }},
+	{"ARTOO_PLUGIN_TIMEOUT", true, func(c AppConfig) any { return c.Agent.PluginTimeout }},
+	{"ARTOO_DEFER_TOOLS", true, func(c AppConfig) any { return c.Agent.DeferTools }},
+	{"ARTOO_TOOL_CACHE_TTL", true, func(c AppConfig) any { return c.Agent.ToolCacheTTL }},
+	{"ARTOO_HTTP_ALLOW", true, func(c AppConfig) any { return c.HTTPAllow }},
+	{"ARTOO_ENV_ALLOW", true, func(c AppConfig) any { return c.EnvAllow }},
+	{"ARTOO_KNOWLEDGE", true, func(c AppConfig) any { return c.Knowledge }},
+	{"ARTOO_KNOWLEDGE_TOKEN", true, func(c AppConfig) any { return c.KnowledgeToken }},
+	{"ARTOO_DB_DSN", true, func(c AppConfig) any { return c.DatabaseDSN }},
+	{"ARTOO_DB_WRITE", true, func(c AppConfig) any { return c.DatabaseWrite }},
+	{"ARTOO_STORAGE_DIR", true, func(c AppConfig) any { return c.StorageDir }},
+	{"ARTOO_HISTORY_BACKEND", true, func(c AppConfig) any { return c.HistoryBackend }},
+	{"ARTOO_STATS", true, func(c AppConfig) any { return c.Stats }},
+	{"ARTOO_STATS_FILE", true, func(c AppConfig) any { return c.StatsFile }},
+	{"ARTOO_DEBUG", true, func(c AppConfig) any { return c.Debug }},
+	{"ARTOO_UPDATE_CHECK", true, func(c AppConfig) any { return c.UpdateCheck }},
+	{"ANTHROPIC_API_KEY", true, func(c AppConfig) any { return c.APIKey }},
+	{"ARTOO_BASE_URL", true, func(c AppConfig) any { return c.BaseURL }},
+	{"ARTOO_API_HEADERS", true, func(c AppConfig) any { return c.APIHeaders }},
+	{"ARTOO_OFFLINE_MODEL", true, func(c AppConfig) any { return c.OfflineModel }},
+	{"ARTOO_OFFLINE_URL", true, func(c AppConfig) any { return c.OfflineURL }},
+}
+
+// configChanges compares two configurations, returning the names of the
+// changed settings that apply immediately and of those that need a restart.
+func configChanges(old, updated AppConfig) (applied, restart []string) {
+	for _, s := range reloadSettings {
+		if reflect.DeepEqual(s.value(old), s.value(updated)) {
+			continue
+		}
+
+		if s.restart {
+			restart = append(restart, s.name)
+		} else {
+			applied = append(applied, s.name)
+		}
+	}
+
+	return applied, restart
+}
+
+// reloadCommand reloads the configuration from the environment and the
+// project settings files and applies it without losing the conversation.
+// The active profile stays in effect; switch profiles with /profile.
+func (a *app) reloadCommand(string) {
+	cfg, err := loadAppConfig()
+	if err != nil {
+		a.term.PrintError(fmt.Errorf("reload: %w (keeping the current configuration)", err))
+
+		return
+	}
+
+	agentCfg := cfg.Agent
+	if a.profile != "" {
+		if agentCfg, err = applyProfile(cfg.Agent, a.profile); err != nil {
+			a.term.PrintError(err)
+
+			return
+		}
+	}
+
+	// Restart-only settings are still in effect as they were at startup
+	applied, _ := configChanges(a.config, cfg)
+	_, restart := configChanges(a.started, cfg)
+	cfg.Profile = a.config.Profile
+
+	env := a.agent.ToolEnvironment()
+	if err := env.SetSecretFiles(cfg.SecretFiles); err != nil {
+		a.term.PrintError(fmt.Errorf("reload: ARTOO_SECRET_FILES: %w (keeping the current configuration)", err))
+
+		return
+	}
+
+	env.SetInjectionScan(cfg.InjectionScan)
+	env.SetLimits(cfg.subprocessLimits())
+	a.agent.Reconfigure(agentCfg, cfg.conversationConfig(agentCfg.Model))
+	a.term.SetAccessible(cfg.Accessible)
+	a.config = cfg
+
+	a.term.PrintInfo(reloadReport(applied, restart))
+}
+
+// reloadReport describes what a reload changed.
+func reloadReport(applied, restart []string) string {
+	if len(applied)+len(restart) == 0 {
+		return "Configuration reloaded: nothing changed."
+	}
+
+	var b strings.Builder
+	b.WriteString("Configuration reloaded.")
+
+	if len(applied) > 0 {
+		b.WriteString("\nApplied: " + strings.Join(applied, ", "))
+	}
+
+	if len(restart) > 0 {
+		b.WriteString("\nChanged, but only take effect after a restart: " + strings.Join(restart, ", "))
+	}
+
+	return b.String()
+}
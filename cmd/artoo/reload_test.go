@@ -0,0 +1,44 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestConfigChanges(t *testing.T) {
+	t.Parallel()
+
+	old := LoadConfig()
+	updated := old
+	updated.Agent.Model = "claude-opus-4-20250805"
+	updated.Agent.Tools = []string{"grep"}
+	updated.Conversation.ToolResultMaxChars = 5_000
+	updated.Agent.PluginDir = "/elsewhere"
+
+	applied, restart := configChanges(old, updated)
+
+	if want := []string{"ARTOO_MODEL", "ARTOO_TOOL_RESULT_MAX_CHARS", "ARTOO_TOOLS"}; !slices.Equal(applied, want) {
+		t.Errorf("applied = %v, want %v", applied, want)
+	}
+
+	if want := []string{"ARTOO_PLUGIN_DIR"}; !slices.Equal(restart, want) {
+		t.Errorf("restart = %v, want %v", restart, want)
+	}
+
+	if applied, restart := configChanges(old, old); applied != nil || restart != nil {
+		t.Errorf("an unchanged configuration should report nothing, got %v %v", applied, restart)
+	}
+}
+
+func TestReloadReport(t *testing.T) {
+	t.Parallel()
+
+	if got := reloadReport(nil, nil); got != "Configuration reloaded: nothing changed." {
+		t.Errorf("unchanged report = %q", got)
+	}
+
+	want := "Configuration reloaded.\nApplied: ARTOO_MODEL\nChanged, but only take effect after a restart: ARTOO_DB_DSN"
+	if got := reloadReport([]string{"ARTOO_MODEL"}, []string{"ARTOO_DB_DSN"}); got != want {
+		t.Errorf("report = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,380 @@
+// Package main provides the review subcommand for analyzing diffs.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const reviewUsage = `usage:
+  artoo review [--staged | <ref> | <PR URL>] [--json] [--post]
+                                      review a diff without editing anything:
+                                      uncommitted changes by default, --staged for the index,
+                                      <ref> for the changes since the branch left ref
+                                      (a range such as a..b is used as is), or a GitHub pull
+                                      request (needs gh); --json prints the findings as JSON;
+                                      --post adds them to the pull request as a review`
+
+// reviewMaxDiff caps the diff sent to the model, in bytes.
+const reviewMaxDiff = 200_000
+
+var (
+	errReviewUsage = errors.New(reviewUsage)
+	errEmptyDiff   = errors.New("nothing to review: the diff is empty")
+)
+
+// reviewSchema is the shape of the review's structured answer.
+const reviewSchema = `{
+  "type": "object",
+  "properties": {
+    "summary": {"type": "string", "description": "Overall assessment in two or three sentences"},
+    "findings": {
+      "type": "array",
+      "items": {
+        "type": "object",
+        "properties": {
+          "file": {"type": "string", "description": "Path as shown in the diff"},
+          "line": {"type": "integer", "description": "Line in the new version of the file (0 if not line-specific)"},
+          "severity": {"type": "string", "enum": ["critical", "major", "minor", "nit"]},
+          "comment": {"type": "string", "description": "The problem and a concrete fix"}
+        },
+        "required": ["file", "line", "severity", "comment"]
+      }
+    }
+  },
+  "required": ["summary", "findings"]
+}`
+
+// reviewSeverities orders severities from most to least serious.
+var reviewSeverities = []string{"critical", "major", "minor", "nit"}
+
+// pullRequestURL matches a GitHub pull request URL.
+var pullRequestURL = regexp.MustCompile(`^https://github\.com/([^/]+)/([^/]+)/pull/(\d+)`)
+
+// review is the structured result of a review.
+type review struct {
+	Summary  string          `json:"summary"`
+	Findings []reviewFinding `json:"findings"`
+}
+
+// reviewFinding is one problem found in the diff.
+type reviewFinding struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Severity string `json:"severity"`
+	Comment  string `json:"comment"`
+}
+
+// reviewTarget is what to review, parsed from the command line.
+type reviewTarget struct {
+	staged bool
+	ref    string   // "" for uncommitted changes
+	pr     []string // owner, repo and number of a pull request
+	json   bool
+	post   bool
+}
+
+// parseReviewArgs parses the review subcommand's arguments.
+func parseReviewArgs(args []string) (reviewTarget, error) {
+	var t reviewTarget
+
+	for _, arg := range args {
+		switch {
+		case arg == "--staged":
+			t.staged = true
+		case arg == "--json":
+			t.json = true
+		case arg == "--post":
+			t.post = true
+		case strings.HasPrefix(arg, "-") || t.ref != "" || t.pr != nil:
+			return t, errReviewUsage
+		case strings.HasPrefix(arg, "http://") || strings.HasPrefix(arg, "https://"):
+			m := pullRequestURL.FindStringSubmatch(arg)
+			if m == nil {
+				return t, fmt.Errorf("%w\n\nonly GitHub pull request URLs are supported", errReviewUsage)
+			}
+
+			t.pr = m[1:]
+		default:
+			t.ref = arg
+		}
+	}
+
+	if t.staged && (t.ref != "" || t.pr != nil) || t.post && t.pr == nil {
+		return t, errReviewUsage
+	}
+
+	return t, nil
+}
+
+// command returns the command printing the diff to review.
+func (t reviewTarget) command() []string {
+	switch {
+	case t.pr != nil:
+		return []string{"gh", "pr", "diff", t.pr[2], "--repo", t.pr[0] + "/" + t.pr[1]}
+	case t.staged:
+		return []string{"git", "diff", "--cached"}
+	case t.ref == "":
+		return []string{"git", "diff", "HEAD"}
+	case strings.Contains(t.ref, ".."):
+		return []string{"git", "diff", t.ref}
+	}
+
+	return []string{"git", "diff", t.ref + "...HEAD"}
+}
+
+// runReview implements the `artoo review` subcommand. Findings are written
+// to stdout and tool activity to stderr.
+func runReview(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	target, err := parseReviewArgs(args)
+	if err != nil {
+		return err
+	}
+
+	diff, err := output(ctx, target.command()...)
+	if err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(diff) == "" {
+		return errEmptyDiff
+	}
+
+	agentCfg, err := applyProfile(cfg.Agent, "review")
+	if err != nil {
+		return err
+	}
+
+	agentCfg.Streaming = false
+
+	a, err := agent.New(client, agentCfg, loadTools(cfg)...)
+	if err != nil {
+		return err
+	}
+
+	a.SetConversationConfig(cfg.conversationConfig(a.Model()))
+
+	answer, err := a.SendStructured(ctx, reviewPrompt(diff), []byte(reviewSchema), &headlessCallbacks{out: os.Stderr})
+	if err != nil {
+		return err
+	}
+
+	var r review
+	if err := json.Unmarshal(answer, &r); err != nil {
+		return fmt.Errorf("reading review: %w", err)
+	}
+
+	sortFindings(r.Findings)
+
+	if target.json {
+		out, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(out))
+	} else {
+		fmt.Print(formatReview(r))
+	}
+
+	if !target.post {
+		return nil
+	}
+
+	if err := postReview(ctx, target.pr, r, diff); err != nil {
+		return fmt.Errorf("posting review: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Posted %d findings to %s/%s#%s\n", len(r.Findings), target.pr[0], target.pr[1], target.pr[2])
+
+	return nil
+}
+
+// reviewPrompt asks for a review of diff, truncated to reviewMaxDiff.
+func reviewPrompt(diff string) string {
+	note := ""
+	if len(diff) > reviewMaxDiff {
+		note = fmt.Sprintf("\n\nThe diff was truncated to %d of %d bytes; say so in the summary.", reviewMaxDiff, len(diff))
+		diff = diff[:reviewMaxDiff]
+	}
+
+	return "Review the following diff. Use the tools to read surrounding code where the diff alone is not " +
+		"enough to judge a change. Report real problems — bugs, security issues, missing error handling or " +
+		"tests, unclear code — not style preferences the codebase does not follow. Give each finding the file " +
+		"and the line in the new version." + note + "\n\n```diff\n" + diff + "\n```"
+}
+
+// sortFindings orders findings by severity, then file and line.
+func sortFindings(findings []reviewFinding) {
+	slices.SortStableFunc(findings, func(a, b reviewFinding) int {
+		if d := slices.Index(reviewSeverities, a.Severity) - slices.Index(reviewSeverities, b.Severity); d != 0 {
+			return d
+		}
+
+		if a.File != b.File {
+			return strings.Compare(a.File, b.File)
+		}
+
+		return a.Line - b.Line
+	})
+}
+
+// formatReview renders a review for the terminal.
+func formatReview(r review) string {
+	var b strings.Builder
+
+	b.WriteString(strings.TrimSpace(r.Summary) + "\n")
+
+	if len(r.Findings) == 0 {
+		b.WriteString("\nNo findings.\n")
+
+		return b.String()
+	}
+
+	for _, f := range r.Findings {
+		location := f.File
+		if f.Line > 0 {
+			location += ":" + strconv.Itoa(f.Line)
+		}
+
+		fmt.Fprintf(&b, "\n[%s] %s\n  %s\n", f.Severity, location, strings.ReplaceAll(strings.TrimSpace(f.Comment), "\n", "\n  "))
+	}
+
+	return b.String()
+}
+
+// githubReview is the request body of GitHub's create-review endpoint.
+type githubReview struct {
+	Event    string          `json:"event"`
+	Body     string          `json:"body"`
+	Comments []githubComment `json:"comments"`
+}
+
+type githubComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
+	Body string `json:"body"`
+}
+
+// newGitHubReview turns findings into a review. GitHub only accepts comments
+// on lines the diff shows, so other findings are listed in the review body.
+func newGitHubReview(r review, diff string) githubReview {
+	lines := diffLines(diff)
+	gr := githubReview{Event: "COMMENT", Body: strings.TrimSpace(r.Summary), Comments: []githubComment{}}
+
+	for _, f := range r.Findings {
+		body := fmt.Sprintf("**%s**: %s", f.Severity, strings.TrimSpace(f.Comment))
+
+		if lines[f.File][f.Line] {
+			gr.Comments = append(gr.Comments, githubComment{Path: f.File, Line: f.Line, Side: "RIGHT", Body: body})
+
+			continue
+		}
+
+		location := f.File
+		if f.Line > 0 {
+			location += ":" + strconv.Itoa(f.Line)
+		}
+
+		gr.Body += fmt.Sprintf("\n\n- `%s` %s", location, body)
+	}
+
+	return gr
+}
+
+// postReview adds the findings to a GitHub pull request as a review.
+func postReview(ctx context.Context, pr []string, r review, diff string) error {
+	body, err := json.Marshal(newGitHubReview(r, diff))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "gh", "api", "--method", "POST", //nolint:gosec // fixed command, parsed URL parts
+		fmt.Sprintf("repos/%s/%s/pulls/%s/reviews", pr[0], pr[1], pr[2]), "--input", "-")
+	cmd.Stdin = bytes.NewReader(body)
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+// hunkHeader matches a unified diff hunk header, capturing the new side's
+// start line and line count.
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// diffLines returns, per file, the lines of the new version a unified diff
+// shows: added lines and context.
+func diffLines(diff string) map[string]map[int]bool {
+	files := make(map[string]map[int]bool)
+
+	var current map[int]bool
+
+	line, header := 0, false
+
+	for text := range strings.SplitSeq(diff, "\n") {
+		// A file header is "--- old" then "+++ new"; elsewhere such lines
+		// are removed or added content
+		wasHeader := header
+		header = strings.HasPrefix(text, "--- ")
+
+		if path, ok := strings.CutPrefix(text, "+++ "); ok && wasHeader {
+			current = nil
+
+			if path != "/dev/null" {
+				current = make(map[int]bool)
+				files[strings.TrimPrefix(path, "b/")] = current
+			}
+
+			continue
+		}
+
+		if m := hunkHeader.FindStringSubmatch(text); m != nil {
+			line, _ = strconv.Atoi(m[1])
+
+			continue
+		}
+
+		if current == nil || text == "" {
+			continue
+		}
+
+		switch text[0] {
+		case '+', ' ':
+			current[line] = true
+			line++
+		}
+	}
+
+	return files
+}
+
+// output runs a command and returns its standard output, or an error
+// including its standard error.
+func output(ctx context.Context, command ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, command[0], command[1:]...).Output() //nolint:gosec // fixed commands
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("%s: %w: %s", strings.Join(command, " "), err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+
+		return "", fmt.Errorf("%s: %w", strings.Join(command, " "), err)
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseReviewArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		args    []string
+		command []string
+		post    bool
+		wantErr bool
+	}{
+		{args: nil, command: []string{"git", "diff", "HEAD"}},
+		{args: []string{"--staged"}, command: []string{"git", "diff", "--cached"}},
+		{args: []string{"main"}, command: []string{"git", "diff", "main...HEAD"}},
+		{args: []string{"v1..v2", "--json"}, command: []string{"git", "diff", "v1..v2"}},
+		{
+			args:    []string{"--post", "https://github.com/aelse/artoo/pull/42/files"},
+			command: []string{"gh", "pr", "diff", "42", "--repo", "aelse/artoo"},
+			post:    true,
+		},
+		{args: []string{"--staged", "main"}, wantErr: true},
+		{args: []string{"main", "other"}, wantErr: true},
+		{args: []string{"--post", "main"}, wantErr: true},
+		{args: []string{"--verbose"}, wantErr: true},
+		{args: []string{"https://gitlab.com/a/b/-/merge_requests/1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		target, err := parseReviewArgs(tt.args)
+		if tt.wantErr {
+			if !errors.Is(err, errReviewUsage) {
+				t.Errorf("parseReviewArgs(%q) error = %v, want errReviewUsage", tt.args, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("parseReviewArgs(%q): %v", tt.args, err)
+
+			continue
+		}
+
+		if got := target.command(); !slices.Equal(got, tt.command) || target.post != tt.post {
+			t.Errorf("parseReviewArgs(%q) = command %q post %v, want %q %v", tt.args, got, target.post, tt.command, tt.post)
+		}
+	}
+}
+
+const reviewDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -10,4 +10,5 @@ func main() {
+ 	a := 1
+-	b := 2
++	b := 3
++	c := 4
+ 	fmt.Println(a, b)
+diff --git a/old.go b/old.go
+deleted file mode 100644
+--- a/old.go
++++ /dev/null
+@@ -1,2 +0,0 @@
+-package main
+--- not a header
+`
+
+func TestDiffLines(t *testing.T) {
+	t.Parallel()
+
+	lines := diffLines(reviewDiff)
+
+	var got []int
+	for line := range lines["main.go"] {
+		got = append(got, line)
+	}
+
+	slices.Sort(got)
+
+	if want := []int{10, 11, 12, 13}; !slices.Equal(got, want) {
+		t.Errorf("main.go lines = %v, want %v", got, want)
+	}
+
+	if len(lines) != 1 {
+		t.Errorf("expected only main.go, got %v", lines)
+	}
+}
+
+func TestNewGitHubReview(t *testing.T) {
+	t.Parallel()
+
+	r := review{
+		Summary: "Looks fine.",
+		Findings: []reviewFinding{
+			{File: "main.go", Line: 12, Severity: "minor", Comment: "c is unused"},
+			{File: "main.go", Line: 40, Severity: "major", Comment: "outside the diff"},
+		},
+	}
+
+	gr := newGitHubReview(r, reviewDiff)
+
+	if gr.Event != "COMMENT" || len(gr.Comments) != 1 {
+		t.Fatalf("unexpected review %+v", gr)
+	}
+
+	if c := gr.Comments[0]; c.Path != "main.go" || c.Line != 12 || c.Side != "RIGHT" || c.Body != "**minor**: c is unused" {
+		t.Errorf("unexpected comment %+v", c)
+	}
+
+	if !strings.HasPrefix(gr.Body, "Looks fine.") || !strings.Contains(gr.Body, "`main.go:40` **major**: outside the diff") {
+		t.Errorf("findings outside the diff should be in the body, got %q", gr.Body)
+	}
+}
+
+func TestFormatReview(t *testing.T) {
+	t.Parallel()
+
+	findings := []reviewFinding{
+		{File: "b.go", Line: 3, Severity: "nit", Comment: "typo"},
+		{File: "a.go", Severity: "critical", Comment: "drops errors\nsilently"},
+		{File: "a.go", Line: 9, Severity: "nit", Comment: "rename"},
+	}
+
+	sortFindings(findings)
+
+	got := formatReview(review{Summary: "Two issues.", Findings: findings})
+	want := "Two issues.\n\n[critical] a.go\n  drops errors\n  silently\n\n[nit] a.go:9\n  rename\n\n[nit] b.go:3\n  typo\n"
+
+	if got != want {
+		t.Errorf("formatReview() = %q, want %q", got, want)
+	}
+
+	if got := formatReview(review{Summary: "Clean."}); got != "Clean.\n\nNo findings.\n" {
+		t.Errorf("formatReview() without findings = %q", got)
+	}
+}
+
+func TestReviewPromptTruncates(t *testing.T) {
+	t.Parallel()
+
+	prompt := reviewPrompt(strings.Repeat("x", reviewMaxDiff+10))
+
+	if !strings.Contains(prompt, "truncated") || strings.Contains(prompt, strings.Repeat("x", reviewMaxDiff+1)) {
+		t.Errorf("long diffs should be truncated with a note")
+	}
+}
@@ -0,0 +1,266 @@
+// Package main provides the run subcommand for headless one-shot prompts.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/tool"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const runUsage = `usage:
+  artoo run [--schema <schema.json> | --plan | --detach] [--session <id>]
+            [--tool-choice <auto|any|none|tool>] [--quiet] [prompt...]
+                                      answer one prompt (read from stdin if omitted);
+                                      --schema forces a JSON answer conforming to the schema;
+                                      --plan prints the planned tool calls as JSON without
+                                      executing any tool that modifies state;
+                                      --detach runs in the background, saving progress to
+                                      the conversation history (follow with artoo attach);
+                                      --session continues a saved conversation;
+                                      --tool-choice sets how the first response may use
+                                      tools: any forces a tool call, none a text answer,
+                                      and a tool name a call to that tool;
+                                      --quiet leaves out the progress on stderr, so only
+                                      the answer and errors are printed. The exit status is
+                                      0 on success, 1 on failure, 2 for a usage error, 3 if
+                                      the turn did not finish and 4 if verification fails
+  artoo run <workflow.yaml> [name=value...]
+                                      run the steps of a workflow file, setting its variables`
+
+var (
+	errRunUsage     = errors.New(runUsage)
+	errVerifyFailed = errors.New("verification still fails (ARTOO_VERIFY_COMMAND); the task may be incomplete")
+	errUnfinished   = errors.New("the turn did not finish")
+)
+
+// Exit statuses of subcommands, so scripts can branch on the outcome.
+const (
+	exitFailed     = 1 // the request failed
+	exitUsage      = 2 // the command line is invalid
+	exitUnfinished = 3 // the turn ended without a complete answer
+	exitUnverified = 4 // the answer is complete but verification still fails
+)
+
+// usageErrors are the errors of subcommands given invalid arguments.
+var usageErrors = []error{
+	errUnknownCommand, errHelpUsage, errRunUsage, errAttachUsage, errBatchUsage, errChatUsage, errCompletionUsage,
+	errExplainUsage, errHistoryUsage, errLoginUsage, errMaintainUsage, errModelsUsage, errReviewUsage,
+	errVersionUsage,
+}
+
+// exitStatus returns the exit status for a subcommand that failed with err.
+func exitStatus(err error) int {
+	switch {
+	case slices.ContainsFunc(usageErrors, func(usage error) bool { return errors.Is(err, usage) }):
+		return exitUsage
+	case errors.Is(err, errUnfinished), errors.Is(err, agent.ErrNoFinalAnswer), errors.Is(err, agent.ErrSchemaViolation):
+		return exitUnfinished
+	case errors.Is(err, errVerifyFailed):
+		return exitUnverified
+	default:
+		return exitFailed
+	}
+}
+
+// turnError returns the error for a turn that ended without a complete,
+// verified answer, or nil.
+func turnError(resp *agent.Response) error {
+	switch {
+	case resp.Unfinished != "":
+		return fmt.Errorf("%w: %s", errUnfinished, resp.Unfinished)
+	case resp.VerifyFailed != "":
+		return errVerifyFailed
+	default:
+		return nil
+	}
+}
+
+// runOnce implements the `artoo run` subcommand. The final answer is written
+// to stdout and progress (tool calls and results) to stderr, so the output
+// can be consumed by pipelines.
+func runOnce(ctx context.Context, cfg AppConfig, client anthropic.Client, args []string) error {
+	if len(args) > 0 && isWorkflowFile(args[0]) {
+		return runWorkflow(ctx, cfg, client, args[0], args[1:])
+	}
+
+	var schemaPath, session, toolChoice string
+	var plan, detach, quiet bool
+
+flags:
+	for len(args) > 0 {
+		switch args[0] {
+		case "--schema":
+			if len(args) < 2 {
+				return errRunUsage
+			}
+
+			schemaPath, args = args[1], args[2:]
+		case "--plan":
+			plan, args = true, args[1:]
+		case "--detach":
+			detach, args = true, args[1:]
+		case "--quiet", "-q":
+			quiet, args = true, args[1:]
+		case "--session":
+			if len(args) < 2 {
+				return errRunUsage
+			}
+
+			session, args = args[1], args[2:]
+		case "--tool-choice":
+			if len(args) < 2 || args[1] == "" {
+				return errRunUsage
+			}
+
+			toolChoice, args = args[1], args[2:]
+		default:
+			break flags
+		}
+	}
+
+	if plan && schemaPath != "" || detach && (plan || schemaPath != "") || toolChoice != "" && (plan || detach) {
+		return errRunUsage
+	}
+
+	prompt, err := readRunPrompt(args, os.Stdin)
+	if err != nil {
+		return err
+	}
+
+	if prompt == "" {
+		return errRunUsage
+	}
+
+	if detach {
+		return startDetached(cfg, session, prompt)
+	}
+
+	// Text is printed once the answer is complete, so streaming adds nothing
+	cfg.Agent.Streaming = false
+
+	// Progress goes to stderr unless only the answer is wanted
+	var progress io.Writer = os.Stderr
+	if quiet {
+		progress = io.Discard
+	}
+
+	a, err := agent.New(client, cfg.agentConfig(), loadTools(cfg)...)
+	if err != nil {
+		return err
+	}
+
+	a.SetConversationConfig(cfg.conversationConfig(a.Model()))
+	a.SetFallback(offlineFallback(cfg, func(err error) { fmt.Fprintln(progress, offlineBanner(cfg, err)) }))
+
+	if toolChoice != "" {
+		a.SetToolChoice(agent.ToolChoice(toolChoice))
+	}
+
+	if session != "" {
+		if err := resumeSession(cfg, a, session); err != nil {
+			return err
+		}
+	}
+
+	store := openStats(cfg, a)
+	defer saveStats(store)
+
+	cb := &headlessCallbacks{out: progress}
+
+	if plan {
+		p, err := a.Plan(ctx, prompt, cb)
+		if err != nil {
+			return err
+		}
+
+		out, err := json.MarshalIndent(p, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(out))
+
+		return nil
+	}
+
+	if schemaPath == "" {
+		resp, err := a.SendMessage(ctx, prompt, cb)
+		if err != nil {
+			return offlineError(cfg, err)
+		}
+
+		fmt.Println(resp.Text)
+
+		return turnError(resp)
+	}
+
+	schema, err := os.ReadFile(schemaPath) //nolint:gosec // user-supplied schema file
+	if err != nil {
+		return err
+	}
+
+	answer, err := a.SendStructured(ctx, prompt, schema, cb)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(answer))
+
+	return nil
+}
+
+// readRunPrompt joins the prompt arguments, or reads stdin when there are none.
+func readRunPrompt(args []string, stdin io.Reader) (string, error) {
+	if len(args) > 0 {
+		return strings.TrimSpace(strings.Join(args, " ")), nil
+	}
+
+	data, err := io.ReadAll(stdin)
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// headlessCallbacks reports tool activity to out. Assistant text is left to
+// the caller, which prints only the final answer.
+type headlessCallbacks struct {
+	out io.Writer
+}
+
+var _ agent.Callbacks = (*headlessCallbacks)(nil)
+
+func (h *headlessCallbacks) OnThinking()        {}
+func (h *headlessCallbacks) OnThinkingDone()    {}
+func (h *headlessCallbacks) OnText(string)      {}
+func (h *headlessCallbacks) OnTextDelta(string) {}
+
+func (h *headlessCallbacks) OnTurnStart(string)                    {}
+func (h *headlessCallbacks) OnTurnEnd(string, agent.Usage, string) {}
+
+func (h *headlessCallbacks) OnToolCall(id string, name string, input string) {
+	fmt.Fprintf(h.out, "tool %s %s %s\n", name, id, input)
+}
+
+func (h *headlessCallbacks) OnToolResult(id string, name string, _ string, isError bool) {
+	if isError {
+		fmt.Fprintf(h.out, "tool %s %s failed\n", name, id)
+	}
+}
+
+// OnSuspectedInjection implements agent.InjectionWarner.
+func (h *headlessCallbacks) OnSuspectedInjection(name string, found []tool.Injection) {
+	for _, f := range found {
+		fmt.Fprintf(h.out, "warning: %s returned a possible prompt injection from %s: %q\n", name, f.Source, f.Text)
+	}
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/agent"
+)
+
+func TestExitStatus(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errRunUsage, exitUsage},
+		{errReviewUsage, exitUsage},
+		{turnError(&agent.Response{Unfinished: "hit the token limit"}), exitUnfinished},
+		{fmt.Errorf("%w: refused", agent.ErrNoFinalAnswer), exitUnfinished},
+		{turnError(&agent.Response{VerifyFailed: "FAIL"}), exitUnverified},
+		{errors.New("connection refused"), exitFailed},
+	}
+
+	for _, tt := range tests {
+		if got := exitStatus(tt.err); got != tt.want {
+			t.Errorf("exitStatus(%v) = %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestReadRunPrompt(t *testing.T) {
+	t.Parallel()
+
+	if got, err := readRunPrompt([]string{"fix", "the build "}, strings.NewReader("ignored")); err != nil || got != "fix the build" {
+		t.Errorf("expected the arguments, got %q (err %v)", got, err)
+	}
+
+	if got, err := readRunPrompt(nil, strings.NewReader("  from stdin\n")); err != nil || got != "from stdin" {
+		t.Errorf("expected stdin, got %q (err %v)", got, err)
+	}
+}
@@ -0,0 +1,55 @@
+// Package main provides /secrets, which lets the model read secret files for
+// the rest of a session.
+package main
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/aelse/artoo/tool"
+)
+
+var errSecretsUsage = errors.New("usage: /secrets [allow | deny]")
+
+// secretsCommand shows whether secret files are withheld from read_many and
+// grep, or allows ("allow") or withholds ("deny") them for the rest of the
+// session.
+func (a *app) secretsCommand(args string) {
+	switch args {
+	case "":
+		a.term.PrintInfo(formatSecrets(a.agent.ToolEnvironment().SecretsAllowed(), a.config.SecretFiles))
+	case "allow":
+		a.agent.ToolEnvironment().AllowSecrets(true)
+		a.invalidateReads()
+		a.term.PrintWarning("Secret files can now be read and are sent to the model; /secrets deny withholds them again.")
+	case "deny":
+		a.agent.ToolEnvironment().AllowSecrets(false)
+		a.invalidateReads()
+		a.term.PrintInfo("Secret files are withheld again.")
+	default:
+		a.term.PrintError(errSecretsUsage)
+	}
+}
+
+// invalidateReads drops cached tool results, which may hold secret files as
+// withheld or as read.
+func (a *app) invalidateReads() {
+	if cache := a.agent.ResultCache(); cache != nil {
+		cache.Invalidate()
+	}
+}
+
+// formatSecrets describes whether secret files are withheld, and which.
+func formatSecrets(allowed bool, patterns []string) string {
+	if allowed {
+		return "Secret files are readable for this session (/secrets deny withholds them)."
+	}
+
+	source := "ARTOO_SECRET_FILES"
+	if len(patterns) == 0 {
+		patterns, source = tool.DefaultSecretFiles, "the defaults"
+	}
+
+	return "read_many and grep withhold files matching " + source + " (/secrets allow reads them this session):\n  " +
+		strings.Join(patterns, " ")
+}
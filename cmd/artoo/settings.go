@@ -0,0 +1,265 @@
+// Package main provides project settings files shared by a team.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/aelse/artoo/agent"
+)
+
+const (
+	// settingsFile is checked into the repository and shared by the team.
+	settingsFile = "settings.json"
+
+	// localSettingsFile holds personal settings and secrets; keep it out of
+	// version control.
+	localSettingsFile = "settings.local.json"
+)
+
+var (
+	errInvalidSettings = errors.New("invalid settings")
+	errLocalOnly       = errors.New("only allowed in " + localSettingsFile)
+)
+
+// settings is the content of a project settings file. Absent fields leave
+// the configured value alone.
+type settings struct {
+	Model        *string  `json:"model,omitempty"`
+	MaxTokens    *int64   `json:"max_tokens,omitempty"`
+	Tools        []string `json:"tools,omitempty"`
+	ServerTools  []string `json:"server_tools,omitempty"`
+	Autonomy     *string  `json:"autonomy,omitempty"`
+	Profile      *string  `json:"profile,omitempty"`
+	Instructions string   `json:"instructions,omitempty"` // appended to the system prompt
+
+	// Local only: credentials, and settings that let the agent do more
+	// unattended or run code from the workspace.
+	APIKey      *string  `json:"api_key,omitempty"`
+	DatabaseDSN *string  `json:"db_dsn,omitempty"`
+	DBWrite     *bool    `json:"db_write,omitempty"`
+	PluginDir   *string  `json:"plugin_dir,omitempty"`
+	BaseURL     *string  `json:"base_url,omitempty"`     // where the API key is sent
+	APIHeaders  []string `json:"api_headers,omitempty"`  // may hold gateway credentials
+	SecretFiles []string `json:"secret_files,omitempty"` // may expose keys to the model
+	HTTPAllow   []string `json:"http_allow,omitempty"`   // hosts the model may send data to
+	EnvAllow    []string `json:"env_allow,omitempty"`    // variables that may carry credentials
+}
+
+// loadSettings reads dir/settings.json and dir/settings.local.json, either of
+// which may be missing, and merges them: the local file wins, except that
+// instructions from both are kept.
+func loadSettings(dir string) (settings, error) {
+	shared, err := readSettings(filepath.Join(dir, settingsFile))
+	if err != nil {
+		return settings{}, err
+	}
+
+	if err := shared.checkShared(); err != nil {
+		return settings{}, fmt.Errorf("%s: %w", filepath.Join(dir, settingsFile), err)
+	}
+
+	local, err := readSettings(filepath.Join(dir, localSettingsFile))
+	if err != nil {
+		return settings{}, err
+	}
+
+	return mergeSettings(shared, local), nil
+}
+
+// readSettings decodes and validates one settings file. Unknown fields are
+// errors, so a typo does not silently do nothing.
+func readSettings(path string) (settings, error) {
+	var s settings
+
+	data, err := os.ReadFile(path) //nolint:gosec // project settings file
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+
+	if err != nil {
+		return s, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&s); err != nil {
+		return s, fmt.Errorf("%w: %s: %w", errInvalidSettings, path, err)
+	}
+
+	if err := s.validate(); err != nil {
+		return s, fmt.Errorf("%w: %s: %w", errInvalidSettings, path, err)
+	}
+
+	return s, nil
+}
+
+// validate checks the values that have a fixed set of choices.
+func (s settings) validate() error {
+	if s.Autonomy != nil {
+		if _, err := agent.ParseAutonomy(*s.Autonomy); err != nil {
+			return err
+		}
+	}
+
+	if s.Profile != nil {
+		if _, ok := profiles[*s.Profile]; !ok {
+			return fmt.Errorf("%w: %q", errUnknownProfile, *s.Profile)
+		}
+	}
+
+	if s.MaxTokens != nil && *s.MaxTokens <= 0 {
+		return fmt.Errorf("max_tokens must be positive, got %d", *s.MaxTokens)
+	}
+
+	return nil
+}
+
+// checkShared refuses settings a shared, checked-in file must not contain:
+// credentials, and anything that would let a repository grant the agent
+// more than the user chose to.
+func (s settings) checkShared() error {
+	var local []string
+
+	if s.APIKey != nil {
+		local = append(local, "api_key")
+	}
+
+	if s.DatabaseDSN != nil {
+		local = append(local, "db_dsn")
+	}
+
+	if s.DBWrite != nil {
+		local = append(local, "db_write")
+	}
+
+	if s.PluginDir != nil {
+		local = append(local, "plugin_dir")
+	}
+
+	if s.BaseURL != nil {
+		local = append(local, "base_url")
+	}
+
+	if s.APIHeaders != nil {
+		local = append(local, "api_headers")
+	}
+
+	if s.SecretFiles != nil {
+		local = append(local, "secret_files")
+	}
+
+	if s.HTTPAllow != nil {
+		local = append(local, "http_allow")
+	}
+
+	if s.EnvAllow != nil {
+		local = append(local, "env_allow")
+	}
+
+	if s.Autonomy != nil && *s.Autonomy == string(agent.AutonomyFullAuto) {
+		local = append(local, `autonomy "full-auto"`)
+	}
+
+	if s.Profile != nil && *s.Profile == "yolo" {
+		local = append(local, `profile "yolo"`)
+	}
+
+	if len(local) > 0 {
+		return fmt.Errorf("%s %w", strings.Join(local, ", "), errLocalOnly)
+	}
+
+	return nil
+}
+
+// mergeSettings returns shared overridden by local.
+func mergeSettings(shared, local settings) settings {
+	merged := shared
+
+	override(&merged.Model, local.Model)
+	override(&merged.MaxTokens, local.MaxTokens)
+	override(&merged.Autonomy, local.Autonomy)
+	override(&merged.Profile, local.Profile)
+	override(&merged.APIKey, local.APIKey)
+	override(&merged.DatabaseDSN, local.DatabaseDSN)
+	override(&merged.DBWrite, local.DBWrite)
+	override(&merged.PluginDir, local.PluginDir)
+	override(&merged.BaseURL, local.BaseURL)
+
+	for _, list := range []struct{ dst, src *[]string }{
+		{&merged.Tools, &local.Tools},
+		{&merged.ServerTools, &local.ServerTools},
+		{&merged.HTTPAllow, &local.HTTPAllow},
+		{&merged.EnvAllow, &local.EnvAllow},
+		{&merged.APIHeaders, &local.APIHeaders},
+		{&merged.SecretFiles, &local.SecretFiles},
+	} {
+		if *list.src != nil {
+			*list.dst = *list.src
+		}
+	}
+
+	merged.Instructions = strings.TrimSpace(shared.Instructions + "\n\n" + local.Instructions)
+
+	return merged
+}
+
+func override[T any](dst **T, src *T) {
+	if src != nil {
+		*dst = src
+	}
+}
+
+// applySettings sets cfg from s wherever the corresponding environment
+// variable is unset: environment variables take precedence over files.
+func applySettings(cfg *AppConfig, s settings) {
+	setFrom(&cfg.Agent.Model, s.Model, "ARTOO_MODEL")
+	setFrom(&cfg.Agent.MaxTokens, s.MaxTokens, "ARTOO_MAX_TOKENS")
+	setFrom(&cfg.Profile, s.Profile, "ARTOO_PROFILE")
+	setFrom(&cfg.APIKey, s.APIKey, "ANTHROPIC_API_KEY")
+	setFrom(&cfg.DatabaseDSN, s.DatabaseDSN, "ARTOO_DB_DSN")
+	setFrom(&cfg.DatabaseWrite, s.DBWrite, "ARTOO_DB_WRITE")
+	setFrom(&cfg.Agent.PluginDir, s.PluginDir, "ARTOO_PLUGIN_DIR")
+	setFrom(&cfg.BaseURL, s.BaseURL, "ARTOO_BASE_URL")
+
+	if s.Autonomy != nil && !envSet("ARTOO_AUTONOMY") {
+		cfg.Agent.Autonomy, _ = agent.ParseAutonomy(*s.Autonomy) // validated when read
+	}
+
+	for _, list := range []struct {
+		dst *[]string
+		src []string
+		env string
+	}{
+		{&cfg.Agent.Tools, s.Tools, "ARTOO_TOOLS"},
+		{&cfg.Agent.ServerTools, s.ServerTools, "ARTOO_SERVER_TOOLS"},
+		{&cfg.HTTPAllow, s.HTTPAllow, "ARTOO_HTTP_ALLOW"},
+		{&cfg.EnvAllow, s.EnvAllow, "ARTOO_ENV_ALLOW"},
+		{&cfg.APIHeaders, s.APIHeaders, "ARTOO_API_HEADERS"},
+		{&cfg.SecretFiles, s.SecretFiles, "ARTOO_SECRET_FILES"},
+	} {
+		if list.src != nil && !envSet(list.env) {
+			*list.dst = list.src
+		}
+	}
+
+	cfg.Instructions = s.Instructions
+}
+
+func setFrom[T any](dst *T, src *T, env string) {
+	if src != nil && !envSet(env) {
+		*dst = *src
+	}
+}
+
+func envSet(key string) bool {
+	_, ok := os.LookupEnv(key)
+
+	return ok
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeSettings writes the shared and local settings files into a new
+// directory, skipping empty content.
+func writeSettings(t *testing.T, shared, local string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	for name, content := range map[string]string{settingsFile: shared, localSettingsFile: local} {
+		if content == "" {
+			continue
+		}
+
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoadSettings_Merge(t *testing.T) {
+	t.Parallel()
+
+	dir := writeSettings(t,
+		`{"model": "shared-model", "tools": ["grep", "list"], "autonomy": "suggest", "instructions": "Run make lint."}`,
+		`{"model": "my-model", "api_key": "sk-test", "autonomy": "full-auto", "instructions": "Answer in British English."}`)
+
+	s, err := loadSettings(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *s.Model != "my-model" || *s.Autonomy != "full-auto" || *s.APIKey != "sk-test" {
+		t.Errorf("local settings should win, got model %q autonomy %q", *s.Model, *s.Autonomy)
+	}
+
+	if !slices.Equal(s.Tools, []string{"grep", "list"}) {
+		t.Errorf("shared tools should be kept when local sets none, got %v", s.Tools)
+	}
+
+	if s.Instructions != "Run make lint.\n\nAnswer in British English." {
+		t.Errorf("instructions from both files should be kept, got %q", s.Instructions)
+	}
+}
+
+func TestLoadSettings_Missing(t *testing.T) {
+	t.Parallel()
+
+	s, err := loadSettings(filepath.Join(t.TempDir(), ".artoo"))
+	if err != nil || s.Model != nil {
+		t.Errorf("missing files should give empty settings, got %+v, %v", s, err)
+	}
+}
+
+func TestLoadSettings_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		shared, local string
+		want          error
+	}{
+		{"unknown field", `{"modle": "x"}`, "", errInvalidSettings},
+		{"wrong type", `{"tools": "grep"}`, "", errInvalidSettings},
+		{"bad autonomy", "", `{"autonomy": "sometimes"}`, errInvalidSettings},
+		{"bad profile", `{"profile": "fast"}`, "", errInvalidSettings},
+		{"shared key", `{"api_key": "sk-test"}`, "", errLocalOnly},
+		{"shared full-auto", `{"autonomy": "full-auto"}`, "", errLocalOnly},
+		{"shared plugin dir", `{"plugin_dir": "tools"}`, "", errLocalOnly},
+		{"shared base URL", `{"base_url": "https://gateway.example.com"}`, "", errLocalOnly},
+		{"shared headers", `{"api_headers": ["X-Team: core"]}`, "", errLocalOnly},
+		{"shared HTTP allowlist", `{"http_allow": ["attacker.example.com"]}`, "", errLocalOnly},
+		{"shared env allowlist", `{"env_allow": ["AWS_SECRET_ACCESS_KEY"]}`, "", errLocalOnly},
+		{"local allowlists", "", `{"http_allow": ["api.internal"], "env_allow": ["GOPATH"]}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := loadSettings(writeSettings(t, tt.shared, tt.local)); !errors.Is(err, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+}
+
+func TestApplySettings_EnvWins(t *testing.T) {
+	t.Setenv("ARTOO_MODEL", "env-model")
+
+	s, err := loadSettings(writeSettings(t, `{"model": "file-model"}`, `{"http_allow": ["api.internal"]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := LoadConfig()
+	applySettings(&cfg, s)
+
+	if cfg.Agent.Model != "env-model" {
+		t.Errorf("environment should override settings files, got %q", cfg.Agent.Model)
+	}
+
+	if !slices.Equal(cfg.HTTPAllow, []string{"api.internal"}) {
+		t.Errorf("settings should fill unset variables, got %v", cfg.HTTPAllow)
+	}
+}
@@ -0,0 +1,64 @@
+// Package main provides the stats subcommand for local usage statistics.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/stats"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// runStats implements the `artoo stats` subcommand, which renders the local
+// usage statistics recorded when ARTOO_STATS is enabled.
+func runStats(_ context.Context, cfg AppConfig, _ anthropic.Client, _ []string) error {
+	store, err := stats.Open(cfg.StatsFile)
+	if err != nil {
+		return err
+	}
+
+	st := store.Snapshot()
+	if st.Sessions == 0 && !cfg.Stats {
+		fmt.Println("No statistics recorded. Set ARTOO_STATS=true to record local usage statistics.")
+
+		return nil
+	}
+
+	fmt.Print(stats.Render(st, time.Now()))
+
+	return nil
+}
+
+// openStats opens the statistics store and records a new session when
+// statistics are enabled. It returns nil when they are disabled or unavailable.
+func openStats(cfg AppConfig, a *agent.Agent) *stats.Store {
+	if !cfg.Stats {
+		return nil
+	}
+
+	store, err := stats.Open(cfg.StatsFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+
+		return nil
+	}
+
+	store.RecordSession()
+	a.SetRecorder(store)
+
+	return store
+}
+
+// saveStats persists statistics, warning on failure.
+func saveStats(store *stats.Store) {
+	if store == nil {
+		return
+	}
+
+	if err := store.Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/stats"
+	"github.com/aelse/artoo/tool"
+)
+
+// summaryMaxFiles is how many modified files the session summary names.
+const summaryMaxFiles = 10
+
+// modelPrice is a model's list price in dollars per million tokens.
+type modelPrice struct {
+	prefix        string
+	input, output float64
+}
+
+// modelPrices are matched against the model name by prefix; the first match
+// applies. Models not listed get no cost estimate.
+var modelPrices = []modelPrice{
+	{"claude-opus-4-5", 5, 25},
+	{"claude-opus-4-1", 15, 75},
+	{"claude-opus-4-0", 15, 75},
+	{"claude-opus-4-2025", 15, 75},
+	{"claude-sonnet-4", 3, 15},
+	{"claude-3-7-sonnet", 3, 15},
+	{"claude-haiku-4-5", 1, 5},
+	{"claude-3-5-haiku", 0.8, 4},
+}
+
+// priceOf returns the price of model and whether it is known.
+func priceOf(model string) (modelPrice, bool) {
+	for _, p := range modelPrices {
+		if strings.HasPrefix(model, p.prefix) {
+			return p, true
+		}
+	}
+
+	return modelPrice{}, false
+}
+
+// sessionSummary is a Recorder tallying an interactive session for the
+// summary printed when it ends, passing usage on to the next recorder.
+type sessionSummary struct {
+	next    agent.Recorder // nil if statistics are off
+	model   func() string  // model in use, which a profile may switch
+	changes *tool.Changes
+	started time.Time
+
+	mu       sync.Mutex
+	turns    int           // prompts sent
+	busy     time.Duration // time spent waiting on turns
+	requests int
+	input    int64
+	output   int64
+	cost     float64
+	unpriced bool // some requests went to a model without a known price
+	tools    map[string]*toolCount
+}
+
+type toolCount struct {
+	calls, errors int
+}
+
+var _ agent.Recorder = (*sessionSummary)(nil)
+
+// startSummary starts tallying the session run by a, reporting the files
+// in changes and passing usage on to store if statistics are enabled.
+func startSummary(a *agent.Agent, store *stats.Store, changes *tool.Changes) *sessionSummary {
+	s := &sessionSummary{
+		model:   a.Model,
+		changes: changes,
+		started: time.Now(),
+		tools:   map[string]*toolCount{},
+	}
+
+	if store != nil {
+		s.next = store
+	}
+
+	a.SetRecorder(s)
+
+	return s
+}
+
+// RecordUsage implements agent.Recorder.
+func (s *sessionSummary) RecordUsage(inputTokens, outputTokens int64) {
+	if s.next != nil {
+		s.next.RecordUsage(inputTokens, outputTokens)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests++
+	s.input += inputTokens
+	s.output += outputTokens
+
+	price, ok := priceOf(s.model())
+	if !ok {
+		s.unpriced = true
+
+		return
+	}
+
+	s.cost += (float64(inputTokens)*price.input + float64(outputTokens)*price.output) / 1e6
+}
+
+// RecordTool implements agent.Recorder.
+func (s *sessionSummary) RecordTool(name string, isError bool) {
+	if s.next != nil {
+		s.next.RecordTool(name, isError)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.tools[name]
+	if t == nil {
+		t = &toolCount{}
+		s.tools[name] = t
+	}
+
+	t.calls++
+	if isError {
+		t.errors++
+	}
+}
+
+// turn records a prompt that took d to answer.
+func (s *sessionSummary) turn(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.turns++
+	s.busy += d
+}
+
+// print writes the summary, unless no prompt was sent.
+func (s *sessionSummary) print() {
+	if out := s.format(time.Now(), s.changes.Paths()); out != "" {
+		fmt.Print(out)
+	}
+}
+
+// format renders the summary of the session as of now, with the files
+// modified. It is empty if no prompt was sent.
+func (s *sessionSummary) format(now time.Time, files []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.turns == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "\nSession: %s, %d turns (%s on average), %d requests\n",
+		now.Sub(s.started).Round(time.Second), s.turns, (s.busy / time.Duration(s.turns)).Round(100*time.Millisecond), s.requests)
+
+	fmt.Fprintf(&b, "Tokens: %d in, %d out", s.input, s.output)
+
+	switch {
+	case s.unpriced && s.cost == 0:
+		b.WriteString(" (no price known for the model)")
+	case s.unpriced:
+		fmt.Fprintf(&b, " (about $%.2f, excluding models without a known price)", s.cost)
+	default:
+		fmt.Fprintf(&b, " (about $%.2f)", s.cost)
+	}
+
+	b.WriteString("\n")
+
+	if len(s.tools) > 0 {
+		b.WriteString("Tools:")
+		s.writeTools(&b)
+		b.WriteString("\n")
+	}
+
+	if len(files) > 0 {
+		names := make([]string, 0, summaryMaxFiles)
+		for _, f := range files[:min(len(files), summaryMaxFiles)] {
+			names = append(names, tool.DisplayPath(f))
+		}
+
+		fmt.Fprintf(&b, "Files modified: %d (%s", len(files), strings.Join(names, ", "))
+
+		if more := len(files) - len(names); more > 0 {
+			fmt.Fprintf(&b, " and %d more", more)
+		}
+
+		b.WriteString(")\n")
+	}
+
+	return b.String()
+}
+
+// writeTools writes the tool call counts, most called first.
+func (s *sessionSummary) writeTools(b *strings.Builder) {
+	names := make([]string, 0, len(s.tools))
+	for name := range s.tools {
+		names = append(names, name)
+	}
+
+	slices.SortFunc(names, func(x, y string) int {
+		return cmp.Or(cmp.Compare(s.tools[y].calls, s.tools[x].calls), cmp.Compare(x, y))
+	})
+
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(",")
+		}
+
+		t := s.tools[name]
+		fmt.Fprintf(b, " %s %d", name, t.calls)
+
+		if t.errors > 0 {
+			fmt.Fprintf(b, " (%d failed)", t.errors)
+		}
+	}
+}
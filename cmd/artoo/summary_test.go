@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionSummary_Format(t *testing.T) {
+	t.Parallel()
+
+	model := "claude-sonnet-4-20250514"
+	started := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	s := &sessionSummary{model: func() string { return model }, started: started, tools: map[string]*toolCount{}}
+
+	if got := s.format(started.Add(time.Minute), nil); got != "" {
+		t.Errorf("format() before any prompt = %q, want empty", got)
+	}
+
+	s.RecordUsage(900_000, 10_000)
+	s.RecordUsage(100_000, 0)
+	s.RecordTool("read_file", false)
+	s.RecordTool("grep", false)
+	s.RecordTool("read_file", true)
+	s.turn(3 * time.Second)
+	s.turn(5 * time.Second)
+
+	got := s.format(started.Add(2*time.Minute+30*time.Second), []string{"/src/a.go", "/src/b.go"})
+
+	for _, want := range []string{
+		"Session: 2m30s, 2 turns (4s on average), 2 requests\n",
+		"Tokens: 1000000 in, 10000 out (about $3.15)\n",
+		"Tools: read_file 2 (1 failed), grep 1\n",
+		"Files modified: 2 (/src/a.go, /src/b.go)\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("format() = %q, missing %q", got, want)
+		}
+	}
+
+	// Usage on a model without a known price is left out of the estimate
+	model = "some-other-model"
+	s.RecordUsage(1_000_000, 0)
+
+	got = s.format(started.Add(time.Hour), nil)
+	if want := "Tokens: 2000000 in, 10000 out (about $3.15, excluding models without a known price)"; !strings.Contains(got, want) {
+		t.Errorf("format() = %q, missing %q", got, want)
+	}
+}
+
+func TestSessionSummary_ManyFiles(t *testing.T) {
+	t.Parallel()
+
+	s := &sessionSummary{model: func() string { return "unknown" }, started: time.Now(), tools: map[string]*toolCount{}}
+	s.RecordUsage(10, 10)
+	s.turn(time.Second)
+
+	files := make([]string, summaryMaxFiles+3)
+	for i := range files {
+		files[i] = "/f" + string(rune('a'+i))
+	}
+
+	got := s.format(time.Now(), files)
+
+	if !strings.Contains(got, "Files modified: 13 (/fa, ") || !strings.Contains(got, "/fj and 3 more)") {
+		t.Errorf("format() = %q, want the first %d files and a count of the rest", got, summaryMaxFiles)
+	}
+
+	if !strings.Contains(got, "(no price known for the model)") {
+		t.Errorf("format() = %q, want no cost estimate", got)
+	}
+}
+
+func TestPriceOf(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		model string
+		input float64
+		known bool
+	}{
+		{"claude-opus-4-5-20251101", 5, true},
+		{"claude-opus-4-1-20250805", 15, true},
+		{"claude-opus-4-20250514", 15, true},
+		{"claude-sonnet-4-5", 3, true},
+		{"claude-3-5-haiku-latest", 0.8, true},
+		{"claude-opus-5", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		price, ok := priceOf(tt.model)
+		if ok != tt.known || price.input != tt.input {
+			t.Errorf("priceOf(%q) = %v, %v; want input %v, %v", tt.model, price, ok, tt.input, tt.known)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+// Package main provides the trash of overwritten files and /restore.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/tool"
+)
+
+// trashListLimit caps the versions /restore lists.
+const trashListLimit = 20
+
+var errEmptyTrash = errors.New("the trash is empty")
+
+// trashDir holds the previous versions of files overwritten by tools.
+func trashDir(cfg AppConfig) string {
+	return filepath.Join(cfg.StorageDir, "trash")
+}
+
+// restoreCommand lists the versions in the trash, newest first, or restores
+// the one numbered or identified by args.
+func (a *app) restoreCommand(args string) {
+	trash := tool.NewTrash(trashDir(a.started))
+
+	entries, err := trash.List()
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	if args == "" {
+		a.term.PrintInfo(formatTrash(entries, time.Now()))
+
+		if len(entries) > 0 {
+			a.term.PrintInfo("Use /restore <n> to write a version back.")
+		}
+
+		return
+	}
+
+	if len(entries) == 0 {
+		a.term.PrintError(errEmptyTrash)
+
+		return
+	}
+
+	id := args
+	if n, err := strconv.Atoi(args); err == nil && n >= 1 && n <= len(entries) {
+		id = entries[n-1].ID
+	}
+
+	entry, err := trash.Restore(id)
+	if err != nil {
+		a.term.PrintError(err)
+
+		return
+	}
+
+	a.term.PrintInfo(fmt.Sprintf("Restored %s as of %s; the content it replaced, if any, is now in the trash",
+		entry.Path, entry.Saved.Format(time.DateTime)))
+}
+
+// formatTrash numbers the versions in the trash from 1, newest first.
+func formatTrash(entries []tool.TrashEntry, now time.Time) string {
+	if len(entries) == 0 {
+		return "No overwritten files in the trash."
+	}
+
+	var b strings.Builder
+
+	b.WriteString("Recoverable versions:")
+
+	for i, entry := range entries[:min(len(entries), trashListLimit)] {
+		fmt.Fprintf(&b, "\n%3d. %s (%d bytes, %s ago)", i+1, entry.Path, entry.Size, now.Sub(entry.Saved).Round(time.Second))
+	}
+
+	if more := len(entries) - trashListLimit; more > 0 {
+		fmt.Fprintf(&b, "\n... and %d older", more)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aelse/artoo/tool"
+)
+
+func TestFormatTrash(t *testing.T) {
+	t.Parallel()
+
+	if got := formatTrash(nil, time.Now()); !strings.Contains(got, "No overwritten files") {
+		t.Errorf("empty trash = %q", got)
+	}
+
+	now := time.Date(2026, 10, 14, 12, 0, 0, 0, time.UTC)
+
+	entries := make([]tool.TrashEntry, trashListLimit+3)
+	for i := range entries {
+		entries[i] = tool.TrashEntry{ID: "x", Path: "/src/main.go", Size: 120, Saved: now.Add(-time.Duration(i+1) * time.Minute)}
+	}
+
+	got := formatTrash(entries, now)
+
+	for _, want := range []string{"  1. /src/main.go (120 bytes, 1m0s ago)", " 20. /src/main.go", "... and 3 older"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+
+	if strings.Contains(got, " 21. ") {
+		t.Errorf("at most %d versions should be listed:\n%s", trashListLimit, got)
+	}
+}
@@ -0,0 +1,325 @@
+// Package main provides the version subcommand and the update check.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Build information, set when building a release:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD) \
+//	    -X main.date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Unset, they come from the Go build information where available.
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+const versionUsage = `usage:
+  artoo version [--check]             print the version, commit and build date;
+                                      --check also looks for a newer release`
+
+const (
+	releasesURL         = "https://api.github.com/repos/aelse/artoo/releases/latest"
+	updateCheckInterval = 24 * time.Hour  // how long a checked release is remembered
+	updateCheckTimeout  = 5 * time.Second // bounds the request to GitHub
+)
+
+var (
+	errVersionUsage = errors.New(versionUsage)
+	errReleaseCheck = errors.New("checking for a newer release failed")
+)
+
+// buildInfo describes the running binary.
+type buildInfo struct {
+	Version  string
+	Commit   string
+	Date     string
+	Modified bool // built from a checkout with uncommitted changes
+}
+
+// currentBuild returns the build information from the linker flags or else
+// from the Go build information: go install records the module version and
+// a build in a checkout the commit.
+func currentBuild() buildInfo {
+	b := buildInfo{Version: version, Commit: commit, Date: date}
+
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if b.Version == "" && info.Main.Version != "(devel)" {
+			b.Version = info.Main.Version
+		}
+
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if b.Commit == "" {
+					b.Commit = s.Value
+				}
+			case "vcs.time":
+				if b.Date == "" {
+					b.Date = s.Value
+				}
+			case "vcs.modified":
+				b.Modified = s.Value == "true" && commit == ""
+			}
+		}
+	}
+
+	if b.Version == "" {
+		b.Version = "dev"
+	}
+
+	return b
+}
+
+// String renders b on one line.
+func (b buildInfo) String() string {
+	details := []string{}
+
+	if b.Commit != "" {
+		short := b.Commit[:min(len(b.Commit), 12)]
+		if b.Modified {
+			short += "-dirty"
+		}
+
+		details = append(details, "commit "+short)
+	}
+
+	if b.Date != "" {
+		details = append(details, "built "+b.Date)
+	}
+
+	details = append(details, runtime.Version()+" "+runtime.GOOS+"/"+runtime.GOARCH)
+
+	return "artoo " + b.Version + " (" + strings.Join(details, ", ") + ")"
+}
+
+// runVersion implements the `artoo version` subcommand.
+func runVersion(ctx context.Context, _ AppConfig, _ anthropic.Client, args []string) error {
+	check := false
+
+	switch {
+	case len(args) == 0:
+	case len(args) == 1 && args[0] == "--check":
+		check = true
+	default:
+		return errVersionUsage
+	}
+
+	build := currentBuild()
+	fmt.Println(build)
+
+	if !check {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+	defer cancel()
+
+	latest, err := latestRelease(ctx, releasesURL)
+	if err != nil {
+		return err
+	}
+
+	switch notice := updateNotice(build.Version, latest); {
+	case notice != "":
+		fmt.Println(notice)
+	case isRelease(build.Version):
+		fmt.Printf("Up to date (the latest release is %s).\n", latest.Tag)
+	default:
+		fmt.Printf("The latest release is %s: %s\n", latest.Tag, latest.URL)
+	}
+
+	return nil
+}
+
+// release is the part of a GitHub release the update check uses.
+type release struct {
+	Tag string `json:"tag_name"`
+	URL string `json:"html_url"`
+}
+
+// latestRelease fetches the latest release from the GitHub API at url.
+func latestRelease(ctx context.Context, url string) (release, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return release{}, err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "artoo/"+currentBuild().Version)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return release{}, fmt.Errorf("%w: %w", errReleaseCheck, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return release{}, fmt.Errorf("%w: %s", errReleaseCheck, resp.Status)
+	}
+
+	var r release
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return release{}, fmt.Errorf("%w: %w", errReleaseCheck, err)
+	}
+
+	return r, nil
+}
+
+// updateCache remembers the latest release between sessions.
+type updateCache struct {
+	Checked time.Time `json:"checked"`
+	Latest  release   `json:"latest"`
+}
+
+// cachedRelease returns the latest release from the cache file at path if
+// it was checked within updateCheckInterval of now, and otherwise from url,
+// saving it to the cache.
+func cachedRelease(ctx context.Context, path, url string, now time.Time) (release, error) {
+	var cache updateCache
+
+	data, err := os.ReadFile(path) //nolint:gosec // artoo's own cache
+	if err == nil && json.Unmarshal(data, &cache) == nil && now.Sub(cache.Checked) < updateCheckInterval {
+		return cache.Latest, nil
+	}
+
+	latest, err := latestRelease(ctx, url)
+	if err != nil {
+		return release{}, err
+	}
+
+	data, err = json.Marshal(updateCache{Checked: now, Latest: latest})
+	if err == nil && os.MkdirAll(filepath.Dir(path), 0o700) == nil {
+		_ = os.WriteFile(path, data, 0o600) // checked again next time if unsaved
+	}
+
+	return latest, nil
+}
+
+// checkForUpdate looks for a newer release in the background, at most once
+// a day, when ARTOO_UPDATE_CHECK is set. The channel delivers a notice if
+// there is one and is closed either way; it is nil when nothing is checked.
+func checkForUpdate(ctx context.Context, cfg AppConfig) <-chan string {
+	current := currentBuild().Version
+	if !cfg.UpdateCheck || !isRelease(current) {
+		return nil
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+
+	notices := make(chan string, 1)
+
+	go func() {
+		defer close(notices)
+
+		ctx, cancel := context.WithTimeout(ctx, updateCheckTimeout)
+		defer cancel()
+
+		// A failed check is retried next session rather than reported
+		cacheFile := filepath.Join(homeDir, ".artoo", "update-check.json")
+
+		latest, err := cachedRelease(ctx, cacheFile, releasesURL, time.Now())
+		if err != nil {
+			return
+		}
+
+		if notice := updateNotice(current, latest); notice != "" {
+			notices <- notice
+		}
+	}()
+
+	return notices
+}
+
+// updateNotice returns the message announcing latest if it is newer than
+// current, or "".
+func updateNotice(current string, latest release) string {
+	if !newerVersion(latest.Tag, current) {
+		return ""
+	}
+
+	notice := fmt.Sprintf("artoo %s is available (you have %s)", latest.Tag, current)
+	if latest.URL != "" {
+		notice += ": " + latest.URL
+	}
+
+	return notice
+}
+
+// isRelease reports whether v is a version the update check can compare.
+func isRelease(v string) bool {
+	_, _, ok := parseVersion(v)
+
+	return ok
+}
+
+// newerVersion reports whether the semantic version latest is newer than
+// current. A pre-release is older than the release of the same number.
+func newerVersion(latest, current string) bool {
+	l, lpre, ok := parseVersion(latest)
+	if !ok {
+		return false
+	}
+
+	c, cpre, ok := parseVersion(current)
+	if !ok {
+		return false
+	}
+
+	for i := range l {
+		if l[i] != c[i] {
+			return l[i] > c[i]
+		}
+	}
+
+	return cpre != "" && lpre == ""
+}
+
+// parseVersion splits a version such as v1.2.3-rc.1 into its numbers and
+// pre-release.
+func parseVersion(v string) ([3]int, string, bool) {
+	var nums [3]int
+
+	v, ok := strings.CutPrefix(v, "v")
+	if !ok {
+		return nums, "", false
+	}
+
+	v, _, _ = strings.Cut(v, "+")
+	v, pre, _ := strings.Cut(v, "-")
+
+	parts := strings.Split(v, ".")
+	if len(parts) != len(nums) {
+		return nums, "", false
+	}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nums, "", false
+		}
+
+		nums[i] = n
+	}
+
+	return nums, pre, true
+}
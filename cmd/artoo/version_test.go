@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewerVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		latest, current string
+		want            bool
+	}{
+		{"v1.3.0", "v1.2.9", true},
+		{"v1.10.0", "v1.9.0", true},
+		{"v2.0.0", "v1.99.99", true},
+		{"v1.2.0", "v1.2.0", false},
+		{"v1.2.0", "v1.3.0", false},
+		{"v1.2.0", "v1.2.0-rc.1", true},
+		{"v1.2.0-rc.2", "v1.2.0", false},
+		{"v0.1.0", "v0.0.0-20260101000000-abcdef123456", true},
+		{"v1.2.0", "dev", false},
+		{"latest", "v1.0.0", false},
+		{"v1.2", "v1.0.0", false},
+	} {
+		if got := newerVersion(tt.latest, tt.current); got != tt.want {
+			t.Errorf("newerVersion(%q, %q) = %v, want %v", tt.latest, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateNotice(t *testing.T) {
+	t.Parallel()
+
+	latest := release{Tag: "v1.3.0", URL: "https://github.com/aelse/artoo/releases/tag/v1.3.0"}
+
+	if got := updateNotice("v1.2.0", latest); !strings.Contains(got, "v1.3.0 is available (you have v1.2.0)") ||
+		!strings.HasSuffix(got, latest.URL) {
+		t.Errorf("updateNotice = %q", got)
+	}
+
+	if got := updateNotice("v1.3.0", latest); got != "" {
+		t.Errorf("updateNotice for the latest version = %q, want none", got)
+	}
+}
+
+func TestBuildInfo_String(t *testing.T) {
+	t.Parallel()
+
+	b := buildInfo{Version: "v1.2.0", Commit: "0123456789abcdef", Date: "2026-10-01T12:00:00Z", Modified: true}
+
+	got := b.String()
+	if !strings.HasPrefix(got, "artoo v1.2.0 (commit 0123456789ab-dirty, built 2026-10-01T12:00:00Z, go") {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestCachedRelease(t *testing.T) {
+	t.Parallel()
+
+	var requests atomic.Int32
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"tag_name": "v1.3.0", "html_url": "https://example.com/v1.3.0"}`))
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "update-check.json")
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, at := range []time.Time{now, now.Add(time.Hour), now.Add(updateCheckInterval)} {
+		got, err := cachedRelease(context.Background(), path, srv.URL, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got.Tag != "v1.3.0" {
+			t.Errorf("release at %v = %+v", at, got)
+		}
+	}
+
+	// Fetched at first, then from the cache until it is a day old
+	if n := requests.Load(); n != 2 {
+		t.Errorf("%d requests, want 2", n)
+	}
+}
+
+func TestLatestRelease_Error(t *testing.T) {
+	t.Parallel()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "rate limited", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	if _, err := latestRelease(context.Background(), srv.URL); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("latestRelease error = %v, want the status", err)
+	}
+}
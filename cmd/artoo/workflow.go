@@ -0,0 +1,383 @@
+// Package main provides the workflow runner for scripted, repeatable tasks.
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/aelse/artoo/agent"
+	"github.com/anthropics/anthropic-sdk-go"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// workflowMaxRetries bounds a step's retries.
+	workflowMaxRetries = 10
+
+	// workflowMaxOutput caps the failed check output given back to the model.
+	workflowMaxOutput = 4_000
+)
+
+var (
+	errInvalidWorkflow = errors.New("invalid workflow")
+	errWorkflowFailed  = errors.New("workflow failed")
+)
+
+// stepName restricts step names to those usable in environment variables.
+var stepName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// workflow is a sequence of steps read from a YAML file.
+type workflow struct {
+	Name  string            `yaml:"name"`
+	Vars  map[string]string `yaml:"vars"`
+	Steps []workflowStep    `yaml:"steps"`
+}
+
+// workflowStep is one step: a prompt for the agent or a shell command.
+type workflowStep struct {
+	Name            string   `yaml:"name"`
+	Prompt          string   `yaml:"prompt"`            // sent to the agent
+	Run             string   `yaml:"run"`               // run with sh -c
+	Tools           []string `yaml:"tools"`             // tools offered for a prompt (default: as configured)
+	Check           string   `yaml:"check"`             // succeeds if it exits 0
+	Retries         int      `yaml:"retries"`           // extra attempts when the step or its check fails
+	If              string   `yaml:"if"`                // the step runs only if this exits 0
+	ContinueOnError bool     `yaml:"continue_on_error"` // a failure does not stop the workflow
+}
+
+// stepStatus is the outcome of a step, visible to later shell commands as
+// ARTOO_STEP_<NAME>.
+type stepStatus string
+
+const (
+	stepOK      stepStatus = "ok"
+	stepFailed  stepStatus = "failed"
+	stepSkipped stepStatus = "skipped"
+)
+
+// stepResult records how a step went.
+type stepResult struct {
+	name     string
+	status   stepStatus
+	attempts int
+	detail   string // why the step failed or was skipped
+}
+
+// workflowRunner executes workflows. send and shell are replaced in tests.
+type workflowRunner struct {
+	send  func(ctx context.Context, prompt string, tools []string) error
+	shell func(ctx context.Context, command string, env []string) (string, error)
+	env   []string // environment commands start with (nil is the process's)
+	log   io.Writer
+}
+
+// isWorkflowFile reports whether a run argument names a workflow file rather
+// than starting a prompt.
+func isWorkflowFile(arg string) bool {
+	return !strings.ContainsAny(arg, " \t\n") && (strings.HasSuffix(arg, ".yaml") || strings.HasSuffix(arg, ".yml"))
+}
+
+// loadWorkflow reads and validates a workflow, rendering its prompts and
+// commands with its variables overridden by vars.
+func loadWorkflow(path string, vars map[string]string) (workflow, error) {
+	var wf workflow
+
+	data, err := os.ReadFile(path) //nolint:gosec // user-supplied workflow file
+	if err != nil {
+		return wf, err
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&wf); err != nil {
+		return wf, fmt.Errorf("%w: %s: %w", errInvalidWorkflow, path, err)
+	}
+
+	if err := wf.prepare(vars); err != nil {
+		return wf, fmt.Errorf("%w: %s: %w", errInvalidWorkflow, path, err)
+	}
+
+	return wf, nil
+}
+
+// prepare checks the steps, names unnamed ones and renders templates.
+func (wf *workflow) prepare(vars map[string]string) error {
+	if len(wf.Steps) == 0 {
+		return errors.New("no steps")
+	}
+
+	data := make(map[string]string, len(wf.Vars)+len(vars))
+	for k, v := range wf.Vars {
+		data[k] = v
+	}
+
+	for k, v := range vars {
+		data[k] = v
+	}
+
+	seen := make(map[string]bool, len(wf.Steps))
+
+	for i := range wf.Steps {
+		s := &wf.Steps[i]
+
+		if s.Name == "" {
+			s.Name = fmt.Sprintf("step%d", i+1)
+		}
+
+		switch {
+		case !stepName.MatchString(s.Name):
+			return fmt.Errorf("step %q: names must start with a letter and hold only letters, digits, _ and -", s.Name)
+		case seen[s.Name]:
+			return fmt.Errorf("step %q appears twice", s.Name)
+		case (s.Prompt == "") == (s.Run == ""):
+			return fmt.Errorf("step %q needs exactly one of prompt and run", s.Name)
+		case s.Run != "" && s.Tools != nil:
+			return fmt.Errorf("step %q: tools apply only to prompt steps", s.Name)
+		case s.Retries < 0 || s.Retries > workflowMaxRetries:
+			return fmt.Errorf("step %q: retries must be between 0 and %d", s.Name, workflowMaxRetries)
+		}
+
+		seen[s.Name] = true
+
+		for _, field := range []*string{&s.Prompt, &s.Run, &s.Check, &s.If} {
+			rendered, err := renderWorkflowText(*field, data)
+			if err != nil {
+				return fmt.Errorf("step %q: %w", s.Name, err)
+			}
+
+			*field = rendered
+		}
+	}
+
+	return nil
+}
+
+// renderWorkflowText expands {{.var}} references in text.
+func renderWorkflowText(text string, data map[string]string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+
+	tmpl, err := template.New("step").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// run executes the steps in order. It stops at the first failed step unless
+// that step continues on error, and returns every step's result.
+func (r *workflowRunner) run(ctx context.Context, wf workflow) ([]stepResult, error) {
+	results := make([]stepResult, 0, len(wf.Steps))
+	env := slices.Clone(r.env)
+	if env == nil {
+		env = os.Environ()
+	}
+
+	for _, step := range wf.Steps {
+		result := r.step(ctx, step, env)
+		results = append(results, result)
+		env = append(env, stepEnv(step.Name)+"="+string(result.status))
+
+		fmt.Fprintf(r.log, "step %s: %s\n", step.Name, result.describe())
+
+		if result.status == stepFailed && !step.ContinueOnError {
+			return results, fmt.Errorf("%w: step %s: %s", errWorkflowFailed, step.Name, result.detail)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// step executes one step, retrying it while it or its check fails.
+func (r *workflowRunner) step(ctx context.Context, step workflowStep, env []string) stepResult {
+	result := stepResult{name: step.Name}
+
+	if step.If != "" {
+		if _, err := r.shell(ctx, step.If, env); err != nil {
+			result.status, result.detail = stepSkipped, "condition not met"
+
+			return result
+		}
+	}
+
+	prompt := step.Prompt
+
+	for result.attempts < step.Retries+1 && ctx.Err() == nil {
+		result.attempts++
+
+		failure := r.attempt(ctx, step, prompt, env)
+		if failure == "" {
+			result.status, result.detail = stepOK, ""
+
+			return result
+		}
+
+		result.status, result.detail = stepFailed, failure
+		prompt = fmt.Sprintf("That did not work: %s\n\nFix the problem and finish the task:\n\n%s", failure, step.Prompt)
+	}
+
+	if result.attempts == 0 {
+		result.status, result.detail = stepFailed, context.Cause(ctx).Error()
+	}
+
+	return result
+}
+
+// attempt runs a step once and returns why it failed, or "" on success.
+func (r *workflowRunner) attempt(ctx context.Context, step workflowStep, prompt string, env []string) string {
+	if step.Prompt != "" {
+		if err := r.send(ctx, prompt, step.Tools); err != nil {
+			return err.Error()
+		}
+	} else if out, err := r.shell(ctx, step.Run, env); err != nil {
+		return fmt.Sprintf("`%s` failed (%v):\n%s", step.Run, err, tail(out, workflowMaxOutput))
+	}
+
+	if step.Check == "" {
+		return ""
+	}
+
+	if out, err := r.shell(ctx, step.Check, env); err != nil {
+		return fmt.Sprintf("the check `%s` failed (%v):\n%s", step.Check, err, tail(out, workflowMaxOutput))
+	}
+
+	return ""
+}
+
+// describe summarizes a result on one line.
+func (s stepResult) describe() string {
+	text := string(s.status)
+	if s.attempts > 1 {
+		text += fmt.Sprintf(" after %d attempts", s.attempts)
+	}
+
+	if s.status == stepSkipped {
+		text += " (" + s.detail + ")"
+	}
+
+	return text
+}
+
+// stepEnv is the environment variable holding a step's status.
+func stepEnv(name string) string {
+	return "ARTOO_STEP_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_"))
+}
+
+// tail returns the last n bytes of s.
+func tail(s string, n int) string {
+	s = strings.TrimSpace(s)
+	if len(s) <= n {
+		return s
+	}
+
+	return "…" + s[len(s)-n:]
+}
+
+// shellCommand runs command with sh -c and returns its combined output.
+func shellCommand(ctx context.Context, command string, env []string) (string, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command) //nolint:gosec // commands come from the user's workflow
+	cmd.Env = env
+
+	out, err := cmd.CombinedOutput()
+
+	return string(out), err
+}
+
+// runWorkflow implements `artoo run <workflow.yaml> [name=value...]`. All
+// prompts share one conversation, so later steps see earlier work. Progress
+// goes to stderr.
+func runWorkflow(ctx context.Context, cfg AppConfig, client anthropic.Client, path string, args []string) error {
+	vars, err := parseWorkflowVars(args)
+	if err != nil {
+		return errRunUsage
+	}
+
+	wf, err := loadWorkflow(path, vars)
+	if err != nil {
+		return err
+	}
+
+	cfg.Agent.Streaming = false
+	agentCfg := cfg.agentConfig()
+
+	a, err := agent.New(client, agentCfg, loadTools(cfg)...)
+	if err != nil {
+		return err
+	}
+
+	a.SetConversationConfig(cfg.conversationConfig(a.Model()))
+
+	store := openStats(cfg, a)
+	defer saveStats(store)
+
+	if wf.Name != "" {
+		fmt.Fprintf(os.Stderr, "workflow %s: %d steps\n", wf.Name, len(wf.Steps))
+	}
+
+	_, err = newWorkflowRunner(a, agentCfg.Tools, os.Stderr).run(ctx, wf)
+
+	return err
+}
+
+// parseWorkflowVars parses name=value arguments.
+func parseWorkflowVars(args []string) (map[string]string, error) {
+	vars := make(map[string]string, len(args))
+
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("%w: variables are name=value, got %q", errInvalidWorkflow, arg)
+		}
+
+		vars[name] = value
+	}
+
+	return vars, nil
+}
+
+// newWorkflowRunner returns a runner sending prompts to a, offering each
+// step's tools or, for steps without, tools. Progress goes to log.
+func newWorkflowRunner(a *agent.Agent, tools []string, log io.Writer) *workflowRunner {
+	cb := &headlessCallbacks{out: log}
+
+	return &workflowRunner{
+		send: func(ctx context.Context, prompt string, stepTools []string) error {
+			if stepTools == nil {
+				stepTools = tools
+			}
+
+			a.SetAllowedTools(stepTools)
+
+			resp, err := a.SendMessage(ctx, prompt, cb)
+			if err == nil {
+				err = turnError(resp)
+			}
+
+			return err
+		},
+		shell: shellCommand,
+		env:   a.ToolEnvironment().Environ(),
+		log:   log,
+	}
+}
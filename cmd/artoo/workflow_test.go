@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeWorkflow(t *testing.T, text string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "workflow.yaml")
+	if err := os.WriteFile(path, []byte(text), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadWorkflow(t *testing.T) {
+	t.Parallel()
+
+	path := writeWorkflow(t, `
+name: upgrade
+vars:
+  dep: golang.org/x/net
+  version: v0.1.0
+steps:
+  - name: bump
+    prompt: Upgrade {{.dep}} to {{.version}}
+    tools: [grep, write_files]
+    check: go build ./...
+    retries: 2
+  - run: go mod tidy
+`)
+
+	wf, err := loadWorkflow(path, map[string]string{"version": "v0.2.0"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(wf.Steps) != 2 || wf.Steps[0].Prompt != "Upgrade golang.org/x/net to v0.2.0" {
+		t.Errorf("unexpected steps %+v", wf.Steps)
+	}
+
+	if !slices.Equal(wf.Steps[0].Tools, []string{"grep", "write_files"}) || wf.Steps[0].Retries != 2 {
+		t.Errorf("unexpected first step %+v", wf.Steps[0])
+	}
+
+	if wf.Steps[1].Name != "step2" {
+		t.Errorf("unnamed step should be numbered, got %q", wf.Steps[1].Name)
+	}
+}
+
+func TestLoadWorkflow_Invalid(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"no steps":       "name: empty\n",
+		"unknown field":  "steps:\n  - prompt: hi\n    retry: 1\n",
+		"both kinds":     "steps:\n  - prompt: hi\n    run: ls\n",
+		"neither kind":   "steps:\n  - check: ls\n",
+		"duplicate name": "steps:\n  - {name: a, run: ls}\n  - {name: a, run: ls}\n",
+		"bad name":       "steps:\n  - {name: 1st, run: ls}\n",
+		"too many tries": "steps:\n  - {run: ls, retries: 50}\n",
+		"tools on run":   "steps:\n  - {run: ls, tools: [grep]}\n",
+		"missing var":    "steps:\n  - prompt: '{{.nope}}'\n",
+	}
+
+	for name, text := range tests {
+		if _, err := loadWorkflow(writeWorkflow(t, text), nil); !errors.Is(err, errInvalidWorkflow) {
+			t.Errorf("%s: expected errInvalidWorkflow, got %v", name, err)
+		}
+	}
+}
+
+func TestIsWorkflowFile(t *testing.T) {
+	t.Parallel()
+
+	if !isWorkflowFile("upgrade.yaml") || !isWorkflowFile("ci/release.yml") {
+		t.Error("YAML files should be workflows")
+	}
+
+	if isWorkflowFile("explain config.yaml") || isWorkflowFile("hello") {
+		t.Error("prompts should not be workflows")
+	}
+}
+
+// fakeRunner records prompts and shell commands. A command listed in fail
+// fails that many times, then succeeds.
+type fakeRunner struct {
+	prompts []string
+	tools   [][]string
+	runs    []string
+	envs    [][]string
+	fail    map[string]int
+}
+
+func (f *fakeRunner) runner() *workflowRunner {
+	return &workflowRunner{
+		send: func(_ context.Context, prompt string, tools []string) error {
+			f.prompts = append(f.prompts, prompt)
+			f.tools = append(f.tools, tools)
+
+			return nil
+		},
+		shell: func(_ context.Context, command string, env []string) (string, error) {
+			f.runs = append(f.runs, command)
+			f.envs = append(f.envs, env)
+
+			if f.fail[command] > 0 {
+				f.fail[command]--
+
+				return "FAIL: TestThing", errors.New("exit status 1")
+			}
+
+			return "ok", nil
+		},
+		log: io.Discard,
+	}
+}
+
+func TestWorkflowRun_RetriesUntilCheckPasses(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeRunner{fail: map[string]int{"go test ./...": 1}}
+	wf := workflow{Steps: []workflowStep{
+		{Name: "fix", Prompt: "Fix the tests", Tools: []string{"grep"}, Check: "go test ./...", Retries: 1},
+	}}
+
+	results, err := f.runner().run(context.Background(), wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if results[0].status != stepOK || results[0].attempts != 2 {
+		t.Errorf("unexpected result %+v", results[0])
+	}
+
+	if len(f.prompts) != 2 || !strings.Contains(f.prompts[1], "FAIL: TestThing") || !strings.HasSuffix(f.prompts[1], "Fix the tests") {
+		t.Errorf("retry should include the check output, got %q", f.prompts)
+	}
+
+	if !slices.Equal(f.tools[0], []string{"grep"}) {
+		t.Errorf("step tools should be passed on, got %v", f.tools[0])
+	}
+}
+
+func TestWorkflowRun_StopsOnFailure(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeRunner{fail: map[string]int{"make": 5}}
+	wf := workflow{Steps: []workflowStep{
+		{Name: "build", Run: "make", Retries: 1},
+		{Name: "after", Run: "echo never"},
+	}}
+
+	results, err := f.runner().run(context.Background(), wf)
+	if !errors.Is(err, errWorkflowFailed) {
+		t.Fatalf("expected errWorkflowFailed, got %v", err)
+	}
+
+	if len(results) != 1 || results[0].attempts != 2 || slices.Contains(f.runs, "echo never") {
+		t.Errorf("workflow should stop after the failed step, ran %q", f.runs)
+	}
+}
+
+func TestWorkflowRun_Conditionals(t *testing.T) {
+	t.Parallel()
+
+	f := &fakeRunner{fail: map[string]int{"make": 1, "test -f skip": 1}}
+	wf := workflow{Steps: []workflowStep{
+		{Name: "build", Run: "make", ContinueOnError: true},
+		{Name: "skipped", Run: "echo skipped", If: "test -f skip"},
+		{Name: "report", Run: "echo report", If: `[ "$ARTOO_STEP_BUILD" = failed ]`},
+	}}
+
+	results, err := f.runner().run(context.Background(), wf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statuses []stepStatus
+	for _, r := range results {
+		statuses = append(statuses, r.status)
+	}
+
+	if want := []stepStatus{stepFailed, stepSkipped, stepOK}; !slices.Equal(statuses, want) {
+		t.Errorf("statuses = %v, want %v", statuses, want)
+	}
+
+	last := f.envs[len(f.envs)-1]
+	if !slices.Contains(last, "ARTOO_STEP_BUILD=failed") || !slices.Contains(last, "ARTOO_STEP_SKIPPED=skipped") {
+		t.Errorf("later steps should see earlier outcomes, got %v", last[len(last)-2:])
+	}
+}
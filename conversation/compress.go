@@ -0,0 +1,375 @@
+package conversation
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// minCompressChars is the smallest tool result worth compressing.
+	minCompressChars = 1000
+
+	// keepRecentPrompts is how many of the latest prompts keep their tool
+	// results intact when old results are compressed.
+	keepRecentPrompts = 2
+
+	// maxDefinedNames is how many defined names a local summary lists.
+	maxDefinedNames = 8
+
+	// maxSummaryArg is how long the tool argument in a summary may be.
+	maxSummaryArg = 80
+
+	// compressedPrefix marks a tool result replaced by its summary.
+	compressedPrefix = "[compressed] "
+)
+
+// summaryArgs are the input fields that name what a tool acted on, in the
+// order a summary prefers them.
+var summaryArgs = []string{"path", "paths", "command", "pattern", "query", "url", "file"}
+
+// definition matches a line declaring a function, type or class in common
+// languages, capturing its name.
+var definition = regexp.MustCompile(
+	`^\s*(?:export\s+)?(?:pub\s+)?(?:func|type|class|def|fn|struct|interface|enum|trait)\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)`)
+
+// Summarizer writes a short summary of a tool result for compression, given
+// the tool's name, its JSON input and its output. Returning "" falls back to
+// the local summary.
+type Summarizer func(tool, input, output string) string
+
+// oldResult is an old tool result chosen for compression.
+type oldResult struct {
+	id      string // tool_use ID
+	isError bool
+	text    string
+	call    resultCall
+	summary string
+}
+
+// compressToolResults replaces the bodies of old tool results, oldest first,
+// with short summaries until the estimated tokens fall to threshold. Results
+// after the last keepRecentPrompts prompts are kept, as are short ones and
+// those a later duplicate refers to.
+// The summaries are written without holding mu, as summarize may call a
+// model; results changed meanwhile are left as they are. It reports whether
+// the estimate is at or below threshold. Caller must not hold mu.
+func (c *Conversation) compressToolResults(threshold int, summarize Summarizer) bool {
+	c.mu.RLock()
+	old, tokens := c.oldResults(), c.totalInputTokens
+	c.mu.RUnlock()
+
+	var summarized []oldResult
+
+	for _, r := range old {
+		if tokens <= threshold {
+			break
+		}
+
+		r.summary = compressedPrefix + summarizeResult(r.call, r.text, summarize)
+		tokens -= max(0, len(r.text)-len(r.summary)) / charsPerToken
+		summarized = append(summarized, r)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, r := range summarized {
+		if c.totalInputTokens <= threshold {
+			return true
+		}
+
+		c.replaceResult(r)
+	}
+
+	return c.totalInputTokens <= threshold
+}
+
+// oldResults returns the tool results that may be compressed, oldest first.
+// Caller must hold mu.
+func (c *Conversation) oldResults() []oldResult {
+	calls := toolCalls(c.messages)
+	referenced := referencedResults(c.messages)
+
+	var old []oldResult
+
+	for i := range c.compressibleMessages() {
+		if c.messages[i].Role != anthropic.MessageParamRoleUser {
+			continue
+		}
+
+		for _, block := range c.messages[i].Content {
+			text, ok := compressible(block, referenced)
+			if !ok {
+				continue
+			}
+
+			id := block.OfToolResult.ToolUseID
+			old = append(old, oldResult{id: id, isError: block.OfToolResult.IsError.Value, text: text, call: calls[id]})
+		}
+	}
+
+	return old
+}
+
+// replaceResult puts r's summary in place of its result, unless the result
+// has changed or is gone. Caller must hold mu.
+func (c *Conversation) replaceResult(r oldResult) {
+	for i, message := range c.messages {
+		for j, block := range message.Content {
+			if text, ok := toolResultText(block); !ok || block.OfToolResult.ToolUseID != r.id || text != r.text {
+				continue
+			}
+
+			// Messages may be shared with snapshots, so the content is copied
+			content := append([]anthropic.ContentBlockParamUnion(nil), message.Content...)
+			content[j] = anthropic.NewToolResultBlock(r.id, r.summary, r.isError)
+			message.Content = content
+			c.messages[i] = message
+
+			c.totalInputTokens -= max(0, len(r.text)-len(r.summary)) / charsPerToken
+			delete(c.results, sha256.Sum256([]byte(r.text)))
+
+			return
+		}
+	}
+}
+
+// Compressible returns, for each message, how many characters compressing
+// its tool results with local summaries would free.
+func (c *Conversation) Compressible() []int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	freed := make([]int, len(c.messages))
+	calls := toolCalls(c.messages)
+	referenced := referencedResults(c.messages)
+
+	for i := range c.compressibleMessages() {
+		for _, block := range c.messages[i].Content {
+			text, ok := compressible(block, referenced)
+			if !ok {
+				continue
+			}
+
+			call := calls[block.OfToolResult.ToolUseID]
+			summary := compressedPrefix + LocalSummary(call.name, call.input, text)
+			freed[i] += max(0, len(text)-len(summary))
+		}
+	}
+
+	return freed
+}
+
+// compressible returns the text of block if it is a tool result that may be
+// compressed: long enough, not compressed yet and not referred to by a later
+// duplicate.
+func compressible(block anthropic.ContentBlockParamUnion, referenced map[string]bool) (string, bool) {
+	text, ok := toolResultText(block)
+	if !ok || len(text) < minCompressChars || strings.HasPrefix(text, compressedPrefix) {
+		return "", false
+	}
+
+	// A later duplicate refers to this result for its content
+	if referenced[block.OfToolResult.ToolUseID] {
+		return "", false
+	}
+
+	return text, true
+}
+
+// compressibleMessages returns the number of leading messages whose tool
+// results may be compressed: those before the last keepRecentPrompts
+// prompts. Caller must hold mu.
+func (c *Conversation) compressibleMessages() int {
+	prompts := 0
+
+	for i := len(c.messages) - 1; i >= 0; i-- {
+		if IsPrompt(c.messages[i]) {
+			prompts++
+			if prompts == keepRecentPrompts {
+				return i
+			}
+		}
+	}
+
+	return 0
+}
+
+// resultCall is the call that produced a tool result.
+type resultCall struct {
+	name  string
+	input string // JSON
+}
+
+// toolCalls returns the tool calls in messages, by ID.
+func toolCalls(messages []anthropic.MessageParam) map[string]resultCall {
+	calls := map[string]resultCall{}
+
+	for _, m := range messages {
+		for _, block := range m.Content {
+			if block.OfToolUse == nil {
+				continue
+			}
+
+			input, _ := json.Marshal(block.OfToolUse.Input)
+			calls[block.OfToolUse.ID] = resultCall{name: block.OfToolUse.Name, input: string(input)}
+		}
+	}
+
+	return calls
+}
+
+// referencedResults returns the IDs of the tool calls whose results later
+// duplicates refer to instead of repeating them.
+func referencedResults(messages []anthropic.MessageParam) map[string]bool {
+	referenced := map[string]bool{}
+
+	for _, m := range messages {
+		for _, block := range m.Content {
+			text, ok := toolResultText(block)
+			if !ok {
+				continue
+			}
+
+			if rest, found := strings.CutPrefix(text, dedupNote); found {
+				id, _, _ := strings.Cut(rest, " ")
+				referenced[id] = true
+			}
+		}
+	}
+
+	return referenced
+}
+
+// summarizeResult summarizes output of call with summarize, falling back to
+// a local summary when summarize is nil or returns nothing.
+func summarizeResult(call resultCall, output string, summarize Summarizer) string {
+	if summarize != nil {
+		if s := strings.TrimSpace(summarize(call.name, call.input, output)); s != "" {
+			return s
+		}
+	}
+
+	return LocalSummary(call.name, call.input, output)
+}
+
+// LocalSummary summarizes a tool result without a model: what the tool acted
+// on, the size of its output, the names the output defines and its first
+// line, e.g. "read_file main.go: 180 lines, 5234 characters; defines Agent,
+// Run".
+func LocalSummary(tool, input, output string) string {
+	var b strings.Builder
+
+	if tool == "" {
+		tool = "tool"
+	}
+
+	b.WriteString(tool)
+
+	if arg := summaryArg(input); arg != "" {
+		b.WriteString(" " + arg)
+	}
+
+	lines := strings.Count(output, "\n")
+	if !strings.HasSuffix(output, "\n") {
+		lines++
+	}
+
+	unit := "lines"
+	if lines == 1 {
+		unit = "line"
+	}
+
+	fmt.Fprintf(&b, ": %d %s, %d characters", lines, unit, len(output))
+
+	if names := definedNames(output); len(names) > 0 {
+		b.WriteString("; defines " + strings.Join(names, ", "))
+	}
+
+	if first := firstLine(output); first != "" {
+		fmt.Fprintf(&b, "; begins %q", first)
+	}
+
+	b.WriteString(". Run the tool again for the full output.")
+
+	return b.String()
+}
+
+// summaryArg returns the first of summaryArgs set in input, shortened.
+func summaryArg(input string) string {
+	var fields map[string]any
+	if json.Unmarshal([]byte(input), &fields) != nil {
+		return ""
+	}
+
+	for _, name := range summaryArgs {
+		var arg string
+
+		switch v := fields[name].(type) {
+		case string:
+			arg = v
+		case []any:
+			var parts []string
+
+			for _, p := range v {
+				if s, ok := p.(string); ok {
+					parts = append(parts, s)
+				}
+			}
+
+			arg = strings.Join(parts, ", ")
+		}
+
+		if arg = strings.Join(strings.Fields(arg), " "); arg != "" {
+			return shorten(arg, maxSummaryArg)
+		}
+	}
+
+	return ""
+}
+
+// definedNames returns the first maxDefinedNames distinct names declared in
+// output.
+func definedNames(output string) []string {
+	var names []string
+
+	for line := range strings.Lines(output) {
+		m := definition.FindStringSubmatch(line)
+		if m == nil || slices.Contains(names, m[1]) {
+			continue
+		}
+
+		names = append(names, m[1])
+		if len(names) == maxDefinedNames {
+			break
+		}
+	}
+
+	return names
+}
+
+// firstLine returns the first non-blank line of output, shortened.
+func firstLine(output string) string {
+	for line := range strings.Lines(output) {
+		if line = strings.TrimSpace(line); line != "" {
+			return shorten(line, maxSummaryArg)
+		}
+	}
+
+	return ""
+}
+
+// shorten truncates s to limit runes, marking the cut.
+func shorten(s string, limit int) string {
+	if r := []rune(s); len(r) > limit {
+		return string(r[:limit]) + "…"
+	}
+
+	return s
+}
@@ -0,0 +1,243 @@
+package conversation
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// goSource is a tool result large enough to be compressed.
+var goSource = "package agent\n\ntype Agent struct{}\n\nfunc (a *Agent) Run() {}\n\nfunc New() *Agent { return nil }\n" +
+	strings.Repeat("// filler\n", 200)
+
+// compressibleConversation returns a conversation of three prompts; the
+// first read a file, and the last read another.
+func compressibleConversation() *Conversation {
+	c := NewWithConfig(Config{MaxContextTokens: 1000, ToolResultMaxChars: 10_000})
+
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("explain the agent")))
+	c.Append(anthropic.NewAssistantMessage(
+		anthropic.NewToolUseBlock("t1", map[string]any{"path": "agent.go"}, "read_file")))
+	c.AppendToolResults(anthropic.NewToolResultBlock("t1", goSource, false))
+	c.Append(anthropic.NewAssistantMessage(anthropic.NewTextBlock("It runs the loop.")))
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("thanks")))
+	c.Append(anthropic.NewAssistantMessage(anthropic.NewTextBlock("You're welcome.")))
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("read it again")))
+	c.Append(anthropic.NewAssistantMessage(
+		anthropic.NewToolUseBlock("t2", map[string]any{"path": "tool.go"}, "read_file")))
+	c.AppendToolResults(anthropic.NewToolResultBlock("t2", strings.ReplaceAll(goSource, "agent", "tool"), false))
+
+	return c
+}
+
+func TestTrim_CompressesOldToolResults(t *testing.T) {
+	t.Parallel()
+
+	c := compressibleConversation()
+	count := c.MessageCount()
+
+	c.UpdateTokenCount(800)
+	c.Trim()
+
+	if c.MessageCount() != count {
+		t.Fatalf("MessageCount = %d, want %d: compression should have freed enough", c.MessageCount(), count)
+	}
+
+	if c.EstimatedTokens() > 750 {
+		t.Errorf("EstimatedTokens = %d, want at most the threshold of 750", c.EstimatedTokens())
+	}
+
+	messages := c.Snapshot()
+
+	old, _ := toolResultText(messages[2].Content[0])
+	if !strings.HasPrefix(old, compressedPrefix) {
+		t.Fatalf("old result = %.60q, want it compressed", old)
+	}
+
+	for _, want := range []string{"read_file agent.go", "defines Agent, Run, New", `begins "package agent"`} {
+		if !strings.Contains(old, want) {
+			t.Errorf("summary %q does not contain %q", old, want)
+		}
+	}
+
+	// The latest prompt's result is kept
+	if recent, _ := toolResultText(messages[8].Content[0]); strings.HasPrefix(recent, compressedPrefix) {
+		t.Errorf("recent result = %.60q, want it intact", recent)
+	}
+}
+
+func TestTrim_KeepsReferencedResults(t *testing.T) {
+	t.Parallel()
+
+	c := NewWithConfig(Config{MaxContextTokens: 1000, ToolResultMaxChars: 10_000})
+
+	// The same file is read after each of three prompts; the later reads
+	// refer to the first
+	for i, id := range []string{"t1", "t2", "t3"} {
+		c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("read it")))
+		c.Append(anthropic.NewAssistantMessage(
+			anthropic.NewToolUseBlock(id, map[string]any{"path": "agent.go"}, "read_file")))
+		c.AppendToolResults(anthropic.NewToolResultBlock(id, goSource, false))
+
+		if i < 2 {
+			c.Append(anthropic.NewAssistantMessage(anthropic.NewTextBlock("done")))
+		}
+	}
+
+	c.UpdateTokenCount(800)
+	c.Trim()
+
+	for _, m := range c.Snapshot() {
+		for _, block := range m.Content {
+			if text, ok := toolResultText(block); ok && strings.HasPrefix(text, compressedPrefix) {
+				t.Errorf("result of %s compressed, but later duplicates refer to it", block.OfToolResult.ToolUseID)
+			}
+		}
+	}
+}
+
+func TestTrimWith_Summarizer(t *testing.T) {
+	t.Parallel()
+
+	c := compressibleConversation()
+	c.UpdateTokenCount(800)
+
+	var gotTool, gotInput string
+
+	c.TrimWith(func(tool, input, _ string) string {
+		gotTool, gotInput = tool, input
+
+		return "read agent.go: defines Agent"
+	})
+
+	if gotTool != "read_file" || gotInput != `{"path":"agent.go"}` {
+		t.Errorf("summarizer got (%q, %q), want the read_file call", gotTool, gotInput)
+	}
+
+	old, _ := toolResultText(c.Snapshot()[2].Content[0])
+	if old != compressedPrefix+"read agent.go: defines Agent" {
+		t.Errorf("old result = %q, want the summarizer's summary", old)
+	}
+}
+
+func TestTrimWith_SummarizesUnlocked(t *testing.T) {
+	t.Parallel()
+
+	c := compressibleConversation()
+	c.UpdateTokenCount(800)
+
+	// The conversation can be used while a summary is written, and the
+	// result it replaces is left alone once it has changed
+	c.TrimWith(func(string, string, string) string {
+		c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("meanwhile")))
+
+		c.mu.Lock()
+		c.messages[2] = anthropic.NewUserMessage(anthropic.NewToolResultBlock("t1", "edited", false))
+		c.mu.Unlock()
+
+		return "read agent.go: defines Agent"
+	})
+
+	appended, edited := false, false
+
+	for _, m := range c.Snapshot() {
+		for _, block := range m.Content {
+			if block.OfText != nil && block.OfText.Text == "meanwhile" {
+				appended = true
+			}
+
+			if text, ok := toolResultText(block); ok && block.OfToolResult.ToolUseID == "t1" {
+				edited = text == "edited"
+			}
+		}
+	}
+
+	if !appended || !edited {
+		t.Errorf("appended %v, edited result kept %v; want both", appended, edited)
+	}
+}
+
+func TestTrimWith_EmptySummaryFallsBack(t *testing.T) {
+	t.Parallel()
+
+	c := compressibleConversation()
+	c.UpdateTokenCount(800)
+	c.TrimWith(func(string, string, string) string { return "" })
+
+	old, _ := toolResultText(c.Snapshot()[2].Content[0])
+	if !strings.Contains(old, "read_file agent.go:") {
+		t.Errorf("old result = %q, want the local summary", old)
+	}
+}
+
+func TestTrim_DropsWhenCompressionIsNotEnough(t *testing.T) {
+	t.Parallel()
+
+	c := compressibleConversation()
+	count := c.MessageCount()
+
+	c.UpdateTokenCount(2000)
+	c.Trim()
+
+	if c.MessageCount() >= count {
+		t.Errorf("MessageCount = %d, want messages dropped after compression", c.MessageCount())
+	}
+}
+
+func TestLocalSummary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		tool   string
+		input  string
+		output string
+		want   string
+	}{
+		{
+			"file", "read_file", `{"path":"main.go"}`, "package main\n\nfunc main() {}\n",
+			`read_file main.go: 3 lines, 29 characters; defines main; begins "package main". Run the tool again for the full output.`,
+		},
+		{
+			"paths", "read_many", `{"paths":["a.py","b.py"]}`, "class A:\n    def run(self): pass",
+			`read_many a.py, b.py: 2 lines, 32 characters; defines A, run; begins "class A:". Run the tool again for the full output.`,
+		},
+		{
+			"command", "bash", `{"command":"go test ./...","timeout":60}`, "\nok  \tpkg\n",
+			`bash go test ./...: 2 lines, 10 characters; begins "ok  \tpkg". Run the tool again for the full output.`,
+		},
+		{
+			"no input", "", "", "x",
+			`tool: 1 line, 1 characters; begins "x". Run the tool again for the full output.`,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := LocalSummary(tt.tool, tt.input, tt.output); got != tt.want {
+			t.Errorf("%s: LocalSummary() =\n%q\nwant\n%q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompressible(t *testing.T) {
+	t.Parallel()
+
+	c := compressibleConversation()
+	freed := c.Compressible()
+
+	if len(freed) != c.MessageCount() {
+		t.Fatalf("len(Compressible()) = %d, want one per message (%d)", len(freed), c.MessageCount())
+	}
+
+	for i, n := range freed {
+		if want := i == 2; (n > 0) != want {
+			t.Errorf("message %d frees %d characters; want only the old result (2) to free any", i, n)
+		}
+	}
+
+	// Compressing changes nothing until Trim
+	if old, _ := toolResultText(c.Snapshot()[2].Content[0]); strings.HasPrefix(old, compressedPrefix) {
+		t.Error("Compressible modified the conversation")
+	}
+}
@@ -2,15 +2,37 @@
 package conversation
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
 )
 
+const (
+	// charsPerToken approximates how many characters of tool output make a token.
+	charsPerToken = 4
+
+	// turnBudgetResults sets a turn's tool result budget as a multiple of
+	// ToolResultMaxChars.
+	turnBudgetResults = 4
+
+	// minToolResultChars is the least any tool result is truncated to.
+	minToolResultChars = 1000
+
+	// TrimPercent is the share of MaxContextTokens at which Trim starts.
+	TrimPercent = 75
+)
+
 // Config holds conversation configuration.
 type Config struct {
 	MaxContextTokens int // e.g. 180_000 for Sonnet's 200k window, with headroom
-	ToolResultMaxChars int // max chars for tool results before truncation (e.g. 10_000)
+	ToolResultMaxChars int // baseline max chars per tool result; see toolResultBudgets (e.g. 10_000)
 }
 
 // DefaultConfig returns a Config with sensible defaults.
@@ -22,11 +44,28 @@ func DefaultConfig() Config {
 }
 
 // Conversation manages the message history for an agent conversation
-// with context window management.
+// with context window management. It is safe for concurrent use.
 type Conversation struct {
+	mu               sync.RWMutex
+	id               string
+	title            string
+	createdAt        time.Time
+	updatedAt        time.Time
 	messages         []anthropic.MessageParam
+	notes            []string // scratchpad notes kept outside the messages
 	config           Config
-	totalInputTokens int // Updated from API response usage
+	totalInputTokens int                             // Updated from API response usage
+	results          map[[sha256.Size]byte]resultRef // tool results in context, by content hash
+}
+
+// Record is the persisted form of a conversation.
+type Record struct {
+	ID        string                   `json:"id"`
+	Title     string                   `json:"title"`
+	CreatedAt time.Time                `json:"created_at"`
+	UpdatedAt time.Time                `json:"updated_at"`
+	Messages  []anthropic.MessageParam `json:"messages"`
+	Notes     []string                 `json:"notes,omitempty"`
 }
 
 // New creates a new empty Conversation with default config.
@@ -36,15 +75,98 @@ func New() *Conversation {
 
 // NewWithConfig creates a new Conversation with a custom config.
 func NewWithConfig(config Config) *Conversation {
+	now := time.Now()
+
+	return &Conversation{
+		id:        generateID(now),
+		createdAt: now,
+		updatedAt: now,
+		messages:  make([]anthropic.MessageParam, 0),
+		config:    config,
+	}
+}
+
+// FromRecord restores a persisted conversation with the given config.
+func FromRecord(record Record, config Config) *Conversation {
 	return &Conversation{
-		messages: make([]anthropic.MessageParam, 0),
-		config:   config,
+		id:        record.ID,
+		title:     record.Title,
+		createdAt: record.CreatedAt,
+		updatedAt: record.UpdatedAt,
+		messages:  slices.Clone(record.Messages),
+		notes:     slices.Clone(record.Notes),
+		config:    config,
+	}
+}
+
+// generateID returns an ID of the form YYYYMMDD-HHMMSS-xxxx, where xxxx is random hex.
+func generateID(now time.Time) string {
+	b := make([]byte, 2)
+	_, _ = rand.Read(b)
+
+	return fmt.Sprintf("%s-%s", now.Format("20060102-150405"), hex.EncodeToString(b))
+}
+
+// Config returns the conversation's configuration.
+func (c *Conversation) Config() Config {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.config
+}
+
+// SetConfig replaces the conversation's configuration. Messages already in
+// the conversation are kept; the new limits apply from the next turn.
+func (c *Conversation) SetConfig(config Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.config = config
+}
+
+// ID returns the conversation's unique ID.
+func (c *Conversation) ID() string {
+	return c.id
+}
+
+// Title returns the conversation's human-readable title, if set.
+func (c *Conversation) Title() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.title
+}
+
+// SetTitle sets the conversation's human-readable title.
+func (c *Conversation) SetTitle(title string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.title = title
+}
+
+// Record returns a snapshot of the conversation for persistence.
+func (c *Conversation) Record() Record {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return Record{
+		ID:        c.id,
+		Title:     c.title,
+		CreatedAt: c.createdAt,
+		UpdatedAt: c.updatedAt,
+		Messages:  slices.Clone(c.messages),
+		Notes:     slices.Clone(c.notes),
 	}
 }
 
 // Append adds a message parameter to the conversation.
 func (c *Conversation) Append(message anthropic.MessageParam) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.messages = append(c.messages, message)
+	c.updatedAt = time.Now()
 }
 
 // AppendToolResult adds a tool result, truncating it if it exceeds the max character limit.
@@ -54,30 +176,106 @@ func (c *Conversation) AppendToolResult(result anthropic.ContentBlockParamUnion)
 	c.Append(anthropic.NewUserMessage(truncated))
 }
 
-// truncateToolResult checks if a tool result exceeds the character limit and truncates if needed.
-func (c *Conversation) truncateToolResult(result anthropic.ContentBlockParamUnion) anthropic.ContentBlockParamUnion {
-	if result.OfToolResult == nil {
-		return result
+// AppendToolResults adds the results of one turn's tool calls as a single
+// user message. Results identical to one already in context are replaced
+// by a reference to it, and the rest are truncated to budgets from
+// toolResultBudgets. Blocks other than tool results are appended unchanged.
+func (c *Conversation) AppendToolResults(blocks ...anthropic.ContentBlockParamUnion) {
+	blocks = c.dedupeToolResults(blocks)
+
+	var sizes []int
+	for _, block := range blocks {
+		if text, ok := toolResultText(block); ok {
+			sizes = append(sizes, len(text))
+		}
 	}
 
-	toolResult := result.OfToolResult
-	if len(toolResult.Content) == 0 {
-		return result
+	c.mu.RLock()
+	budgets := c.toolResultBudgets(sizes)
+	c.mu.RUnlock()
+
+	truncated := make([]anthropic.ContentBlockParamUnion, len(blocks))
+	next := 0
+
+	for i, block := range blocks {
+		truncated[i] = block
+		if _, ok := toolResultText(block); ok {
+			truncated[i] = truncateToolResultTo(block, budgets[next])
+			next++
+		}
+	}
+
+	c.Append(anthropic.NewUserMessage(truncated...))
+}
+
+// toolResultBudgets returns the character limit for each of a turn's tool
+// results, given their sizes. Without a context limit every result gets
+// ToolResultMaxChars. Otherwise the turn's results share a budget of
+// turnBudgetResults × ToolResultMaxChars, capped at half the remaining
+// context window: results under their fair share keep their full size and
+// the rest split what is left, so a lone result gets far more room than
+// many parallel ones. No result is cut below minToolResultChars.
+// Caller must hold mu.
+func (c *Conversation) toolResultBudgets(sizes []int) []int {
+	budgets := make([]int, len(sizes))
+
+	if c.config.MaxContextTokens == 0 {
+		for i := range budgets {
+			budgets[i] = c.config.ToolResultMaxChars
+		}
+
+		return budgets
+	}
+
+	remaining := max(0, c.config.MaxContextTokens-c.totalInputTokens) * charsPerToken
+	total := min(c.config.ToolResultMaxChars*turnBudgetResults, remaining/2)
+
+	// Water-fill: hand out the budget from the smallest result up
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
 	}
 
-	// Extract text content
-	textContent := toolResult.Content[0]
-	if textContent.OfText == nil {
+	slices.SortFunc(order, func(a, b int) int { return sizes[a] - sizes[b] })
+
+	for n, i := range order {
+		share := total / (len(order) - n)
+		budgets[i] = max(min(sizes[i], share), minToolResultChars)
+		total = max(0, total-min(sizes[i], share))
+	}
+
+	return budgets
+}
+
+// toolResultText returns the text of a tool result block.
+func toolResultText(block anthropic.ContentBlockParamUnion) (string, bool) {
+	if block.OfToolResult == nil || len(block.OfToolResult.Content) == 0 ||
+		block.OfToolResult.Content[0].OfText == nil {
+		return "", false
+	}
+
+	return block.OfToolResult.Content[0].OfText.Text, true
+}
+
+// truncateToolResult checks if a tool result exceeds the character limit and truncates if needed.
+func (c *Conversation) truncateToolResult(result anthropic.ContentBlockParamUnion) anthropic.ContentBlockParamUnion {
+	return truncateToolResultTo(result, c.Config().ToolResultMaxChars)
+}
+
+// truncateToolResultTo truncates a tool result's text to limit characters.
+func truncateToolResultTo(result anthropic.ContentBlockParamUnion, limit int) anthropic.ContentBlockParamUnion {
+	text, ok := toolResultText(result)
+	if !ok {
 		return result
 	}
 
-	text := textContent.OfText.Text
+	toolResult := result.OfToolResult
 
 	// Check if truncation is needed
-	if len(text) > c.config.ToolResultMaxChars {
-		truncated := text[:c.config.ToolResultMaxChars]
+	if len(text) > limit {
+		truncated := text[:limit]
 		truncated += fmt.Sprintf("\n\n(Output truncated from %d to %d characters)",
-			len(text), c.config.ToolResultMaxChars)
+			len(text), limit)
 
 		// Create a new tool result with truncated text
 		newBlock := anthropic.NewToolResultBlock(
@@ -95,22 +293,44 @@ func (c *Conversation) truncateToolResult(result anthropic.ContentBlockParamUnio
 // UpdateTokenCount updates the token count from an API response.
 // This should be called after each API call with the response's InputTokens.
 func (c *Conversation) UpdateTokenCount(inputTokens int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	c.totalInputTokens = inputTokens
 }
 
 // Trim removes old messages if token count approaches the limit.
 // It preserves the system message (if present) and the most recent messages.
 // Trimming happens when totalInputTokens exceeds 75% of MaxContextTokens.
+// Old tool results are first compressed to local summaries; see TrimWith.
 func (c *Conversation) Trim() {
-	if c.config.MaxContextTokens == 0 {
-		return // No limit set
+	c.TrimWith(nil)
+}
+
+// TrimWith is Trim with summarize writing the summaries of compressed tool
+// results. Before any message is dropped, the bodies of tool results from
+// before the last two prompts are replaced with short summaries, which keeps
+// the narrative of the session while reclaiming most of its tokens.
+func (c *Conversation) TrimWith(summarize Summarizer) {
+	c.mu.RLock()
+	trimThreshold, over := c.trimThreshold()
+	c.mu.RUnlock()
+
+	if !over {
+		return
+	}
+
+	// Summaries may come from a model, so mu is only held once they are written
+	if c.compressToolResults(trimThreshold, summarize) {
+		return
 	}
 
-	// Calculate trim threshold (75% of max)
-	trimThreshold := (c.config.MaxContextTokens * 75) / 100
+	c.mu.Lock()
+	defer c.mu.Unlock()
 
-	if c.totalInputTokens <= trimThreshold {
-		return // Not yet at threshold
+	// The config may have changed while summarizing
+	if trimThreshold, over = c.trimThreshold(); !over {
+		return
 	}
 
 	// Keep system message (if present at index 0) and recent messages
@@ -125,6 +345,9 @@ func (c *Conversation) Trim() {
 
 	// Remove messages until we're below the trim threshold
 	// We'll do this greedily from the oldest (after system message)
+	// Trimmed results can no longer be referenced by later duplicates
+	clear(c.results)
+
 	for len(c.messages) > startIndex+2 && c.totalInputTokens > trimThreshold {
 		// Remove the oldest non-system message
 		c.messages = append(c.messages[:startIndex], c.messages[startIndex+1:]...)
@@ -134,30 +357,80 @@ func (c *Conversation) Trim() {
 	}
 }
 
+// trimThreshold returns the token count above which the conversation is
+// trimmed (75% of MaxContextTokens), and whether it is over it. Without a
+// limit it is never over. Caller must hold mu.
+func (c *Conversation) trimThreshold() (int, bool) {
+	if c.config.MaxContextTokens == 0 {
+		return 0, false // No limit set
+	}
+
+	threshold := (c.config.MaxContextTokens * TrimPercent) / 100
+
+	return threshold, c.totalInputTokens > threshold
+}
+
 // MessageCount returns the number of messages in the conversation.
 func (c *Conversation) MessageCount() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return len(c.messages)
 }
 
 // EstimatedTokens returns the estimated input tokens used by the conversation.
 // This is updated from actual API responses via UpdateTokenCount.
 func (c *Conversation) EstimatedTokens() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.totalInputTokens
 }
 
-// Messages returns the slice of messages for use with the Claude API.
+// Messages returns the messages for use with the Claude API. It is
+// equivalent to Snapshot.
 // Callers should ensure Trim() has been called before this if context
 // management is desired.
 func (c *Conversation) Messages() []anthropic.MessageParam {
-	return c.messages
+	return c.Snapshot()
+}
+
+// Snapshot returns a copy of the message history that is unaffected by
+// later appends or trimming, so an API request can be built from it while
+// other goroutines continue to modify the conversation.
+func (c *Conversation) Snapshot() []anthropic.MessageParam {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.messages)
 }
 
 // Len returns the number of messages in the conversation.
 func (c *Conversation) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return len(c.messages)
 }
 
 // Get returns the message at the specified index.
 func (c *Conversation) Get(index int) anthropic.MessageParam {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
 	return c.messages[index]
 }
+
+// MessageText returns the concatenated text blocks of a message, ignoring
+// tool use, tool results and other non-text content.
+func MessageText(message anthropic.MessageParam) string {
+	var parts []string
+
+	for _, block := range message.Content {
+		if block.OfText != nil && block.OfText.Text != "" {
+			parts = append(parts, block.OfText.Text)
+		}
+	}
+
+	return strings.Join(parts, "\n")
+}
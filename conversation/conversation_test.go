@@ -2,6 +2,7 @@ package conversation
 
 import (
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -109,6 +110,39 @@ func TestTrim_AboveThreshold(t *testing.T) {
 	}
 }
 
+func TestSetConfig_ConcurrentWithTrim(t *testing.T) {
+	t.Parallel()
+
+	c := NewWithConfig(Config{MaxContextTokens: 100, ToolResultMaxChars: 1000})
+
+	var wg sync.WaitGroup
+
+	for i := range 50 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			c.SetConfig(Config{MaxContextTokens: 100 + i, ToolResultMaxChars: 1000})
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("msg")))
+			c.UpdateTokenCount(90)
+			c.Trim()
+			_ = c.Config()
+		}()
+	}
+
+	wg.Wait()
+
+	if got := c.Config().MaxContextTokens; got < 100 {
+		t.Errorf("MaxContextTokens = %d, want one of the configs set", got)
+	}
+}
+
 func TestTruncateToolResult_LargeOutput(t *testing.T) {
 	t.Parallel()
 
@@ -225,3 +259,208 @@ func contains(s, substr string) bool {
 
 	return false
 }
+
+func TestMessageText(t *testing.T) {
+	t.Parallel()
+
+	msg := anthropic.NewUserMessage(
+		anthropic.NewTextBlock("first"),
+		anthropic.NewToolResultBlock("tool-1", "ignored", false),
+		anthropic.NewTextBlock("second"),
+	)
+
+	if got := MessageText(msg); got != "first\nsecond" {
+		t.Errorf("expected text blocks joined by newline, got %q", got)
+	}
+}
+
+func TestSnapshot_Isolated(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("first")))
+
+	snap := c.Snapshot()
+	c.Append(anthropic.NewAssistantMessage(anthropic.NewTextBlock("second")))
+
+	if len(snap) != 1 {
+		t.Errorf("snapshot should not see later appends, got %d messages", len(snap))
+	}
+
+	if c.Len() != 2 {
+		t.Errorf("expected 2 messages, got %d", c.Len())
+	}
+}
+
+func TestConcurrentAppendAndSnapshot(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Go(func() {
+			for range 100 {
+				c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("hello")))
+				_ = c.Snapshot()
+			}
+		})
+	}
+	wg.Wait()
+
+	if c.Len() != 1000 {
+		t.Errorf("expected 1000 messages, got %d", c.Len())
+	}
+}
+
+func TestConversation_Record(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+	if c.ID() == "" {
+		t.Fatal("new conversation should have an ID")
+	}
+
+	created := c.Record().UpdatedAt
+	c.SetTitle("Title")
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("hello")))
+
+	record := c.Record()
+	if record.Title != "Title" || len(record.Messages) != 1 || record.UpdatedAt.Before(created) {
+		t.Errorf("unexpected record: %+v", record)
+	}
+
+	restored := FromRecord(record, DefaultConfig())
+	if restored.ID() != c.ID() || restored.Title() != "Title" || restored.Len() != 1 {
+		t.Errorf("restored conversation does not match: %+v", restored.Record())
+	}
+}
+
+func TestToolResultBudgets(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		config      Config
+		inputTokens int
+		sizes       []int
+		want        []int
+	}{
+		{
+			name:   "no context limit uses fixed limit",
+			config: Config{ToolResultMaxChars: 100},
+			sizes:  []int{50, 5000},
+			want:   []int{100, 100},
+		},
+		{
+			name:   "lone result gets the whole turn budget",
+			config: Config{MaxContextTokens: 180_000, ToolResultMaxChars: 10_000},
+			sizes:  []int{100_000},
+			want:   []int{40_000},
+		},
+		{
+			name:   "parallel results share the budget",
+			config: Config{MaxContextTokens: 180_000, ToolResultMaxChars: 10_000},
+			sizes:  []int{100_000, 100_000, 100_000, 100_000, 100_000, 100_000, 100_000, 100_000, 100_000, 100_000},
+			want:   []int{4000, 4000, 4000, 4000, 4000, 4000, 4000, 4000, 4000, 4000},
+		},
+		{
+			name:   "small results leave room for large ones",
+			config: Config{MaxContextTokens: 180_000, ToolResultMaxChars: 10_000},
+			sizes:  []int{100_000, 2000, 100_000},
+			want:   []int{19_000, 2000, 19_000},
+		},
+		{
+			name:        "budget shrinks as the context fills",
+			config:      Config{MaxContextTokens: 10_000, ToolResultMaxChars: 10_000},
+			inputTokens: 8000,
+			sizes:       []int{100_000},
+			want:        []int{4000},
+		},
+		{
+			name:        "never below the minimum",
+			config:      Config{MaxContextTokens: 10_000, ToolResultMaxChars: 10_000},
+			inputTokens: 10_000,
+			sizes:       []int{100_000, 100_000},
+			want:        []int{minToolResultChars, minToolResultChars},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			c := NewWithConfig(tt.config)
+			c.UpdateTokenCount(tt.inputTokens)
+
+			got := c.toolResultBudgets(tt.sizes)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("budgets = %v, want %v", got, tt.want)
+
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestAppendToolResults(t *testing.T) {
+	t.Parallel()
+
+	c := NewWithConfig(Config{MaxContextTokens: 180_000, ToolResultMaxChars: 1000})
+
+	big := strings.Repeat("x", 10_000)
+	c.AppendToolResults(
+		anthropic.NewToolResultBlock("t1", big, false),
+		anthropic.NewToolResultBlock("t2", "small", false),
+		anthropic.NewTextBlock(big),
+	)
+
+	if c.Len() != 1 {
+		t.Fatalf("expected one message, got %d", c.Len())
+	}
+
+	content := c.Get(0).Content
+	if text := content[0].OfToolResult.Content[0].OfText.Text; !strings.Contains(text, "truncated") || len(text) > 4100 {
+		t.Errorf("expected large result truncated to about 4000 chars, got %d", len(text))
+	}
+
+	if text := content[1].OfToolResult.Content[0].OfText.Text; text != "small" {
+		t.Errorf("expected small result unchanged, got %q", text)
+	}
+
+	if content[2].OfText.Text != big {
+		t.Error("expected non-tool-result block unchanged")
+	}
+}
+
+func TestAppendToolResults_Dedup(t *testing.T) {
+	t.Parallel()
+
+	c := NewWithConfig(Config{ToolResultMaxChars: 100_000})
+	content := strings.Repeat("package main\n", 50)
+
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("read main.go")))
+	c.AppendToolResults(anthropic.NewToolResultBlock("t1", content, false))
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("read it again")))
+	c.AppendToolResults(
+		anthropic.NewToolResultBlock("t2", content, false),
+		anthropic.NewToolResultBlock("t3", "short", false),
+		anthropic.NewToolResultBlock("t4", "short", false),
+	)
+
+	first := c.Get(1).Content[0].OfToolResult.Content[0].OfText.Text
+	if first != content {
+		t.Error("expected the first occurrence to be kept")
+	}
+
+	later := c.Get(3).Content
+	if text := later[0].OfToolResult.Content[0].OfText.Text; !strings.Contains(text, "Identical to the result of tool call t1 in turn 1") {
+		t.Errorf("expected a reference to the earlier result, got %q", text)
+	}
+
+	if text := later[2].OfToolResult.Content[0].OfText.Text; text != "short" {
+		t.Errorf("expected short results to be kept, got %q", text)
+	}
+}
@@ -0,0 +1,73 @@
+package conversation
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// minDedupChars is the smallest tool result worth replacing with a reference.
+const minDedupChars = 200
+
+// dedupNote begins a result replaced by a reference to an earlier one, which
+// is followed by the earlier call's ID.
+const dedupNote = "Identical to the result of tool call "
+
+// resultRef locates an earlier tool result with the same content.
+type resultRef struct {
+	turn      int    // user prompt number the result followed, from 1
+	toolUseID string // tool call that produced it
+}
+
+// dedupeToolResults replaces tool results whose text is identical to an
+// earlier result still in context with a short reference to it, and
+// remembers the others. Error results and short outputs are left alone.
+func (c *Conversation) dedupeToolResults(blocks []anthropic.ContentBlockParamUnion) []anthropic.ContentBlockParamUnion {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results == nil {
+		c.results = make(map[[sha256.Size]byte]resultRef)
+	}
+
+	turn := c.promptCount()
+	deduped := make([]anthropic.ContentBlockParamUnion, len(blocks))
+
+	for i, block := range blocks {
+		deduped[i] = block
+
+		text, ok := toolResultText(block)
+		if !ok || len(text) < minDedupChars || block.OfToolResult.IsError.Value {
+			continue
+		}
+
+		key := sha256.Sum256([]byte(text))
+
+		ref, seen := c.results[key]
+		if !seen {
+			c.results[key] = resultRef{turn: turn, toolUseID: block.OfToolResult.ToolUseID}
+
+			continue
+		}
+
+		note := fmt.Sprintf(dedupNote+"%s in turn %d (%d characters); see that result.",
+			ref.toolUseID, ref.turn, len(text))
+		deduped[i] = anthropic.NewToolResultBlock(block.OfToolResult.ToolUseID, note, false)
+	}
+
+	return deduped
+}
+
+// promptCount returns the number of user prompts so far. Caller must hold mu.
+func (c *Conversation) promptCount() int {
+	n := 0
+
+	for _, m := range c.messages {
+		if IsPrompt(m) {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,37 @@
+package conversation
+
+import "slices"
+
+// AddNote appends a scratchpad note and returns its number, counted from 1.
+// Notes are kept apart from the messages, so trimming never drops them.
+func (c *Conversation) AddNote(text string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.notes = append(c.notes, text)
+
+	return len(c.notes)
+}
+
+// Notes returns a copy of the scratchpad notes in the order they were added.
+func (c *Conversation) Notes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return slices.Clone(c.notes)
+}
+
+// DeleteNote removes note n (counted from 1), renumbering the notes after
+// it. It reports whether the note existed.
+func (c *Conversation) DeleteNote(n int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if n < 1 || n > len(c.notes) {
+		return false
+	}
+
+	c.notes = slices.Delete(c.notes, n-1, n)
+
+	return true
+}
@@ -0,0 +1,83 @@
+package conversation
+
+import (
+	"slices"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// orphanResult is the synthetic result given to a tool call whose result was
+// lost, e.g. when artoo was interrupted while the tool ran.
+const orphanResult = "Tool result unavailable: the call was interrupted before it finished. " +
+	"Run it again if the result is still needed."
+
+// Repair gives every tool call in the conversation a result, so the next
+// request is accepted: the API rejects a tool_use block without a matching
+// tool_result in the following user message. Results that went missing are
+// replaced by an error result saying the call was interrupted. It returns
+// the number of results added.
+func (c *Conversation) Repair() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	repaired := 0
+
+	for i := 0; i < len(c.messages); i++ {
+		if c.messages[i].Role != anthropic.MessageParamRoleAssistant {
+			continue
+		}
+
+		calls := toolUseIDs(c.messages[i])
+		if len(calls) == 0 {
+			continue
+		}
+
+		// Results of the call must open the next message, which must be
+		// from the user
+		if i+1 == len(c.messages) || c.messages[i+1].Role != anthropic.MessageParamRoleUser {
+			c.messages = slices.Insert(c.messages, i+1, anthropic.MessageParam{Role: anthropic.MessageParamRoleUser})
+		}
+
+		next := &c.messages[i+1]
+
+		answered := make(map[string]bool, len(next.Content))
+		for _, block := range next.Content {
+			if block.OfToolResult != nil {
+				answered[block.OfToolResult.ToolUseID] = true
+			}
+		}
+
+		var missing []anthropic.ContentBlockParamUnion
+
+		for _, id := range calls {
+			if !answered[id] {
+				missing = append(missing, anthropic.NewToolResultBlock(id, orphanResult, true))
+			}
+		}
+
+		if len(missing) > 0 {
+			next.Content = append(missing, next.Content...)
+			repaired += len(missing)
+		}
+	}
+
+	if repaired > 0 {
+		c.updatedAt = time.Now()
+	}
+
+	return repaired
+}
+
+// toolUseIDs returns the IDs of the tool calls in a message.
+func toolUseIDs(message anthropic.MessageParam) []string {
+	var ids []string
+
+	for _, block := range message.Content {
+		if block.OfToolUse != nil {
+			ids = append(ids, block.OfToolUse.ID)
+		}
+	}
+
+	return ids
+}
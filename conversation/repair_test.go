@@ -0,0 +1,119 @@
+package conversation
+
+import (
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func toolCall(ids ...string) anthropic.MessageParam {
+	blocks := []anthropic.ContentBlockParamUnion{anthropic.NewTextBlock("Let me look.")}
+	for _, id := range ids {
+		blocks = append(blocks, anthropic.NewToolUseBlock(id, map[string]any{}, "grep"))
+	}
+
+	return anthropic.NewAssistantMessage(blocks...)
+}
+
+// resultIDs returns the tool result IDs of a message, in order.
+func resultIDs(message anthropic.MessageParam) []string {
+	var ids []string
+
+	for _, block := range message.Content {
+		if block.OfToolResult != nil {
+			ids = append(ids, block.OfToolResult.ToolUseID)
+		}
+	}
+
+	return ids
+}
+
+func TestRepair_CompleteConversationUnchanged(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("find it")))
+	c.Append(toolCall("a"))
+	c.AppendToolResults(anthropic.NewToolResultBlock("a", "found", false))
+
+	if n := c.Repair(); n != 0 || c.Len() != 3 {
+		t.Errorf("Repair() = %d with %d messages, want no change", n, c.Len())
+	}
+}
+
+func TestRepair_InterruptedBeforeResults(t *testing.T) {
+	t.Parallel()
+
+	// Cancelled while the tools ran, then the user sent a new prompt
+	c := New()
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("find it")))
+	c.Append(toolCall("a", "b"))
+	c.Append(anthropic.NewUserMessage(anthropic.NewTextBlock("never mind")))
+
+	if n := c.Repair(); n != 2 {
+		t.Fatalf("Repair() = %d, want 2", n)
+	}
+
+	next := c.Get(2)
+	if ids := resultIDs(next); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("results = %v, want [a b]", ids)
+	}
+
+	if !next.Content[0].OfToolResult.IsError.Value {
+		t.Error("synthetic results should be errors")
+	}
+
+	if last := next.Content[len(next.Content)-1]; last.OfText == nil || last.OfText.Text != "never mind" {
+		t.Error("the user's prompt should follow the results")
+	}
+
+	if n := c.Repair(); n != 0 {
+		t.Errorf("second Repair() = %d, want 0", n)
+	}
+}
+
+func TestRepair_PartialResults(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+	c.Append(toolCall("a", "b"))
+	c.AppendToolResults(anthropic.NewToolResultBlock("b", "done", false))
+
+	if n := c.Repair(); n != 1 {
+		t.Fatalf("Repair() = %d, want 1", n)
+	}
+
+	if ids := resultIDs(c.Get(1)); len(ids) != 2 || ids[0] != "a" || ids[1] != "b" {
+		t.Errorf("results = %v, want [a b]", ids)
+	}
+}
+
+func TestRepair_TrailingToolCall(t *testing.T) {
+	t.Parallel()
+
+	// Saved mid-turn by a crash: the conversation ends with the call
+	c := FromRecord(Record{Messages: []anthropic.MessageParam{
+		anthropic.NewUserMessage(anthropic.NewTextBlock("find it")),
+		toolCall("a"),
+	}}, DefaultConfig())
+
+	if n := c.Repair(); n != 1 || c.Len() != 3 {
+		t.Fatalf("Repair() = %d with %d messages, want 1 and 3", n, c.Len())
+	}
+
+	if last := c.Get(2); last.Role != anthropic.MessageParamRoleUser || len(resultIDs(last)) != 1 {
+		t.Errorf("expected a user message with the synthetic result, got %+v", last)
+	}
+}
+
+func TestRepair_ConsecutiveAssistantMessages(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+	c.Append(toolCall("a"))
+	c.Append(anthropic.NewAssistantMessage(anthropic.NewTextBlock("done")))
+
+	if n := c.Repair(); n != 1 || c.Len() != 3 || c.Get(1).Role != anthropic.MessageParamRoleUser {
+		t.Errorf("results should be inserted between the assistant messages, got %d with %d messages", n, c.Len())
+	}
+}
@@ -0,0 +1,99 @@
+package conversation
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// Sanitize fixes the message shapes the API rejects, which interrupted
+// turns, failed requests and trimming leave behind:
+//
+//   - text blocks that are empty or only whitespace are removed
+//   - tool results answering no tool call in the preceding assistant
+//     message, such as one whose call was trimmed, are removed
+//   - messages left without content are removed
+//   - the conversation starts with a user message
+//   - consecutive messages from the same role are merged, tool results first
+//
+// It returns the number of fixes made. Run it before Repair, which supplies
+// results for calls still unanswered.
+func (c *Conversation) Sanitize() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	messages, fixes := sanitize(c.messages)
+	if fixes > 0 {
+		c.messages = messages
+		c.updatedAt = time.Now()
+	}
+
+	return fixes
+}
+
+// sanitize returns messages with the fixes described on Sanitize applied,
+// and the number of fixes. messages is not modified.
+func sanitize(messages []anthropic.MessageParam) ([]anthropic.MessageParam, int) {
+	out := make([]anthropic.MessageParam, 0, len(messages))
+	fixes := 0
+
+	// Tool calls that user messages may answer: those of the last assistant message
+	var calls []string
+
+	for _, message := range messages {
+		content := make([]anthropic.ContentBlockParamUnion, 0, len(message.Content))
+
+		for _, block := range message.Content {
+			switch {
+			case block.OfText != nil && strings.TrimSpace(block.OfText.Text) == "":
+				fixes++
+			case block.OfToolResult != nil &&
+				(message.Role != anthropic.MessageParamRoleUser || !slices.Contains(calls, block.OfToolResult.ToolUseID)):
+				fixes++
+			default:
+				content = append(content, block)
+			}
+		}
+
+		if len(content) == 0 || len(out) == 0 && message.Role != anthropic.MessageParamRoleUser {
+			fixes++
+
+			continue
+		}
+
+		if last := len(out) - 1; last >= 0 && out[last].Role == message.Role {
+			out[last].Content = mergeContent(out[last].Content, content)
+			fixes++
+		} else {
+			message.Content = content
+			out = append(out, message)
+		}
+
+		if message.Role == anthropic.MessageParamRoleAssistant {
+			calls = toolUseIDs(out[len(out)-1])
+		}
+	}
+
+	return out, fixes
+}
+
+// mergeContent joins the content of two messages from the same role, moving
+// tool results to the front as the API requires.
+func mergeContent(first, second []anthropic.ContentBlockParamUnion) []anthropic.ContentBlockParamUnion {
+	merged := slices.Concat(first, second)
+
+	slices.SortStableFunc(merged, func(a, b anthropic.ContentBlockParamUnion) int {
+		switch {
+		case a.OfToolResult != nil && b.OfToolResult == nil:
+			return -1
+		case a.OfToolResult == nil && b.OfToolResult != nil:
+			return 1
+		}
+
+		return 0
+	})
+
+	return merged
+}
@@ -0,0 +1,180 @@
+package conversation
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func userText(text string) anthropic.MessageParam {
+	return anthropic.NewUserMessage(anthropic.NewTextBlock(text))
+}
+
+func assistantText(text string) anthropic.MessageParam {
+	return anthropic.NewAssistantMessage(anthropic.NewTextBlock(text))
+}
+
+// roles returns the roles of messages, e.g. "user assistant".
+func roles(messages []anthropic.MessageParam) string {
+	names := make([]string, 0, len(messages))
+	for _, m := range messages {
+		names = append(names, string(m.Role))
+	}
+
+	return strings.Join(names, " ")
+}
+
+func TestSanitize_ValidConversationUnchanged(t *testing.T) {
+	t.Parallel()
+
+	messages := []anthropic.MessageParam{
+		userText("find it"),
+		toolCall("a"),
+		anthropic.NewUserMessage(anthropic.NewToolResultBlock("a", "found", false)),
+		assistantText("It is in main.go."),
+	}
+
+	if out, fixes := sanitize(messages); fixes != 0 || len(out) != 4 {
+		t.Errorf("sanitize() = %d messages, %d fixes; want no change", len(out), fixes)
+	}
+}
+
+func TestSanitize_RetriedPromptAfterFailedRequest(t *testing.T) {
+	t.Parallel()
+
+	// The request failed, so the first prompt has no answer
+	messages := []anthropic.MessageParam{
+		userText("find it"),
+		assistantText("It is in main.go."),
+		userText("and the tests?"),
+		userText("and the tests?"),
+	}
+
+	out, fixes := sanitize(messages)
+	if fixes != 1 || roles(out) != "user assistant user" || len(out[2].Content) != 2 {
+		t.Errorf("consecutive prompts should merge, got %q with %d fixes", roles(out), fixes)
+	}
+}
+
+func TestSanitize_CancelledStream(t *testing.T) {
+	t.Parallel()
+
+	// Streaming was cancelled before any text arrived
+	messages := []anthropic.MessageParam{
+		userText("explain"),
+		assistantText(""),
+		userText("  \n"),
+		userText("explain briefly"),
+	}
+
+	out, _ := sanitize(messages)
+	if roles(out) != "user" || len(out[0].Content) != 2 {
+		t.Fatalf("expected one user message with both prompts, got %q", roles(out))
+	}
+
+	if out[0].Content[1].OfText.Text != "explain briefly" {
+		t.Errorf("unexpected content %+v", out[0].Content)
+	}
+}
+
+func TestSanitize_TrimmedToolCall(t *testing.T) {
+	t.Parallel()
+
+	// Trimming removed the assistant message holding the call, leaving its result
+	messages := []anthropic.MessageParam{
+		userText("refactor the parser"),
+		anthropic.NewUserMessage(
+			anthropic.NewToolResultBlock("gone", "old output", false),
+			anthropic.NewTextBlock("continue"),
+		),
+		assistantText("Done."),
+	}
+
+	out, _ := sanitize(messages)
+	if roles(out) != "user assistant" {
+		t.Fatalf("roles = %q", roles(out))
+	}
+
+	for _, block := range out[0].Content {
+		if block.OfToolResult != nil {
+			t.Error("the stray result should be removed")
+		}
+	}
+}
+
+func TestSanitize_StartsWithAssistant(t *testing.T) {
+	t.Parallel()
+
+	messages := []anthropic.MessageParam{
+		toolCall("a"),
+		anthropic.NewUserMessage(anthropic.NewToolResultBlock("a", "found", false)),
+		userText("what next?"),
+	}
+
+	out, _ := sanitize(messages)
+	if roles(out) != "user" || len(out[0].Content) != 1 || out[0].Content[0].OfText == nil {
+		t.Errorf("leading assistant message and its results should go, got %q %+v", roles(out), out)
+	}
+}
+
+func TestSanitize_MergedResultsFirst(t *testing.T) {
+	t.Parallel()
+
+	messages := []anthropic.MessageParam{
+		userText("find it"),
+		toolCall("a"),
+		userText("hurry"),
+		anthropic.NewUserMessage(anthropic.NewToolResultBlock("a", "found", false)),
+	}
+
+	out, _ := sanitize(messages)
+	if roles(out) != "user assistant user" || out[2].Content[0].OfToolResult == nil {
+		t.Errorf("tool results should lead the merged message, got %+v", out[2].Content)
+	}
+}
+
+func TestSanitize_DoesNotModifyInput(t *testing.T) {
+	t.Parallel()
+
+	messages := []anthropic.MessageParam{userText("a"), userText("b")}
+
+	sanitize(messages)
+
+	if len(messages[0].Content) != 1 {
+		t.Error("sanitize should not modify its input")
+	}
+}
+
+func TestConversationSanitize(t *testing.T) {
+	t.Parallel()
+
+	c := New()
+	c.Append(userText("a"))
+	c.Append(userText("b"))
+
+	if n := c.Sanitize(); n != 1 || c.Len() != 1 {
+		t.Errorf("Sanitize() = %d with %d messages", n, c.Len())
+	}
+
+	if n := c.Sanitize(); n != 0 {
+		t.Errorf("second Sanitize() = %d, want 0", n)
+	}
+}
+
+func BenchmarkSanitize(b *testing.B) {
+	// A long, already valid conversation: the common case before each request
+	messages := []anthropic.MessageParam{userText("start")}
+	for i := range 200 {
+		id := fmt.Sprintf("call%d", i)
+		messages = append(messages,
+			toolCall(id),
+			anthropic.NewUserMessage(anthropic.NewToolResultBlock(id, "output", false)),
+		)
+	}
+
+	for b.Loop() {
+		sanitize(messages)
+	}
+}
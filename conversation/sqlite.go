@@ -0,0 +1,285 @@
+package conversation
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+// sqliteTimeLayout is a fixed-width layout so stored times sort as text.
+const sqliteTimeLayout = "2006-01-02T15:04:05.000000000Z"
+
+// sqliteSchema creates the session table and a full-text index of message text.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	id         TEXT PRIMARY KEY,
+	title      TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	updated_at TEXT NOT NULL,
+	messages   BLOB NOT NULL,
+	notes      BLOB NOT NULL DEFAULT '[]'
+);
+CREATE VIRTUAL TABLE IF NOT EXISTS message_index USING fts5(
+	session_id UNINDEXED,
+	idx UNINDEXED,
+	role UNINDEXED,
+	text
+);`
+
+// SQLiteStore stores conversations in a SQLite database with an FTS5
+// full-text index over message text, so searches don't read every session.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+var _ Store = (*SQLiteStore)(nil)
+
+// NewSQLiteStore opens (creating if needed) the database at path.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening history database: %w", err)
+	}
+
+	// SQLite allows a single writer; serialize access through one connection
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(sqliteSchema); err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("creating history schema: %w", err)
+	}
+
+	if err := migrateSQLite(db); err != nil {
+		_ = db.Close()
+
+		return nil, fmt.Errorf("upgrading history schema: %w", err)
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// migrateSQLite adds columns introduced after a database was created.
+func migrateSQLite(db *sql.DB) error {
+	var hasNotes bool
+
+	err := db.QueryRow(`SELECT COUNT(*) > 0 FROM pragma_table_info('sessions') WHERE name = 'notes'`).Scan(&hasNotes)
+	if err != nil || hasNotes {
+		return err
+	}
+
+	_, err = db.Exec(`ALTER TABLE sessions ADD COLUMN notes BLOB NOT NULL DEFAULT '[]'`)
+
+	return err
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// Save upserts the session and re-indexes its messages in one transaction.
+func (s *SQLiteStore) Save(record Record) error {
+	if record.ID == "" {
+		return fmt.Errorf("%w: %q", errInvalidID, record.ID)
+	}
+
+	messages, err := json.Marshal(record.Messages)
+	if err != nil {
+		return fmt.Errorf("encoding conversation: %w", err)
+	}
+
+	notes, err := json.Marshal(record.Notes)
+	if err != nil {
+		return fmt.Errorf("encoding notes: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`INSERT INTO sessions (id, title, created_at, updated_at, messages, notes)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET title = excluded.title,
+			updated_at = excluded.updated_at, messages = excluded.messages, notes = excluded.notes`,
+		record.ID, record.Title, formatTime(record.CreatedAt), formatTime(record.UpdatedAt), messages, notes)
+	if err != nil {
+		return fmt.Errorf("saving conversation: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM message_index WHERE session_id = ?`, record.ID); err != nil {
+		return fmt.Errorf("indexing conversation: %w", err)
+	}
+
+	for i, message := range record.Messages {
+		text := MessageText(message)
+		if text == "" {
+			continue
+		}
+
+		_, err := tx.Exec(`INSERT INTO message_index (session_id, idx, role, text) VALUES (?, ?, ?, ?)`,
+			record.ID, i, string(message.Role), text)
+		if err != nil {
+			return fmt.Errorf("indexing conversation: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Load returns the session with the given ID.
+func (s *SQLiteStore) Load(id string) (Record, error) {
+	var record Record
+	var createdAt, updatedAt string
+	var messages, notes []byte
+
+	err := s.db.QueryRow(`SELECT id, title, created_at, updated_at, messages, notes FROM sessions WHERE id = ?`, id).
+		Scan(&record.ID, &record.Title, &createdAt, &updatedAt, &messages, &notes)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	if err != nil {
+		return Record{}, err
+	}
+
+	if err := json.Unmarshal(messages, &record.Messages); err != nil {
+		return Record{}, fmt.Errorf("decoding conversation %s: %w", id, err)
+	}
+
+	if err := json.Unmarshal(notes, &record.Notes); err != nil {
+		return Record{}, fmt.Errorf("decoding notes of %s: %w", id, err)
+	}
+
+	record.CreatedAt = parseTime(createdAt)
+	record.UpdatedAt = parseTime(updatedAt)
+
+	return record, nil
+}
+
+// List returns all sessions, most recently updated first.
+func (s *SQLiteStore) List() ([]Summary, error) {
+	rows, err := s.db.Query(`SELECT id, title, updated_at FROM sessions`)
+	if err != nil {
+		return nil, fmt.Errorf("listing conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var summaries []Summary
+
+	for rows.Next() {
+		var summary Summary
+		var updatedAt string
+
+		if err := rows.Scan(&summary.ID, &summary.Title, &updatedAt); err != nil {
+			return nil, err
+		}
+
+		summary.UpdatedAt = parseTime(updatedAt)
+		summaries = append(summaries, summary)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sortSummaries(summaries)
+
+	return summaries, nil
+}
+
+// Delete removes a session and its index entries.
+func (s *SQLiteStore) Delete(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.Exec(`DELETE FROM sessions WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM message_index WHERE session_id = ?`, id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Search queries the full-text index. Every term must appear in a message;
+// the final term also matches as a prefix.
+func (s *SQLiteStore) Search(query string) ([]Match, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	rows, err := s.db.Query(`SELECT m.session_id, s.title, s.updated_at, m.idx, m.role, m.text
+		FROM message_index m JOIN sessions s ON s.id = m.session_id
+		WHERE message_index MATCH ?
+		ORDER BY s.updated_at DESC, m.idx
+		LIMIT ?`, ftsQuery(terms), maxSearchResults)
+	if err != nil {
+		return nil, fmt.Errorf("searching conversations: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []Match
+
+	for rows.Next() {
+		var m Match
+		var updatedAt, text string
+
+		if err := rows.Scan(&m.ID, &m.Title, &updatedAt, &m.Index, &m.Role, &text); err != nil {
+			return nil, err
+		}
+
+		m.UpdatedAt = parseTime(updatedAt)
+
+		lower := strings.ToLower(text)
+		start := max(0, strings.Index(lower, terms[0]))
+		m.Snippet = snippet(text, start, len(terms[0]))
+
+		matches = append(matches, m)
+	}
+
+	return matches, rows.Err()
+}
+
+// ftsQuery quotes each term as an FTS5 string so punctuation in user input
+// isn't parsed as query syntax.
+func ftsQuery(terms []string) string {
+	quoted := make([]string, len(terms))
+	for i, term := range terms {
+		quoted[i] = `"` + strings.ReplaceAll(term, `"`, `""`) + `"`
+	}
+
+	quoted[len(quoted)-1] += "*"
+
+	return strings.Join(quoted, " ")
+}
+
+// formatTime stores times in a sortable text form.
+func formatTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// parseTime parses a time stored by formatTime, returning zero on error.
+func parseTime(s string) time.Time {
+	t, _ := time.Parse(sqliteTimeLayout, s)
+
+	return t
+}
@@ -0,0 +1,379 @@
+package conversation
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// maxSearchResults caps the number of matches a search returns.
+const maxSearchResults = 50
+
+// snippetRadius is the number of characters shown either side of a match.
+const snippetRadius = 60
+
+var (
+	// ErrNotFound is returned when a conversation ID is not in the store.
+	ErrNotFound = errors.New("conversation not found")
+
+	errInvalidID = errors.New("invalid conversation ID")
+)
+
+// Store persists conversations and searches across them.
+type Store interface {
+	// Save creates or replaces the stored conversation with record.ID.
+	Save(record Record) error
+
+	// Load returns the stored conversation with the given ID, or ErrNotFound.
+	Load(id string) (Record, error)
+
+	// List returns all stored conversations, most recently updated first.
+	List() ([]Summary, error)
+
+	// Delete removes the stored conversation with the given ID.
+	Delete(id string) error
+
+	// Search returns messages containing every whitespace-separated term
+	// of query (case-insensitive), most recently updated sessions first.
+	Search(query string) ([]Match, error)
+}
+
+// Summary describes a stored conversation without its messages.
+type Summary struct {
+	ID        string    `json:"id"`
+	Title     string    `json:"title"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Match is a message in a stored conversation that matched a search.
+type Match struct {
+	Summary
+
+	Index   int    // index of the message in the conversation
+	Role    string // "user" or "assistant"
+	Snippet string // text around the first matched term
+}
+
+// Branch returns a new conversation that copies record up to the end of the
+// turn containing message index, so it can be continued independently of
+// the original. The copy ends before the next user prompt, which keeps tool
+// calls paired with their results.
+func Branch(record Record, index int) Record {
+	now := time.Now()
+
+	end := len(record.Messages)
+	for i := index + 1; i < len(record.Messages); i++ {
+		if IsPrompt(record.Messages[i]) {
+			end = i
+
+			break
+		}
+	}
+
+	title := record.Title
+	if title != "" {
+		title = "Branch of " + title
+	}
+
+	return Record{
+		ID:        generateID(now),
+		Title:     title,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Messages:  slices.Clone(record.Messages[:end]),
+	}
+}
+
+// FeedbackPrefix starts the text of user messages the agent sends on its
+// own within a turn, such as a failed verification.
+const FeedbackPrefix = "[feedback] "
+
+// NewFeedbackMessage returns a user message carrying feedback text, which
+// belongs to the current turn rather than starting a new one.
+func NewFeedbackMessage(text string) anthropic.MessageParam {
+	return anthropic.NewUserMessage(anthropic.NewTextBlock(FeedbackPrefix + text))
+}
+
+// IsPrompt reports whether message is a user prompt rather than tool results
+// or feedback.
+func IsPrompt(message anthropic.MessageParam) bool {
+	if message.Role != anthropic.MessageParamRoleUser {
+		return false
+	}
+
+	for _, block := range message.Content {
+		if block.OfToolResult != nil {
+			return false
+		}
+
+		if block.OfText != nil && strings.HasPrefix(block.OfText.Text, FeedbackPrefix) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FileStore stores each conversation as a JSON file named {id}.json.
+type FileStore struct {
+	dir string
+}
+
+var _ Store = (*FileStore)(nil)
+
+// NewFileStore creates a FileStore in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating storage directory: %w", err)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+// Save writes record to {dir}/{id}.json atomically (temp file, then rename).
+func (s *FileStore) Save(record Record) error {
+	path, err := s.path(record.ID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding conversation: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(s.dir, record.ID+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("saving conversation: %w", err)
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("saving conversation: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return fmt.Errorf("saving conversation: %w", err)
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads {dir}/{id}.json.
+func (s *FileStore) Load(id string) (Record, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Record{}, err
+	}
+
+	data, err := os.ReadFile(path) //nolint:gosec // path built from a validated ID
+	if errors.Is(err, os.ErrNotExist) {
+		return Record{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+
+	if err != nil {
+		return Record{}, err
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return Record{}, fmt.Errorf("decoding conversation %s: %w", id, err)
+	}
+
+	return record, nil
+}
+
+// List reads the summary fields of every stored conversation.
+func (s *FileStore) List() ([]Summary, error) {
+	var summaries []Summary
+
+	err := s.each(func(data []byte) error {
+		var summary Summary
+		if err := json.Unmarshal(data, &summary); err != nil {
+			return nil //nolint:nilerr // skip unreadable files
+		}
+
+		summaries = append(summaries, summary)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sortSummaries(summaries)
+
+	return summaries, nil
+}
+
+// Delete removes {dir}/{id}.json.
+func (s *FileStore) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	} else if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Search scans every stored conversation for messages matching query.
+func (s *FileStore) Search(query string) ([]Match, error) {
+	terms := searchTerms(query)
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	var matches []Match
+
+	err := s.each(func(data []byte) error {
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return nil //nolint:nilerr // skip unreadable files
+		}
+
+		matches = append(matches, searchRecord(record, terms)...)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(matches, func(a, b Match) int {
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(a.Index, b.Index))
+	})
+
+	if len(matches) > maxSearchResults {
+		matches = matches[:maxSearchResults]
+	}
+
+	return matches, nil
+}
+
+// path returns the file for id, rejecting IDs that could escape the directory.
+func (s *FileStore) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.HasPrefix(id, ".") {
+		return "", fmt.Errorf("%w: %q", errInvalidID, id)
+	}
+
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// each calls fn with the contents of every conversation file.
+func (s *FileStore) each(fn func(data []byte) error) error {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return fmt.Errorf("listing conversations: %w", err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+
+		if err := fn(data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortSummaries orders summaries by most recent update first.
+func sortSummaries(summaries []Summary) {
+	slices.SortFunc(summaries, func(a, b Summary) int {
+		return cmp.Or(b.UpdatedAt.Compare(a.UpdatedAt), cmp.Compare(a.ID, b.ID))
+	})
+}
+
+// searchTerms splits query into lower-cased terms.
+func searchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// searchRecord returns the messages of record whose text contains every term.
+func searchRecord(record Record, terms []string) []Match {
+	var matches []Match
+
+	for i, message := range record.Messages {
+		text := MessageText(message)
+		lower := strings.ToLower(text)
+
+		if !containsAll(lower, terms) {
+			continue
+		}
+
+		matches = append(matches, Match{
+			Summary: Summary{ID: record.ID, Title: record.Title, UpdatedAt: record.UpdatedAt},
+			Index:   i,
+			Role:    string(message.Role),
+			Snippet: snippet(text, strings.Index(lower, terms[0]), len(terms[0])),
+		})
+	}
+
+	return matches
+}
+
+// containsAll reports whether s contains every term.
+func containsAll(s string, terms []string) bool {
+	for _, term := range terms {
+		if !strings.Contains(s, term) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// snippet returns the text around the match at byte offset start, on one line.
+func snippet(text string, start, length int) string {
+	// Offsets come from the lower-cased text, which may differ in length
+	start = min(start, len(text))
+
+	from := max(0, start-snippetRadius)
+	to := min(len(text), start+length+snippetRadius)
+
+	// Move to rune boundaries
+	for from > 0 && !utf8.RuneStart(text[from]) {
+		from--
+	}
+
+	for to < len(text) && !utf8.RuneStart(text[to]) {
+		to++
+	}
+
+	s := strings.Join(strings.Fields(text[from:to]), " ")
+	if from > 0 {
+		s = "…" + s
+	}
+
+	if to < len(text) {
+		s += "…"
+	}
+
+	return s
+}
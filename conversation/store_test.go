@@ -0,0 +1,365 @@
+package conversation
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// storeFactories creates each Store implementation in a temporary directory.
+var storeFactories = map[string]func(t *testing.T) Store{
+	"file": func(t *testing.T) Store {
+		t.Helper()
+
+		s, err := NewFileStore(filepath.Join(t.TempDir(), "conversations"))
+		if err != nil {
+			t.Fatalf("NewFileStore: %v", err)
+		}
+
+		return s
+	},
+	"sqlite": func(t *testing.T) Store {
+		t.Helper()
+
+		s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "history.db"))
+		if err != nil {
+			t.Fatalf("NewSQLiteStore: %v", err)
+		}
+
+		t.Cleanup(func() { _ = s.Close() })
+
+		return s
+	},
+}
+
+func testRecord(id, title string, updated time.Time, texts ...string) Record {
+	record := Record{ID: id, Title: title, CreatedAt: updated, UpdatedAt: updated}
+
+	for i, text := range texts {
+		if i%2 == 0 {
+			record.Messages = append(record.Messages, anthropic.NewUserMessage(anthropic.NewTextBlock(text)))
+		} else {
+			record.Messages = append(record.Messages, anthropic.NewAssistantMessage(anthropic.NewTextBlock(text)))
+		}
+	}
+
+	return record
+}
+
+func TestStore_SaveAndLoad(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newStore(t)
+			now := time.Now().UTC().Truncate(time.Second)
+			record := testRecord("a", "Debugging grep", now, "why does grep fail?", "It is missing a flag.")
+
+			if err := s.Save(record); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			// Saving again replaces the stored conversation
+			record.Messages = append(record.Messages, anthropic.NewUserMessage(anthropic.NewTextBlock("thanks")))
+			record.Notes = []string{"grep is wrapped by GrepTool in tool/grep.go"}
+			if err := s.Save(record); err != nil {
+				t.Fatalf("resave: %v", err)
+			}
+
+			got, err := s.Load("a")
+			if err != nil {
+				t.Fatalf("load: %v", err)
+			}
+
+			if got.Title != "Debugging grep" || !got.UpdatedAt.Equal(now) || len(got.Messages) != 3 {
+				t.Errorf("unexpected record: %+v", got)
+			}
+
+			if text := MessageText(got.Messages[1]); text != "It is missing a flag." {
+				t.Errorf("unexpected message text %q", text)
+			}
+
+			if len(got.Notes) != 1 || got.Notes[0] != record.Notes[0] {
+				t.Errorf("unexpected notes %q", got.Notes)
+			}
+		})
+	}
+}
+
+func TestSQLiteStore_MigratesNotes(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "history.db")
+
+	// A database created before notes were stored
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	_, err = db.Exec(`CREATE TABLE sessions (id TEXT PRIMARY KEY, title TEXT NOT NULL,
+		created_at TEXT NOT NULL, updated_at TEXT NOT NULL, messages BLOB NOT NULL);
+		INSERT INTO sessions VALUES ('old', 'Old', '', '', '[]')`)
+	_ = db.Close()
+
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		t.Fatalf("NewSQLiteStore: %v", err)
+	}
+	defer s.Close()
+
+	record, err := s.Load("old")
+	if err != nil || len(record.Notes) != 0 {
+		t.Fatalf("load: %+v, %v", record, err)
+	}
+
+	record.Notes = []string{"kept"}
+	if err := s.Save(record); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	if got, err := s.Load("old"); err != nil || len(got.Notes) != 1 {
+		t.Errorf("expected notes after migration, got %+v, %v", got, err)
+	}
+}
+
+func TestStore_LoadMissing(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := newStore(t).Load("missing"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStore_List(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newStore(t)
+			base := time.Now().UTC()
+
+			for i, id := range []string{"old", "new", "mid"} {
+				offsets := []time.Duration{0, 2 * time.Hour, time.Hour}
+				if err := s.Save(testRecord(id, id, base.Add(offsets[i]), "hi")); err != nil {
+					t.Fatalf("save: %v", err)
+				}
+			}
+
+			summaries, err := s.List()
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+
+			var ids []string
+			for _, summary := range summaries {
+				ids = append(ids, summary.ID)
+			}
+
+			if strings.Join(ids, ",") != "new,mid,old" {
+				t.Errorf("expected most recent first, got %v", ids)
+			}
+		})
+	}
+}
+
+func TestStore_Delete(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newStore(t)
+			if err := s.Save(testRecord("a", "", time.Now(), "auth bug")); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+
+			if err := s.Delete("a"); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+
+			if _, err := s.Load("a"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound after delete, got %v", err)
+			}
+
+			if matches, _ := s.Search("auth"); len(matches) != 0 {
+				t.Errorf("expected deleted session to be unsearchable, got %v", matches)
+			}
+
+			if err := s.Delete("a"); !errors.Is(err, ErrNotFound) {
+				t.Errorf("expected ErrNotFound deleting twice, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStore_Search(t *testing.T) {
+	t.Parallel()
+
+	for name, newStore := range storeFactories {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newStore(t)
+			now := time.Now().UTC()
+
+			records := []Record{
+				testRecord("auth", "Auth", now, "Please fix the Auth bug in login", "Fixed the auth token refresh."),
+				testRecord("other", "Other", now.Add(-time.Hour), "Rename the package", "Done."),
+			}
+			for _, r := range records {
+				if err := s.Save(r); err != nil {
+					t.Fatalf("save: %v", err)
+				}
+			}
+
+			matches, err := s.Search("auth bug")
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+
+			if len(matches) != 1 || matches[0].ID != "auth" || matches[0].Index != 0 || matches[0].Role != "user" {
+				t.Fatalf("unexpected matches: %+v", matches)
+			}
+
+			if !strings.Contains(matches[0].Snippet, "Auth bug") {
+				t.Errorf("expected snippet around match, got %q", matches[0].Snippet)
+			}
+
+			if matches, _ := s.Search(`"unbalanced`); len(matches) != 0 {
+				t.Errorf("expected no matches, got %v", matches)
+			}
+		})
+	}
+}
+
+func TestFileStore_AtomicWrite(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+
+	s, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	if err := s.Save(testRecord("a", "", time.Now(), "hi")); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("readdir: %v", err)
+	}
+
+	if len(entries) != 1 || entries[0].Name() != "a.json" {
+		t.Errorf("expected only a.json (no temp files), got %v", entries)
+	}
+}
+
+func TestFileStore_RejectsPathIDs(t *testing.T) {
+	t.Parallel()
+
+	s, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStore: %v", err)
+	}
+
+	for _, id := range []string{"", "../escape", "a/b", ".hidden"} {
+		if err := s.Save(Record{ID: id}); !errors.Is(err, errInvalidID) {
+			t.Errorf("expected invalid ID error for %q, got %v", id, err)
+		}
+	}
+}
+
+func TestGenerateID(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 2, 28, 14, 30, 52, 0, time.UTC)
+	id := generateID(now)
+
+	if !regexp.MustCompile(`^20260228-143052-[0-9a-f]{4}$`).MatchString(id) {
+		t.Errorf("unexpected ID format %q", id)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	t.Parallel()
+
+	text := strings.Repeat("a ", 50) + "needle" + strings.Repeat(" b", 50)
+	got := snippet(text, strings.Index(text, "needle"), len("needle"))
+
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") || !strings.Contains(got, "needle") {
+		t.Errorf("unexpected snippet %q", got)
+	}
+}
+
+func TestIsPrompt(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		message anthropic.MessageParam
+		want    bool
+	}{
+		{"prompt", anthropic.NewUserMessage(anthropic.NewTextBlock("fix auth")), true},
+		{"tool results", anthropic.NewUserMessage(anthropic.NewToolResultBlock("t1", "ok", false)), false},
+		{"feedback", NewFeedbackMessage("The verification command failed"), false},
+		{"assistant", anthropic.NewAssistantMessage(anthropic.NewTextBlock("done")), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsPrompt(tt.message); got != tt.want {
+			t.Errorf("%s: IsPrompt = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBranch(t *testing.T) {
+	t.Parallel()
+
+	record := testRecord("a", "Auth", time.Now(), "fix auth", "looking")
+	record.Messages = append(record.Messages,
+		anthropic.NewUserMessage(anthropic.NewToolResultBlock("t1", "ok", false)),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock("fixed")),
+		anthropic.NewUserMessage(anthropic.NewTextBlock("now add tests")),
+		anthropic.NewAssistantMessage(anthropic.NewTextBlock("added")),
+	)
+
+	branch := Branch(record, 0)
+
+	if branch.ID == record.ID || branch.Title != "Branch of Auth" {
+		t.Errorf("expected a new, retitled conversation, got %q %q", branch.ID, branch.Title)
+	}
+
+	// The turn includes the tool result and final reply, but not the next prompt
+	if len(branch.Messages) != 4 {
+		t.Errorf("expected 4 messages, got %d", len(branch.Messages))
+	}
+
+	if full := Branch(record, 4); len(full.Messages) != 6 {
+		t.Errorf("expected branch from last turn to copy everything, got %d", len(full.Messages))
+	}
+}
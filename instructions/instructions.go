@@ -0,0 +1,167 @@
+// Package instructions discovers per-directory instruction files such as
+// AGENTS.md and CLAUDE.md and merges them into model context.
+package instructions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// FileNames are the recognised instruction file names. When a directory
+// contains several, they are included in this order.
+var FileNames = []string{"AGENTS.md", "CLAUDE.md"}
+
+// File is an instruction file and the directory it applies to.
+type File struct {
+	Dir     string // absolute directory the instructions apply to
+	Path    string // absolute path of the instruction file
+	Content string
+}
+
+// Set tracks instruction files for a workspace. Files in the workspace root
+// and its ancestors are loaded up front; files in subdirectories are loaded
+// lazily the first time a path inside them is touched. It is safe for
+// concurrent use.
+type Set struct {
+	mu      sync.Mutex
+	root    string
+	base    []File
+	checked map[string]bool // directories already searched for instruction files
+}
+
+// Discover loads the instruction files in root and every ancestor of root.
+func Discover(root string) (*Set, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path: %w", err)
+	}
+
+	s := &Set{root: absRoot}
+	s.Reset()
+
+	for _, dir := range ancestors(absRoot) {
+		s.base = append(s.base, readDir(dir)...)
+	}
+
+	return s, nil
+}
+
+// ancestors returns dir and each directory above it, outermost first.
+func ancestors(dir string) []string {
+	// Collect from dir upwards, then reverse so ancestors come first
+	var dirs []string
+	for ; ; dir = filepath.Dir(dir) {
+		dirs = append(dirs, dir)
+
+		if filepath.Dir(dir) == dir {
+			break
+		}
+	}
+
+	slices.Reverse(dirs)
+
+	return dirs
+}
+
+// Reset forgets which subdirectories have been searched, so Touch returns
+// their files again. Call it when the context they were added to is gone,
+// such as when a new conversation starts or old messages are trimmed.
+func (s *Set) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checked = make(map[string]bool)
+	for _, dir := range ancestors(s.root) {
+		s.checked[dir] = true
+	}
+}
+
+// Base returns the instruction files that apply to the whole workspace.
+func (s *Set) Base() []File {
+	return slices.Clone(s.base)
+}
+
+// Touch returns instruction files from directories between the workspace
+// root and path that have not been returned before. path may be a file or
+// directory, absolute or relative to the root. Paths outside the root
+// return nothing.
+func (s *Set) Touch(path string) []File {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.root, path)
+	}
+
+	path = filepath.Clean(path)
+
+	rel, err := filepath.Rel(s.root, path)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return nil
+	}
+
+	if info, err := os.Stat(path); err != nil || !info.IsDir() {
+		rel = filepath.Dir(rel)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var files []File
+
+	dir := s.root
+	for part := range strings.SplitSeq(rel, string(filepath.Separator)) {
+		if part == "." || part == "" {
+			continue
+		}
+
+		dir = filepath.Join(dir, part)
+		if s.checked[dir] {
+			continue
+		}
+
+		s.checked[dir] = true
+		files = append(files, readDir(dir)...)
+	}
+
+	return files
+}
+
+// Render merges instruction files into a single context section. Files are
+// listed from the most general directory to the most specific, and the
+// header states that more specific instructions take precedence.
+func Render(files []File) string {
+	if len(files) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString("The following instruction files apply to this workspace. " +
+		"Instructions for a more specific directory take precedence over those for its parents.\n")
+
+	for _, f := range files {
+		fmt.Fprintf(&b, "\n<instructions path=%q applies_to=%q>\n%s\n</instructions>\n",
+			f.Path, f.Dir+string(filepath.Separator), strings.TrimSpace(f.Content))
+	}
+
+	return b.String()
+}
+
+// readDir reads the instruction files present in dir, in FileNames order.
+func readDir(dir string) []File {
+	var files []File
+
+	for _, name := range FileNames {
+		path := filepath.Join(dir, name)
+
+		data, err := os.ReadFile(path) //nolint:gosec // fixed file names in workspace directories
+		if err != nil || len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+
+		files = append(files, File{Dir: dir, Path: path, Content: string(data)})
+	}
+
+	return files
+}
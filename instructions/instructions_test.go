@@ -0,0 +1,144 @@
+package instructions
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupTree creates root/AGENTS.md, root/CLAUDE.md and nested instruction files.
+func setupTree(t *testing.T) string {
+	t.Helper()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"AGENTS.md":                 "root agents",
+		"CLAUDE.md":                 "root claude",
+		"services/api/AGENTS.md":    "api rules",
+		"services/api/handler.go":   "package api",
+		"services/web/CLAUDE.md":    "web rules",
+		"services/web/index.ts":     "",
+		"services/empty/AGENTS.md":  "   \n",
+		"services/empty/README.txt": "",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+
+	return root
+}
+
+func TestDiscover_Base(t *testing.T) {
+	t.Parallel()
+
+	root := setupTree(t)
+
+	set, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	base := set.Base()
+	if len(base) < 2 {
+		t.Fatalf("expected at least the root AGENTS.md and CLAUDE.md, got %d files", len(base))
+	}
+
+	// Root files come last (most specific) and AGENTS.md precedes CLAUDE.md
+	last := base[len(base)-2:]
+	if last[0].Content != "root agents" || last[1].Content != "root claude" {
+		t.Errorf("unexpected base order: %+v", last)
+	}
+
+	for _, f := range base {
+		if strings.Contains(f.Path, "services") {
+			t.Errorf("nested file %s should not be in base set", f.Path)
+		}
+	}
+}
+
+func TestSet_Touch(t *testing.T) {
+	t.Parallel()
+
+	root := setupTree(t)
+
+	set, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	files := set.Touch("services/api/handler.go")
+	if len(files) != 1 || files[0].Content != "api rules" {
+		t.Fatalf("expected api AGENTS.md when touching handler.go, got %+v", files)
+	}
+
+	// Each file is only returned the first time its directory is touched
+	if files := set.Touch(filepath.Join(root, "services", "api")); len(files) != 0 {
+		t.Errorf("expected no files on second touch, got %+v", files)
+	}
+
+	if files := set.Touch(filepath.Join(root, "services", "web", "index.ts")); len(files) != 1 {
+		t.Errorf("expected web CLAUDE.md, got %+v", files)
+	}
+
+	// Blank instruction files and paths outside the root are ignored
+	if files := set.Touch("services/empty/README.txt"); len(files) != 0 {
+		t.Errorf("expected blank AGENTS.md to be skipped, got %+v", files)
+	}
+
+	if files := set.Touch(filepath.Dir(root)); len(files) != 0 {
+		t.Errorf("expected nothing for a path outside the root, got %+v", files)
+	}
+}
+
+func TestSet_Reset(t *testing.T) {
+	t.Parallel()
+
+	root := setupTree(t)
+
+	set, err := Discover(root)
+	if err != nil {
+		t.Fatalf("Discover failed: %v", err)
+	}
+
+	set.Touch("services/api/handler.go")
+	set.Reset()
+
+	// Files are returned again after a reset, but base files never are
+	if files := set.Touch("services/api/handler.go"); len(files) != 1 || files[0].Content != "api rules" {
+		t.Errorf("expected api AGENTS.md again after Reset, got %+v", files)
+	}
+
+	if files := set.Touch("README.md"); len(files) != 0 {
+		t.Errorf("base files should not be touched, got %+v", files)
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	if Render(nil) != "" {
+		t.Error("rendering no files should produce an empty string")
+	}
+
+	out := Render([]File{
+		{Dir: "/ws", Path: "/ws/AGENTS.md", Content: "GENERAL_RULE\n"},
+		{Dir: "/ws/api", Path: "/ws/api/AGENTS.md", Content: "SPECIFIC_RULE"},
+	})
+
+	if !strings.Contains(out, "take precedence") {
+		t.Error("rendered output should state the precedence rule")
+	}
+
+	if strings.Index(out, "GENERAL_RULE") > strings.Index(out, "SPECIFIC_RULE") {
+		t.Error("general instructions should precede more specific ones")
+	}
+}
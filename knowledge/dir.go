@@ -0,0 +1,179 @@
+package knowledge
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// maxSlugLen bounds the file name derived from an entry's title.
+const maxSlugLen = 60
+
+// DirStore keeps each entry as a Markdown file with a small front matter
+// block, so entries can be reviewed and committed like any other file.
+type DirStore struct {
+	dir string
+	now func() time.Time
+}
+
+// NewDirStore returns a store keeping entries in dir, which is created when
+// the first entry is added.
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir, now: time.Now}
+}
+
+// Location implements Store.
+func (s *DirStore) Location() string {
+	return s.dir
+}
+
+// Add implements Store. The entry's ID is its file name without extension.
+func (s *DirStore) Add(e Entry) (Entry, error) {
+	e, err := validate(e, s.now())
+	if err != nil {
+		return e, err
+	}
+
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		return e, err
+	}
+
+	slug := slugify(e.Title)
+
+	for n := 1; ; n++ {
+		e.ID = slug
+		if n > 1 {
+			e.ID = fmt.Sprintf("%s-%d", slug, n)
+		}
+
+		f, err := os.OpenFile(filepath.Join(s.dir, e.ID+".md"), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644) //nolint:gosec // shared with the team
+		if errors.Is(err, os.ErrExist) {
+			continue
+		}
+
+		if err != nil {
+			return e, err
+		}
+
+		_, err = f.WriteString(formatEntry(e))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+
+		return e, err
+	}
+}
+
+// Search implements Store.
+func (s *DirStore) Search(query string, limit int) ([]Entry, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.md"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(paths))
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path) //nolint:gosec // entries in the knowledge directory
+		if err != nil {
+			return nil, err
+		}
+
+		e := parseEntry(string(data))
+		e.ID = strings.TrimSuffix(filepath.Base(path), ".md")
+
+		entries = append(entries, e)
+	}
+
+	return rank(entries, query, limit), nil
+}
+
+// formatEntry renders an entry as front matter followed by its body.
+func formatEntry(e Entry) string {
+	var b strings.Builder
+
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "title: %s\n", oneLine(e.Title))
+
+	if len(e.Tags) > 0 {
+		fmt.Fprintf(&b, "tags: %s\n", strings.Join(e.Tags, ", "))
+	}
+
+	if e.Author != "" {
+		fmt.Fprintf(&b, "author: %s\n", oneLine(e.Author))
+	}
+
+	fmt.Fprintf(&b, "created: %s\n", e.Created.Format(time.RFC3339))
+	b.WriteString("---\n\n")
+	b.WriteString(e.Body)
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// parseEntry reads a file written by formatEntry, or edited by hand. A file
+// without front matter is all body, titled by its first line.
+func parseEntry(text string) Entry {
+	var e Entry
+
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		e.Body = strings.TrimSpace(text)
+		e.Title, _, _ = strings.Cut(e.Body, "\n")
+		e.Title = strings.TrimLeft(e.Title, "# ")
+
+		return e
+	}
+
+	header, body, _ := strings.Cut(rest, "\n---")
+	e.Body = strings.TrimSpace(body)
+
+	scanner := bufio.NewScanner(strings.NewReader(header))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+
+		switch strings.TrimSpace(key) {
+		case "title":
+			e.Title = value
+		case "tags":
+			for tag := range strings.SplitSeq(value, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					e.Tags = append(e.Tags, strings.ToLower(tag))
+				}
+			}
+		case "author":
+			e.Author = value
+		case "created":
+			e.Created, _ = time.Parse(time.RFC3339, value)
+		}
+	}
+
+	return e
+}
+
+// slugify derives a file name from a title.
+func slugify(title string) string {
+	slug := strings.Join(terms(title), "-")
+	if len(slug) > maxSlugLen {
+		slug = strings.TrimRight(slug[:maxSlugLen], "-")
+	}
+
+	if slug == "" {
+		slug = "entry"
+	}
+
+	return slug
+}
+
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
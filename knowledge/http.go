@@ -0,0 +1,103 @@
+package knowledge
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// httpTimeout bounds one request to the knowledge service.
+const httpTimeout = 15 * time.Second
+
+// HTTPStore is a knowledge service reached over HTTP. The service answers
+//
+//	GET  <base>/entries?q=<query>&limit=<n>  with a JSON array of entries, best first
+//	POST <base>/entries                      with the stored entry, given a JSON entry
+type HTTPStore struct {
+	base   string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPStore returns a store using the service at base, sending token, if
+// set, as a bearer token.
+func NewHTTPStore(base, token string) *HTTPStore {
+	return &HTTPStore{
+		base:   strings.TrimSuffix(base, "/"),
+		token:  token,
+		client: &http.Client{Timeout: httpTimeout},
+	}
+}
+
+// Location implements Store.
+func (s *HTTPStore) Location() string {
+	return s.base
+}
+
+// Add implements Store.
+func (s *HTTPStore) Add(e Entry) (Entry, error) {
+	e, err := validate(e, time.Now())
+	if err != nil {
+		return e, err
+	}
+
+	body, err := json.Marshal(e)
+	if err != nil {
+		return e, err
+	}
+
+	var stored Entry
+
+	err = s.do(http.MethodPost, s.base+"/entries", bytes.NewReader(body), &stored)
+
+	return stored, err
+}
+
+// Search implements Store.
+func (s *HTTPStore) Search(query string, limit int) ([]Entry, error) {
+	q := url.Values{"q": {query}, "limit": {strconv.Itoa(limit)}}
+
+	var entries []Entry
+
+	err := s.do(http.MethodGet, s.base+"/entries?"+q.Encode(), nil, &entries)
+
+	return entries, err
+}
+
+// do sends a request and decodes the JSON answer into out.
+func (s *HTTPStore) do(method, target string, body io.Reader, out any) error {
+	req, err := http.NewRequest(method, target, body) //nolint:noctx // bounded by the client timeout
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+
+		return fmt.Errorf("%w: %s: %s", ErrService, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
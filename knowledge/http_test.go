@@ -0,0 +1,58 @@
+package knowledge
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHTTPStore(t *testing.T) {
+	t.Parallel()
+
+	var stored []Entry
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+
+			return
+		}
+
+		switch r.Method {
+		case http.MethodPost:
+			var e Entry
+			if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+
+			e.ID = "42"
+			stored = append(stored, e)
+
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(e)
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(rank(stored, r.URL.Query().Get("q"), 5))
+		}
+	}))
+	defer srv.Close()
+
+	s := Open(srv.URL+"/", "secret")
+
+	e, err := s.Add(Entry{Title: "Proxy settings", Body: "Set HTTPS_PROXY for the CI runners."})
+	if err != nil || e.ID != "42" {
+		t.Fatalf("Add = %+v, %v", e, err)
+	}
+
+	results, err := s.Search("proxy", 5)
+	if err != nil || len(results) != 1 || results[0].Title != "Proxy settings" {
+		t.Errorf("Search = %+v, %v", results, err)
+	}
+
+	if _, err := NewHTTPStore(srv.URL, "wrong").Search("proxy", 5); !errors.Is(err, ErrService) {
+		t.Errorf("expected ErrService for a rejected token, got %v", err)
+	}
+}
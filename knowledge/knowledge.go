@@ -0,0 +1,147 @@
+// Package knowledge stores curated facts and past solutions that a team's
+// sessions share: in a directory of Markdown files tracked in the project's
+// repository, or on a small HTTP service.
+package knowledge
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// DefaultDir is the knowledge directory used when none is configured, if it
+// exists, relative to the workspace root.
+const DefaultDir = ".artoo/knowledge"
+
+var (
+	// ErrEmptyEntry is returned when adding an entry without a title or body.
+	ErrEmptyEntry = errors.New("an entry needs a title and a body")
+
+	// ErrService is returned when the knowledge service answers with an error.
+	ErrService = errors.New("knowledge service error")
+)
+
+// Entry is one piece of shared knowledge.
+type Entry struct {
+	ID      string    `json:"id,omitempty"` // assigned by the store
+	Title   string    `json:"title"`
+	Body    string    `json:"body"`
+	Tags    []string  `json:"tags,omitempty"`
+	Author  string    `json:"author,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Store is a shared knowledge base.
+type Store interface {
+	// Add stores a new entry and returns it with its ID set.
+	Add(e Entry) (Entry, error)
+
+	// Search returns up to limit entries relevant to query, best first.
+	Search(query string, limit int) ([]Entry, error)
+
+	// Location describes where the entries are kept, for display.
+	Location() string
+}
+
+// Open returns the store at location: an HTTP service if location is an
+// http or https URL, otherwise a directory. token, if set, is sent to an
+// HTTP service as a bearer token.
+func Open(location, token string) Store {
+	if strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://") {
+		return NewHTTPStore(location, token)
+	}
+
+	return NewDirStore(location)
+}
+
+// validate checks and normalizes an entry before it is stored.
+func validate(e Entry, now time.Time) (Entry, error) {
+	e.Title = strings.TrimSpace(e.Title)
+	e.Body = strings.TrimSpace(e.Body)
+
+	if e.Title == "" || e.Body == "" {
+		return e, ErrEmptyEntry
+	}
+
+	if e.Created.IsZero() {
+		e.Created = now
+	}
+
+	tags := make([]string, 0, len(e.Tags))
+
+	for _, tag := range e.Tags {
+		if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	e.Tags = tags
+
+	return e, nil
+}
+
+// rank returns the entries matching query, best first. Title matches weigh
+// the most, then tags, then the body; entries matching no query word are
+// dropped.
+func rank(entries []Entry, query string, limit int) []Entry {
+	words := terms(query)
+	if len(words) == 0 {
+		return nil
+	}
+
+	type scored struct {
+		entry Entry
+		score int
+	}
+
+	var matches []scored
+
+	for _, e := range entries {
+		title, body := terms(e.Title), terms(e.Body)
+		score := 0
+
+		for _, w := range words {
+			score += 3*count(title, w) + 2*count(e.Tags, w) + min(count(body, w), 5)
+		}
+
+		if score > 0 {
+			matches = append(matches, scored{e, score})
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		if a.score != b.score {
+			return b.score - a.score
+		}
+
+		return b.entry.Created.Compare(a.entry.Created)
+	})
+
+	results := make([]Entry, 0, min(len(matches), limit))
+	for _, m := range matches[:min(len(matches), limit)] {
+		results = append(results, m.entry)
+	}
+
+	return results
+}
+
+// terms splits text into lower-case words.
+func terms(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+func count(words []string, w string) int {
+	n := 0
+
+	for _, word := range words {
+		if word == w {
+			n++
+		}
+	}
+
+	return n
+}
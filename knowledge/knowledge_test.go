@@ -0,0 +1,112 @@
+package knowledge
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T) *DirStore {
+	t.Helper()
+
+	s := NewDirStore(filepath.Join(t.TempDir(), "knowledge"))
+	s.now = func() time.Time { return time.Date(2026, 10, 14, 9, 0, 0, 0, time.UTC) }
+
+	return s
+}
+
+func TestDirStore_AddAndSearch(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+
+	e, err := s.Add(Entry{
+		Title:  "Flaky TestUpload is a clock skew issue",
+		Body:   "The upload test compares server and local time; run it with TZ=UTC.",
+		Tags:   []string{"Tests", "upload", "tests"},
+		Author: "sam",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if e.ID != "flaky-testupload-is-a-clock-skew-issue" || strings.Join(e.Tags, ",") != "tests,upload" {
+		t.Errorf("unexpected entry %+v", e)
+	}
+
+	if _, err := s.Add(Entry{Title: "Cache keys", Body: "The build cache key ignores mtime, so touch does not rebuild."}); err != nil {
+		t.Fatal(err)
+	}
+
+	dup, err := s.Add(Entry{Title: "Cache keys", Body: "Second entry with the same title."})
+	if err != nil || dup.ID != "cache-keys-2" {
+		t.Errorf("a title collision should get a numbered ID, got %q, %v", dup.ID, err)
+	}
+
+	results, err := s.Search("why is the upload test flaky", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) == 0 || results[0].ID != e.ID {
+		t.Fatalf("expected the upload entry first, got %+v", results)
+	}
+
+	got := results[0]
+	if got.Title != e.Title || got.Body != e.Body || got.Author != "sam" || !got.Created.Equal(e.Created) {
+		t.Errorf("entry did not round-trip: %+v", got)
+	}
+
+	if results, _ := s.Search("kubernetes", 5); len(results) != 0 {
+		t.Errorf("unrelated query should match nothing, got %+v", results)
+	}
+
+	if _, err := s.Add(Entry{Title: "no body"}); !errors.Is(err, ErrEmptyEntry) {
+		t.Errorf("expected ErrEmptyEntry, got %v", err)
+	}
+}
+
+func TestDirStore_HandWrittenEntry(t *testing.T) {
+	t.Parallel()
+
+	s := newTestStore(t)
+	if err := os.MkdirAll(s.dir, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "# Deploying to staging\n\nRun make deploy ENV=staging from the repo root.\n"
+	if err := os.WriteFile(filepath.Join(s.dir, "staging.md"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := s.Search("staging deploy", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 || results[0].Title != "Deploying to staging" || results[0].ID != "staging" {
+		t.Errorf("hand-written entry should be found, titled by its heading, got %+v", results)
+	}
+}
+
+func TestRank(t *testing.T) {
+	t.Parallel()
+
+	entries := []Entry{
+		{ID: "body", Title: "Other", Body: "mentions retries once"},
+		{ID: "title", Title: "Retries in the client", Body: "details"},
+		{ID: "tag", Title: "Backoff", Body: "details", Tags: []string{"retries"}},
+	}
+
+	var ids []string
+	for _, e := range rank(entries, "retries", 2) {
+		ids = append(ids, e.ID)
+	}
+
+	if strings.Join(ids, ",") != "title,tag" {
+		t.Errorf("expected title then tag matches, limited to 2, got %v", ids)
+	}
+}
@@ -0,0 +1,96 @@
+package stats
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	barWidth   = 30
+	chartDays  = 14
+	barFull    = "█"
+	labelWidth = 16
+)
+
+// Render formats statistics as text with bar charts: totals, tool usage with
+// error rates, and tokens per day over the last two weeks ending at now.
+func Render(st Stats, now time.Time) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Sessions: %d   Input tokens: %d   Output tokens: %d\n",
+		st.Sessions, st.InputTokens, st.OutputTokens)
+
+	b.WriteString("\nTool usage\n")
+
+	if len(st.Tools) == 0 {
+		b.WriteString("  (no tool calls recorded)\n")
+	}
+
+	names := make([]string, 0, len(st.Tools))
+	maxCalls := 0
+
+	for name, t := range st.Tools {
+		names = append(names, name)
+		maxCalls = max(maxCalls, t.Calls)
+	}
+
+	slices.SortFunc(names, func(x, y string) int {
+		return cmp.Or(cmp.Compare(st.Tools[y].Calls, st.Tools[x].Calls), cmp.Compare(x, y))
+	})
+
+	for _, name := range names {
+		t := st.Tools[name]
+		fmt.Fprintf(&b, "  %-*s %-*s %5d calls  %5.1f%% errors\n",
+			labelWidth, truncate(name, labelWidth), barWidth, bar(t.Calls, maxCalls),
+			t.Calls, 100*t.ErrorRate())
+	}
+
+	fmt.Fprintf(&b, "\nTokens per day (last %d days)\n", chartDays)
+
+	var maxTokens int64
+
+	days := make([]string, chartDays)
+	for i := range chartDays {
+		days[i] = now.AddDate(0, 0, i-chartDays+1).Format(dayFormat)
+		if d, ok := st.Days[days[i]]; ok {
+			maxTokens = max(maxTokens, d.Tokens)
+		}
+	}
+
+	for _, day := range days {
+		var d DayStats
+		if p, ok := st.Days[day]; ok {
+			d = *p
+		}
+
+		fmt.Fprintf(&b, "  %-*s %-*s %8d tokens  %3d sessions\n",
+			labelWidth, day, barWidth, bar(d.Tokens, maxTokens), d.Tokens, d.Sessions)
+	}
+
+	return b.String()
+}
+
+// bar returns a bar proportional to value/maxValue, at least one cell for
+// any non-zero value.
+func bar[T int | int64](value, maxValue T) string {
+	if value <= 0 || maxValue <= 0 {
+		return ""
+	}
+
+	cells := max(1, int(int64(value)*barWidth/int64(maxValue)))
+
+	return strings.Repeat(barFull, cells)
+}
+
+// truncate shortens s to n runes.
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+
+	return string(r[:n])
+}
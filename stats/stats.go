@@ -0,0 +1,170 @@
+// Package stats keeps opt-in local usage statistics (sessions, tokens and
+// tool usage) in a JSON file. Nothing is ever sent anywhere.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// dayFormat keys daily statistics.
+const dayFormat = time.DateOnly
+
+// Stats is the persisted usage data.
+type Stats struct {
+	Sessions     int                   `json:"sessions"`
+	InputTokens  int64                 `json:"input_tokens"`
+	OutputTokens int64                 `json:"output_tokens"`
+	Tools        map[string]*ToolStats `json:"tools"`
+	Days         map[string]*DayStats  `json:"days"`
+}
+
+// ToolStats counts calls to one tool.
+type ToolStats struct {
+	Calls  int `json:"calls"`
+	Errors int `json:"errors"`
+}
+
+// ErrorRate returns the fraction of calls that failed.
+func (t ToolStats) ErrorRate() float64 {
+	if t.Calls == 0 {
+		return 0
+	}
+
+	return float64(t.Errors) / float64(t.Calls)
+}
+
+// DayStats is the usage for one calendar day.
+type DayStats struct {
+	Sessions int   `json:"sessions"`
+	Tokens   int64 `json:"tokens"`
+}
+
+// Store records usage to a stats file. It is safe for concurrent use.
+type Store struct {
+	mu   sync.Mutex
+	path string
+	data Stats
+	now  func() time.Time
+}
+
+// Open loads the stats file at path, starting empty if it doesn't exist.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, now: time.Now}
+
+	data, err := os.ReadFile(path) //nolint:gosec // stats file path from config
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return nil, fmt.Errorf("reading stats: %w", err)
+	default:
+		if err := json.Unmarshal(data, &s.data); err != nil {
+			return nil, fmt.Errorf("parsing stats %s: %w", path, err)
+		}
+	}
+
+	if s.data.Tools == nil {
+		s.data.Tools = make(map[string]*ToolStats)
+	}
+
+	if s.data.Days == nil {
+		s.data.Days = make(map[string]*DayStats)
+	}
+
+	return s, nil
+}
+
+// RecordSession counts the start of a session.
+func (s *Store) RecordSession() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.Sessions++
+	s.today().Sessions++
+}
+
+// RecordUsage adds the tokens used by one API response.
+func (s *Store) RecordUsage(inputTokens, outputTokens int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.data.InputTokens += inputTokens
+	s.data.OutputTokens += outputTokens
+	s.today().Tokens += inputTokens + outputTokens
+}
+
+// RecordTool counts a tool call and whether it failed.
+func (s *Store) RecordTool(name string, isError bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.data.Tools[name]
+	if !ok {
+		t = &ToolStats{}
+		s.data.Tools[name] = t
+	}
+
+	t.Calls++
+	if isError {
+		t.Errors++
+	}
+}
+
+// Snapshot returns a copy of the current statistics.
+func (s *Store) Snapshot() Stats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snap := s.data
+	snap.Tools = make(map[string]*ToolStats, len(s.data.Tools))
+	for name, t := range s.data.Tools {
+		snap.Tools[name] = new(*t)
+	}
+
+	snap.Days = make(map[string]*DayStats, len(s.data.Days))
+	for day, d := range s.data.Days {
+		snap.Days[day] = new(*d)
+	}
+
+	return snap
+}
+
+// Save writes the statistics to the stats file, replacing it atomically.
+func (s *Store) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s.data, "", "  ")
+	s.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o750); err != nil {
+		return fmt.Errorf("creating stats directory: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("writing stats: %w", err)
+	}
+
+	return os.Rename(tmp, s.path)
+}
+
+// today returns the stats for the current day. Caller must hold mu.
+func (s *Store) today() *DayStats {
+	key := s.now().Format(dayFormat)
+
+	d, ok := s.data.Days[key]
+	if !ok {
+		d = &DayStats{}
+		s.data.Days[key] = d
+	}
+
+	return d
+}
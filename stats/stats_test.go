@@ -0,0 +1,103 @@
+package stats
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_RecordAndSave(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "nested", "stats.json")
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	day := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return day }
+
+	s.RecordSession()
+	s.RecordUsage(100, 20)
+	s.RecordTool("grep", false)
+	s.RecordTool("grep", true)
+
+	if err := s.Save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+
+	st := reopened.Snapshot()
+	if st.Sessions != 1 || st.InputTokens != 100 || st.OutputTokens != 20 {
+		t.Errorf("unexpected totals: %+v", st)
+	}
+
+	grep := st.Tools["grep"]
+	if grep == nil || grep.Calls != 2 || grep.Errors != 1 || grep.ErrorRate() != 0.5 {
+		t.Errorf("unexpected grep stats: %+v", grep)
+	}
+
+	d := st.Days["2026-03-04"]
+	if d == nil || d.Sessions != 1 || d.Tokens != 120 {
+		t.Errorf("unexpected day stats: %+v", d)
+	}
+}
+
+func TestStore_SnapshotIsCopy(t *testing.T) {
+	t.Parallel()
+
+	s, err := Open(filepath.Join(t.TempDir(), "stats.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+
+	s.RecordTool("ls", false)
+	snap := s.Snapshot()
+	s.RecordTool("ls", false)
+
+	if snap.Tools["ls"].Calls != 1 {
+		t.Errorf("snapshot changed after recording: %d calls", snap.Tools["ls"].Calls)
+	}
+}
+
+func TestRender(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	st := Stats{
+		Sessions: 2,
+		Tools: map[string]*ToolStats{
+			"grep": {Calls: 10, Errors: 1},
+			"ls":   {Calls: 5},
+		},
+		Days: map[string]*DayStats{
+			"2026-03-04": {Sessions: 2, Tokens: 500},
+			"2026-01-01": {Sessions: 9, Tokens: 9000}, // outside the chart window
+		},
+	}
+
+	out := Render(st, now)
+
+	if strings.Index(out, "grep") > strings.Index(out, "ls ") {
+		t.Errorf("expected tools sorted by calls:\n%s", out)
+	}
+
+	if !strings.Contains(out, "10.0% errors") {
+		t.Errorf("expected grep error rate:\n%s", out)
+	}
+
+	if strings.Contains(out, "2026-01-01") {
+		t.Errorf("expected old days to be excluded:\n%s", out)
+	}
+
+	if !strings.Contains(out, strings.Repeat(barFull, barWidth)+" ") {
+		t.Errorf("expected a full bar for the maximum:\n%s", out)
+	}
+}
@@ -0,0 +1,314 @@
+package tool
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ReadOnly is implemented by tools that never modify the filesystem or other
+// external state. Calling a tool that is not read-only invalidates cached results.
+type ReadOnly interface {
+	ReadOnly() bool
+}
+
+// Idempotent is implemented by read-only tools whose result depends only on
+// their input and the filesystem, so repeated calls can be served from cache.
+type Idempotent interface {
+	Idempotent() bool
+}
+
+// FileEditor is implemented by tools whose only side effect is editing files
+// in the workspace. Under the auto-edit autonomy level they run without
+// asking the user, while other tools that are not read-only need approval.
+type FileEditor interface {
+	EditsFiles() bool
+}
+
+// ApprovalRequired is implemented by tools that ask the user before every
+// call at any autonomy level, such as running commands inside containers.
+type ApprovalRequired interface {
+	RequiresApproval() bool
+}
+
+// IsReadOnly reports whether t declares itself read-only.
+func IsReadOnly(t Tool) bool {
+	r, ok := t.(ReadOnly)
+
+	return ok && r.ReadOnly()
+}
+
+// IsFileEditor reports whether t declares itself a file editor.
+func IsFileEditor(t Tool) bool {
+	e, ok := t.(FileEditor)
+
+	return ok && e.EditsFiles()
+}
+
+// RequiresApproval reports whether every call of t needs the user's approval.
+func RequiresApproval(t Tool) bool {
+	r, ok := t.(ApprovalRequired)
+
+	return ok && r.RequiresApproval()
+}
+
+// IsIdempotent reports whether t's results may be cached.
+func IsIdempotent(t Tool) bool {
+	i, ok := t.(Idempotent)
+
+	return ok && i.Idempotent() && IsReadOnly(t)
+}
+
+// ReadOnly implements ReadOnly by delegating to the typed tool.
+func (w *toolWrapper[P]) ReadOnly() bool {
+	r, ok := w.typed.(ReadOnly)
+
+	return ok && r.ReadOnly()
+}
+
+// EditsFiles implements FileEditor by delegating to the typed tool.
+func (w *toolWrapper[P]) EditsFiles() bool {
+	e, ok := w.typed.(FileEditor)
+
+	return ok && e.EditsFiles()
+}
+
+// RequiresApproval implements ApprovalRequired by delegating to the typed tool.
+func (w *toolWrapper[P]) RequiresApproval() bool {
+	r, ok := w.typed.(ApprovalRequired)
+
+	return ok && r.RequiresApproval()
+}
+
+// Idempotent implements Idempotent by delegating to the typed tool.
+func (w *toolWrapper[P]) Idempotent() bool {
+	i, ok := w.typed.(Idempotent)
+
+	return ok && i.Idempotent()
+}
+
+// cacheMaxFiles bounds the files walked to fingerprint a directory; calls on
+// larger trees are not cached.
+const cacheMaxFiles = 10_000
+
+// cacheEntry is a cached successful tool output.
+type cacheEntry struct {
+	output  string
+	expires time.Time
+}
+
+// ResultCache caches outputs of idempotent tools keyed by tool name,
+// canonicalized input, the settings of the calling agent's Environment that
+// change results and the size and modification time of the paths in the
+// input, or of every file under them for directories. It is safe for concurrent use and may be shared by several agents
+// in one session so fan-out exploration reuses results.
+//
+// Any write (a call to a tool that is not read-only) invalidates the whole
+// cache. A generation counter ensures that a read racing with a write never
+// stores a result computed against the pre-write filesystem.
+type ResultCache struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	entries    map[string]cacheEntry
+	generation uint64
+	now        func() time.Time
+}
+
+// NewResultCache creates a cache whose entries expire after ttl.
+func NewResultCache(ttl time.Duration) *ResultCache {
+	return &ResultCache{
+		ttl:     ttl,
+		entries: make(map[string]cacheEntry),
+		now:     time.Now,
+	}
+}
+
+// Get returns the cached output for a call made with env, if present and not
+// expired. The returned generation must be passed to Put when storing a fresh
+// result.
+func (c *ResultCache) Get(env *Environment, name string, input []byte) (string, uint64, bool) {
+	key, cacheable := cacheKey(env, name, input)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !cacheable {
+		return "", c.generation, false
+	}
+
+	entry, ok := c.entries[key]
+	if ok && c.now().After(entry.expires) {
+		delete(c.entries, key)
+
+		ok = false
+	}
+
+	return entry.output, c.generation, ok
+}
+
+// Put stores output for a call unless the cache was invalidated since
+// generation was obtained from Get.
+func (c *ResultCache) Put(env *Environment, name string, input []byte, output string, generation uint64) {
+	key, cacheable := cacheKey(env, name, input)
+	if !cacheable {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	c.entries[key] = cacheEntry{
+		output:  output,
+		expires: c.now().Add(c.ttl),
+	}
+}
+
+// Invalidate drops all entries. It is called before and after every write.
+func (c *ResultCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	clear(c.entries)
+}
+
+// Len returns the number of cached entries.
+func (c *ResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// cacheKey hashes the tool name with a canonical form of the input so that
+// key order and whitespace differences don't cause misses. The default search
+// root and whether secrets are allowed, which change what a call returns,
+// are hashed too, as is the state of the paths the input names. It reports
+// false if one of those paths is a directory too large to fingerprint.
+func cacheKey(env *Environment, name string, input []byte) (string, bool) {
+	if env == nil {
+		env = defaultEnvironment
+	}
+
+	canonical := input
+
+	var v any
+	if err := json.Unmarshal(input, &v); err == nil {
+		if b, err := json.Marshal(v); err == nil {
+			canonical = b
+		}
+	}
+
+	h := sha256.New()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write(canonical)
+	h.Write([]byte{0})
+
+	cacheable := fingerprintPath(h, env.defaultSearchPath())
+	h.Write([]byte(strconv.FormatBool(env.SecretsAllowed())))
+
+	for _, path := range inputPaths(v) {
+		cacheable = fingerprintPath(h, path) && cacheable
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), cacheable
+}
+
+// inputPaths returns the paths a tool input names in its path or paths
+// parameter.
+func inputPaths(input any) []string {
+	params, _ := input.(map[string]any)
+
+	var paths []string
+	if path, ok := params["path"].(string); ok {
+		paths = append(paths, path)
+	}
+
+	list, _ := params["paths"].([]any)
+	for _, p := range list {
+		if path, ok := p.(string); ok {
+			paths = append(paths, path)
+		}
+	}
+
+	return paths
+}
+
+// fingerprintPath writes what path resolves to and its size and modification
+// time, so a file changed by anything other than a tool misses the cache. For
+// a directory it writes those of every file under it instead, skipping
+// version control internals, and reports false if there are more than
+// cacheMaxFiles.
+func fingerprintPath(h hash.Hash, path string) bool {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		resolved = path
+	}
+
+	fmt.Fprintf(h, "%s\x00", resolved)
+
+	info, err := os.Stat(resolved)
+
+	switch {
+	case err != nil:
+		h.Write([]byte("missing\x00"))
+	case info.IsDir():
+		return fingerprintDir(h, resolved)
+	default:
+		fmt.Fprintf(h, "%d %d\x00", info.Size(), info.ModTime().UnixNano())
+	}
+
+	return true
+}
+
+// fingerprintDir writes the path, size and modification time of every entry
+// under dir, reporting false if there are more than cacheMaxFiles.
+func fingerprintDir(h hash.Hash, dir string) bool {
+	files, complete := 0, true
+
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// An unreadable entry is fingerprinted as such
+			fmt.Fprintf(h, "%s unreadable\x00", path)
+
+			return nil
+		}
+
+		if d.IsDir() && path != dir && slices.Contains(snapshotSkipDirs, d.Name()) {
+			return filepath.SkipDir
+		}
+
+		if files++; files > cacheMaxFiles {
+			complete = false
+
+			return filepath.SkipAll
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			fmt.Fprintf(h, "%s missing\x00", path)
+
+			return nil
+		}
+
+		fmt.Fprintf(h, "%s %d %d\x00", path, info.Size(), info.ModTime().UnixNano())
+
+		return nil
+	})
+
+	return complete
+}
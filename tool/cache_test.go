@@ -0,0 +1,154 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResultCache_CanonicalKey(t *testing.T) {
+	t.Parallel()
+
+	c := NewResultCache(time.Minute)
+	_, gen, _ := c.Get(nil, "grep", []byte(`{"pattern":"foo","path":"."}`))
+	c.Put(nil, "grep", []byte(`{"pattern":"foo","path":"."}`), "result", gen)
+
+	// Same arguments with different key order and whitespace hit the cache
+	output, _, ok := c.Get(nil, "grep", []byte(`{ "path": ".", "pattern": "foo" }`))
+	if !ok || output != "result" {
+		t.Errorf("expected cache hit with canonicalized input, got %q, %v", output, ok)
+	}
+
+	// Same input for a different tool misses
+	if _, _, ok := c.Get(nil, "list", []byte(`{"pattern":"foo","path":"."}`)); ok {
+		t.Error("expected cache miss for a different tool name")
+	}
+}
+
+func TestResultCache_Expiry(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	c := NewResultCache(time.Minute)
+	c.now = func() time.Time { return now }
+
+	_, gen, _ := c.Get(nil, "list", []byte(`{}`))
+	c.Put(nil, "list", []byte(`{}`), "tree", gen)
+
+	now = now.Add(2 * time.Minute)
+
+	if _, _, ok := c.Get(nil, "list", []byte(`{}`)); ok {
+		t.Error("expected expired entry to miss")
+	}
+
+	if c.Len() != 0 {
+		t.Errorf("expected expired entry to be evicted, got %d entries", c.Len())
+	}
+}
+
+func TestResultCache_InvalidateRejectsStalePut(t *testing.T) {
+	t.Parallel()
+
+	c := NewResultCache(time.Minute)
+
+	// A read starts, then a write invalidates the cache before the read stores its result
+	_, gen, _ := c.Get(nil, "grep", []byte(`{"pattern":"x"}`))
+	c.Invalidate()
+	c.Put(nil, "grep", []byte(`{"pattern":"x"}`), "stale", gen)
+
+	if _, _, ok := c.Get(nil, "grep", []byte(`{"pattern":"x"}`)); ok {
+		t.Error("result computed before a write should not be cached")
+	}
+}
+
+func TestResultCache_KeyedOnRootAndFiles(t *testing.T) {
+	t.Parallel()
+
+	c := NewResultCache(time.Minute)
+	env := NewEnvironment()
+	root := t.TempDir()
+	env.SetDefaultRoot(root)
+
+	list := []byte(`{}`)
+	_, gen, _ := c.Get(env, "list", list)
+	c.Put(env, "list", list, "tree", gen)
+
+	if _, _, ok := c.Get(env, "list", list); !ok {
+		t.Fatal("expected a hit for the same root")
+	}
+
+	// Path-less calls after the default root changes, as /project does, miss
+	env.SetDefaultRoot(t.TempDir())
+
+	if _, _, ok := c.Get(env, "list", list); ok {
+		t.Error("expected a miss after the default root changed")
+	}
+
+	if _, _, ok := c.Get(NewEnvironment(), "list", list); ok {
+		t.Error("expected a miss for an environment with another root")
+	}
+
+	path := filepath.Join(root, "main.go")
+	writeTestFile(t, path, "package main\n")
+
+	read := []byte(`{"paths": ["` + path + `"]}`)
+	_, gen, _ = c.Get(env, "read_many", read)
+	c.Put(env, "read_many", read, "package main", gen)
+
+	// A file changed outside the tools, which do not invalidate the cache
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, ok := c.Get(env, "read_many", read); ok {
+		t.Error("expected a miss after the file was modified")
+	}
+
+	// Editing a file inside a searched directory leaves the directory's own
+	// modification time alone, but still misses
+	grep := []byte(`{"pattern": "main", "path": "` + root + `"}`)
+	_, gen, _ = c.Get(env, "grep", grep)
+	c.Put(env, "grep", grep, "main.go:1", gen)
+
+	if _, _, ok := c.Get(env, "grep", grep); !ok {
+		t.Fatal("expected a hit for an unchanged directory")
+	}
+
+	writeTestFile(t, path, "package other\n")
+
+	if _, _, ok := c.Get(env, "grep", grep); ok {
+		t.Error("expected a miss after a file in the directory was modified")
+	}
+}
+
+func TestIsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		tool           Tool
+		wantReadOnly   bool
+		wantIdempotent bool
+	}{
+		{"grep", WrapTypedTool(&GrepTool{}), true, true},
+		{"list", WrapTypedTool(&LsTool{}), true, true},
+		{"random numbers are not cacheable", WrapTypedTool(&RandomNumberTool{}), true, false},
+		{"plugins may write", &PluginTool{}, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := IsReadOnly(tt.tool); got != tt.wantReadOnly {
+				t.Errorf("IsReadOnly = %v, want %v", got, tt.wantReadOnly)
+			}
+
+			if got := IsIdempotent(tt.tool); got != tt.wantIdempotent {
+				t.Errorf("IsIdempotent = %v, want %v", got, tt.wantIdempotent)
+			}
+		})
+	}
+}
@@ -0,0 +1,723 @@
+package tool
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// dateLayout is the layout of dates without a time of day.
+const dateLayout = "2006-01-02"
+
+var (
+	// ErrEmptyExpression is returned when calculate is called without an expression.
+	ErrEmptyExpression = errors.New("expression is required")
+
+	// ErrUnsupportedExpression is returned for syntax calculate does not evaluate.
+	ErrUnsupportedExpression = errors.New("unsupported expression")
+
+	// ErrUnknownFunction is returned when an expression calls an undefined function.
+	ErrUnknownFunction = errors.New("unknown function")
+
+	// ErrBadArguments is returned when a function or operator gets arguments of the wrong number or type.
+	ErrBadArguments = errors.New("bad arguments")
+
+	// ErrDivisionByZero is returned when an expression divides by zero.
+	ErrDivisionByZero = errors.New("division by zero")
+)
+
+// CalculateParams defines the parameters for the calculate tool.
+type CalculateParams struct {
+	Expression string `json:"expression"`
+}
+
+// Ensure CalculateTool implements TypedTool[CalculateParams].
+var _ TypedTool[CalculateParams] = (*CalculateTool)(nil)
+
+// CalculateTool evaluates arithmetic, date and list expressions in-process.
+// Expressions use Go syntax but are only evaluated, never compiled or run,
+// so there is nothing to sandbox: the only operations are the functions below.
+type CalculateTool struct {
+	now func() time.Time // clock for now() and today(); time.Now if nil
+}
+
+// Values are float64, string, bool, time.Time, time.Duration or []any.
+type calcFunc func(args []any) (any, error)
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *CalculateTool) Call(params CalculateParams) (string, error) {
+	if strings.TrimSpace(params.Expression) == "" {
+		return "", ErrEmptyExpression
+	}
+
+	expr, err := parser.ParseExpr(params.Expression)
+	if err != nil {
+		return "", fmt.Errorf("parsing expression: %w", err)
+	}
+
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+
+	v, err := (&calculator{funcs: calcFuncs(now)}).eval(expr)
+	if err != nil {
+		return "", err
+	}
+
+	return formatValue(v), nil
+}
+
+// calculator evaluates a parsed expression.
+type calculator struct {
+	funcs map[string]calcFunc
+}
+
+func (c *calculator) eval(expr ast.Expr) (any, error) {
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		return evalLiteral(e)
+	case *ast.Ident:
+		return evalIdent(e.Name)
+	case *ast.ParenExpr:
+		return c.eval(e.X)
+	case *ast.UnaryExpr:
+		x, err := c.eval(e.X)
+		if err != nil {
+			return nil, err
+		}
+
+		return unaryOp(e.Op, x)
+	case *ast.BinaryExpr:
+		x, err := c.eval(e.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := c.eval(e.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return binaryOp(e.Op, x, y)
+	case *ast.CallExpr:
+		return c.call(e)
+	}
+
+	return nil, fmt.Errorf("%w: %T", ErrUnsupportedExpression, expr)
+}
+
+func (c *calculator) call(e *ast.CallExpr) (any, error) {
+	name, ok := e.Fun.(*ast.Ident)
+	if !ok {
+		return nil, fmt.Errorf("%w: only calls of named functions are allowed", ErrUnsupportedExpression)
+	}
+
+	fn, ok := c.funcs[name.Name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFunction, name.Name)
+	}
+
+	args := make([]any, len(e.Args))
+	for i, arg := range e.Args {
+		v, err := c.eval(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = v
+	}
+
+	v, err := fn(args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name.Name, err)
+	}
+
+	return v, nil
+}
+
+func evalLiteral(lit *ast.BasicLit) (any, error) {
+	switch lit.Kind {
+	case token.INT:
+		n, err := strconv.ParseInt(lit.Value, 0, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedExpression, lit.Value)
+		}
+
+		return float64(n), nil
+	case token.FLOAT:
+		return strconv.ParseFloat(lit.Value, 64)
+	case token.STRING:
+		return strconv.Unquote(lit.Value)
+	}
+
+	return nil, fmt.Errorf("%w: literal %s", ErrUnsupportedExpression, lit.Value)
+}
+
+func evalIdent(name string) (any, error) {
+	switch name {
+	case "pi":
+		return math.Pi, nil
+	case "e":
+		return math.E, nil
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown name %s", ErrUnsupportedExpression, name)
+}
+
+func unaryOp(op token.Token, x any) (any, error) {
+	switch v := x.(type) {
+	case float64:
+		switch op {
+		case token.SUB:
+			return -v, nil
+		case token.ADD:
+			return v, nil
+		}
+	case time.Duration:
+		if op == token.SUB {
+			return -v, nil
+		}
+	case bool:
+		if op == token.NOT {
+			return !v, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s%s", ErrBadArguments, op, typeName(x))
+}
+
+// binaryOp applies op to numbers, strings, booleans, dates and durations.
+// Dates and durations combine as in the time package: date-date and
+// duration±duration give durations, date±duration gives a date, and
+// duration/duration gives a number (e.g. elapsed/days(1)).
+//
+//nolint:cyclop,gocyclo // one case per supported pair of types
+func binaryOp(op token.Token, x, y any) (any, error) {
+	switch a := x.(type) {
+	case float64:
+		switch b := y.(type) {
+		case float64:
+			return numberOp(op, a, b)
+		case time.Duration:
+			if op == token.MUL {
+				return time.Duration(a * float64(b)), nil
+			}
+		}
+	case string:
+		if b, ok := y.(string); ok {
+			if op == token.ADD {
+				return a + b, nil
+			}
+
+			return compare(op, strings.Compare(a, b))
+		}
+	case bool:
+		if b, ok := y.(bool); ok {
+			switch op {
+			case token.LAND:
+				return a && b, nil
+			case token.LOR:
+				return a || b, nil
+			case token.EQL:
+				return a == b, nil
+			case token.NEQ:
+				return a != b, nil
+			}
+		}
+	case time.Time:
+		switch b := y.(type) {
+		case time.Time:
+			if op == token.SUB {
+				return a.Sub(b), nil
+			}
+
+			return compare(op, a.Compare(b))
+		case time.Duration:
+			switch op {
+			case token.ADD:
+				return a.Add(b), nil
+			case token.SUB:
+				return a.Add(-b), nil
+			}
+		}
+	case time.Duration:
+		switch b := y.(type) {
+		case time.Duration:
+			switch op {
+			case token.ADD:
+				return a + b, nil
+			case token.SUB:
+				return a - b, nil
+			case token.QUO:
+				if b == 0 {
+					return nil, ErrDivisionByZero
+				}
+
+				return float64(a) / float64(b), nil
+			}
+
+			return compare(op, cmp.Compare(a, b))
+		case time.Time:
+			if op == token.ADD {
+				return b.Add(a), nil
+			}
+		case float64:
+			switch op {
+			case token.MUL:
+				return time.Duration(float64(a) * b), nil
+			case token.QUO:
+				if b == 0 {
+					return nil, ErrDivisionByZero
+				}
+
+				return time.Duration(float64(a) / b), nil
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s %s %s", ErrBadArguments, typeName(x), op, typeName(y))
+}
+
+func numberOp(op token.Token, a, b float64) (any, error) {
+	switch op {
+	case token.ADD:
+		return a + b, nil
+	case token.SUB:
+		return a - b, nil
+	case token.MUL:
+		return a * b, nil
+	case token.QUO, token.REM:
+		if b == 0 {
+			return nil, ErrDivisionByZero
+		}
+
+		if op == token.REM {
+			return math.Mod(a, b), nil
+		}
+
+		return a / b, nil
+	}
+
+	return compare(op, cmp.Compare(a, b))
+}
+
+// compare applies a comparison operator to the result of a three-way compare.
+func compare(op token.Token, c int) (any, error) {
+	switch op {
+	case token.EQL:
+		return c == 0, nil
+	case token.NEQ:
+		return c != 0, nil
+	case token.LSS:
+		return c < 0, nil
+	case token.LEQ:
+		return c <= 0, nil
+	case token.GTR:
+		return c > 0, nil
+	case token.GEQ:
+		return c >= 0, nil
+	}
+
+	return nil, fmt.Errorf("%w: operator %s", ErrUnsupportedExpression, op)
+}
+
+// calcFuncs returns the functions available to expressions.
+func calcFuncs(now func() time.Time) map[string]calcFunc {
+	sum := func(nums []float64) float64 {
+		var total float64
+		for _, n := range nums {
+			total += n
+		}
+
+		return total
+	}
+
+	return map[string]calcFunc{
+		"sqrt":  mathFunc(math.Sqrt),
+		"abs":   mathFunc(math.Abs),
+		"floor": mathFunc(math.Floor),
+		"ceil":  mathFunc(math.Ceil),
+		"round": mathFunc(math.Round),
+		"exp":   mathFunc(math.Exp),
+		"ln":    mathFunc(math.Log),
+		"log10": mathFunc(math.Log10),
+		"log2":  mathFunc(math.Log2),
+		"sin":   mathFunc(math.Sin),
+		"cos":   mathFunc(math.Cos),
+		"tan":   mathFunc(math.Tan),
+		"pow": func(args []any) (any, error) {
+			nums, err := numbers(args, 2)
+			if err != nil {
+				return nil, err
+			}
+
+			return math.Pow(nums[0], nums[1]), nil
+		},
+		"sum": aggregate(sum),
+		"avg": aggregate(func(nums []float64) float64 {
+			return sum(nums) / float64(len(nums))
+		}),
+		"min": aggregate(slices.Min[[]float64]),
+		"max": aggregate(slices.Max[[]float64]),
+		"now": func(args []any) (any, error) {
+			if len(args) != 0 {
+				return nil, ErrBadArguments
+			}
+
+			return now(), nil
+		},
+		"today": func(args []any) (any, error) {
+			if len(args) != 0 {
+				return nil, ErrBadArguments
+			}
+
+			y, m, d := now().Date()
+
+			return time.Date(y, m, d, 0, 0, 0, 0, time.UTC), nil
+		},
+		"date": func(args []any) (any, error) {
+			if len(args) != 1 {
+				return nil, ErrBadArguments
+			}
+
+			return parseDate(args[0])
+		},
+		"duration": func(args []any) (any, error) {
+			s, ok := single[string](args)
+			if !ok {
+				return nil, ErrBadArguments
+			}
+
+			return time.ParseDuration(s)
+		},
+		"days": func(args []any) (any, error) {
+			nums, err := numbers(args, 1)
+			if err != nil {
+				return nil, err
+			}
+
+			return time.Duration(nums[0] * float64(24*time.Hour)), nil
+		},
+		"weekday": func(args []any) (any, error) {
+			d, ok := single[time.Time](args)
+			if !ok {
+				return nil, ErrBadArguments
+			}
+
+			return d.Weekday().String(), nil
+		},
+		"unix": func(args []any) (any, error) {
+			d, ok := single[time.Time](args)
+			if !ok {
+				return nil, ErrBadArguments
+			}
+
+			return float64(d.Unix()), nil
+		},
+		"len": func(args []any) (any, error) {
+			if len(args) != 1 {
+				return nil, ErrBadArguments
+			}
+
+			switch v := args[0].(type) {
+			case string:
+				return float64(len([]rune(v))), nil
+			case []any:
+				return float64(len(v)), nil
+			}
+
+			return nil, ErrBadArguments
+		},
+		"list": func(args []any) (any, error) {
+			return args, nil
+		},
+		"upper": stringFunc(strings.ToUpper),
+		"lower": stringFunc(strings.ToLower),
+		"trim":  stringFunc(strings.TrimSpace),
+		"split": func(args []any) (any, error) {
+			s, sep, ok := stringPair(args)
+			if !ok {
+				return nil, ErrBadArguments
+			}
+
+			parts := strings.Split(s, sep)
+			list := make([]any, len(parts))
+
+			for i, part := range parts {
+				list[i] = part
+			}
+
+			return list, nil
+		},
+		"join": func(args []any) (any, error) {
+			if len(args) != 2 {
+				return nil, ErrBadArguments
+			}
+
+			list, ok1 := args[0].([]any)
+			sep, ok2 := args[1].(string)
+
+			if !ok1 || !ok2 {
+				return nil, ErrBadArguments
+			}
+
+			parts := make([]string, len(list))
+			for i, v := range list {
+				parts[i] = formatValue(v)
+			}
+
+			return strings.Join(parts, sep), nil
+		},
+		"sort": func(args []any) (any, error) {
+			list, ok := single[[]any](args)
+			if !ok {
+				return nil, ErrBadArguments
+			}
+
+			return sortList(list)
+		},
+	}
+}
+
+// mathFunc adapts a one-argument math function.
+func mathFunc(f func(float64) float64) calcFunc {
+	return func(args []any) (any, error) {
+		nums, err := numbers(args, 1)
+		if err != nil {
+			return nil, err
+		}
+
+		return f(nums[0]), nil
+	}
+}
+
+// stringFunc adapts a one-argument string function.
+func stringFunc(f func(string) string) calcFunc {
+	return func(args []any) (any, error) {
+		s, ok := single[string](args)
+		if !ok {
+			return nil, ErrBadArguments
+		}
+
+		return f(s), nil
+	}
+}
+
+// aggregate adapts a function of a non-empty set of numbers, given either
+// as arguments or as lists.
+func aggregate(f func([]float64) float64) calcFunc {
+	return func(args []any) (any, error) {
+		values := flatten(args)
+		if len(values) == 0 {
+			return nil, ErrBadArguments
+		}
+
+		nums, err := numbers(values, len(values))
+		if err != nil {
+			return nil, err
+		}
+
+		return f(nums), nil
+	}
+}
+
+// flatten expands list arguments into their elements.
+func flatten(args []any) []any {
+	var values []any
+
+	for _, arg := range args {
+		if list, ok := arg.([]any); ok {
+			values = append(values, list...)
+		} else {
+			values = append(values, arg)
+		}
+	}
+
+	return values
+}
+
+// numbers checks that args are n numbers.
+func numbers(args []any, n int) ([]float64, error) {
+	if len(args) != n {
+		return nil, fmt.Errorf("%w: want %d numbers, got %d arguments", ErrBadArguments, n, len(args))
+	}
+
+	nums := make([]float64, n)
+
+	for i, arg := range args {
+		f, ok := arg.(float64)
+		if !ok {
+			return nil, fmt.Errorf("%w: want a number, got %s", ErrBadArguments, typeName(arg))
+		}
+
+		nums[i] = f
+	}
+
+	return nums, nil
+}
+
+// single returns the only argument if it has type T.
+func single[T any](args []any) (T, bool) {
+	var zero T
+
+	if len(args) != 1 {
+		return zero, false
+	}
+
+	v, ok := args[0].(T)
+
+	return v, ok
+}
+
+func stringPair(args []any) (string, string, bool) {
+	if len(args) != 2 {
+		return "", "", false
+	}
+
+	a, ok1 := args[0].(string)
+	b, ok2 := args[1].(string)
+
+	return a, b, ok1 && ok2
+}
+
+// parseDate parses a date, date-time (RFC 3339) or Unix timestamp.
+func parseDate(v any) (time.Time, error) {
+	switch d := v.(type) {
+	case float64:
+		return time.Unix(int64(d), 0).UTC(), nil
+	case string:
+		if t, err := time.Parse(dateLayout, d); err == nil {
+			return t, nil
+		}
+
+		t, err := time.Parse(time.RFC3339, d)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: want YYYY-MM-DD or RFC 3339, got %q", ErrBadArguments, d)
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: want a string or Unix time, got %s", ErrBadArguments, typeName(v))
+}
+
+// sortList sorts a list of numbers or of strings.
+func sortList(list []any) ([]any, error) {
+	sorted := slices.Clone(list)
+
+	var err error
+
+	slices.SortStableFunc(sorted, func(a, b any) int {
+		switch x := a.(type) {
+		case float64:
+			if y, ok := b.(float64); ok {
+				return cmp.Compare(x, y)
+			}
+		case string:
+			if y, ok := b.(string); ok {
+				return strings.Compare(x, y)
+			}
+		}
+
+		err = fmt.Errorf("%w: cannot compare %s and %s", ErrBadArguments, typeName(a), typeName(b))
+
+		return 0
+	})
+
+	return sorted, err
+}
+
+// formatValue renders a result: whole numbers without a decimal point,
+// dates without a time of day as YYYY-MM-DD, and lists in brackets.
+func formatValue(v any) string {
+	switch x := v.(type) {
+	case float64:
+		if x == math.Trunc(x) && math.Abs(x) < 1e21 {
+			return strconv.FormatFloat(x, 'f', 0, 64)
+		}
+
+		return strconv.FormatFloat(x, 'g', -1, 64)
+	case string:
+		return x
+	case time.Time:
+		if x.Hour() == 0 && x.Minute() == 0 && x.Second() == 0 && x.Nanosecond() == 0 {
+			return x.Format(dateLayout)
+		}
+
+		return x.Format(time.RFC3339)
+	case []any:
+		parts := make([]string, len(x))
+		for i, e := range x {
+			if s, ok := e.(string); ok {
+				parts[i] = strconv.Quote(s)
+			} else {
+				parts[i] = formatValue(e)
+			}
+		}
+
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+
+	return fmt.Sprint(v)
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case time.Time:
+		return "date"
+	case time.Duration:
+		return "duration"
+	case []any:
+		return "list"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func (t *CalculateTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "calculate",
+		Description: anthropic.String("Evaluate an expression exactly, instead of running a script for a quick " +
+			"computation. Syntax is Go-like: numbers, \"strings\", + - * / %, comparisons, && || !, parentheses, " +
+			"pi and e. Functions: sqrt abs floor ceil round exp ln log10 log2 sin cos tan pow(x, y), " +
+			"sum min max avg (numbers or lists), list(...) len sort split(s, sep) join(list, sep) upper lower trim, " +
+			"date(\"2024-01-31\" or RFC 3339 or Unix time) now() today() weekday(d) unix(d) " +
+			"duration(\"1h30m\") days(n). date - date gives a duration, date ± duration a date, and " +
+			"duration / duration a number, e.g. (date(\"2024-03-01\") - date(\"2024-01-01\")) / days(1) is 60."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"expression": map[string]any{
+					"type":        "string",
+					"description": "Expression to evaluate",
+				},
+			},
+			Required: []string{"expression"},
+		},
+	}
+}
+
+// ReadOnly implements ReadOnly; CalculateTool only computes.
+func (t *CalculateTool) ReadOnly() bool {
+	return true
+}
@@ -0,0 +1,81 @@
+package tool
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCalculateTool_Call(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2026, 3, 14, 15, 9, 26, 0, time.UTC)
+	calc := &CalculateTool{now: func() time.Time { return now }}
+
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{"1 + 2 * 3", "7"},
+		{"(1 + 2) * 3", "9"},
+		{"7 / 2", "3.5"},
+		{"2 * -3 % 4", "-2"},
+		{"pow(2, 62)", "4611686018427387904"},
+		{"sqrt(2) > 1.41 && sqrt(2) < 1.42", "true"},
+		{"round(pi * 100) / 100", "3.14"},
+		{"0x1f + 1e3", "1031"},
+		{"sum(1, 2, list(3, 4))", "10"},
+		{"avg(list(1, 2, 3, 4))", "2.5"},
+		{"max(3, 9, 4)", "9"},
+		{`(date("2024-03-01") - date("2024-01-01")) / days(1)`, "60"},
+		{`date("2024-01-31") + days(30)`, "2024-03-01"},
+		{`weekday(date("2024-02-29"))`, "Thursday"},
+		{`date("2024-01-01T12:00:00Z") + duration("90m")`, "2024-01-01T13:30:00Z"},
+		{`today() - days(14)`, "2026-02-28"},
+		{`unix(date("1970-01-02"))`, "86400"},
+		{`duration("1h") * 3`, "3h0m0s"},
+		{`upper("abc") + "!"`, "ABC!"},
+		{`len("héllo")`, "5"},
+		{`sort(split("pear,apple,fig", ","))`, `["apple", "fig", "pear"]`},
+		{`join(sort(list(3, 1, 2)), "-")`, "1-2-3"},
+	}
+
+	for _, tt := range tests {
+		got, err := calc.Call(CalculateParams{Expression: tt.expr})
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", tt.expr, err)
+
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("%s = %q, want %q", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCalculateTool_Errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		expr string
+		want error
+	}{
+		{" ", ErrEmptyExpression},
+		{"1 / 0", ErrDivisionByZero},
+		{"exec(1)", ErrUnknownFunction},
+		{"os.Exit(1)", ErrUnsupportedExpression},
+		{"x + 1", ErrUnsupportedExpression},
+		{`1 + "a"`, ErrBadArguments},
+		{`sqrt("a")`, ErrBadArguments},
+		{`date("yesterday")`, ErrBadArguments},
+		{`sort(list(1, "a"))`, ErrBadArguments},
+	}
+
+	for _, tt := range tests {
+		_, err := (&CalculateTool{}).Call(CalculateParams{Expression: tt.expr})
+		if !errors.Is(err, tt.want) {
+			t.Errorf("%s: got error %v, want %v", tt.expr, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// changeMaxBytes is the largest file whose previous content a Changes keeps
+// for reverting.
+const changeMaxBytes = 10 << 20
+
+// ErrNotRevertible is returned when reverting a change whose previous content
+// was not kept, such as a large file or a symlink.
+var ErrNotRevertible = errors.New("previous content was not kept")
+
+// SetChanges sets where write_files, move_file and delete_file report the
+// files they modify. nil stops reporting.
+func (e *Environment) SetChanges(c *Changes) {
+	e.changes.Store(c)
+}
+
+// Changes collects the resolved paths of files tools have written, moved or
+// deleted. Files changed by shell commands or plugins are not seen.
+type Changes struct {
+	mu      sync.Mutex
+	paths   map[string]bool
+	pending map[string]Change // changes since the last Take
+}
+
+// Change is a file tools changed and what it was before the first change
+// since the last Take.
+type Change struct {
+	Path       string
+	Existed    bool        // the file existed before
+	Content    []byte      // its content before, if it existed
+	Mode       fs.FileMode // its permissions before, if it existed
+	Revertible bool        // Content was kept, so the change can be reverted
+
+	trash *Trash // where Revert keeps the content it replaces
+}
+
+// NewChanges returns an empty collection of changed files.
+func NewChanges() *Changes {
+	return &Changes{paths: map[string]bool{}, pending: map[string]Change{}}
+}
+
+// Paths returns every file changed, sorted.
+func (c *Changes) Paths() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	paths := make([]string, 0, len(c.paths))
+	for path := range c.paths {
+		paths = append(paths, path)
+	}
+
+	slices.Sort(paths)
+
+	return paths
+}
+
+// Len returns the number of files changed.
+func (c *Changes) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.paths)
+}
+
+// Take returns the files changed since the last Take, sorted by path, and
+// starts collecting afresh.
+func (c *Changes) Take() []Change {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	changes := make([]Change, 0, len(c.pending))
+	for _, change := range c.pending {
+		changes = append(changes, change)
+	}
+
+	slices.SortFunc(changes, func(a, b Change) int { return strings.Compare(a.Path, b.Path) })
+	c.pending = map[string]Change{}
+
+	return changes
+}
+
+// captureChanges records what paths hold before a tool changes them, for
+// recordChanges once the change is made. It is nil if no Changes is set.
+func (e *Environment) captureChanges(paths ...string) []Change {
+	if e.changes.Load() == nil {
+		return nil
+	}
+
+	changes := make([]Change, 0, len(paths))
+	trash := e.trash.Load()
+
+	for _, path := range paths {
+		change := Change{Path: path, trash: trash}
+
+		info, err := os.Lstat(path)
+
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			change.Revertible = true
+		case err != nil || !info.Mode().IsRegular() || info.Size() > changeMaxBytes:
+			change.Existed = err == nil
+		default:
+			change.Existed = true
+			change.Mode = info.Mode().Perm()
+			change.Content, err = os.ReadFile(path) //nolint:gosec // a file a tool is about to change
+			change.Revertible = err == nil
+		}
+
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// recordChanges reports changes captured with captureChanges to the
+// Environment's Changes, if any. A path's earliest pending change is kept, so
+// reverting it undoes every change since the last Take.
+func (e *Environment) recordChanges(changes []Change) {
+	c := e.changes.Load()
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, change := range changes {
+		c.paths[change.Path] = true
+
+		if _, ok := c.pending[change.Path]; !ok {
+			c.pending[change.Path] = change
+		}
+	}
+}
+
+// Revert puts the file back as it was before the change. Content the file
+// holds now is kept in the trash set with SetTrash when the change was made,
+// if any, so a revert can itself be undone.
+func (c Change) Revert() error {
+	if !c.Revertible {
+		return fmt.Errorf("%w: %s", ErrNotRevertible, displayPath(c.Path))
+	}
+
+	if c.trash != nil {
+		if err := c.trash.keepCurrent(c.Path); err != nil {
+			return err
+		}
+	}
+
+	if !c.Existed {
+		if err := os.Remove(c.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		return nil
+	}
+
+	temp, _, err := stageContent(c.Path, string(c.Content))
+	if err != nil {
+		return err
+	}
+
+	if err := os.Chmod(temp, c.Mode); err == nil {
+		err = os.Rename(temp, c.Path)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp)
+
+		return err
+	}
+
+	return nil
+}
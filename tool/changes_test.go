@@ -0,0 +1,173 @@
+package tool
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestChanges_RecordsFileTools(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	changes := NewChanges()
+	env.SetChanges(changes)
+	writeFiles := bound(env, &WriteFilesTool{})
+
+	dir, err := resolvePath(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	written := filepath.Join(dir, "new.go")
+	if _, err := writeFiles.Call(WriteFilesParams{Files: []FileWrite{{Path: written, Content: "package x"}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed batch is rolled back, so changes nothing
+	blocked := filepath.Join(dir, "blocked")
+	if err := os.Mkdir(blocked, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	failed := []FileWrite{{Path: filepath.Join(dir, "rolled-back.go"), Content: "x"}, {Path: blocked, Content: "x"}}
+	if _, err := writeFiles.Call(WriteFilesParams{Files: failed}); err == nil {
+		t.Fatal("writing over a directory succeeded")
+	}
+
+	moved := filepath.Join(dir, "moved.go")
+	if _, err := bound(env, &MoveFileTool{}).Call(MoveFileParams{Source: written, Destination: moved}); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := filepath.Join(dir, "gen")
+	writeTestFile(t, filepath.Join(tree, "a.go"), "a")
+	writeTestFile(t, filepath.Join(tree, "sub", "b.go"), "b")
+
+	if _, err := bound(env, &DeleteFileTool{}).Call(DeleteFileParams{Path: tree, Recursive: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+
+	for _, path := range changes.Paths() {
+		if strings.HasPrefix(path, dir) {
+			got = append(got, path)
+		}
+	}
+
+	want := []string{
+		filepath.Join(tree, "a.go"),
+		filepath.Join(tree, "sub", "b.go"),
+		moved,
+		written,
+	}
+
+	if !slices.Equal(got, want) {
+		t.Errorf("Paths() = %q, want %q", got, want)
+	}
+}
+
+func TestChanges_TakeAndRevert(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	changes := NewChanges()
+	env.SetChanges(changes)
+
+	trash := useTrash(t, env)
+
+	dir, err := resolvePath(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited := filepath.Join(dir, "edited.go")
+	deleted := filepath.Join(dir, "deleted.go")
+	source := filepath.Join(dir, "source.go")
+	moved := filepath.Join(dir, "sub", "moved.go")
+	added := filepath.Join(dir, "added.go")
+
+	writeTestFile(t, edited, "original")
+	writeTestFile(t, deleted, "deleted content")
+	writeTestFile(t, source, "moved content")
+
+	writeFiles := bound(env, &WriteFilesTool{})
+	write := func(path, content string) {
+		t.Helper()
+
+		if _, err := writeFiles.Call(WriteFilesParams{Files: []FileWrite{{Path: path, Content: content}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(edited, "first edit")
+	write(edited, "second edit")
+	write(added, "new")
+
+	if _, err := bound(env, &DeleteFileTool{}).Call(DeleteFileParams{Path: deleted}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := bound(env, &MoveFileTool{}).Call(MoveFileParams{Source: source, Destination: moved}); err != nil {
+		t.Fatal(err)
+	}
+
+	taken := changes.Take()
+
+	var paths []string
+	for _, c := range taken {
+		paths = append(paths, c.Path)
+	}
+
+	if want := []string{added, deleted, edited, source, moved}; !slices.Equal(paths, want) {
+		t.Fatalf("Take() paths = %q, want %q", paths, want)
+	}
+
+	// The earliest content since the last Take is kept
+	if c := taken[2]; string(c.Content) != "original" || !c.Existed || !c.Revertible {
+		t.Errorf("Take() change of %s = %+v, want its original content", edited, c)
+	}
+
+	if again := changes.Take(); len(again) != 0 {
+		t.Errorf("second Take() = %d changes, want none", len(again))
+	}
+
+	versions, _ := trash.List()
+
+	for _, c := range taken {
+		if err := c.Revert(); err != nil {
+			t.Fatalf("Revert(%s): %v", c.Path, err)
+		}
+	}
+
+	for path, want := range map[string]string{edited: "original", deleted: "deleted content", source: "moved content"} {
+		if got, err := os.ReadFile(path); err != nil || string(got) != want {
+			t.Errorf("after revert %s = %q, %v; want %q", path, got, err, want)
+		}
+	}
+
+	for _, path := range []string{added, moved} {
+		if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("after revert %s still exists (%v)", path, err)
+		}
+	}
+
+	// What the revert replaced or removed went to the trash
+	if after, _ := trash.List(); len(after) != len(versions)+3 {
+		t.Errorf("trash has %d versions after revert, want %d", len(after), len(versions)+3)
+	}
+}
+
+func TestChange_RevertNotKept(t *testing.T) {
+	t.Parallel()
+
+	c := Change{Path: filepath.Join(t.TempDir(), "big.bin"), Existed: true}
+	if err := c.Revert(); !errors.Is(err, ErrNotRevertible) {
+		t.Errorf("Revert() = %v, want ErrNotRevertible", err)
+	}
+}
@@ -0,0 +1,97 @@
+package tool
+
+import "encoding/json"
+
+// ConcurrencyClass says how a tool call may overlap with the other calls of
+// the same turn.
+type ConcurrencyClass int
+
+const (
+	// ConcurrencyParallel calls run alongside any call that is not exclusive.
+	ConcurrencyParallel ConcurrencyClass = iota
+
+	// ConcurrencyExclusive calls run alone, after the turn's earlier calls
+	// and before its later ones.
+	ConcurrencyExclusive
+
+	// ConcurrencyPerPath calls run one at a time, in order, among calls
+	// touching a common path, or a path in a directory another touches, and
+	// alongside other calls.
+	ConcurrencyPerPath
+)
+
+// String returns the class name.
+func (c ConcurrencyClass) String() string {
+	switch c {
+	case ConcurrencyParallel:
+		return "parallel"
+	case ConcurrencyExclusive:
+		return "exclusive"
+	case ConcurrencyPerPath:
+		return "per-path"
+	}
+
+	return "unknown"
+}
+
+// Concurrent is implemented by tools that declare how a call with the given
+// input may overlap with others. Per-path calls also return the paths they
+// touch. Tools that do not implement it run in parallel if read-only and
+// exclusively otherwise.
+type Concurrent interface {
+	Concurrency(input json.RawMessage) (ConcurrencyClass, []string)
+}
+
+// typedConcurrent is Concurrent for typed tools, given decoded parameters.
+type typedConcurrent[P any] interface {
+	Concurrency(params P) (ConcurrencyClass, []string)
+}
+
+// ConcurrencyOf returns the class of calling t with input and, for per-path
+// calls, the resolved paths touched.
+func ConcurrencyOf(t Tool, input json.RawMessage) (ConcurrencyClass, []string) {
+	c, ok := t.(Concurrent)
+	if !ok {
+		return defaultConcurrency(t), nil
+	}
+
+	class, paths := c.Concurrency(input)
+	if class != ConcurrencyPerPath {
+		return class, nil
+	}
+
+	abs := make([]string, 0, len(paths))
+
+	for _, path := range paths {
+		if p, err := resolvePath(path); err == nil {
+			abs = append(abs, p)
+		}
+	}
+
+	return class, abs
+}
+
+// defaultConcurrency is the class of tools that do not declare one.
+func defaultConcurrency(t Tool) ConcurrencyClass {
+	if IsReadOnly(t) {
+		return ConcurrencyParallel
+	}
+
+	return ConcurrencyExclusive
+}
+
+// Concurrency implements Concurrent by delegating to the typed tool. Input
+// that does not decode runs exclusively; the call will fail anyway.
+func (w *toolWrapper[P]) Concurrency(input json.RawMessage) (ConcurrencyClass, []string) {
+	c, ok := w.typed.(typedConcurrent[P])
+	if !ok {
+		return defaultConcurrency(w), nil
+	}
+
+	var params P
+	if err := json.Unmarshal(input, &params); err != nil {
+		return ConcurrencyExclusive, nil
+	}
+
+	return c.Concurrency(params)
+}
@@ -0,0 +1,89 @@
+package tool
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestConcurrencyOf_Defaults(t *testing.T) {
+	t.Parallel()
+
+	random := WrapTypedTool[RandomNumberParams](&RandomNumberTool{})
+	if class, _ := ConcurrencyOf(random, json.RawMessage(`{"min":1,"max":2}`)); class != ConcurrencyParallel {
+		t.Errorf("read-only tools should run in parallel, got %s", class)
+	}
+
+	if class, _ := ConcurrencyOf(rawTool{}, json.RawMessage(`{}`)); class != ConcurrencyExclusive {
+		t.Errorf("other tools should run exclusively, got %s", class)
+	}
+}
+
+// rawTool is a Tool declaring nothing about itself.
+type rawTool struct{}
+
+func (rawTool) Call(anthropic.ToolUseBlock) *anthropic.ContentBlockParamUnion { return nil }
+func (rawTool) Param() anthropic.ToolParam                                    { return anthropic.ToolParam{Name: "raw"} }
+
+func TestConcurrencyOf_WriteFiles(t *testing.T) {
+	t.Parallel()
+
+	w := WrapTypedTool[WriteFilesParams](&WriteFilesTool{})
+
+	class, paths := ConcurrencyOf(w, json.RawMessage(`{"files":[{"path":"a.go","content":""},{"path":"/tmp/b.go","content":""}]}`))
+	if class != ConcurrencyPerPath {
+		t.Fatalf("write_files should be per-path, got %s", class)
+	}
+
+	a, _ := resolvePath("a.go")
+	b, _ := resolvePath("/tmp/b.go")
+	if !slices.Equal(paths, []string{a, b}) || !filepath.IsAbs(a) {
+		t.Errorf("paths = %v, want resolved paths", paths)
+	}
+
+	if class, _ := ConcurrencyOf(w, json.RawMessage(`{"files":"nope"}`)); class != ConcurrencyExclusive {
+		t.Errorf("undecodable input should run exclusively, got %s", class)
+	}
+}
+
+func TestConcurrencyOf_Reads(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	env.SetDefaultRoot("/srv/project")
+
+	grep := WrapTypedTool[GrepParams](&GrepTool{})
+	ls := WrapTypedTool[LsParams](&LsTool{})
+	read := WrapTypedTool[ReadManyParams](&ReadManyTool{})
+	BindEnvironment(env, grep, ls, read)
+
+	tests := []struct {
+		name  string
+		tool  Tool
+		input string
+		want  []string
+	}{
+		{"grep with a path", grep, `{"pattern":"x","path":"/srv/other"}`, []string{"/srv/other"}},
+		{"grep without a path", grep, `{"pattern":"x"}`, []string{"/srv/project"}},
+		{"list_files", ls, `{"path":"/srv/project/cmd"}`, []string{"/srv/project/cmd"}},
+		{"read_many", read, `{"paths":["/srv/a.go","/srv/b.go"]}`, []string{"/srv/a.go", "/srv/b.go"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			class, paths := ConcurrencyOf(tt.tool, json.RawMessage(tt.input))
+			if class != ConcurrencyPerPath {
+				t.Fatalf("class = %s, want per-path", class)
+			}
+
+			if !slices.Equal(paths, tt.want) {
+				t.Errorf("paths = %v, want %v", paths, tt.want)
+			}
+		})
+	}
+}
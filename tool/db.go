@@ -0,0 +1,371 @@
+package tool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+
+	_ "modernc.org/sqlite" // registers the "sqlite" database/sql driver
+)
+
+const (
+	// dbDefaultRows and dbMaxRows bound the rows a query returns.
+	dbDefaultRows = 100
+	dbMaxRows     = 1000
+
+	// dbTimeout bounds each database call.
+	dbTimeout = 30 * time.Second
+)
+
+var (
+	// ErrInvalidDSN is returned when a database DSN is not of the form driver:source.
+	ErrInvalidDSN = errors.New("invalid database DSN (want driver:source, e.g. sqlite:app.db)")
+
+	// ErrUnknownDriver is returned when a DSN names a driver not compiled into artoo.
+	ErrUnknownDriver = errors.New("database driver not available")
+
+	// ErrReadOnlyQuery is returned when a read-only db tool is asked to change data.
+	ErrReadOnlyQuery = errors.New("only SELECT, WITH, EXPLAIN, SHOW and VALUES statements are allowed in read-only mode")
+
+	// ErrMultipleStatements is returned when a query contains more than one statement.
+	ErrMultipleStatements = errors.New("run one statement at a time")
+)
+
+// readStatements are the statement keywords that return rows without writing.
+var readStatements = []string{"SELECT", "WITH", "EXPLAIN", "SHOW", "VALUES"}
+
+// dbDialect holds the catalog queries of one kind of database. The columns
+// query takes the table name as its only argument.
+type dbDialect struct {
+	tables  string
+	columns string
+}
+
+// dbDialects maps driver names to their catalog queries.
+var dbDialects = map[string]dbDialect{
+	"sqlite": {
+		tables: `SELECT type, name FROM sqlite_master
+			WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name`,
+		columns: `SELECT name, type, CASE WHEN "notnull" = 1 THEN 'NO' ELSE 'YES' END AS nullable, pk
+			FROM pragma_table_info(?) ORDER BY cid`,
+	},
+	"postgres": {
+		tables: `SELECT table_schema, table_name, table_type FROM information_schema.tables
+			WHERE table_schema NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2`,
+		columns: `SELECT column_name, data_type, is_nullable FROM information_schema.columns
+			WHERE table_name = $1 ORDER BY table_schema, ordinal_position`,
+	},
+	"mysql": {
+		tables: `SELECT table_name, table_type FROM information_schema.tables
+			WHERE table_schema = DATABASE() ORDER BY 1`,
+		columns: `SELECT column_name, column_type, is_nullable FROM information_schema.columns
+			WHERE table_schema = DATABASE() AND table_name = ? ORDER BY ordinal_position`,
+	},
+}
+
+func init() {
+	dbDialects["pgx"] = dbDialects["postgres"]
+}
+
+// DBParams defines the parameters for the db tool.
+type DBParams struct {
+	Action string `json:"action"`          // "tables", "describe" or "query"
+	Table  string `json:"table,omitempty"` // Table to describe
+	SQL    string `json:"sql,omitempty"`   // Statement to run
+	Limit  int    `json:"limit,omitempty"` // Maximum rows to return
+}
+
+// Ensure DBTool implements TypedTool[DBParams].
+var _ TypedTool[DBParams] = (*DBTool)(nil)
+
+// DBTool inspects the database configured by a DSN: it lists tables,
+// describes their columns and runs queries. Unless writes are allowed it
+// only runs statements that read, inside a read-only transaction.
+type DBTool struct {
+	driver string
+	source string
+	write  bool
+
+	once sync.Once
+	db   *sql.DB
+	err  error
+}
+
+// NewDBTool creates a db tool for dsn, which has the form driver:source
+// (e.g. sqlite:app.db or postgres://user@host/app). The connection is opened
+// on first use. With write false, statements that modify data are refused.
+func NewDBTool(dsn string, write bool) (*DBTool, error) {
+	driver, source, ok := strings.Cut(dsn, ":")
+	if !ok || driver == "" || source == "" {
+		return nil, ErrInvalidDSN
+	}
+
+	if !slices.Contains(sql.Drivers(), driver) {
+		return nil, fmt.Errorf("%w: %q (available: %s)", ErrUnknownDriver, driver, strings.Join(sql.Drivers(), ", "))
+	}
+
+	switch {
+	case driver == "sqlite":
+		source = strings.TrimPrefix(source, "//")
+		if !write {
+			source = withQueryParam(source, "_pragma=query_only(1)")
+		}
+	case strings.HasPrefix(source, "//"):
+		// URL-style DSNs are passed to the driver whole
+		source = dsn
+	}
+
+	return &DBTool{driver: driver, source: source, write: write}, nil
+}
+
+// withQueryParam appends a query parameter to a DSN.
+func withQueryParam(source, param string) string {
+	if strings.Contains(source, "?") {
+		return source + "&" + param
+	}
+
+	return source + "?" + param
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *DBTool) Call(params DBParams) (string, error) {
+	db, err := t.open()
+	if err != nil {
+		return "", err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), dbTimeout)
+	defer cancel()
+
+	dialect, known := dbDialects[t.driver]
+
+	switch params.Action {
+	case "tables":
+		if !known {
+			return "", fmt.Errorf("listing tables is not supported for %s; query its catalog instead", t.driver)
+		}
+
+		return t.query(ctx, db, dbMaxRows, dialect.tables)
+
+	case "describe":
+		if params.Table == "" {
+			return "", errors.New("table is required")
+		}
+
+		if !known {
+			return "", fmt.Errorf("describing tables is not supported for %s; query its catalog instead", t.driver)
+		}
+
+		return t.query(ctx, db, dbMaxRows, dialect.columns, params.Table)
+
+	case "query":
+		return t.run(ctx, db, params)
+	}
+
+	return "", fmt.Errorf("unknown action %q (want tables, describe or query)", params.Action)
+}
+
+// open connects to the database once.
+func (t *DBTool) open() (*sql.DB, error) {
+	t.once.Do(func() {
+		t.db, t.err = sql.Open(t.driver, t.source)
+		if t.err != nil {
+			t.err = fmt.Errorf("opening database: %w", t.err)
+		}
+	})
+
+	return t.db, t.err
+}
+
+// run executes the statement of a query action.
+func (t *DBTool) run(ctx context.Context, db *sql.DB, params DBParams) (string, error) {
+	stmt := strings.TrimSuffix(strings.TrimSpace(params.SQL), ";")
+	if stmt == "" {
+		return "", errors.New("sql is required")
+	}
+
+	if strings.Contains(stmt, ";") {
+		return "", ErrMultipleStatements
+	}
+
+	limit := params.Limit
+	if limit <= 0 {
+		limit = dbDefaultRows
+	}
+
+	limit = min(limit, dbMaxRows)
+
+	if isReadStatement(stmt) {
+		return t.query(ctx, db, limit, stmt)
+	}
+
+	if !t.write {
+		return "", ErrReadOnlyQuery
+	}
+
+	result, err := db.ExecContext(ctx, stmt)
+	if err != nil {
+		return "", err
+	}
+
+	if n, err := result.RowsAffected(); err == nil {
+		return fmt.Sprintf("OK, %d rows affected", n), nil
+	}
+
+	return "OK", nil
+}
+
+// isReadStatement reports whether stmt starts with a keyword that reads.
+func isReadStatement(stmt string) bool {
+	keyword := strings.TrimLeft(stmt, "( \t\r\n")
+	if end := strings.IndexFunc(keyword, func(r rune) bool { return !unicode.IsLetter(r) }); end >= 0 {
+		keyword = keyword[:end]
+	}
+
+	return slices.Contains(readStatements, strings.ToUpper(keyword))
+}
+
+// dbQueryer is implemented by *sql.DB and *sql.Tx.
+type dbQueryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// query runs a statement that returns rows and formats up to limit of them.
+// Without write access it runs in a read-only transaction, so statements
+// like WITH ... DELETE are refused by databases that support them.
+func (t *DBTool) query(ctx context.Context, db *sql.DB, limit int, stmt string, args ...any) (string, error) {
+	var queryer dbQueryer = db
+
+	if !t.write {
+		tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+		if err != nil {
+			return "", err
+		}
+		defer func() { _ = tx.Rollback() }()
+
+		queryer = tx
+	}
+
+	rows, err := queryer.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(strings.Join(columns, " | "))
+
+	values := make([]any, len(columns))
+	ptrs := make([]any, len(columns))
+
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	n := 0
+	truncated := false
+
+	for rows.Next() {
+		if n == limit {
+			truncated = true
+
+			break
+		}
+
+		if err := rows.Scan(ptrs...); err != nil {
+			return "", err
+		}
+
+		cells := make([]string, len(values))
+		for i, v := range values {
+			cells[i] = formatCell(v)
+		}
+
+		b.WriteString("\n" + strings.Join(cells, " | "))
+		n++
+	}
+
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if truncated {
+		fmt.Fprintf(&b, "\n(first %d rows; narrow the query or raise limit, up to %d)", limit, dbMaxRows)
+	} else {
+		fmt.Fprintf(&b, "\n(%d rows)", n)
+	}
+
+	return b.String(), nil
+}
+
+// formatCell renders a scanned value, showing binary data by size.
+func formatCell(v any) string {
+	switch x := v.(type) {
+	case nil:
+		return "NULL"
+	case []byte:
+		if utf8.Valid(x) {
+			return string(x)
+		}
+
+		return fmt.Sprintf("<%d bytes>", len(x))
+	case time.Time:
+		return x.Format(time.RFC3339)
+	}
+
+	return fmt.Sprint(v)
+}
+
+func (t *DBTool) Param() anthropic.ToolParam {
+	mode := "Read-only: only SELECT, WITH, EXPLAIN, SHOW and VALUES statements run."
+	if t.write {
+		mode = "Statements that modify data are allowed."
+	}
+
+	return anthropic.ToolParam{
+		Name: "db",
+		Description: anthropic.String("Inspect the configured " + t.driver + " database: list its tables, " +
+			"describe a table's columns, or run one SQL statement. " + mode),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"tables", "describe", "query"},
+					"description": "tables lists tables and views, describe shows a table's columns, query runs sql",
+				},
+				"table": map[string]any{
+					"type":        "string",
+					"description": "Table to describe (for describe)",
+				},
+				"sql": map[string]any{
+					"type":        "string",
+					"description": "SQL statement to run (for query)",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Maximum rows to return (default %d, at most %d)", dbDefaultRows, dbMaxRows),
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// ReadOnly implements ReadOnly; the db tool is read-only unless writes are allowed.
+func (t *DBTool) ReadOnly() bool {
+	return !t.write
+}
@@ -0,0 +1,118 @@
+package tool
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestDB creates a SQLite database with a users table.
+func newTestDB(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "app.db")
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL, name TEXT)`,
+		`INSERT INTO users (email, name) VALUES ('a@example.com', 'Ada'), ('b@example.com', NULL), ('c@example.com', 'Cy')`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+
+	return path
+}
+
+func TestDBTool_ReadOnly(t *testing.T) {
+	t.Parallel()
+
+	db, err := NewDBTool("sqlite:"+newTestDB(t), false)
+	if err != nil {
+		t.Fatalf("NewDBTool: %v", err)
+	}
+
+	if !db.ReadOnly() {
+		t.Error("db tool should be read-only by default")
+	}
+
+	out, err := db.Call(DBParams{Action: "tables"})
+	if err != nil || !strings.Contains(out, "table | users") {
+		t.Errorf("tables = %q, %v", out, err)
+	}
+
+	out, err = db.Call(DBParams{Action: "describe", Table: "users"})
+	if err != nil || !strings.Contains(out, "email | TEXT | NO | 0") {
+		t.Errorf("describe = %q, %v", out, err)
+	}
+
+	out, err = db.Call(DBParams{Action: "query", SQL: "select id, name from users order by id;"})
+	if err != nil {
+		t.Fatalf("query: %v", err)
+	}
+
+	if want := "id | name\n1 | Ada\n2 | NULL\n3 | Cy\n(3 rows)"; out != want {
+		t.Errorf("query = %q, want %q", out, want)
+	}
+
+	out, err = db.Call(DBParams{Action: "query", SQL: "SELECT * FROM users", Limit: 2})
+	if err != nil || !strings.Contains(out, "(first 2 rows;") {
+		t.Errorf("limited query = %q, %v", out, err)
+	}
+
+	if _, err := db.Call(DBParams{Action: "query", SQL: "DELETE FROM users"}); !errors.Is(err, ErrReadOnlyQuery) {
+		t.Errorf("DELETE: got %v, want %v", err, ErrReadOnlyQuery)
+	}
+
+	if _, err := db.Call(DBParams{Action: "query", SQL: "SELECT 1; DELETE FROM users"}); !errors.Is(err, ErrMultipleStatements) {
+		t.Errorf("two statements: got %v, want %v", err, ErrMultipleStatements)
+	}
+
+	// Writes hidden in a read statement are refused by the database
+	if _, err := db.Call(DBParams{Action: "query", SQL: "WITH x AS (SELECT 1) DELETE FROM users"}); err == nil {
+		t.Error("WITH ... DELETE should fail in read-only mode")
+	}
+
+	out, _ = db.Call(DBParams{Action: "query", SQL: "SELECT count(*) AS n FROM users"})
+	if !strings.Contains(out, "\n3\n") {
+		t.Errorf("rows should be untouched, got %q", out)
+	}
+}
+
+func TestDBTool_Write(t *testing.T) {
+	t.Parallel()
+
+	db, err := NewDBTool("sqlite://"+newTestDB(t), true)
+	if err != nil {
+		t.Fatalf("NewDBTool: %v", err)
+	}
+
+	if db.ReadOnly() {
+		t.Error("db tool with writes allowed should not be read-only")
+	}
+
+	out, err := db.Call(DBParams{Action: "query", SQL: "DELETE FROM users WHERE name IS NULL"})
+	if err != nil || out != "OK, 1 rows affected" {
+		t.Errorf("DELETE = %q, %v", out, err)
+	}
+}
+
+func TestNewDBTool_Errors(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewDBTool("app.db", false); !errors.Is(err, ErrInvalidDSN) {
+		t.Errorf("missing driver: got %v, want %v", err, ErrInvalidDSN)
+	}
+
+	if _, err := NewDBTool("oracle://db/app", false); !errors.Is(err, ErrUnknownDriver) {
+		t.Errorf("unknown driver: got %v, want %v", err, ErrUnknownDriver)
+	}
+}
@@ -0,0 +1,189 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+var (
+	// ErrIsDirectory is returned when delete_file is given a directory
+	// without recursive.
+	ErrIsDirectory = errors.New("is a directory")
+
+	// ErrProtectedPath is returned for paths whose removal would take the
+	// working directory with it.
+	ErrProtectedPath = errors.New("refusing to remove the working directory or one of its parents")
+)
+
+// DeleteFileParams defines the parameters for the delete_file tool.
+type DeleteFileParams struct {
+	Path      string `json:"path"`
+	Recursive bool   `json:"recursive,omitempty"` // required to delete a directory
+}
+
+// Ensure DeleteFileTool implements TypedTool[DeleteFileParams].
+var _ TypedTool[DeleteFileParams] = (*DeleteFileTool)(nil)
+
+// DeleteFileTool deletes a file or directory, moving regular files to the
+// trash set with SetTrash, if any, so they can be restored.
+type DeleteFileTool struct {
+	envBinding
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *DeleteFileTool) Call(params DeleteFileParams) (string, error) {
+	if params.Path == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, params.Path)
+	}
+
+	path, err := resolveEntry(params.Path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, params.Path)
+	}
+
+	if err := checkRemovable(path); err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(path)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() && !params.Recursive {
+		return "", fmt.Errorf("%s %w; pass recursive to delete it and everything in it", displayPath(path), ErrIsDirectory)
+	}
+
+	files, err := regularFiles(path, info)
+	if err != nil {
+		return "", err
+	}
+
+	// A symlink or empty directory has no files, but is still a change
+	env := t.environment()
+
+	changes := env.captureChanges(trashedPaths(files)...)
+	if len(files) == 0 {
+		changes = env.captureChanges(path)
+	}
+
+	kept := 0
+
+	if trash := env.trash.Load(); trash != nil {
+		if kept, err = trash.keepAll(files); err != nil {
+			return "", fmt.Errorf("%w (%d of %d files were already moved to the trash; the rest are in place)", err, kept, len(files))
+		}
+	}
+
+	if err := os.RemoveAll(path); err != nil {
+		return "", err
+	}
+
+	env.recordChanges(changes)
+
+	result := "Deleted " + displayPath(path)
+	if info.IsDir() {
+		result += fmt.Sprintf(" (%d files)", len(files))
+	}
+
+	if kept > 0 {
+		result += "; the user can restore its content from the trash"
+	}
+
+	return result, nil
+}
+
+// checkRemovable reports an error if removing path would remove the
+// working directory.
+func checkRemovable(path string) error {
+	wd, err := resolvePath(".")
+	if err != nil {
+		return err
+	}
+
+	if wd == path || strings.HasPrefix(wd, path+string(filepath.Separator)) || path == filepath.Dir(path) {
+		return fmt.Errorf("%w: %s", ErrProtectedPath, path)
+	}
+
+	return nil
+}
+
+// regularFiles returns the regular files at or under path, whose content the
+// trash can keep. Symlinks are not followed.
+func regularFiles(path string, info fs.FileInfo) ([]trashedFile, error) {
+	if !info.IsDir() {
+		if !info.Mode().IsRegular() {
+			return nil, nil
+		}
+
+		return []trashedFile{{path: path, file: path}}, nil
+	}
+
+	var files []trashedFile
+
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type().IsRegular() {
+			files = append(files, trashedFile{path: p, file: p})
+		}
+
+		return nil
+	})
+
+	return files, err
+}
+
+// trashedPaths returns the paths of files.
+func trashedPaths(files []trashedFile) []string {
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.path
+	}
+
+	return paths
+}
+
+func (t *DeleteFileTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "delete_file",
+		Description: anthropic.String("Delete a file, or a directory and everything in it with recursive. " +
+			"Use this instead of rm in a shell: deleted files go to the trash, from which the user can restore them."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Path of the file or directory to delete",
+				},
+				"recursive": map[string]any{
+					"type":        "boolean",
+					"description": "Required to delete a directory",
+				},
+			},
+			Required: []string{"path"},
+		},
+	}
+}
+
+// EditsFiles implements FileEditor; deleted files can be restored from the trash.
+func (t *DeleteFileTool) EditsFiles() bool {
+	return true
+}
+
+// Concurrency implements Concurrent. Deleting a directory affects every
+// path under it, so it runs exclusively.
+func (t *DeleteFileTool) Concurrency(params DeleteFileParams) (ConcurrencyClass, []string) {
+	if params.Recursive {
+		return ConcurrencyExclusive, nil
+	}
+
+	return ConcurrencyPerPath, []string{params.Path}
+}
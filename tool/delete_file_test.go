@@ -0,0 +1,133 @@
+package tool
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useTrash sets a trash in a temporary directory for env.
+func useTrash(t *testing.T, env *Environment) *Trash {
+	t.Helper()
+
+	trash := NewTrash(t.TempDir())
+	env.SetTrash(trash)
+
+	return trash
+}
+
+// bound binds tool to env.
+func bound[T EnvironmentUser](env *Environment, tool T) T {
+	tool.SetEnvironment(env)
+
+	return tool
+}
+
+func TestDeleteFileTool_MovesToTrash(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	trash := useTrash(t, env)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "old.go")
+	writeTestFile(t, file, "package old")
+
+	tree := filepath.Join(dir, "gen")
+	writeTestFile(t, filepath.Join(tree, "a.go"), "a")
+	writeTestFile(t, filepath.Join(tree, "sub", "b.go"), "b")
+
+	tool := bound(env, &DeleteFileTool{})
+
+	if _, err := tool.Call(DeleteFileParams{Path: file}); err != nil {
+		t.Fatalf("deleting a file: %v", err)
+	}
+
+	out, err := tool.Call(DeleteFileParams{Path: tree, Recursive: true})
+	if err != nil {
+		t.Fatalf("deleting a directory: %v", err)
+	}
+
+	for _, path := range []string{file, tree} {
+		if _, err := os.Lstat(path); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("%s should be gone", path)
+		}
+	}
+
+	if entries, _ := trash.List(); len(entries) != 3 {
+		t.Errorf("got %d versions in the trash, want 3; output %q", len(entries), out)
+	}
+}
+
+func TestDeleteFileTool_Refusals(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "sub", "f"), "x")
+
+	t.Chdir(filepath.Join(dir, "sub"))
+
+	tool := &DeleteFileTool{}
+
+	tests := []struct {
+		name   string
+		params DeleteFileParams
+		want   error
+	}{
+		{"empty path", DeleteFileParams{}, ErrInvalidPath},
+		{"working directory by absolute path", DeleteFileParams{Path: filepath.Join(dir, "sub")}, ErrProtectedPath},
+		{"working directory", DeleteFileParams{Path: ".", Recursive: true}, ErrProtectedPath},
+		{"parent of the working directory", DeleteFileParams{Path: "..", Recursive: true}, ErrProtectedPath},
+		{"missing", DeleteFileParams{Path: "nope"}, fs.ErrNotExist},
+	}
+
+	for _, tt := range tests {
+		if _, err := tool.Call(tt.params); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	other := filepath.Join(t.TempDir(), "dir")
+	writeTestFile(t, filepath.Join(other, "f"), "x")
+
+	if _, err := tool.Call(DeleteFileParams{Path: other}); !errors.Is(err, ErrIsDirectory) {
+		t.Errorf("expected ErrIsDirectory, got %v", err)
+	}
+}
+
+func TestDeleteFileTool_Symlink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	writeTestFile(t, target, "keep me")
+
+	link := filepath.Join(dir, "link.txt")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	if _, err := (&DeleteFileTool{}).Call(DeleteFileParams{Path: link}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Lstat(link); !errors.Is(err, fs.ErrNotExist) {
+		t.Error("the link should be deleted")
+	}
+
+	if data, _ := os.ReadFile(target); string(data) != "keep me" {
+		t.Error("the link's target should be untouched")
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
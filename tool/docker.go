@@ -0,0 +1,278 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// dockerDefaultTail and dockerMaxTail bound the log lines returned.
+	dockerDefaultTail = 100
+	dockerMaxTail     = 1000
+
+	// dockerTimeout bounds inspection commands; dockerControlTimeout bounds
+	// exec and compose, which may pull images or run builds.
+	dockerTimeout        = 30 * time.Second
+	dockerControlTimeout = 10 * time.Minute
+
+	// dockerMaxOutput caps the output returned to the model.
+	dockerMaxOutput = 20_000
+)
+
+var (
+	// ErrInvalidContainer is returned for an empty container name or one that looks like a flag.
+	ErrInvalidContainer = errors.New("invalid container name")
+
+	// ErrNoCommand is returned when docker_control exec is called without a command.
+	ErrNoCommand = errors.New("command is required")
+)
+
+// DockerParams defines the parameters for the docker tool.
+type DockerParams struct {
+	Action    string `json:"action"`              // "containers", "images" or "logs"
+	Container string `json:"container,omitempty"` // Container whose logs to show
+	Tail      int    `json:"tail,omitempty"`      // Number of log lines from the end
+}
+
+// Ensure DockerTool implements TypedTool[DockerParams].
+var _ TypedTool[DockerParams] = (*DockerTool)(nil)
+
+// DockerTool lists containers and images and shows container logs through
+// the docker CLI. It never changes anything; see DockerControlTool.
+type DockerTool struct {
+	envBinding
+
+	docker string // docker executable; looked up in PATH if empty
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *DockerTool) Call(params DockerParams) (string, error) {
+	var args []string
+
+	switch params.Action {
+	case "containers":
+		args = []string{"ps", "--all", "--format", "table {{.ID}}\t{{.Names}}\t{{.Image}}\t{{.Status}}\t{{.Ports}}"}
+	case "images":
+		args = []string{"images", "--format", "table {{.Repository}}\t{{.Tag}}\t{{.ID}}\t{{.Size}}"}
+	case "logs":
+		if err := checkContainer(params.Container); err != nil {
+			return "", err
+		}
+
+		tail := params.Tail
+		if tail <= 0 {
+			tail = dockerDefaultTail
+		}
+
+		args = []string{"logs", "--tail", strconv.Itoa(min(tail, dockerMaxTail)), params.Container}
+	default:
+		return "", fmt.Errorf("unknown action %q (want containers, images or logs)", params.Action)
+	}
+
+	return runDocker(&t.environment().processes, t.docker, dockerTimeout, args...)
+}
+
+func (t *DockerTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "docker",
+		Description: anthropic.String("Inspect the local Docker environment: list containers (including stopped " +
+			"ones), list images, or show the last lines of a container's logs."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type": "string",
+					"enum": []string{"containers", "images", "logs"},
+				},
+				"container": map[string]any{
+					"type":        "string",
+					"description": "Container name or ID (for logs)",
+				},
+				"tail": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Log lines from the end (default %d, at most %d)", dockerDefaultTail, dockerMaxTail),
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// ReadOnly implements ReadOnly; DockerTool only inspects.
+func (t *DockerTool) ReadOnly() bool {
+	return true
+}
+
+// DockerControlParams defines the parameters for the docker_control tool.
+type DockerControlParams struct {
+	Action    string   `json:"action"`              // "exec", "compose_up" or "compose_down"
+	Container string   `json:"container,omitempty"` // Container to run the command in
+	Command   []string `json:"command,omitempty"`   // Command and arguments for exec
+	File      string   `json:"file,omitempty"`      // Compose file; docker's default if empty
+	Services  []string `json:"services,omitempty"`  // Services to start; all if empty
+}
+
+// Ensure DockerControlTool implements TypedTool[DockerControlParams].
+var _ TypedTool[DockerControlParams] = (*DockerControlTool)(nil)
+
+// DockerControlTool runs commands in containers and brings compose projects
+// up or down. Every call needs the user's approval.
+type DockerControlTool struct {
+	envBinding
+
+	docker string // docker executable; looked up in PATH if empty
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *DockerControlTool) Call(params DockerControlParams) (string, error) {
+	var args []string
+
+	switch params.Action {
+	case "exec":
+		if err := checkContainer(params.Container); err != nil {
+			return "", err
+		}
+
+		if len(params.Command) == 0 {
+			return "", ErrNoCommand
+		}
+
+		args = append([]string{"exec", params.Container}, params.Command...)
+	case "compose_up", "compose_down":
+		args = []string{"compose"}
+		if params.File != "" {
+			args = append(args, "--file", params.File)
+		}
+
+		if params.Action == "compose_down" {
+			args = append(args, "down")
+
+			break
+		}
+
+		for _, service := range params.Services {
+			if strings.HasPrefix(service, "-") {
+				return "", fmt.Errorf("invalid service name %q", service)
+			}
+		}
+
+		args = append(append(args, "up", "--detach"), params.Services...)
+	default:
+		return "", fmt.Errorf("unknown action %q (want exec, compose_up or compose_down)", params.Action)
+	}
+
+	return runDocker(&t.environment().processes, t.docker, dockerControlTimeout, args...)
+}
+
+func (t *DockerControlTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "docker_control",
+		Description: anthropic.String("Change the local Docker environment: run a command in a running container " +
+			"(non-interactive), or start (detached) or stop a docker compose project. The user approves every call."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type": "string",
+					"enum": []string{"exec", "compose_up", "compose_down"},
+				},
+				"container": map[string]any{
+					"type":        "string",
+					"description": "Container name or ID (for exec)",
+				},
+				"command": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Command and arguments to run, e.g. [\"ls\", \"-la\", \"/app\"] (for exec)",
+				},
+				"file": map[string]any{
+					"type":        "string",
+					"description": "Compose file (for compose_up and compose_down; default compose.yaml)",
+				},
+				"services": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Services to start (for compose_up; default all)",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// RequiresApproval implements ApprovalRequired; commands in containers and
+// compose changes can do anything the containers can.
+func (t *DockerControlTool) RequiresApproval() bool {
+	return true
+}
+
+// DockerTools returns the docker and docker_control tools, or nil if the
+// docker CLI is not installed.
+func DockerTools() []Tool {
+	docker, err := exec.LookPath("docker")
+	if err != nil {
+		return nil
+	}
+
+	return []Tool{
+		WrapTypedTool(&DockerTool{docker: docker}),
+		WrapTypedTool(&DockerControlTool{docker: docker}),
+	}
+}
+
+// checkContainer rejects names that docker would parse as flags.
+func checkContainer(name string) error {
+	if name == "" || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("%w: %q", ErrInvalidContainer, name)
+	}
+
+	return nil
+}
+
+// runDocker runs the docker CLI, tracked in procs while it runs, and returns
+// its combined output, truncated to dockerMaxOutput characters.
+func runDocker(procs *processRegistry, docker string, timeout time.Duration, args ...string) (string, error) {
+	if docker == "" {
+		var err error
+		if docker, err = exec.LookPath("docker"); err != nil {
+			return "", fmt.Errorf("docker not found in PATH: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var out bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, docker, args...) //nolint:gosec // arguments are built from validated parameters
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Start()
+	if err == nil {
+		procs.track("docker", "docker "+strings.Join(args, " "), cmd)
+		err = cmd.Wait()
+		procs.untrack(cmd.Process.Pid)
+	}
+
+	output := strings.TrimSpace(out.String())
+	if len(output) > dockerMaxOutput {
+		output = "…" + output[len(output)-dockerMaxOutput:]
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("docker %s failed: %w\n%s", args[0], err, output)
+	}
+
+	if output == "" {
+		return "OK (no output)", nil
+	}
+
+	return output, nil
+}
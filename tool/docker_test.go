@@ -0,0 +1,76 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeDocker writes a docker executable that prints its arguments.
+func fakeDocker(t *testing.T) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "docker")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho \"$@\"\n"), 0o700); err != nil { //nolint:gosec // test executable
+		t.Fatalf("write: %v", err)
+	}
+
+	return path
+}
+
+func TestDockerTool_Call(t *testing.T) {
+	t.Parallel()
+
+	docker := &DockerTool{docker: fakeDocker(t)}
+
+	tests := []struct {
+		params DockerParams
+		want   string
+	}{
+		{DockerParams{Action: "images"}, "images --format table {{.Repository}}\t{{.Tag}}\t{{.ID}}\t{{.Size}}"},
+		{DockerParams{Action: "logs", Container: "web"}, "logs --tail 100 web"},
+		{DockerParams{Action: "logs", Container: "web", Tail: 1_000_000}, "logs --tail 1000 web"},
+	}
+
+	for _, tt := range tests {
+		got, err := docker.Call(tt.params)
+		if err != nil || got != tt.want {
+			t.Errorf("%+v = %q, %v; want %q", tt.params, got, err, tt.want)
+		}
+	}
+
+	if _, err := docker.Call(DockerParams{Action: "logs", Container: "--help"}); !errors.Is(err, ErrInvalidContainer) {
+		t.Errorf("flag as container: got %v, want %v", err, ErrInvalidContainer)
+	}
+}
+
+func TestDockerControlTool_Call(t *testing.T) {
+	t.Parallel()
+
+	control := &DockerControlTool{docker: fakeDocker(t)}
+
+	if !control.RequiresApproval() {
+		t.Error("docker_control should require approval")
+	}
+
+	tests := []struct {
+		params DockerControlParams
+		want   string
+	}{
+		{DockerControlParams{Action: "exec", Container: "db", Command: []string{"psql", "-c", "select 1"}}, "exec db psql -c select 1"},
+		{DockerControlParams{Action: "compose_up", File: "dev.yaml", Services: []string{"api"}}, "compose --file dev.yaml up --detach api"},
+		{DockerControlParams{Action: "compose_down"}, "compose down"},
+	}
+
+	for _, tt := range tests {
+		got, err := control.Call(tt.params)
+		if err != nil || got != tt.want {
+			t.Errorf("%+v = %q, %v; want %q", tt.params, got, err, tt.want)
+		}
+	}
+
+	if _, err := control.Call(DockerControlParams{Action: "exec", Container: "db"}); !errors.Is(err, ErrNoCommand) {
+		t.Errorf("exec without command: got %v, want %v", err, ErrNoCommand)
+	}
+}
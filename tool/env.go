@@ -0,0 +1,154 @@
+package tool
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// DefaultEnvAllow lists the variables the env tool shows when no allowlist is
+// configured: toolchain and locale settings, never credentials.
+var DefaultEnvAllow = []string{
+	"PATH", "HOME", "USER", "SHELL", "PWD", "TERM", "LANG", "LC_*", "TZ", "EDITOR", "CI",
+	"GO*", "CGO_ENABLED",
+	"NODE_ENV", "NODE_OPTIONS", "NPM_CONFIG_*",
+	"PYTHONPATH", "VIRTUAL_ENV", "CONDA_*",
+	"JAVA_HOME", "CARGO_HOME", "RUSTUP_HOME", "RUSTFLAGS",
+	"DOCKER_HOST", "KUBECONFIG",
+}
+
+// secretNameParts mark variables whose values are masked even when allowed.
+var secretNameParts = []string{"KEY", "TOKEN", "SECRET", "PASSWORD", "PASSWD", "CREDENTIAL", "AUTH", "PRIVATE", "COOKIE", "SESSION"}
+
+// notSecretNames contain a secretNameParts word but hold no credentials.
+var notSecretNames = []string{"GOPRIVATE"}
+
+// EnvParams defines the parameters for the env tool.
+type EnvParams struct {
+	Names []string `json:"names,omitempty"` // Variables to show; every allowed one if empty
+}
+
+// Ensure EnvTool implements TypedTool[EnvParams].
+var _ TypedTool[EnvParams] = (*EnvTool)(nil)
+
+// EnvTool shows environment variables matching its allowlist. Values of
+// variables whose names look secret are masked, as are passwords in URLs, so
+// checking configuration does not put credentials into the conversation.
+type EnvTool struct {
+	allow   []string
+	environ func() []string // os.Environ if nil
+}
+
+// NewEnvTool creates an env tool showing the variables that match allow.
+// Entries are names or globs such as "GO*".
+func NewEnvTool(allow []string) *EnvTool {
+	return &EnvTool{allow: allow}
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *EnvTool) Call(params EnvParams) (string, error) {
+	environ := os.Environ
+	if t.environ != nil {
+		environ = t.environ
+	}
+
+	vars := make(map[string]string)
+
+	for _, kv := range environ() {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			vars[name] = value
+		}
+	}
+
+	names := params.Names
+	if len(names) == 0 {
+		for name := range vars {
+			if t.allowed(name) {
+				names = append(names, name)
+			}
+		}
+
+		slices.Sort(names)
+	}
+
+	if len(names) == 0 {
+		return "No allowed environment variables are set.", nil
+	}
+
+	var b strings.Builder
+
+	for _, name := range names {
+		value, set := vars[name]
+
+		switch {
+		case !t.allowed(name):
+			fmt.Fprintf(&b, "%s: not shown (not in the allowlist, ARTOO_ENV_ALLOW)\n", name)
+		case !set:
+			fmt.Fprintf(&b, "%s: not set\n", name)
+		default:
+			fmt.Fprintf(&b, "%s=%s\n", name, redactEnv(name, value))
+		}
+	}
+
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+// allowed reports whether name matches the allowlist.
+func (t *EnvTool) allowed(name string) bool {
+	for _, pattern := range t.allow {
+		if ok, err := path.Match(pattern, name); err == nil && ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// redactEnv masks the value of a secret-looking variable and the password of
+// URL values.
+func redactEnv(name, value string) string {
+	upper := strings.ToUpper(name)
+	for _, part := range secretNameParts {
+		if strings.Contains(upper, part) && !slices.Contains(notSecretNames, upper) {
+			return fmt.Sprintf("<masked, %d characters>", len(value))
+		}
+	}
+
+	if u, err := url.Parse(value); err == nil && u.User != nil {
+		if _, hasPassword := u.User.Password(); hasPassword {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+
+			return u.String()
+		}
+	}
+
+	return value
+}
+
+func (t *EnvTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "env",
+		Description: anthropic.String("Show environment variables relevant to the build and toolchain. Only " +
+			"variables on the user's allowlist are shown, and secret-looking values are masked. Allowed: " +
+			strings.Join(t.allow, ", ") + "."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"names": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Variables to show (default: every allowed variable that is set)",
+				},
+			},
+		},
+	}
+}
+
+// ReadOnly implements ReadOnly; EnvTool only reads the environment.
+func (t *EnvTool) ReadOnly() bool {
+	return true
+}
@@ -0,0 +1,42 @@
+package tool
+
+import (
+	"testing"
+)
+
+func TestEnvTool_Call(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvTool([]string{"PATH", "GO*", "APP_*"})
+	env.environ = func() []string {
+		return []string{
+			"PATH=/usr/bin",
+			"GOFLAGS=-mod=mod",
+			"GOPRIVATE=example.com/*",
+			"GITHUB_TOKEN=ghp_secret",
+			"APP_API_KEY=abc123",
+			"APP_DATABASE_URL=postgres://app:hunter2@db/app",
+		}
+	}
+
+	out, err := env.Call(EnvParams{})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	want := "APP_API_KEY=<masked, 6 characters>\n" +
+		"APP_DATABASE_URL=postgres://app:xxxxx@db/app\n" +
+		"GOFLAGS=-mod=mod\n" +
+		"GOPRIVATE=example.com/*\n" +
+		"PATH=/usr/bin"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+
+	out, _ = env.Call(EnvParams{Names: []string{"GITHUB_TOKEN", "GOPATH"}})
+
+	want = "GITHUB_TOKEN: not shown (not in the allowlist, ARTOO_ENV_ALLOW)\nGOPATH: not set"
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
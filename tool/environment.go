@@ -0,0 +1,88 @@
+package tool
+
+import (
+	"os"
+	"sync/atomic"
+)
+
+// Environment holds the settings the tools of one agent share: the default
+// search root, where changed files are reported and their previous content
+// kept, which files are secret, injection scanning, subprocess limits and
+// the scratch directory, and the child processes the tools start. Agents with their own Environment do not see each
+// other's settings. The zero value uses the defaults; it is safe for
+// concurrent use.
+type Environment struct {
+	root             atomic.Pointer[string]
+	trash            atomic.Pointer[Trash]
+	changes          atomic.Pointer[Changes]
+	secretFiles      atomic.Pointer[ignoreMatcher] // nil uses DefaultSecretFiles
+	secretsAllowed   atomic.Bool
+	injectionScanOff atomic.Bool
+	limits           atomic.Pointer[Limits]
+	scratchDir       atomic.Pointer[string]
+	streamed         streamedFiles
+	processes        processRegistry
+}
+
+// NewEnvironment returns an Environment with the default settings.
+func NewEnvironment() *Environment {
+	return &Environment{}
+}
+
+// defaultEnvironment is used by tools not bound to an Environment.
+var defaultEnvironment = NewEnvironment()
+
+// EnvironmentUser is implemented by tools that use the settings of an
+// Environment.
+type EnvironmentUser interface {
+	SetEnvironment(env *Environment)
+}
+
+// BindEnvironment binds each tool that uses an Environment to env. Tools
+// never bound use a default Environment.
+func BindEnvironment(env *Environment, tools ...Tool) {
+	for _, t := range tools {
+		if u, ok := t.(EnvironmentUser); ok {
+			u.SetEnvironment(env)
+		}
+	}
+}
+
+// SetEnvironment implements EnvironmentUser by delegating to the typed tool.
+func (w *toolWrapper[P]) SetEnvironment(env *Environment) {
+	if u, ok := w.typed.(EnvironmentUser); ok {
+		u.SetEnvironment(env)
+	}
+}
+
+// envBinding is embedded in tools that use an Environment.
+type envBinding struct {
+	env *Environment
+}
+
+// SetEnvironment implements EnvironmentUser.
+func (b *envBinding) SetEnvironment(env *Environment) {
+	b.env = env
+}
+
+// environment returns the Environment the tool is bound to, or the default
+// one.
+func (b *envBinding) environment() *Environment {
+	if b.env == nil {
+		return defaultEnvironment
+	}
+
+	return b.env
+}
+
+// Environ returns the environment variables of the commands run for the
+// tools' agent: the process's, with ScratchDirEnv naming the scratch
+// directory if there is one.
+func (e *Environment) Environ() []string {
+	env := os.Environ()
+	if dir := e.ScratchDir(); dir != "" {
+		env = append(env, ScratchDirEnv+"="+dir)
+	}
+
+	return env
+}
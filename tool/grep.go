@@ -2,16 +2,17 @@
 package tool
 
 import (
-	"bufio"
 	"bytes"
 	"cmp"
 	"context"
+	"encoding/base64"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"slices"
-	"strconv"
 	"strings"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -19,26 +20,65 @@ import (
 
 // GrepParams defines the parameters for the grep tool.
 type GrepParams struct {
-	Pattern string  `json:"pattern"`           // The regex pattern to search for
-	Path    *string `json:"path,omitempty"`    // Optional directory to search in
-	Include *string `json:"include,omitempty"` // Optional file pattern to include
+	Pattern string   `json:"pattern"`           // The regex pattern to search for
+	Path    *string  `json:"path,omitempty"`    // Optional directory to search in
+	Include *string  `json:"include,omitempty"` // Optional file pattern to include
+	Ignore  []string `json:"ignore,omitempty"`  // Optional gitignore-style patterns to leave out
 }
 
-// Number of fields in ripgrep output format: filepath|lineNum|lineText.
-const grepOutputFieldCount = 3
-
 // grepMatch represents a single match from ripgrep.
 type grepMatch struct {
 	path     string
 	modTime  int64
 	lineNum  int
+	column   int   // 1-based byte column of the first submatch
+	offset   int64 // byte offset of the line in the file
 	lineText string
+	binary   bool // ripgrep stopped searching the file on finding binary data
+	secret   bool // the file is a secret file, whose lines are withheld
+}
+
+// rgEvent is a line of ripgrep's --json output. Only match and end events
+// are used.
+type rgEvent struct {
+	Type string `json:"type"`
+	Data struct {
+		Path           rgText `json:"path"`
+		Lines          rgText `json:"lines"`
+		LineNumber     int    `json:"line_number"`
+		AbsoluteOffset int64  `json:"absolute_offset"`
+		BinaryOffset   *int64 `json:"binary_offset"`
+		Submatches     []struct {
+			Start int `json:"start"`
+			End   int `json:"end"`
+		} `json:"submatches"`
+	} `json:"data"`
+}
+
+// rgText is ripgrep's encoding of paths and lines: text when valid UTF-8,
+// base64 bytes otherwise.
+type rgText struct {
+	Text  *string `json:"text"`
+	Bytes string  `json:"bytes"`
+}
+
+// String returns the decoded text.
+func (t rgText) String() string {
+	if t.Text != nil {
+		return *t.Text
+	}
+
+	b, _ := base64.StdEncoding.DecodeString(t.Bytes)
+
+	return string(b)
 }
 
 // Ensure GrepTool implements TypedTool[GrepParams].
 var _ TypedTool[GrepParams] = (*GrepTool)(nil)
 
-type GrepTool struct{}
+type GrepTool struct {
+	envBinding
+}
 
 // Call implements TypedTool.Call with strongly-typed parameters.
 func (t *GrepTool) Call(params GrepParams) (string, error) {
@@ -47,9 +87,17 @@ func (t *GrepTool) Call(params GrepParams) (string, error) {
 	}
 
 	// Determine search path
-	searchPath := "."
-	if params.Path != nil && *params.Path != "" {
-		searchPath = *params.Path
+	searchPath := t.environment().searchPath(params.Path)
+
+	// rg reports paths under searchPath, so resolving it resolves them
+	searchPath, err := resolvePath(searchPath)
+	if err != nil {
+		return "", fmt.Errorf("resolving search path: %w", err)
+	}
+
+	ignore, err := newIgnoreMatcher(params.Ignore)
+	if err != nil {
+		return "", err
 	}
 
 	// Find ripgrep executable
@@ -60,9 +108,8 @@ func (t *GrepTool) Call(params GrepParams) (string, error) {
 
 	// Build ripgrep arguments
 	args := []string{
-		"-nH",                       // Show line numbers and filenames
-		"--field-match-separator=|", // Use | as separator
-		params.Pattern,
+		"--json",
+		"--regexp", params.Pattern,
 	}
 
 	if params.Include != nil && *params.Include != "" {
@@ -96,15 +143,34 @@ func (t *GrepTool) Call(params GrepParams) (string, error) {
 		return "", fmt.Errorf("parsing ripgrep output: %w", err)
 	}
 
+	// Patterns match paths relative to the search path, as in the list tool
+	matches = slices.DeleteFunc(matches, func(m grepMatch) bool {
+		rel, err := filepath.Rel(searchPath, m.path)
+
+		return err == nil && ignore.Ignored(rel)
+	})
+
 	if len(matches) == 0 {
 		return "No files found", nil
 	}
 
-	// Sort matches by modification time (most recent first)
-	slices.SortFunc(matches, func(a, b grepMatch) int {
+	// Sort matches by modification time (most recent first), keeping each
+	// file's matches in line order
+	slices.SortStableFunc(matches, func(a, b grepMatch) int {
 		return cmp.Compare(b.modTime, a.modTime)
 	})
 
+	secrets := map[string]bool{}
+	for i, m := range matches {
+		secret, seen := secrets[m.path]
+		if !seen {
+			secret = t.environment().secretPath(m.path)
+			secrets[m.path] = secret
+		}
+
+		matches[i].secret = secret
+	}
+
 	// Limit and truncate results
 	limit := 100
 	truncated := len(matches) > limit
@@ -116,48 +182,67 @@ func (t *GrepTool) Call(params GrepParams) (string, error) {
 	return t.formatOutput(params.Pattern, matches, truncated), nil
 }
 
-// parseRipgrepOutput parses the output from ripgrep into matches.
+// parseRipgrepOutput parses ripgrep's --json output into matches.
 func (t *GrepTool) parseRipgrepOutput(output string) ([]grepMatch, error) {
 	var matches []grepMatch
 
-	scanner := bufio.NewScanner(strings.NewReader(output))
-	for scanner.Scan() {
-		line := scanner.Text()
-		if line == "" {
-			continue
-		}
+	// Modification times by path; ok is false for files that cannot be stat'd.
+	// Matches cluster in few files, so each is stat'd once.
+	type stat struct {
+		modTime int64
+		ok      bool
+	}
 
-		// Parse format: filepath|lineNum|lineText
-		parts := strings.SplitN(line, "|", grepOutputFieldCount)
-		if len(parts) < grepOutputFieldCount {
-			continue
-		}
+	stats := make(map[string]stat)
 
-		filePath := parts[0]
-		lineNumStr := parts[1]
-		lineText := parts[2]
+	// Index of the first match of the file being reported
+	first := 0
 
-		lineNum, err := strconv.Atoi(lineNumStr)
-		if err != nil {
-			continue
+	for line := range strings.Lines(output) {
+		var event rgEvent
+		if err := json.Unmarshal([]byte(line), &event); err != nil {
+			return nil, err
 		}
 
-		// Get file modification time
-		info, err := os.Stat(filePath)
-		if err != nil {
-			continue
-		}
+		switch event.Type {
+		case "begin":
+			first = len(matches)
+		case "end":
+			if event.Data.BinaryOffset != nil {
+				for i := first; i < len(matches); i++ {
+					matches[i].binary = true
+				}
+			}
+		case "match":
+			filePath := event.Data.Path.String()
 
-		matches = append(matches, grepMatch{
-			path:     filePath,
-			modTime:  info.ModTime().Unix(),
-			lineNum:  lineNum,
-			lineText: lineText,
-		})
-	}
+			st, seen := stats[filePath]
+			if !seen {
+				if info, err := os.Stat(filePath); err == nil {
+					st = stat{modTime: info.ModTime().Unix(), ok: true}
+				}
+
+				stats[filePath] = st
+			}
+
+			if !st.ok {
+				continue
+			}
+
+			column := 0
+			if len(event.Data.Submatches) > 0 {
+				column = event.Data.Submatches[0].Start + 1
+			}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+			matches = append(matches, grepMatch{
+				path:     filePath,
+				modTime:  st.modTime,
+				lineNum:  event.Data.LineNumber,
+				column:   column,
+				offset:   event.Data.AbsoluteOffset,
+				lineText: strings.TrimRight(event.Data.Lines.String(), "\r\n"),
+			})
+		}
 	}
 
 	return matches, nil
@@ -177,10 +262,20 @@ func (t *GrepTool) formatOutput(_ string, matches []grepMatch, truncated bool) s
 			}
 
 			currentFile = match.path
-			output.WriteString(match.path + ":\n")
+			if match.secret {
+				output.WriteString(displayPath(match.path) + ": " + secretNotice + "\n")
+			} else if match.binary {
+				output.WriteString(displayPath(match.path) + ": (binary file, later matches not shown)\n")
+			} else {
+				output.WriteString(displayPath(match.path) + ":\n")
+			}
+		}
+
+		if match.secret {
+			continue
 		}
 
-		fmt.Fprintf(&output, "  Line %d: %s\n", match.lineNum, match.lineText)
+		fmt.Fprintf(&output, "  Line %d, col %d: %s\n", match.lineNum, match.column, match.lineText)
 	}
 
 	if truncated {
@@ -209,14 +304,37 @@ func (t *GrepTool) Param() anthropic.ToolParam {
 				},
 				"path": map[string]any{
 					"type":        "string",
-					"description": "The directory to search in. Defaults to the current working directory.",
+					"description": "The directory to search in. Defaults to the current workspace directory.",
 				},
 				"include": map[string]any{
 					"type":        "string",
 					"description": "File pattern to include in the search (e.g. \"*.js\", \"*.{ts,tsx}\")",
 				},
+				"ignore": map[string]any{
+					"type":        "array",
+					"description": ignoreDescription,
+					"items": map[string]any{
+						"type": "string",
+					},
+				},
 			},
 			Required: []string{"pattern"},
 		},
 	}
 }
+
+// ReadOnly implements ReadOnly; GrepTool never modifies the filesystem.
+func (t *GrepTool) ReadOnly() bool {
+	return true
+}
+
+// Idempotent implements Idempotent so repeated identical calls can be cached.
+func (t *GrepTool) Idempotent() bool {
+	return true
+}
+
+// Concurrency implements Concurrent: a search waits for earlier writes under
+// the directory it searches, so it finds what they wrote.
+func (t *GrepTool) Concurrency(params GrepParams) (ConcurrencyClass, []string) {
+	return ConcurrencyPerPath, []string{t.environment().searchPath(params.Path)}
+}
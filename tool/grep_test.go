@@ -0,0 +1,139 @@
+package tool
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ripgrepOutput writes files under dir and returns ripgrep --json output
+// with perFile matches in each.
+func ripgrepOutput(tb testing.TB, dir string, files, perFile int) string {
+	tb.Helper()
+
+	var out strings.Builder
+
+	for i := range files {
+		path := filepath.Join(dir, fmt.Sprintf("file|%d.go", i))
+		if err := os.WriteFile(path, []byte("package x\n"), 0o600); err != nil {
+			tb.Fatal(err)
+		}
+
+		writeEvent(tb, &out, "begin", map[string]any{"path": map[string]any{"text": path}})
+
+		for line := range perFile {
+			writeEvent(tb, &out, "match", map[string]any{
+				"path":            map[string]any{"text": path},
+				"lines":           map[string]any{"text": fmt.Sprintf("\tlog.Error(err) // a|b %d\n", line)},
+				"line_number":     line + 1,
+				"absolute_offset": line * 30,
+				"submatches":      []map[string]any{{"match": map[string]any{"text": "log"}, "start": 1, "end": 4}},
+			})
+		}
+
+		writeEvent(tb, &out, "end", map[string]any{"path": map[string]any{"text": path}, "binary_offset": nil})
+	}
+
+	writeEvent(tb, &out, "summary", map[string]any{})
+
+	return out.String()
+}
+
+func writeEvent(tb testing.TB, out *strings.Builder, kind string, data map[string]any) {
+	tb.Helper()
+
+	b, err := json.Marshal(map[string]any{"type": kind, "data": data})
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	out.Write(b)
+	out.WriteByte('\n')
+}
+
+func TestGrepTool_ParseRipgrepOutput(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	output := ripgrepOutput(t, dir, 2, 3)
+
+	matches, err := (&GrepTool{}).parseRipgrepOutput(output)
+	if err != nil {
+		t.Fatalf("parseRipgrepOutput() error = %v", err)
+	}
+
+	if len(matches) != 6 {
+		t.Fatalf("got %d matches, want 6", len(matches))
+	}
+
+	m := matches[1]
+	if m.path != filepath.Join(dir, "file|0.go") || m.lineNum != 2 || m.lineText != "\tlog.Error(err) // a|b 1" {
+		t.Errorf("unexpected match %+v", m)
+	}
+
+	if m.column != 2 || m.offset != 30 || m.modTime == 0 || m.binary {
+		t.Errorf("unexpected match details %+v", m)
+	}
+}
+
+func TestGrepTool_ParseRipgrepOutputEdgeCases(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "data.bin")
+	if err := os.WriteFile(binary, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var out strings.Builder
+
+	// A binary file, a line that is not UTF-8, and a file deleted since the search
+	writeEvent(t, &out, "begin", map[string]any{"path": map[string]any{"text": binary}})
+	writeEvent(t, &out, "match", map[string]any{
+		"path":        map[string]any{"text": binary},
+		"lines":       map[string]any{"bytes": base64.StdEncoding.EncodeToString([]byte("caf\xe9\n"))},
+		"line_number": 1,
+	})
+	writeEvent(t, &out, "end", map[string]any{"path": map[string]any{"text": binary}, "binary_offset": 9})
+	writeEvent(t, &out, "match", map[string]any{
+		"path":        map[string]any{"text": filepath.Join(dir, "gone.go")},
+		"lines":       map[string]any{"text": "deleted\n"},
+		"line_number": 1,
+	})
+
+	matches, err := (&GrepTool{}).parseRipgrepOutput(out.String())
+	if err != nil {
+		t.Fatalf("parseRipgrepOutput() error = %v", err)
+	}
+
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+
+	if m := matches[0]; !m.binary || m.lineText != "caf\xe9" {
+		t.Errorf("unexpected match %+v", m)
+	}
+
+	if !strings.Contains((&GrepTool{}).formatOutput("", matches, false), "binary file") {
+		t.Error("output should note the binary file")
+	}
+}
+
+func BenchmarkGrepTool_ParseRipgrepOutput(b *testing.B) {
+	for _, size := range []struct{ files, perFile int }{{1000, 1}, {10, 1000}, {100, 1000}} {
+		b.Run(fmt.Sprintf("files=%d/perFile=%d", size.files, size.perFile), func(b *testing.B) {
+			output := ripgrepOutput(b, b.TempDir(), size.files, size.perFile)
+			tool := &GrepTool{}
+
+			for b.Loop() {
+				if _, err := tool.parseRipgrepOutput(output); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,197 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Help documents t for the user: its description, its parameters and an
+// example input derived from its input schema.
+func Help(t Tool) string {
+	param := t.Param()
+	info := InfoOf(t)
+
+	var b strings.Builder
+
+	b.WriteString(param.Name)
+
+	if info.Origin != "" {
+		fmt.Fprintf(&b, " (%s: %s)", info.Source, info.Origin)
+	} else if info.Source != SourceBuiltin {
+		fmt.Fprintf(&b, " (%s)", info.Source)
+	}
+
+	if description := strings.TrimSpace(param.Description.Value); description != "" {
+		b.WriteString("\n\n" + description)
+	}
+
+	properties := schemaProperties(param.InputSchema.Properties)
+	required := param.InputSchema.Required
+
+	if len(properties) == 0 {
+		b.WriteString("\n\nParameters: none")
+
+		return b.String()
+	}
+
+	names := slices.Sorted(maps.Keys(properties))
+
+	// Required parameters first, in the order the schema lists them
+	slices.SortStableFunc(names, func(x, y string) int {
+		return requiredRank(required, x) - requiredRank(required, y)
+	})
+
+	width := 0
+	for _, name := range names {
+		width = max(width, len(name))
+	}
+
+	b.WriteString("\n\nParameters:")
+
+	for _, name := range names {
+		prop, _ := properties[name].(map[string]any)
+		fmt.Fprintf(&b, "\n  %-*s  %s", width, name, describeProperty(prop, slices.Contains(required, name)))
+	}
+
+	example := map[string]any{}
+
+	for _, name := range names {
+		if len(required) == 0 || slices.Contains(required, name) {
+			prop, _ := properties[name].(map[string]any)
+			example[name] = exampleValue(name, prop)
+		}
+	}
+
+	var data bytes.Buffer
+
+	enc := json.NewEncoder(&data)
+	enc.SetEscapeHTML(false)
+
+	if enc.Encode(example) == nil {
+		b.WriteString("\n\nExample input: " + strings.TrimSpace(data.String()))
+	}
+
+	return b.String()
+}
+
+// schemaProperties returns the properties of an input schema as decoded
+// JSON, however the tool built them.
+func schemaProperties(properties any) map[string]any {
+	data, err := json.Marshal(properties)
+	if err != nil {
+		return nil
+	}
+
+	var decoded map[string]any
+	if json.Unmarshal(data, &decoded) != nil {
+		return nil
+	}
+
+	return decoded
+}
+
+// requiredRank orders required parameters by their place in required,
+// before the optional ones.
+func requiredRank(required []string, name string) int {
+	if i := slices.Index(required, name); i >= 0 {
+		return i
+	}
+
+	return len(required)
+}
+
+// describeProperty summarizes a parameter: its type, whether it is
+// required, its choices and default, and its description.
+func describeProperty(prop map[string]any, required bool) string {
+	kind := schemaType(prop)
+	if required {
+		kind += ", required"
+	}
+
+	parts := []string{kind}
+
+	if choices, ok := prop["enum"].([]any); ok && len(choices) > 0 {
+		values := make([]string, len(choices))
+		for i, c := range choices {
+			values[i] = fmt.Sprint(c)
+		}
+
+		parts = append(parts, "one of "+strings.Join(values, ", "))
+	}
+
+	if def, ok := prop["default"]; ok {
+		parts = append(parts, fmt.Sprintf("default %v", def))
+	}
+
+	line := strings.Join(parts, "; ")
+
+	if description, _ := prop["description"].(string); description != "" {
+		line += "  " + strings.Join(strings.Fields(description), " ")
+	}
+
+	return line
+}
+
+// schemaType names a parameter's type, such as "string" or "array of
+// string".
+func schemaType(prop map[string]any) string {
+	kind, _ := prop["type"].(string)
+
+	switch kind {
+	case "":
+		return "any"
+	case "array":
+		if items, ok := prop["items"].(map[string]any); ok {
+			return "array of " + schemaType(items)
+		}
+	}
+
+	return kind
+}
+
+// exampleValue makes up a value for a parameter: its first example, enum
+// value or default if it has one, or else a placeholder of its type.
+func exampleValue(name string, prop map[string]any) any {
+	if examples, ok := prop["examples"].([]any); ok && len(examples) > 0 {
+		return examples[0]
+	}
+
+	if choices, ok := prop["enum"].([]any); ok && len(choices) > 0 {
+		return choices[0]
+	}
+
+	if def, ok := prop["default"]; ok {
+		return def
+	}
+
+	switch prop["type"] {
+	case "integer", "number":
+		return 1
+	case "boolean":
+		return true
+	case "array":
+		items, _ := prop["items"].(map[string]any)
+
+		return []any{exampleValue(name, items)}
+	case "object":
+		properties, _ := prop["properties"].(map[string]any)
+		required, _ := prop["required"].([]any)
+
+		value := map[string]any{}
+
+		for key, sub := range properties {
+			if len(required) == 0 || slices.Contains(required, any(key)) {
+				subProp, _ := sub.(map[string]any)
+				value[key] = exampleValue(key, subProp)
+			}
+		}
+
+		return value
+	default:
+		return "<" + name + ">"
+	}
+}
@@ -0,0 +1,72 @@
+package tool
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// schemaTool is a tool with a given input schema.
+type schemaTool struct {
+	schema anthropic.ToolInputSchemaParam
+}
+
+func (s *schemaTool) Call(anthropic.ToolUseBlock) *anthropic.ContentBlockParamUnion { return nil }
+
+func (s *schemaTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name:        "deploy",
+		Description: anthropic.String("Deploy a service.\nIt waits for the rollout."),
+		InputSchema: s.schema,
+	}
+}
+
+func TestHelp(t *testing.T) {
+	t.Parallel()
+
+	out := Help(&schemaTool{schema: anthropic.ToolInputSchemaParam{
+		Properties: map[string]any{
+			"service":  map[string]any{"type": "string", "description": "Service\n  to deploy"},
+			"env":      map[string]any{"type": "string", "enum": []string{"staging", "prod"}},
+			"replicas": map[string]any{"type": "integer", "default": 2},
+			"tags":     map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		Required: []string{"service", "env"},
+	}})
+
+	for _, want := range []string{
+		"deploy\n\nDeploy a service.\nIt waits for the rollout.",
+		"service   string, required  Service to deploy",
+		"env       string, required; one of staging, prod",
+		"replicas  integer; default 2",
+		"tags      array of string",
+		`Example input: {"env":"staging","service":"<service>"}`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in:\n%s", want, out)
+		}
+	}
+
+	if strings.Index(out, "service ") > strings.Index(out, "env ") {
+		t.Errorf("required parameters should come first, in schema order:\n%s", out)
+	}
+}
+
+func TestHelp_NoParameters(t *testing.T) {
+	t.Parallel()
+
+	if out := Help(&schemaTool{}); !strings.Contains(out, "Parameters: none") {
+		t.Errorf("expected no parameters:\n%s", out)
+	}
+}
+
+func TestHelp_Builtins(t *testing.T) {
+	t.Parallel()
+
+	for _, tl := range Builtins().Tools() {
+		if out := Help(tl); !strings.Contains(out, "Example input: {") {
+			t.Errorf("%s: expected an example:\n%s", tl.Param().Name, out)
+		}
+	}
+}
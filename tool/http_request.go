@@ -0,0 +1,215 @@
+package tool
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// httpDefaultTimeout and httpMaxTimeout bound a request, in seconds.
+	httpDefaultTimeout = 30
+	httpMaxTimeout     = 120
+
+	// httpMaxBody caps the response body returned to the model; httpReadLimit
+	// caps how much of it is read.
+	httpMaxBody   = 10_000
+	httpReadLimit = 1 << 20
+)
+
+// DefaultHTTPAllow is the domain policy when none is configured: local
+// development servers only.
+var DefaultHTTPAllow = []string{"localhost", "127.0.0.1", "::1"}
+
+var (
+	// ErrInvalidURL is returned for URLs that are not absolute http or https URLs.
+	ErrInvalidURL = errors.New("invalid URL (want an absolute http or https URL)")
+
+	// ErrDomainNotAllowed is returned when the domain policy does not allow a host.
+	ErrDomainNotAllowed = errors.New("domain not allowed")
+)
+
+// HTTPRequestParams defines the parameters for the http_request tool.
+type HTTPRequestParams struct {
+	Method  string            `json:"method,omitempty"`  // HTTP method; GET if empty
+	URL     string            `json:"url"`               // Absolute http or https URL
+	Headers map[string]string `json:"headers,omitempty"` // Request headers
+	Body    string            `json:"body,omitempty"`    // Request body
+	Timeout int               `json:"timeout,omitempty"` // Timeout in seconds
+}
+
+// Ensure HTTPRequestTool implements TypedTool[HTTPRequestParams].
+var _ TypedTool[HTTPRequestParams] = (*HTTPRequestTool)(nil)
+
+// HTTPRequestTool sends HTTP requests to hosts allowed by its domain policy
+// and returns the response status, headers and (truncated) body.
+type HTTPRequestTool struct {
+	envBinding
+
+	allow  []string
+	client *http.Client
+}
+
+// NewHTTPRequestTool creates an http_request tool that may only contact the
+// allowed domains. An entry allows the host itself and its subdomains, and
+// "*" allows every host. Redirects are followed only to allowed hosts.
+func NewHTTPRequestTool(allow []string) *HTTPRequestTool {
+	t := &HTTPRequestTool{allow: allow}
+	t.client = &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+
+			return t.checkHost(req.URL)
+		},
+	}
+
+	return t
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *HTTPRequestTool) Call(params HTTPRequestParams) (string, error) {
+	target, err := url.Parse(params.URL)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidURL, params.URL)
+	}
+
+	if err := t.checkHost(target); err != nil {
+		return "", err
+	}
+
+	method := strings.ToUpper(params.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	timeout := params.Timeout
+	if timeout <= 0 {
+		timeout = httpDefaultTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(min(timeout, httpMaxTimeout))*time.Second)
+	defer cancel()
+
+	var body io.Reader
+	if params.Body != "" {
+		body = strings.NewReader(params.Body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, target.String(), body)
+	if err != nil {
+		return "", err
+	}
+
+	for name, value := range params.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, httpReadLimit))
+	if err != nil {
+		return "", fmt.Errorf("reading response: %w", err)
+	}
+
+	return t.environment().formatResponse(resp, data), nil
+}
+
+// checkHost enforces the domain policy.
+func (t *HTTPRequestTool) checkHost(u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+
+	for _, domain := range t.allow {
+		domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+		if domain == "*" || host == domain || strings.HasSuffix(host, "."+domain) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %s (allowed: %s)", ErrDomainNotAllowed, host, strings.Join(t.allow, ", "))
+}
+
+// formatResponse renders the status line, sorted headers and body, delimited
+// as untrusted content.
+func (e *Environment) formatResponse(resp *http.Response, body []byte) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s\n", resp.Proto, resp.Status)
+
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	b.WriteString("\n")
+	b.Write(body[:min(len(body), httpMaxBody)])
+
+	source := "an HTTP response"
+	if resp.Request != nil {
+		source = resp.Request.URL.String()
+	}
+
+	response := e.wrapUntrusted(source, b.String())
+
+	if len(body) > httpMaxBody {
+		response += fmt.Sprintf("\n… (body truncated to %d of %d bytes read)", httpMaxBody, len(body))
+	}
+
+	return response
+}
+
+func (t *HTTPRequestTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "http_request",
+		Description: anthropic.String("Send an HTTP request and return the response status, headers and body " +
+			"(truncated to " + fmt.Sprint(httpMaxBody) + " bytes). Use it to exercise APIs, e.g. a local dev " +
+			"server. Only these domains and their subdomains may be contacted: " + strings.Join(t.allow, ", ") + "."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"method": map[string]any{
+					"type":        "string",
+					"description": "HTTP method (default GET)",
+				},
+				"url": map[string]any{
+					"type":        "string",
+					"description": "Absolute http or https URL",
+				},
+				"headers": map[string]any{
+					"type":                 "object",
+					"additionalProperties": map[string]any{"type": "string"},
+					"description":          "Request headers",
+				},
+				"body": map[string]any{
+					"type":        "string",
+					"description": "Request body",
+				},
+				"timeout": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Timeout in seconds (default %d, at most %d)", httpDefaultTimeout, httpMaxTimeout),
+				},
+			},
+			Required: []string{"url"},
+		},
+	}
+}
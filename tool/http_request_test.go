@@ -0,0 +1,96 @@
+package tool
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestHTTPRequestTool_Call(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		w.Header().Set("X-Method", r.Method)
+		w.Header().Set("X-Token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("echo: " + string(body)))
+	}))
+	defer server.Close()
+
+	out, err := NewHTTPRequestTool(DefaultHTTPAllow).Call(HTTPRequestParams{
+		Method:  "post",
+		URL:     server.URL + "/users",
+		Headers: map[string]string{"Authorization": "Bearer t"},
+		Body:    `{"name": "ada"}`,
+	})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	for _, want := range []string{"HTTP/1.1 201 Created\n", "X-Method: POST\n", "X-Token: Bearer t\n", "\n\necho: {\"name\": \"ada\"}"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output %q missing %q", out, want)
+		}
+	}
+}
+
+func TestHTTPRequestTool_TruncatesBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", httpMaxBody+5)))
+	}))
+	defer server.Close()
+
+	out, err := NewHTTPRequestTool([]string{"127.0.0.1"}).Call(HTTPRequestParams{URL: server.URL})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if !strings.HasSuffix(out, "(body truncated to 10000 of 10005 bytes read)") {
+		t.Errorf("expected truncation notice, got %q", out[len(out)-80:])
+	}
+}
+
+func TestHTTPRequestTool_DomainPolicy(t *testing.T) {
+	t.Parallel()
+
+	// Redirects may not leave the allowed domains
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://evil.example.net/", http.StatusFound)
+	}))
+	defer server.Close()
+
+	local := NewHTTPRequestTool(DefaultHTTPAllow)
+	if _, err := local.Call(HTTPRequestParams{URL: server.URL}); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Errorf("redirect: got %v, want %v", err, ErrDomainNotAllowed)
+	}
+
+	if _, err := local.Call(HTTPRequestParams{URL: "https://api.example.com/"}); !errors.Is(err, ErrDomainNotAllowed) {
+		t.Errorf("remote host: got %v, want %v", err, ErrDomainNotAllowed)
+	}
+
+	if _, err := local.Call(HTTPRequestParams{URL: "file:///etc/passwd"}); !errors.Is(err, ErrInvalidURL) {
+		t.Errorf("file URL: got %v, want %v", err, ErrInvalidURL)
+	}
+
+	policy := NewHTTPRequestTool([]string{"example.com"})
+
+	for host, allowed := range map[string]bool{
+		"example.com":      true,
+		"api.example.com":  true,
+		"badexample.com":   false,
+		"example.com.evil": false,
+	} {
+		err := policy.checkHost(&url.URL{Host: host})
+		if (err == nil) != allowed {
+			t.Errorf("%s: allowed = %v, want %v", host, err == nil, allowed)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ignoreDescription describes the ignore parameter of tools using ignoreMatcher.
+const ignoreDescription = "Gitignore-style patterns to leave out, matched case-insensitively " +
+	"(e.g. \"*.log\", \"build/\", \"/docs/**/*.md\"). Prefix a pattern with \"!\" to re-include what an earlier one ignored"
+
+var errIgnorePattern = errors.New("invalid ignore pattern")
+
+// ignoreMatcher matches relative paths against gitignore-style patterns,
+// ignoring case:
+//
+//   - a pattern without a slash, such as "*.log", matches a file or
+//     directory name at any depth
+//   - a pattern with a leading or inner slash, such as "/build" or
+//     "docs/*.md", matches from the root of the listing
+//   - a trailing slash, as in "cache/", matches directories only
+//   - "**" matches any number of directories
+//   - a leading "!" re-includes what an earlier pattern ignored; the last
+//     matching pattern wins
+//
+// As in git, files inside an ignored directory cannot be re-included.
+type ignoreMatcher struct {
+	patterns []ignorePattern
+}
+
+// ignorePattern is a parsed pattern line.
+type ignorePattern struct {
+	segments []string // lowercased, split on "/"
+	negate   bool
+	dirOnly  bool
+	anchored bool
+}
+
+// newIgnoreMatcher parses patterns. Blank patterns and "#" comments are
+// skipped.
+func newIgnoreMatcher(patterns ...[]string) (*ignoreMatcher, error) {
+	m := &ignoreMatcher{}
+
+	for _, list := range patterns {
+		for _, line := range list {
+			p, ok, err := parseIgnorePattern(line)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				m.patterns = append(m.patterns, p)
+			}
+		}
+	}
+
+	return m, nil
+}
+
+// parseIgnorePattern parses one pattern, returning false for lines that
+// hold none.
+func parseIgnorePattern(line string) (ignorePattern, bool, error) {
+	var p ignorePattern
+
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return p, false, nil
+	}
+
+	if rest, ok := strings.CutPrefix(line, "!"); ok {
+		p.negate = true
+		line = rest
+	}
+
+	if rest, ok := strings.CutSuffix(line, "/"); ok {
+		p.dirOnly = true
+		line = rest
+	}
+
+	p.anchored = strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	if line == "" {
+		return p, false, nil
+	}
+
+	p.segments = strings.Split(strings.ToLower(line), "/")
+	for _, segment := range p.segments {
+		if _, err := path.Match(segment, ""); err != nil {
+			return p, false, fmt.Errorf("%w %q: %w", errIgnorePattern, line, err)
+		}
+	}
+
+	return p, true, nil
+}
+
+// Ignored reports whether file, a path relative to the root of the listing,
+// is ignored either itself or through one of its parent directories.
+func (m *ignoreMatcher) Ignored(file string) bool {
+	if len(m.patterns) == 0 {
+		return false
+	}
+
+	segments := strings.Split(strings.ToLower(filepath.ToSlash(file)), "/")
+
+	for i := 1; i < len(segments); i++ {
+		if m.match(segments[:i], true) {
+			return true
+		}
+	}
+
+	return m.match(segments, false)
+}
+
+// Filter returns the files that are not ignored.
+func (m *ignoreMatcher) Filter(files []string) []string {
+	kept := files[:0:0]
+
+	for _, file := range files {
+		if !m.Ignored(file) {
+			kept = append(kept, file)
+		}
+	}
+
+	return kept
+}
+
+// match reports whether the last pattern matching segments ignores it.
+func (m *ignoreMatcher) match(segments []string, isDir bool) bool {
+	ignored := false
+
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+
+		var ok bool
+		if p.anchored {
+			ok = matchSegments(p.segments, segments)
+		} else {
+			ok = matchSegments(p.segments, segments[len(segments)-1:])
+		}
+
+		if ok {
+			ignored = !p.negate
+		}
+	}
+
+	return ignored
+}
+
+// matchSegments matches path segments against pattern segments, where a
+// "**" segment matches any number of path segments.
+func matchSegments(pattern, segments []string) bool {
+	if len(pattern) == 0 {
+		return len(segments) == 0
+	}
+
+	if pattern[0] == "**" {
+		for i := range len(segments) + 1 {
+			if matchSegments(pattern[1:], segments[i:]) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(segments) == 0 {
+		return false
+	}
+
+	if ok, _ := path.Match(pattern[0], segments[0]); !ok {
+		return false
+	}
+
+	return matchSegments(pattern[1:], segments[1:])
+}
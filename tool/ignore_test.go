@@ -0,0 +1,123 @@
+package tool
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestIgnoreMatcher_Ignored(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		patterns []string
+		ignored  []string
+		kept     []string
+	}{
+		{
+			name:     "name at any depth",
+			patterns: []string{"subdir"},
+			ignored:  []string{"subdir", "subdir/a.go", "pkg/subdir/b.go"},
+			kept:     []string{"subdirectory/a.go", "pkg/mysubdir"},
+		},
+		{
+			name:     "trailing slash matches directories only",
+			patterns: []string{"build/"},
+			ignored:  []string{"build/out.bin", "cmd/build/x"},
+			kept:     []string{"build", "cmd/build.go"},
+		},
+		{
+			name:     "leading slash anchors",
+			patterns: []string{"/vendor"},
+			ignored:  []string{"vendor/mod.go"},
+			kept:     []string{"internal/vendor/mod.go"},
+		},
+		{
+			name:     "inner slash anchors",
+			patterns: []string{"docs/*.md"},
+			ignored:  []string{"docs/README.md"},
+			kept:     []string{"pkg/docs/README.md", "docs/api/index.md"},
+		},
+		{
+			name:     "double star",
+			patterns: []string{"docs/**/*.md", "**/testdata"},
+			ignored:  []string{"docs/a.md", "docs/api/v1/index.md", "testdata/x", "a/b/testdata/y"},
+			kept:     []string{"docs/api/index.txt", "pkg/docs/a.md"},
+		},
+		{
+			name:     "case-insensitive",
+			patterns: []string{"*.LOG", "Node_Modules/"},
+			ignored:  []string{"server.log", "Debug.Log", "node_modules/x/index.js"},
+		},
+		{
+			name:     "negation re-includes",
+			patterns: []string{"*.log", "!keep.log"},
+			ignored:  []string{"a.log"},
+			kept:     []string{"keep.log", "logs/keep.log"},
+		},
+		{
+			name:     "last match wins",
+			patterns: []string{"!a.log", "*.log"},
+			ignored:  []string{"a.log"},
+		},
+		{
+			name:     "files in ignored directories cannot be re-included",
+			patterns: []string{"cache/", "!cache/keep"},
+			ignored:  []string{"cache/keep"},
+		},
+		{
+			name:     "blank lines and comments",
+			patterns: []string{"", "  ", "# *.go"},
+			kept:     []string{"main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			m, err := newIgnoreMatcher(tt.patterns)
+			if err != nil {
+				t.Fatalf("newIgnoreMatcher() error = %v", err)
+			}
+
+			for _, path := range tt.ignored {
+				if !m.Ignored(path) {
+					t.Errorf("%q should be ignored", path)
+				}
+			}
+
+			for _, path := range tt.kept {
+				if m.Ignored(path) {
+					t.Errorf("%q should be kept", path)
+				}
+			}
+		})
+	}
+}
+
+func TestIgnoreMatcher_Filter(t *testing.T) {
+	t.Parallel()
+
+	m, err := newIgnoreMatcher(ignorePatterns, []string{"*.md", "!.git/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := []string{"main.go", "README.md", "node_modules/x.js", ".git/config", "pkg/lib.go"}
+
+	// .git/ is a default pattern, and "!.git/" re-includes it
+	want := []string{"main.go", ".git/config", "pkg/lib.go"}
+	if got := m.Filter(files); !slices.Equal(got, want) {
+		t.Errorf("Filter() = %v, want %v", got, want)
+	}
+}
+
+func TestNewIgnoreMatcher_BadPattern(t *testing.T) {
+	t.Parallel()
+
+	if _, err := newIgnoreMatcher([]string{"[abc"}); !errors.Is(err, errIgnorePattern) {
+		t.Errorf("expected errIgnorePattern, got %v", err)
+	}
+}
@@ -0,0 +1,207 @@
+package tool
+
+import (
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// jsonFrame is an object or array being read by a jsonStream.
+type jsonFrame struct {
+	array   bool
+	key     string // object: key of the current member
+	index   int    // array: index of the current element
+	wantKey bool   // object: the next string is a key
+}
+
+// jsonStream reads JSON as it arrives, in chunks that may split a token
+// anywhere, and reports the string values it finds as they are decoded. It
+// does not validate: the complete input is decoded again when the call runs.
+type jsonStream struct {
+	// value receives each string value in decoded pieces, with the frames
+	// enclosing it; done is set with the last piece.
+	value func(frames []jsonFrame, piece []byte, done bool)
+
+	frames  []jsonFrame
+	inKey   bool   // reading an object key
+	inValue bool   // reading a string value
+	key     []byte // key read so far
+	piece   []byte // value decoded since the last report
+	escape  int    // 0 outside an escape, 1 after a backslash, 2 reading \u digits
+	hex     []byte // \u digits read so far
+	high    rune   // high surrogate awaiting its low half (0 if none)
+}
+
+// Write feeds the next chunk of input.
+func (s *jsonStream) Write(p []byte) (int, error) {
+	for _, c := range p {
+		switch {
+		case s.inKey || s.inValue:
+			s.stringByte(c)
+		default:
+			s.structByte(c)
+		}
+	}
+
+	if s.inValue && len(s.piece) > 0 {
+		s.value(s.frames, s.piece, false)
+		s.piece = s.piece[:0]
+	}
+
+	return len(p), nil
+}
+
+// structByte handles a byte outside strings.
+func (s *jsonStream) structByte(c byte) {
+	var top *jsonFrame
+	if len(s.frames) > 0 {
+		top = &s.frames[len(s.frames)-1]
+	}
+
+	switch c {
+	case '{':
+		s.frames = append(s.frames, jsonFrame{wantKey: true})
+	case '[':
+		s.frames = append(s.frames, jsonFrame{array: true})
+	case '}', ']':
+		if top != nil {
+			s.frames = s.frames[:len(s.frames)-1]
+		}
+	case ':':
+		if top != nil && !top.array {
+			top.wantKey = false
+		}
+	case ',':
+		if top != nil && top.array {
+			top.index++
+		} else if top != nil {
+			top.wantKey = true
+		}
+	case '"':
+		if top != nil && !top.array && top.wantKey {
+			s.inKey, s.key = true, s.key[:0]
+		} else {
+			s.inValue = true
+		}
+	}
+}
+
+// stringByte handles a byte of a key or string value.
+func (s *jsonStream) stringByte(c byte) {
+	switch s.escape {
+	case 1:
+		s.escape = 0
+
+		switch c {
+		case 'n':
+			s.emit('\n')
+		case 't':
+			s.emit('\t')
+		case 'r':
+			s.emit('\r')
+		case 'b':
+			s.emit('\b')
+		case 'f':
+			s.emit('\f')
+		case 'u':
+			s.escape, s.hex = 2, s.hex[:0]
+		default:
+			s.emit(c)
+		}
+
+		return
+	case 2:
+		s.hex = append(s.hex, c)
+		if len(s.hex) == 4 {
+			s.escape = 0
+			s.emitHex()
+		}
+
+		return
+	}
+
+	switch c {
+	case '\\':
+		s.escape = 1
+	case '"':
+		s.endString()
+	default:
+		s.emit(c)
+	}
+}
+
+// emitHex appends the character of a \u escape.
+func (s *jsonStream) emitHex() {
+	r, err := strconv.ParseUint(string(s.hex), 16, 32)
+	if err != nil {
+		return
+	}
+
+	s.appendRune(rune(r))
+}
+
+// appendRune appends r to the key or value being read. A surrogate pair
+// arrives as two escapes: the high half is held until the low half
+// completes it. A lone half becomes U+FFFD, as encoding/json decodes it
+// (utf8.AppendRune does so for a lone low half).
+func (s *jsonStream) appendRune(r rune) {
+	high := s.high
+	s.high = 0
+
+	if high != 0 {
+		if combined := utf16.DecodeRune(high, r); combined != utf8.RuneError {
+			s.append(utf8.AppendRune(nil, combined)...)
+
+			return
+		}
+
+		s.append(utf8.AppendRune(nil, utf8.RuneError)...)
+	}
+
+	if r >= 0xd800 && r < 0xdc00 {
+		s.high = r
+
+		return
+	}
+
+	s.append(utf8.AppendRune(nil, r)...)
+}
+
+// emit appends a decoded byte to the key or value being read.
+func (s *jsonStream) emit(c byte) {
+	s.flushHigh()
+	s.append(c)
+}
+
+// flushHigh replaces a held high surrogate that no low half followed.
+func (s *jsonStream) flushHigh() {
+	if s.high != 0 {
+		s.high = 0
+		s.append(utf8.AppendRune(nil, utf8.RuneError)...)
+	}
+}
+
+// append appends decoded bytes to the key or value being read.
+func (s *jsonStream) append(b ...byte) {
+	if s.inKey {
+		s.key = append(s.key, b...)
+	} else {
+		s.piece = append(s.piece, b...)
+	}
+}
+
+// endString finishes the key or value being read.
+func (s *jsonStream) endString() {
+	s.flushHigh()
+
+	if s.inKey {
+		s.inKey = false
+		s.frames[len(s.frames)-1].key = string(s.key)
+
+		return
+	}
+
+	s.inValue = false
+	s.value(s.frames, s.piece, true)
+	s.piece = s.piece[:0]
+}
@@ -0,0 +1,61 @@
+package tool
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestJSONStream_DecodesStringsAcrossChunks(t *testing.T) {
+	t.Parallel()
+
+	input := `{"files": [{"path": "a.go", "mode": 420, "content": "tab\there \"quoted\" \\ é 😀 \ud800x"},` +
+		` {"meta": {"path": "nested"}, "path": "b\/c.go"}], "note": "top"}`
+
+	var want struct {
+		Files []map[string]any `json:"files"`
+	}
+
+	if err := json.Unmarshal([]byte(input), &want); err != nil {
+		t.Fatalf("test input is not JSON: %v", err)
+	}
+
+	// Every split point must decode the same
+	for split := range len(input) {
+		got := map[string]string{}
+
+		var current string
+
+		s := &jsonStream{value: func(frames []jsonFrame, piece []byte, done bool) {
+			current += string(piece)
+
+			if done {
+				if len(frames) == 3 && frames[0].key == "files" && frames[1].array {
+					got[string(rune('0'+frames[1].index))+"."+frames[2].key] = current
+				}
+
+				current = ""
+			}
+		}}
+
+		_, _ = s.Write([]byte(input[:split]))
+		_, _ = s.Write([]byte(input[split:]))
+
+		for i, file := range want.Files {
+			for key, value := range file {
+				text, ok := value.(string)
+				if !ok {
+					continue
+				}
+
+				name := string(rune('0'+i)) + "." + key
+				if got[name] != text {
+					t.Fatalf("split at %d: %s = %q, want %q", split, name, got[name], text)
+				}
+			}
+		}
+
+		if len(got) != 3 {
+			t.Fatalf("split at %d: got %v, want the three string fields of files", split, got)
+		}
+	}
+}
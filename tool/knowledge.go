@@ -0,0 +1,173 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aelse/artoo/knowledge"
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// knowledgeDefaultLimit and knowledgeMaxLimit bound the entries a search returns.
+	knowledgeDefaultLimit = 5
+	knowledgeMaxLimit     = 20
+
+	// knowledgeMaxBody caps each entry's body in search results.
+	knowledgeMaxBody = 3_000
+)
+
+// ErrNoQuery is returned when searching the knowledge base without a query.
+var ErrNoQuery = errors.New("query is required for search")
+
+// KnowledgeParams defines the parameters for the knowledge tool.
+type KnowledgeParams struct {
+	Action string   `json:"action"`          // "search" or "add"
+	Query  string   `json:"query,omitempty"` // For search
+	Limit  int      `json:"limit,omitempty"` // For search
+	Title  string   `json:"title,omitempty"` // For add
+	Body   string   `json:"body,omitempty"`  // For add
+	Tags   []string `json:"tags,omitempty"`  // For add
+}
+
+// Ensure KnowledgeTool implements TypedTool[KnowledgeParams].
+var _ TypedTool[KnowledgeParams] = (*KnowledgeTool)(nil)
+
+// KnowledgeTool searches and adds to the team's shared knowledge base, so a
+// discovery made in one engineer's session helps everyone's later sessions.
+type KnowledgeTool struct {
+	store  knowledge.Store
+	author string
+	local  bool // entries are files in the workspace
+}
+
+// NewKnowledgeTool creates a knowledge tool on store, recording author on
+// entries it adds.
+func NewKnowledgeTool(store knowledge.Store, author string) *KnowledgeTool {
+	_, local := store.(*knowledge.DirStore)
+
+	return &KnowledgeTool{store: store, author: author, local: local}
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *KnowledgeTool) Call(params KnowledgeParams) (string, error) {
+	switch params.Action {
+	case "search":
+		if strings.TrimSpace(params.Query) == "" {
+			return "", ErrNoQuery
+		}
+
+		limit := params.Limit
+		if limit <= 0 {
+			limit = knowledgeDefaultLimit
+		}
+
+		entries, err := t.store.Search(params.Query, min(limit, knowledgeMaxLimit))
+		if err != nil {
+			return "", err
+		}
+
+		return formatKnowledge(params.Query, entries), nil
+
+	case "add":
+		e, err := t.store.Add(knowledge.Entry{Title: params.Title, Body: params.Body, Tags: params.Tags, Author: t.author})
+		if err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Added %q to the knowledge base (%s, id %s)", e.Title, t.store.Location(), e.ID), nil
+	}
+
+	return "", fmt.Errorf("unknown action %q (want search or add)", params.Action)
+}
+
+// formatKnowledge renders search results.
+func formatKnowledge(query string, entries []knowledge.Entry) string {
+	if len(entries) == 0 {
+		return fmt.Sprintf("No knowledge base entries match %q.", query)
+	}
+
+	var b strings.Builder
+
+	for i, e := range entries {
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+
+		fmt.Fprintf(&b, "## %s\n", e.Title)
+
+		meta := []string{"id " + e.ID}
+		if len(e.Tags) > 0 {
+			meta = append(meta, "tags: "+strings.Join(e.Tags, ", "))
+		}
+
+		if e.Author != "" {
+			meta = append(meta, "by "+e.Author)
+		}
+
+		if !e.Created.IsZero() {
+			meta = append(meta, e.Created.Format(time.DateOnly))
+		}
+
+		fmt.Fprintf(&b, "(%s)\n\n", strings.Join(meta, "; "))
+
+		body := e.Body
+		if len(body) > knowledgeMaxBody {
+			body = body[:knowledgeMaxBody] + "\n… (truncated)"
+		}
+
+		b.WriteString(body)
+	}
+
+	return b.String()
+}
+
+func (t *KnowledgeTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "knowledge",
+		Description: anthropic.String("The team's shared knowledge base of curated facts and past solutions, " +
+			"shared by everyone's sessions. Search it at the start of a task and before debugging something that " +
+			"looks familiar. Add an entry when you find something non-obvious that others would otherwise " +
+			"rediscover: a root cause, a workaround, how a subsystem really works. Keep entries short, specific " +
+			"and free of secrets."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type": "string",
+					"enum": []string{"search", "add"},
+				},
+				"query": map[string]any{
+					"type":        "string",
+					"description": "Words to search for (for search)",
+				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Entries to return (for search; default %d, at most %d)", knowledgeDefaultLimit, knowledgeMaxLimit),
+				},
+				"title": map[string]any{
+					"type":        "string",
+					"description": "One-line summary (for add)",
+				},
+				"body": map[string]any{
+					"type":        "string",
+					"description": "The fact or solution, in Markdown (for add)",
+				},
+				"tags": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Keywords such as component names (for add)",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// EditsFiles implements FileEditor when entries are files in the workspace;
+// adding to a knowledge service is not a file edit and needs approval under
+// auto-edit.
+func (t *KnowledgeTool) EditsFiles() bool {
+	return t.local
+}
@@ -0,0 +1,44 @@
+package tool
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aelse/artoo/knowledge"
+)
+
+func TestKnowledgeTool_Call(t *testing.T) {
+	t.Parallel()
+
+	kt := NewKnowledgeTool(knowledge.NewDirStore(filepath.Join(t.TempDir(), "knowledge")), "sam")
+
+	if !kt.EditsFiles() {
+		t.Error("a knowledge directory in the workspace should count as file editing")
+	}
+
+	out, err := kt.Call(KnowledgeParams{Action: "add", Title: "Staging deploys", Body: "Use make deploy ENV=staging.", Tags: []string{"deploy"}})
+	if err != nil || !strings.Contains(out, "id staging-deploys") {
+		t.Fatalf("add = %q, %v", out, err)
+	}
+
+	out, err = kt.Call(KnowledgeParams{Action: "search", Query: "deploy"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"## Staging deploys", "tags: deploy", "by sam", "Use make deploy ENV=staging."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("search output missing %q:\n%s", want, out)
+		}
+	}
+
+	if out, _ := kt.Call(KnowledgeParams{Action: "search", Query: "kubernetes"}); !strings.HasPrefix(out, "No knowledge base entries") {
+		t.Errorf("unmatched search = %q", out)
+	}
+
+	if _, err := kt.Call(KnowledgeParams{Action: "search"}); !errors.Is(err, ErrNoQuery) {
+		t.Errorf("expected ErrNoQuery, got %v", err)
+	}
+}
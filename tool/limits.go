@@ -0,0 +1,70 @@
+package tool
+
+import "bytes"
+
+// DefaultMaxOutputBytes is how much output a plugin call keeps unless
+// configured otherwise.
+const DefaultMaxOutputBytes = 1_000_000
+
+// Limits bound the resources of the subprocesses tools start: plugins and the
+// python interpreter. Zero means no limit.
+type Limits struct {
+	MemoryMB    int // virtual memory per process, in MiB
+	CPUSeconds  int // CPU time per process, over its whole life
+	OutputBytes int // output kept from each stream of a plugin call; the plugin is stopped once it writes more
+}
+
+// SetLimits sets the limits of subprocesses started from now on.
+func (e *Environment) SetLimits(l Limits) {
+	e.limits.Store(&l)
+}
+
+// currentLimits returns the limits set with SetLimits, or by default only
+// DefaultMaxOutputBytes.
+func (e *Environment) currentLimits() Limits {
+	if l := e.limits.Load(); l != nil {
+		return *l
+	}
+
+	return Limits{OutputBytes: DefaultMaxOutputBytes}
+}
+
+// cappedBuffer keeps the first max bytes written to it and discards the
+// rest, calling full once when it first has to. A max of zero keeps
+// everything.
+type cappedBuffer struct {
+	buf      bytes.Buffer // not embedded, so io.Copy cannot bypass Write with ReadFrom
+	max      int
+	full     func()
+	overflow bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	room := b.max - b.buf.Len()
+	if b.max <= 0 || len(p) <= room {
+		return b.buf.Write(p)
+	}
+
+	b.buf.Write(p[:max(room, 0)])
+
+	if !b.overflow {
+		b.overflow = true
+		if b.full != nil {
+			b.full()
+		}
+	}
+
+	// Claim the whole write, so the process is not ended by a broken pipe
+	// before it can be stopped
+	return len(p), nil
+}
+
+// Len returns the number of bytes kept.
+func (b *cappedBuffer) Len() int {
+	return b.buf.Len()
+}
+
+// String returns the bytes kept.
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package tool
+
+import "os/exec"
+
+// limitCommand leaves cmd unchanged: memory and CPU limits need ulimit.
+func limitCommand(*exec.Cmd, Limits) {}
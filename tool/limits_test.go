@@ -0,0 +1,77 @@
+package tool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// These tests change the package's subprocess limits, so they do not run in
+// parallel.
+
+func TestCappedBuffer(t *testing.T) {
+	full := 0
+	b := &cappedBuffer{max: 5, full: func() { full++ }}
+
+	for _, s := range []string{"abc", "defg", "hij"} {
+		if n, err := b.Write([]byte(s)); n != len(s) || err != nil {
+			t.Fatalf("Write(%q) = %d, %v; want the whole write accepted", s, n, err)
+		}
+	}
+
+	if b.String() != "abcde" || !b.overflow || full != 1 {
+		t.Errorf("buffer = %q, overflow %v, full called %d times; want abcde, true and once", b.String(), b.overflow, full)
+	}
+
+	unlimited := &cappedBuffer{}
+	_, _ = unlimited.Write([]byte(strings.Repeat("x", 10_000)))
+
+	if unlimited.Len() != 10_000 || unlimited.overflow {
+		t.Error("a zero max should keep everything")
+	}
+}
+
+func TestPluginTool_Call_OutputLimit(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	env.SetLimits(Limits{OutputBytes: 1000})
+
+	path := filepath.Join(t.TempDir(), "flood")
+	script := `#!/bin/bash
+if [ "$1" = "--schema" ]; then echo '{"protocolVersion": 1, "name": "flood"}'; exit 0; fi
+yes
+`
+
+	if err := os.WriteFile(path, []byte(script), 0o700); err != nil { //nolint:gosec // test executable
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	pt, err := NewPluginTool(path, 10*time.Second)
+	if err != nil {
+		t.Fatalf("NewPluginTool failed: %v", err)
+	}
+
+	pt.SetEnvironment(env)
+
+	start := time.Now()
+	result := pt.Call(anthropic.ToolUseBlock{ID: "1", Name: "flood", Input: json.RawMessage(`{}`)}).OfToolResult
+	text := result.Content[0].OfText.Text
+
+	if !result.IsError.Value || !strings.HasPrefix(text, "Plugin error: output exceeded 1000 bytes") {
+		t.Errorf("result = %.200q, want the output limit reported", text)
+	}
+
+	if strings.Count(text, "y\n") != 500 {
+		t.Errorf("result kept %d lines, want the first 1000 bytes", strings.Count(text, "y\n"))
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("call took %v, want the plugin stopped at the limit", elapsed)
+	}
+}
@@ -0,0 +1,33 @@
+//go:build unix
+
+package tool
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// limitCommand runs cmd through sh, which sets the memory and CPU limits in
+// l on itself with ulimit and then execs the command under them. The limits
+// thus apply before the command runs, to it and to anything it starts.
+func limitCommand(cmd *exec.Cmd, l Limits) {
+	var script strings.Builder
+
+	if l.MemoryMB > 0 {
+		fmt.Fprintf(&script, "ulimit -v %d || exit 126; ", l.MemoryMB*1024)
+	}
+
+	if l.CPUSeconds > 0 {
+		fmt.Fprintf(&script, "ulimit -t %d || exit 126; ", l.CPUSeconds)
+	}
+
+	if script.Len() == 0 {
+		return
+	}
+
+	script.WriteString(`exec "$0" "$@"`)
+
+	cmd.Args = append([]string{"sh", "-c", script.String(), cmd.Path}, cmd.Args[1:]...)
+	cmd.Path = "/bin/sh"
+}
@@ -0,0 +1,32 @@
+//go:build unix
+
+package tool
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestLimitCommand(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sh", "-c", `ulimit -v; ulimit -t; echo "$1"`, "sh", "arg")
+	limitCommand(cmd, Limits{MemoryMB: 512, CPUSeconds: 7})
+
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("running the limited command: %v", err)
+	}
+
+	if got := strings.Fields(string(out)); strings.Join(got, " ") != "524288 7 arg" {
+		t.Errorf("limited command printed %q, want its limits and argument", out)
+	}
+
+	unlimited := exec.Command("true")
+	limitCommand(unlimited, Limits{OutputBytes: 10})
+
+	if unlimited.Path == "/bin/sh" {
+		t.Error("without memory or CPU limits the command should run as is")
+	}
+}
@@ -4,6 +4,7 @@ package tool
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"os/exec"
 	"path/filepath"
@@ -13,7 +14,8 @@ import (
 	"github.com/anthropics/anthropic-sdk-go"
 )
 
-// Default patterns to ignore when listing files.
+// Default patterns to ignore when listing files, in the syntax of
+// ignoreMatcher.
 var ignorePatterns = []string{
 	"node_modules/",
 	"__pycache__/",
@@ -40,62 +42,80 @@ var ignorePatterns = []string{
 	"env/",
 }
 
-const lsLimit = 100
+// lsLimit is the number of files listed by default, and lsMaxLimit the most
+// a single call may ask for.
+const (
+	lsLimit    = 100
+	lsMaxLimit = 1000
+)
+
+var errLsPage = errors.New("invalid page")
 
 // LsParams defines the parameters for the ls tool.
 type LsParams struct {
 	Path   *string  `json:"path,omitempty"`   // Optional absolute path to list
 	Ignore []string `json:"ignore,omitempty"` // Optional glob patterns to ignore
+	Limit  *int     `json:"limit,omitempty"`  // Optional number of files to list
+	Offset *int     `json:"offset,omitempty"` // Optional number of files to skip
+}
+
+// lsPage describes the part of a listing that is shown.
+type lsPage struct {
+	offset int // files skipped
+	total  int // files in the whole listing
 }
 
 // Ensure LsTool implements TypedTool[LsParams].
 var _ TypedTool[LsParams] = (*LsTool)(nil)
 
-type LsTool struct{}
+type LsTool struct {
+	envBinding
+}
 
 // Call implements TypedTool.Call with strongly-typed parameters.
 func (t *LsTool) Call(params LsParams) (string, error) {
 	// Determine search path
-	searchPath := "."
-	if params.Path != nil && *params.Path != "" {
-		searchPath = *params.Path
-	}
+	searchPath := t.environment().searchPath(params.Path)
 
-	// Get absolute path
-	absPath, err := filepath.Abs(searchPath)
+	absPath, err := resolvePath(searchPath)
 	if err != nil {
-		return "", fmt.Errorf("getting absolute path: %w", err)
+		return "", fmt.Errorf("resolving path: %w", err)
 	}
 
-	// Build ignore globs
-	ignoreGlobs := make([]string, 0, len(ignorePatterns)+len(params.Ignore))
-	for _, pattern := range ignorePatterns {
-		ignoreGlobs = append(ignoreGlobs, "!"+pattern+"*")
-	}
-	for _, pattern := range params.Ignore {
-		ignoreGlobs = append(ignoreGlobs, "!"+pattern)
+	// The caller's patterns follow the defaults, so they can re-include with "!"
+	ignore, err := newIgnoreMatcher(ignorePatterns, params.Ignore)
+	if err != nil {
+		return "", err
 	}
 
 	// Get files using ripgrep
-	files, err := t.getFiles(absPath, ignoreGlobs)
+	files, err := t.getFiles(absPath)
 	if err != nil {
 		return "", fmt.Errorf("listing files: %w", err)
 	}
 
-	// Limit results
-	truncated := len(files) > lsLimit
-	if truncated {
-		files = files[:lsLimit]
+	files = ignore.Filter(files)
+
+	// rg lists files in no particular order, so sort them for stable pages
+	slices.Sort(files)
+
+	files, page, err := paginate(files, params)
+	if err != nil {
+		return "", err
+	}
+
+	if len(files) == 0 && page.total > 0 {
+		return fmt.Sprintf("No files at offset %d; the listing has %d files.", page.offset, page.total), nil
 	}
 
 	// Build and render directory tree
-	output := t.renderTree(absPath, files, truncated)
+	output := t.renderTree(displayPath(absPath), files, page)
 
 	return output, nil
 }
 
-// getFiles uses ripgrep to list files with ignore patterns.
-func (t *LsTool) getFiles(searchPath string, ignoreGlobs []string) ([]string, error) {
+// getFiles uses ripgrep to list files, honoring .gitignore files.
+func (t *LsTool) getFiles(searchPath string) ([]string, error) {
 	// Find ripgrep executable
 	rgPath, err := exec.LookPath("rg")
 	if err != nil {
@@ -104,9 +124,6 @@ func (t *LsTool) getFiles(searchPath string, ignoreGlobs []string) ([]string, er
 
 	// Build ripgrep arguments for listing files
 	args := []string{"--files"}
-	for _, glob := range ignoreGlobs {
-		args = append(args, "--glob", glob)
-	}
 
 	// Execute ripgrep in the search path
 	cmd := exec.CommandContext(context.Background(), rgPath, args...)
@@ -137,8 +154,32 @@ func (t *LsTool) getFiles(searchPath string, ignoreGlobs []string) ([]string, er
 	return files, nil
 }
 
-// renderTree builds a tree structure representation of files.
-func (t *LsTool) renderTree(basePath string, files []string, truncated bool) string {
+// paginate returns the page of files that params asks for.
+func paginate(files []string, params LsParams) ([]string, lsPage, error) {
+	limit, offset := lsLimit, 0
+	if params.Limit != nil {
+		limit = min(*params.Limit, lsMaxLimit)
+	}
+
+	if params.Offset != nil {
+		offset = *params.Offset
+	}
+
+	if limit < 1 || offset < 0 {
+		return nil, lsPage{}, fmt.Errorf("%w: limit must be positive and offset not negative", errLsPage)
+	}
+
+	page := lsPage{offset: offset, total: len(files)}
+	if offset >= len(files) {
+		return nil, page, nil
+	}
+
+	return files[offset:min(offset+limit, len(files))], page, nil
+}
+
+// renderTree builds a tree structure representation of files, which are
+// the given page of the listing.
+func (t *LsTool) renderTree(basePath string, files []string, page lsPage) string {
 	// Build directory structure
 	dirs := make(map[string]bool)
 	filesByDir := make(map[string][]string)
@@ -218,8 +259,14 @@ func (t *LsTool) renderTree(basePath string, files []string, truncated bool) str
 
 	output.WriteString(renderDir(".", 0))
 
-	if truncated {
-		fmt.Fprintf(&output, "\n(Showing first %d files. Results truncated.)\n", lsLimit)
+	if shown := page.offset + len(files); page.offset > 0 || shown < page.total {
+		fmt.Fprintf(&output, "\n(Showing files %d-%d of %d.", page.offset+1, shown, page.total)
+
+		if shown < page.total {
+			fmt.Fprintf(&output, " Results truncated; list again with offset %d for more, or list a subdirectory.", shown)
+		}
+
+		output.WriteString(")\n")
 	}
 
 	return output.String()
@@ -228,7 +275,8 @@ func (t *LsTool) renderTree(basePath string, files []string, truncated bool) str
 func (t *LsTool) Param() anthropic.ToolParam {
 	const desc = "Lists files and directories in a given path. The path parameter must be absolute; " +
 		"omit it to use the current workspace directory. You can optionally provide an array of glob patterns " +
-		"to ignore with the ignore parameter. You should generally prefer the Glob and Grep tools, " +
+		"to ignore with the ignore parameter. Large listings are paged: use offset to continue one, or list a subdirectory. " +
+		"You should generally prefer the Glob and Grep tools, " +
 		"if you know which directories to search."
 
 	return anthropic.ToolParam{
@@ -242,12 +290,36 @@ func (t *LsTool) Param() anthropic.ToolParam {
 				},
 				"ignore": map[string]any{
 					"type":        "array",
-					"description": "List of glob patterns to ignore",
+					"description": ignoreDescription,
 					"items": map[string]any{
 						"type": "string",
 					},
 				},
+				"limit": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Number of files to list (default %d, at most %d)", lsLimit, lsMaxLimit),
+				},
+				"offset": map[string]any{
+					"type":        "integer",
+					"description": "Number of files to skip, to continue a truncated listing",
+				},
 			},
 		},
 	}
 }
+
+// ReadOnly implements ReadOnly; LsTool never modifies the filesystem.
+func (t *LsTool) ReadOnly() bool {
+	return true
+}
+
+// Idempotent implements Idempotent so repeated identical calls can be cached.
+func (t *LsTool) Idempotent() bool {
+	return true
+}
+
+// Concurrency implements Concurrent: a listing waits for earlier writes under
+// its directory, so it shows the files they created.
+func (t *LsTool) Concurrency(params LsParams) (ConcurrencyClass, []string) {
+	return ConcurrencyPerPath, []string{t.environment().searchPath(params.Path)}
+}
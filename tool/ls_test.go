@@ -3,6 +3,7 @@ package tool
 import (
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 )
@@ -154,14 +155,13 @@ func TestLsTool_RenderTree(t *testing.T) {
 		name     string
 		basePath string
 		files    []string
-		truncate bool
+		page     lsPage
 		expected []string // Strings that should be in the output
 	}{
 		{
 			name:     "simple flat directory",
 			basePath: "/test",
 			files:    []string{"file1.txt", "file2.go"},
-			truncate: false,
 			expected: []string{
 				"/test/",
 				"file1.txt",
@@ -176,7 +176,6 @@ func TestLsTool_RenderTree(t *testing.T) {
 				"subdir/file2.txt",
 				"subdir/nested/file3.txt",
 			},
-			truncate: false,
 			expected: []string{
 				"/test/",
 				"subdir/",
@@ -190,18 +189,19 @@ func TestLsTool_RenderTree(t *testing.T) {
 			name:     "truncated results",
 			basePath: "/test",
 			files:    []string{"file1.txt"},
-			truncate: true,
+			page:     lsPage{offset: 0, total: 5},
 			expected: []string{
 				"/test/",
 				"file1.txt",
+				"Showing files 1-1 of 5",
 				"Results truncated",
+				"offset 1",
 			},
 		},
 		{
 			name:     "empty directory",
 			basePath: "/test",
 			files:    []string{},
-			truncate: false,
 			expected: []string{
 				"/test/",
 			},
@@ -212,7 +212,7 @@ func TestLsTool_RenderTree(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			output := tool.renderTree(tt.basePath, tt.files, tt.truncate)
+			output := tool.renderTree(tt.basePath, tt.files, tt.page)
 
 			for _, expected := range tt.expected {
 				if !strings.Contains(output, expected) {
@@ -234,7 +234,7 @@ func TestLsTool_RenderTreeStructure(t *testing.T) {
 		"b/d/e.txt",
 	}
 
-	output := tool.renderTree("/root", files, false)
+	output := tool.renderTree("/root", files, lsPage{total: len(files)})
 
 	lines := strings.Split(strings.TrimSpace(output), "\n")
 
@@ -286,6 +286,63 @@ func TestLsTool_RenderTreeStructure(t *testing.T) {
 	}
 }
 
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	files := make([]string, 250)
+	for i := range files {
+		files[i] = "file" + strconv.Itoa(i)
+	}
+
+	tests := []struct {
+		name          string
+		limit, offset *int
+		want          int // files returned
+		wantFirst     string
+		wantErr       bool
+	}{
+		{name: "default", want: lsLimit, wantFirst: "file0"},
+		{name: "second page", offset: ptr(100), want: 100, wantFirst: "file100"},
+		{name: "last page", offset: ptr(200), want: 50, wantFirst: "file200"},
+		{name: "larger limit", limit: ptr(300), want: 250, wantFirst: "file0"},
+		{name: "past the end", offset: ptr(250), want: 0},
+		{name: "zero limit", limit: ptr(0), wantErr: true},
+		{name: "negative offset", offset: ptr(-1), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, page, err := paginate(files, LsParams{Limit: tt.limit, Offset: tt.offset})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("paginate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != tt.want || page.total != len(files) {
+				t.Fatalf("got %d files of %d, want %d of %d", len(got), page.total, tt.want, len(files))
+			}
+
+			if len(got) > 0 && got[0] != tt.wantFirst {
+				t.Errorf("first file = %q, want %q", got[0], tt.wantFirst)
+			}
+		})
+	}
+
+	many := make([]string, lsMaxLimit+500)
+	if got, _, _ := paginate(many, LsParams{Limit: ptr(len(many))}); len(got) != lsMaxLimit {
+		t.Errorf("limit above the maximum should be capped, got %d files", len(got))
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
 func TestLsTool_Param(t *testing.T) {
 	t.Parallel()
 
@@ -319,3 +376,17 @@ func TestLsTool_Param(t *testing.T) {
 		t.Error("expected 'ignore' property to exist")
 	}
 }
+
+func BenchmarkLsTool_RenderTree(b *testing.B) {
+	tool := &LsTool{}
+
+	// A deep, wide listing at the limit the tool renders
+	files := make([]string, 0, lsLimit)
+	for i := range lsLimit {
+		files = append(files, filepath.Join("pkg", "mod"+strconv.Itoa(i%10), "sub"+strconv.Itoa(i%3), "file"+strconv.Itoa(i)+".go"))
+	}
+
+	for b.Loop() {
+		tool.renderTree("/project", files, lsPage{total: 2 * lsLimit})
+	}
+}
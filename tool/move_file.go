@@ -0,0 +1,223 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+var (
+	// ErrDestinationExists is returned when move_file would replace an
+	// existing file without overwrite, or would replace a directory.
+	ErrDestinationExists = errors.New("destination exists")
+
+	// ErrMoveIntoItself is returned when move_file is asked to move a
+	// directory inside itself.
+	ErrMoveIntoItself = errors.New("cannot move a directory inside itself")
+)
+
+// MoveFileParams defines the parameters for the move_file tool.
+type MoveFileParams struct {
+	Source      string `json:"source"`
+	Destination string `json:"destination"`
+	Overwrite   bool   `json:"overwrite,omitempty"` // replace an existing destination file
+}
+
+// Ensure MoveFileTool implements TypedTool[MoveFileParams].
+var _ TypedTool[MoveFileParams] = (*MoveFileTool)(nil)
+
+// MoveFileTool moves or renames a file or directory. A file it overwrites is
+// moved to the trash set with SetTrash, if any.
+type MoveFileTool struct {
+	envBinding
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *MoveFileTool) Call(params MoveFileParams) (string, error) {
+	src, err := resolveParam(params.Source)
+	if err != nil {
+		return "", err
+	}
+
+	dst, err := resolveParam(params.Destination)
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkRemovable(src); err != nil {
+		return "", err
+	}
+
+	info, err := os.Lstat(src)
+	if err != nil {
+		return "", err
+	}
+
+	if src == dst {
+		return "", fmt.Errorf("%w: %s is the source", ErrDestinationExists, displayPath(dst))
+	}
+
+	if info.IsDir() && strings.HasPrefix(dst, src+string(filepath.Separator)) {
+		return "", fmt.Errorf("%w: %s", ErrMoveIntoItself, displayPath(dst))
+	}
+
+	replaced, err := checkDestination(dst, params.Overwrite)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o750); err != nil {
+		return "", err
+	}
+
+	env := t.environment()
+
+	changes, err := env.captureMove(src, dst, info)
+	if err != nil {
+		return "", err
+	}
+
+	// Keep the file being replaced; the rename then takes its place
+	kept := false
+
+	if trash := env.trash.Load(); replaced != nil && trash != nil {
+		backup, err := backupFile(dst, replaced.Mode().Perm())
+		if err != nil {
+			return "", fmt.Errorf("keeping %s: %w", displayPath(dst), err)
+		}
+
+		if _, err := trash.keep(dst, backup); err != nil {
+			_ = os.Remove(backup)
+
+			return "", fmt.Errorf("keeping %s: %w", displayPath(dst), err)
+		}
+
+		kept = true
+	}
+
+	if err := os.Rename(src, dst); err != nil {
+		return "", err
+	}
+
+	env.recordChanges(changes)
+
+	result := fmt.Sprintf("Moved %s to %s", displayPath(src), displayPath(dst))
+	if kept {
+		result += "; the file it replaced can be restored from the trash"
+	}
+
+	return result, nil
+}
+
+// captureMove captures the changes moving src to dst makes: every file
+// moved leaves its old path and appears at its new one.
+func (e *Environment) captureMove(src, dst string, info fs.FileInfo) ([]Change, error) {
+	if e.changes.Load() == nil {
+		return nil, nil
+	}
+
+	if !info.IsDir() {
+		return e.captureChanges(src, dst), nil
+	}
+
+	files, err := regularFiles(src, info)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, 0, 2*len(files))
+
+	for _, f := range files {
+		rel, err := filepath.Rel(src, f.path)
+		if err != nil {
+			return nil, err
+		}
+
+		paths = append(paths, f.path, filepath.Join(dst, rel))
+	}
+
+	return e.captureChanges(paths...), nil
+}
+
+// resolveParam resolves a path parameter naming a directory entry, which
+// must not be empty.
+func resolveParam(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, path)
+	}
+
+	resolved, err := resolveEntry(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidPath, path)
+	}
+
+	return resolved, nil
+}
+
+// checkDestination returns the regular file that moving to dst replaces, if
+// any, and an error if the move may not replace what is there.
+func checkDestination(dst string, overwrite bool) (fs.FileInfo, error) {
+	info, err := os.Lstat(dst)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	case info.IsDir():
+		return nil, fmt.Errorf("%w: %s is a directory; give the full destination path", ErrDestinationExists, displayPath(dst))
+	case !overwrite:
+		return nil, fmt.Errorf("%w: %s; pass overwrite to replace it", ErrDestinationExists, displayPath(dst))
+	case !info.Mode().IsRegular():
+		return nil, nil
+	}
+
+	return info, nil
+}
+
+func (t *MoveFileTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "move_file",
+		Description: anthropic.String("Move or rename a file or directory. Missing parent directories of the destination " +
+			"are created. Use this instead of mv in a shell. An existing destination file is only replaced with overwrite, " +
+			"and then goes to the trash, from which the user can restore it."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"source": map[string]any{
+					"type":        "string",
+					"description": "Path of the file or directory to move",
+				},
+				"destination": map[string]any{
+					"type":        "string",
+					"description": "New path of the file or directory, including its name",
+				},
+				"overwrite": map[string]any{
+					"type":        "boolean",
+					"description": "Replace the destination if it is an existing file",
+				},
+			},
+			Required: []string{"source", "destination"},
+		},
+	}
+}
+
+// EditsFiles implements FileEditor; move_file only moves files.
+func (t *MoveFileTool) EditsFiles() bool {
+	return true
+}
+
+// Concurrency implements Concurrent. Moving a file touches its source and
+// destination; moving a directory touches every path under it, so it runs
+// exclusively.
+func (t *MoveFileTool) Concurrency(params MoveFileParams) (ConcurrencyClass, []string) {
+	if info, err := os.Lstat(params.Source); err != nil || info.IsDir() {
+		return ConcurrencyExclusive, nil
+	}
+
+	return ConcurrencyPerPath, []string{params.Source, params.Destination}
+}
@@ -0,0 +1,105 @@
+package tool
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMoveFileTool_Call(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "a.go"), "a")
+	writeTestFile(t, filepath.Join(dir, "pkg", "b.go"), "b")
+
+	tool := &MoveFileTool{}
+
+	// A file into a directory that does not exist yet
+	dst := filepath.Join(dir, "internal", "util", "a.go")
+	if _, err := tool.Call(MoveFileParams{Source: filepath.Join(dir, "a.go"), Destination: dst}); err != nil {
+		t.Fatalf("moving a file: %v", err)
+	}
+
+	if data, _ := os.ReadFile(dst); string(data) != "a" {
+		t.Errorf("moved content = %q", data)
+	}
+
+	// A whole directory
+	if _, err := tool.Call(MoveFileParams{Source: filepath.Join(dir, "pkg"), Destination: filepath.Join(dir, "lib")}); err != nil {
+		t.Fatalf("moving a directory: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "lib", "b.go")); err != nil {
+		t.Errorf("directory contents should move with it: %v", err)
+	}
+}
+
+func TestMoveFileTool_Collisions(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	writeTestFile(t, src, "new")
+	writeTestFile(t, dst, "old")
+	writeTestFile(t, filepath.Join(dir, "tree", "f"), "x")
+
+	tool := &MoveFileTool{}
+
+	tests := []struct {
+		name   string
+		params MoveFileParams
+		want   error
+	}{
+		{"existing file", MoveFileParams{Source: src, Destination: dst}, ErrDestinationExists},
+		{"existing directory", MoveFileParams{Source: src, Destination: filepath.Join(dir, "tree"), Overwrite: true}, ErrDestinationExists},
+		{"onto itself", MoveFileParams{Source: src, Destination: src}, ErrDestinationExists},
+		{"into itself", MoveFileParams{Source: filepath.Join(dir, "tree"), Destination: filepath.Join(dir, "tree", "sub")}, ErrMoveIntoItself},
+		{"missing source", MoveFileParams{Source: filepath.Join(dir, "nope"), Destination: filepath.Join(dir, "x")}, fs.ErrNotExist},
+		{"empty destination", MoveFileParams{Source: src}, ErrInvalidPath},
+	}
+
+	for _, tt := range tests {
+		if _, err := tool.Call(tt.params); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	if data, _ := os.ReadFile(dst); string(data) != "old" {
+		t.Error("a refused move should leave the destination alone")
+	}
+}
+
+func TestMoveFileTool_OverwriteKeepsReplacedFile(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	trash := useTrash(t, env)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src.txt")
+	dst := filepath.Join(dir, "dst.txt")
+	writeTestFile(t, src, "new")
+	writeTestFile(t, dst, "old")
+
+	params := MoveFileParams{Source: src, Destination: dst, Overwrite: true}
+	if _, err := bound(env, &MoveFileTool{}).Call(params); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(dst); string(data) != "new" {
+		t.Errorf("destination content = %q", data)
+	}
+
+	entries, _ := trash.List()
+	if len(entries) != 1 || entries[0].Size != int64(len("old")) {
+		t.Fatalf("the replaced file should be in the trash, got %+v", entries)
+	}
+
+	if entries[0].Mode != 0o600 {
+		t.Errorf("the replaced file's mode should be kept, got %v", entries[0].Mode)
+	}
+}
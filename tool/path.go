@@ -0,0 +1,78 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolvePath returns the canonical form of path that tools use to identify
+// a file: cleaned, made absolute against the working directory and with
+// symlinks resolved. A path that does not exist yet, such as a file about to
+// be written, has its longest existing ancestor resolved.
+func resolvePath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	rest := ""
+
+	for dir := abs; ; {
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return abs, nil
+		}
+
+		rest = filepath.Join(filepath.Base(dir), rest)
+		dir = parent
+	}
+}
+
+// displayPath returns how tools report a resolved path: relative to the
+// working directory when inside it, absolute otherwise. Reporting every file
+// one way keeps the same file from appearing under several names in the
+// conversation.
+func displayPath(path string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return path
+	}
+
+	if resolved, err := filepath.EvalSymlinks(wd); err == nil {
+		wd = resolved
+	}
+
+	rel, err := filepath.Rel(wd, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+
+	return rel
+}
+
+// DisplayPath is displayPath for callers reporting files tools changed.
+func DisplayPath(path string) string {
+	return displayPath(path)
+}
+
+// resolveEntry is resolvePath for tools acting on a directory entry itself,
+// such as deleting or moving it: the parent directory is resolved, but not
+// the final element, so a symlink is not replaced by its target.
+func resolveEntry(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := resolvePath(filepath.Dir(abs))
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, filepath.Base(abs)), nil
+}
@@ -0,0 +1,69 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolvePath(t *testing.T) {
+	t.Parallel()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(dir, "target")
+	if err := os.Mkdir(target, 0o750); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(dir, "link")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skipf("symlinks unsupported: %v", err)
+	}
+
+	tests := []struct {
+		name, path, want string
+	}{
+		{"clean", dir + "/target/./sub/../file.go", filepath.Join(target, "file.go")},
+		{"symlinked directory", filepath.Join(link, "file.go"), filepath.Join(target, "file.go")},
+		{"missing directories", filepath.Join(link, "new", "dir", "file.go"), filepath.Join(target, "new", "dir", "file.go")},
+	}
+
+	for _, tt := range tests {
+		got, err := resolvePath(tt.path)
+		if err != nil || got != tt.want {
+			t.Errorf("%s: resolvePath(%q) = %q, %v; want %q", tt.name, tt.path, got, err, tt.want)
+		}
+	}
+}
+
+func TestDisplayPath(t *testing.T) {
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Chdir(dir)
+
+	if got := displayPath(filepath.Join(dir, "pkg", "a.go")); got != filepath.Join("pkg", "a.go") {
+		t.Errorf("paths in the working directory should be relative, got %q", got)
+	}
+
+	if got := displayPath(dir); got != "." {
+		t.Errorf("displayPath(wd) = %q, want .", got)
+	}
+
+	outside := filepath.Dir(dir)
+	if got := displayPath(outside); got != outside {
+		t.Errorf("paths outside the working directory should stay absolute, got %q", got)
+	}
+
+	// Relative input is resolved against the working directory first
+	resolved, err := resolvePath("a.go")
+	if err != nil || displayPath(resolved) != "a.go" {
+		t.Errorf("resolvePath then displayPath should round-trip a relative path, got %q, %v", displayPath(resolved), err)
+	}
+}
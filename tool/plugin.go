@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"strconv"
 	"time"
 
 	"github.com/anthropics/anthropic-sdk-go"
@@ -15,6 +16,19 @@ import (
 
 const schemaTimeoutDuration = 5 * time.Second
 
+// pluginGracePeriod is how long a plugin that overran its timeout has to
+// exit after SIGTERM before it is killed.
+const pluginGracePeriod = 5 * time.Second
+
+// PluginProtocolVersion is the newest version of the plugin protocol artoo
+// speaks. A plugin declares the version it was written for as protocolVersion
+// in its schema, and is told this one in PluginProtocolEnv.
+const PluginProtocolVersion = 1
+
+// PluginProtocolEnv is the environment variable holding PluginProtocolVersion
+// when a plugin runs.
+const PluginProtocolEnv = "ARTOO_PLUGIN_PROTOCOL"
+
 var (
 	errPluginNotFound      = errors.New("plugin not found")
 	errPluginIsDirectory   = errors.New("plugin path is a directory")
@@ -22,20 +36,74 @@ var (
 	errPluginEmptyName     = errors.New("plugin has empty name in schema")
 	errPluginSchemaFailed  = errors.New("plugin schema failed")
 	errInvalidSchemaJSON   = errors.New("invalid schema JSON")
+	errPluginProtocol      = errors.New("plugin protocol version not supported")
 )
 
 // PluginSchema is the JSON structure returned by --schema.
 type PluginSchema struct {
-	Name        string         `json:"name"`
-	Description string         `json:"description"`
-	InputSchema map[string]any `json:"inputSchema"`
+	ProtocolVersion int            `json:"protocolVersion,omitempty"` // 0 for schemas that predate versioning
+	Name            string         `json:"name"`
+	Description     string         `json:"description"`
+	InputSchema     map[string]any `json:"inputSchema"`
+}
+
+// pluginShims upgrade a schema from the protocol version it is keyed by to
+// the next, so a plugin written for an older version keeps working. data is
+// the schema as the plugin printed it.
+var pluginShims = map[int]func(data []byte, schema *PluginSchema) error{
+	// Schemas from before versioning were documented with input_schema.
+	0: func(data []byte, schema *PluginSchema) error {
+		if schema.InputSchema != nil {
+			return nil
+		}
+
+		var legacy struct {
+			InputSchema map[string]any `json:"input_schema"`
+		}
+
+		if err := json.Unmarshal(data, &legacy); err != nil {
+			return err
+		}
+
+		schema.InputSchema = legacy.InputSchema
+
+		return nil
+	},
+}
+
+// upgradeSchema applies the shims that bring schema, parsed from data, from
+// its declared protocol version to PluginProtocolVersion.
+func upgradeSchema(data []byte, schema *PluginSchema) error {
+	if schema.ProtocolVersion < 0 || schema.ProtocolVersion > PluginProtocolVersion {
+		return fmt.Errorf("%w: %d (artoo supports versions up to %d)",
+			errPluginProtocol, schema.ProtocolVersion, PluginProtocolVersion)
+	}
+
+	for v := schema.ProtocolVersion; v < PluginProtocolVersion; v++ {
+		if err := pluginShims[v](data, schema); err != nil {
+			return errors.Join(errInvalidSchemaJSON, err)
+		}
+	}
+
+	schema.ProtocolVersion = PluginProtocolVersion
+
+	return nil
+}
+
+// pluginEnv is the environment plugins run in.
+func (e *Environment) pluginEnv() []string {
+	return append(e.Environ(), PluginProtocolEnv+"="+strconv.Itoa(PluginProtocolVersion))
 }
 
 // PluginTool wraps an external executable as a Tool.
 type PluginTool struct {
-	path    string        // absolute path to executable
-	schema  PluginSchema
-	timeout time.Duration // execution timeout
+	envBinding
+
+	path     string        // absolute path to executable
+	schema   PluginSchema  // upgraded to PluginProtocolVersion
+	protocol int           // protocol version the schema declared
+	timeout  time.Duration // execution timeout
+	grace    time.Duration // time to exit after SIGTERM before SIGKILL
 }
 
 // NewPluginTool creates a PluginTool by reading the schema from the executable.
@@ -58,7 +126,11 @@ func NewPluginTool(path string, timeout time.Duration) (*PluginTool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), schemaTimeoutDuration)
 	defer cancel()
 
+	// The tool is not bound to an Environment yet, so the default one applies
 	cmd := exec.CommandContext(ctx, path, "--schema")
+	cmd.Env = defaultEnvironment.pluginEnv()
+	stopGracefully(cmd, pluginGracePeriod)
+	limitCommand(cmd, defaultEnvironment.currentLimits())
 	var stdout, stderr bytes.Buffer
 	cmd.Stdout = &stdout
 	cmd.Stderr = &stderr
@@ -72,14 +144,21 @@ func NewPluginTool(path string, timeout time.Duration) (*PluginTool, error) {
 		return nil, errors.Join(errInvalidSchemaJSON, err)
 	}
 
+	protocol := schema.ProtocolVersion
+	if err := upgradeSchema(stdout.Bytes(), &schema); err != nil {
+		return nil, err
+	}
+
 	if schema.Name == "" {
 		return nil, errPluginEmptyName
 	}
 
 	return &PluginTool{
-		path:    path,
-		schema:  schema,
-		timeout: timeout,
+		path:     path,
+		schema:   schema,
+		protocol: protocol,
+		timeout:  timeout,
+		grace:    pluginGracePeriod,
 	}, nil
 }
 
@@ -88,18 +167,38 @@ func (p *PluginTool) Call(block anthropic.ToolUseBlock) *anthropic.ContentBlockP
 	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
 	defer cancel()
 
+	env := p.environment()
+
 	cmd := exec.CommandContext(ctx, p.path) //nolint:gosec
+	cmd.Env = env.pluginEnv()
 	cmd.Stdin = bytes.NewReader([]byte(block.JSON.Input.Raw()))
+	stopGracefully(cmd, p.grace)
 
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	limits := env.currentLimits()
+	limitCommand(cmd, limits)
+
+	// A plugin flooding its output is stopped rather than left to run out
+	// its timeout
+	stdout := &cappedBuffer{max: limits.OutputBytes, full: cancel}
+	stderr := &cappedBuffer{max: limits.OutputBytes, full: cancel}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
 
 	err := cmd.Run()
+	if stdout.overflow || stderr.overflow {
+		errMsg := fmt.Sprintf("Plugin error: output exceeded %d bytes, so the plugin was stopped. The output began:\n%s",
+			limits.OutputBytes, stdout.String())
+		if stderr.Len() > 0 {
+			errMsg += "\n" + stderr.String()
+		}
+
+		return new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
+	}
+
 	if err != nil {
-		errMsg := fmt.Sprintf("Plugin error: %v", err)
+		errMsg := "Plugin error: " + p.failure(ctx, cmd.ProcessState, err)
 		if stderr.Len() > 0 {
-			errMsg = fmt.Sprintf("Plugin error: %v\n%s", err, stderr.String())
+			errMsg += "\n" + stderr.String()
 		}
 
 		return new(anthropic.NewToolResultBlock(block.ID, errMsg, true))
@@ -108,6 +207,30 @@ func (p *PluginTool) Call(block anthropic.ToolUseBlock) *anthropic.ContentBlockP
 	return new(anthropic.NewToolResultBlock(block.ID, stdout.String(), false))
 }
 
+// failure describes how a plugin run that returned err ended, naming the
+// signal that ended it, if any.
+func (p *PluginTool) failure(ctx context.Context, state *os.ProcessState, err error) string {
+	signal := ""
+	if state != nil {
+		signal = exitSignal(state)
+	}
+
+	switch {
+	case ctx.Err() != nil && signal == "SIGKILL":
+		return fmt.Sprintf("timed out after %v and was killed with SIGKILL after ignoring SIGTERM for %v", p.timeout, p.grace)
+	case ctx.Err() != nil && signal != "":
+		return fmt.Sprintf("timed out after %v and was ended by %s", p.timeout, signal)
+	case ctx.Err() != nil:
+		return fmt.Sprintf("timed out after %v and exited when asked to stop (%v)", p.timeout, err)
+	case signal == "SIGXCPU":
+		return "used up its CPU time limit and was ended by SIGXCPU"
+	case signal != "":
+		return "ended by " + signal
+	default:
+		return err.Error()
+	}
+}
+
 // Param returns the anthropic tool parameter from the plugin's schema.
 func (p *PluginTool) Param() anthropic.ToolParam {
 	param := anthropic.ToolParam{
@@ -135,3 +258,14 @@ func (p *PluginTool) Param() anthropic.ToolParam {
 
 	return param
 }
+
+// Source implements Sourced: a plugin is provided by its executable.
+func (p *PluginTool) Source() (Source, string) {
+	return SourcePlugin, p.path
+}
+
+// ProtocolVersion returns the plugin protocol version the plugin's schema
+// declared, or 0 if it predates versioning.
+func (p *PluginTool) ProtocolVersion() int {
+	return p.protocol
+}
@@ -10,7 +10,6 @@ import (
 const defaultPluginTimeout = 30 * time.Second
 
 var (
-	errPluginConflict      = errors.New("plugin tool conflicts with built-in tool")
 	errReadingPluginDir    = errors.New("reading plugin directory")
 )
 
@@ -55,27 +54,3 @@ func LoadPlugins(dir string, timeout time.Duration) ([]Tool, []error) {
 
 	return tools, errs
 }
-
-// MergeTools combines built-in tools with plugin tools.
-// Returns an error if any plugin name conflicts with a built-in tool.
-func MergeTools(builtIn []Tool, plugins []Tool) ([]Tool, error) {
-	names := make(map[string]bool)
-	for _, t := range builtIn {
-		names[t.Param().Name] = true
-	}
-
-	for _, p := range plugins {
-		name := p.Param().Name
-		if names[name] {
-			return nil, errPluginConflict
-		}
-
-		names[name] = true
-	}
-
-	result := make([]Tool, 0, len(builtIn)+len(plugins))
-	result = append(result, builtIn...)
-	result = append(result, plugins...)
-
-	return result, nil
-}
@@ -1,9 +1,9 @@
 package tool
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
-	"strings"
 	"testing"
 	"time"
 )
@@ -166,9 +166,9 @@ fi
 	}
 }
 
-// TestMergeTools_NoConflict verifies that built-in and plugin tools with unique names
-// are merged successfully.
-func TestMergeTools_NoConflict(t *testing.T) {
+// TestRegisterPlugins_NoConflict verifies that plugin tools with unique names
+// are registered beside the built-in tools.
+func TestRegisterPlugins_NoConflict(t *testing.T) {
 	t.Parallel()
 
 	tmpDir := t.TempDir()
@@ -197,12 +197,16 @@ fi
 
 	plugins := []Tool{plugin}
 
-	merged, err := MergeTools(AllTools, plugins)
-	if err != nil {
-		t.Fatalf("MergeTools failed: %v", err)
+	registry := Builtins()
+	builtins := len(registry.Tools())
+
+	if err := registry.Register(plugins...); err != nil {
+		t.Fatalf("Register failed: %v", err)
 	}
 
-	expectedLen := len(AllTools) + len(plugins)
+	merged := registry.Tools()
+
+	expectedLen := builtins + len(plugins)
 	if len(merged) != expectedLen {
 		t.Errorf("Expected %d tools in merged list, got %d", expectedLen, len(merged))
 	}
@@ -223,9 +227,9 @@ fi
 	}
 }
 
-// TestMergeTools_Conflict verifies that a plugin with the same name as a built-in tool
-// returns an error.
-func TestMergeTools_Conflict(t *testing.T) {
+// TestRegisterPlugins_Conflict verifies that a plugin with the same name as a
+// built-in tool returns an error.
+func TestRegisterPlugins_Conflict(t *testing.T) {
 	t.Parallel()
 
 	tmpDir := t.TempDir()
@@ -255,13 +259,13 @@ fi
 
 	plugins := []Tool{plugin}
 
-	_, err = MergeTools(AllTools, plugins)
+	err = Builtins().Register(plugins...)
 	if err == nil {
 		t.Errorf("Expected error for conflicting tool name, got nil")
 	}
 
-	if err != nil && !strings.Contains(err.Error(), "conflicts") {
-		t.Errorf("Expected conflict error message, got: %v", err)
+	if err != nil && !errors.Is(err, ErrToolExists) {
+		t.Errorf("Expected conflict error, got: %v", err)
 	}
 }
 
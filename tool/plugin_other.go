@@ -0,0 +1,20 @@
+//go:build !unix
+
+package tool
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// stopGracefully bounds how long cmd's output is waited for once its context
+// ends. Without signals to ask it to stop, the plugin is killed.
+func stopGracefully(cmd *exec.Cmd, grace time.Duration) {
+	cmd.WaitDelay = grace
+}
+
+// exitSignal returns "", since processes here are not ended by signals.
+func exitSignal(*os.ProcessState) string {
+	return ""
+}
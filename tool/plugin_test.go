@@ -2,6 +2,7 @@ package tool
 
 import (
 	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -382,3 +383,89 @@ fi
 		t.Errorf("Expected required=['arg1'], got %v", param.InputSchema.Required)
 	}
 }
+
+// TestNewPluginTool_ProtocolVersion verifies that schemas are upgraded from
+// the version they declare, and that newer versions are refused.
+func TestNewPluginTool_ProtocolVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		schema   string
+		protocol int
+		err      error
+	}{
+		{"current", `{"protocolVersion": 1, "name": "p", "description": "d", "inputSchema": {"properties": {"a": {}}}}`, 1, nil},
+		{"unversioned", `{"name": "p", "description": "d", "inputSchema": {"properties": {"a": {}}}}`, 0, nil},
+		{"unversioned legacy key", `{"name": "p", "description": "d", "input_schema": {"properties": {"a": {}}}}`, 0, nil},
+		{"newer", `{"protocolVersion": 2, "name": "p", "description": "d"}`, 0, errPluginProtocol},
+		{"negative", `{"protocolVersion": -1, "name": "p", "description": "d"}`, 0, errPluginProtocol},
+	}
+
+	for _, tt := range tests {
+		scriptPath := filepath.Join(t.TempDir(), "p")
+		script := "#!/bin/sh\ncat <<'EOF'\n" + tt.schema + "\nEOF\n"
+
+		if err := os.WriteFile(scriptPath, []byte(script), 0o700); err != nil { //nolint:gosec // test executable
+			t.Fatalf("Failed to write test script: %v", err)
+		}
+
+		pt, err := NewPluginTool(scriptPath, 5*time.Second)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got error %v, want %v", tt.name, err, tt.err)
+
+			continue
+		}
+
+		if err != nil {
+			continue
+		}
+
+		if pt.ProtocolVersion() != tt.protocol || pt.schema.ProtocolVersion != PluginProtocolVersion {
+			t.Errorf("%s: declared version %d, schema version %d; want %d and %d",
+				tt.name, pt.ProtocolVersion(), pt.schema.ProtocolVersion, tt.protocol, PluginProtocolVersion)
+		}
+
+		if _, ok := pt.Param().InputSchema.Properties.(map[string]any)["a"]; !ok {
+			t.Errorf("%s: input schema lost: %+v", tt.name, pt.Param().InputSchema)
+		}
+	}
+}
+
+// TestPluginTool_ProtocolEnv verifies that plugins are told the protocol
+// version artoo speaks.
+func TestPluginTool_ProtocolEnv(t *testing.T) {
+	t.Parallel()
+
+	scriptPath := filepath.Join(t.TempDir(), "p")
+	script := `#!/bin/sh
+if [ "$1" = "--schema" ]; then
+    echo '{"protocolVersion": 1, "name": "p", "description": "speaks '"$ARTOO_PLUGIN_PROTOCOL"'"}'
+    exit 0
+fi
+echo "$ARTOO_PLUGIN_PROTOCOL"
+`
+
+	if err := os.WriteFile(scriptPath, []byte(script), 0o700); err != nil { //nolint:gosec // test executable
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	pt, err := NewPluginTool(scriptPath, 5*time.Second)
+	if err != nil {
+		t.Fatalf("NewPluginTool failed: %v", err)
+	}
+
+	if pt.schema.Description != "speaks 1" {
+		t.Errorf("--schema saw %q, want the protocol version", pt.schema.Description)
+	}
+
+	var block anthropic.ToolUseBlock
+	if err := json.Unmarshal([]byte(`{"id": "1", "name": "p", "input": {}, "type": "tool_use"}`), &block); err != nil {
+		t.Fatal(err)
+	}
+
+	result := pt.Call(block).OfToolResult
+	if text := result.Content[0].OfText.Text; strings.TrimSpace(text) != "1" {
+		t.Errorf("call saw %q, want the protocol version", text)
+	}
+}
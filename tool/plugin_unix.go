@@ -0,0 +1,60 @@
+//go:build unix
+
+package tool
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+)
+
+// signalNames names the signals that commonly end a plugin.
+var signalNames = map[syscall.Signal]string{
+	syscall.SIGHUP:  "SIGHUP",
+	syscall.SIGINT:  "SIGINT",
+	syscall.SIGQUIT: "SIGQUIT",
+	syscall.SIGABRT: "SIGABRT",
+	syscall.SIGKILL: "SIGKILL",
+	syscall.SIGSEGV: "SIGSEGV",
+	syscall.SIGPIPE: "SIGPIPE",
+	syscall.SIGTERM: "SIGTERM",
+	syscall.SIGXCPU: "SIGXCPU",
+}
+
+// stopGracefully runs cmd in its own process group so that, when its context
+// ends, SIGTERM reaches the plugin and any children it started, and whatever
+// is left after grace is killed with SIGKILL.
+func stopGracefully(cmd *exec.Cmd, grace time.Duration) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		group := -cmd.Process.Pid
+
+		time.AfterFunc(grace, func() { _ = syscall.Kill(group, syscall.SIGKILL) })
+
+		err := syscall.Kill(group, syscall.SIGTERM)
+		if errors.Is(err, syscall.ESRCH) {
+			return os.ErrProcessDone
+		}
+
+		return err
+	}
+	// Stop waiting for output held open by a process that left the group.
+	cmd.WaitDelay = 2 * grace
+}
+
+// exitSignal names the signal that ended a process, or returns "" if it
+// exited.
+func exitSignal(state *os.ProcessState) string {
+	status, ok := state.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() {
+		return ""
+	}
+
+	if name, ok := signalNames[status.Signal()]; ok {
+		return name
+	}
+
+	return status.Signal().String()
+}
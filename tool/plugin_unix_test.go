@@ -0,0 +1,120 @@
+//go:build unix
+
+package tool
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// slowPlugin writes a plugin that runs script when called, returning a
+// PluginTool for it with a short timeout and grace period.
+func slowPlugin(t *testing.T, script string) *PluginTool {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "slow")
+	content := "#!/bin/bash\n" + `if [ "$1" = "--schema" ]; then echo '{"protocolVersion": 1, "name": "slow"}'; exit 0; fi` + "\n" + script
+
+	if err := os.WriteFile(path, []byte(content), 0o700); err != nil { //nolint:gosec // test executable
+		t.Fatalf("Failed to write test script: %v", err)
+	}
+
+	pt, err := NewPluginTool(path, 200*time.Millisecond)
+	if err != nil {
+		t.Fatalf("NewPluginTool failed: %v", err)
+	}
+
+	pt.grace = 300 * time.Millisecond
+
+	return pt
+}
+
+// callText calls pt and returns its result's text and whether it is an error.
+func callText(t *testing.T, pt *PluginTool) (string, bool) {
+	t.Helper()
+
+	result := pt.Call(anthropic.ToolUseBlock{ID: "1", Name: "slow", Input: json.RawMessage(`{}`)}).OfToolResult
+
+	return result.Content[0].OfText.Text, result.IsError.Value
+}
+
+// TestPluginTool_Call_TerminateCleansUp verifies that a plugin that overruns
+// its timeout gets SIGTERM, and the chance to clean up, before SIGKILL.
+func TestPluginTool_Call_TerminateCleansUp(t *testing.T) {
+	t.Parallel()
+
+	pt := slowPlugin(t, `trap 'touch "$0.cleaned"; exit 3' TERM
+while true; do sleep 0.05; done
+`)
+
+	text, isError := callText(t, pt)
+	if !isError || !strings.Contains(text, "timed out after 200ms and exited when asked to stop") {
+		t.Errorf("result = %q, want a timeout the plugin exited from", text)
+	}
+
+	if _, err := os.Stat(pt.path + ".cleaned"); err != nil {
+		t.Errorf("plugin did not clean up after SIGTERM: %v", err)
+	}
+}
+
+// TestPluginTool_Call_KillsIgnoringGroup verifies that a plugin and its
+// children that ignore SIGTERM are killed once the grace period ends.
+func TestPluginTool_Call_KillsIgnoringGroup(t *testing.T) {
+	t.Parallel()
+
+	pt := slowPlugin(t, `trap '' TERM
+sleep 30 &
+echo $! > "$0.child"
+wait
+`)
+
+	start := time.Now()
+
+	text, isError := callText(t, pt)
+	if !isError || !strings.Contains(text, "killed with SIGKILL after ignoring SIGTERM for 300ms") {
+		t.Errorf("result = %q, want the plugin killed after the grace period", text)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("call took %v, want it ended soon after the grace period", elapsed)
+	}
+
+	data, err := os.ReadFile(pt.path + ".child")
+	if err != nil {
+		t.Fatalf("reading child pid: %v", err)
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		t.Fatalf("child pid %q: %v", data, err)
+	}
+
+	for deadline := time.Now().Add(2 * time.Second); syscall.Kill(pid, 0) == nil; time.Sleep(20 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			_ = syscall.Kill(pid, syscall.SIGKILL)
+
+			t.Fatal("the plugin's child survived")
+		}
+	}
+}
+
+// TestPluginTool_Call_ReportsSignal verifies that a plugin ended by a signal
+// names it.
+func TestPluginTool_Call_ReportsSignal(t *testing.T) {
+	t.Parallel()
+
+	pt := slowPlugin(t, `kill -SEGV $$
+`)
+
+	if text, isError := callText(t, pt); !isError || !strings.HasPrefix(text, "Plugin error: ended by SIGSEGV") {
+		t.Errorf("result = %q, want the signal named", text)
+	}
+}
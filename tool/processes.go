@@ -0,0 +1,165 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// ErrUnknownProcess is returned when killing a process the agent did not start.
+var ErrUnknownProcess = errors.New("not a process started by artoo")
+
+// trackedProcess is a running child process started by a tool.
+type trackedProcess struct {
+	owner   string // tool that started it
+	command string
+	started time.Time
+	cmd     *exec.Cmd
+}
+
+// processRegistry records the child processes tools start, so they can be
+// listed and killed, and are not left behind when artoo exits.
+type processRegistry struct {
+	mu    sync.Mutex
+	procs map[int]*trackedProcess // by pid
+}
+
+// track records a started command, described as command, until untrack is
+// called with its pid.
+func (r *processRegistry) track(owner, command string, cmd *exec.Cmd) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.procs == nil {
+		r.procs = make(map[int]*trackedProcess)
+	}
+
+	r.procs[cmd.Process.Pid] = &trackedProcess{
+		owner:   owner,
+		command: command,
+		started: time.Now(),
+		cmd:     cmd,
+	}
+}
+
+// untrack forgets a process once it has exited.
+func (r *processRegistry) untrack(pid int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.procs, pid)
+}
+
+// kill kills a tracked process.
+func (r *processRegistry) kill(pid int) error {
+	r.mu.Lock()
+	p, ok := r.procs[pid]
+	r.mu.Unlock()
+
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownProcess, pid)
+	}
+
+	return p.cmd.Process.Kill()
+}
+
+// StopProcesses kills every process the tools of e started that is still
+// running. Call it before exiting so that no interpreter or command is
+// orphaned.
+func (e *Environment) StopProcesses() {
+	e.processes.mu.Lock()
+	defer e.processes.mu.Unlock()
+
+	for _, p := range e.processes.procs {
+		_ = p.cmd.Process.Kill()
+	}
+}
+
+// ProcessesParams defines the parameters for the processes tool.
+type ProcessesParams struct {
+	Action string `json:"action"`        // "list" or "kill"
+	PID    int    `json:"pid,omitempty"` // Process to kill
+}
+
+// Ensure ProcessesTool implements TypedTool[ProcessesParams].
+var _ TypedTool[ProcessesParams] = (*ProcessesTool)(nil)
+
+// ProcessesTool lists the running processes the tools of its Environment
+// started and kills them on request. Other processes, including those of
+// other agents, are neither shown nor killable.
+type ProcessesTool struct {
+	envBinding
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *ProcessesTool) Call(params ProcessesParams) (string, error) {
+	switch params.Action {
+	case "list":
+		return t.environment().processes.list(time.Now()), nil
+	case "kill":
+		if err := t.environment().processes.kill(params.PID); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("Killed process %d", params.PID), nil
+	}
+
+	return "", fmt.Errorf("unknown action %q (want list or kill)", params.Action)
+}
+
+// list formats the tracked processes, oldest first.
+func (r *processRegistry) list(now time.Time) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.procs) == 0 {
+		return "No processes started by artoo are running."
+	}
+
+	pids := make([]int, 0, len(r.procs))
+	for pid := range r.procs {
+		pids = append(pids, pid)
+	}
+
+	slices.SortFunc(pids, func(a, b int) int {
+		return r.procs[a].started.Compare(r.procs[b].started)
+	})
+
+	var b strings.Builder
+	b.WriteString("PID | tool | running for | command")
+
+	for _, pid := range pids {
+		p := r.procs[pid]
+		fmt.Fprintf(&b, "\n%d | %s | %s | %s", pid, p.owner, now.Sub(p.started).Round(time.Second), p.command)
+	}
+
+	return b.String()
+}
+
+func (t *ProcessesTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "processes",
+		Description: anthropic.String("List the processes artoo's tools started that are still running (such as " +
+			"the python interpreter or docker commands), or kill one of them by PID. They are also stopped when " +
+			"artoo exits."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type": "string",
+					"enum": []string{"list", "kill"},
+				},
+				"pid": map[string]any{
+					"type":        "integer",
+					"description": "Process to kill, as shown by list (for kill)",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
@@ -0,0 +1,87 @@
+package tool
+
+import (
+	"errors"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestProcessesTool_ListAndKill(t *testing.T) {
+	t.Parallel()
+
+	cmd := exec.Command("sleep", "30")
+	if err := cmd.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+
+	env := NewEnvironment()
+	pid := cmd.Process.Pid
+	env.processes.track("test", "sleep 30", cmd)
+
+	done := make(chan error, 1)
+
+	go func() {
+		err := cmd.Wait()
+		env.processes.untrack(pid)
+		done <- err
+	}()
+
+	procs := &ProcessesTool{}
+	procs.SetEnvironment(env)
+
+	// Another agent's tool sees none of env's processes
+	if out, _ := (&ProcessesTool{}).Call(ProcessesParams{Action: "list"}); strings.Contains(out, strconv.Itoa(pid)) {
+		t.Errorf("another environment listed the process: %q", out)
+	}
+
+	if _, err := (&ProcessesTool{}).Call(ProcessesParams{Action: "kill", PID: pid}); !errors.Is(err, ErrUnknownProcess) {
+		t.Errorf("another environment killing the process: got %v, want %v", err, ErrUnknownProcess)
+	}
+
+	out, err := procs.Call(ProcessesParams{Action: "list"})
+	if err != nil || !strings.Contains(out, strconv.Itoa(pid)+" | test | ") || !strings.Contains(out, "| sleep 30") {
+		t.Errorf("list = %q, %v", out, err)
+	}
+
+	if _, err := procs.Call(ProcessesParams{Action: "kill", PID: pid}); err != nil {
+		t.Fatalf("kill: %v", err)
+	}
+
+	if err := <-done; err == nil {
+		t.Error("expected the process to be killed")
+	}
+
+	if _, err := procs.Call(ProcessesParams{Action: "kill", PID: pid}); !errors.Is(err, ErrUnknownProcess) {
+		t.Errorf("killing an exited process: got %v, want %v", err, ErrUnknownProcess)
+	}
+}
+
+func TestEnvironment_StopProcesses(t *testing.T) {
+	t.Parallel()
+
+	mine, other := exec.Command("sleep", "30"), exec.Command("sleep", "30")
+	for _, cmd := range []*exec.Cmd{mine, other} {
+		if err := cmd.Start(); err != nil {
+			t.Skipf("cannot start sleep: %v", err)
+		}
+	}
+
+	t.Cleanup(func() { _ = other.Process.Kill() })
+
+	env, otherEnv := NewEnvironment(), NewEnvironment()
+	env.processes.track("test", "sleep 30", mine)
+	otherEnv.processes.track("test", "sleep 30", other)
+
+	env.StopProcesses()
+
+	if err := mine.Wait(); err == nil {
+		t.Error("expected the environment's process to be killed")
+	}
+
+	if other.ProcessState != nil || other.Process.Signal(syscall.Signal(0)) != nil {
+		t.Error("another environment's process should keep running")
+	}
+}
@@ -0,0 +1,286 @@
+package tool
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// pythonDefaultTimeout and pythonMaxTimeout bound a snippet, in seconds.
+	pythonDefaultTimeout = 30
+	pythonMaxTimeout     = 300
+
+	// pythonMaxOutput caps the output returned to the model.
+	pythonMaxOutput = 10_000
+)
+
+// pythonDriver runs snippets sent as JSON lines on stdin in one namespace
+// and answers on fd 3, so output the snippets write to the real stdout
+// cannot corrupt the protocol. Like the interactive interpreter, it prints
+// the repr of a trailing expression.
+const pythonDriver = `
+import ast, contextlib, io, json, os, sys, traceback
+proto = os.fdopen(3, "w")
+namespace = {"__name__": "__main__"}
+for line in sys.stdin:
+    code = json.loads(line)["code"]
+    out, error = io.StringIO(), None
+    try:
+        tree = ast.parse(code, "<cell>", "exec")
+        last = None
+        if tree.body and isinstance(tree.body[-1], ast.Expr):
+            last = ast.Expression(tree.body.pop().value)
+        with contextlib.redirect_stdout(out), contextlib.redirect_stderr(out):
+            exec(compile(tree, "<cell>", "exec"), namespace)
+            if last is not None:
+                value = eval(compile(last, "<cell>", "eval"), namespace)
+                if value is not None:
+                    print(repr(value))
+    except BaseException:
+        error = traceback.format_exc()
+    proto.write(json.dumps({"output": out.getvalue(), "error": error}) + "\n")
+    proto.flush()
+`
+
+// ErrNoCode is returned when the python tool is called without code.
+var ErrNoCode = errors.New("code is required")
+
+// PythonParams defines the parameters for the python tool.
+type PythonParams struct {
+	Code    string `json:"code,omitempty"`    // Snippet to run
+	Reset   bool   `json:"reset,omitempty"`   // Restart the interpreter first
+	Timeout int    `json:"timeout,omitempty"` // Timeout in seconds
+}
+
+// Ensure PythonTool implements TypedTool[PythonParams].
+var _ TypedTool[PythonParams] = (*PythonTool)(nil)
+
+// PythonTool runs snippets in a persistent Python interpreter, so variables,
+// imports and loaded data carry over between calls for the rest of the
+// session. The interpreter starts on first use.
+type PythonTool struct {
+	envBinding
+
+	python string // python executable
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	out   *bufio.Reader
+}
+
+// pythonResult is the driver's answer to one snippet.
+type pythonResult struct {
+	Output string  `json:"output"`
+	Error  *string `json:"error"`
+}
+
+// PythonTools returns the python tool, or nil if python3 is not installed.
+func PythonTools() []Tool {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		return nil
+	}
+
+	return []Tool{WrapTypedTool(&PythonTool{python: python})}
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *PythonTool) Call(params PythonParams) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if params.Reset {
+		t.stop()
+	}
+
+	if params.Code == "" {
+		if params.Reset {
+			return "Interpreter reset.", nil
+		}
+
+		return "", ErrNoCode
+	}
+
+	if t.cmd == nil {
+		if err := t.start(); err != nil {
+			return "", fmt.Errorf("starting python: %w", err)
+		}
+	}
+
+	request, err := json.Marshal(map[string]string{"code": params.Code})
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := t.stdin.Write(append(request, '\n')); err != nil {
+		t.stop()
+
+		return "", fmt.Errorf("python exited; its state was lost: %w", err)
+	}
+
+	timeout := params.Timeout
+	if timeout <= 0 {
+		timeout = pythonDefaultTimeout
+	}
+
+	timeout = min(timeout, pythonMaxTimeout)
+
+	result, err := t.read(time.Duration(timeout) * time.Second)
+	if err != nil {
+		t.stop()
+
+		return "", err
+	}
+
+	output := result.Output
+	if len(output) > pythonMaxOutput {
+		// Cut at a rune boundary so the output stays valid UTF-8
+		cut := pythonMaxOutput
+		for cut > 0 && !utf8.RuneStart(output[cut]) {
+			cut--
+		}
+
+		output = output[:cut] + fmt.Sprintf("\n… (output truncated to %d of %d bytes)", cut, len(result.Output))
+	}
+
+	if result.Error != nil {
+		return "", fmt.Errorf("%s%s", output, *result.Error)
+	}
+
+	if output == "" {
+		return "OK (no output)", nil
+	}
+
+	return strings.TrimSuffix(output, "\n"), nil
+}
+
+// read waits for the driver's answer to the snippet just sent.
+func (t *PythonTool) read(timeout time.Duration) (pythonResult, error) {
+	type answer struct {
+		line []byte
+		err  error
+	}
+
+	done := make(chan answer, 1)
+
+	go func(out *bufio.Reader) {
+		line, err := out.ReadBytes('\n')
+		done <- answer{line, err}
+	}(t.out)
+
+	select {
+	case a := <-done:
+		if a.err != nil {
+			return pythonResult{}, fmt.Errorf("python exited; its state was lost: %w", a.err)
+		}
+
+		var result pythonResult
+		if err := json.Unmarshal(a.line, &result); err != nil {
+			return pythonResult{}, fmt.Errorf("reading python result: %w", err)
+		}
+
+		return result, nil
+	case <-time.After(timeout):
+		return pythonResult{}, fmt.Errorf("timed out after %s; the interpreter was restarted and its state lost", timeout)
+	}
+}
+
+// start launches the interpreter.
+func (t *PythonTool) start() error {
+	protoRead, protoWrite, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command(t.python, "-u", "-c", pythonDriver) //nolint:gosec // fixed driver script
+	cmd.ExtraFiles = []*os.File{protoWrite}
+	cmd.Dir = t.environment().DefaultRoot()
+	cmd.Env = t.environment().Environ()
+	limitCommand(cmd, t.environment().currentLimits())
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		_ = protoRead.Close()
+		_ = protoWrite.Close()
+
+		return err
+	}
+
+	err = cmd.Start()
+
+	// The child holds its own copy of the write end
+	_ = protoWrite.Close()
+
+	if err != nil {
+		_ = protoRead.Close()
+
+		return err
+	}
+
+	t.cmd, t.stdin, t.out = cmd, stdin, bufio.NewReader(protoRead)
+	procs := &t.environment().processes
+	procs.track("python", t.python+" (interpreter)", cmd)
+
+	go func() {
+		_ = cmd.Wait()
+		_ = protoRead.Close()
+		procs.untrack(cmd.Process.Pid)
+	}()
+
+	return nil
+}
+
+// stop kills the interpreter, discarding its state.
+func (t *PythonTool) stop() {
+	if t.cmd == nil {
+		return
+	}
+
+	_ = t.stdin.Close()
+	_ = t.cmd.Process.Kill()
+	t.cmd, t.stdin, t.out = nil, nil, nil
+}
+
+// RequiresApproval implements ApprovalRequired; snippets can do anything the
+// user can, like a shell.
+func (t *PythonTool) RequiresApproval() bool {
+	return true
+}
+
+func (t *PythonTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "python",
+		Description: anthropic.String("Run Python code in a persistent interpreter: variables, imports and loaded " +
+			"data are kept between calls, so explore data step by step instead of rewriting a script. " +
+			"Printed output is returned, and so is the value of a trailing expression. Errors return the traceback. " +
+			"Set reset to start over with an empty namespace."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"code": map[string]any{
+					"type":        "string",
+					"description": "Python code to run",
+				},
+				"reset": map[string]any{
+					"type":        "boolean",
+					"description": "Restart the interpreter before running code, discarding all state",
+				},
+				"timeout": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Timeout in seconds (default %d, at most %d); on timeout the interpreter restarts", pythonDefaultTimeout, pythonMaxTimeout),
+				},
+			},
+		},
+	}
+}
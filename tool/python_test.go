@@ -0,0 +1,123 @@
+package tool
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func newTestPython(t *testing.T) *PythonTool {
+	t.Helper()
+
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not installed")
+	}
+
+	py := &PythonTool{python: python}
+	t.Cleanup(func() { py.Call(PythonParams{Reset: true}) }) //nolint:errcheck // stops the interpreter
+
+	return py
+}
+
+func TestPythonTool_RequiresApproval(t *testing.T) {
+	t.Parallel()
+
+	if !RequiresApproval(WrapTypedTool(&PythonTool{})) {
+		t.Error("python should require approval")
+	}
+}
+
+func TestPythonTool_KeepsState(t *testing.T) {
+	t.Parallel()
+
+	py := newTestPython(t)
+
+	steps := []struct {
+		code string
+		want string
+	}{
+		{"import json\ndata = json.loads('[3, 1, 2]')", "OK (no output)"},
+		{"print('sorted:', sorted(data))\nlen(data)", "sorted: [1, 2, 3]\n3"},
+		{"data.append(4)\nsum(data)", "10"},
+	}
+
+	for _, step := range steps {
+		got, err := py.Call(PythonParams{Code: step.code})
+		if err != nil || got != step.want {
+			t.Errorf("%q = %q, %v; want %q", step.code, got, err, step.want)
+		}
+	}
+
+	if _, err := py.Call(PythonParams{Code: "1 / 0"}); err == nil || !strings.Contains(err.Error(), "ZeroDivisionError") {
+		t.Errorf("expected traceback, got %v", err)
+	}
+
+	// An error does not lose state, but reset does
+	if got, err := py.Call(PythonParams{Code: "data"}); err != nil || got != "[3, 1, 2, 4]" {
+		t.Errorf("data after error = %q, %v", got, err)
+	}
+
+	if _, err := py.Call(PythonParams{Code: "data", Reset: true}); err == nil || !strings.Contains(err.Error(), "NameError") {
+		t.Errorf("expected NameError after reset, got %v", err)
+	}
+}
+
+func TestPythonTool_Timeout(t *testing.T) {
+	t.Parallel()
+
+	py := newTestPython(t)
+
+	py.Call(PythonParams{Code: "x = 1"}) //nolint:errcheck // sets state to lose
+
+	if _, err := py.Call(PythonParams{Code: "import time\ntime.sleep(10)", Timeout: 1}); err == nil {
+		t.Fatal("expected timeout")
+	}
+
+	if _, err := py.Call(PythonParams{Code: "x"}); err == nil {
+		t.Error("state should be lost after a timeout")
+	}
+
+	if _, err := py.Call(PythonParams{}); !errors.Is(err, ErrNoCode) {
+		t.Errorf("got %v, want %v", err, ErrNoCode)
+	}
+}
+
+func TestPythonTool_TruncatesAtRuneBoundary(t *testing.T) {
+	t.Parallel()
+
+	py := newTestPython(t)
+
+	// Each "é" is two bytes, so an odd offset puts one across the limit
+	got, err := py.Call(PythonParams{Code: "print('a' + 'é' * 10000)"})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if !utf8.ValidString(got) || !strings.Contains(got, "output truncated") {
+		t.Errorf("expected valid, truncated output, got %d bytes ending %q", len(got), got[len(got)-80:])
+	}
+}
+
+func TestPythonTool_RunsInDefaultRoot(t *testing.T) {
+	t.Parallel()
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatalf("EvalSymlinks: %v", err)
+	}
+
+	env := NewEnvironment()
+	env.SetDefaultRoot(dir)
+
+	py := newTestPython(t)
+	py.SetEnvironment(env)
+
+	got, err := py.Call(PythonParams{Code: "import os\nos.getcwd()"})
+	if err != nil || got != "'"+dir+"'" {
+		t.Errorf("cwd = %q, %v; want %q", got, err, dir)
+	}
+}
@@ -65,3 +65,8 @@ func (t *RandomNumberTool) Param() anthropic.ToolParam {
 		},
 	}
 }
+
+// ReadOnly implements ReadOnly; RandomNumberTool never modifies the filesystem.
+func (t *RandomNumberTool) ReadOnly() bool {
+	return true
+}
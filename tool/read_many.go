@@ -0,0 +1,188 @@
+package tool
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// readManyMaxFiles bounds the files read in one call.
+	readManyMaxFiles = 50
+
+	// readManyDefaultBytes and readManyMaxBytes bound the bytes shown per file.
+	readManyDefaultBytes = 20_000
+	readManyMaxBytes     = 100_000
+
+	// readManyTotalBytes bounds the content returned by one call.
+	readManyTotalBytes = 200_000
+
+	// binarySniffBytes is how much of a file is checked for NUL bytes.
+	binarySniffBytes = 8_000
+)
+
+// ErrNoPaths is returned when read_many is called without any paths.
+var ErrNoPaths = errors.New("no paths to read")
+
+// ReadManyParams defines the parameters for the read_many tool.
+type ReadManyParams struct {
+	Paths    []string `json:"paths"`
+	MaxBytes int      `json:"max_bytes,omitempty"` // Per file; default readManyDefaultBytes
+}
+
+// Ensure ReadManyTool implements TypedTool[ReadManyParams].
+var _ TypedTool[ReadManyParams] = (*ReadManyTool)(nil)
+
+// ReadManyTool reads several files in one call, so exploring a codebase does
+// not take a round trip per file. Each file is truncated independently and a
+// file that cannot be read is reported in place without failing the others.
+type ReadManyTool struct {
+	envBinding
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *ReadManyTool) Call(params ReadManyParams) (string, error) {
+	if len(params.Paths) == 0 {
+		return "", ErrNoPaths
+	}
+
+	if len(params.Paths) > readManyMaxFiles {
+		return "", fmt.Errorf("%d paths requested; read at most %d per call", len(params.Paths), readManyMaxFiles)
+	}
+
+	perFile := params.MaxBytes
+	if perFile <= 0 {
+		perFile = readManyDefaultBytes
+	}
+
+	perFile = min(perFile, readManyMaxBytes)
+
+	var b strings.Builder
+
+	remaining := readManyTotalBytes
+
+	for i, path := range params.Paths {
+		if resolved, err := resolvePath(path); err == nil {
+			path = displayPath(resolved)
+		}
+
+		if i > 0 {
+			b.WriteString("\n\n")
+		}
+
+		if remaining <= 0 {
+			fmt.Fprintf(&b, "==> %s <==\n(not read: the %d byte limit for one call was reached; read the rest in another call)", path, readManyTotalBytes)
+
+			continue
+		}
+
+		limit := min(perFile, remaining)
+		remaining -= t.environment().readOne(&b, path, limit)
+	}
+
+	return b.String(), nil
+}
+
+// readOne writes path's content, up to limit bytes, under a header and
+// returns the number of content bytes written. Content from outside the
+// workspace is delimited as untrusted.
+func (e *Environment) readOne(b *strings.Builder, path string, limit int) int {
+	fmt.Fprintf(b, "==> %s <==\n", path)
+
+	if e.secretPath(path) {
+		b.WriteString(secretNotice)
+
+		return 0
+	}
+
+	f, err := os.Open(path) //nolint:gosec // reading the files the model asked for
+	if err != nil {
+		fmt.Fprintf(b, "(error: %v)", err)
+
+		return 0
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		fmt.Fprintf(b, "(error: %v)", err)
+
+		return 0
+	}
+
+	if info.IsDir() {
+		b.WriteString("(error: is a directory; use list to see its files)")
+
+		return 0
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, int64(limit)))
+	if err != nil {
+		fmt.Fprintf(b, "(error: %v)", err)
+
+		return 0
+	}
+
+	if bytes.IndexByte(data[:min(len(data), binarySniffBytes)], 0) >= 0 {
+		fmt.Fprintf(b, "(binary file, %d bytes, not shown)", info.Size())
+
+		return 0
+	}
+
+	if resolved, err := resolvePath(path); err != nil || e.outsideWorkspace(resolved) {
+		b.WriteString(e.wrapUntrusted(path, string(data)))
+	} else {
+		b.Write(data)
+	}
+
+	if size := info.Size(); size > int64(len(data)) {
+		fmt.Fprintf(b, "\n… (truncated: showing %d of %d bytes)", len(data), size)
+	}
+
+	return len(data)
+}
+
+func (t *ReadManyTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "read_many",
+		Description: anthropic.String(fmt.Sprintf("Read several files in one call, each under a \"==> path <==\" "+
+			"header. Prefer this to reading files one at a time when exploring. Long files are truncated (by "+
+			"default to %d bytes each); a file that cannot be read is reported in its place. At most %d files "+
+			"and %d bytes in total per call.", readManyDefaultBytes, readManyMaxFiles, readManyTotalBytes)),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"paths": map[string]any{
+					"type":        "array",
+					"items":       map[string]any{"type": "string"},
+					"description": "Files to read",
+				},
+				"max_bytes": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Bytes to show per file (default %d, at most %d)", readManyDefaultBytes, readManyMaxBytes),
+				},
+			},
+			Required: []string{"paths"},
+		},
+	}
+}
+
+// ReadOnly implements ReadOnly; read_many only reads files.
+func (t *ReadManyTool) ReadOnly() bool {
+	return true
+}
+
+// Idempotent implements Idempotent; the result depends only on the files.
+func (t *ReadManyTool) Idempotent() bool {
+	return true
+}
+
+// Concurrency implements Concurrent: reads wait for earlier writes to the
+// same files, so they see them.
+func (t *ReadManyTool) Concurrency(params ReadManyParams) (ConcurrencyClass, []string) {
+	return ConcurrencyPerPath, params.Paths
+}
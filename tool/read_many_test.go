@@ -0,0 +1,53 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadManyTool_Call(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.go":    "package a\n",
+		"big.txt": strings.Repeat("x", 100),
+		"bin":     "ELF\x00\x01",
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	out, err := (&ReadManyTool{}).Call(ReadManyParams{
+		Paths:    []string{path("a.go"), path("big.txt"), path("missing.go"), path("bin"), dir},
+		MaxBytes: 10,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"==> " + path("a.go") + " <==\n<untrusted-content source=",
+		"\npackage a\n</untrusted-content>",
+		"\nxxxxxxxxxx\n</untrusted-content>\n… (truncated: showing 10 of 100 bytes)",
+		"==> " + path("missing.go") + " <==\n(error: ",
+		"(binary file, 5 bytes, not shown)",
+		"(error: is a directory",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if _, err := (&ReadManyTool{}).Call(ReadManyParams{}); !errors.Is(err, ErrNoPaths) {
+		t.Errorf("expected ErrNoPaths, got %v", err)
+	}
+}
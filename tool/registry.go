@@ -0,0 +1,158 @@
+package tool
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Source is where a tool comes from.
+type Source string
+
+const (
+	SourceBuiltin Source = "builtin" // ships with artoo
+	SourcePlugin  Source = "plugin"  // an executable in the plugin directory
+)
+
+// ErrToolExists is returned when registering a tool under a name that is
+// already taken.
+var ErrToolExists = errors.New("a tool with this name is already registered")
+
+// Sourced is optionally implemented by tools that do not ship with artoo.
+type Sourced interface {
+	// Source returns where the tool comes from and what provides it, such
+	// as a plugin's path.
+	Source() (Source, string)
+}
+
+// SourceOf returns where t comes from and what provides it. Tools that do
+// not say are built in.
+func SourceOf(t Tool) (Source, string) {
+	if s, ok := t.(Sourced); ok {
+		return s.Source()
+	}
+
+	return SourceBuiltin, ""
+}
+
+// Info describes a registered tool.
+type Info struct {
+	Name        string
+	Description string // first line of the tool's description
+	Source      Source
+	Origin      string // what provides the tool, e.g. a plugin's path ("" for built-in tools)
+}
+
+// InfoOf describes t.
+func InfoOf(t Tool) Info {
+	param := t.Param()
+	source, origin := SourceOf(t)
+	description, _, _ := strings.Cut(strings.TrimSpace(param.Description.Value), "\n")
+
+	return Info{Name: param.Name, Description: description, Source: source, Origin: origin}
+}
+
+// Registry holds tools by name, in the order they were registered. It is
+// safe for concurrent use. A nil Registry is empty and cannot be registered
+// with.
+type Registry struct {
+	mu     sync.RWMutex
+	tools  []Tool
+	byName map[string]Tool
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{byName: map[string]Tool{}}
+}
+
+// Builtins returns a new registry holding the tools that ship with artoo and
+// need no configuration or optional programs.
+func Builtins() *Registry {
+	r := NewRegistry()
+
+	_ = r.Register(
+		WrapTypedTool(&RandomNumberTool{}),
+		WrapTypedTool(&GrepTool{}),
+		WrapTypedTool(&LsTool{}),
+		WrapTypedTool(&WorkspaceStatsTool{}),
+		WrapTypedTool(&ReadManyTool{}),
+		WrapTypedTool(&WriteFilesTool{}),
+		WrapTypedTool(&MoveFileTool{}),
+		WrapTypedTool(&DeleteFileTool{}),
+		WrapTypedTool(&CalculateTool{}),
+		WrapTypedTool(&TreeSnapshotTool{}),
+		WrapTypedTool(&ProcessesTool{}),
+	) // the names are distinct
+
+	return r
+}
+
+// Register adds tools to the registry. A tool whose name is taken is not
+// added, and the first such name is reported with ErrToolExists; the other
+// tools are still added.
+func (r *Registry) Register(tools ...Tool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var err error
+
+	for _, t := range tools {
+		name := t.Param().Name
+		if _, taken := r.byName[name]; taken {
+			if err == nil {
+				err = fmt.Errorf("%w: %s", ErrToolExists, name)
+			}
+
+			continue
+		}
+
+		r.byName[name] = t
+		r.tools = append(r.tools, t)
+	}
+
+	return err
+}
+
+// Lookup returns the tool registered as name.
+func (r *Registry) Lookup(name string) (Tool, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, ok := r.byName[name]
+
+	return t, ok
+}
+
+// Tools returns the registered tools in the order they were registered.
+func (r *Registry) Tools() []Tool {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Clone(r.tools)
+}
+
+// List describes the registered tools, sorted by name.
+func (r *Registry) List() []Info {
+	tools := r.Tools()
+
+	infos := make([]Info, len(tools))
+	for i, t := range tools {
+		infos[i] = InfoOf(t)
+	}
+
+	slices.SortFunc(infos, func(a, b Info) int { return cmp.Compare(a.Name, b.Name) })
+
+	return infos
+}
@@ -0,0 +1,57 @@
+package tool
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	t.Parallel()
+
+	r := NewRegistry()
+	grep, ls := WrapTypedTool(&GrepTool{}), WrapTypedTool(&LsTool{})
+	plugin := &PluginTool{path: "/plugins/deploy", schema: PluginSchema{Name: "deploy", Description: "Deploy the app.\nMore."}}
+
+	if err := r.Register(ls, grep, plugin); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := r.Register(WrapTypedTool(&GrepTool{})); !errors.Is(err, ErrToolExists) {
+		t.Errorf("registering grep twice: got %v, want %v", err, ErrToolExists)
+	}
+
+	if got, ok := r.Lookup("grep"); !ok || got != grep {
+		t.Errorf("Lookup(grep) = %v, %v; want the first grep", got, ok)
+	}
+
+	if _, ok := r.Lookup("missing"); ok {
+		t.Error("Lookup(missing) should fail")
+	}
+
+	if tools := r.Tools(); !slices.Equal(tools, []Tool{ls, grep, plugin}) {
+		t.Errorf("Tools() = %v, want registration order", tools)
+	}
+
+	list := r.List()
+	if len(list) != 3 || list[0].Name != "deploy" || list[1].Name != "grep" || list[2].Name != "list" {
+		t.Fatalf("List() = %+v, want deploy, grep and list by name", list)
+	}
+
+	if want := (Info{Name: "deploy", Description: "Deploy the app.", Source: SourcePlugin, Origin: "/plugins/deploy"}); list[0] != want {
+		t.Errorf("plugin info = %+v, want %+v", list[0], want)
+	}
+
+	if list[1].Source != SourceBuiltin || list[1].Origin != "" {
+		t.Errorf("grep info = %+v, want a built-in tool", list[1])
+	}
+}
+
+func TestRegistry_Nil(t *testing.T) {
+	t.Parallel()
+
+	var r *Registry
+	if _, ok := r.Lookup("grep"); ok || len(r.Tools()) != 0 || len(r.List()) != 0 {
+		t.Error("a nil registry should be empty")
+	}
+}
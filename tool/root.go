@@ -0,0 +1,45 @@
+package tool
+
+// SetDefaultRoot sets the directory grep and list operate on when the model
+// omits the path parameter. An empty dir restores the current working directory.
+func (e *Environment) SetDefaultRoot(dir string) {
+	if dir == "" {
+		e.root.Store(nil)
+
+		return
+	}
+
+	e.root.Store(&dir)
+}
+
+// searchPath returns path, or the default root if path is unset or empty.
+func (e *Environment) searchPath(path *string) string {
+	if path != nil && *path != "" {
+		return *path
+	}
+
+	return e.defaultSearchPath()
+}
+
+// DefaultRoot returns the directory set with SetDefaultRoot, or "" for the
+// current working directory.
+func (e *Environment) DefaultRoot() string {
+	if e == nil {
+		return ""
+	}
+
+	if dir := e.root.Load(); dir != nil {
+		return *dir
+	}
+
+	return ""
+}
+
+// defaultSearchPath returns the configured default root, or "." if unset.
+func (e *Environment) defaultSearchPath() string {
+	if dir := e.DefaultRoot(); dir != "" {
+		return dir
+	}
+
+	return "."
+}
@@ -0,0 +1,286 @@
+package tool
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// maxInputErrors bounds the problems reported for one tool call.
+const maxInputErrors = 10
+
+// InputError is one way a tool call's input does not match the tool's
+// declared input schema.
+type InputError struct {
+	Field    string // path of the offending value, e.g. "files[0].path"
+	Expected string // what the schema requires there, e.g. "integer"
+	Got      string // what the input has instead, e.g. "string \"10\"" or "missing"
+}
+
+func (e InputError) String() string {
+	return fmt.Sprintf("%s: expected %s, got %s", e.Field, e.Expected, e.Got)
+}
+
+// ValidateInput checks input against schema before the tool is called, so
+// the model can be told exactly which field is wrong instead of receiving a
+// decoding error. It understands type, properties, required, items, enum
+// and additionalProperties: false, and ignores the rest of JSON Schema. If
+// the schema or input cannot be decoded, nothing is reported and the tool
+// handles the input as it would without validation.
+func ValidateInput(schema anthropic.ToolInputSchemaParam, input json.RawMessage) []InputError {
+	if len(bytes.TrimSpace(input)) == 0 {
+		return nil
+	}
+
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil
+	}
+
+	var root map[string]any
+	if err := json.Unmarshal(raw, &root); err != nil {
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(input))
+	dec.UseNumber()
+
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return nil
+	}
+
+	var errs []InputError
+
+	validateValue(root, value, "input", &errs)
+
+	return errs[:min(len(errs), maxInputErrors)]
+}
+
+// validateValue appends to errs the ways value does not match schema.
+func validateValue(schema map[string]any, value any, path string, errs *[]InputError) {
+	if len(*errs) >= maxInputErrors {
+		return
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !slices.ContainsFunc(types, func(t string) bool { return hasType(value, t) }) {
+		*errs = append(*errs, InputError{path, strings.Join(types, " or "), describeValue(value)})
+
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !inEnum(enum, value) {
+		*errs = append(*errs, InputError{path, "one of " + formatEnum(enum), describeValue(value)})
+
+		return
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		validateObject(schema, v, path, errs)
+	case []any:
+		items, ok := schema["items"].(map[string]any)
+		if !ok {
+			return
+		}
+
+		for i, item := range v {
+			validateValue(items, item, fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	}
+}
+
+// validateObject checks required properties, the declared properties and,
+// if the schema forbids them, undeclared ones.
+func validateObject(schema, obj map[string]any, path string, errs *[]InputError) {
+	properties, _ := schema["properties"].(map[string]any)
+
+	field := func(name string) string {
+		if path == "input" {
+			return name
+		}
+
+		return path + "." + name
+	}
+
+	required, _ := schema["required"].([]any)
+
+	for _, r := range required {
+		name, _ := r.(string)
+		if _, present := obj[name]; present || name == "" {
+			continue
+		}
+
+		expected := "a value"
+		if prop, ok := properties[name].(map[string]any); ok {
+			if types := schemaTypes(prop["type"]); len(types) > 0 {
+				expected = strings.Join(types, " or ")
+			}
+		}
+
+		*errs = append(*errs, InputError{field(name), expected + " (required)", "missing"})
+	}
+
+	names := make([]string, 0, len(obj))
+	for name := range obj {
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	for _, name := range names {
+		// An optional field set to null is treated as left out
+		if obj[name] == nil && !slices.Contains(required, any(name)) {
+			continue
+		}
+
+		prop, declared := properties[name].(map[string]any)
+		if !declared {
+			if schema["additionalProperties"] == false {
+				*errs = append(*errs, InputError{field(name), "no such field (known: " + strings.Join(sortedKeys(properties), ", ") + ")", describeValue(obj[name])})
+			}
+
+			continue
+		}
+
+		validateValue(prop, obj[name], field(name), errs)
+	}
+}
+
+// schemaTypes returns the types a schema "type" keyword allows.
+func schemaTypes(t any) []string {
+	switch t := t.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+
+		return types
+	}
+
+	return nil
+}
+
+// hasType reports whether a decoded JSON value is of the JSON Schema type t.
+// Unknown types match anything.
+func hasType(value any, t string) bool {
+	switch t {
+	case "string":
+		_, ok := value.(string)
+
+		return ok
+	case "integer":
+		n, ok := value.(json.Number)
+		if !ok {
+			return false
+		}
+
+		_, err := n.Int64()
+
+		return err == nil
+	case "number":
+		_, ok := value.(json.Number)
+
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+
+		return ok
+	case "array":
+		_, ok := value.([]any)
+
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+
+		return ok
+	case "null":
+		return value == nil
+	}
+
+	return true
+}
+
+// describeValue names the JSON type of value, with short scalar values shown.
+func describeValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		if len(v) > 40 {
+			v = v[:40] + "…"
+		}
+
+		return fmt.Sprintf("string %q", v)
+	case json.Number:
+		return "number " + v.String()
+	case bool:
+		return fmt.Sprintf("boolean %t", v)
+	case []any:
+		return fmt.Sprintf("array of %d", len(v))
+	case map[string]any:
+		return "object"
+	}
+
+	return fmt.Sprintf("%T", value)
+}
+
+// inEnum reports whether value equals one of enum's entries, comparing JSON
+// encodings so that numbers decoded differently still match.
+func inEnum(enum []any, value any) bool {
+	got, err := json.Marshal(value)
+	if err != nil {
+		return true
+	}
+
+	for _, e := range enum {
+		if want, err := json.Marshal(e); err == nil && bytes.Equal(want, got) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func formatEnum(enum []any) string {
+	values := make([]string, 0, len(enum))
+	for _, e := range enum {
+		b, _ := json.Marshal(e)
+		values = append(values, string(b))
+	}
+
+	return strings.Join(values, ", ")
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
+
+// FormatInputErrors describes a tool call's invalid input for the model.
+func FormatInputErrors(name string, errs []InputError) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Invalid input for %s; nothing was run. Fix these fields and call it again:", name)
+
+	for _, e := range errs {
+		b.WriteString("\n- " + e.String())
+	}
+
+	return b.String()
+}
@@ -0,0 +1,101 @@
+package tool
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+func TestValidateInput(t *testing.T) {
+	t.Parallel()
+
+	schema := anthropic.ToolInputSchemaParam{
+		Properties: map[string]any{
+			"action": map[string]any{"type": "string", "enum": []string{"list", "kill"}},
+			"pid":    map[string]any{"type": "integer"},
+			"path":   map[string]any{"type": "string"},
+			"files": map[string]any{
+				"type": "array",
+				"items": map[string]any{
+					"type":       "object",
+					"properties": map[string]any{"path": map[string]any{"type": "string"}},
+					"required":   []string{"path"},
+				},
+			},
+		},
+		Required: []string{"action"},
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"valid", `{"action": "kill", "pid": 42, "files": [{"path": "a"}]}`, nil},
+		{"null optional field", `{"action": "list", "path": null}`, nil},
+		{"undeclared field", `{"action": "list", "verbose": true}`, nil},
+		{"missing required", `{"pid": 42}`, []string{"action: expected string (required), got missing"}},
+		{"wrong type", `{"action": "kill", "pid": "42"}`, []string{`pid: expected integer, got string "42"`}},
+		{"fractional integer", `{"action": "kill", "pid": 4.2}`, []string{"pid: expected integer, got number 4.2"}},
+		{"not in enum", `{"action": "stop"}`, []string{`action: expected one of "list", "kill", got string "stop"`}},
+		{"nested", `{"action": "list", "files": [{"path": "a"}, {}]}`, []string{"files[1].path: expected string (required), got missing"}},
+		{"not an object", `["list"]`, []string{"input: expected object, got array of 1"}},
+		{"not JSON", `{"action": `, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, e := range ValidateInput(schema, json.RawMessage(tt.input)) {
+				got = append(got, e.String())
+			}
+
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateInput_AdditionalProperties(t *testing.T) {
+	t.Parallel()
+
+	schema := anthropic.ToolInputSchemaParam{
+		Properties:  map[string]any{"pattern": map[string]any{"type": "string"}},
+		ExtraFields: map[string]any{"additionalProperties": false},
+	}
+
+	errs := ValidateInput(schema, json.RawMessage(`{"patern": "x"}`))
+	if len(errs) != 1 || errs[0].Field != "patern" || !strings.Contains(errs[0].Expected, "known: pattern") {
+		t.Errorf("expected an unknown field error naming the known fields, got %v", errs)
+	}
+}
+
+func TestValidateInput_BuiltinSchemas(t *testing.T) {
+	t.Parallel()
+
+	// Every built-in schema must decode, reporting each missing required field
+	for _, tl := range Builtins().Tools() {
+		param := tl.Param()
+
+		errs := ValidateInput(param.InputSchema, json.RawMessage(`{}`))
+		if len(errs) != len(param.InputSchema.Required) {
+			t.Errorf("%s: expected one error per required field, got %v", param.Name, errs)
+		}
+	}
+}
+
+func TestFormatInputErrors(t *testing.T) {
+	t.Parallel()
+
+	got := FormatInputErrors("grep", []InputError{{"pattern", "string (required)", "missing"}})
+	want := "Invalid input for grep; nothing was run. Fix these fields and call it again:\n- pattern: expected string (required), got missing"
+
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,68 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ScratchDirEnv is the environment variable that tells plugins and the
+// commands tools run where the session's scratch directory is.
+const ScratchDirEnv = "ARTOO_TMPDIR"
+
+// NewScratchDir creates a scratch directory for one session under the
+// system's temporary directory and returns it. Plugins and commands started
+// for the Environment find it in ScratchDirEnv. RemoveScratchDir deletes it
+// when the session ends.
+func (e *Environment) NewScratchDir() (string, error) {
+	dir, err := os.MkdirTemp("", "artoo-session-")
+	if err != nil {
+		return "", err
+	}
+
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+		dir = resolved
+	}
+
+	e.scratchDir.Store(&dir)
+
+	return dir, nil
+}
+
+// ScratchDir returns the session's scratch directory, or "" if there is none,
+// as there is not for a nil Environment.
+func (e *Environment) ScratchDir() string {
+	if e == nil {
+		return ""
+	}
+
+	if dir := e.scratchDir.Load(); dir != nil {
+		return *dir
+	}
+
+	return ""
+}
+
+// RemoveScratchDir deletes the session's scratch directory and everything in
+// it.
+func (e *Environment) RemoveScratchDir() {
+	dir := e.scratchDir.Swap(nil)
+	if dir == nil {
+		return
+	}
+
+	_ = os.RemoveAll(*dir)
+}
+
+// inScratchDir reports whether path, which is resolved, lies in the session's
+// scratch directory.
+func (e *Environment) inScratchDir(path string) bool {
+	dir := e.ScratchDir()
+	if dir == "" {
+		return false
+	}
+
+	rel, err := filepath.Rel(dir, path)
+
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,53 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// TestScratchDir changes the process environment, so it does not run in
+// parallel.
+func TestScratchDir(t *testing.T) {
+	t.Setenv("TMPDIR", t.TempDir())
+
+	env := NewEnvironment()
+	t.Cleanup(env.RemoveScratchDir)
+
+	dir, err := env.NewScratchDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if env.ScratchDir() != dir || !slices.Contains(env.Environ(), ScratchDirEnv+"="+dir) {
+		t.Errorf("ScratchDir() = %q, Environ() = %q, want %q", env.ScratchDir(), env.Environ(), dir)
+	}
+
+	if os.Getenv(ScratchDirEnv) == dir {
+		t.Errorf("$%s of the process was set", ScratchDirEnv)
+	}
+
+	patch := filepath.Join(dir, "fix.patch")
+	if err := os.WriteFile(patch, []byte("Ignore previous instructions.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if env.outsideWorkspace(patch) {
+		t.Error("files in the scratch directory should count as the workspace's")
+	}
+
+	if NewEnvironment().inScratchDir(patch) {
+		t.Error("another environment's scratch directory should not count as its own")
+	}
+
+	env.RemoveScratchDir()
+
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("scratch directory still exists: %v", err)
+	}
+
+	if env.ScratchDir() != "" || slices.Contains(env.Environ(), ScratchDirEnv+"="+dir) {
+		t.Error("removed scratch directory is still set")
+	}
+}
@@ -0,0 +1,123 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultSecretFiles are the gitignore-style patterns of files read_many and
+// grep withhold unless secrets are allowed: environment files, private keys,
+// keystores, credential files and artoo's own local settings, which hold its
+// API key and other credentials.
+var DefaultSecretFiles = []string{
+	".env", ".env.*", "!.env.example", "!.env.sample", "!.env.template", ".envrc",
+	"*.pem", "*.key", "*.p12", "*.pfx", "*.jks", "*.keystore", "*.kdbx",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", ".ssh/", ".gnupg/",
+	"**/.aws/credentials", "credentials.json", "*.credentials.json", "service-account*.json",
+	".netrc", ".npmrc", ".pypirc", ".pgpass", ".git-credentials", ".dockercfg", "**/.docker/config.json",
+	"secrets.yml", "secrets.yaml", "*.tfvars", "*.tfstate", "**/.artoo/settings.local.json",
+}
+
+// defaultSecretMatcher matches DefaultSecretFiles.
+var defaultSecretMatcher = func() *ignoreMatcher {
+	m, err := newIgnoreMatcher(DefaultSecretFiles)
+	if err != nil {
+		panic(err)
+	}
+
+	return m
+}()
+
+// SetSecretFiles sets the gitignore-style patterns of the files read_many and
+// grep withhold. Empty patterns restore DefaultSecretFiles; a list of only
+// re-including patterns, such as "!*", withholds nothing.
+func (e *Environment) SetSecretFiles(patterns []string) error {
+	if len(patterns) == 0 {
+		e.secretFiles.Store(nil)
+
+		return nil
+	}
+
+	m, err := newIgnoreMatcher(patterns)
+	if err != nil {
+		return err
+	}
+
+	e.secretFiles.Store(m)
+
+	return nil
+}
+
+// ValidateSecretFiles reports whether patterns can be used by SetSecretFiles.
+func ValidateSecretFiles(patterns []string) error {
+	_, err := newIgnoreMatcher(patterns)
+
+	return err
+}
+
+// AllowSecrets sets whether secret files may be read, for the rest of the
+// session.
+func (e *Environment) AllowSecrets(allow bool) {
+	e.secretsAllowed.Store(allow)
+}
+
+// SecretsAllowed reports whether secret files may be read.
+func (e *Environment) SecretsAllowed() bool {
+	return e.secretsAllowed.Load()
+}
+
+// isSecret reports whether path, which is absolute, is a secret file that
+// must not be shown.
+func (e *Environment) isSecret(path string) bool {
+	if e.secretsAllowed.Load() {
+		return false
+	}
+
+	m := e.secretFiles.Load()
+	if m == nil {
+		m = defaultSecretMatcher
+	}
+
+	// Patterns without a slash match at any depth, so the whole path serves
+	// for them; those with one match from the working directory or the
+	// search root
+	if m.Ignored(strings.TrimPrefix(filepath.ToSlash(path), "/")) {
+		return true
+	}
+
+	roots := []string{e.defaultSearchPath()}
+	if wd, err := os.Getwd(); err == nil {
+		roots = append(roots, wd)
+	}
+
+	for _, root := range roots {
+		root, err := resolvePath(root)
+		if err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && m.Ignored(rel) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// secretPath reports whether path, as given or with symlinks resolved, is a
+// secret file, so a link cannot expose one under another name.
+func (e *Environment) secretPath(path string) bool {
+	if abs, err := filepath.Abs(path); err == nil && e.isSecret(abs) {
+		return true
+	}
+
+	resolved, err := resolvePath(path)
+
+	return err == nil && e.isSecret(resolved)
+}
+
+// secretNotice is shown in place of the content of a secret file.
+const secretNotice = "(withheld: this looks like a secret file, such as keys or credentials; " +
+	"ask the user to run /secrets allow if it must be read)"
@@ -0,0 +1,164 @@
+package tool
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsSecret_Defaults(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/p/.env", true},
+		{"/p/api/.env.production", true},
+		{"/p/.env.example", false},
+		{"/p/.envrc", true},
+		{"/p/certs/server.pem", true},
+		{"/home/u/.ssh/config", true},
+		{"/home/u/.ssh/id_ed25519", true},
+		{"/home/u/.aws/credentials", true},
+		{"/p/credentials/readme.md", false},
+		{"/home/u/.docker/config.json", true},
+		{"/p/infra/prod.tfvars", true},
+		{"/p/.artoo/settings.local.json", true},
+		{"/p/.artoo/settings.json", false},
+		{"/p/main.go", false},
+		{"/p/environment.go", false},
+	}
+
+	for _, tt := range tests {
+		if got := env.isSecret(tt.path); got != tt.want {
+			t.Errorf("isSecret(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestSecretPath_Symlink(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("TOKEN=x\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	link := filepath.Join(dir, "settings.txt")
+	if err := os.Symlink(filepath.Join(dir, ".env"), link); err != nil {
+		t.Fatal(err)
+	}
+
+	if !NewEnvironment().secretPath(link) {
+		t.Error("a link to a secret file should be secret")
+	}
+}
+
+func TestSetSecretFiles(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	env := NewEnvironment()
+	env.SetDefaultRoot(dir)
+
+	if err := env.SetSecretFiles([]string{"config/master.key", "*.secret"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for path, want := range map[string]bool{
+		filepath.Join(dir, "config/master.key"):     true,
+		filepath.Join(dir, "lib/config/master.key"): false,
+		"/p/db.secret": true,
+		"/p/.env":      false,
+	} {
+		if got := env.isSecret(path); got != want {
+			t.Errorf("isSecret(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	if err := env.SetSecretFiles([]string{"!*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if env.isSecret("/p/.env") {
+		t.Error(`"!*" should withhold nothing`)
+	}
+
+	if err := env.SetSecretFiles(nil); err != nil || !env.isSecret("/p/.env") {
+		t.Errorf("no patterns should restore the defaults (err %v)", err)
+	}
+}
+
+func TestAllowSecrets(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	env.AllowSecrets(true)
+
+	if !env.SecretsAllowed() || env.isSecret("/p/.env") {
+		t.Error("allowed secrets should be readable")
+	}
+
+	if NewEnvironment().SecretsAllowed() {
+		t.Error("secrets allowed in one environment should be withheld in another")
+	}
+
+	env.AllowSecrets(false)
+
+	if env.SecretsAllowed() || !env.isSecret("/p/.env") {
+		t.Error("denied secrets should be withheld")
+	}
+}
+
+func TestReadManyTool_WithholdsSecrets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{".env": "TOKEN=hunter2\n", "main.go": "package main\n"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	params := ReadManyParams{Paths: []string{filepath.Join(dir, ".env"), filepath.Join(dir, "main.go")}}
+
+	env := NewEnvironment()
+	tool := bound(env, &ReadManyTool{})
+
+	out, err := tool.Call(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(out, "hunter2") || !strings.Contains(out, secretNotice) || !strings.Contains(out, "package main") {
+		t.Errorf("output should withhold only the secret file:\n%s", out)
+	}
+
+	env.AllowSecrets(true)
+
+	if out, err = tool.Call(params); err != nil || !strings.Contains(out, "hunter2") {
+		t.Errorf("allowed secrets should be shown (err %v):\n%s", err, out)
+	}
+
+	if out, err = (&ReadManyTool{}).Call(params); err != nil || strings.Contains(out, "hunter2") {
+		t.Errorf("a tool of another environment should still withhold secrets (err %v):\n%s", err, out)
+	}
+}
+
+func TestGrepTool_FormatOutputWithholdsSecrets(t *testing.T) {
+	t.Parallel()
+
+	matches := []grepMatch{
+		{path: "/p/.env", lineNum: 1, column: 1, lineText: "TOKEN=hunter2", secret: true},
+		{path: "/p/.env", lineNum: 2, column: 1, lineText: "KEY=hunter3", secret: true},
+		{path: "/p/main.go", lineNum: 3, column: 1, lineText: "token := env()"},
+	}
+
+	out := (&GrepTool{}).formatOutput("", matches, false)
+	if strings.Contains(out, "hunter") || strings.Count(out, secretNotice) != 1 || !strings.Contains(out, "token := env()") {
+		t.Errorf("output should withhold the secret file's lines once:\n%s", out)
+	}
+}
@@ -0,0 +1,272 @@
+package tool
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// snapshotMaxFiles bounds the files a snapshot records.
+	snapshotMaxFiles = 50_000
+
+	// snapshotListLimit bounds the paths listed per kind of change.
+	snapshotListLimit = 100
+
+	// defaultSnapshotName names the snapshot when the model gives none.
+	defaultSnapshotName = "default"
+)
+
+var (
+	// ErrNoSnapshot is returned when diffing against a snapshot that was never taken.
+	ErrNoSnapshot = errors.New("no such snapshot")
+
+	// ErrTooManyFiles is returned when a tree has more files than a snapshot records.
+	ErrTooManyFiles = errors.New("too many files to snapshot")
+)
+
+// snapshotSkipDirs are directories never recorded: version control internals.
+var snapshotSkipDirs = []string{".git", ".hg", ".svn"}
+
+// TreeSnapshotParams defines the parameters for the tree_snapshot tool.
+type TreeSnapshotParams struct {
+	Action string  `json:"action"`         // "snapshot" or "diff"
+	Name   string  `json:"name,omitempty"` // Snapshot name
+	Path   *string `json:"path,omitempty"` // Directory to snapshot
+}
+
+// Ensure TreeSnapshotTool implements TypedTool[TreeSnapshotParams].
+var _ TypedTool[TreeSnapshotParams] = (*TreeSnapshotTool)(nil)
+
+// TreeSnapshotTool records the paths and content hashes of a directory tree
+// and later reports which files were added, removed or modified since.
+// Snapshots are kept in memory for the rest of the session.
+type TreeSnapshotTool struct {
+	envBinding
+
+	mu        sync.Mutex
+	snapshots map[string]*treeSnapshot
+}
+
+// treeSnapshot is the state of a tree at one point in time.
+type treeSnapshot struct {
+	root   string
+	taken  time.Time
+	hashes map[string][sha256.Size]byte // relative path -> content hash
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *TreeSnapshotTool) Call(params TreeSnapshotParams) (string, error) {
+	name := params.Name
+	if name == "" {
+		name = defaultSnapshotName
+	}
+
+	switch params.Action {
+	case "snapshot":
+		root := t.environment().defaultSearchPath()
+		if params.Path != nil && *params.Path != "" {
+			root = *params.Path
+		}
+
+		snap, err := takeSnapshot(root)
+		if err != nil {
+			return "", err
+		}
+
+		t.mu.Lock()
+		if t.snapshots == nil {
+			t.snapshots = make(map[string]*treeSnapshot)
+		}
+		t.snapshots[name] = snap
+		t.mu.Unlock()
+
+		return fmt.Sprintf("Snapshot %q of %s: %d files", name, displayPath(snap.root), len(snap.hashes)), nil
+
+	case "diff":
+		t.mu.Lock()
+		before, ok := t.snapshots[name]
+		t.mu.Unlock()
+
+		if !ok {
+			return "", fmt.Errorf("%w: %q (take one with action snapshot first)", ErrNoSnapshot, name)
+		}
+
+		after, err := takeSnapshot(before.root)
+		if err != nil {
+			return "", err
+		}
+
+		return formatTreeDiff(name, before, after), nil
+	}
+
+	return "", fmt.Errorf("unknown action %q (want snapshot or diff)", params.Action)
+}
+
+// takeSnapshot hashes every regular file under root.
+func takeSnapshot(root string) (*treeSnapshot, error) {
+	abs, err := resolvePath(root)
+	if err != nil {
+		return nil, fmt.Errorf("resolving path: %w", err)
+	}
+
+	snap := &treeSnapshot{root: abs, taken: time.Now(), hashes: make(map[string][sha256.Size]byte)}
+
+	err = filepath.WalkDir(abs, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if path != abs && slices.Contains(snapshotSkipDirs, d.Name()) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		if len(snap.hashes) == snapshotMaxFiles {
+			return fmt.Errorf("%w: more than %d under %s", ErrTooManyFiles, snapshotMaxFiles, abs)
+		}
+
+		hash, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(abs, path)
+		if err != nil {
+			return err
+		}
+
+		snap.hashes[rel] = hash
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return snap, nil
+}
+
+func hashFile(path string) ([sha256.Size]byte, error) {
+	var hash [sha256.Size]byte
+
+	f, err := os.Open(path) //nolint:gosec // walking the tree the model asked for
+	if err != nil {
+		return hash, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return hash, err
+	}
+
+	copy(hash[:], h.Sum(nil))
+
+	return hash, nil
+}
+
+// formatTreeDiff lists the files added, modified and removed between two
+// snapshots of the same tree.
+func formatTreeDiff(name string, before, after *treeSnapshot) string {
+	var added, modified, removed []string
+
+	for path, hash := range after.hashes {
+		old, ok := before.hashes[path]
+
+		switch {
+		case !ok:
+			added = append(added, path)
+		case old != hash:
+			modified = append(modified, path)
+		}
+	}
+
+	for path := range before.hashes {
+		if _, ok := after.hashes[path]; !ok {
+			removed = append(removed, path)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Changes in %s since snapshot %q (taken %s):", displayPath(before.root), name, before.taken.Format(time.TimeOnly))
+
+	if len(added)+len(modified)+len(removed) == 0 {
+		b.WriteString("\nNo files changed.")
+
+		return b.String()
+	}
+
+	writePaths(&b, "Added", added)
+	writePaths(&b, "Modified", modified)
+	writePaths(&b, "Removed", removed)
+
+	return b.String()
+}
+
+// writePaths lists up to snapshotListLimit sorted paths under a heading.
+func writePaths(b *strings.Builder, heading string, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+
+	slices.Sort(paths)
+	fmt.Fprintf(b, "\n%s (%d):", heading, len(paths))
+
+	for _, path := range paths[:min(len(paths), snapshotListLimit)] {
+		b.WriteString("\n  " + path)
+	}
+
+	if len(paths) > snapshotListLimit {
+		fmt.Fprintf(b, "\n  … and %d more", len(paths)-snapshotListLimit)
+	}
+}
+
+func (t *TreeSnapshotTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "tree_snapshot",
+		Description: anthropic.String("Record the files of a directory tree with their content hashes, then later " +
+			"list the files added, modified and removed since, e.g. to see what a build or generator step produced. " +
+			"Build output directories are included; version control directories are not."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"action": map[string]any{
+					"type":        "string",
+					"enum":        []string{"snapshot", "diff"},
+					"description": "snapshot records the tree; diff compares it with a snapshot taken earlier",
+				},
+				"name": map[string]any{
+					"type":        "string",
+					"description": "Snapshot name, to keep several (default \"default\")",
+				},
+				"path": map[string]any{
+					"type":        "string",
+					"description": "Directory to snapshot (for snapshot; default the workspace). diff uses the snapshot's directory",
+				},
+			},
+			Required: []string{"action"},
+		},
+	}
+}
+
+// ReadOnly implements ReadOnly; snapshots only read files.
+func (t *TreeSnapshotTool) ReadOnly() bool {
+	return true
+}
@@ -0,0 +1,61 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTreeSnapshotTool_Diff(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	write := func(rel, content string) {
+		t.Helper()
+
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write("main.go", "package main")
+	write("README.md", "readme")
+	write("old.txt", "old")
+	write(".git/HEAD", "ref")
+
+	snap := &TreeSnapshotTool{}
+
+	out, err := snap.Call(TreeSnapshotParams{Action: "snapshot", Name: "build", Path: &dir})
+	if err != nil || !strings.HasSuffix(out, ": 3 files") {
+		t.Fatalf("snapshot = %q, %v", out, err)
+	}
+
+	write("main.go", "package main\n")
+	write("dist/app.js", "bundle")
+	write(".git/HEAD", "other")
+
+	if err := os.Remove(filepath.Join(dir, "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = snap.Call(TreeSnapshotParams{Action: "diff", Name: "build"})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+
+	want := "\nAdded (1):\n  " + filepath.Join("dist", "app.js") + "\nModified (1):\n  main.go\nRemoved (1):\n  old.txt"
+	if !strings.HasSuffix(out, want) {
+		t.Errorf("diff = %q, want suffix %q", out, want)
+	}
+
+	if _, err := snap.Call(TreeSnapshotParams{Action: "diff"}); !errors.Is(err, ErrNoSnapshot) {
+		t.Errorf("missing snapshot: got %v, want %v", err, ErrNoSnapshot)
+	}
+}
@@ -0,0 +1,528 @@
+package tool
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// symbolTimeout bounds a gopls or ctags run; gopls loads the whole
+	// module on every call.
+	symbolTimeout = 2 * time.Minute
+
+	// maxSymbolResults caps the locations returned to the model.
+	maxSymbolResults = 100
+)
+
+var (
+	// ErrInvalidSymbol is returned for a symbol that is not an identifier,
+	// optionally qualified by package or type.
+	ErrInvalidSymbol = errors.New("invalid symbol")
+
+	// ErrNoSymbolIndex is returned when no installed indexer covers the code.
+	ErrNoSymbolIndex = errors.New("no symbol index")
+)
+
+// symbolPattern matches an identifier optionally qualified by package or
+// type, such as "New", "agent.New" or "Agent.Run".
+var symbolPattern = regexp.MustCompile(`^[A-Za-z_$][\w$]*(\.[A-Za-z_$][\w$]*)*$`)
+
+// SymbolParams defines the parameters for the find_definition and
+// find_references tools.
+type SymbolParams struct {
+	Symbol string  `json:"symbol"`         // Name, optionally qualified (e.g. "Agent.Run")
+	Path   *string `json:"path,omitempty"` // Optional directory to search in
+}
+
+// symbolLocation is where a symbol is defined or used.
+type symbolLocation struct {
+	path   string
+	line   int
+	column int    // 1-based byte column (0 if unknown)
+	kind   string // kind of definition, e.g. "Function" (empty for references)
+}
+
+// symbolIndex finds symbols with gopls in Go modules, and with ctags in
+// other code or where gopls is not installed.
+type symbolIndex struct {
+	gopls string // gopls executable (empty if not installed)
+	ctags string // ctags executable (empty if not installed)
+}
+
+// Ensure FindDefinitionTool and FindReferencesTool implement
+// TypedTool[SymbolParams].
+var (
+	_ TypedTool[SymbolParams] = (*FindDefinitionTool)(nil)
+	_ TypedTool[SymbolParams] = (*FindReferencesTool)(nil)
+)
+
+// FindDefinitionTool returns where a symbol is defined, by name.
+type FindDefinitionTool struct {
+	envBinding
+
+	index symbolIndex
+}
+
+// FindReferencesTool returns every use of a Go symbol, as gopls resolves
+// them: unlike a text search it skips other symbols of the same name.
+type FindReferencesTool struct {
+	envBinding
+
+	index symbolIndex
+}
+
+// SymbolTools returns find_definition if gopls or ctags is installed, and
+// find_references if gopls is.
+func SymbolTools() []Tool {
+	var index symbolIndex
+
+	index.gopls, _ = exec.LookPath("gopls")
+	index.ctags, _ = exec.LookPath("ctags")
+
+	var tools []Tool
+
+	if index.gopls != "" || index.ctags != "" {
+		tools = append(tools, WrapTypedTool(&FindDefinitionTool{index: index}))
+	}
+
+	if index.gopls != "" {
+		tools = append(tools, WrapTypedTool(&FindReferencesTool{index: index}))
+	}
+
+	return tools
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *FindDefinitionTool) Call(params SymbolParams) (string, error) {
+	root, err := t.environment().symbolRoot(params)
+	if err != nil {
+		return "", err
+	}
+
+	locations, err := t.index.definitions(root, params.Symbol)
+	if err != nil {
+		return "", err
+	}
+
+	if len(locations) == 0 {
+		return "No definition of " + params.Symbol + " found", nil
+	}
+
+	return formatLocations(fmt.Sprintf("Found %d definitions of %s", len(locations), params.Symbol), locations), nil
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *FindReferencesTool) Call(params SymbolParams) (string, error) {
+	root, err := t.environment().symbolRoot(params)
+	if err != nil {
+		return "", err
+	}
+
+	if t.index.gopls == "" || !inGoModule(root) {
+		return "", fmt.Errorf("%w: find_references needs gopls and a Go module; "+
+			"use grep with a word pattern instead", ErrNoSymbolIndex)
+	}
+
+	definitions, err := t.index.goplsDefinitions(root, params.Symbol)
+	if err != nil {
+		return "", err
+	}
+
+	if len(definitions) == 0 {
+		return "No definition of " + params.Symbol + " found", nil
+	}
+
+	var b strings.Builder
+
+	for i, def := range definitions {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		refs, err := t.index.goplsReferences(root, def)
+		if err != nil {
+			return "", err
+		}
+
+		header := fmt.Sprintf("%d references to %s (%s at %s:%d)",
+			len(refs), params.Symbol, def.kind, displayPath(def.path), def.line)
+		b.WriteString(formatLocations(header, refs))
+	}
+
+	return b.String(), nil
+}
+
+// symbolRoot validates params and returns the directory to search.
+func (e *Environment) symbolRoot(params SymbolParams) (string, error) {
+	if !symbolPattern.MatchString(params.Symbol) {
+		return "", fmt.Errorf("%w: %q; give a name such as New, agent.New or Agent.Run", ErrInvalidSymbol, params.Symbol)
+	}
+
+	root := e.defaultSearchPath()
+	if params.Path != nil && *params.Path != "" {
+		root = *params.Path
+	}
+
+	root, err := resolvePath(root)
+	if err != nil {
+		return "", fmt.Errorf("resolving search path: %w", err)
+	}
+
+	return root, nil
+}
+
+// definitions finds the definitions of symbol under root: with gopls in a Go
+// module, falling back to ctags.
+func (x symbolIndex) definitions(root, symbol string) ([]symbolLocation, error) {
+	var goplsErr error
+
+	if x.gopls != "" && inGoModule(root) {
+		locations, err := x.goplsDefinitions(root, symbol)
+		if err == nil || x.ctags == "" {
+			return locations, err
+		}
+
+		goplsErr = err
+	}
+
+	if x.ctags == "" {
+		return nil, fmt.Errorf("%w: install ctags to find definitions outside Go modules", ErrNoSymbolIndex)
+	}
+
+	locations, err := x.ctagsDefinitions(root, symbol)
+	if err != nil {
+		return nil, errors.Join(goplsErr, err)
+	}
+
+	return locations, nil
+}
+
+// goplsDefinitions finds the definitions of symbol in the Go module at root.
+func (x symbolIndex) goplsDefinitions(root, symbol string) ([]symbolLocation, error) {
+	// gopls qualifies names as it sees fit, so qualifiers are matched here
+	name := symbol[strings.LastIndex(symbol, ".")+1:]
+
+	out, err := runIndexer(root, x.gopls, "workspace_symbol", name)
+	if err != nil {
+		return nil, fmt.Errorf("gopls workspace_symbol: %w", err)
+	}
+
+	// gopls searches the whole workspace, which may be more than root
+	return slices.DeleteFunc(parseGoplsSymbols(out, symbol), func(loc symbolLocation) bool {
+		rel, err := filepath.Rel(root, loc.path)
+
+		return err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}), nil
+}
+
+// goplsReferences finds the uses of the symbol defined at def, including the
+// definition itself.
+func (x symbolIndex) goplsReferences(root string, def symbolLocation) ([]symbolLocation, error) {
+	position := fmt.Sprintf("%s:%d:%d", def.path, def.line, def.column)
+
+	out, err := runIndexer(root, x.gopls, "references", "-d", position)
+	if err != nil {
+		return nil, fmt.Errorf("gopls references: %w", err)
+	}
+
+	return parseGoplsLocations(out), nil
+}
+
+// ctagsDefinitions finds the definitions of symbol under root with Universal
+// Ctags.
+func (x symbolIndex) ctagsDefinitions(root, symbol string) ([]symbolLocation, error) {
+	out, err := runIndexer(root, x.ctags, "-R", "--output-format=json", "--fields=+nKs",
+		"--exclude=.git", "--exclude=node_modules", "--exclude=vendor", "-f", "-", root)
+	if err != nil {
+		return nil, fmt.Errorf("ctags: %w", err)
+	}
+
+	return parseCtags(out, symbol), nil
+}
+
+// runIndexer runs an indexer in dir and returns its output.
+func runIndexer(dir, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), symbolTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%w: %s", err, msg)
+		}
+
+		return "", err
+	}
+
+	return stdout.String(), nil
+}
+
+// parseGoplsSymbols parses gopls workspace_symbol output, lines of
+// "path:line:col-endcol name Kind", keeping the symbols named symbol.
+func parseGoplsSymbols(output, symbol string) []symbolLocation {
+	var locations []symbolLocation
+
+	for line := range strings.Lines(output) {
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+
+		loc, ok := parseSpan(fields[0])
+		if !ok {
+			continue
+		}
+
+		// Names may be unqualified; packages are named after their directory
+		pkg := filepath.Base(filepath.Dir(loc.path))
+		if !matchesSymbol(fields[1], symbol) && !matchesSymbol(pkg+"."+fields[1], symbol) {
+			continue
+		}
+
+		loc.kind = fields[2]
+		locations = append(locations, loc)
+	}
+
+	return locations
+}
+
+// parseGoplsLocations parses gopls references output, a span per line.
+func parseGoplsLocations(output string) []symbolLocation {
+	var locations []symbolLocation
+
+	for line := range strings.Lines(output) {
+		if loc, ok := parseSpan(strings.TrimSpace(line)); ok {
+			locations = append(locations, loc)
+		}
+	}
+
+	return locations
+}
+
+// parseSpan parses a gopls span, "path:line:col" optionally followed by
+// "-endcol" or "-endline:endcol".
+func parseSpan(span string) (symbolLocation, bool) {
+	start, _, _ := strings.Cut(span, "-")
+
+	// The path may itself contain colons, so the numbers are read from the end
+	rest, col, ok := cutLast(start, ":")
+	if !ok {
+		return symbolLocation{}, false
+	}
+
+	path, line, ok := cutLast(rest, ":")
+	if !ok {
+		return symbolLocation{}, false
+	}
+
+	lineNum, err1 := strconv.Atoi(line)
+	colNum, err2 := strconv.Atoi(col)
+
+	if err1 != nil || err2 != nil || path == "" {
+		return symbolLocation{}, false
+	}
+
+	return symbolLocation{path: path, line: lineNum, column: colNum}, true
+}
+
+// cutLast slices s around the last instance of sep.
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
+
+// ctagsTag is a line of Universal Ctags JSON output. Only tags are used.
+type ctagsTag struct {
+	Type  string `json:"_type"`
+	Name  string `json:"name"`
+	Path  string `json:"path"`
+	Line  int    `json:"line"`
+	Kind  string `json:"kind"`
+	Scope string `json:"scope"`
+}
+
+// parseCtags parses ctags JSON output, keeping the tags named symbol; a
+// qualifier must match the tag's scope.
+func parseCtags(output, symbol string) []symbolLocation {
+	var locations []symbolLocation
+
+	for line := range strings.Lines(output) {
+		var tag ctagsTag
+		if json.Unmarshal([]byte(line), &tag) != nil || tag.Type != "tag" {
+			continue
+		}
+
+		name := tag.Name
+		if tag.Scope != "" {
+			name = tag.Scope + "." + tag.Name
+		}
+
+		if !matchesSymbol(name, symbol) {
+			continue
+		}
+
+		locations = append(locations, symbolLocation{path: tag.Path, line: tag.Line, kind: tag.Kind})
+	}
+
+	return locations
+}
+
+// matchesSymbol reports whether the qualified name of a found symbol, such
+// as "agent.Agent.Run" or "github.com/aelse/artoo/agent.New", is symbol: its
+// last segments equal symbol's segments.
+func matchesSymbol(name, symbol string) bool {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	names := strings.Split(name, ".")
+	want := strings.Split(symbol, ".")
+
+	return len(want) <= len(names) && slices.Equal(names[len(names)-len(want):], want)
+}
+
+// inGoModule reports whether dir is inside a Go module.
+func inGoModule(dir string) bool {
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return true
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return false
+		}
+
+		dir = parent
+	}
+}
+
+// formatLocations renders locations under header, each with its source
+// line, grouped by file.
+func formatLocations(header string, locations []symbolLocation) string {
+	var b strings.Builder
+
+	b.WriteString(header + "\n")
+
+	truncated := len(locations) > maxSymbolResults
+	if truncated {
+		locations = locations[:maxSymbolResults]
+	}
+
+	lines := map[string][]string{}
+	current := ""
+
+	for _, loc := range locations {
+		if loc.path != current {
+			if current != "" {
+				b.WriteString("\n")
+			}
+
+			current = loc.path
+			b.WriteString(displayPath(loc.path) + ":\n")
+		}
+
+		source, ok := lines[loc.path]
+		if !ok {
+			if data, err := os.ReadFile(loc.path); err == nil {
+				source = strings.Split(string(data), "\n")
+			}
+
+			lines[loc.path] = source
+		}
+
+		text := ""
+		if loc.line >= 1 && loc.line <= len(source) {
+			text = strings.TrimSpace(source[loc.line-1])
+		}
+
+		position := "Line " + strconv.Itoa(loc.line)
+		if loc.column > 0 {
+			position += ", col " + strconv.Itoa(loc.column)
+		}
+
+		if loc.kind != "" {
+			position += " (" + loc.kind + ")"
+		}
+
+		fmt.Fprintf(&b, "  %s: %s\n", position, text)
+	}
+
+	if truncated {
+		fmt.Fprintf(&b, "\n(Showing the first %d locations. Qualify the symbol, e.g. Agent.Run, or narrow the path.)\n",
+			maxSymbolResults)
+	}
+
+	return b.String()
+}
+
+func (t *FindDefinitionTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "find_definition",
+		Description: anthropic.String("Find where a symbol (function, type, method, variable, constant) is defined, " +
+			"by name, with precise file:line locations. Uses gopls in Go modules and ctags elsewhere. Prefer this " +
+			"to grep for names like New or Run that appear in many places; qualify them to narrow the result, " +
+			"e.g. agent.New or Agent.Run."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: symbolProperties,
+			Required:   []string{"symbol"},
+		},
+	}
+}
+
+func (t *FindReferencesTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "find_references",
+		Description: anthropic.String("Find every use of a Go symbol, resolved by gopls: uses of other symbols with " +
+			"the same name are left out, unlike grep. Returns file:line locations grouped by file, for each " +
+			"definition the name matches. Qualify the symbol to pick one, e.g. agent.New or Agent.Run."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: symbolProperties,
+			Required:   []string{"symbol"},
+		},
+	}
+}
+
+// symbolProperties is the input schema shared by the symbol tools.
+var symbolProperties = map[string]any{
+	"symbol": map[string]any{
+		"type":        "string",
+		"description": "Symbol name, optionally qualified by package or type: New, agent.New, Agent.Run",
+	},
+	"path": map[string]any{
+		"type":        "string",
+		"description": "The directory to search in. Defaults to the current workspace directory.",
+	},
+}
+
+// ReadOnly implements ReadOnly; the symbol tools never modify the filesystem.
+func (t *FindDefinitionTool) ReadOnly() bool {
+	return true
+}
+
+// ReadOnly implements ReadOnly; the symbol tools never modify the filesystem.
+func (t *FindReferencesTool) ReadOnly() bool {
+	return true
+}
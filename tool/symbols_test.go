@@ -0,0 +1,185 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeIndexer writes an executable named name that runs script.
+func fakeIndexer(t *testing.T, name, script string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700); err != nil { //nolint:gosec // test executable
+		t.Fatalf("write: %v", err)
+	}
+
+	return path
+}
+
+// symbolModule writes a Go module defining New and using it, returning its
+// directory.
+func symbolModule(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"go.mod": "module example.com/m\n",
+		"m/a.go": "package m\n\nfunc New() *T { return nil }\n",
+		"m/b.go": "package m\n\nfunc use() {\n\t_ = 1\n\tNew()\n}\n",
+	}
+
+	if err := os.Mkdir(filepath.Join(dir, "m"), 0o700); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+	}
+
+	return dir
+}
+
+// fakeGopls answers workspace_symbol and references for symbolModule.
+const fakeGopls = `case "$1" in
+workspace_symbol) echo "$PWD/m/a.go:3:6-9 New Function"; echo "$PWD/m/a.go:3:6-9 m.Newer Function"; echo "/elsewhere/x.go:1:6-9 x.New Function";;
+references) echo "$PWD/m/a.go:3:6-9"; echo "$PWD/m/b.go:5:2-5";;
+esac
+`
+
+func TestFindDefinitionTool_Gopls(t *testing.T) {
+	t.Parallel()
+
+	dir := symbolModule(t)
+	tl := &FindDefinitionTool{index: symbolIndex{gopls: fakeIndexer(t, "gopls", fakeGopls)}}
+
+	got, err := tl.Call(SymbolParams{Symbol: "New", Path: &dir})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if !strings.Contains(got, "Found 1 definitions of New") || !strings.Contains(got, "Line 3, col 6 (Function): func New() *T") {
+		t.Errorf("output = %q, want the one definition in the module with its source line", got)
+	}
+}
+
+func TestFindReferencesTool_Gopls(t *testing.T) {
+	t.Parallel()
+
+	dir := symbolModule(t)
+	tl := &FindReferencesTool{index: symbolIndex{gopls: fakeIndexer(t, "gopls", fakeGopls)}}
+
+	got, err := tl.Call(SymbolParams{Symbol: "m.New", Path: &dir})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	for _, want := range []string{"2 references to m.New (Function at", "Line 5, col 2: New()"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output = %q, want it to contain %q", got, want)
+		}
+	}
+}
+
+func TestFindReferencesTool_NeedsGoModule(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tl := &FindReferencesTool{index: symbolIndex{gopls: fakeIndexer(t, "gopls", fakeGopls)}}
+
+	if _, err := tl.Call(SymbolParams{Symbol: "New", Path: &dir}); !errors.Is(err, ErrNoSymbolIndex) {
+		t.Errorf("outside a module: got %v, want %v", err, ErrNoSymbolIndex)
+	}
+}
+
+func TestFindDefinitionTool_Ctags(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.py"), []byte("class App:\n    def run(self):\n        pass\n"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	ctags := fakeIndexer(t, "ctags", `echo '{"_type": "ptag", "name": "JSON_OUTPUT_VERSION"}'
+echo '{"_type": "tag", "name": "App", "path": "'"$PWD"'/app.py", "line": 1, "kind": "class"}'
+echo '{"_type": "tag", "name": "run", "path": "'"$PWD"'/app.py", "line": 2, "kind": "member", "scope": "App"}'
+`)
+	tl := &FindDefinitionTool{index: symbolIndex{ctags: ctags}}
+
+	got, err := tl.Call(SymbolParams{Symbol: "App.run", Path: &dir})
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if !strings.Contains(got, "Found 1 definitions of App.run") || !strings.Contains(got, "Line 2 (member): def run(self):") {
+		t.Errorf("output = %q, want the method found by ctags", got)
+	}
+}
+
+func TestFindDefinitionTool_Errors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	tl := &FindDefinitionTool{}
+
+	if _, err := tl.Call(SymbolParams{Symbol: "New", Path: &dir}); !errors.Is(err, ErrNoSymbolIndex) {
+		t.Errorf("no indexer: got %v, want %v", err, ErrNoSymbolIndex)
+	}
+
+	for _, symbol := range []string{"", "-help", "a b", "New("} {
+		if _, err := tl.Call(SymbolParams{Symbol: symbol, Path: &dir}); !errors.Is(err, ErrInvalidSymbol) {
+			t.Errorf("symbol %q: got %v, want %v", symbol, err, ErrInvalidSymbol)
+		}
+	}
+}
+
+func TestMatchesSymbol(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name, symbol string
+		want         bool
+	}{
+		{"New", "New", true},
+		{"agent.New", "New", true},
+		{"agent.New", "agent.New", true},
+		{"github.com/aelse/artoo/agent.New", "agent.New", true},
+		{"agent.Agent.Run", "Agent.Run", true},
+		{"Newer", "New", false},
+		{"conversation.New", "agent.New", false},
+		{"New", "agent.New", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchesSymbol(tt.name, tt.symbol); got != tt.want {
+			t.Errorf("matchesSymbol(%q, %q) = %v, want %v", tt.name, tt.symbol, got, tt.want)
+		}
+	}
+}
+
+func TestParseSpan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		span string
+		want symbolLocation
+		ok   bool
+	}{
+		{"/a/b.go:3:6-9", symbolLocation{path: "/a/b.go", line: 3, column: 6}, true},
+		{"/a/b.go:3:6-4:2", symbolLocation{path: "/a/b.go", line: 3, column: 6}, true},
+		{`C:\a\b.go:10:1`, symbolLocation{path: `C:\a\b.go`, line: 10, column: 1}, true},
+		{"b.go:x:1", symbolLocation{}, false},
+		{"", symbolLocation{}, false},
+	}
+
+	for _, tt := range tests {
+		if got, ok := parseSpan(tt.span); got != tt.want || ok != tt.ok {
+			t.Errorf("parseSpan(%q) = %+v, %v; want %+v, %v", tt.span, got, ok, tt.want, tt.ok)
+		}
+	}
+}
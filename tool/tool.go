@@ -60,9 +60,3 @@ func (w *toolWrapper[P]) Call(block anthropic.ToolUseBlock) *anthropic.ContentBl
 func (w *toolWrapper[P]) Param() anthropic.ToolParam {
 	return w.typed.Param()
 }
-
-var AllTools = []Tool{
-	WrapTypedTool(&RandomNumberTool{}),
-	WrapTypedTool(&GrepTool{}),
-	WrapTypedTool(&LsTool{}),
-}
@@ -0,0 +1,293 @@
+package tool
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Retention limits of a Trash: the oldest versions are removed once either
+// is exceeded, though the newest version is always kept.
+const (
+	trashMaxVersions = 200
+	trashMaxBytes    = 100 << 20
+)
+
+// ErrNoVersion is returned when restoring a version the trash does not hold.
+var ErrNoVersion = errors.New("no such version in the trash")
+
+// SetTrash sets the trash that write_files, move_file and delete_file move
+// overwritten and deleted content to. nil discards it.
+func (e *Environment) SetTrash(t *Trash) {
+	e.trash.Store(t)
+}
+
+// Trash keeps the previous versions of files tools overwrite or delete, so they
+// can be restored where no version control would have them. Each version is
+// a copy of the content beside a JSON file describing it.
+type Trash struct {
+	dir string
+	mu  sync.Mutex
+
+	maxVersions int
+	maxBytes    int64
+}
+
+// TrashEntry describes one version of a file held in a Trash.
+type TrashEntry struct {
+	ID    string      `json:"id"`
+	Path  string      `json:"path"` // resolved path the content was at
+	Saved time.Time   `json:"saved"`
+	Size  int64       `json:"size"`
+	Mode  fs.FileMode `json:"mode"`
+}
+
+// NewTrash returns a trash keeping versions in dir, which is created when
+// the first version is kept.
+func NewTrash(dir string) *Trash {
+	return &Trash{dir: dir, maxVersions: trashMaxVersions, maxBytes: trashMaxBytes}
+}
+
+// trashedFile is a file to move into the trash as a version of path.
+type trashedFile struct {
+	path string
+	file string
+}
+
+// keep moves file, holding the content path had before being overwritten
+// or deleted, into the trash.
+func (t *Trash) keep(path, file string) (TrashEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry, err := t.add(path, file)
+	if err == nil {
+		t.prune()
+	}
+
+	return entry, err
+}
+
+// keepAll keeps several files, pruning once at the end, and returns the
+// number kept. It stops at the first file it fails to keep.
+func (t *Trash) keepAll(files []trashedFile) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	defer t.prune()
+
+	for i, f := range files {
+		if _, err := t.add(f.path, f.file); err != nil {
+			return i, fmt.Errorf("keeping %s: %w", f.path, err)
+		}
+	}
+
+	return len(files), nil
+}
+
+// add moves file into the trash as a version of path, without pruning.
+func (t *Trash) add(path, file string) (TrashEntry, error) {
+	info, err := os.Stat(file)
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	if err := os.MkdirAll(t.dir, 0o750); err != nil {
+		return TrashEntry{}, fmt.Errorf("creating trash directory: %w", err)
+	}
+
+	entry := TrashEntry{Path: path, Saved: time.Now(), Size: info.Size(), Mode: info.Mode().Perm()}
+
+	// IDs sort by age; bump past any taken in the same instant
+	for n := entry.Saved.UnixNano(); ; n++ {
+		entry.ID = strconv.FormatInt(n, 10)
+		if _, err := os.Lstat(t.content(entry.ID)); errors.Is(err, fs.ErrNotExist) {
+			break
+		}
+	}
+
+	if err := moveFile(file, t.content(entry.ID)); err != nil {
+		return TrashEntry{}, err
+	}
+
+	meta, err := json.Marshal(entry)
+	if err == nil {
+		err = os.WriteFile(t.meta(entry.ID), meta, 0o600)
+	}
+
+	if err != nil {
+		_ = os.Remove(t.content(entry.ID))
+
+		return TrashEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// List returns the versions in the trash, newest first.
+func (t *Trash) List() ([]TrashEntry, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.list()
+}
+
+func (t *Trash) list() ([]TrashEntry, error) {
+	names, err := filepath.Glob(filepath.Join(t.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]TrashEntry, 0, len(names))
+
+	for _, name := range names {
+		data, err := os.ReadFile(name) //nolint:gosec // files in the trash directory
+		if err != nil {
+			continue
+		}
+
+		var entry TrashEntry
+		if json.Unmarshal(data, &entry) == nil && entry.ID == strings.TrimSuffix(filepath.Base(name), ".json") {
+			entries = append(entries, entry)
+		}
+	}
+
+	slices.SortFunc(entries, func(a, b TrashEntry) int {
+		return cmp.Or(b.Saved.Compare(a.Saved), cmp.Compare(b.ID, a.ID))
+	})
+
+	return entries, nil
+}
+
+// Restore writes the version with the given ID back to its path. Content
+// the file holds by then is kept in the trash first, so a restore can itself
+// be undone.
+func (t *Trash) Restore(id string) (TrashEntry, error) {
+	entries, err := t.List()
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	i := slices.IndexFunc(entries, func(e TrashEntry) bool { return e.ID == id })
+	if i < 0 {
+		return TrashEntry{}, fmt.Errorf("%w: %s", ErrNoVersion, id)
+	}
+
+	entry := entries[i]
+
+	data, err := os.ReadFile(t.content(id))
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	if err := t.keepCurrent(entry.Path); err != nil {
+		return TrashEntry{}, err
+	}
+
+	temp, _, err := stageContent(entry.Path, string(data))
+	if err != nil {
+		return TrashEntry{}, err
+	}
+
+	if err := os.Chmod(temp, entry.Mode); err == nil {
+		err = os.Rename(temp, entry.Path)
+	}
+
+	if err != nil {
+		_ = os.Remove(temp)
+
+		return TrashEntry{}, err
+	}
+
+	return entry, nil
+}
+
+// keepCurrent keeps a copy of what path holds now, if it is a file, before
+// it is replaced.
+func (t *Trash) keepCurrent(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil //nolint:nilerr // nothing to keep
+	}
+
+	backup, err := backupFile(path, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	if _, err := t.keep(path, backup); err != nil {
+		_ = os.Remove(backup)
+
+		return fmt.Errorf("keeping current content of %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// prune removes the oldest versions beyond the retention limits.
+func (t *Trash) prune() {
+	entries, err := t.list()
+	if err != nil {
+		return
+	}
+
+	var total int64
+	for i, entry := range entries {
+		total += entry.Size
+
+		if i > 0 && (i >= t.maxVersions || total > t.maxBytes) {
+			_ = os.Remove(t.meta(entry.ID))
+			_ = os.Remove(t.content(entry.ID))
+		}
+	}
+}
+
+func (t *Trash) content(id string) string {
+	return filepath.Join(t.dir, id)
+}
+
+func (t *Trash) meta(id string) string {
+	return filepath.Join(t.dir, id+".json")
+}
+
+// moveFile renames src to dst, copying regular files when they are on
+// different file systems.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src) //nolint:gosec // a file tools are moving to the trash
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600) //nolint:gosec // a file in the trash directory
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		_ = os.Remove(dst)
+
+		return err
+	}
+
+	return os.Remove(src)
+}
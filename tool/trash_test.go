@@ -0,0 +1,137 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrash_WriteFilesKeepsOverwrittenContent(t *testing.T) {
+	t.Parallel()
+
+	env := NewEnvironment()
+	trash := useTrash(t, env)
+
+	path := filepath.Join(t.TempDir(), "main.go")
+	tool := bound(env, &WriteFilesTool{})
+
+	for _, content := range []string{"v1", "v2", "v3"} {
+		if _, err := tool.Call(WriteFilesParams{Files: []FileWrite{{Path: path, Content: content}}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := trash.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Creating the file overwrote nothing; the next two writes did
+	if len(entries) != 2 {
+		t.Fatalf("got %d versions, want 2", len(entries))
+	}
+
+	resolved, _ := resolvePath(path)
+	if entries[0].Path != resolved || entries[0].Size != 2 {
+		t.Errorf("unexpected newest version %+v", entries[0])
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".artoo-*"))
+	if len(matches) != 0 {
+		t.Errorf("backups should move to the trash, found %v", matches)
+	}
+}
+
+func TestTrash_Restore(t *testing.T) {
+	t.Parallel()
+
+	trash := NewTrash(t.TempDir())
+	path := filepath.Join(t.TempDir(), "notes.txt")
+
+	keepVersion(t, trash, path, "original")
+
+	if err := os.WriteFile(path, []byte("current"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, _ := trash.List()
+	if _, err := trash.Restore(entries[0].ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "original" {
+		t.Errorf("restored content = %q", data)
+	}
+
+	// The content replaced by the restore is itself recoverable
+	entries, _ = trash.List()
+	if len(entries) != 2 || entries[0].Size != int64(len("current")) {
+		t.Errorf("expected the replaced content to be kept, got %+v", entries)
+	}
+
+	if _, err := trash.Restore("missing"); !errors.Is(err, ErrNoVersion) {
+		t.Errorf("expected ErrNoVersion, got %v", err)
+	}
+}
+
+func TestTrash_RestoreDeletedFile(t *testing.T) {
+	t.Parallel()
+
+	trash := NewTrash(t.TempDir())
+	path := filepath.Join(t.TempDir(), "gone", "file.txt")
+
+	keepVersion(t, trash, path, "data")
+
+	entries, _ := trash.List()
+	if _, err := trash.Restore(entries[0].ID); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "data" {
+		t.Errorf("restored content = %q", data)
+	}
+}
+
+func TestTrash_Retention(t *testing.T) {
+	t.Parallel()
+
+	trash := NewTrash(t.TempDir())
+	trash.maxVersions = 3
+	trash.maxBytes = 10
+
+	path := filepath.Join(t.TempDir(), "f")
+	for _, content := range []string{"aaaa", "bbbb", "cccc", "dddd"} {
+		keepVersion(t, trash, path, content)
+	}
+
+	entries, _ := trash.List()
+	if len(entries) != 2 || entries[0].Size != 4 {
+		t.Fatalf("the byte limit should leave the 2 newest versions, got %+v", entries)
+	}
+
+	// The newest version is kept even when alone over the limit
+	keepVersion(t, trash, path, "larger than the limit")
+
+	if entries, _ = trash.List(); len(entries) != 1 {
+		t.Errorf("got %d versions, want 1", len(entries))
+	}
+
+	if files, _ := os.ReadDir(trash.dir); len(files) != 2 {
+		t.Errorf("pruned versions should be removed from disk, %d files remain", len(files))
+	}
+}
+
+// keepVersion puts content into trash as a previous version of path.
+func keepVersion(t *testing.T, trash *Trash, path, content string) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "backup")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := trash.keep(path, file); err != nil {
+		t.Fatalf("keep() error = %v", err)
+	}
+}
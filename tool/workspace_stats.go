@@ -0,0 +1,346 @@
+package tool
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+const (
+	// statsMaxFiles caps the files workspace_stats reads in one call.
+	statsMaxFiles = 20000
+
+	// statsMaxLineBytes is the largest file whose lines are counted; larger
+	// files count towards sizes only.
+	statsMaxLineBytes = 8 << 20
+
+	// statsSniffBytes is how much of a file is searched for the NUL byte
+	// that marks it binary.
+	statsSniffBytes = 8000
+
+	statsDefaultTop = 10
+	statsMaxTop     = 50
+)
+
+// languages names the language of source files by extension.
+var languages = map[string]string{
+	".go": "Go", ".py": "Python", ".rb": "Ruby", ".php": "PHP", ".pl": "Perl", ".lua": "Lua",
+	".js": "JavaScript", ".jsx": "JavaScript", ".mjs": "JavaScript", ".cjs": "JavaScript",
+	".ts": "TypeScript", ".tsx": "TypeScript", ".vue": "Vue", ".svelte": "Svelte",
+	".rs": "Rust", ".c": "C", ".h": "C", ".cc": "C++", ".cpp": "C++", ".cxx": "C++", ".hpp": "C++",
+	".cs": "C#", ".java": "Java", ".kt": "Kotlin", ".kts": "Kotlin", ".scala": "Scala", ".swift": "Swift",
+	".m": "Objective-C", ".zig": "Zig", ".dart": "Dart", ".ex": "Elixir", ".exs": "Elixir", ".erl": "Erlang",
+	".hs": "Haskell", ".ml": "OCaml", ".clj": "Clojure", ".r": "R", ".jl": "Julia",
+	".sh": "Shell", ".bash": "Shell", ".zsh": "Shell", ".ps1": "PowerShell",
+	".html": "HTML", ".css": "CSS", ".scss": "SCSS", ".sql": "SQL", ".proto": "Protocol Buffers",
+	".tf": "Terraform", ".md": "Markdown", ".json": "JSON", ".yaml": "YAML", ".yml": "YAML",
+	".toml": "TOML", ".xml": "XML",
+}
+
+// languageNames names the language of files known by their whole name.
+var languageNames = map[string]string{
+	"makefile": "Makefile", "dockerfile": "Dockerfile", "jenkinsfile": "Groovy",
+	"go.mod": "Go", "go.sum": "Go",
+}
+
+// WorkspaceStatsParams defines the parameters for the workspace_stats tool.
+type WorkspaceStatsParams struct {
+	Path *string `json:"path,omitempty"` // Optional directory to measure
+	Top  *int    `json:"top,omitempty"`  // Optional number of largest files and directories to list
+}
+
+// Ensure WorkspaceStatsTool implements TypedTool[WorkspaceStatsParams].
+var _ TypedTool[WorkspaceStatsParams] = (*WorkspaceStatsTool)(nil)
+
+// WorkspaceStatsTool sizes up a directory tree: files and lines by language
+// and the largest files and directories. It sees the files the list tool
+// does.
+type WorkspaceStatsTool struct {
+	envBinding
+}
+
+// workspaceStats is the measure of a tree.
+type workspaceStats struct {
+	files, lines int
+	bytes        int64
+	skipped      int // files beyond statsMaxFiles
+	languages    map[string]*fileTally
+	dirs         map[string]*fileTally // by top-level directory
+	largest      []fileSize
+}
+
+// fileTally counts files, lines and bytes.
+type fileTally struct {
+	name   string
+	files  int
+	lines  int
+	bytes  int64
+	binary bool
+}
+
+type fileSize struct {
+	path  string
+	bytes int64
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *WorkspaceStatsTool) Call(params WorkspaceStatsParams) (string, error) {
+	searchPath := t.environment().defaultSearchPath()
+	if params.Path != nil && *params.Path != "" {
+		searchPath = *params.Path
+	}
+
+	top := statsDefaultTop
+	if params.Top != nil && *params.Top > 0 {
+		top = min(*params.Top, statsMaxTop)
+	}
+
+	root, err := resolvePath(searchPath)
+	if err != nil {
+		return "", fmt.Errorf("resolving path: %w", err)
+	}
+
+	ignore, err := newIgnoreMatcher(ignorePatterns)
+	if err != nil {
+		return "", err
+	}
+
+	files, err := (&LsTool{}).getFiles(root)
+	if err != nil {
+		return "", fmt.Errorf("listing files: %w", err)
+	}
+
+	// Sorted, so which files a capped measure skips does not vary
+	files = ignore.Filter(files)
+	slices.Sort(files)
+
+	stats := collectStats(root, files)
+
+	return stats.format(displayPath(root), top), nil
+}
+
+// collectStats measures files, given relative to root.
+func collectStats(root string, files []string) workspaceStats {
+	stats := workspaceStats{languages: map[string]*fileTally{}, dirs: map[string]*fileTally{}}
+
+	if len(files) > statsMaxFiles {
+		stats.skipped = len(files) - statsMaxFiles
+		files = files[:statsMaxFiles]
+	}
+
+	for _, file := range files {
+		size, lines, binary, err := measureFile(filepath.Join(root, file))
+		if err != nil {
+			continue
+		}
+
+		stats.files++
+		stats.lines += lines
+		stats.bytes += size
+		stats.largest = append(stats.largest, fileSize{path: file, bytes: size})
+
+		language := languageOf(file)
+		if binary {
+			language = "(binary)"
+		}
+
+		tally(stats.languages, language, lines, size, binary)
+
+		if dir, _, ok := strings.Cut(filepath.ToSlash(file), "/"); ok {
+			tally(stats.dirs, dir+"/", lines, size, false)
+		}
+	}
+
+	slices.SortFunc(stats.largest, func(a, b fileSize) int {
+		return cmp.Or(cmp.Compare(b.bytes, a.bytes), strings.Compare(a.path, b.path))
+	})
+
+	return stats
+}
+
+func tally(tallies map[string]*fileTally, name string, lines int, size int64, binary bool) {
+	t := tallies[name]
+	if t == nil {
+		t = &fileTally{name: name, binary: binary}
+		tallies[name] = t
+	}
+
+	t.files++
+	t.lines += lines
+	t.bytes += size
+}
+
+// measureFile returns a file's size and line count, and whether it looks
+// binary, in which case its lines are not counted.
+func measureFile(path string) (int64, int, bool, error) {
+	f, err := os.Open(path) //nolint:gosec // files in the tree being measured
+	if err != nil {
+		return 0, 0, false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	// Files too large to count lines in are only sniffed for binary content
+	limit := int64(statsMaxLineBytes)
+	if info.Size() > limit {
+		limit = statsSniffBytes
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, limit))
+	if err != nil {
+		return 0, 0, false, err
+	}
+
+	if bytes.IndexByte(data[:min(len(data), statsSniffBytes)], 0) >= 0 {
+		return info.Size(), 0, true, nil
+	}
+
+	if info.Size() > statsMaxLineBytes {
+		return info.Size(), 0, false, nil
+	}
+
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		lines++
+	}
+
+	return info.Size(), lines, false, nil
+}
+
+// languageOf names the language of file from its name or extension.
+func languageOf(file string) string {
+	base := strings.ToLower(filepath.Base(file))
+	if name, ok := languageNames[base]; ok {
+		return name
+	}
+
+	ext := filepath.Ext(base)
+	if name, ok := languages[ext]; ok {
+		return name
+	}
+
+	if ext == "" || ext == base {
+		return "(no extension)"
+	}
+
+	return ext
+}
+
+// format renders the statistics, listing top entries in each ranking.
+func (s workspaceStats) format(root string, top int) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s: %d files, %d lines, %s\n", root, s.files, s.lines, formatBytes(s.bytes))
+
+	if s.skipped > 0 {
+		fmt.Fprintf(&b, "(only the first %d files were measured; %d more were skipped. Measure a subdirectory for full counts.)\n",
+			statsMaxFiles, s.skipped)
+	}
+
+	if s.files == 0 {
+		return b.String()
+	}
+
+	b.WriteString("\nBy language:\n")
+	writeTallies(&b, s.languages, len(s.languages), func(a, b *fileTally) int {
+		return cmp.Or(cmp.Compare(b.lines, a.lines), cmp.Compare(b.files, a.files), strings.Compare(a.name, b.name))
+	})
+
+	b.WriteString("\nLargest files:\n")
+
+	for _, f := range s.largest[:min(top, len(s.largest))] {
+		fmt.Fprintf(&b, "  %-40s %s\n", filepath.ToSlash(f.path), formatBytes(f.bytes))
+	}
+
+	if len(s.dirs) > 0 {
+		b.WriteString("\nLargest top-level directories:\n")
+		writeTallies(&b, s.dirs, top, func(a, b *fileTally) int {
+			return cmp.Or(cmp.Compare(b.bytes, a.bytes), strings.Compare(a.name, b.name))
+		})
+	}
+
+	return b.String()
+}
+
+// writeTallies writes up to n tallies in the order given by compare.
+func writeTallies(b *strings.Builder, tallies map[string]*fileTally, n int, compare func(a, b *fileTally) int) {
+	sorted := make([]*fileTally, 0, len(tallies))
+	for _, t := range tallies {
+		sorted = append(sorted, t)
+	}
+
+	slices.SortFunc(sorted, compare)
+
+	for _, t := range sorted[:min(n, len(sorted))] {
+		if t.binary {
+			fmt.Fprintf(b, "  %-20s %6d files %12s %10s\n", t.name, t.files, "", formatBytes(t.bytes))
+
+			continue
+		}
+
+		fmt.Fprintf(b, "  %-20s %6d files %6d lines %10s\n", t.name, t.files, t.lines, formatBytes(t.bytes))
+	}
+}
+
+// formatBytes renders a size with a binary unit.
+func formatBytes(n int64) string {
+	const unit = 1024
+
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	size, suffix := float64(n)/unit, "KiB"
+
+	for _, next := range []string{"MiB", "GiB", "TiB"} {
+		if size < unit {
+			break
+		}
+
+		size, suffix = size/unit, next
+	}
+
+	return fmt.Sprintf("%.1f %s", size, suffix)
+}
+
+func (t *WorkspaceStatsTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "workspace_stats",
+		Description: anthropic.String("Size up a directory tree in one call: file and line counts by language, " +
+			"total size, and the largest files and top-level directories. Use it first in an unfamiliar repository " +
+			"instead of listing directories one by one. Ignores the same files as list."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"path": map[string]any{
+					"type":        "string",
+					"description": "The directory to measure. Defaults to the current workspace directory.",
+				},
+				"top": map[string]any{
+					"type":        "integer",
+					"description": fmt.Sprintf("Number of largest files and directories to list (default %d, at most %d)", statsDefaultTop, statsMaxTop),
+				},
+			},
+		},
+	}
+}
+
+// ReadOnly implements ReadOnly; WorkspaceStatsTool never modifies the filesystem.
+func (t *WorkspaceStatsTool) ReadOnly() bool {
+	return true
+}
+
+// Idempotent implements Idempotent so repeated identical calls can be cached.
+func (t *WorkspaceStatsTool) Idempotent() bool {
+	return true
+}
@@ -0,0 +1,87 @@
+package tool
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCollectStats(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	files := map[string]string{
+		"main.go":          "package main\n\nfunc main() {}\n",
+		"pkg/util.go":      "package pkg\n// no trailing newline",
+		"pkg/util_test.go": "package pkg\n",
+		"web/app.ts":       strings.Repeat("x\n", 50),
+		"web/logo.png":     "\x89PNG\x00\x00data",
+		"Makefile":         "all:\n",
+	}
+
+	names := make([]string, 0, len(files))
+	for name, content := range files {
+		writeTestFile(t, filepath.Join(root, name), content)
+		names = append(names, name)
+	}
+
+	stats := collectStats(root, names)
+
+	if stats.files != 6 || stats.lines != 3+2+1+50+1 {
+		t.Errorf("got %d files and %d lines", stats.files, stats.lines)
+	}
+
+	if g := stats.languages["Go"]; g == nil || g.files != 3 || g.lines != 6 {
+		t.Errorf("Go tally = %+v", g)
+	}
+
+	if b := stats.languages["(binary)"]; b == nil || b.lines != 0 {
+		t.Errorf("binary files should be tallied apart, without lines: %+v", b)
+	}
+
+	if stats.largest[0].path != filepath.Join("web", "app.ts") {
+		t.Errorf("largest file = %s", stats.largest[0].path)
+	}
+
+	if w := stats.dirs["web/"]; w == nil || w.files != 2 {
+		t.Errorf("web/ tally = %+v", w)
+	}
+
+	out := stats.format("repo", 2)
+	for _, want := range []string{"repo: 6 files, 57 lines", "By language:", "TypeScript", "Makefile", "Largest files:", "web/app.ts", "Largest top-level directories:"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if strings.Contains(out, "main.go ") {
+		t.Errorf("only the top 2 files should be listed:\n%s", out)
+	}
+}
+
+func TestLanguageOf(t *testing.T) {
+	t.Parallel()
+
+	for file, want := range map[string]string{
+		"cmd/main.go": "Go",
+		"App.TSX":     "TypeScript",
+		"Dockerfile":  "Dockerfile",
+		"data.parq":   ".parq",
+		"LICENSE":     "(no extension)",
+		".gitignore":  "(no extension)",
+	} {
+		if got := languageOf(file); got != want {
+			t.Errorf("languageOf(%q) = %q, want %q", file, got, want)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	t.Parallel()
+
+	for n, want := range map[int64]string{512: "512 B", 2048: "2.0 KiB", 5 << 20: "5.0 MiB", 3 << 30: "3.0 GiB"} {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, want %q", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,344 @@
+package tool
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/anthropics/anthropic-sdk-go"
+)
+
+// newFileMode is the permission of files created by write_files.
+const newFileMode = 0o644
+
+var (
+	// ErrNoFiles is returned when write_files is called without any files.
+	ErrNoFiles = errors.New("no files to write")
+
+	// ErrInvalidPath is returned when a write_files path is empty or cannot be resolved.
+	ErrInvalidPath = errors.New("invalid path")
+
+	// ErrDuplicatePath is returned when write_files names the same file twice.
+	ErrDuplicatePath = errors.New("file listed more than once")
+)
+
+// WriteFilesParams defines the parameters for the write_files tool.
+type WriteFilesParams struct {
+	Files []FileWrite `json:"files"`
+}
+
+// FileWrite is one file to write: its path and complete new content.
+type FileWrite struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// Ensure WriteFilesTool implements TypedTool[WriteFilesParams].
+var _ TypedTool[WriteFilesParams] = (*WriteFilesTool)(nil)
+
+// WriteFilesTool writes several files as one transaction: either every file
+// gets its new content or, if any write fails, all files are left as they were.
+// Overwritten content is moved to the trash set with SetTrash, if any.
+type WriteFilesTool struct {
+	envBinding
+}
+
+// stagedWrite tracks one file through a write_files transaction.
+type stagedWrite struct {
+	path     string
+	size     int
+	temp     string // new content, renamed over path on commit
+	backup   string   // copy of the original content ("" if the file is new)
+	dirs     []string // directories created for the file, deepest first
+	replaced bool     // temp has been renamed over path
+}
+
+// Call implements TypedTool.Call with strongly-typed parameters.
+func (t *WriteFilesTool) Call(params WriteFilesParams) (string, error) {
+	if len(params.Files) == 0 {
+		return "", ErrNoFiles
+	}
+
+	env := t.environment()
+	staged := make([]*stagedWrite, 0, len(params.Files))
+	seen := make(map[string]bool, len(params.Files))
+	done := false
+
+	// Clean up temporary files and backups however the transaction ends,
+	// and the directories it created if it failed
+	defer func() {
+		for _, s := range staged {
+			if !s.replaced && s.temp != "" {
+				_ = os.Remove(s.temp)
+			}
+
+			if s.backup != "" {
+				_ = os.Remove(s.backup)
+			}
+		}
+
+		if done {
+			return
+		}
+
+		for i := len(staged) - 1; i >= 0; i-- {
+			for _, dir := range staged[i].dirs {
+				_ = os.Remove(dir)
+			}
+		}
+	}()
+
+	// Stage every file's new content next to it before touching any file
+	for _, f := range params.Files {
+		path, err := resolvePath(f.Path)
+		if err != nil || f.Path == "" {
+			return "", fmt.Errorf("%w: %q", ErrInvalidPath, f.Path)
+		}
+
+		if seen[path] {
+			return "", fmt.Errorf("%w: %s", ErrDuplicatePath, f.Path)
+		}
+
+		seen[path] = true
+
+		s := &stagedWrite{path: path, size: len(f.Content)}
+		staged = append(staged, s)
+
+		// Content streamed to disk while the call arrived only needs renaming
+		if s.temp = env.streamed.take(path, f.Content); s.temp != "" {
+			continue
+		}
+
+		if s.temp, s.dirs, err = stageContent(path, f.Content); err != nil {
+			return "", fmt.Errorf("writing %s: %w", f.Path, err)
+		}
+	}
+
+	paths := make([]string, len(staged))
+	for i, s := range staged {
+		paths[i] = s.path
+	}
+
+	changes := env.captureChanges(paths...)
+
+	// Back up and replace each file, undoing earlier replacements on failure
+	for _, s := range staged {
+		if err := s.commit(); err != nil {
+			rollback(staged)
+
+			return "", fmt.Errorf("writing %s (earlier files in the batch were rolled back): %w", displayPath(s.path), err)
+		}
+	}
+
+	done = true
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Wrote %d files:", len(staged))
+
+	for _, s := range staged {
+		fmt.Fprintf(&b, "\n%s (%d bytes)", displayPath(s.path), s.size)
+	}
+
+	env.recordChanges(changes)
+
+	// The transaction is complete: keep what it overwrote in the trash
+	if trash := env.trash.Load(); trash != nil {
+		for _, s := range staged {
+			if s.backup == "" {
+				continue
+			}
+
+			if _, err := trash.keep(s.path, s.backup); err != nil {
+				fmt.Fprintf(&b, "\n(previous content of %s was not kept: %v)", displayPath(s.path), err)
+
+				continue
+			}
+
+			s.backup = ""
+		}
+	}
+
+	return b.String(), nil
+}
+
+// stageContent writes content to a temporary file in path's directory,
+// creating the directory if needed. It returns the temporary file's name and
+// the directories it created, deepest first, so a rollback can remove them.
+func stageContent(path, content string) (string, []string, error) {
+	dir := filepath.Dir(path)
+
+	created := missingDirs(dir)
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return "", created, err
+	}
+
+	tmp, err := os.CreateTemp(dir, ".artoo-write-*")
+	if err != nil {
+		return "", created, err
+	}
+
+	if _, err := tmp.WriteString(content); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+
+		return "", created, err
+	}
+
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return "", created, err
+	}
+
+	return tmp.Name(), created, nil
+}
+
+// missingDirs returns dir and those of its parents that do not exist, deepest first.
+func missingDirs(dir string) []string {
+	var missing []string
+
+	for {
+		if _, err := os.Stat(dir); !errors.Is(err, fs.ErrNotExist) {
+			return missing
+		}
+
+		missing = append(missing, dir)
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return missing
+		}
+
+		dir = parent
+	}
+}
+
+// commit backs up the file's current content, if any, then atomically
+// replaces it with the staged content, keeping its permissions.
+func (s *stagedWrite) commit() error {
+	mode := fs.FileMode(newFileMode)
+
+	info, err := os.Stat(s.path)
+
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+
+		if s.backup, err = backupFile(s.path, mode); err != nil {
+			return err
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if err := os.Chmod(s.temp, mode); err != nil {
+		return err
+	}
+
+	if err := os.Rename(s.temp, s.path); err != nil {
+		return err
+	}
+
+	s.replaced = true
+
+	return nil
+}
+
+// backupFile copies path to a temporary file beside it and returns its name.
+func backupFile(path string, mode fs.FileMode) (string, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path chosen by the model, as for every file tool
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".artoo-backup-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err == nil {
+		err = os.Chmod(tmp.Name(), mode)
+	}
+
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+
+		return "", err
+	}
+
+	return tmp.Name(), nil
+}
+
+// rollback restores replaced files from their backups and removes files
+// that did not exist before the transaction.
+func rollback(staged []*stagedWrite) {
+	for _, s := range staged {
+		if !s.replaced {
+			continue
+		}
+
+		if s.backup == "" {
+			_ = os.Remove(s.path)
+
+			continue
+		}
+
+		if err := os.Rename(s.backup, s.path); err == nil {
+			s.backup = ""
+		}
+	}
+}
+
+func (t *WriteFilesTool) Param() anthropic.ToolParam {
+	return anthropic.ToolParam{
+		Name: "write_files",
+		Description: anthropic.String("Write the complete content of one or more files as a single transaction. " +
+			"Missing directories are created. If any file cannot be written, every file is left unchanged."),
+		InputSchema: anthropic.ToolInputSchemaParam{
+			Properties: map[string]any{
+				"files": map[string]any{
+					"type":        "array",
+					"description": "Files to write",
+					"items": map[string]any{
+						"type": "object",
+						"properties": map[string]any{
+							"path": map[string]any{
+								"type":        "string",
+								"description": "Path of the file, absolute or relative to the working directory",
+							},
+							"content": map[string]any{
+								"type":        "string",
+								"description": "Complete new content of the file",
+							},
+						},
+						"required": []string{"path", "content"},
+					},
+				},
+			},
+			Required: []string{"files"},
+		},
+	}
+}
+
+// EditsFiles implements FileEditor; write_files only writes files.
+func (t *WriteFilesTool) EditsFiles() bool {
+	return true
+}
+
+// Concurrency implements Concurrent: writes to different files may overlap.
+func (t *WriteFilesTool) Concurrency(params WriteFilesParams) (ConcurrencyClass, []string) {
+	paths := make([]string, 0, len(params.Files))
+	for _, f := range params.Files {
+		paths = append(paths, f.Path)
+	}
+
+	return ConcurrencyPerPath, paths
+}
@@ -0,0 +1,130 @@
+package tool
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteFilesTool_Call(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "main.go")
+
+	if err := os.WriteFile(existing, []byte("old"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	params := WriteFilesParams{Files: []FileWrite{
+		{Path: existing, Content: "package main\n"},
+		{Path: filepath.Join(dir, "pkg", "util", "util.go"), Content: "package util\n"},
+	}}
+
+	out, err := (&WriteFilesTool{}).Call(params)
+	if err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if !strings.HasPrefix(out, "Wrote 2 files:") {
+		t.Errorf("unexpected output %q", out)
+	}
+
+	for _, f := range params.Files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil || string(data) != f.Content {
+			t.Errorf("%s: got %q, %v", f.Path, data, err)
+		}
+	}
+
+	if info, err := os.Stat(existing); err != nil || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the existing file's permissions to be kept, got %v", info.Mode())
+	}
+
+	assertNoLeftovers(t, dir)
+}
+
+func TestWriteFilesTool_RollsBackOnFailure(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "a.txt")
+	created := filepath.Join(dir, "new", "sub", "b.txt")
+	blocked := filepath.Join(dir, "c")
+
+	if err := os.WriteFile(existing, []byte("original"), 0o600); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	// A non-empty directory cannot be replaced by a file
+	if err := os.MkdirAll(filepath.Join(blocked, "child"), 0o750); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	_, err := (&WriteFilesTool{}).Call(WriteFilesParams{Files: []FileWrite{
+		{Path: existing, Content: "changed"},
+		{Path: created, Content: "new"},
+		{Path: blocked, Content: "oops"},
+	}})
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	if data, err := os.ReadFile(existing); err != nil || string(data) != "original" {
+		t.Errorf("expected %s to be restored, got %q, %v", existing, data, err)
+	}
+
+	if _, err := os.Stat(created); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected %s to be removed, got %v", created, err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "new")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected the directories created for %s to be removed, got %v", created, err)
+	}
+
+	assertNoLeftovers(t, dir)
+}
+
+func TestWriteFilesTool_InvalidParams(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+
+	tests := []struct {
+		name  string
+		files []FileWrite
+		want  error
+	}{
+		{"no files", nil, ErrNoFiles},
+		{"empty path", []FileWrite{{Path: "", Content: "x"}}, ErrInvalidPath},
+		{"duplicate", []FileWrite{{Path: path, Content: "x"}, {Path: path, Content: "y"}}, ErrDuplicatePath},
+	}
+
+	for _, tt := range tests {
+		if _, err := (&WriteFilesTool{}).Call(WriteFilesParams{Files: tt.files}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected nothing to be written, got %v", err)
+	}
+
+	assertNoLeftovers(t, dir)
+}
+
+// assertNoLeftovers fails if temporary or backup files remain in dir.
+func assertNoLeftovers(t *testing.T, dir string) {
+	t.Helper()
+
+	_ = filepath.WalkDir(dir, func(path string, _ os.DirEntry, _ error) error {
+		if strings.HasPrefix(filepath.Base(path), ".artoo-") {
+			t.Errorf("leftover file %s", path)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,260 @@
+package tool
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// InputStreamer is implemented by tools that act on their input while it
+// streams in, before the call runs.
+type InputStreamer interface {
+	// StreamInput returns where the JSON input of one call is written as it
+	// arrives. Close ends the stream once the call has run or will not run.
+	StreamInput() io.WriteCloser
+}
+
+// StreamInput returns the input stream for a call of t, or nil if t does not
+// act on streamed input.
+func StreamInput(t Tool) io.WriteCloser {
+	s, ok := t.(InputStreamer)
+	if !ok {
+		return nil
+	}
+
+	return s.StreamInput()
+}
+
+// StreamInput implements InputStreamer by delegating to the typed tool.
+func (w *toolWrapper[P]) StreamInput() io.WriteCloser {
+	s, ok := w.typed.(InputStreamer)
+	if !ok {
+		return nil
+	}
+
+	return s.StreamInput()
+}
+
+// streamedFile is new content for a path written to a temporary file while
+// a write_files call streamed in.
+type streamedFile struct {
+	temp string
+	size int
+	sum  [sha256.Size]byte
+}
+
+// streamedFiles holds the streamed content not yet taken by a call, by
+// resolved path.
+type streamedFiles struct {
+	mu    sync.Mutex
+	files map[string]streamedFile
+}
+
+// take returns the temporary file holding content for path, if content was
+// streamed to one, and hands it to the caller. Content that differs from
+// what was streamed leaves the temporary file to its stream.
+func (s *streamedFiles) take(path, content string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.files[path]
+	if !ok || f.size != len(content) || f.sum != sha256.Sum256([]byte(content)) {
+		return ""
+	}
+
+	delete(s.files, path)
+
+	return f.temp
+}
+
+// StreamInput implements InputStreamer: each file's content is written to a
+// temporary file next to it as it arrives, so when the call runs it only has
+// to be renamed into place. Files whose path arrives after their content, or
+// whose directory does not exist yet, are written when the call runs.
+func (t *WriteFilesTool) StreamInput() io.WriteCloser {
+	ws := &writeStream{streamed: &t.environment().streamed, paths: map[int]string{}}
+	ws.json.value = ws.value
+
+	return ws
+}
+
+// writeStream is the input stream of one write_files call.
+type writeStream struct {
+	streamed *streamedFiles // where the call finds the streamed content
+	json     jsonStream
+	paths    map[int]string // resolved path of each file, by index
+	path     []byte         // path being read
+
+	// The file whose content is being read
+	index  int
+	open   bool // content is being read
+	file   *os.File
+	buf    *bufio.Writer
+	sum    hash.Hash
+	size   int
+	temps  []string // streamed files, removed on Close unless taken
+	closed bool
+}
+
+// Write feeds the next chunk of the call's JSON input.
+func (w *writeStream) Write(p []byte) (int, error) {
+	return w.json.Write(p)
+}
+
+// value handles string values of the input: files[i].path and
+// files[i].content.
+func (w *writeStream) value(frames []jsonFrame, piece []byte, done bool) {
+	if len(frames) != 3 || frames[0].key != "files" || !frames[1].array {
+		return
+	}
+
+	index := frames[1].index
+
+	switch frames[2].key {
+	case "path":
+		w.path = append(w.path, piece...)
+		if done {
+			w.setPath(index, string(w.path))
+			w.path = w.path[:0]
+		}
+	case "content":
+		if !w.open {
+			w.start(index)
+		}
+
+		w.write(piece)
+
+		if done {
+			w.finish()
+		}
+	}
+}
+
+// setPath records the resolved path of file index, if it can be streamed to.
+func (w *writeStream) setPath(index int, path string) {
+	resolved, err := resolvePath(path)
+	if err != nil || path == "" {
+		return
+	}
+
+	// Directories are created when the call runs, which may be declined
+	if info, err := os.Stat(filepath.Dir(resolved)); err != nil || !info.IsDir() {
+		return
+	}
+
+	w.paths[index] = resolved
+}
+
+// start begins the content of file index, streaming it to a temporary file
+// if its path is known.
+func (w *writeStream) start(index int) {
+	w.index, w.open, w.size = index, true, 0
+
+	path, ok := w.paths[index]
+	if !ok || w.closed {
+		return
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".artoo-write-*")
+	if err != nil {
+		return
+	}
+
+	w.file, w.buf, w.sum = file, bufio.NewWriter(file), sha256.New()
+}
+
+// write appends a piece of content to the temporary file, if any.
+func (w *writeStream) write(piece []byte) {
+	w.size += len(piece)
+
+	if w.file == nil {
+		return
+	}
+
+	if _, err := w.buf.Write(piece); err != nil {
+		w.discard()
+
+		return
+	}
+
+	w.sum.Write(piece)
+}
+
+// finish completes the temporary file and offers it to the call.
+func (w *writeStream) finish() {
+	w.open = false
+
+	if w.file == nil {
+		return
+	}
+
+	file := w.file
+	w.file = nil
+
+	if err := w.buf.Flush(); err != nil {
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+
+		return
+	}
+
+	if err := file.Close(); err != nil {
+		_ = os.Remove(file.Name())
+
+		return
+	}
+
+	f := streamedFile{temp: file.Name(), size: w.size}
+	copy(f.sum[:], w.sum.Sum(nil))
+
+	w.streamed.mu.Lock()
+	defer w.streamed.mu.Unlock()
+
+	// A later call streaming the same path supersedes this one
+	if old, ok := w.streamed.files[w.paths[w.index]]; ok {
+		_ = os.Remove(old.temp)
+	}
+
+	if w.streamed.files == nil {
+		w.streamed.files = map[string]streamedFile{}
+	}
+
+	w.streamed.files[w.paths[w.index]] = f
+	w.temps = append(w.temps, f.temp)
+}
+
+// discard abandons the temporary file being written.
+func (w *writeStream) discard() {
+	_ = w.file.Close()
+	_ = os.Remove(w.file.Name())
+	w.file = nil
+}
+
+// Close removes the streamed files no call took, and any content still
+// being written.
+func (w *writeStream) Close() error {
+	w.closed = true
+
+	if w.file != nil {
+		w.discard()
+	}
+
+	w.streamed.mu.Lock()
+	defer w.streamed.mu.Unlock()
+
+	for path, f := range w.streamed.files {
+		if slices.Contains(w.temps, f.temp) {
+			delete(w.streamed.files, path)
+			_ = os.Remove(f.temp)
+		}
+	}
+
+	w.temps = nil
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package tool
+
+import (
+	"encoding/json"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// streamCall streams params to a write_files input stream in small chunks,
+// as the API delivers them.
+func streamCall(t *testing.T, params WriteFilesParams) io.WriteCloser {
+	t.Helper()
+
+	input, err := json.Marshal(params)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	w := (&WriteFilesTool{}).StreamInput()
+	for chunk := range chunksOf(input, 7) {
+		_, _ = w.Write(chunk)
+	}
+
+	return w
+}
+
+// chunksOf yields b in pieces of at most n bytes.
+func chunksOf(b []byte, n int) iter.Seq[[]byte] {
+	return func(yield func([]byte) bool) {
+		for len(b) > 0 {
+			size := min(n, len(b))
+			if !yield(b[:size]) {
+				return
+			}
+
+			b = b[size:]
+		}
+	}
+}
+
+func TestWriteFilesTool_StreamedContent(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.txt")
+	params := WriteFilesParams{Files: []FileWrite{
+		{Path: path, Content: strings.Repeat("line \"quoted\"\n", 1000)},
+		{Path: filepath.Join(dir, "new", "dir.txt"), Content: "not streamed: its directory is new"},
+	}}
+
+	w := streamCall(t, params)
+
+	// The first file's content is on disk before the call runs
+	temps, _ := filepath.Glob(filepath.Join(dir, ".artoo-write-*"))
+	if len(temps) != 1 {
+		t.Fatalf("%d streamed files before the call, want 1", len(temps))
+	}
+
+	if _, err := (&WriteFilesTool{}).Call(params); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	_ = w.Close()
+
+	for _, f := range params.Files {
+		if data, err := os.ReadFile(f.Path); err != nil || string(data) != f.Content {
+			t.Errorf("%s: got %d bytes, %v; want the content", f.Path, len(data), err)
+		}
+	}
+
+	assertNoLeftovers(t, dir)
+}
+
+func TestWriteFilesTool_StreamedContentMismatch(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+
+	w := streamCall(t, WriteFilesParams{Files: []FileWrite{{Path: path, Content: "streamed"}}})
+
+	// The call's final input differs, so its own content is written
+	if _, err := (&WriteFilesTool{}).Call(WriteFilesParams{Files: []FileWrite{{Path: path, Content: "final"}}}); err != nil {
+		t.Fatalf("Call: %v", err)
+	}
+
+	if data, _ := os.ReadFile(path); string(data) != "final" {
+		t.Errorf("content = %q, want final", data)
+	}
+
+	_ = w.Close()
+
+	assertNoLeftovers(t, dir)
+}
+
+func TestWriteFilesTool_StreamClosedWithoutCall(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a.txt")
+
+	w := streamCall(t, WriteFilesParams{Files: []FileWrite{{Path: path, Content: "declined"}}})
+	_ = w.Close()
+
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("stat %s: %v, want the file not written", path, err)
+	}
+
+	assertNoLeftovers(t, dir)
+}
@@ -0,0 +1,189 @@
+package ui
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/atotto/clipboard"
+	"github.com/charmbracelet/x/term"
+	"github.com/muesli/cancelreader"
+)
+
+const (
+	clipboardTimeout  = 3 * time.Second
+	osc52ReplyTimeout = 2 * time.Second
+	pngMediaType      = "image/png"
+)
+
+var (
+	errClipboardEmpty = errors.New("clipboard is empty")
+	errNotATerminal   = errors.New("stdin is not a terminal")
+	errNoOSC52Reply   = errors.New("terminal did not answer the OSC 52 clipboard query")
+)
+
+// ClipboardContent is the data pulled from the system clipboard.
+type ClipboardContent struct {
+	Text      string
+	Image     []byte // raw image bytes, set instead of Text for image clipboards
+	MediaType string // media type of Image, e.g. "image/png"
+}
+
+// ReadClipboard returns the clipboard contents, preferring an image when one
+// is present. Native tools are tried first (pbpaste/osascript on macOS,
+// wl-paste or xclip on Linux). When no native clipboard is reachable, as over
+// SSH, text is requested from the terminal with an OSC 52 query.
+func ReadClipboard() (ClipboardContent, error) {
+	if img, err := readClipboardImage(); err == nil && len(img) > 0 {
+		return ClipboardContent{Image: img, MediaType: pngMediaType}, nil
+	}
+
+	text, err := clipboard.ReadAll()
+	if err != nil || text == "" {
+		text, err = readOSC52()
+	}
+
+	if err != nil {
+		return ClipboardContent{}, fmt.Errorf("reading clipboard: %w", err)
+	}
+
+	if text == "" {
+		return ClipboardContent{}, errClipboardEmpty
+	}
+
+	return ClipboardContent{Text: text}, nil
+}
+
+// readClipboardImage returns PNG data from the clipboard using platform tools.
+func readClipboardImage() ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), clipboardTimeout)
+	defer cancel()
+
+	if runtime.GOOS == "darwin" {
+		if _, err := exec.LookPath("pngpaste"); err == nil {
+			return exec.CommandContext(ctx, "pngpaste", "-").Output()
+		}
+
+		out, err := exec.CommandContext(ctx, "osascript", "-e", "the clipboard as «class PNGf»").Output()
+		if err != nil {
+			return nil, err
+		}
+
+		return parseAppleScriptData(string(out))
+	}
+
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		if _, err := exec.LookPath("wl-paste"); err == nil {
+			types, err := exec.CommandContext(ctx, "wl-paste", "--list-types").Output()
+			if err != nil || !bytes.Contains(types, []byte(pngMediaType)) {
+				return nil, err
+			}
+
+			return exec.CommandContext(ctx, "wl-paste", "--no-newline", "--type", pngMediaType).Output()
+		}
+	}
+
+	if _, err := exec.LookPath("xclip"); err == nil {
+		targets, err := exec.CommandContext(ctx, "xclip", "-selection", "clipboard", "-t", "TARGETS", "-o").Output()
+		if err != nil || !bytes.Contains(targets, []byte(pngMediaType)) {
+			return nil, err
+		}
+
+		return exec.CommandContext(ctx, "xclip", "-selection", "clipboard", "-t", pngMediaType, "-o").Output()
+	}
+
+	return nil, nil
+}
+
+// parseAppleScriptData decodes osascript output of the form «data PNGf89504E47...».
+func parseAppleScriptData(out string) ([]byte, error) {
+	out = strings.TrimSpace(out)
+	out = strings.TrimPrefix(out, "«data PNGf")
+	out = strings.TrimSuffix(out, "»")
+
+	return hex.DecodeString(out)
+}
+
+// readOSC52 asks the terminal for the clipboard with an OSC 52 query and
+// decodes the base64 reply. Terminals that don't support (or allow) clipboard
+// reads never answer, so the wait is bounded by osc52ReplyTimeout.
+func readOSC52() (string, error) {
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) {
+		return "", errNotATerminal
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = term.Restore(fd, state) }()
+
+	reader, err := cancelreader.NewReader(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	if _, err := fmt.Fprint(os.Stdout, "\033]52;c;?\a"); err != nil {
+		return "", err
+	}
+
+	timer := time.AfterFunc(osc52ReplyTimeout, func() { reader.Cancel() })
+	defer timer.Stop()
+
+	var reply []byte
+	buf := make([]byte, 4096)
+
+	for {
+		n, err := reader.Read(buf)
+		reply = append(reply, buf[:n]...)
+
+		if text, ok := parseOSC52Reply(reply); ok {
+			return text, nil
+		}
+
+		if err != nil {
+			return "", errNoOSC52Reply
+		}
+	}
+}
+
+// parseOSC52Reply extracts the clipboard text from a complete OSC 52 reply
+// ("ESC ] 52 ; c ; <base64> BEL" or terminated by ESC \). ok is false until
+// the reply is complete.
+func parseOSC52Reply(reply []byte) (string, bool) {
+	start := bytes.Index(reply, []byte("\033]52;"))
+	if start < 0 {
+		return "", false
+	}
+
+	body := reply[start+len("\033]52;"):]
+
+	end := bytes.IndexByte(body, '\a')
+	if st := bytes.Index(body, []byte("\033\\")); st >= 0 && (end < 0 || st < end) {
+		end = st
+	}
+
+	if end < 0 {
+		return "", false
+	}
+
+	// Skip the selection parameter (e.g. "c;")
+	_, payload, _ := bytes.Cut(body[:end], []byte(";"))
+
+	decoded, err := base64.StdEncoding.DecodeString(string(payload))
+	if err != nil {
+		return "", false
+	}
+
+	return string(decoded), true
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestParseOSC52Reply(t *testing.T) {
+	t.Parallel()
+
+	payload := base64.StdEncoding.EncodeToString([]byte("hello clipboard"))
+
+	tests := []struct {
+		name   string
+		reply  string
+		want   string
+		wantOK bool
+	}{
+		{"BEL terminated", "\033]52;c;" + payload + "\a", "hello clipboard", true},
+		{"ST terminated", "\033]52;c;" + payload + "\033\\", "hello clipboard", true},
+		{"leading noise", "xx\033]52;c;" + payload + "\a", "hello clipboard", true},
+		{"incomplete", "\033]52;c;" + payload[:4], "", false},
+		{"no reply", "abc", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, ok := parseOSC52Reply([]byte(tt.reply))
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseOSC52Reply = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseAppleScriptData(t *testing.T) {
+	t.Parallel()
+
+	data, err := parseAppleScriptData("«data PNGf89504E47»\n")
+	if err != nil {
+		t.Fatalf("parseAppleScriptData failed: %v", err)
+	}
+
+	if string(data) != "\x89PNG" {
+		t.Errorf("expected PNG magic bytes, got %q", data)
+	}
+}
@@ -0,0 +1,176 @@
+package ui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+var (
+	errUnknownMessage = errors.New("unknown message")
+	errBadMessage     = errors.New("message does not fit its arguments")
+)
+
+// Messages holds user-facing text by key. Keys with arguments are fmt
+// templates; argument indexes such as %[2]s let a translation reorder them.
+type Messages map[string]string
+
+// Message keys.
+const (
+	msgTitle          = "title"
+	msgQuitHint       = "quit_hint"
+	msgAssistant      = "assistant"
+	msgTool           = "tool"
+	msgThinking       = "thinking"
+	msgThinkingPlain  = "thinking_plain"
+	msgConfirm        = "confirm"
+	msgConfirmYes     = "confirm_yes"
+	msgError          = "error"
+	msgStatus         = "status"
+	msgWindowTitle    = "window_title"
+	msgApprove        = "approve"
+	msgToolStarted    = "tool_started"
+	msgToolFinished   = "tool_finished"
+	msgToolFailed     = "tool_failed"
+	msgToolOK         = "tool_ok"
+	msgToolError      = "tool_error"
+	msgInjection      = "injection"
+	msgToolsRunning   = "tools_running"
+	msgLongest        = "longest"
+	msgBannerRoot     = "banner_root"
+	msgBannerBranch   = "banner_branch"
+	msgBannerDirty    = "banner_dirty"
+	msgBannerTools    = "banner_tools"
+	msgBannerAutonomy = "banner_autonomy"
+	msgBannerProfile  = "banner_profile"
+)
+
+// message is a built-in message and sample arguments of the types it is
+// formatted with (nil if it takes none).
+type message struct {
+	text string
+	args []any
+}
+
+var defaultMessages = map[string]message{
+	msgTitle:          {"Artoo Agent", nil},
+	msgQuitHint:       {"Type 'quit' to exit", nil},
+	msgAssistant:      {"Claude", nil},
+	msgTool:           {"Tool", nil},
+	msgThinking:       {"Thinking...", nil},
+	msgThinkingPlain:  {"Claude is thinking…", nil},
+	msgConfirm:        {"%s [Y/n]", []any{"Continue?"}},
+	msgConfirmYes:     {"y,yes", nil}, // answers meaning yes, besides an empty one
+	msgError:          {"Error: %v", []any{errBadMessage}},
+	msgStatus:         {"Status: %s", []any{"summary"}},
+	msgWindowTitle:    {"artoo: %s", []any{"summary"}},
+	msgApprove:        {"Allow %s %s?", []any{"grep", "{}"}},
+	msgToolStarted:    {"Tool %s started: %s", []any{"grep", "{}"}},
+	msgToolFinished:   {"Tool %s finished", []any{"grep"}},
+	msgToolFailed:     {"Tool %s failed", []any{"grep"}},
+	msgToolOK:         {"[OK] %s", []any{"grep"}},
+	msgToolError:      {"[ERROR] %s", []any{"grep"}},
+	msgToolsRunning:   {"%d tools running: %s, …", []any{4, "grep, ls"}},
+	msgLongest:        {"(longest %s)", []any{"1.0s"}},
+	msgBannerRoot:     {"%s", []any{"~/src/app"}},
+	msgBannerBranch:   {"%s on %s", []any{"~/src/app", "main"}},
+	msgBannerDirty:    {"%s on %s (uncommitted changes)", []any{"~/src/app", "main"}},
+	msgBannerTools:    {"%d tools, %d from plugins", []any{24, 2}},
+	msgBannerAutonomy: {"autonomy %s", []any{"full-auto"}},
+	msgBannerProfile:  {"profile %s", []any{"careful"}},
+	msgInjection: {"Warning: %s returned content from %s that looks like a prompt injection: %q. " +
+		"The model was told not to follow it.", []any{"fetch", "https://example.com", "ignore previous instructions"}},
+}
+
+// DefaultMessages returns the built-in messages.
+func DefaultMessages() Messages {
+	m := make(Messages, len(defaultMessages))
+	for key, def := range defaultMessages {
+		m[key] = def.text
+	}
+
+	return m
+}
+
+// ValidateMessages reports whether overrides can be used by SetMessages:
+// every key must be known, and every template must use each of its
+// arguments with a verb that fits.
+func ValidateMessages(overrides Messages) error {
+	var errs []error
+
+	for _, key := range slices.Sorted(maps.Keys(overrides)) {
+		def, ok := defaultMessages[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w: %q", errUnknownMessage, key))
+
+			continue
+		}
+
+		if def.args == nil {
+			continue
+		}
+
+		if strings.Contains(fmt.Sprintf(strings.ReplaceAll(overrides[key], "%%", ""), def.args...), "%!") {
+			errs = append(errs, fmt.Errorf("%w: %s takes %d (%q)", errBadMessage, key, len(def.args), def.text))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ReadMessages reads overrides from a JSON file mapping keys to text.
+func ReadMessages(path string) (Messages, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var overrides Messages
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := ValidateMessages(overrides); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return overrides, nil
+}
+
+// SetMessages replaces the built-in text of the keys in overrides, to
+// rebrand or translate the terminal. Keys left out keep the built-in text.
+// Call it before the terminal is used.
+func (t *Terminal) SetMessages(overrides Messages) error {
+	if err := ValidateMessages(overrides); err != nil {
+		return err
+	}
+
+	t.messages = maps.Clone(overrides)
+	t.status.messages = t.messages
+
+	return nil
+}
+
+// msg returns the message key, formatted with args.
+func (t *Terminal) msg(key string, args ...any) string {
+	return t.messages.format(key, args...)
+}
+
+// format returns the message key, formatted with args, using the built-in
+// text unless m replaces it.
+func (m Messages) format(key string, args ...any) string {
+	text, ok := m[key]
+	if !ok {
+		text = defaultMessages[key].text
+	}
+
+	if len(args) == 0 {
+		return text
+	}
+
+	return fmt.Sprintf(text, args...)
+}
@@ -0,0 +1,97 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidateMessages(t *testing.T) {
+	t.Parallel()
+
+	if err := ValidateMessages(DefaultMessages()); err != nil {
+		t.Errorf("the built-in messages should be valid: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		overrides Messages
+		want      error
+	}{
+		{"translated", Messages{"approve": "Autoriser %s %s ?", "title": "100% Acme"}, nil},
+		{"reordered", Messages{"tool_started": "%[2]s → %[1]s"}, nil},
+		{"escaped percent", Messages{"status": "%s (100%%)"}, nil},
+		{"unknown key", Messages{"farewell": "Bye"}, errUnknownMessage},
+		{"missing argument", Messages{"approve": "Allow %s?"}, errBadMessage},
+		{"extra verb", Messages{"error": "%v: %v"}, errBadMessage},
+		{"wrong verb", Messages{"status": "%d"}, errBadMessage},
+	}
+
+	for _, tt := range tests {
+		err := ValidateMessages(tt.overrides)
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, err)
+		}
+	}
+}
+
+func TestReadMessages(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "messages.json")
+	if err := os.WriteFile(path, []byte(`{"thinking": "Réflexion…"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := ReadMessages(path)
+	if err != nil || m["thinking"] != "Réflexion…" {
+		t.Errorf("expected the override, got %v (err %v)", m, err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"approve": "Allow?"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ReadMessages(path); !errors.Is(err, errBadMessage) {
+		t.Errorf("expected an invalid template to be refused, got %v", err)
+	}
+}
+
+func TestTerminal_SetMessages(t *testing.T) {
+	t.Parallel()
+
+	term := NewTerminal(false)
+	if err := term.SetMessages(Messages{"approve": "Autoriser %s %s ?", "confirm_yes": "o, oui"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := term.msg(msgApprove, "grep", "{}"); got != "Autoriser grep {} ?" {
+		t.Errorf("expected the override, got %q", got)
+	}
+
+	if got := term.msg(msgToolFailed, "grep"); got != "Tool grep failed" {
+		t.Errorf("keys left out should keep the built-in text, got %q", got)
+	}
+
+	if got := term.status.messages.format(msgLongest, "1.0s"); got != "(longest 1.0s)" {
+		t.Errorf("the tool status should share the messages, got %q", got)
+	}
+
+	term.SetAccessible(true)
+	term.in = bufio.NewReader(strings.NewReader("Oui\ny\n"))
+
+	if !term.Confirm("Continuer ?") {
+		t.Error("a translated yes should confirm")
+	}
+
+	if term.Confirm("Continuer ?") {
+		t.Error("an answer outside confirm_yes should decline")
+	}
+
+	if err := term.SetMessages(Messages{"farewell": "Bye"}); !errors.Is(err, errUnknownMessage) {
+		t.Errorf("expected an unknown key to be refused, got %v", err)
+	}
+}
@@ -2,13 +2,17 @@
 package ui
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/aelse/artoo/agent"
+	"github.com/aelse/artoo/tool"
 	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -25,6 +29,7 @@ var (
 	claudeStyle lipgloss.Style
 	debugStyle  lipgloss.Style
 	errorStyle  lipgloss.Style
+	warnStyle   lipgloss.Style
 	promptStyle lipgloss.Style
 )
 
@@ -34,6 +39,7 @@ func init() {
 	claudeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))           // Blue
 	debugStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))             // Grey
 	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9")).Bold(true)  // Red
+	warnStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11")).Bold(true)  // Yellow
 	promptStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))          // Magenta
 }
 
@@ -47,6 +53,10 @@ type spinnerRunner struct {
 
 const spinnerTickInterval = 100 * time.Millisecond
 
+// formingMaxWidth caps the line showing a tool call being formed, so it
+// stays on one line of most terminals.
+const formingMaxWidth = 100
+
 // newSpinner creates a new spinner with the given message.
 func newSpinner(message string) *spinnerRunner {
 	s := spinner.New()
@@ -91,6 +101,7 @@ func (s *spinnerRunner) stop() {
 type inputModel struct {
 	textInput textinput.Model
 	submitted bool
+	canceled  bool // Ctrl-C or Esc was pressed
 	value     string
 }
 
@@ -129,6 +140,7 @@ func (m inputModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			m.value = ""
 			m.submitted = true
+			m.canceled = true
 
 			return m, tea.Quit
 		default:
@@ -153,52 +165,202 @@ func (m inputModel) View() string {
 type Terminal struct {
 	mu        sync.Mutex
 	spinner   *spinnerRunner
+	status    *toolStatus // in-flight tool calls, guarded by mu
+	forming   bool        // a tool call being formed is shown on the last line, guarded by mu
 	streaming bool
+	plain     bool          // accessible output: no color, spinners or cursor control
+	in        *bufio.Reader // line reader used instead of the input widget in plain mode
+	messages  Messages      // text replacing the built-in messages (see SetMessages)
 }
 
 // NewTerminal creates a new Terminal with optional streaming support.
 func NewTerminal(streaming bool) *Terminal {
-	return &Terminal{streaming: streaming}
+	return &Terminal{streaming: streaming, status: newToolStatus()}
 }
 
-// PrintTitle prints the application title.
-func (t *Terminal) PrintTitle() {
-	_, _ = fmt.Fprintln(os.Stdout, titleStyle.Render("Artoo Agent")+" - Type 'quit' to exit")
+// SetAccessible switches to screen-reader friendly output: no color,
+// spinners or cursor-control sequences, and plain announcements of what the
+// agent is doing. Input is read line by line, so it also suits dumb
+// terminals and CI logs.
+func (t *Terminal) SetAccessible(on bool) {
+	t.plain = on
+	if on && t.in == nil {
+		t.in = bufio.NewReader(os.Stdin)
+	}
+}
+
+// render applies style unless output is plain.
+func (t *Terminal) render(style lipgloss.Style, text string) string {
+	if t.plain {
+		return text
+	}
+
+	return style.Render(text)
+}
+
+// Banner is the context a session starts in, shown under the title.
+type Banner struct {
+	Model     string
+	Workspace string // workspace root
+	Branch    string // checked-out git branch ("" outside a repository)
+	Dirty     bool   // the working tree has uncommitted changes
+	Tools     int
+	Plugins   int // tools that come from plugins
+	Autonomy  string
+	Profile   string // active profile ("" for none)
+}
+
+// PrintTitle prints the application title and, under it, the banner.
+func (t *Terminal) PrintTitle(b Banner) {
+	title := t.render(titleStyle, t.msg(msgTitle))
+	if hint := t.msg(msgQuitHint); hint != "" {
+		title += " - " + hint
+	}
+
+	_, _ = fmt.Fprintln(os.Stdout, title)
+	_, _ = fmt.Fprintln(os.Stdout, t.render(debugStyle, t.banner(b)))
+}
+
+// banner renders b on one line.
+func (t *Terminal) banner(b Banner) string {
+	var where string
+
+	switch {
+	case b.Branch == "":
+		where = t.msg(msgBannerRoot, b.Workspace)
+	case b.Dirty:
+		where = t.msg(msgBannerDirty, b.Workspace, b.Branch)
+	default:
+		where = t.msg(msgBannerBranch, b.Workspace, b.Branch)
+	}
+
+	parts := []string{b.Model, where, t.msg(msgBannerTools, b.Tools, b.Plugins), t.msg(msgBannerAutonomy, b.Autonomy)}
+	if b.Profile != "" {
+		parts = append(parts, t.msg(msgBannerProfile, b.Profile))
+	}
+
+	return strings.Join(parts, " · ")
 }
 
 // ReadInput reads a line of input from the user.
 func (t *Terminal) ReadInput() (string, error) {
+	value, _, err := t.readLine()
+
+	return value, err
+}
+
+// Confirm asks a yes/no question, defaulting to yes on an empty answer.
+// Cancelling with Ctrl-C or Esc answers no.
+func (t *Terminal) Confirm(question string) bool {
+	t.mu.Lock()
+	_, _ = fmt.Fprintln(os.Stdout, t.render(promptStyle, t.msg(msgConfirm, question)))
+	t.mu.Unlock()
+
+	value, canceled, err := t.readLine()
+	if err != nil || canceled {
+		return false
+	}
+
+	if value == "" {
+		return true
+	}
+
+	for yes := range strings.SplitSeq(t.msg(msgConfirmYes), ",") {
+		if strings.EqualFold(value, strings.TrimSpace(yes)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// readLine runs the input model, returning the trimmed line and whether it was canceled.
+// In plain mode the line is read from stdin, end of input counting as canceled.
+func (t *Terminal) readLine() (string, bool, error) {
+	if t.plain {
+		_, _ = fmt.Fprint(os.Stdout, "> ")
+
+		line, err := t.in.ReadString('\n')
+		if errors.Is(err, io.EOF) {
+			return strings.TrimSpace(line), line == "", nil
+		}
+
+		return strings.TrimSpace(line), false, err
+	}
+
 	m := newInputModel()
 	p := tea.NewProgram(m)
 
 	finalModel, err := p.Run()
 	if err != nil {
-		return "", err
+		return "", false, err
 	}
 
 	if im, ok := finalModel.(inputModel); ok {
-		return strings.TrimSpace(im.value), nil
+		return strings.TrimSpace(im.value), im.canceled, nil
 	}
 
-	return "", nil
+	return "", false, nil
 }
 
 // PrintAssistant prints assistant text with Claude styling.
 func (t *Terminal) PrintAssistant(text string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	_, _ = fmt.Fprintf(os.Stdout, "%s: %s\n", claudeStyle.Render("Claude"), text)
+	_, _ = fmt.Fprintf(os.Stdout, "%s: %s\n", t.render(claudeStyle, t.msg(msgAssistant)), text)
 }
 
 // PrintError prints an error message in error styling.
 func (t *Terminal) PrintError(err error) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	_, _ = fmt.Fprintf(os.Stdout, "%s\n", errorStyle.Render(fmt.Sprintf("Error: %v", err)))
+	_, _ = fmt.Fprintf(os.Stdout, "%s\n", t.render(errorStyle, t.msg(msgError, err)))
+}
+
+// PrintWarning prints a notice the user must not miss, such as running in a
+// degraded mode, in warning styling.
+func (t *Terminal) PrintWarning(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = fmt.Fprintf(os.Stdout, "%s\n", t.render(warnStyle, text))
+}
+
+// PrintStatus shows the one-line task summary above the prompt and in the
+// terminal window title, so a returning user can see what the agent was doing.
+func (t *Terminal) PrintStatus(summary string) {
+	if summary == "" {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.plain {
+		_, _ = fmt.Fprintln(os.Stdout, t.msg(msgStatus, summary))
+
+		return
+	}
+
+	_, _ = fmt.Fprintf(os.Stdout, "\033]0;%s\007", t.msg(msgWindowTitle, summary))
+	_, _ = fmt.Fprintf(os.Stdout, "%s\n", debugStyle.Render("● "+summary))
+}
+
+// PrintInfo prints an informational message in muted styling.
+func (t *Terminal) PrintInfo(text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, _ = fmt.Fprintf(os.Stdout, "%s\n", t.render(debugStyle, text))
 }
 
 // ShowSpinner displays a spinner with a message and returns a function to stop it.
+// In plain mode the message is printed once instead.
 func (t *Terminal) ShowSpinner(message string) func() {
+	if t.plain {
+		t.PrintInfo(message)
+
+		return func() {}
+	}
+
 	t.mu.Lock()
 	t.spinner = newSpinner(message)
 	t.mu.Unlock()
@@ -220,12 +382,18 @@ func (t *Terminal) ShowSpinner(message string) func() {
 
 // OnThinking is called when the agent starts thinking.
 func (t *Terminal) OnThinking() {
+	if t.plain && !t.streaming {
+		_, _ = fmt.Fprintln(os.Stdout, t.msg(msgThinkingPlain))
+
+		return
+	}
+
 	if t.streaming {
 		// Print prefix; text will stream after OnThinkingDone
-		_, _ = fmt.Fprint(os.Stdout, claudeStyle.Render("Claude")+": ")
+		_, _ = fmt.Fprint(os.Stdout, t.render(claudeStyle, t.msg(msgAssistant))+": ")
 	} else {
 		t.mu.Lock()
-		spinner := newSpinner("Thinking...")
+		spinner := newSpinner(t.msg(msgThinking))
 		t.spinner = spinner
 		t.mu.Unlock()
 		spinner.start()
@@ -234,7 +402,7 @@ func (t *Terminal) OnThinking() {
 
 // OnThinkingDone is called when the API response is received.
 func (t *Terminal) OnThinkingDone() {
-	if !t.streaming {
+	if !t.streaming && !t.plain {
 		t.mu.Lock()
 		spinner := t.spinner
 		t.spinner = nil
@@ -251,10 +419,13 @@ func (t *Terminal) OnText(text string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	if t.streaming {
-		// Text was already printed via deltas; just finish the line
-		_, _ = fmt.Fprintln(os.Stdout)
+		// Text was already printed via deltas; just finish the line, unless
+		// a tool call being formed already did
+		if !t.endForming() {
+			_, _ = fmt.Fprintln(os.Stdout)
+		}
 	} else {
-		_, _ = fmt.Fprintf(os.Stdout, "%s: %s\n", claudeStyle.Render("Claude"), text)
+		_, _ = fmt.Fprintf(os.Stdout, "%s: %s\n", t.render(claudeStyle, t.msg(msgAssistant)), text)
 	}
 }
 
@@ -263,20 +434,183 @@ func (t *Terminal) OnTextDelta(delta string) {
 	_, _ = fmt.Fprint(os.Stdout, delta)
 }
 
-// OnToolCall is called when the assistant calls a tool.
-func (t *Terminal) OnToolCall(name string, input string) {
+// OnToolInputDelta shows the tool call being formed on one line, replaced as
+// more input arrives, until the call starts. It implements
+// agent.ToolInputWatcher.
+func (t *Terminal) OnToolInputDelta(name string, preview string) {
+	if t.plain {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.forming {
+		_, _ = fmt.Fprintln(os.Stdout)
+		t.forming = true
+	}
+
+	line := strings.Join(strings.Fields(name+": "+preview), " ")
+	if len([]rune(line)) > formingMaxWidth {
+		line = string([]rune(line)[:formingMaxWidth-1]) + "…"
+	}
+
+	_, _ = fmt.Fprint(os.Stdout, "\r\033[2K"+debugStyle.Render(line))
+}
+
+// endForming erases the line of a tool call being formed, reporting whether
+// there was one. Called with t.mu held.
+func (t *Terminal) endForming() bool {
+	if !t.forming {
+		return false
+	}
+
+	_, _ = fmt.Fprint(os.Stdout, "\r\033[2K")
+	t.forming = false
+
+	return true
+}
+
+// PrintToolCall prints a tool call without tracking it as running.
+func (t *Terminal) PrintToolCall(name string, input string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.printToolCall(name, input)
+}
+
+func (t *Terminal) printToolCall(name string, input string) {
+	_, _ = fmt.Fprintf(os.Stdout, "%s: %s\n", t.render(claudeStyle, t.msg(msgTool)), name+": "+input)
+}
+
+// OnToolCall is called when the assistant calls a tool. The call is shown
+// with a live status line until the OnToolResult with its id.
+func (t *Terminal) OnToolCall(id string, name string, input string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.plain {
+		_, _ = fmt.Fprintln(os.Stdout, t.msg(msgToolStarted, name, input))
+
+		return
+	}
+
+	now := time.Now()
+
+	t.endForming()
+	t.status.clear()
+	t.printToolCall(name, input)
+	t.status.add(id, name, now)
+	t.status.draw(now)
+
+	if t.status.quit == nil {
+		t.status.quit = make(chan struct{})
+		go t.animateToolStatus(t.status.quit)
+	}
+}
+
+// animateToolStatus redraws the tool status lines until quit is closed.
+func (t *Terminal) animateToolStatus(quit chan struct{}) {
+	ticker := time.NewTicker(spinnerTickInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-quit:
+			return
+		case now := <-ticker.C:
+			t.mu.Lock()
+			select {
+			case <-quit:
+				// Stopped while waiting for the lock
+			default:
+				t.status.model, _ = t.status.model.Update(t.status.model.Tick())
+				t.status.clear()
+				t.status.draw(now)
+			}
+			t.mu.Unlock()
+		}
+	}
+}
+
+// OnTurnStart is called before each request to the model.
+func (t *Terminal) OnTurnStart(string) {}
+
+// OnTurnEnd is called when a turn's tool calls have completed.
+// A tool call still being formed, as when the request failed, is erased.
+func (t *Terminal) OnTurnEnd(string, agent.Usage, string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.endForming()
+}
+
+// Approve asks the user whether a tool call may run, implementing agent.Approver.
+// The tool status lines are hidden while the question is open.
+func (t *Terminal) Approve(name string, input string) bool {
+	if t.plain {
+		return t.Confirm(t.msg(msgApprove, name, input))
+	}
+
+	t.mu.Lock()
+	t.status.clear()
+	t.status.paused = true
+	t.mu.Unlock()
+
+	approved := t.Confirm(t.msg(msgApprove, name, input))
+
+	t.mu.Lock()
+	t.status.paused = false
+	t.status.draw(time.Now())
+	t.mu.Unlock()
+
+	return approved
+}
+
+// OnSuspectedInjection warns that a tool returned untrusted content that
+// looks like a prompt injection, implementing agent.InjectionWarner.
+func (t *Terminal) OnSuspectedInjection(name string, found []tool.Injection) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	_, _ = fmt.Fprintf(os.Stdout, "%s: %s\n", claudeStyle.Render("Tool"), name+": "+input)
+
+	if !t.plain {
+		t.status.clear()
+		defer t.status.draw(time.Now())
+	}
+
+	for _, f := range found {
+		text := t.msg(msgInjection, name, f.Source, f.Text)
+		_, _ = fmt.Fprintf(os.Stdout, "%s\n", t.render(warnStyle, text))
+	}
 }
 
 // OnToolResult is called after a tool completes.
-func (t *Terminal) OnToolResult(name string, _ string, isError bool) {
+func (t *Terminal) OnToolResult(id string, name string, _ string, isError bool) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	status := "OK"
+
+	if t.plain {
+		outcome := msgToolFinished
+		if isError {
+			outcome = msgToolFailed
+		}
+		_, _ = fmt.Fprintln(os.Stdout, t.msg(outcome, name))
+
+		return
+	}
+
+	t.status.clear()
+	t.status.remove(id)
+
+	status := msgToolOK
 	if isError {
-		status = "ERROR"
+		status = msgToolError
+	}
+	_, _ = fmt.Fprintf(os.Stdout, "%s\n", debugStyle.Render(t.msg(status, name)))
+
+	if len(t.status.tools) > 0 {
+		t.status.draw(time.Now())
+	} else if t.status.quit != nil {
+		close(t.status.quit)
+		t.status.quit = nil
 	}
-	_, _ = fmt.Fprintf(os.Stdout, "%s\n", debugStyle.Render(fmt.Sprintf("[%s] %s", status, name)))
 }
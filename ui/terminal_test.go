@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"bufio"
+	"strings"
 	"sync"
 	"testing"
 )
@@ -19,12 +21,69 @@ func TestTerminal_ConcurrentOnToolResult(t *testing.T) {
 		wg.Go(func() {
 			// Call output methods that will be called concurrently during tool execution
 			term.OnText("test text")
-			term.OnToolCall("testTool", `{"param": "value"}`)
-			term.OnToolResult("testTool", "output", false)
-			term.OnToolResult("testTool", "error", true)
+			term.OnToolCall("id", "testTool", `{"param": "value"}`)
+			term.OnToolResult("id", "testTool", "output", false)
+			term.OnToolResult("id", "testTool", "error", true)
 		})
 	}
 
 	wg.Wait()
 	// If we reach here without a panic or race condition, the test passes
 }
+
+func TestTerminal_AccessibleInput(t *testing.T) {
+	t.Parallel()
+
+	term := NewTerminal(false)
+	term.SetAccessible(true)
+	term.in = bufio.NewReader(strings.NewReader("  hello  \nn\nlast"))
+
+	if got, err := term.ReadInput(); err != nil || got != "hello" {
+		t.Errorf("ReadInput = %q, %v", got, err)
+	}
+
+	if term.Confirm("Proceed?") {
+		t.Error("expected answer n to decline")
+	}
+
+	if got, err := term.ReadInput(); err != nil || got != "last" {
+		t.Errorf("expected unterminated last line, got %q, %v", got, err)
+	}
+
+	if got, err := term.ReadInput(); err != nil || got != "" {
+		t.Errorf("expected empty input at end of input, got %q, %v", got, err)
+	}
+
+	if term.Confirm("Proceed?") {
+		t.Error("expected end of input to decline")
+	}
+}
+
+func TestTerminal_AccessibleRender(t *testing.T) {
+	t.Parallel()
+
+	term := NewTerminal(false)
+	term.SetAccessible(true)
+
+	if got := term.render(errorStyle, "Error: x"); got != "Error: x" {
+		t.Errorf("expected unstyled text, got %q", got)
+	}
+}
+
+func TestTerminal_Banner(t *testing.T) {
+	t.Parallel()
+
+	term := NewTerminal(false)
+	b := Banner{Model: "claude-sonnet-4", Workspace: "~/src/app", Tools: 24, Plugins: 2, Autonomy: "auto-edit"}
+
+	if got, want := term.banner(b), "claude-sonnet-4 · ~/src/app · 24 tools, 2 from plugins · autonomy auto-edit"; got != want {
+		t.Errorf("banner = %q, want %q", got, want)
+	}
+
+	b.Branch, b.Dirty, b.Profile = "main", true, "careful"
+
+	if got := term.banner(b); !strings.Contains(got, "~/src/app on main (uncommitted changes)") ||
+		!strings.HasSuffix(got, " · profile careful") {
+		t.Errorf("banner should show the dirty branch and profile, got %q", got)
+	}
+}
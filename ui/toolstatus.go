@@ -0,0 +1,110 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+)
+
+// maxToolStatusLines is the number of running tools shown one per line;
+// beyond it the status collapses to a single summary line.
+const maxToolStatusLines = 3
+
+// runningTool is a tool call that has been requested but not yet finished.
+type runningTool struct {
+	id      string // tool_use ID
+	name    string
+	started time.Time
+}
+
+// toolStatus tracks in-flight tool calls and draws a live status line for
+// each below the regular output. It is guarded by the Terminal's mutex.
+type toolStatus struct {
+	model  spinner.Model
+	tools  []runningTool
+	lines  int           // status lines currently on screen
+	paused bool          // status hidden while the user answers a prompt
+	quit   chan struct{} // closed to stop the redraw loop (nil when not running)
+
+	messages Messages // the terminal's messages
+}
+
+// newToolStatus creates an empty tool status display.
+func newToolStatus() *toolStatus {
+	s := spinner.New()
+	s.Spinner = spinner.Points
+	s.Style = promptStyle
+
+	return &toolStatus{model: s}
+}
+
+// add records that the call with the given tool_use ID started.
+func (s *toolStatus) add(id string, name string, now time.Time) {
+	s.tools = append(s.tools, runningTool{id: id, name: name, started: now})
+}
+
+// remove records that the call with the given tool_use ID finished.
+func (s *toolStatus) remove(id string) {
+	for i, t := range s.tools {
+		if t.id == id {
+			s.tools = append(s.tools[:i], s.tools[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// clear erases the status lines so regular output can be printed.
+func (s *toolStatus) clear() {
+	for range s.lines {
+		_, _ = fmt.Fprint(os.Stdout, "\033[1A\033[2K")
+	}
+
+	s.lines = 0
+}
+
+// draw prints the current status lines.
+func (s *toolStatus) draw(now time.Time) {
+	if s.paused {
+		return
+	}
+
+	lines := s.render(now)
+	for _, line := range lines {
+		_, _ = fmt.Fprintln(os.Stdout, line)
+	}
+
+	s.lines = len(lines)
+}
+
+// render returns one line per running tool with its elapsed time, or a
+// single summary line when more than maxToolStatusLines are running.
+func (s *toolStatus) render(now time.Time) []string {
+	frame := s.model.View()
+
+	if len(s.tools) <= maxToolStatusLines {
+		lines := make([]string, len(s.tools))
+		for i, t := range s.tools {
+			lines[i] = fmt.Sprintf("%s %s %s", frame, t.name, debugStyle.Render(elapsed(now, t.started)))
+		}
+
+		return lines
+	}
+
+	names := make([]string, 0, maxToolStatusLines)
+	for _, t := range s.tools[:maxToolStatusLines] {
+		names = append(names, t.name)
+	}
+
+	// Tools are added in start order, so the first has run longest
+	return []string{fmt.Sprintf("%s %s %s", frame, s.messages.format(msgToolsRunning, len(s.tools), strings.Join(names, ", ")),
+		debugStyle.Render(s.messages.format(msgLongest, elapsed(now, s.tools[0].started))))}
+}
+
+// elapsed formats the time since start to a tenth of a second.
+func elapsed(now, start time.Time) string {
+	return fmt.Sprintf("%.1fs", now.Sub(start).Seconds())
+}
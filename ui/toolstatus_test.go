@@ -0,0 +1,56 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToolStatus_Render(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := newToolStatus()
+	s.add("1", "grep", start)
+	s.add("2", "list_files", start.Add(time.Second))
+
+	lines := s.render(start.Add(2500 * time.Millisecond))
+	if len(lines) != 2 {
+		t.Fatalf("expected one line per tool, got %q", lines)
+	}
+
+	if !strings.Contains(lines[0], "grep") || !strings.Contains(lines[0], "2.5s") {
+		t.Errorf("unexpected first line %q", lines[0])
+	}
+
+	s.add("3", "grep", start)
+	s.add("4", "random_number", start)
+
+	lines = s.render(start.Add(3 * time.Second))
+	if len(lines) != 1 || !strings.Contains(lines[0], "4 tools running: grep, list_files, grep") ||
+		!strings.Contains(lines[0], "longest 3.0s") {
+		t.Errorf("expected a summary line, got %q", lines)
+	}
+}
+
+func TestToolStatus_RemoveByID(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	s := newToolStatus()
+	s.add("1", "grep", start)
+	s.add("2", "list_files", start)
+	s.add("3", "grep", start.Add(time.Second))
+
+	s.remove("3")
+
+	if len(s.tools) != 2 || s.tools[0].id != "1" || s.tools[1].name != "list_files" {
+		t.Errorf("expected the grep call with ID 3 to be removed, got %+v", s.tools)
+	}
+
+	s.remove("missing")
+
+	if len(s.tools) != 2 {
+		t.Errorf("removing an unknown call should be a no-op, got %+v", s.tools)
+	}
+}
@@ -0,0 +1,373 @@
+// Package workspace detects monorepo sub-projects and tracks which one is active.
+package workspace
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Kind identifies the workspace manifest a sub-project was discovered from.
+type Kind string
+
+const (
+	KindGoWork Kind = "go.work"
+	KindNPM    Kind = "package.json"
+	KindCargo  Kind = "Cargo.toml"
+)
+
+var (
+	errProjectNotFound  = errors.New("project not found")
+	errAmbiguousProject = errors.New("project name is ambiguous")
+)
+
+// quotedString matches a double-quoted TOML string.
+var quotedString = regexp.MustCompile(`"([^"]*)"`)
+
+// Project is a sub-project of a monorepo workspace.
+type Project struct {
+	Name string // workspace-relative path using forward slashes, e.g. "services/api"
+	Path string // absolute path to the sub-project directory
+	Kind Kind
+}
+
+// Workspace holds the detected sub-projects of a root directory and the
+// currently focused one. It is safe for concurrent use.
+type Workspace struct {
+	mu       sync.RWMutex
+	root     string
+	projects []Project
+	active   *Project
+}
+
+// New detects sub-projects under root and returns a Workspace with no
+// active project (searches are scoped to root).
+func New(root string) (*Workspace, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("getting absolute path: %w", err)
+	}
+
+	projects, err := Detect(absRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Workspace{
+		root:     absRoot,
+		projects: projects,
+	}, nil
+}
+
+// Root returns the absolute workspace root.
+func (w *Workspace) Root() string {
+	return w.root
+}
+
+// Projects returns the detected sub-projects sorted by name.
+func (w *Workspace) Projects() []Project {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return slices.Clone(w.projects)
+}
+
+// Active returns the focused sub-project, or nil when the whole workspace is in scope.
+func (w *Workspace) Active() *Project {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.active == nil {
+		return nil
+	}
+
+	p := *w.active
+
+	return &p
+}
+
+// SetActive focuses the named sub-project. A project is named by its full
+// name or a trailing part of it, such as its directory name; a part that ends
+// more than one name is an error, and a longer one must be given. An empty
+// name, "." or "/" clears the focus so the whole workspace is in scope again.
+func (w *Workspace) SetActive(name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	name = strings.TrimSuffix(filepath.ToSlash(name), "/")
+	if name == "" || name == "." {
+		w.active = nil
+
+		return nil
+	}
+
+	var matches []Project
+
+	for _, p := range w.projects {
+		if p.Name == name {
+			w.active = &p
+
+			return nil
+		}
+
+		if strings.HasSuffix(p.Name, "/"+name) {
+			matches = append(matches, p)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return fmt.Errorf("%w: %s", errProjectNotFound, name)
+	case 1:
+		w.active = &matches[0]
+
+		return nil
+	}
+
+	names := make([]string, len(matches))
+	for i, p := range matches {
+		names[i] = p.Name
+	}
+
+	return fmt.Errorf("%w: %s matches %s; give more of the path", errAmbiguousProject, name, strings.Join(names, ", "))
+}
+
+// SearchRoot returns the directory that search and listing tools should
+// default to: the active sub-project if one is focused, otherwise the root.
+func (w *Workspace) SearchRoot() string {
+	if p := w.Active(); p != nil {
+		return p.Path
+	}
+
+	return w.root
+}
+
+// Detect finds sub-projects declared by go.work, package.json workspaces
+// and Cargo workspaces in root. Missing manifests are not an error.
+func Detect(root string) ([]Project, error) {
+	var projects []Project
+
+	detectors := []struct {
+		kind   Kind
+		detect func(root string) ([]string, error)
+	}{
+		{KindGoWork, goWorkMembers},
+		{KindNPM, npmWorkspaceMembers},
+		{KindCargo, cargoWorkspaceMembers},
+	}
+
+	seen := make(map[string]bool)
+
+	for _, d := range detectors {
+		dirs, err := d.detect(root)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", d.kind, err)
+		}
+
+		for _, dir := range dirs {
+			abs := filepath.Clean(dir)
+			if !filepath.IsAbs(abs) {
+				abs = filepath.Join(root, dir)
+			}
+
+			if seen[abs] || abs == root {
+				continue
+			}
+
+			info, err := os.Stat(abs)
+			if err != nil || !info.IsDir() {
+				continue
+			}
+
+			rel, err := filepath.Rel(root, abs)
+			if err != nil {
+				continue
+			}
+
+			seen[abs] = true
+			projects = append(projects, Project{
+				Name: filepath.ToSlash(rel),
+				Path: abs,
+				Kind: d.kind,
+			})
+		}
+	}
+
+	slices.SortFunc(projects, func(a, b Project) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return projects, nil
+}
+
+// goWorkMembers parses the use directives of root/go.work.
+func goWorkMembers(root string) ([]string, error) {
+	data, err := readManifest(filepath.Join(root, "go.work"))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var dirs []string
+	inBlock := false
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "//"); i >= 0 {
+			line = line[:i]
+		}
+
+		line = strings.TrimSpace(line)
+
+		switch {
+		case line == "":
+			continue
+		case inBlock && line == ")":
+			inBlock = false
+		case inBlock:
+			dirs = append(dirs, unquote(line))
+		case line == "use (":
+			inBlock = true
+		case strings.HasPrefix(line, "use "):
+			dirs = append(dirs, unquote(strings.TrimSpace(strings.TrimPrefix(line, "use "))))
+		}
+	}
+
+	return dirs, scanner.Err()
+}
+
+// npmWorkspaceMembers expands the workspaces globs of root/package.json.
+// Both the array form and the {"packages": [...]} form are supported.
+func npmWorkspaceMembers(root string) ([]string, error) {
+	data, err := readManifest(filepath.Join(root, "package.json"))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	if len(manifest.Workspaces) == 0 {
+		return nil, nil
+	}
+
+	var patterns []string
+	if err := json.Unmarshal(manifest.Workspaces, &patterns); err != nil {
+		var nested struct {
+			Packages []string `json:"packages"`
+		}
+
+		if err := json.Unmarshal(manifest.Workspaces, &nested); err != nil {
+			return nil, err
+		}
+
+		patterns = nested.Packages
+	}
+
+	return expandMembers(root, patterns, "package.json"), nil
+}
+
+// cargoWorkspaceMembers expands the members of the [workspace] table in root/Cargo.toml.
+func cargoWorkspaceMembers(root string) ([]string, error) {
+	data, err := readManifest(filepath.Join(root, "Cargo.toml"))
+	if err != nil || data == nil {
+		return nil, err
+	}
+
+	var patterns []string
+	inWorkspace := false
+	inMembers := false
+
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = strings.TrimSpace(line[:i])
+		}
+
+		if strings.HasPrefix(line, "[") && !inMembers {
+			inWorkspace = line == "[workspace]"
+
+			continue
+		}
+
+		if !inWorkspace {
+			continue
+		}
+
+		if !inMembers {
+			key, value, ok := strings.Cut(line, "=")
+			if !ok || strings.TrimSpace(key) != "members" {
+				continue
+			}
+
+			line = value
+			inMembers = true
+		}
+
+		for _, m := range quotedString.FindAllStringSubmatch(line, -1) {
+			patterns = append(patterns, m[1])
+		}
+
+		if strings.Contains(line, "]") {
+			inMembers = false
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return expandMembers(root, patterns, "Cargo.toml"), nil
+}
+
+// expandMembers resolves glob patterns relative to root, keeping only
+// directories that contain the given manifest file.
+func expandMembers(root string, patterns []string, manifest string) []string {
+	var dirs []string
+
+	for _, pattern := range patterns {
+		if strings.HasPrefix(pattern, "!") {
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			continue
+		}
+
+		for _, m := range matches {
+			if _, err := os.Stat(filepath.Join(m, manifest)); err == nil {
+				dirs = append(dirs, m)
+			}
+		}
+	}
+
+	return dirs
+}
+
+// readManifest reads a manifest file, returning nil data if it doesn't exist.
+func readManifest(path string) ([]byte, error) {
+	data, err := os.ReadFile(path) //nolint:gosec // path is a fixed manifest name under the workspace root
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return data, err
+}
+
+func unquote(s string) string {
+	return strings.Trim(s, "\"`")
+}
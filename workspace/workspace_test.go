@@ -0,0 +1,211 @@
+package workspace
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files (and parent directories) under root.
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func projectNames(projects []Project) []string {
+	names := make([]string, len(projects))
+	for i, p := range projects {
+		names[i] = p.Name
+	}
+
+	return names
+}
+
+func TestDetect_GoWork(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.work":             "go 1.26\n\nuse (\n\t./services/api // main API\n\t./libs/common\n)\n\nuse ./tools\n",
+		"services/api/go.mod": "module api\n",
+		"libs/common/go.mod":  "module common\n",
+		"tools/go.mod":        "module tools\n",
+	})
+
+	projects, err := Detect(root)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	want := []string{"libs/common", "services/api", "tools"}
+	got := projectNames(projects)
+
+	if len(got) != len(want) {
+		t.Fatalf("expected projects %v, got %v", want, got)
+	}
+
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("expected project %d to be %q, got %q", i, want[i], got[i])
+		}
+
+		if projects[i].Kind != KindGoWork {
+			t.Errorf("expected kind %q, got %q", KindGoWork, projects[i].Kind)
+		}
+	}
+}
+
+func TestDetect_NPMWorkspaces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		manifest string
+	}{
+		{"array form", `{"workspaces": ["packages/*"]}`},
+		{"object form", `{"workspaces": {"packages": ["packages/*"]}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			root := t.TempDir()
+			writeFiles(t, root, map[string]string{
+				"package.json":            tt.manifest,
+				"packages/a/package.json": `{"name": "a"}`,
+				"packages/b/package.json": `{"name": "b"}`,
+				"packages/notes/README":   "not a package",
+			})
+
+			projects, err := Detect(root)
+			if err != nil {
+				t.Fatalf("Detect failed: %v", err)
+			}
+
+			got := projectNames(projects)
+			if len(got) != 2 || got[0] != "packages/a" || got[1] != "packages/b" {
+				t.Errorf("expected [packages/a packages/b], got %v", got)
+			}
+		})
+	}
+}
+
+func TestDetect_CargoWorkspace(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"Cargo.toml":             "[workspace]\nmembers = [\n  \"crates/*\", # all crates\n  \"cli\",\n]\n\n[profile.release]\nlto = true\n",
+		"crates/core/Cargo.toml": "[package]\n",
+		"cli/Cargo.toml":         "[package]\n",
+	})
+
+	projects, err := Detect(root)
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	got := projectNames(projects)
+	if len(got) != 2 || got[0] != "cli" || got[1] != "crates/core" {
+		t.Errorf("expected [cli crates/core], got %v", got)
+	}
+}
+
+func TestDetect_NoManifests(t *testing.T) {
+	t.Parallel()
+
+	projects, err := Detect(t.TempDir())
+	if err != nil {
+		t.Fatalf("Detect failed: %v", err)
+	}
+
+	if len(projects) != 0 {
+		t.Errorf("expected no projects, got %v", projects)
+	}
+}
+
+func TestWorkspace_SetActive(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.work":             "use ./services/api\n",
+		"services/api/go.mod": "module api\n",
+	})
+
+	ws, err := New(root)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if ws.SearchRoot() != ws.Root() {
+		t.Errorf("expected search root %q with no focus, got %q", ws.Root(), ws.SearchRoot())
+	}
+
+	// Projects can be selected by full name or by directory name
+	for _, name := range []string{"services/api", "api"} {
+		if err := ws.SetActive(name); err != nil {
+			t.Fatalf("SetActive(%q) failed: %v", name, err)
+		}
+
+		if want := filepath.Join(ws.Root(), "services", "api"); ws.SearchRoot() != want {
+			t.Errorf("expected search root %q, got %q", want, ws.SearchRoot())
+		}
+	}
+
+	if err := ws.SetActive("missing"); err == nil {
+		t.Error("expected error for unknown project")
+	}
+
+	if err := ws.SetActive("."); err != nil {
+		t.Fatalf("SetActive(\".\") failed: %v", err)
+	}
+
+	if ws.Active() != nil {
+		t.Error("expected no active project after clearing focus")
+	}
+}
+
+func TestWorkspace_SetActive_Ambiguous(t *testing.T) {
+	t.Parallel()
+
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"go.work":             "use (\n\t./services/api\n\t./tools/api\n)\n",
+		"services/api/go.mod": "module api\n",
+		"tools/api/go.mod":    "module toolsapi\n",
+	})
+
+	ws, err := New(root)
+	if err != nil {
+		t.Fatalf("New failed: %v", err)
+	}
+
+	if err := ws.SetActive("api"); !errors.Is(err, errAmbiguousProject) {
+		t.Fatalf("expected errAmbiguousProject, got %v", err)
+	}
+
+	if ws.Active() != nil {
+		t.Error("an ambiguous name should not focus a project")
+	}
+
+	if err := ws.SetActive("tools/api"); err != nil {
+		t.Fatalf("SetActive with a longer path failed: %v", err)
+	}
+
+	if want := filepath.Join(ws.Root(), "tools", "api"); ws.SearchRoot() != want {
+		t.Errorf("expected search root %q, got %q", want, ws.SearchRoot())
+	}
+}